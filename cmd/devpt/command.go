@@ -0,0 +1,295 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/devports/devpt/pkg/cli"
+	"github.com/devports/devpt/pkg/models"
+)
+
+// command is a devpt subcommand: its usage, its flags and what it runs.
+type command struct {
+	name    string
+	aliases []string
+	usage   []string // argument synopses, e.g. "<name> [--lines N]"; one per form
+	summary string
+	group   string // heading the command is listed under by devpt help
+	// setup registers the command's flags and returns the function that runs
+	// it once they are parsed.
+	setup func(fs *flag.FlagSet) func(inv *invocation) error
+	// minArgs and maxArgs bound the positional arguments; maxArgs < 0 means
+	// any number.
+	minArgs, maxArgs int
+	// passthrough hands everything after "--" to the command verbatim.
+	passthrough bool
+	// json marks commands that honor the global --json flag.
+	json bool
+	// standalone commands never go through a running devpt daemon.
+	standalone bool
+	// serves marks commands that run until interrupted; their operations
+	// are traced on their own rather than under one span for the command.
+	serves bool
+	// service marks commands whose first argument may name a managed
+	// service; a short name finds the current project's service first.
+	service bool
+	// subcommands are selected by the first argument, as in `devpt job add`.
+	subcommands []*command
+	parent      *command
+}
+
+// invocation is what a command runs with.
+type invocation struct {
+	app     *cli.App
+	args    []string // positional arguments
+	argv    []string // arguments after "--", for passthrough commands
+	globals *globalOptions
+}
+
+// path is the command as typed, e.g. "job add".
+func (c *command) path() string {
+	if c.parent != nil {
+		return c.parent.path() + " " + c.name
+	}
+	return c.name
+}
+
+func (c *command) matches(name string) bool {
+	if c.name == name {
+		return true
+	}
+	for _, a := range c.aliases {
+		if a == name {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup finds the command named name among cmds.
+func lookup(cmds []*command, name string) *command {
+	for _, c := range cmds {
+		if c.matches(name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// globalOptions are the flags every command accepts, before or after its
+// name.
+type globalOptions struct {
+	json           bool
+	quiet          bool
+	nonInteractive bool
+	yes            bool
+	theme          string
+	noColor        bool
+	noEmoji        bool
+	project        string
+}
+
+func (g *globalOptions) register(fs *flag.FlagSet) {
+	fs.BoolVar(&g.json, "json", g.json, "Print JSON instead of text (ls, status, port, logs, events, history, stats, watch)")
+	fs.BoolVar(&g.quiet, "quiet", g.quiet, "Print nothing but errors and warnings")
+	fs.BoolVar(&g.quiet, "q", g.quiet, "Short for --quiet")
+	fs.BoolVar(&g.nonInteractive, "non-interactive", g.nonInteractive, "Never prompt (answer no), no TUI, plain output; also "+cli.NonInteractiveEnv+"=1")
+	fs.BoolVar(&g.yes, "yes", g.yes, "Like --non-interactive, but answer prompts with yes")
+	fs.BoolVar(&g.yes, "y", g.yes, "Short for --yes")
+	fs.StringVar(&g.theme, "theme", g.theme, "TUI color `theme`: "+strings.Join(models.TUIThemes, ", ")+" (overrides tui.theme)")
+	fs.BoolVar(&g.noColor, "no-color", g.noColor, "Draw the TUI without colors; also NO_COLOR=1")
+	fs.BoolVar(&g.noEmoji, "no-emoji", g.noEmoji, "Show health as OK, SLOW, TIMEOUT, DOWN and ? instead of emoji (like health.icons \"text\")")
+	fs.StringVar(&g.project, "project", g.project, "`Project` new services join and short service names are looked up in (default: detected from the current directory)")
+}
+
+// isGlobalFlag reports whether name is one of the global flags.
+func isGlobalFlag(name string) bool {
+	switch name {
+	case "json", "quiet", "q", "non-interactive", "yes", "y", "theme", "no-color", "no-emoji", "project":
+		return true
+	}
+	return false
+}
+
+// usageError is a mistake in the command line, reported with exit code 2.
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string { return e.msg }
+
+func usageErrorf(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// errHelpShown is returned when help was asked for and printed.
+var errHelpShown = errors.New("help shown")
+
+// prepare parses args for c, descending into subcommands, and returns the
+// leaf command, its invocation (without the app, which is created once
+// the command line is known to be valid) and its run function.
+func (c *command) prepare(args []string, globals *globalOptions) (*command, *invocation, func(*invocation) error, error) {
+	if len(c.subcommands) > 0 {
+		if len(args) == 0 {
+			printCommandHelp(os.Stderr, c, nil)
+			return nil, nil, nil, usageErrorf("%s subcommand required", c.path())
+		}
+		if args[0] == "-h" || args[0] == "--help" {
+			printCommandHelp(os.Stdout, c, nil)
+			return nil, nil, nil, errHelpShown
+		}
+		sub := lookup(c.subcommands, args[0])
+		if sub == nil {
+			printCommandHelp(os.Stderr, c, nil)
+			return nil, nil, nil, usageErrorf("unknown %s subcommand: %s", c.path(), args[0])
+		}
+		return sub.prepare(args[1:], globals)
+	}
+
+	fs := flag.NewFlagSet("devpt "+c.path(), flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	run := c.setup(fs)
+	globals.register(fs)
+
+	inv := &invocation{globals: globals}
+	if c.passthrough {
+		for i, arg := range args {
+			if arg == "--" {
+				args, inv.argv = args[:i], args[i+1:]
+				break
+			}
+		}
+	}
+	positional, err := parseInterspersed(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		printCommandHelp(os.Stdout, c, fs)
+		return nil, nil, nil, errHelpShown
+	}
+	if err != nil {
+		printUsageLines(os.Stderr, c)
+		return nil, nil, nil, &usageError{msg: err.Error()}
+	}
+	if len(positional) < c.minArgs || (c.maxArgs >= 0 && len(positional) > c.maxArgs) {
+		printUsageLines(os.Stderr, c)
+		return nil, nil, nil, usageErrorf("wrong number of arguments for devpt %s", c.path())
+	}
+	if globals.json && !c.json {
+		return nil, nil, nil, usageErrorf("devpt %s does not support --json", c.path())
+	}
+	inv.args = positional
+	return c, inv, run, nil
+}
+
+// parseInterspersed parses flags that may appear before, between, or after
+// positional arguments and returns the positionals in order.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// printUsageLines prints the synopses of c.
+func printUsageLines(w io.Writer, c *command) {
+	for i, u := range c.usage {
+		prefix := "Usage:"
+		if i > 0 {
+			prefix = "      "
+		}
+		fmt.Fprintf(w, "%s devpt %s %s\n", prefix, c.path(), u)
+	}
+	if len(c.usage) == 0 {
+		fmt.Fprintf(w, "Usage: devpt %s\n", c.path())
+	}
+}
+
+// printCommandHelp prints the usage, summary and flags of c; fs holds its
+// flags and is nil for commands with subcommands.
+func printCommandHelp(w io.Writer, c *command, fs *flag.FlagSet) {
+	printUsageLines(w, c)
+	if c.summary != "" {
+		fmt.Fprintf(w, "\n%s\n", c.summary)
+	}
+	if len(c.subcommands) > 0 {
+		fmt.Fprintln(w, "\nCommands:")
+		printCommandList(w, c.subcommands)
+		fmt.Fprintf(w, "\nRun 'devpt help %s <command>' for a command's options.\n", c.path())
+		return
+	}
+	if fs != nil && hasOwnFlags(fs) {
+		fmt.Fprintln(w, "\nOptions:")
+		printFlags(w, fs, func(name string) bool { return !isGlobalFlag(name) })
+	}
+	fmt.Fprintln(w, "\nRun 'devpt help' for the global options.")
+}
+
+// printCommandList prints one line per command: its synopsis and summary.
+func printCommandList(w io.Writer, cmds []*command) {
+	for _, c := range cmds {
+		synopsis := c.path()
+		if len(c.usage) > 0 && c.usage[0] != "" {
+			synopsis += " " + c.usage[0]
+		}
+		if len(synopsis) > 34 {
+			fmt.Fprintf(w, "  %s\n  %-34s %s\n", synopsis, "", c.summary)
+			continue
+		}
+		fmt.Fprintf(w, "  %-34s %s\n", synopsis, c.summary)
+	}
+}
+
+func hasOwnFlags(fs *flag.FlagSet) bool {
+	own := false
+	fs.VisitAll(func(f *flag.Flag) {
+		if !isGlobalFlag(f.Name) {
+			own = true
+		}
+	})
+	return own
+}
+
+// printFlags prints the flags of fs that keep accepts, like
+// flag.PrintDefaults but with double dashes and aligned descriptions.
+func printFlags(w io.Writer, fs *flag.FlagSet, keep func(name string) bool) {
+	fs.VisitAll(func(f *flag.Flag) {
+		if !keep(f.Name) {
+			return
+		}
+		arg, usage := flag.UnquoteUsage(f)
+		name := "--" + f.Name
+		if len(f.Name) == 1 {
+			name = "-" + f.Name
+		}
+		switch arg {
+		case "":
+		case "duration":
+			name += " DUR"
+		case "int", "uint", "float":
+			name += " N"
+		case "string", "value":
+			name += " VALUE"
+		default:
+			name += " " + strings.ToUpper(arg)
+		}
+		if def := f.DefValue; def != "" && def != "0" && def != "0s" && def != "false" && def != "[]" {
+			usage += fmt.Sprintf(" (default: %s)", def)
+		}
+		if len(name) > 24 {
+			fmt.Fprintf(w, "  %s\n  %-24s %s\n", name, "", usage)
+			return
+		}
+		fmt.Fprintf(w, "  %-24s %s\n", name, usage)
+	})
+}
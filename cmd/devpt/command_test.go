@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args      []string
+		wantPath  string
+		wantArgs  []string
+		wantArgv  []string
+		wantJSON  bool
+		wantUsage bool
+	}{
+		{args: []string{"logs", "api", "--lines", "5"}, wantPath: "logs", wantArgs: []string{"api"}},
+		{args: []string{"logs", "--lines=5", "api"}, wantPath: "logs", wantArgs: []string{"api"}},
+		{args: []string{"logs"}, wantUsage: true},
+		{args: []string{"logs", "api", "--lines", "x"}, wantUsage: true},
+		{args: []string{"ls", "--json"}, wantPath: "ls", wantJSON: true},
+		{args: []string{"status", "api", "--json"}, wantPath: "status", wantArgs: []string{"api"}, wantJSON: true},
+		{args: []string{"kill-port", "3000", "--json"}, wantUsage: true},
+		{args: []string{"events", "--json"}, wantPath: "events", wantJSON: true},
+		{args: []string{"job", "rm", "migrate"}, wantPath: "job rm", wantArgs: []string{"migrate"}},
+		{args: []string{"job", "remove", "migrate"}, wantPath: "job rm", wantArgs: []string{"migrate"}},
+		{args: []string{"job", "nope"}, wantUsage: true},
+		{args: []string{"run", "web", "--port", "3000", "--", "python3", "-m", "http.server"}, wantPath: "run", wantArgs: []string{"web"}, wantArgv: []string{"python3", "-m", "http.server"}},
+	}
+	for _, tt := range tests {
+		var globals globalOptions
+		cmd, inv, _, err := lookup(commands, tt.args[0]).prepare(tt.args[1:], &globals)
+		if tt.wantUsage {
+			var usageErr *usageError
+			if !errors.As(err, &usageErr) {
+				t.Errorf("%v: expected a usage error, got %v", tt.args, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: %v", tt.args, err)
+			continue
+		}
+		if cmd.path() != tt.wantPath || !reflect.DeepEqual(inv.args, tt.wantArgs) || !reflect.DeepEqual(inv.argv, tt.wantArgv) || globals.json != tt.wantJSON {
+			t.Errorf("%v: got %q args %q argv %q json %v", tt.args, cmd.path(), inv.args, inv.argv, globals.json)
+		}
+	}
+}
+
+func TestHelp(t *testing.T) {
+	t.Parallel()
+
+	var globals globalOptions
+	if _, _, _, err := lookup(commands, "stop").prepare([]string{"--help"}, &globals); !errors.Is(err, errHelpShown) {
+		t.Fatalf("expected help to be shown, got %v", err)
+	}
+	for _, c := range commands {
+		if c.group == "" {
+			t.Errorf("command %s is not listed under any help group", c.name)
+		}
+	}
+}
+
+func TestInvalidListFormatIsUsageError(t *testing.T) {
+	t.Parallel()
+
+	var globals globalOptions
+	_, inv, run, err := lookup(commands, "ls").prepare([]string{"--format", "xml"}, &globals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The options are checked before the app is used, so none is needed.
+	if err := run(inv); exitCode(err) != exitUsage {
+		t.Fatalf("exit code = %d for %v, want %d", exitCode(err), err, exitUsage)
+	}
+}
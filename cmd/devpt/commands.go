@@ -0,0 +1,1017 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/devports/devpt/pkg/cli"
+	"github.com/devports/devpt/pkg/filewatch"
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/process"
+)
+
+// commands are the devpt subcommands, in the order devpt help lists them.
+var commands = withParents(nil, []*command{
+	{
+		name:  "add",
+		group: "Manage services",
+		usage: []string{
+			"<name> <cwd> <command> [ports...|auto] [options]",
+			"<name> <cwd> [ports...|auto] (--proc NAME=COMMAND... | --procfile PATH) [options]",
+		},
+		summary: "Register a service",
+		minArgs: 2, maxArgs: -1,
+		setup: setupAdd,
+	},
+	{
+		name:    "init",
+		group:   "Manage services",
+		usage:   []string{"[dir] [--dry-run]"},
+		summary: "Find the services of a repo's workspaces and register them",
+		minArgs: 0, maxArgs: 1,
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			dryRun := fs.Bool("dry-run", false, "List the services found without registering any")
+			return func(inv *invocation) error {
+				dir := "."
+				if len(inv.args) > 0 {
+					dir = inv.args[0]
+				}
+				return inv.app.InitCmd(dir, *dryRun)
+			}
+		},
+	},
+	{
+		name:    "start",
+		group:   "Manage services",
+		usage:   []string{"<name> [options]"},
+		summary: "Start a service",
+		minArgs: 1, maxArgs: 1,
+		service: true,
+		setup:   setupStart,
+	},
+	{
+		name:    "stop",
+		group:   "Manage services",
+		usage:   []string{"<name|port> [options]", "--port PORT [options]"},
+		summary: "Stop a service, or whatever listens on a port",
+		minArgs: 0, maxArgs: 1,
+		service: true,
+		setup:   setupStop,
+	},
+	{
+		name:    "restart",
+		group:   "Manage services",
+		usage:   []string{"<name>"},
+		summary: "Stop and start a service",
+		minArgs: 1, maxArgs: 1,
+		service: true,
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			return func(inv *invocation) error { return inv.app.RestartCmd(inv.args[0]) }
+		},
+	},
+	{
+		name:    "logs",
+		group:   "Manage services",
+		usage:   []string{"<name> [--lines N]"},
+		summary: "Show the latest log lines of a service",
+		minArgs: 1, maxArgs: 1,
+		service: true,
+		json:    true,
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			lines := fs.Int("lines", 50, "Number of lines to show")
+			return func(inv *invocation) error { return inv.app.LogsCmd(inv.args[0], *lines, inv.globals.json) }
+		},
+	},
+	{
+		name:    "run",
+		group:   "Manage services",
+		usage:   []string{"[name] [--port N|auto]... -- <command> [args...]"},
+		summary: "Run a one-off server in the foreground, tracked while it runs",
+		minArgs: 0, maxArgs: 1,
+		passthrough: true,
+		setup:       setupRun,
+	},
+	{
+		name:    "open",
+		group:   "Manage services",
+		usage:   []string{"<name|port> [url-name]"},
+		summary: "Open a server's URL in the default browser",
+		minArgs: 1, maxArgs: 2,
+		service: true,
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			return func(inv *invocation) error {
+				urlName := ""
+				if len(inv.args) == 2 {
+					urlName = inv.args[1]
+				}
+				return inv.app.OpenCmd(inv.args[0], urlName)
+			}
+		},
+	},
+	{
+		name:    "attach",
+		group:   "Manage services",
+		usage:   []string{"<name>"},
+		summary: "Type into a service added with --pty (Ctrl+] detaches)",
+		minArgs: 1, maxArgs: 1,
+		service: true,
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			return func(inv *invocation) error { return inv.app.AttachCmd(inv.args[0]) }
+		},
+	},
+	{
+		name:    "signal",
+		group:   "Manage services",
+		usage:   []string{"<name|pid> <SIGNAL>"},
+		summary: "Send a signal, e.g. HUP to reload configuration",
+		minArgs: 2, maxArgs: 2,
+		service: true,
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			return func(inv *invocation) error {
+				sig, err := process.ParseSignal(inv.args[1])
+				if err != nil {
+					return err
+				}
+				return inv.app.SignalCmd(inv.args[0], sig)
+			}
+		},
+	},
+	{
+		name:    "pause",
+		group:   "Manage services",
+		usage:   []string{"<name|pid>"},
+		summary: "Freeze a service with SIGSTOP",
+		minArgs: 1, maxArgs: 1,
+		service: true,
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			return func(inv *invocation) error { return inv.app.PauseCmd(inv.args[0]) }
+		},
+	},
+	{
+		name:    "resume",
+		group:   "Manage services",
+		usage:   []string{"<name|pid>"},
+		summary: "Continue a paused service with SIGCONT",
+		minArgs: 1, maxArgs: 1,
+		service: true,
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			return func(inv *invocation) error { return inv.app.ResumeCmd(inv.args[0]) }
+		},
+	},
+	{
+		name:    "boot",
+		group:   "Manage services",
+		summary: "Start services when you log in",
+		usage:   []string{"<command> [args]"},
+		subcommands: []*command{
+			{
+				name:    "enable",
+				usage:   []string{"<name>"},
+				summary: "Start a service at login",
+				minArgs: 1, maxArgs: 1,
+				service: true,
+				setup: func(fs *flag.FlagSet) func(*invocation) error {
+					return func(inv *invocation) error { return inv.app.BootEnableCmd(inv.args[0]) }
+				},
+			},
+			{
+				name:    "disable",
+				usage:   []string{"<name>"},
+				summary: "Stop starting a service at login",
+				minArgs: 1, maxArgs: 1,
+				service: true,
+				setup: func(fs *flag.FlagSet) func(*invocation) error {
+					return func(inv *invocation) error { return inv.app.BootDisableCmd(inv.args[0]) }
+				},
+			},
+			{
+				name:    "status",
+				usage:   []string{"[name]"},
+				summary: "Show which services start at login",
+				minArgs: 0, maxArgs: 1,
+				service: true,
+				setup: func(fs *flag.FlagSet) func(*invocation) error {
+					return func(inv *invocation) error {
+						name := ""
+						if len(inv.args) > 0 {
+							name = inv.args[0]
+						}
+						return inv.app.BootStatusCmd(name)
+					}
+				},
+			},
+			{
+				name:    "logs",
+				usage:   []string{"<name> [--lines N]"},
+				summary: "Show what devpt printed when it started a service at login",
+				minArgs: 1, maxArgs: 1,
+				service: true,
+				setup: func(fs *flag.FlagSet) func(*invocation) error {
+					lines := fs.Int("lines", 50, "Number of lines to show")
+					return func(inv *invocation) error { return inv.app.BootLogsCmd(inv.args[0], *lines) }
+				},
+			},
+		},
+	},
+	{
+		name:    "snapshot",
+		group:   "Manage services",
+		summary: "Save which services are running and bring them back later",
+		usage:   []string{"<command> [args]"},
+		subcommands: []*command{
+			{
+				name:    "save",
+				usage:   []string{"<name>"},
+				summary: "Save the running services, with their ports and env",
+				minArgs: 1, maxArgs: 1,
+				setup: func(fs *flag.FlagSet) func(*invocation) error {
+					return func(inv *invocation) error { return inv.app.SnapshotSaveCmd(inv.args[0]) }
+				},
+			},
+			{
+				name:    "restore",
+				usage:   []string{"<name>"},
+				summary: "Start the services of a snapshot that are not running",
+				minArgs: 1, maxArgs: 1,
+				setup: func(fs *flag.FlagSet) func(*invocation) error {
+					return func(inv *invocation) error { return inv.app.SnapshotRestoreCmd(inv.args[0]) }
+				},
+			},
+			{
+				name:    "ls",
+				summary: "List saved snapshots",
+				minArgs: 0, maxArgs: 0,
+				setup: func(fs *flag.FlagSet) func(*invocation) error {
+					return func(inv *invocation) error { return inv.app.SnapshotListCmd() }
+				},
+			},
+			{
+				name:    "rm",
+				aliases: []string{"remove"},
+				usage:   []string{"<name>"},
+				summary: "Delete a snapshot",
+				minArgs: 1, maxArgs: 1,
+				setup: func(fs *flag.FlagSet) func(*invocation) error {
+					return func(inv *invocation) error { return inv.app.SnapshotRemoveCmd(inv.args[0]) }
+				},
+			},
+		},
+	},
+	{
+		name:    "project",
+		group:   "Manage services",
+		summary: "Keep the services of several repos apart under --project",
+		usage:   []string{"<command> [args]"},
+		subcommands: []*command{
+			{
+				name:    "ls",
+				summary: "List projects, marking the current one with *",
+				minArgs: 0, maxArgs: 0,
+				setup: func(fs *flag.FlagSet) func(*invocation) error {
+					return func(inv *invocation) error { return inv.app.ProjectListCmd() }
+				},
+			},
+		},
+	},
+	{
+		name:    "job",
+		group:   "Jobs (tasks that exit, e.g. migrations)",
+		summary: "Register and run jobs",
+		usage:   []string{"<command> [args]"},
+		subcommands: []*command{
+			{
+				name:    "add",
+				usage:   []string{"<name> <cwd> <command> [--shell] [--schedule SPEC]"},
+				summary: "Register a job",
+				minArgs: 3, maxArgs: 3,
+				setup: func(fs *flag.FlagSet) func(*invocation) error {
+					shell := fs.Bool("shell", false, "Run the command through your shell (allows $VARS, pipes and &&)")
+					sched := fs.String("schedule", "", "Run periodically: \"every 15m\" or a cron expression")
+					return func(inv *invocation) error {
+						return inv.app.JobAddCmd(&models.Job{Name: inv.args[0], CWD: inv.args[1], Command: inv.args[2], Shell: *shell, Schedule: *sched})
+					}
+				},
+			},
+			{
+				name:    "run",
+				usage:   []string{"<name|\"<command>\">", "[name] -- <command> [args...]"},
+				summary: "Run in the foreground, logging output and exit status",
+				minArgs: 0, maxArgs: 1,
+				passthrough: true,
+				setup: func(fs *flag.FlagSet) func(*invocation) error {
+					return func(inv *invocation) error {
+						if len(inv.args) == 0 && len(inv.argv) == 0 {
+							return usageErrorf("expected a job name or a command")
+						}
+						name := ""
+						if len(inv.args) == 1 {
+							name = inv.args[0]
+						}
+						return inv.app.JobRunCmd(name, inv.argv)
+					}
+				},
+			},
+			{
+				name:    "ls",
+				usage:   []string{"[--runs N]"},
+				summary: "List jobs and their recent runs",
+				minArgs: 0, maxArgs: 0,
+				setup: func(fs *flag.FlagSet) func(*invocation) error {
+					runs := fs.Int("runs", 10, "Number of recent runs to show")
+					return func(inv *invocation) error { return inv.app.JobListCmd(*runs) }
+				},
+			},
+			{
+				name:    "logs",
+				usage:   []string{"<name> [--lines N]"},
+				summary: "Show the log of a job's latest run",
+				minArgs: 1, maxArgs: 1,
+				setup: func(fs *flag.FlagSet) func(*invocation) error {
+					lines := fs.Int("lines", 50, "Number of lines to show")
+					return func(inv *invocation) error { return inv.app.JobLogsCmd(inv.args[0], *lines) }
+				},
+			},
+			{
+				name:    "rm",
+				aliases: []string{"remove"},
+				usage:   []string{"<name>"},
+				summary: "Unregister a job",
+				minArgs: 1, maxArgs: 1,
+				setup: func(fs *flag.FlagSet) func(*invocation) error {
+					return func(inv *invocation) error { return inv.app.JobRemoveCmd(inv.args[0]) }
+				},
+			},
+		},
+	},
+	{
+		name:    "schedule",
+		group:   "Schedules (run while the TUI or devpt watch is open)",
+		usage:   []string{"[<job|service> <\"every 15m\"|\"<cron expr>\"|off>]"},
+		summary: "List schedules, or run a job or restart a service periodically",
+		minArgs: 0, maxArgs: 2,
+		service: true,
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			return func(inv *invocation) error {
+				switch len(inv.args) {
+				case 0:
+					return inv.app.ScheduleListCmd()
+				case 2:
+					return inv.app.ScheduleCmd(inv.args[0], inv.args[1])
+				}
+				return usageErrorf("expected a name and a schedule")
+			}
+		},
+	},
+	{
+		name:    "ls",
+		group:   "Inspect",
+		usage:   []string{"[--details] [--sort KEY] [--filter TEXT] [--source S] [--status S] [--columns LIST] [--format F]"},
+		summary: "List dev servers and managed services",
+		minArgs: 0, maxArgs: 0,
+		json: true,
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			detailed := fs.Bool("details", false, "Show extended metadata and log disk usage")
+			udp := fs.Bool("udp", false, "Also list bound UDP sockets")
+			all := fs.Bool("all", false, "List every listener, not only dev servers")
+			sortBy := fs.String("sort", "", "Order rows by `key`: "+strings.Join(cli.ListSorts, ", "))
+			filter := fs.String("filter", "", "Only servers whose name, project, command or port contains `text`")
+			source := fs.String("source", "", "Only servers from one `source`: managed, agent, manual, container or port-forward")
+			status := fs.String("status", "", "Only servers in one `status`: running, stopped, crashed, ...")
+			columns := fs.String("columns", "", "Comma-separated `list` of columns, e.g. name,port,pid,health")
+			format := fs.String("format", "", "Output `format`: "+strings.Join(cli.ListFormats, ", ")+", or template='{{.Name}} {{.Port}}'")
+			return func(inv *invocation) error {
+				opts := cli.ListOptions{Detailed: *detailed, Sort: *sortBy, Filter: *filter, Source: *source, Status: *status, Format: *format, JSON: inv.globals.json}
+				if *columns != "" {
+					for _, col := range strings.Split(*columns, ",") {
+						opts.Columns = append(opts.Columns, strings.TrimSpace(col))
+					}
+				}
+				if err := opts.Validate(); err != nil {
+					return &usageError{msg: err.Error()}
+				}
+				inv.app.SetIncludeUDP(*udp)
+				inv.app.SetShowAll(*all)
+				return inv.app.ListCmd(opts)
+			}
+		},
+	},
+	{
+		name:    "status",
+		group:   "Inspect",
+		usage:   []string{"<name|port> [--quiet]"},
+		summary: "Show details and health of a server",
+		minArgs: 1, maxArgs: 1,
+		service: true,
+		json:    true,
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			return func(inv *invocation) error { return inv.app.StatusCmd(inv.args[0], inv.globals.json) }
+		},
+	},
+	{
+		name:    "port",
+		group:   "Inspect",
+		usage:   []string{"<port>"},
+		summary: "Show who owns a port",
+		minArgs: 1, maxArgs: 1,
+		json: true,
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			return func(inv *invocation) error {
+				port, err := parsePort(inv.args[0])
+				if err != nil {
+					return err
+				}
+				return inv.app.PortCmd(port, inv.globals.json)
+			}
+		},
+	},
+	{
+		name:    "inspect",
+		group:   "Inspect",
+		usage:   []string{"<service|port> [--addr 127.0.0.1:PORT]"},
+		summary: "Proxy a service on another port and log every request to it",
+		serves:  true,
+		minArgs: 1, maxArgs: 1,
+		service: true,
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			addr := fs.String("addr", cli.DefaultInspectAddr, "`Address` to listen on (port 0 picks a free one)")
+			return func(inv *invocation) error { return inv.app.InspectCmd(inv.args[0], *addr) }
+		},
+	},
+	{
+		name:    "kill-port",
+		group:   "Inspect",
+		usage:   []string{"<port> [--force]"},
+		summary: "Stop whatever listens on a port",
+		minArgs: 1, maxArgs: 1,
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			force := fs.Bool("force", false, "Send SIGKILL immediately instead of SIGTERM")
+			return func(inv *invocation) error {
+				port, err := parsePort(inv.args[0])
+				if err != nil {
+					return err
+				}
+				return inv.app.KillPortCmd(port, *force)
+			}
+		},
+	},
+	{
+		name:    "events",
+		group:   "Inspect",
+		usage:   []string{"[--follow] [--lines N]"},
+		summary: "Show service lifecycle events",
+		minArgs: 0, maxArgs: 0,
+		json: true,
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			follow := fs.Bool("follow", false, "Keep printing new events as they happen")
+			lines := fs.Int("lines", 50, "Number of past events to show")
+			return func(inv *invocation) error { return inv.app.EventsCmd(*lines, *follow, inv.globals.json) }
+		},
+	},
+	{
+		name:    "history",
+		group:   "Inspect",
+		usage:   []string{"<name> [--lines N]"},
+		summary: "Show the runs of a service",
+		minArgs: 1, maxArgs: 1,
+		service: true,
+		json:    true,
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			lines := fs.Int("lines", 20, "Number of entries to show")
+			return func(inv *invocation) error { return inv.app.HistoryCmd(inv.args[0], *lines, inv.globals.json) }
+		},
+	},
+	{
+		name:    "stats",
+		group:   "Inspect",
+		usage:   []string{"[name] [--since DUR]"},
+		summary: "Summarize runs, restarts, crashes, uptime, log volume and health latency per service",
+		minArgs: 0, maxArgs: 1,
+		service: true,
+		json:    true,
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			since := fs.Duration("since", cli.DefaultStatsPeriod, "How far back to look")
+			return func(inv *invocation) error {
+				if *since <= 0 {
+					return usageErrorf("--since must be positive")
+				}
+				name := ""
+				if len(inv.args) == 1 {
+					name = inv.args[0]
+				}
+				return inv.app.StatsCmd(name, *since, inv.globals.json)
+			}
+		},
+	},
+	{
+		name:    "watch",
+		group:   "Inspect",
+		usage:   []string{"[name|--all] [--interval DUR]"},
+		summary: "Print status changes as they happen, for scripts",
+		serves:  true,
+		minArgs: 0, maxArgs: 1,
+		service: true,
+		json:    true,
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			all := fs.Bool("all", false, "Include unmanaged listeners")
+			interval := fs.Duration("interval", cli.DefaultWatchInterval, "Time between scans")
+			return func(inv *invocation) error {
+				name := ""
+				if len(inv.args) == 1 {
+					if *all {
+						return usageErrorf("expected a service name or --all, not both")
+					}
+					name = inv.args[0]
+				}
+				return inv.app.WatchCmd(name, *all, inv.globals.json, *interval)
+			}
+		},
+	},
+	{
+		name:    "api",
+		group:   "Integrations",
+		summary: "Serve a local REST API for dashboards, editors and scripts",
+		usage:   []string{"<command> [args]"},
+		subcommands: []*command{
+			{
+				name:    "serve",
+				usage:   []string{"[--addr 127.0.0.1:7070]"},
+				summary: "Serve the REST API until interrupted",
+				serves:  true,
+				setup: func(fs *flag.FlagSet) func(*invocation) error {
+					addr := fs.String("addr", cli.DefaultAPIAddr, "`Address` to listen on")
+					return func(inv *invocation) error { return inv.app.APIServeCmd(*addr) }
+				},
+			},
+		},
+	},
+	{
+		name:    "proxy",
+		group:   "Integrations",
+		summary: "Reach services at http://<name>.localhost instead of their ports",
+		usage:   []string{"<command> [args]"},
+		subcommands: []*command{
+			{
+				name:    "serve",
+				usage:   []string{"[--addr 127.0.0.1:7080] [--domain localhost]"},
+				summary: "Route http://<service>.localhost to each managed service until interrupted",
+				serves:  true,
+				setup: func(fs *flag.FlagSet) func(*invocation) error {
+					addr := fs.String("addr", cli.DefaultProxyAddr, "`Address` to listen on")
+					domain := fs.String("domain", cli.DefaultProxyDomain, "`Domain` service hostnames end with")
+					return func(inv *invocation) error { return inv.app.ProxyServeCmd(*addr, *domain) }
+				},
+			},
+		},
+	},
+	{
+		name:    "forward",
+		group:   "Integrations",
+		usage:   []string{"<port>:<target-port> [--name NAME]", "<service> <port> [--name NAME]"},
+		summary: "Keep a stable local port pointed at a port or at wherever a service listens",
+		serves:  true,
+		minArgs: 1, maxArgs: 2,
+		service: true,
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			name := fs.String("name", "", "Managed service `name` of the forward (default forward-<port>)")
+			foreground := fs.Bool("foreground", false, "Forward in the foreground instead of as a managed service")
+			return func(inv *invocation) error {
+				spec, err := cli.ParseForwardSpec(inv.args)
+				if err != nil {
+					return usageErrorf("%v", err)
+				}
+				if *foreground {
+					return inv.app.ForwardForegroundCmd(spec)
+				}
+				return inv.app.ForwardCmd(spec, *name)
+			}
+		},
+	},
+	{
+		name:    "cert",
+		group:   "Integrations",
+		summary: "Issue trusted local HTTPS certificates and serve services over TLS",
+		usage:   []string{"<command> [args]"},
+		subcommands: []*command{
+			{
+				name:    "install",
+				summary: "Create the local certificate authority and trust it on this machine",
+				minArgs: 0, maxArgs: 0,
+				setup: func(fs *flag.FlagSet) func(*invocation) error {
+					return func(inv *invocation) error { return inv.app.CertInstallCmd() }
+				},
+			},
+			{
+				name:    "issue",
+				usage:   []string{"<host>... [--name NAME]"},
+				summary: "Write a certificate and key for hosts, e.g. localhost '*.app.test' 127.0.0.1",
+				minArgs: 1, maxArgs: -1,
+				setup: func(fs *flag.FlagSet) func(*invocation) error {
+					name := fs.String("name", "", "File `name` of the certificate (default from the first host)")
+					return func(inv *invocation) error { return inv.app.CertIssueCmd(inv.args, *name) }
+				},
+			},
+			{
+				name:    "ls",
+				summary: "List the local authority and the certificates it issued",
+				minArgs: 0, maxArgs: 0,
+				setup: func(fs *flag.FlagSet) func(*invocation) error {
+					return func(inv *invocation) error { return inv.app.CertListCmd() }
+				},
+			},
+			{
+				name:    "serve",
+				usage:   []string{"<service|port> [--addr 127.0.0.1:8443]"},
+				summary: "Serve a service over HTTPS until interrupted, forwarding to its HTTP port",
+				serves:  true,
+				minArgs: 1, maxArgs: 1,
+				service: true,
+				setup: func(fs *flag.FlagSet) func(*invocation) error {
+					addr := fs.String("addr", cli.DefaultCertAddr, "`Address` to listen on")
+					return func(inv *invocation) error { return inv.app.CertServeCmd(inv.args[0], *addr) }
+				},
+			},
+		},
+	},
+	{
+		name:    "mcp",
+		group:   "Integrations",
+		summary: "Serve the Model Context Protocol on stdio so AI agents can manage servers",
+		serves:  true,
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			return func(inv *invocation) error { return inv.app.MCPServeCmd(version) }
+		},
+	},
+	{
+		name:    "ide",
+		group:   "Integrations",
+		usage:   []string{"[--interval DUR]"},
+		summary: "Serve editor extensions over JSON-RPC on stdio, pushing server list changes",
+		serves:  true,
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			interval := fs.Duration("interval", cli.DefaultIDEInterval, "Time between checks for server list changes")
+			return func(inv *invocation) error { return inv.app.IDEServeCmd(version, *interval) }
+		},
+	},
+	{
+		name:    "statusline",
+		group:   "Integrations",
+		usage:   []string{"[--format compact]"},
+		summary: "Print a one-line summary for tmux or starship",
+		minArgs: 0, maxArgs: 0,
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			format := fs.String("format", "full", "Output `format`: "+strings.Join(cli.StatusLineFormats, ", "))
+			return func(inv *invocation) error { return inv.app.StatusLineCmd(*format) }
+		},
+	},
+	{
+		name:    "menubar",
+		group:   "Integrations",
+		summary: "Print an xbar/SwiftBar plugin menu of dev servers with start/stop actions",
+		minArgs: 0, maxArgs: 0,
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			return func(inv *invocation) error { return inv.app.MenubarCmd() }
+		},
+	},
+	{
+		name:    "daemon",
+		group:   "Maintenance",
+		summary: "Serve devpt to other commands and TUIs from one long-lived process",
+		usage:   []string{"<command> [args]"},
+		subcommands: []*command{
+			{
+				name:       "run",
+				usage:      []string{"[--interval DUR]"},
+				summary:    "Run the daemon in the foreground",
+				serves:     true,
+				standalone: true,
+				setup: func(fs *flag.FlagSet) func(*invocation) error {
+					interval := fs.Duration("interval", cli.DefaultDaemonInterval, "Time between scans")
+					return func(inv *invocation) error { return inv.app.DaemonRunCmd(*interval) }
+				},
+			},
+			{
+				name:       "status",
+				summary:    "Show whether the daemon is running",
+				standalone: true,
+				setup: func(fs *flag.FlagSet) func(*invocation) error {
+					return func(inv *invocation) error { return inv.app.DaemonStatusCmd() }
+				},
+			},
+			{
+				name:       "stop",
+				summary:    "Stop the running daemon",
+				standalone: true,
+				setup: func(fs *flag.FlagSet) func(*invocation) error {
+					return func(inv *invocation) error { return inv.app.DaemonStopCmd() }
+				},
+			},
+		},
+	},
+	{
+		name:    "doctor",
+		group:   "Maintenance",
+		summary: "Check the environment and registry for problems",
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			return func(inv *invocation) error { return inv.app.DoctorCmd() }
+		},
+	},
+	{
+		name:    "gc",
+		group:   "Maintenance",
+		usage:   []string{"[--dry-run]"},
+		summary: "Clear stale PIDs and prune old logs",
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			dryRun := fs.Bool("dry-run", false, "Report what would be cleaned up without changing anything")
+			return func(inv *invocation) error { return inv.app.GCCmd(*dryRun) }
+		},
+	},
+	{
+		name:    "orphans",
+		group:   "Maintenance",
+		usage:   []string{"[--kill]"},
+		summary: "List (and stop) dev servers whose parent or service is gone",
+		setup: func(fs *flag.FlagSet) func(*invocation) error {
+			kill := fs.Bool("kill", false, "Stop the orphaned processes without asking")
+			return func(inv *invocation) error { return inv.app.OrphansCmd(*kill, true) }
+		},
+	},
+})
+
+// withParents links each subcommand to its parent and returns cmds.
+func withParents(parent *command, cmds []*command) []*command {
+	for _, c := range cmds {
+		c.parent = parent
+		withParents(c, c.subcommands)
+	}
+	return cmds
+}
+
+func setupAdd(fs *flag.FlagSet) func(*invocation) error {
+	healthPath := fs.String("health-path", "", "HTTP `path` probed by health checks (e.g. /healthz)")
+	healthStatus := fs.String("health-status", "", "Comma-separated accepted HTTP status `codes`")
+	healthBody := fs.String("health-body", "", "Substring the health response body must contain")
+	healthTLS := fs.Bool("health-tls", false, "Probe health over https")
+	healthTimeout := fs.Duration("health-timeout", 0, "Health check timeout (e.g. 2s)")
+	healthInterval := fs.Duration("health-interval", 0, "Minimum time between health checks (e.g. 10s)")
+	healthProtocol := fs.String("health-protocol", "", "Health probe protocol: http, grpc, websocket, redis, postgres or mysql")
+	healthCmd := fs.String("health-cmd", "", "Command run in the service directory; exit 0 means healthy")
+	var readyPatterns stringList
+	fs.Var(&readyPatterns, "ready-pattern", "Output substring that marks the service ready (repeatable)")
+	healthGRPCService := fs.String("health-grpc-service", "", "Service name for grpc.health.v1 checks")
+	healthProbe := fs.String("health-probe", "", "HTTP `path` hit often to show availability and error rate over 5 minutes")
+	healthProbeInterval := fs.Duration("health-probe-interval", 0, "Time between requests to --health-probe (default: 5s)")
+	var portFlags stringList
+	fs.Var(&portFlags, "port", `Port the service listens on, or "auto" to allocate one on every start (repeatable)`)
+	var urls stringList
+	fs.Var(&urls, "url", "Path (e.g. /docs) or full URL of the service, optionally named as NAME=URL; the first is the primary one (repeatable)")
+	var envFlags stringList
+	fs.Var(&envFlags, "env", "Environment variable set for every run, as KEY=VALUE (repeatable)")
+	var tags stringList
+	fs.Var(&tags, "tag", "Tag for grouping the service (repeatable)")
+	pty := fs.Bool("pty", false, "Run the service on a terminal that devpt attach can connect to")
+	shell := fs.Bool("shell", false, "Run the command through your shell (allows $VARS, pipes and &&)")
+	var procFlags stringList
+	fs.Var(&procFlags, "proc", "Named process of a compound service, as NAME=COMMAND (repeatable)")
+	procfile := fs.String("procfile", "", "Read the processes of a compound service from a Procfile")
+	var requires stringList
+	fs.Var(&requires, "requires", "`Job` that must succeed before each start (repeatable)")
+	sched := fs.String("schedule", "", `Restart periodically: "every 15m" or a cron expression`)
+	stopSignal := fs.String("stop-signal", "", "`Signal` that stops the service gracefully (default: TERM)")
+	stopTimeout := fs.Duration("stop-timeout", 0, "Time the service gets to exit before it is killed (default: 5s)")
+	maxMem := fs.String("max-mem", "", "Memory limit for the service's processes (e.g. 2GB)")
+	maxCPU := fs.Float64("max-cpu", 0, "CPU limit in percent of one core (e.g. 150)")
+	limitFor := fs.Duration("limit-for", 0, "How long a limit must be exceeded before acting (e.g. 30s)")
+	limitAction := fs.String("limit-action", "", "What to do when a limit is exceeded: highlight, notify or restart")
+
+	return func(inv *invocation) error {
+		positional := inv.args
+		var procs []models.ServiceProcess
+		if *procfile != "" {
+			f, err := os.Open(*procfile)
+			if err != nil {
+				return err
+			}
+			procs, err = cli.ParseProcfile(f)
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("%s: %w", *procfile, err)
+			}
+		}
+		for _, raw := range procFlags {
+			name, command, ok := strings.Cut(raw, "=")
+			if !ok {
+				return fmt.Errorf("invalid process %q (want NAME=COMMAND)", raw)
+			}
+			procs = append(procs, models.ServiceProcess{Name: name, Command: command})
+		}
+
+		// A compound service has no command of its own.
+		required := 3
+		if len(procs) > 0 {
+			required = 2
+		}
+		if len(positional) < required {
+			return usageErrorf("expected <name> <cwd> <command>, or --proc or --procfile for a compound service")
+		}
+
+		name := inv.app.ScopedName(positional[0])
+		cwd := positional[1]
+		command := ""
+		if required == 3 {
+			command = positional[2]
+		}
+
+		var ports []int
+		autoPort := false
+		for _, raw := range append(positional[required:], portFlags...) {
+			if raw == "auto" {
+				autoPort = true
+				continue
+			}
+			port, err := strconv.Atoi(raw)
+			if err != nil {
+				return fmt.Errorf("invalid port: %s", raw)
+			}
+			ports = append(ports, port)
+		}
+
+		svc := &models.ManagedService{
+			Name:          name,
+			CWD:           cwd,
+			Command:       command,
+			Processes:     procs,
+			Requires:      requires,
+			Schedule:      *sched,
+			StopSignal:    *stopSignal,
+			StopTimeout:   models.Duration(*stopTimeout),
+			Ports:         ports,
+			AutoPort:      autoPort,
+			Tags:          tags,
+			Env:           envFlags,
+			ReadyPatterns: readyPatterns,
+			PTY:           *pty,
+			Shell:         *shell,
+		}
+		for _, raw := range urls {
+			u, err := cli.ParseServiceURL(raw)
+			if err != nil {
+				return err
+			}
+			svc.URLs = append(svc.URLs, u)
+		}
+
+		if *maxMem != "" || *maxCPU != 0 || *limitFor != 0 || *limitAction != "" {
+			limits := &models.ResourceLimits{
+				CPU:    *maxCPU,
+				For:    models.Duration(*limitFor),
+				Action: *limitAction,
+			}
+			if *maxMem != "" {
+				size, err := models.ParseByteSize(*maxMem)
+				if err != nil {
+					return err
+				}
+				limits.Memory = size
+			}
+			if limits.Memory == 0 && limits.CPU == 0 {
+				return fmt.Errorf("--limit-for and --limit-action need --max-mem or --max-cpu")
+			}
+			svc.Limits = limits
+		}
+
+		switch *healthProtocol {
+		case "", models.HealthProtocolHTTP, models.HealthProtocolGRPC, models.HealthProtocolWebSocket,
+			models.HealthProtocolRedis, models.HealthProtocolPostgres, models.HealthProtocolMySQL:
+		default:
+			return fmt.Errorf("invalid health protocol: %s", *healthProtocol)
+		}
+
+		hc := &models.HealthCheckConfig{
+			Protocol:    *healthProtocol,
+			GRPCService: *healthGRPCService,
+			Command:     *healthCmd,
+			Path:        *healthPath,
+			ExpectBody:  *healthBody,
+			TLS:         *healthTLS,
+			Timeout:     models.Duration(*healthTimeout),
+			Interval:    models.Duration(*healthInterval),
+			ProbePath:   *healthProbe,
+		}
+		if *healthProbeInterval > 0 {
+			if hc.ProbePath == "" {
+				return fmt.Errorf("--health-probe-interval needs --health-probe")
+			}
+			hc.ProbeInterval = models.Duration(*healthProbeInterval)
+		}
+		if *healthStatus != "" {
+			for _, raw := range strings.Split(*healthStatus, ",") {
+				code, err := strconv.Atoi(strings.TrimSpace(raw))
+				if err != nil || code < 100 || code > 599 {
+					return fmt.Errorf("invalid health status code: %s", raw)
+				}
+				hc.ExpectedStatus = append(hc.ExpectedStatus, code)
+			}
+		}
+		if hc.Command != "" || hc.Protocol != "" || hc.GRPCService != "" || hc.Path != "" || hc.ExpectBody != "" || hc.TLS || hc.Timeout > 0 || hc.Interval > 0 || len(hc.ExpectedStatus) > 0 || hc.ProbePath != "" {
+			svc.Health = hc
+		}
+
+		return inv.app.AddServiceCmd(svc)
+	}
+}
+
+func setupStart(fs *flag.FlagSet) func(*invocation) error {
+	var watch, ignore stringList
+	fs.Var(&watch, "watch", "Restart when files matching this `glob` change (repeatable)")
+	fs.Var(&ignore, "ignore", "`Glob` of paths to ignore while watching (repeatable)")
+	watchAll := fs.Bool("watch-all", false, "Restart when any file in the service directory changes")
+	debounce := fs.Duration("debounce", filewatch.DefaultDebounce, "Quiet period before restarting")
+	force := fs.Bool("force", false, "Stop processes already listening on the service's ports")
+	autoPort := fs.Bool("auto-port", false, "Allocate a free port for this run and pass it as $PORT")
+	attach := fs.Bool("attach", false, "Stream the service's output and stop it on Ctrl+C")
+
+	return func(inv *invocation) error {
+		name := inv.args[0]
+		opts := cli.StartOptions{Force: *force, AutoPort: *autoPort}
+		watching := len(watch) > 0 || *watchAll
+		if *attach {
+			if watching {
+				return usageErrorf("--attach cannot be combined with --watch or --watch-all")
+			}
+			return inv.app.StartAttachedCmd(name, opts)
+		}
+		if watching {
+			return inv.app.StartWatchCmd(name, filewatch.Options{
+				Patterns: watch,
+				Ignore:   ignore,
+				Debounce: *debounce,
+			}, opts)
+		}
+		return inv.app.StartServiceCmd(name, opts)
+	}
+}
+
+func setupStop(fs *flag.FlagSet) func(*invocation) error {
+	port := fs.String("port", "", "Stop whatever listens on this `port`")
+	sig := fs.String("signal", "", "`Signal` to send instead of the service's stop signal")
+	timeout := fs.Duration("timeout", 0, "Time to wait before killing instead of the service's stop timeout")
+	cleanup := fs.Bool("cleanup", false, "Also stop processes of the service that outlive the stop, without asking")
+
+	return func(inv *invocation) error {
+		identifier := *port
+		if len(inv.args) > 0 {
+			if identifier != "" {
+				return usageErrorf("expected a service name or --port, not both")
+			}
+			identifier = inv.args[0]
+		}
+		if identifier == "" {
+			return usageErrorf("service name or port required")
+		}
+
+		opts := cli.StopOptions{Cleanup: *cleanup, Interactive: true}
+		if *sig != "" {
+			var err error
+			if opts.Signal, err = process.ParseSignal(*sig); err != nil {
+				return err
+			}
+		}
+		if *timeout < 0 {
+			return usageErrorf("invalid timeout: %s", *timeout)
+		}
+		opts.Timeout = *timeout
+		return inv.app.StopServiceCmd(identifier, opts)
+	}
+}
+
+func setupRun(fs *flag.FlagSet) func(*invocation) error {
+	var portFlags stringList
+	fs.Var(&portFlags, "port", `Port the command listens on, or "auto" to allocate one and pass it as $PORT (repeatable)`)
+
+	return func(inv *invocation) error {
+		if len(inv.argv) == 0 {
+			return usageErrorf("expected a command after --")
+		}
+		name := ""
+		if len(inv.args) == 1 {
+			name = inv.args[0]
+		}
+		var ports []int
+		autoPort := false
+		for _, raw := range portFlags {
+			if raw == "auto" {
+				autoPort = true
+				continue
+			}
+			port, err := strconv.Atoi(raw)
+			if err != nil {
+				return fmt.Errorf("invalid port: %s", raw)
+			}
+			ports = append(ports, port)
+		}
+		return inv.app.RunCmd(name, ports, autoPort, inv.argv)
+	}
+}
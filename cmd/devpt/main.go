@@ -1,184 +1,240 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/devports/devpt/pkg/cli"
+	"github.com/devports/devpt/pkg/process"
 )
 
+const version = "0.1.0"
+
 func main() {
-	app, err := cli.NewApp()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	// The supervisor and the process-group runner run detached from the
+	// user's terminal and must not touch the registry or config, so they are
+	// dispatched before the app is built.
+	if len(os.Args) > 1 && os.Args[1] == process.SupervisorCommand {
+		os.Exit(process.RunSupervisor(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == process.GroupCommand {
+		os.Exit(process.RunGroup(os.Args[2:]))
 	}
-	if len(os.Args) < 2 {
-		if err := app.TopCmd(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+	os.Exit(run(os.Args[1:]))
+}
+
+// run runs the devpt command line args and returns the exit code.
+func run(args []string) int {
+	var globals globalOptions
+	top := flag.NewFlagSet("devpt", flag.ContinueOnError)
+	top.SetOutput(io.Discard)
+	globals.register(top)
+	showVersion := top.Bool("version", false, "Print the version")
+	top.BoolVar(showVersion, "v", false, "Short for --version")
+	if err := top.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printUsage(os.Stdout)
+			return 0
 		}
-		return
-	}
-
-	command := os.Args[1]
-
-	switch command {
-	case "ls":
-		err = handleLS(app, os.Args[2:])
-	case "add":
-		err = handleAdd(app, os.Args[2:])
-	case "start":
-		err = handleStart(app, os.Args[2:])
-	case "stop":
-		err = handleStop(app, os.Args[2:])
-	case "restart":
-		err = handleRestart(app, os.Args[2:])
-	case "logs":
-		err = handleLogs(app, os.Args[2:])
-	case "status":
-		err = handleStatus(app, os.Args[2:])
-	case "--help", "-h", "help":
-		printUsage()
-		os.Exit(0)
-	case "--version", "-v":
-		fmt.Println("devpt version 0.1.0")
-		os.Exit(0)
-	default:
-		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", command)
-		os.Exit(1)
+		return report(&usageError{msg: err.Error()})
+	}
+	if *showVersion {
+		fmt.Printf("devpt version %s\n", version)
+		return 0
+	}
+	args = top.Args()
+
+	var (
+		leaf  *command
+		inv   *invocation
+		runFn func(*invocation) error
+	)
+	if len(args) > 0 {
+		if args[0] == "help" {
+			return report(showHelp(args[1:]))
+		}
+		cmd := lookup(commands, args[0])
+		if cmd == nil {
+			return report(usageErrorf("unknown command: %s (run 'devpt help' for a list)", args[0]))
+		}
+		var err error
+		if leaf, inv, runFn, err = cmd.prepare(args[1:], &globals); err != nil {
+			return report(err)
+		}
+	} else if globals.json || globals.quiet {
+		return report(usageErrorf("--json and --quiet need a command; the TUI does not use them"))
 	}
 
+	app, err := cli.NewApp()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		return report(err)
+	}
+	defer app.Close()
+	if globals.nonInteractive || globals.yes || cli.NonInteractiveFromEnv() {
+		app.SetNonInteractive(globals.yes)
+	}
+	if err := app.SetTheme(globals.theme, globals.noColor); err != nil {
+		return report(&usageError{msg: err.Error()})
+	}
+	if globals.noEmoji {
+		app.SetTextIcons()
 	}
+	if err := app.SetProject(globals.project); err != nil {
+		return report(&usageError{msg: err.Error()})
+	}
+	if leaf == nil || !leaf.standalone {
+		app.ConnectDaemon()
+	}
+	if globals.quiet {
+		app.SetOutput(io.Discard, os.Stderr)
+	}
+	if inv == nil {
+		return report(app.TopCmd())
+	}
+	inv.app = app
+	if leaf.service && len(inv.args) > 0 {
+		inv.args[0] = app.ResolveService(inv.args[0])
+	}
+	if leaf.serves {
+		return report(runFn(inv))
+	}
+	span := app.TraceCommand("devpt " + leaf.path())
+	err = runFn(inv)
+	span.End(err)
+	return report(err)
 }
 
-func handleLS(app *cli.App, args []string) error {
-	fs := flag.NewFlagSet("ls", flag.ContinueOnError)
-	detailed := fs.Bool("details", false, "Show extended metadata")
+// Exit codes for failures scripts may want to branch on. Commands that run
+// a process in the foreground (run, job run, start --attach) exit with its
+// code instead.
+const (
+	exitFailure        = 1 // any other error
+	exitUsage          = 2 // invalid command line
+	exitNotFound       = 3 // cli.ErrServiceNotFound
+	exitPortConflict   = 4 // cli.ErrPortConflict
+	exitAlreadyRunning = 5 // cli.ErrAlreadyRunning
+	exitNeedSudo       = 6 // cli.ErrNeedSudo
+	exitStopped        = 7 // cli.ErrStopped, from devpt status
+	exitCrashed        = 8 // cli.ErrCrashed, from devpt status
+	exitUnhealthy      = 9 // cli.ErrUnhealthy, from devpt status
+)
 
-	if err := fs.Parse(args); err != nil {
-		return err
+// report prints err, if any, and returns the exit code for it.
+func report(err error) int {
+	if err == nil || errors.Is(err, errHelpShown) {
+		return 0
 	}
-
-	return app.ListCmd(*detailed)
-}
-
-func handleAdd(app *cli.App, args []string) error {
-	if len(args) < 3 {
-		fmt.Println("Usage: devpt add <name> <cwd> <command> [ports...]")
-		return fmt.Errorf("insufficient arguments")
+	var exitErr *cli.ExitError
+	if errors.As(err, &exitErr) {
+		// The command already reported its failure on the terminal.
+		return exitErr.Code
 	}
-
-	name := args[0]
-	cwd := args[1]
-	command := args[2]
-
-	var ports []int
-	for i := 3; i < len(args); i++ {
-		port, err := strconv.Atoi(args[i])
-		if err != nil {
-			return fmt.Errorf("invalid port: %s", args[i])
-		}
-		ports = append(ports, port)
+	var statusErr *cli.StatusError
+	if errors.As(err, &statusErr) {
+		// devpt status printed the state; the code is the answer.
+		return exitCode(err)
 	}
-
-	return app.AddCmd(name, cwd, command, ports)
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	return exitCode(err)
 }
 
-func handleStart(app *cli.App, args []string) error {
-	if len(args) < 1 {
-		fmt.Println("Usage: devpt start <name>")
-		return fmt.Errorf("service name required")
+// exitCode maps err to the exit code documented for it.
+func exitCode(err error) int {
+	var usageErr *usageError
+	switch {
+	case errors.As(err, &usageErr):
+		return exitUsage
+	case errors.Is(err, cli.ErrServiceNotFound):
+		return exitNotFound
+	case errors.Is(err, cli.ErrPortConflict):
+		return exitPortConflict
+	case errors.Is(err, cli.ErrAlreadyRunning):
+		return exitAlreadyRunning
+	case errors.Is(err, cli.ErrNeedSudo):
+		return exitNeedSudo
+	case errors.Is(err, cli.ErrStopped):
+		return exitStopped
+	case errors.Is(err, cli.ErrCrashed):
+		return exitCrashed
+	case errors.Is(err, cli.ErrUnhealthy):
+		return exitUnhealthy
 	}
-
-	return app.StartCmd(args[0])
+	return exitFailure
 }
 
-func handleStop(app *cli.App, args []string) error {
-	if len(args) < 1 {
-		fmt.Println("Usage: devpt stop <name|--port PORT>")
-		return fmt.Errorf("service name or port required")
+// showHelp prints the help for the command named by path, e.g. ["job",
+// "add"], or the overall usage when path is empty.
+func showHelp(path []string) error {
+	if len(path) == 0 {
+		printUsage(os.Stdout)
+		return nil
 	}
-
-	if args[0] == "--port" {
-		if len(args) < 2 {
-			return fmt.Errorf("port required after --port")
+	cmds := commands
+	var cmd *command
+	for _, name := range path {
+		if cmd = lookup(cmds, name); cmd == nil {
+			return usageErrorf("unknown command: %s", strings.Join(path, " "))
 		}
-		return app.StopCmd(args[1])
+		cmds = cmd.subcommands
 	}
-
-	return app.StopCmd(args[0])
-}
-
-func handleRestart(app *cli.App, args []string) error {
-	if len(args) < 1 {
-		fmt.Println("Usage: devpt restart <name>")
-		return fmt.Errorf("service name required")
+	if len(cmd.subcommands) > 0 {
+		printCommandHelp(os.Stdout, cmd, nil)
+		return nil
 	}
-
-	return app.RestartCmd(args[0])
+	fs := flag.NewFlagSet("devpt "+cmd.path(), flag.ContinueOnError)
+	cmd.setup(fs)
+	printCommandHelp(os.Stdout, cmd, fs)
+	return nil
 }
 
-func handleLogs(app *cli.App, args []string) error {
-	if len(args) < 1 {
-		fmt.Println("Usage: devpt logs <name> [--lines N]")
-		return fmt.Errorf("service name required")
-	}
-
-	name := args[0]
-	lines := 50
+// commandGroups are the headings of devpt help, in order.
+var commandGroups = []string{
+	"Manage services",
+	"Jobs (tasks that exit, e.g. migrations)",
+	"Schedules (run while the TUI or devpt watch is open)",
+	"Inspect",
+	"Integrations",
+	"Maintenance",
+}
 
-	// Parse optional --lines flag
-	for i := 1; i < len(args); i++ {
-		if args[i] == "--lines" && i+1 < len(args) {
-			if n, err := strconv.Atoi(args[i+1]); err == nil {
-				lines = n
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Dev Process Tracker")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintf(w, "  %-34s %s\n", "devpt", "Open the interactive top UI")
+	fmt.Fprintf(w, "  %-34s %s\n", "devpt <command> [options] [args]", "Run a command")
+	for _, group := range commandGroups {
+		fmt.Fprintf(w, "\n%s:\n", group)
+		for _, c := range commands {
+			if c.group != group {
+				continue
+			}
+			if len(c.subcommands) > 0 {
+				printCommandList(w, c.subcommands)
+			} else {
+				printCommandList(w, []*command{c})
 			}
 		}
 	}
 
-	return app.LogsCmd(name, lines)
-}
-
-func handleStatus(app *cli.App, args []string) error {
-	if len(args) < 1 {
-		fmt.Println("Usage: devpt status <name|port>")
-		return fmt.Errorf("service name or port required")
-	}
-
-	return app.StatusCmd(args[0])
-}
-
-func printUsage() {
-	usage := `Dev Process Tracker
-
-Default:
-  devpt                             Open interactive top UI
-
-Manage services:
-  devpt add <name> <cwd> "<cmd>" [ports...]
-  devpt start <name>
-  devpt stop <name>
-  devpt stop --port <port>
-  devpt restart <name>
-  devpt logs <name> [--lines N]
+	fmt.Fprintln(w, "\nGlobal options (before or after the command):")
+	var globals globalOptions
+	fs := flag.NewFlagSet("devpt", flag.ContinueOnError)
+	globals.register(fs)
+	printFlags(w, fs, func(name string) bool { return len(name) > 1 })
+	fmt.Fprintf(w, "  %-24s %s\n", "-q, -y", "Short for --quiet and --yes")
+	fmt.Fprintf(w, "  %-24s %s\n", "--version, -v", "Print the version")
 
-Inspect:
-  devpt ls [--details]
-  devpt status <name|port>
-
-Meta:
-  devpt help
-  devpt --version
-
-Options:
-  --details       Show extended metadata in ls output
-  --lines N       Number of log lines to show (default: 50)
+	fmt.Fprint(w, `
+Exit codes:
+  0 success, 1 error, 2 invalid command line, 3 service not found, 4 port conflict,
+  5 already running, 6 needs sudo; status exits 7 when stopped, 8 when crashed and
+  9 when unhealthy; run, job run and start --attach exit with the command's own code
 
 Quick start:
   devpt
@@ -188,6 +244,25 @@ Quick start:
 
 Top UI tips:
   Tab switch lists, Enter actions/start, / filter, ? help, ^A add
-`
-	fmt.Print(usage)
+
+Run 'devpt help <command>' or 'devpt <command> --help' for a command's options.
+`)
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ",") }
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+func parsePort(raw string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimPrefix(raw, ":"))
+	if err != nil || port <= 0 || port > 65535 {
+		return 0, fmt.Errorf("invalid port: %s", raw)
+	}
+	return port, nil
 }
@@ -0,0 +1,340 @@
+// Package certs runs a local certificate authority that issues TLS
+// certificates for development hostnames, in the manner of mkcert.
+package certs
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	caCertFile = "ca.pem"
+	caKeyFile  = "ca-key.pem"
+	keySuffix  = "-key.pem"
+)
+
+// caValidity is how long the authority is valid; leafValidity how long the
+// certificates it issues are, kept under the 825 days Apple platforms accept.
+const (
+	caValidity   = 10 * 365 * 24 * time.Hour
+	leafValidity = 820 * 24 * time.Hour
+)
+
+// ErrNoAuthority is returned by Load when dir holds no authority yet.
+var ErrNoAuthority = errors.New("no local certificate authority")
+
+// Authority is a local certificate authority kept in a directory.
+type Authority struct {
+	Cert *x509.Certificate
+	key  crypto.Signer
+	dir  string
+}
+
+// Leaf is a certificate the authority issued, PEM encoded.
+type Leaf struct {
+	CertPEM []byte
+	KeyPEM  []byte
+}
+
+// Issued describes a certificate file in the authority's directory.
+type Issued struct {
+	Name     string
+	Hosts    []string
+	NotAfter time.Time
+	Path     string
+	KeyPath  string
+}
+
+// CertPath is the authority's certificate, the file to trust.
+func (a *Authority) CertPath() string {
+	return filepath.Join(a.dir, caCertFile)
+}
+
+// Load reads the authority in dir, returning ErrNoAuthority when there is
+// none.
+func Load(dir string) (*Authority, error) {
+	certPEM, err := os.ReadFile(filepath.Join(dir, caCertFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoAuthority
+	}
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := os.ReadFile(filepath.Join(dir, caKeyFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the authority's key: %w", err)
+	}
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid local certificate authority in %s: %w", dir, err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+	key, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok || !cert.IsCA {
+		return nil, fmt.Errorf("invalid local certificate authority in %s", dir)
+	}
+	return &Authority{Cert: cert, key: key, dir: dir}, nil
+}
+
+// LoadOrCreate reads the authority in dir, creating it first when there is
+// none; created reports whether it did.
+func LoadOrCreate(dir string) (a *Authority, created bool, err error) {
+	a, err = Load(dir)
+	if !errors.Is(err, ErrNoAuthority) {
+		return a, false, err
+	}
+	a, err = create(dir)
+	return a, err == nil, err
+}
+
+func create(dir string) (*Authority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := newSerial()
+	if err != nil {
+		return nil, err
+	}
+	name := "devpt development CA"
+	if owner := owner(); owner != "" {
+		name += " " + owner
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: name, Organization: []string{"devpt development CA"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	// The key goes first, so that a half-written authority does not load.
+	if err := os.WriteFile(filepath.Join(dir, caKeyFile), keyPEM, 0o600); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, caCertFile), encodeCert(der), 0o644); err != nil {
+		return nil, err
+	}
+	return &Authority{Cert: cert, key: key, dir: dir}, nil
+}
+
+// owner names who the authority belongs to, e.g. "alice@laptop".
+func owner() string {
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	host, _ := os.Hostname()
+	if host == "" {
+		return u.Username
+	}
+	return u.Username + "@" + host
+}
+
+// Issue signs a certificate for hosts: DNS names, wildcards such as
+// *.app.test, and IP addresses.
+func (a *Authority) Issue(hosts []string) (*Leaf, error) {
+	if len(hosts) == 0 {
+		return nil, errors.New("a certificate needs at least one host")
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := newSerial()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: hosts[0], Organization: []string{"devpt development certificate"}},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(leafValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+			continue
+		}
+		if err := ValidHost(h); err != nil {
+			return nil, err
+		}
+		tmpl.DNSNames = append(tmpl.DNSNames, strings.ToLower(h))
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, a.Cert, key.Public(), a.key)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Leaf{CertPEM: encodeCert(der), KeyPEM: keyPEM}, nil
+}
+
+// ValidHost checks that h can be a DNS name of a certificate.
+func ValidHost(h string) error {
+	name := strings.TrimPrefix(h, "*.")
+	if name == "" || len(name) > 253 {
+		return fmt.Errorf("invalid host %q", h)
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return fmt.Errorf("invalid host %q", h)
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+				return fmt.Errorf("invalid host %q", h)
+			}
+		}
+	}
+	return nil
+}
+
+// Write saves leaf in the authority's directory as <name>.pem and
+// <name>-key.pem and returns their paths.
+func (a *Authority) Write(name string, leaf *Leaf) (certPath, keyPath string, err error) {
+	if name == "" || name == strings.TrimSuffix(caCertFile, ".pem") || strings.ContainsAny(name, `/\`) {
+		return "", "", fmt.Errorf("invalid certificate name %q", name)
+	}
+	certPath = filepath.Join(a.dir, name+".pem")
+	keyPath = filepath.Join(a.dir, name+keySuffix)
+	if err := os.WriteFile(keyPath, leaf.KeyPEM, 0o600); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(certPath, leaf.CertPEM, 0o644); err != nil {
+		return "", "", err
+	}
+	return certPath, keyPath, nil
+}
+
+// List returns the certificates written to dir, by name.
+func List(dir string) ([]Issued, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	var out []Issued
+	for _, path := range paths {
+		base := filepath.Base(path)
+		if base == caCertFile || base == caKeyFile || strings.HasSuffix(base, keySuffix) {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		block, _ := pem.Decode(data)
+		if block == nil || block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		name := strings.TrimSuffix(base, ".pem")
+		issued := Issued{Name: name, Hosts: append([]string(nil), cert.DNSNames...), NotAfter: cert.NotAfter, Path: path, KeyPath: filepath.Join(dir, name+keySuffix)}
+		for _, ip := range cert.IPAddresses {
+			issued.Hosts = append(issued.Hosts, ip.String())
+		}
+		out = append(out, issued)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// TLSConfig returns a server configuration that presents a certificate for
+// the name each client asks for when allow accepts it, and one for
+// localhost otherwise. Certificates are issued on first use and kept.
+func (a *Authority) TLSConfig(allow func(name string) bool) *tls.Config {
+	var mu sync.Mutex
+	cache := make(map[string]*tls.Certificate)
+	get := func(name string) (*tls.Certificate, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if cert, ok := cache[name]; ok {
+			return cert, nil
+		}
+		hosts := []string{name}
+		if name == "localhost" {
+			hosts = append(hosts, "127.0.0.1", "::1")
+		}
+		leaf, err := a.Issue(hosts)
+		if err != nil {
+			return nil, err
+		}
+		cert, err := tls.X509KeyPair(leaf.CertPEM, leaf.KeyPEM)
+		if err != nil {
+			return nil, err
+		}
+		cache[name] = &cert
+		return &cert, nil
+	}
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			name := strings.TrimSuffix(strings.ToLower(hello.ServerName), ".")
+			if name == "" || ValidHost(name) != nil || !allow(name) {
+				name = "localhost"
+			}
+			return get(name)
+		},
+	}
+}
+
+func newSerial() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+func encodeCert(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func encodeKey(key *ecdsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
@@ -0,0 +1,107 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuthorityIssuesTrustedCertificates(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	if _, err := Load(dir); !errors.Is(err, ErrNoAuthority) {
+		t.Fatalf("Load of an empty dir = %v, want ErrNoAuthority", err)
+	}
+	ca, created, err := LoadOrCreate(dir)
+	if err != nil || !created {
+		t.Fatalf("LoadOrCreate = %v, %v", created, err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, caKeyFile)); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("authority key mode = %v, %v", info, err)
+	}
+	again, created, err := LoadOrCreate(dir)
+	if err != nil || created || !again.Cert.Equal(ca.Cert) {
+		t.Fatalf("second LoadOrCreate = %v, %v; want the same authority", created, err)
+	}
+
+	leaf, err := ca.Issue([]string{"app.test", "*.app.test", "127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(leaf.CertPEM)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+	for _, host := range []string{"app.test", "api.app.test", "127.0.0.1"} {
+		if _, err := cert.Verify(x509.VerifyOptions{DNSName: host, Roots: roots}); err != nil {
+			t.Errorf("certificate does not verify for %s: %v", host, err)
+		}
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{DNSName: "other.test", Roots: roots}); err == nil {
+		t.Error("certificate verifies for a host it was not issued for")
+	}
+
+	if _, _, err := ca.Write("app.test+2", leaf); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ca.Write("ca", leaf); err == nil {
+		t.Error("a certificate overwrote the authority")
+	}
+	issued, err := List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issued) != 1 || issued[0].Name != "app.test+2" || strings.Join(issued[0].Hosts, ",") != "app.test,*.app.test,127.0.0.1" {
+		t.Errorf("List = %+v", issued)
+	}
+}
+
+func TestIssueRejectsInvalidHosts(t *testing.T) {
+	t.Parallel()
+	ca, _, err := LoadOrCreate(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, hosts := range [][]string{nil, {"bad host"}, {"-x.test"}, {"a..test"}} {
+		if _, err := ca.Issue(hosts); err == nil {
+			t.Errorf("Issue(%q) succeeded", hosts)
+		}
+	}
+}
+
+func TestTLSConfigIssuesPerName(t *testing.T) {
+	t.Parallel()
+	ca, _, err := LoadOrCreate(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = ca.TLSConfig(func(name string) bool { return strings.HasSuffix(name, ".localhost") })
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+	for _, name := range []string{"api.localhost", "localhost"} {
+		conn, err := tls.Dial("tcp", srv.Listener.Addr().String(), &tls.Config{RootCAs: roots, ServerName: name})
+		if err != nil {
+			t.Errorf("handshake as %s: %v", name, err)
+			continue
+		}
+		conn.Close()
+	}
+	if conn, err := tls.Dial("tcp", srv.Listener.Addr().String(), &tls.Config{RootCAs: roots, ServerName: "example.com"}); err == nil {
+		conn.Close()
+		t.Error("a name the config does not allow got its own certificate")
+	}
+}
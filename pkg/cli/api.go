@@ -0,0 +1,487 @@
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devports/devpt/pkg/health"
+	"github.com/devports/devpt/pkg/models"
+)
+
+// DefaultAPIAddr is where devpt api serve listens unless told otherwise.
+const DefaultAPIAddr = "127.0.0.1:7070"
+
+// APIServer is a server as the REST API reports it.
+type APIServer struct {
+	Name        string        `json:"name"`
+	Managed     bool          `json:"managed"`
+	Source      models.Source `json:"source"`
+	Status      string        `json:"status"`
+	Port        int           `json:"port,omitempty"`
+	PID         int           `json:"pid,omitempty"`
+	Command     string        `json:"command,omitempty"`
+	CWD         string        `json:"cwd,omitempty"`
+	Project     string        `json:"project,omitempty"`
+	URL         string        `json:"url,omitempty"`
+	Framework   string        `json:"framework,omitempty"`
+	Agent       string        `json:"agent,omitempty"`
+	CrashReason string        `json:"crash_reason,omitempty"`
+	Health      *APIHealth    `json:"health,omitempty"`
+}
+
+// APIHealth is the result of a health check.
+type APIHealth struct {
+	Status     health.HealthStatus `json:"status"`
+	ResponseMs int                 `json:"response_ms"`
+	Message    string              `json:"message"`
+	CheckedAt  time.Time           `json:"checked_at"`
+}
+
+// apiActionResult is the answer to a start, stop or restart.
+type apiActionResult struct {
+	OK     bool   `json:"ok"`
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// APILogs is the tail of a service's log.
+type APILogs struct {
+	Service string   `json:"service"`
+	Lines   []string `json:"lines"`
+}
+
+// newAPIServer describes srv for the API.
+func newAPIServer(srv *models.ServerInfo) APIServer {
+	out := APIServer{Source: srv.Source, Status: srv.Status, CrashReason: srv.CrashReason}
+	if svc := srv.ManagedService; svc != nil {
+		out.Name = svc.Name
+		out.Managed = true
+		out.Command = svc.CommandSummary()
+		out.CWD = svc.CWD
+		if ports := svc.ActivePorts(); len(ports) > 0 {
+			out.Port = ports[0]
+		}
+		if svc.LastPID != nil && !isCrashStatus(srv.Status) && srv.Status != "stopped" {
+			out.PID = *svc.LastPID
+		}
+	}
+	if rec := srv.ProcessRecord; rec != nil {
+		if out.Name == "" {
+			out.Name = unmanagedName(rec)
+			out.Command = rec.Command
+			out.CWD = rec.CWD
+		}
+		out.Port = rec.Port
+		out.PID = rec.PID
+		out.Project = rec.ProjectRoot
+		out.Framework = rec.Stack()
+		if rec.AgentTag != nil {
+			out.Agent = rec.AgentTag.AgentName
+		}
+	}
+	if urls, err := serverURLs(srv); err == nil && len(urls) > 0 && out.Port > 0 {
+		out.URL = urls[0].URL
+	}
+	return out
+}
+
+func newAPIHealth(check *health.HealthCheck) *APIHealth {
+	if check == nil {
+		return nil
+	}
+	return &APIHealth{Status: check.Status, ResponseMs: check.ResponseMs, Message: check.Message, CheckedAt: check.LastCheck}
+}
+
+// apiServers discovers the servers, every listener when all is set, and
+// describes them for the API in list order.
+func (a *App) apiServers(ctx context.Context, all bool) ([]APIServer, error) {
+	showAll := a.showAll
+	a.showAll = all
+	defer func() { a.showAll = showAll }()
+	servers, err := a.discoverServersContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(servers, func(i, j int) bool { return serverLess(servers[i], servers[j]) })
+	out := make([]APIServer, 0, len(servers))
+	for _, srv := range servers {
+		out = append(out, newAPIServer(srv))
+	}
+	return out, nil
+}
+
+// apiHealth probes the server named by id, a managed service name or a
+// port.
+func (a *App) apiHealth(ctx context.Context, id string) (*APIHealth, error) {
+	servers, err := a.discoverServersContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	srv := findServer(servers, id)
+	if srv == nil {
+		return nil, &NotFoundError{Kind: "server", Name: id}
+	}
+	check := newAPIHealth(a.checkHealth(ctx, srv))
+	if check == nil {
+		return nil, fmt.Errorf("%s is %s; nothing to check", id, srv.Status)
+	}
+	return check, nil
+}
+
+// apiHandler serves the REST API from an App. The App is not safe for
+// concurrent use, so handlers take turns with it.
+type apiHandler struct {
+	app *App
+	mu  sync.Mutex
+}
+
+// APIHandler returns the REST API devpt api serve serves on addr. Actions
+// (POST requests) need token as a bearer token.
+func (a *App) APIHandler(addr, token string) http.Handler {
+	h := &apiHandler{app: a}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/servers", h.listServers)
+	mux.HandleFunc("GET /api/servers/{id}", h.getServer)
+	mux.HandleFunc("GET /api/servers/{id}/health", h.getHealth)
+	mux.HandleFunc("POST /api/services/{name}/start", h.action(DaemonStart))
+	mux.HandleFunc("POST /api/services/{name}/stop", h.action(DaemonStop))
+	mux.HandleFunc("POST /api/services/{name}/restart", h.action(DaemonRestart))
+	mux.HandleFunc("POST /api/ports/{port}/stop", h.stopPort)
+	mux.HandleFunc("GET /api/services/{name}/logs", h.logs)
+	return localOnly(mux, addr, token)
+}
+
+// localOnly rejects requests that web pages can make: those naming another
+// site as Host, which is what a DNS-rebinding page sends, those from pages
+// of other origins, which browsers send to localhost too, and actions
+// without the token, which pages cannot read.
+func localOnly(next http.Handler, addr, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isAllowedHost(r.Host, addr) {
+			writeAPIError(w, http.StatusForbidden, fmt.Errorf("host %s is not allowed", r.Host))
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" && !isLoopbackOrigin(origin) {
+			writeAPIError(w, http.StatusForbidden, fmt.Errorf("origin %s is not allowed", origin))
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				writeAPIError(w, http.StatusUnauthorized, errors.New("actions need the API token as Authorization: Bearer TOKEN"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isAllowedHost reports whether a request's Host header names this machine:
+// localhost, a loopback IP or the host of addr, the address the API listens
+// on. Listening beyond loopback, any IP is allowed too; a rebinding page
+// cannot send one, as its Host is the name it was loaded from.
+func isAllowedHost(hostport, addr string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip != nil && ip.IsLoopback() {
+		return true
+	}
+	listen, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if listen != "" && strings.EqualFold(host, listen) {
+		return true
+	}
+	listenIP := net.ParseIP(listen)
+	return ip != nil && (listen == "" || (listenIP != nil && !listenIP.IsLoopback()))
+}
+
+// isLoopbackOrigin reports whether origin is a page served from this machine.
+func isLoopbackOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// loadAPIToken returns the API token stored at path, creating a random one
+// readable only by the user the first time.
+func loadAPIToken(path string) (string, error) {
+	if data, err := os.ReadFile(path); err == nil {
+		if token := strings.TrimSpace(string(data)); token != "" {
+			return token, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(token+"\n"), 0o600); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// apiStatus maps a command error to an HTTP status.
+func apiStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrServiceNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrAlreadyRunning), errors.Is(err, ErrPortConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrNeedSudo):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+// discover runs discovery with ?all=1 honored, abandoning it when the
+// client goes away.
+func (h *apiHandler) discover(r *http.Request) ([]*models.ServerInfo, error) {
+	all := r.URL.Query().Get("all")
+	showAll := h.app.showAll
+	h.app.showAll = all == "1" || all == "true"
+	defer func() { h.app.showAll = showAll }()
+	return h.app.discoverServersContext(r.Context())
+}
+
+func (h *apiHandler) listServers(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	servers, err := h.discover(r)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	sort.SliceStable(servers, func(i, j int) bool { return serverLess(servers[i], servers[j]) })
+	out := make([]APIServer, 0, len(servers))
+	for _, srv := range servers {
+		out = append(out, newAPIServer(srv))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// findServer looks up the server named by the id path value, a managed
+// service name or a port, and writes a 404 when there is none.
+func (h *apiHandler) findServer(w http.ResponseWriter, r *http.Request) *models.ServerInfo {
+	servers, err := h.discover(r)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return nil
+	}
+	id := r.PathValue("id")
+	srv := findServer(servers, id)
+	if srv == nil {
+		writeAPIError(w, http.StatusNotFound, &NotFoundError{Kind: "server", Name: id})
+	}
+	return srv
+}
+
+func (h *apiHandler) getServer(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	srv := h.findServer(w, r)
+	if srv == nil {
+		return
+	}
+	out := newAPIServer(srv)
+	out.Health = newAPIHealth(h.app.checkHealth(r.Context(), srv))
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (h *apiHandler) getHealth(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	srv := h.findServer(w, r)
+	if srv == nil {
+		return
+	}
+	check := newAPIHealth(h.app.checkHealth(r.Context(), srv))
+	if check == nil {
+		writeAPIError(w, http.StatusConflict, fmt.Errorf("%s is %s; nothing to check", r.PathValue("id"), srv.Status))
+		return
+	}
+	writeJSON(w, http.StatusOK, check)
+}
+
+// run runs a command for the API and writes its output and outcome.
+func (h *apiHandler) run(w http.ResponseWriter, fn func() error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	stdout, stderr, err := h.app.captureOutput(fn)
+	res := apiActionResult{OK: err == nil, Output: stdout + stderr}
+	if err != nil {
+		res.Error = err.Error()
+		writeJSON(w, apiStatus(err), res)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// action returns the handler that starts, stops or restarts a service.
+func (h *apiHandler) action(op string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		h.run(w, func() error {
+			a := h.app
+			if a.registry.GetService(name) == nil {
+				// Ports are stopped through /api/ports.
+				return errServiceNotFound(name)
+			}
+			switch op {
+			case DaemonStart:
+				return a.StartServiceCmd(name, StartOptions{Force: r.URL.Query().Get("force") == "1", via: models.ViaAPI})
+			case DaemonStop:
+				return a.StopServiceCmd(name, StopOptions{})
+			default:
+				return a.RestartCmd(name)
+			}
+		})
+	}
+}
+
+func (h *apiHandler) stopPort(w http.ResponseWriter, r *http.Request) {
+	port, err := strconv.Atoi(r.PathValue("port"))
+	if err != nil || port <= 0 || port > 65535 {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid port: %s", r.PathValue("port")))
+		return
+	}
+	h.run(w, func() error { return h.app.StopServiceCmd(strconv.Itoa(port), StopOptions{}) })
+}
+
+// logs returns the last ?lines=N lines of a service's log as JSON, or with
+// ?follow=1 streams them and everything written after as server-sent
+// events until the client goes away.
+func (h *apiHandler) logs(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	lines := 50
+	if raw := r.URL.Query().Get("lines"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid lines: %s", raw))
+			return
+		}
+		lines = n
+	}
+
+	h.mu.Lock()
+	if h.app.registry.GetService(name) == nil {
+		h.mu.Unlock()
+		writeAPIError(w, http.StatusNotFound, errServiceNotFound(name))
+		return
+	}
+	mgr := h.app.processManager
+	tail, err := mgr.TailContext(r.Context(), name, lines)
+	var updates <-chan string
+	var stop func()
+	follow := r.URL.Query().Get("follow")
+	if follow == "1" || follow == "true" {
+		updates, stop = mgr.Follow(name)
+		defer stop()
+	}
+	h.mu.Unlock()
+	if err != nil && updates == nil {
+		writeAPIError(w, http.StatusNotFound, err)
+		return
+	}
+
+	if updates == nil {
+		if tail == nil {
+			tail = []string{}
+		}
+		writeJSON(w, http.StatusOK, APILogs{Service: name, Lines: tail})
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeAPIError(w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	for _, line := range tail {
+		fmt.Fprintf(w, "data: %s\n\n", line)
+	}
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case line, ok := <-updates:
+			if !ok {
+				return
+			}
+			fmt.Fprintf(w, "data: %s\n\n", line)
+			flusher.Flush()
+		}
+	}
+}
+
+// APIServeCmd serves the REST API on addr until interrupted.
+func (a *App) APIServeCmd(addr string) error {
+	if addr == "" {
+		addr = DefaultAPIAddr
+	}
+	token, err := loadAPIToken(a.config.APITokenFile)
+	if err != nil {
+		return fmt.Errorf("failed to load the API token: %w", err)
+	}
+
+	a.SetVia(models.ViaAPI)
+	// Nobody is at a terminal to answer prompts.
+	a.SetNonInteractive(false)
+	srv := &http.Server{Handler: a.APIHandler(addr, token)}
+	return a.serveLocal(addr, srv, "the API", "anyone there can list servers and read logs", func(listening net.Addr) {
+		fmt.Fprintf(a.out(), "devpt API listening on http://%s\n", listening)
+		fmt.Fprintf(a.out(), "Actions need the token in %s\n", a.config.APITokenFile)
+	})
+}
@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/process"
+	"github.com/devports/devpt/pkg/registry"
+)
+
+// apiTestAddr and apiTestToken are what the test handlers are served with.
+const (
+	apiTestAddr  = "127.0.0.1:7070"
+	apiTestToken = "test-token"
+)
+
+// newAPITestApp returns an app with one registered service, "web", whose
+// log holds the given lines.
+func newAPITestApp(t *testing.T, lines ...string) *App {
+	t.Helper()
+	dir := t.TempDir()
+	reg := registry.NewRegistry(filepath.Join(dir, "registry.json"))
+	if err := reg.AddService(&models.ManagedService{Name: "web", CWD: dir, Command: "npm run dev"}); err != nil {
+		t.Fatal(err)
+	}
+	logsDir := filepath.Join(dir, "logs")
+	if err := os.MkdirAll(filepath.Join(logsDir, "web"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(logsDir, "web", "20261017-120000.log"), []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return &App{registry: reg, processManager: process.NewManager(logsDir)}
+}
+
+func TestAPILogsReturnsTail(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(newAPITestApp(t, "one", "two", "three").APIHandler(apiTestAddr, apiTestToken))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/services/web/logs?lines=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Service string   `json:"service"`
+		Lines   []string `json:"lines"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || body.Service != "web" || !reflect.DeepEqual(body.Lines, []string{"two", "three"}) {
+		t.Fatalf("unexpected response %d %+v", resp.StatusCode, body)
+	}
+}
+
+func TestLogsCmdPrintsAPIJSON(t *testing.T) {
+	t.Parallel()
+
+	app := newAPITestApp(t, "one", "two", "three")
+	var out bytes.Buffer
+	app.SetOutput(&out, io.Discard)
+	if err := app.LogsCmd("web", 2, true); err != nil {
+		t.Fatal(err)
+	}
+	var logs APILogs
+	if err := json.Unmarshal(out.Bytes(), &logs); err != nil {
+		t.Fatalf("%v: %q", err, out.String())
+	}
+	if logs.Service != "web" || !reflect.DeepEqual(logs.Lines, []string{"two", "three"}) {
+		t.Fatalf("logs = %+v", logs)
+	}
+}
+
+func TestAPILogsFollowStreamsEvents(t *testing.T) {
+	t.Parallel()
+
+	app := newAPITestApp(t, "old")
+	srv := httptest.NewServer(app.APIHandler(apiTestAddr, apiTestToken))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/services/web/logs?lines=1&follow=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	path, err := app.processManager.LatestLogPath("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString("new\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() && len(got) < 2 {
+			if data, ok := strings.CutPrefix(sc.Text(), "data: "); ok {
+				got = append(got, data)
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for streamed lines")
+	}
+	if !reflect.DeepEqual(got, []string{"old", "new"}) {
+		t.Fatalf("streamed %q", got)
+	}
+}
+
+func TestAPIErrorsMapToStatuses(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(newAPITestApp(t).APIHandler(apiTestAddr, apiTestToken))
+	defer srv.Close()
+
+	cases := []struct {
+		method, path, origin, host, token string
+		want                              int
+	}{
+		{http.MethodPost, "/api/services/missing/start", "", "", apiTestToken, http.StatusNotFound},
+		{http.MethodGet, "/api/services/missing/logs", "", "", "", http.StatusNotFound},
+		{http.MethodPost, "/api/ports/notaport/stop", "", "", apiTestToken, http.StatusBadRequest},
+		{http.MethodGet, "/api/services/web/logs?lines=x", "", "", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/services/web/logs", "https://evil.example", "", "", http.StatusForbidden},
+		{http.MethodGet, "/api/services/web/logs", "http://localhost:5173", "", "", http.StatusOK},
+		{http.MethodGet, "/api/servers", "", "evil.example:7070", "", http.StatusForbidden},
+		{http.MethodGet, "/api/services/web/logs", "", "localhost:7070", "", http.StatusOK},
+		{http.MethodPost, "/api/services/web/stop", "", "", "", http.StatusUnauthorized},
+		{http.MethodPost, "/api/services/web/stop", "", "", "wrong", http.StatusUnauthorized},
+	}
+	for _, c := range cases {
+		req, err := http.NewRequest(c.method, srv.URL+c.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.origin != "" {
+			req.Header.Set("Origin", c.origin)
+		}
+		if c.host != "" {
+			req.Host = c.host
+		}
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != c.want {
+			t.Errorf("%s %s (origin %q, host %q) = %d, want %d", c.method, c.path, c.origin, c.host, resp.StatusCode, c.want)
+		}
+	}
+}
+
+func TestAPIAllowedHosts(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		host, addr string
+		want       bool
+	}{
+		{"localhost:7070", "127.0.0.1:7070", true},
+		{"127.0.0.1:7070", "127.0.0.1:7070", true},
+		{"[::1]:7070", "127.0.0.1:7070", true},
+		{"evil.example:7070", "127.0.0.1:7070", false},
+		{"192.168.64.3:7070", "127.0.0.1:7070", false},
+		{"192.168.64.3:7070", "192.168.64.3:7070", true},
+		{"192.168.64.3:7070", ":7070", true},
+		{"devbox:7070", "devbox:7070", true},
+		{"evil.example:7070", ":7070", false},
+	}
+	for _, c := range cases {
+		if got := isAllowedHost(c.host, c.addr); got != c.want {
+			t.Errorf("isAllowedHost(%q, %q) = %v, want %v", c.host, c.addr, got, c.want)
+		}
+	}
+}
+
+func TestLoadAPITokenKeepsTheToken(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "api-token")
+	first, err := loadAPIToken(path)
+	if err != nil || len(first) != 64 {
+		t.Fatalf("loadAPIToken = %q, %v", first, err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0o600 {
+		t.Fatalf("token file mode = %v, want 0600", fi.Mode().Perm())
+	}
+	if again, err := loadAPIToken(path); err != nil || again != first {
+		t.Fatalf("second loadAPIToken = %q, %v; want %q", again, err, first)
+	}
+}
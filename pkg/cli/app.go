@@ -1,18 +1,27 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"os/user"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/devports/devpt/pkg/devpt"
+	"github.com/devports/devpt/pkg/events"
 	"github.com/devports/devpt/pkg/health"
+	"github.com/devports/devpt/pkg/history"
 	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/notify"
 	"github.com/devports/devpt/pkg/process"
 	"github.com/devports/devpt/pkg/registry"
 	"github.com/devports/devpt/pkg/scanner"
+	"github.com/devports/devpt/pkg/telemetry"
 )
 
 var warnLegacyCommandsOnce sync.Once
@@ -20,12 +29,107 @@ var warnLegacyCommandsOnce sync.Once
 // App is the main application handler
 type App struct {
 	config         models.ConfigPaths
+	settings       *models.Config
 	registry       *registry.Registry
 	scanner        *scanner.ProcessScanner
 	resolver       *scanner.ProjectResolver
 	detector       *scanner.AgentDetector
+	containers     *scanner.ContainerResolver
+	filter         *scanner.DevFilter
 	processManager *process.Manager
+	jobLogs        *process.Manager // runs jobs; their logs are kept apart from services'
 	healthChecker  *health.Checker
+	events         *events.Log
+	history        *history.Store
+	notifier       *notify.Dispatcher
+	desktop        *notify.Desktop
+	// desktopArmed is set while the TUI's terminal has lost focus; desktop
+	// alerts are only raised then.
+	desktopArmed bool
+	// showAll bypasses the dev-process filter so every listener is reported.
+	showAll bool
+	// enriched holds records from the previous discovery whose project root,
+	// agent tag and framework are already resolved; the scanner reuses their
+	// pointers.
+	enriched  map[*models.ProcessRecord]bool
+	scanStats scanner.ScanStats
+	// via is the interface runs are started from (models.ViaCLI unless set);
+	// actor caches the rest of the run actor.
+	via   string
+	actor *models.RunActor
+	// reconciledAt is when recorded PIDs were last reconciled; zero until
+	// NewApp does it the first time.
+	reconciledAt time.Time
+	// sched tracks scheduled runs while this process runs schedules.
+	sched scheduler
+	// resources keeps the previous resource samples of running services.
+	resources resourceMonitor
+	// nonInteractive disables prompts and the TUI; assumeYes answers the
+	// prompts with yes instead of no.
+	nonInteractive bool
+	assumeYes      bool
+	// theme overrides tui.theme; noColor turns the TUI's colors off.
+	theme   string
+	noColor bool
+	// textIcons shows health as words instead of emoji, over health.icons.
+	textIcons bool
+	// project scopes the service names commands are given; see SetProject.
+	project string
+	// daemon is set while the app is a client of devpt daemon; discovery,
+	// starts and stops then go through it.
+	daemon     *daemonClient
+	includeUDP bool
+	// tracer exports spans of scans, starts, stops and health checks; nil
+	// unless telemetry is configured.
+	tracer *telemetry.Tracer
+	// stdout and stderr are where commands print; os.Stdout and os.Stderr
+	// unless set. captureOutput points them at buffers for one call.
+	stdout, stderr io.Writer
+	outputMu       sync.Mutex
+}
+
+// NonInteractiveEnv is the environment variable that turns on
+// non-interactive mode, like --non-interactive.
+const NonInteractiveEnv = "DEVPT_NONINTERACTIVE"
+
+// NonInteractiveFromEnv reports whether NonInteractiveEnv asks for
+// non-interactive mode: set to anything but "", "0" or "false".
+func NonInteractiveFromEnv() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(NonInteractiveEnv))) {
+	case "", "0", "false", "no":
+		return false
+	}
+	return true
+}
+
+// SetNonInteractive makes the app suitable for scripts and CI: it never
+// prompts, answering yes with assumeYes and no otherwise, refuses to open
+// the TUI, and keeps its output plain (no emoji, rows in a stable order).
+func (a *App) SetNonInteractive(assumeYes bool) {
+	a.nonInteractive = true
+	a.assumeYes = assumeYes
+}
+
+// SetOutput makes commands print to stdout and stderr instead of the
+// process's standard output and error.
+func (a *App) SetOutput(stdout, stderr io.Writer) {
+	a.stdout, a.stderr = stdout, stderr
+}
+
+// out is where commands print their output.
+func (a *App) out() io.Writer {
+	if a.stdout != nil {
+		return a.stdout
+	}
+	return os.Stdout
+}
+
+// errOut is where commands print warnings.
+func (a *App) errOut() io.Writer {
+	if a.stderr != nil {
+		return a.stderr
+	}
+	return os.Stderr
 }
 
 // NewApp creates and initializes the application
@@ -47,156 +151,487 @@ func NewApp() (*App, error) {
 		warnLegacyManagedCommands(reg, os.Stderr)
 	})
 
-	return &App{
+	settings, err := models.LoadConfig(config.ConfigFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v (using defaults)\n", err)
+	}
+
+	app := &App{
 		config:         config,
+		settings:       settings,
 		registry:       reg,
 		scanner:        scanner.NewProcessScanner(),
 		resolver:       scanner.NewProjectResolver(),
-		detector:       scanner.NewAgentDetector(),
+		detector:       scanner.NewAgentDetector(settings.Agents),
+		containers:     scanner.NewContainerResolver(),
+		filter:         scanner.NewDevFilter(settings.Scan.Include, settings.Scan.Exclude),
 		processManager: process.NewManager(config.LogsDir),
-		healthChecker:  health.NewChecker(0),
-	}, nil
+		jobLogs:        process.NewManager(config.JobLogsDir),
+		events:         events.NewLog(config.EventsFile),
+		history:        history.NewStore(config.HistoryDir),
+	}
+	if err := app.scanner.SetBackend(settings.Scan.Backend); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v (using %s)\n", err, app.scanner.Backend())
+	}
+	app.tracer, err = telemetry.New(settings.Telemetry, os.Getenv, func(err error) {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v (not exporting traces)\n", err)
+	}
+	app.healthChecker = app.newHealthChecker(0)
+	app.notifier, err = notify.NewDispatcher(settings.Webhooks, func(err error) {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	app.desktop = notify.NewDesktop(settings.Notifications)
+	if exe, err := os.Executable(); err == nil {
+		app.processManager.SetSupervisor(exe)
+	}
+	boot, _ := process.BootTime()
+	for _, note := range app.reconcilePIDs(time.Now(), boot) {
+		fmt.Fprintf(os.Stderr, "Note: %s\n", note)
+	}
+	return app, nil
+}
+
+// SetVia records the interface this App serves, e.g. models.ViaTUI, for
+// the runs it starts.
+func (a *App) SetVia(via string) {
+	a.via = via
+}
+
+// runActor describes who is starting a run: the OS user, the AI agent
+// devpt itself runs under (if any) and the interface used.
+func (a *App) runActor(via string) models.RunActor {
+	if a.actor == nil {
+		actor := models.RunActor{}
+		if u, err := user.Current(); err == nil {
+			actor.User = u.Username
+		} else {
+			actor.User = os.Getenv("USER")
+		}
+		if a.detector != nil {
+			actor.Agent = a.detector.AncestorAgent(os.Getpid(), os.Getppid())
+		}
+		a.actor = &actor
+	}
+	actor := *a.actor
+	switch {
+	case via != "":
+		actor.Via = via
+	case a.via != "":
+		actor.Via = a.via
+	default:
+		actor.Via = models.ViaCLI
+	}
+	return actor
+}
+
+// notifyFlushTimeout bounds how long Close waits for webhook deliveries.
+const notifyFlushTimeout = 5 * time.Second
+
+// Close waits briefly for in-flight notifications so that a short-lived
+// command that noticed a crash still delivers its webhooks.
+func (a *App) Close() {
+	a.sched.release()
+	if a.daemon != nil {
+		a.daemon.rpc.Close()
+	}
+	a.notifier.Wait(notifyFlushTimeout)
+	a.desktop.Wait(notifyFlushTimeout)
+	a.tracer.Close(notifyFlushTimeout)
+}
+
+// notifying reports whether any notification channel is active.
+func (a *App) notifying() bool {
+	return a.notifier.Enabled() || (a.desktopArmed && a.desktop.Enabled())
+}
+
+// deliver sends n to webhooks and, while armed, to desktop alerts.
+func (a *App) deliver(n notify.Notification) {
+	a.notifier.Notify(n)
+	if a.desktopArmed {
+		a.desktop.Notify(n)
+	}
+}
+
+// newHealthChecker creates a checker that honors the configured thresholds.
+func (a *App) newHealthChecker(timeout time.Duration) *health.Checker {
+	c := health.NewChecker(timeout)
+	if a.settings != nil {
+		c.SetThresholds(health.Thresholds{
+			Slow:    a.settings.Health.SlowThreshold.Std(),
+			Timeout: a.settings.Health.TimeoutThreshold.Std(),
+		})
+	}
+	if a.tracer != nil {
+		c.SetObserver(a.traceHealth)
+	}
+	return c
 }
 
 // discoverServers combines scanning and detection into complete server info
 func (a *App) discoverServers() ([]*models.ServerInfo, error) {
-	processes, err := a.scanner.ScanListeningPorts()
+	return a.discoverServersContext(context.Background())
+}
+
+// discoverServersContext is discoverServers, abandoning the scan once ctx
+// is done.
+func (a *App) discoverServersContext(ctx context.Context) ([]*models.ServerInfo, error) {
+	scan, err := a.scanServers(ctx, a.daemon)
 	if err != nil {
-		return nil, fmt.Errorf("failed to scan processes: %w", err)
+		return nil, err
 	}
+	return a.assembleServers(ctx, scan)
+}
 
-	// Filter to keep only development processes
-	commandMap := a.getCommandMap(processes)
-	processes = scanner.FilterDevProcesses(processes, commandMap)
+// serverScan is what the slow half of discovery found: the servers the
+// daemon reported, or the listeners on this machine.
+type serverScan struct {
+	start     time.Time
+	servers   []*models.ServerInfo
+	records   []*models.ProcessRecord
+	stats     scanner.ScanStats
+	daemonErr error // the daemon failed; discovery falls back to scanning
+}
 
-	for _, proc := range processes {
-		if proc.CWD != "" {
-			proc.ProjectRoot = a.resolver.FindProjectRoot(proc.CWD)
+// scanServers asks daemon, when set, for the servers, or else scans for
+// listeners. Unlike the rest of discovery it touches no App state besides
+// the scanner, so the TUI runs it off its goroutine, canceling it with ctx.
+func (a *App) scanServers(ctx context.Context, daemon *daemonClient) (scan *serverScan, err error) {
+	ctx, span := a.tracer.Start(ctx, "devpt.scan")
+	defer func() {
+		if scan != nil {
+			span.SetAttributes(telemetry.Int("devpt.listeners", len(scan.records)+len(scan.servers)), telemetry.Bool("devpt.daemon", scan.servers != nil))
+		}
+		span.End(err)
+	}()
+	scan = &serverScan{start: time.Now()}
+	if daemon != nil {
+		servers, stats, err := daemon.servers(ctx, DaemonServersArgs{All: a.showAll, UDP: a.includeUDP})
+		if err == nil {
+			scan.servers, scan.stats = servers, stats
+			return scan, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
 		}
-		a.detector.EnrichProcessRecord(proc)
+		scan.daemonErr = err
+		return scan, nil
 	}
 
-	var servers []*models.ServerInfo
-
-	for _, proc := range processes {
-		source := models.SourceManual
-		if proc.AgentTag != nil {
-			source = proc.AgentTag.Source
+	records, err := a.scanner.ScanListeningPortsContext(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
 		}
+		return nil, fmt.Errorf("failed to scan processes: %w", err)
+	}
+	scan.records = records
+	scan.stats = a.scanner.LastStats()
+	return scan, nil
+}
 
-		servers = append(servers, &models.ServerInfo{
-			ProcessRecord: proc,
-			Source:        source,
-			Status:        "running",
-		})
+// assembleServers turns a scan into servers: it resolves containers and
+// projects, detects agents and frameworks, and matches managed services.
+func (a *App) assembleServers(ctx context.Context, scan *serverScan) ([]*models.ServerInfo, error) {
+	if scan.daemonErr != nil {
+		if a.daemon != nil {
+			a.dropDaemon(scan.daemonErr)
+		}
+		return a.discoverServersContext(ctx)
 	}
+	if scan.servers != nil {
+		a.scanStats = scan.stats
+		return scan.servers, nil
+	}
+
+	a.maybeReconcilePIDs(scan.start)
+	defer func() {
+		a.scanStats = scan.stats
+		a.scanStats.Duration = time.Since(scan.start)
+	}()
+
+	processes := scan.records
+	// Resolve docker port proxies to their containers before filtering, so
+	// published container ports are kept.
+	a.containers.AnnotateContext(ctx, processes)
+	scanner.AnnotatePortForwards(processes)
+	scanner.AnnotateInfra(processes)
 
-	type managedIdentity struct {
-		cwd  string
-		root string
+	// Filter to keep only development processes
+	if !a.showAll {
+		processes = a.filter.Filter(processes)
 	}
 
-	managedServices := a.registry.ListServices()
-	portOwners := make(map[int][]*models.ManagedService)
-	rootOwners := make(map[string]int)
-	cwdOwners := make(map[string]int)
-	identities := make(map[*models.ManagedService]managedIdentity, len(managedServices))
-	for _, svc := range managedServices {
-		svcCWD := normalizePath(svc.CWD)
-		svcRoot := normalizePath(a.resolver.FindProjectRoot(svc.CWD))
-		identities[svc] = managedIdentity{
-			cwd:  svcCWD,
-			root: svcRoot,
+	enriched := make(map[*models.ProcessRecord]bool, len(processes))
+	for _, proc := range processes {
+		enriched[proc] = true
+		if !a.enriched[proc] {
+			devpt.Enrich(proc, a.resolver, a.detector, a.scanner)
 		}
-		if svcCWD != "" {
-			cwdOwners[svcCWD]++
+	}
+	a.enriched = enriched
+
+	servers := scanner.NewServers(processes)
+	now := time.Now()
+	for _, svc := range scanner.MatchManaged(servers, a.registry.ListServices(), a.resolver) {
+		alive := svc.LastPID != nil && *svc.LastPID > 0 && a.processManager.IsRunning(*svc.LastPID)
+		var exit *models.ExitStatus
+		fresh := false
+		if !alive {
+			exit, fresh = a.syncLastExit(svc)
 		}
-		if svcRoot != "" {
-			rootOwners[svcRoot]++
+		srv := &models.ServerInfo{
+			ManagedService: svc,
+			Source:         models.SourceManaged,
+			Status:         devpt.IdleStatus(svc, alive, exit, a.crashLoopSettings(), now),
 		}
-		for _, port := range svc.Ports {
-			portOwners[port] = append(portOwners[port], svc)
+		if alive {
+			if line, ok := a.processManager.ReadyMatch(svc.Name, svc.ReadyPatterns); ok {
+				srv.Status = "ready"
+				srv.ReadyLine = line
+			}
 		}
+		if isCrashStatus(srv.Status) {
+			srv.CrashReason, srv.CrashLogTail = a.getCrashReport(svc.Name, 12)
+			if exit != nil && exit.PID == *svc.LastPID {
+				// The supervisor saw the real exit; no need to guess from the log.
+				srv.CrashReason = describeExit(exit, now)
+			}
+		}
+		if fresh && svc.LastPID != nil && exit.PID == *svc.LastPID {
+			a.emitExit(svc, exit, srv.Status)
+		}
+		servers = append(servers, srv)
 	}
-	for _, svc := range managedServices {
-		found := false
-		identity := identities[svc]
-		svcCWD := identity.cwd
-		svcRoot := identity.root
 
-		for _, server := range servers {
-			if server.ProcessRecord == nil || server.ManagedService != nil {
-				continue
-			}
-			procCWD := normalizePath(server.ProcessRecord.CWD)
-			procRoot := normalizePath(server.ProcessRecord.ProjectRoot)
-			if canMatchByPath(svcRoot, svcCWD, procRoot, procCWD, rootOwners, cwdOwners) {
-				server.ManagedService = svc
-				found = true
-				break
-			}
+	for _, server := range servers {
+		if server.ManagedService != nil && server.ProcessRecord != nil && len(server.ManagedService.ReadyPatterns) > 0 {
+			server.ReadyLine, _ = a.processManager.ReadyMatch(server.ManagedService.Name, server.ManagedService.ReadyPatterns)
+		}
+		if server.ManagedService != nil && a.isPaused(server) {
+			server.Status = "paused"
 		}
+	}
 
-		if !found && len(svc.Ports) > 0 {
-			for _, port := range svc.Ports {
-				if owners := portOwners[port]; len(owners) != 1 {
-					continue
-				}
-				for _, server := range servers {
-					if server.ProcessRecord != nil && server.ProcessRecord.Port == port && server.ManagedService == nil {
-						procCWD := normalizePath(server.ProcessRecord.CWD)
-						procRoot := normalizePath(server.ProcessRecord.ProjectRoot)
-						if svcRoot != "" && procRoot != "" && svcRoot != procRoot {
-							continue
-						}
-						if svcCWD != "" && procCWD != "" && svcCWD != procCWD {
-							continue
-						}
-						server.ManagedService = svc
-						found = true
-						break
-					}
-				}
-				if found {
-					break
-				}
-			}
+	return servers, nil
+}
+
+func (a *App) logSettings() models.LogSettings {
+	if a.settings == nil {
+		return models.LogSettings{}.Effective()
+	}
+	return a.settings.Logs.Effective()
+}
+
+func (a *App) crashLoopSettings() models.CrashLoopSettings {
+	if a.settings == nil {
+		return models.CrashLoopSettings{}.Effective()
+	}
+	return a.settings.CrashLoop.Effective()
+}
+
+// noteCrashRestart records a restart when the previous run ended without devpt
+// stopping it, which is what crash-loop detection counts.
+func (a *App) noteCrashRestart(svc *models.ManagedService) {
+	if svc == nil || svc.LastPID == nil || *svc.LastPID <= 0 || a.processManager.IsRunning(*svc.LastPID) {
+		return
+	}
+	if err := a.registry.RecordCrashRestart(svc.Name, time.Now(), a.crashLoopSettings().Window.Std()); err != nil {
+		fmt.Fprintf(a.errOut(), "Warning: failed to record restart for %q: %v\n", svc.Name, err)
+	}
+}
+
+// syncLastExit copies the exit status recorded by the supervisor for the
+// service's latest run into the registry and returns it. fresh reports
+// whether this call is the first to observe that exit.
+func (a *App) syncLastExit(svc *models.ManagedService) (exit *models.ExitStatus, fresh bool) {
+	exit, err := a.processManager.LastExit(svc.Name)
+	if err != nil || exit == nil {
+		return svc.LastExit, false
+	}
+	if svc.LastExit != nil && svc.LastExit.PID == exit.PID && svc.LastExit.ExitedAt.Equal(exit.ExitedAt) {
+		return exit, false
+	}
+	if err := a.registry.RecordExit(svc.Name, exit); err != nil {
+		fmt.Fprintf(a.errOut(), "Warning: failed to record exit for %q: %v\n", svc.Name, err)
+	}
+	if err := a.registry.FinishRun(svc.Name, exit.PID, exit.Describe(), exit.ExitedAt); err != nil {
+		fmt.Fprintf(a.errOut(), "Warning: failed to record exit for %q: %v\n", svc.Name, err)
+	}
+	return exit, true
+}
+
+// emitExit records an event for a run that ended without devpt stopping it.
+func (a *App) emitExit(svc *models.ManagedService, exit *models.ExitStatus, status string) {
+	entry := history.Entry{Time: exit.ExitedAt, Action: history.Crash, PID: exit.PID, Reason: exit.Describe()}
+	if exit.Code == 0 {
+		entry.Action = history.Exit
+	}
+	if start, ok := runStart(svc, exit.PID); ok {
+		entry.Duration = models.Duration(exit.ExitedAt.Sub(start))
+	}
+	a.recordHistory(svc.Name, entry)
+
+	ev := events.Event{
+		Type:    events.ServiceCrashed,
+		Service: svc.Name,
+		PID:     exit.PID,
+		Message: exit.Describe(),
+		Data:    map[string]string{"code": strconv.Itoa(exit.Code), "status": status},
+	}
+	if exit.Code == 0 {
+		ev.Type = events.ServiceExited
+	}
+	if exit.Signal != "" {
+		ev.Data["signal"] = exit.Signal
+	}
+	a.emit(ev)
+
+	if exit.Code != 0 && a.notifying() {
+		n := notify.Notification{
+			Event:   models.WebhookOnCrashed,
+			Service: svc.Name,
+			Status:  status,
+			Reason:  exit.Describe(),
+			Time:    exit.ExitedAt,
+		}
+		if status == "crash-looping" {
+			n.Event = models.WebhookOnCrashLooping
+		}
+		if ports := svc.ActivePorts(); len(ports) > 0 {
+			n.Port = ports[0]
 		}
+		n.LogTail, _ = a.processManager.Tail(svc.Name, 10)
+		a.deliver(n)
+	}
+}
 
-		if !found && svc.LastPID != nil && *svc.LastPID > 0 {
-			for _, server := range servers {
-				if server.ProcessRecord == nil || server.ManagedService != nil || server.ProcessRecord.PID != *svc.LastPID {
-					continue
-				}
-				procCWD := normalizePath(server.ProcessRecord.CWD)
-				procRoot := normalizePath(server.ProcessRecord.ProjectRoot)
-				if serviceMatchesProcess(svc, server.ProcessRecord, svcRoot, procRoot, procCWD) {
-					server.ManagedService = svc
-					found = true
-					break
-				}
-			}
+// emitHealthChange records a health transition. The first result for a
+// port is not a transition and is skipped.
+func (a *App) emitHealthChange(serviceName string, port int, prev, next *health.HealthCheck) {
+	if prev == nil || next == nil || prev.Status == next.Status {
+		return
+	}
+	a.emit(events.Event{
+		Type:    events.HealthChanged,
+		Service: serviceName,
+		Port:    port,
+		Message: next.Message,
+		Data:    map[string]string{"from": string(prev.Status), "to": string(next.Status), "ms": strconv.Itoa(next.ResponseMs)},
+	})
+
+	if next.Status == health.HealthDown && a.notifying() {
+		n := notify.Notification{
+			Event:   models.WebhookOnHealthDown,
+			Service: serviceName,
+			Port:    port,
+			Status:  string(next.Status),
+			Reason:  next.Message,
+			Time:    next.LastCheck,
+		}
+		if serviceName != "" {
+			n.LogTail, _ = a.processManager.Tail(serviceName, 10)
 		}
+		a.deliver(n)
+	}
+}
 
-		if !found {
-			status := "stopped"
-			crashReason := ""
-			crashLogTail := []string(nil)
-			if svc.LastPID != nil && *svc.LastPID > 0 {
-				status = "crashed"
-				crashReason, crashLogTail = a.getCrashReport(svc.Name, 12)
-			}
-			servers = append(servers, &models.ServerInfo{
-				ManagedService: svc,
-				Source:         models.SourceManaged,
-				Status:         status,
-				CrashReason:    crashReason,
-				CrashLogTail:   crashLogTail,
-			})
+// recordHistory appends to a service's history. Like emit, failures are
+// reported but never interrupt the command.
+func (a *App) recordHistory(service string, e history.Entry) {
+	if a.history == nil || service == "" {
+		return
+	}
+	if err := a.history.Append(service, e); err != nil {
+		fmt.Fprintf(a.errOut(), "Warning: %v\n", err)
+	}
+}
+
+// runStart returns when the service's run with pid started.
+func runStart(svc *models.ManagedService, pid int) (time.Time, bool) {
+	if svc == nil {
+		return time.Time{}, false
+	}
+	for i := len(svc.Runs) - 1; i >= 0; i-- {
+		if svc.Runs[i].PID == pid {
+			return svc.Runs[i].StartedAt, true
 		}
 	}
+	if svc.LastPID != nil && *svc.LastPID == pid && svc.LastStart != nil {
+		return *svc.LastStart, true
+	}
+	return time.Time{}, false
+}
 
-	return servers, nil
+// emit appends an event to the events log. Failures are reported but never
+// interrupt the command that produced the event.
+func (a *App) emit(ev events.Event) {
+	if a.events == nil {
+		return
+	}
+	if err := a.events.Append(ev); err != nil {
+		fmt.Fprintf(a.errOut(), "Warning: %v\n", err)
+	}
+}
+
+// describeExit renders an exit status with its age, e.g. "exited 137 (SIGKILL) 3m ago".
+func describeExit(exit *models.ExitStatus, now time.Time) string {
+	return exit.Describe() + " " + formatAgo(now.Sub(exit.ExitedAt))
+}
+
+// describeStart renders a process start time with its age, e.g.
+// "2026-10-16 09:12:03 (3h ago)".
+func describeStart(start, now time.Time) string {
+	return fmt.Sprintf("%s (%s)", start.Format("2006-01-02 15:04:05"), formatAgo(now.Sub(start)))
+}
+
+// describeRun renders a run as e.g. "restarted 5m ago by alice via tui,
+// PID 4242: stopped after 3m10s". live reports whether the run's process
+// is still running.
+func describeRun(run models.RunRecord, live bool, now time.Time) string {
+	verb := "started"
+	if run.Restart {
+		verb = "restarted"
+	}
+	outcome := "running"
+	switch {
+	case run.EndedAt != nil:
+		outcome = fmt.Sprintf("%s after %s", run.Outcome, run.EndedAt.Sub(run.StartedAt).Round(time.Second))
+	case !live:
+		outcome = "ended"
+	}
+	return fmt.Sprintf("%s %s by %s, PID %d: %s", verb, formatAgo(now.Sub(run.StartedAt)), run.Actor, run.PID, outcome)
+}
+
+// runIsLive reports whether run is the service's current, running run.
+func runIsLive(svc *models.ManagedService, run models.RunRecord, status string) bool {
+	return svc.LastPID != nil && *svc.LastPID == run.PID && run.EndedAt == nil && !isCrashStatus(status) && status != "stopped"
+}
+
+// formatAgo renders a coarse relative time such as "45s ago" or "3h ago".
+func formatAgo(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return "just now"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
+// isCrashStatus reports whether a status describes a service that exited unexpectedly.
+func isCrashStatus(status string) bool {
+	return status == "crashed" || status == "crash-looping"
 }
 
 func (a *App) getCrashReport(serviceName string, lines int) (string, []string) {
@@ -251,53 +686,6 @@ func inferCrashReason(lines []string) string {
 	return ""
 }
 
-// getCommandMap creates a map of PID to command string
-func (a *App) getCommandMap(processes []*models.ProcessRecord) map[int]string {
-	cmdMap := make(map[int]string)
-	for _, proc := range processes {
-		if proc != nil {
-			cmdMap[proc.PID] = proc.Command
-		}
-	}
-	return cmdMap
-}
-
-func normalizePath(p string) string {
-	p = strings.TrimSpace(p)
-	p = strings.TrimRight(p, "/")
-	return p
-}
-
-func canMatchByPath(svcRoot, svcCWD, procRoot, procCWD string, rootOwners, cwdOwners map[string]int) bool {
-	if svcRoot != "" && procRoot != "" && svcRoot == procRoot && rootOwners[svcRoot] == 1 {
-		return true
-	}
-	if svcCWD != "" && procCWD != "" && svcCWD == procCWD && cwdOwners[svcCWD] == 1 {
-		return true
-	}
-	return false
-}
-
-func serviceMatchesProcess(svc *models.ManagedService, proc *models.ProcessRecord, svcRoot, procRoot, procCWD string) bool {
-	if svc == nil || proc == nil {
-		return false
-	}
-
-	svcCWD := normalizePath(svc.CWD)
-	if svcCWD != "" && procCWD != "" && svcCWD == procCWD {
-		return true
-	}
-	if svcRoot != "" && procRoot != "" && svcRoot == procRoot {
-		return true
-	}
-	for _, port := range svc.Ports {
-		if port > 0 && proc.Port == port {
-			return true
-		}
-	}
-	return false
-}
-
 func warnLegacyManagedCommands(reg *registry.Registry, out io.Writer) {
 	if reg == nil || out == nil {
 		return
@@ -309,19 +697,24 @@ func warnLegacyManagedCommands(reg *registry.Registry, out io.Writer) {
 
 	var warnings []string
 	for _, svc := range services {
-		if svc == nil {
+		if svc == nil || svc.Ephemeral || svc.Shell {
 			continue
 		}
 		if p, ok := firstBlockedShellPattern(svc.Command); ok {
 			warnings = append(warnings, fmt.Sprintf("  - %s (pattern %q)", svc.Name, p))
 		}
+		for _, proc := range svc.Processes {
+			if p, ok := firstBlockedShellPattern(proc.Command); ok {
+				warnings = append(warnings, fmt.Sprintf("  - %s/%s (pattern %q)", svc.Name, proc.Name, p))
+			}
+		}
 	}
 	if len(warnings) == 0 {
 		return
 	}
 	sort.Strings(warnings)
 	fmt.Fprintln(out, "Warning: legacy managed commands detected that rely on shell patterns.")
-	fmt.Fprintln(out, "These commands may fail under strict execution. Update them to direct executable form.")
+	fmt.Fprintln(out, "These commands may fail under strict execution. Update them to direct executable form, or opt in to shell execution with \"shell\": true.")
 	for _, w := range warnings {
 		fmt.Fprintln(out, w)
 	}
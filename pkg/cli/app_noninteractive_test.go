@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestNonInteractiveMode(t *testing.T) {
+	for value, want := range map[string]bool{"": false, "0": false, "false": false, "1": true, "true": true, "yes": true} {
+		t.Setenv(NonInteractiveEnv, value)
+		if got := NonInteractiveFromEnv(); got != want {
+			t.Errorf("%s=%q: got %t, want %t", NonInteractiveEnv, value, got, want)
+		}
+	}
+
+	app := &App{}
+	app.SetNonInteractive(false)
+	if app.confirm("Stop them?") {
+		t.Fatal("expected non-interactive mode to answer no")
+	}
+	if err := app.TopCmd(); err == nil {
+		t.Fatal("expected the TUI to be refused in non-interactive mode")
+	}
+	app.SetNonInteractive(true)
+	if !app.confirm("Stop them?") {
+		t.Fatal("expected --yes to answer yes")
+	}
+}
+
+func TestServerLessIsStable(t *testing.T) {
+	t.Parallel()
+
+	a := &models.ServerInfo{ProcessRecord: &models.ProcessRecord{Port: 3000, PID: 20}}
+	b := &models.ServerInfo{ProcessRecord: &models.ProcessRecord{Port: 3000, PID: 10}}
+	c := &models.ServerInfo{ManagedService: &models.ManagedService{Name: "api"}}
+	if !serverLess(b, a) || serverLess(a, b) {
+		t.Fatal("expected PID to break port ties")
+	}
+	if !serverLess(c, b) {
+		t.Fatal("expected servers without a listener first")
+	}
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// attachDetachKey (Ctrl+]) ends `devpt attach` and leaves the service
+// running; every other key, Ctrl+C included, goes to the service.
+const attachDetachKey = 0x1d
+
+// attachContextLines is how much recent output is shown on attaching.
+const attachContextLines = 20
+
+// AttachCmd connects the terminal to a running pty-mode service, so that
+// dev servers driven by keypresses can be used without restarting them.
+func (a *App) AttachCmd(name string) error {
+	svc := a.registry.GetService(name)
+	if svc == nil {
+		return errServiceNotFound(name)
+	}
+	if !svc.PTY {
+		return fmt.Errorf("service %q does not run on a terminal; add it with --pty (or set \"pty\": true in the registry) and restart it", name)
+	}
+	if a.lastPIDState(svc) != pidLive {
+		return fmt.Errorf("service %q is not running", name)
+	}
+	socket, err := a.processManager.AttachSocket(name)
+	if err != nil {
+		return fmt.Errorf("%w; restart it to enable attaching", err)
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return fmt.Errorf("failed to attach to %q: %w", name, err)
+	}
+	defer conn.Close()
+
+	if lines, err := a.processManager.Tail(name, attachContextLines); err == nil {
+		for _, line := range lines {
+			fmt.Fprintln(a.out(), line)
+		}
+	}
+	fmt.Fprintf(a.out(), "Attached to %q (PID %d); Ctrl+] detaches and leaves it running\n", name, *svc.LastPID)
+
+	if fd := os.Stdin.Fd(); term.IsTerminal(fd) {
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return fmt.Errorf("failed to put the terminal in raw mode: %w", err)
+		}
+		defer term.Restore(fd, state)
+	}
+
+	ended := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(os.Stdout, conn)
+		close(ended)
+	}()
+	detached := make(chan struct{})
+	go func() {
+		defer close(detached)
+		buf := make([]byte, 1024)
+		for {
+			n, err := os.Stdin.Read(buf)
+			in := buf[:n]
+			i := bytes.IndexByte(in, attachDetachKey)
+			if i >= 0 {
+				in = in[:i]
+			}
+			if len(in) > 0 {
+				if _, werr := conn.Write(in); werr != nil {
+					return
+				}
+			}
+			if i >= 0 || err != nil {
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-ended:
+		fmt.Fprintf(a.out(), "\r\nService %q closed its terminal\r\n", name)
+	case <-detached:
+		fmt.Fprintf(a.out(), "\r\nDetached from %q; it keeps running\r\n", name)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// exitReportWait bounds how long an attached start waits for the
+// supervisor to record the exit status of a service that ended.
+const exitReportWait = time.Second
+
+// StartAttachedCmd starts a managed service and streams its output to the
+// terminal as it is written to the log, like foreman. Ctrl+C stops the
+// service; if it exits on its own, devpt exits with its exit code.
+func (a *App) StartAttachedCmd(name string, opts StartOptions) error {
+	svc := a.registry.GetService(name)
+	if svc == nil {
+		return errServiceNotFound(name)
+	}
+
+	fmt.Fprintf(a.out(), "Starting service %q...\n", name)
+	pid, err := a.launch(svc, opts)
+	if err != nil {
+		return err
+	}
+	logPath, err := a.processManager.LatestLogPath(name)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out(), "Service %q started with PID %d (Ctrl+C to stop it)\n", name, pid)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer stop()
+	running := func() bool { return a.processManager.IsRunning(pid) }
+	offset, err := a.processManager.FollowFile(ctx, logPath, 0, a.out(), running)
+	if err != nil {
+		fmt.Fprintf(a.errOut(), "Warning: %v\n", err)
+	}
+
+	if ctx.Err() != nil {
+		fmt.Fprintf(a.out(), "\nStopping service %q...\n", name)
+		if err := a.stopProcess(svc, pid, StopOptions{}); err != nil {
+			return fmt.Errorf("failed to stop service: %w", err)
+		}
+		if err := a.registry.ClearServicePID(name); err != nil {
+			fmt.Fprintf(a.errOut(), "Warning: failed to clear PID for %q: %v\n", name, err)
+		}
+		a.emitStopped(name, pid)
+		// Show what the service printed while shutting down.
+		_, _ = a.processManager.FollowFile(context.Background(), logPath, offset, a.out(), func() bool { return false })
+		fmt.Fprintf(a.out(), "Service %q stopped\n", name)
+		return nil
+	}
+
+	// The service ended on its own; report it the way discovery would.
+	deadline := time.Now().Add(exitReportWait)
+	for {
+		exit, fresh := a.syncLastExit(svc)
+		if exit != nil && exit.PID == pid {
+			if fresh {
+				status := "stopped"
+				if exit.Code != 0 {
+					status = "crashed"
+				}
+				a.emitExit(svc, exit, status)
+			}
+			fmt.Fprintf(a.out(), "Service %q %s\n", name, exit.Describe())
+			if exit.Code != 0 {
+				return &ExitError{Code: exit.Code, Reason: fmt.Sprintf("service %q %s", name, exit.Describe())}
+			}
+			return nil
+		}
+		if time.Now().After(deadline) {
+			fmt.Fprintf(a.out(), "Service %q exited\n", name)
+			return nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
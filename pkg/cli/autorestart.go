@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/devports/devpt/pkg/filewatch"
+	"github.com/devports/devpt/pkg/models"
+)
+
+// StartWatchCmd starts a managed service and restarts it whenever files
+// matching opts.Patterns change in its directory. opts.Root is set to the
+// service directory. It runs in the foreground until interrupted; the service
+// keeps running afterwards.
+func (a *App) StartWatchCmd(name string, opts filewatch.Options, start StartOptions) error {
+	svc := a.registry.GetService(name)
+	if svc == nil {
+		return errServiceNotFound(name)
+	}
+	opts.Root = svc.CWD
+	opts.OnError = func(err error) {
+		fmt.Fprintf(a.errOut(), "Warning: %v\n", err)
+	}
+	w, err := filewatch.New(opts)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	fmt.Fprintf(a.out(), "Starting service %q...\n", name)
+	pid, err := a.launch(svc, start)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out(), "Service %q started with PID %d\n", name, pid)
+	what := "all files"
+	if len(opts.Patterns) > 0 {
+		what = strings.Join(opts.Patterns, ", ")
+	}
+	fmt.Fprintf(a.out(), "Watching %s in %s (Ctrl+C to stop watching)\n", what, svc.CWD)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err = w.Run(ctx, func(paths []string) {
+		fmt.Fprintf(a.out(), "\nChanged: %s\n", summarizePaths(paths, 3))
+		// Reload so a restart done by another devpt process is noticed.
+		a.reloadRegistry()
+		current := a.registry.GetService(name)
+		if current == nil {
+			fmt.Fprintf(a.errOut(), "Error: service %q was removed\n", name)
+			return
+		}
+		if current.LastPID == nil || *current.LastPID != pid {
+			// Someone else restarted it; fall back to the regular, validated path.
+			if err := a.RestartCmd(name); err != nil {
+				fmt.Fprintf(a.errOut(), "Error: %v\n", err)
+			}
+			if current = a.registry.GetService(name); current != nil && current.LastPID != nil {
+				pid = *current.LastPID
+			}
+			return
+		}
+		// We started this PID ourselves, so it is safe to stop it directly.
+		if a.processManager.IsRunning(pid) {
+			fmt.Fprintf(a.out(), "Stopping PID %d...\n", pid)
+			if err := a.stopProcess(current, pid, StopOptions{}); err != nil {
+				fmt.Fprintf(a.errOut(), "Error: %v\n", err)
+				return
+			}
+			if err := a.registry.ClearServicePID(name); err != nil {
+				fmt.Fprintf(a.errOut(), "Warning: failed to clear PID for %q: %v\n", name, err)
+			}
+			a.emitStopped(name, pid)
+		}
+		next, err := a.launch(current, StartOptions{restart: true, via: models.ViaWatch})
+		if err != nil {
+			fmt.Fprintf(a.errOut(), "Error: %v\n", err)
+			return
+		}
+		pid = next
+		fmt.Fprintf(a.out(), "Service %q restarted with PID %d\n", name, pid)
+	})
+	fmt.Fprintf(a.out(), "\nStopped watching; %q is still running (devpt stop %s)\n", name, name)
+	return err
+}
+
+// summarizePaths lists up to max paths and counts the rest.
+func summarizePaths(paths []string, max int) string {
+	if len(paths) <= max {
+		return strings.Join(paths, ", ")
+	}
+	return fmt.Sprintf("%s (+%d more)", strings.Join(paths[:max], ", "), len(paths)-max)
+}
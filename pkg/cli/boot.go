@@ -0,0 +1,278 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/process"
+)
+
+// bootSpec is what a start-at-login agent runs: devpt, starting Service.
+type bootSpec struct {
+	Service string
+	// Exe is the devpt binary.
+	Exe string
+	// Env is set for devpt; PATH is copied from the shell that enabled the
+	// agent, since login sessions have a minimal one.
+	Env map[string]string
+	// LogPath receives what devpt itself prints, for agents whose output
+	// does not go to a journal.
+	LogPath string
+	// StopTimeout is the longest devpt takes to stop the service.
+	StopTimeout time.Duration
+	// CrashLoop is when devpt calls the service crash-looping; service
+	// managers that restart it give up at the same point.
+	CrashLoop models.CrashLoopSettings
+}
+
+// bootSystem is the service manager that starts agents at login.
+type bootSystem interface {
+	// kind names the agents, e.g. "launchd user agent".
+	kind() string
+	// path is where the agent of a service is written.
+	path(service string) string
+	// render returns the agent file for spec.
+	render(spec bootSpec) []byte
+	// load makes the service manager pick up the agent file of a service.
+	load(service string) error
+	// unload tells the service manager to forget the agent.
+	unload(service string) error
+	// loaded reports whether the service manager will run the agent.
+	loaded(service string) bool
+	// journal returns the command that prints the last lines the agent
+	// logged to the system journal; nil when it logs to LogPath.
+	journal(service string, lines int) *exec.Cmd
+}
+
+// newBootSystem returns the service manager of this platform.
+func newBootSystem() (bootSystem, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return &launchdAgents{dir: filepath.Join(home, "Library", "LaunchAgents"), domain: fmt.Sprintf("gui/%d", os.Getuid())}, nil
+	case "linux":
+		if _, err := exec.LookPath("systemctl"); err != nil {
+			return nil, errors.New("devpt boot needs systemd, and systemctl is not on PATH")
+		}
+		config := os.Getenv("XDG_CONFIG_HOME")
+		if config == "" {
+			config = filepath.Join(home, ".config")
+		}
+		return &systemdUnits{dir: filepath.Join(config, "systemd", "user")}, nil
+	}
+	return nil, fmt.Errorf("devpt boot needs launchd or systemd, which %s does not have", runtime.GOOS)
+}
+
+// bootID turns a service name into something agent names and file names
+// can hold.
+func bootID(service string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '-'
+	}, service)
+}
+
+// bootSpec describes the agent that starts svc.
+func (a *App) bootSpec(svc *models.ManagedService) (bootSpec, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return bootSpec{}, fmt.Errorf("failed to find the devpt binary: %w", err)
+	}
+	_, timeout, err := process.StopParams(svc)
+	if err != nil {
+		return bootSpec{}, err
+	}
+	spec := bootSpec{
+		Service:     svc.Name,
+		Exe:         exe,
+		Env:         map[string]string{},
+		LogPath:     a.bootLogPath(svc.Name),
+		StopTimeout: timeout,
+		CrashLoop:   a.crashLoopSettings(),
+	}
+	if path := os.Getenv("PATH"); path != "" {
+		spec.Env["PATH"] = path
+	}
+	return spec, nil
+}
+
+// bootLogPath is where agents that do not log to a journal write what devpt
+// prints.
+func (a *App) bootLogPath(name string) string {
+	return filepath.Join(a.config.ConfigDir, "boot", bootID(name)+".log")
+}
+
+// BootEnableCmd installs and loads an agent that starts a service when you
+// log in.
+func (a *App) BootEnableCmd(name string) error {
+	svc := a.registry.GetService(name)
+	if svc == nil {
+		return errServiceNotFound(name)
+	}
+	sys, err := newBootSystem()
+	if err != nil {
+		return err
+	}
+	spec, err := a.bootSpec(svc)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(spec.LogPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create boot log directory: %w", err)
+	}
+	path := sys.path(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, sys.render(spec), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := sys.load(name); err != nil {
+		_ = os.Remove(path)
+		return err
+	}
+	fmt.Fprintf(a.out(), "Service %q will start at login (%s %s)\n", name, sys.kind(), path)
+	return nil
+}
+
+// BootDisableCmd unloads and removes the agent that starts a service at
+// login. The service keeps running if it is.
+func (a *App) BootDisableCmd(name string) error {
+	sys, err := newBootSystem()
+	if err != nil {
+		return err
+	}
+	path := sys.path(name)
+	if _, err := os.Stat(path); os.IsNotExist(err) && !sys.loaded(name) {
+		if a.registry.GetService(name) == nil {
+			return errServiceNotFound(name)
+		}
+		fmt.Fprintf(a.out(), "Service %q does not start at login\n", name)
+		return nil
+	}
+	if err := sys.unload(name); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	fmt.Fprintf(a.out(), "Service %q will no longer start at login\n", name)
+	return nil
+}
+
+// BootStatusCmd shows which services start at login: one, or every
+// registered service.
+func (a *App) BootStatusCmd(name string) error {
+	sys, err := newBootSystem()
+	if err != nil {
+		return err
+	}
+	var names []string
+	if name != "" {
+		if a.registry.GetService(name) == nil {
+			return errServiceNotFound(name)
+		}
+		names = []string{name}
+	} else {
+		for _, svc := range a.registry.ListServices() {
+			names = append(names, svc.Name)
+		}
+	}
+	if len(names) == 0 {
+		fmt.Fprintln(a.out(), "No managed services")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(a.out(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Name\tAt login\tLoaded\tFile")
+	for _, n := range names {
+		path := sys.path(n)
+		enabled, loaded, file := "no", "no", "-"
+		if _, err := os.Stat(path); err == nil {
+			enabled, file = "yes", path
+		}
+		if sys.loaded(n) {
+			loaded = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", n, enabled, loaded, file)
+	}
+	return w.Flush()
+}
+
+// BootLogsCmd prints the last lines devpt printed when the login agent of a
+// service ran it: from the systemd journal, or from the agent's log file.
+func (a *App) BootLogsCmd(name string, lines int) error {
+	if a.registry.GetService(name) == nil {
+		return errServiceNotFound(name)
+	}
+	sys, err := newBootSystem()
+	if err != nil {
+		return err
+	}
+	if cmd := sys.journal(name, lines); cmd != nil {
+		cmd.Stdout, cmd.Stderr = a.out(), a.errOut()
+		return cmd.Run()
+	}
+	data, err := os.ReadFile(a.bootLogPath(name))
+	if os.IsNotExist(err) {
+		fmt.Fprintf(a.out(), "Service %q has not been started at login yet\n", name)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read boot log: %w", err)
+	}
+	out := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if lines > 0 && len(out) > lines {
+		out = out[len(out)-lines:]
+	}
+	for _, line := range out {
+		fmt.Fprintln(a.out(), line)
+	}
+	return nil
+}
+
+// removeBootAgent disables the login agent of a service being removed, so
+// that it does not try to start a service that is gone.
+func (a *App) removeBootAgent(name string) {
+	sys, err := newBootSystem()
+	if err != nil {
+		return
+	}
+	path := sys.path(name)
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+	if err := sys.unload(name); err != nil {
+		fmt.Fprintf(a.errOut(), "Warning: failed to unload the login agent of %q: %v\n", name, err)
+	}
+	if err := os.Remove(path); err != nil {
+		fmt.Fprintf(a.errOut(), "Warning: failed to remove %s: %v\n", path, err)
+	}
+}
+
+// runBootTool runs a service manager's command, reporting its output when
+// it fails.
+func runBootTool(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s %s: %s", name, strings.Join(args, " "), msg)
+		}
+		return fmt.Errorf("%s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os/exec"
+	"path/filepath"
+	"sort"
+)
+
+// launchdLabelPrefix starts the labels of the agents devpt boot installs.
+const launchdLabelPrefix = "com.devports.devpt."
+
+// launchdAgents installs launchd user agents in ~/Library/LaunchAgents,
+// loaded into the user's GUI session.
+type launchdAgents struct {
+	dir    string
+	domain string // gui/<uid>
+}
+
+func (l *launchdAgents) kind() string { return "launchd user agent" }
+
+func (l *launchdAgents) label(service string) string {
+	return launchdLabelPrefix + bootID(service)
+}
+
+func (l *launchdAgents) path(service string) string {
+	return filepath.Join(l.dir, l.label(service)+".plist")
+}
+
+// render writes a property list that runs spec once at login.
+// AbandonProcessGroup keeps launchd from killing the service when the devpt
+// command that started it exits.
+func (l *launchdAgents) render(spec bootSpec) []byte {
+	var b bytes.Buffer
+	str := func(indent, s string) {
+		b.WriteString(indent + "<string>")
+		_ = xml.EscapeText(&b, []byte(s))
+		b.WriteString("</string>\n")
+	}
+	key := func(indent, k string) {
+		b.WriteString(indent + "<key>")
+		_ = xml.EscapeText(&b, []byte(k))
+		b.WriteString("</key>\n")
+	}
+
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+`)
+	key("\t", "Label")
+	str("\t", l.label(spec.Service))
+	key("\t", "ProgramArguments")
+	b.WriteString("\t<array>\n")
+	for _, arg := range []string{spec.Exe, "start", spec.Service} {
+		str("\t\t", arg)
+	}
+	b.WriteString("\t</array>\n")
+	if len(spec.Env) > 0 {
+		key("\t", "EnvironmentVariables")
+		b.WriteString("\t<dict>\n")
+		names := make([]string, 0, len(spec.Env))
+		for name := range spec.Env {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			key("\t\t", name)
+			str("\t\t", spec.Env[name])
+		}
+		b.WriteString("\t</dict>\n")
+	}
+	key("\t", "RunAtLoad")
+	b.WriteString("\t<true/>\n")
+	key("\t", "AbandonProcessGroup")
+	b.WriteString("\t<true/>\n")
+	if spec.LogPath != "" {
+		key("\t", "StandardOutPath")
+		str("\t", spec.LogPath)
+		key("\t", "StandardErrorPath")
+		str("\t", spec.LogPath)
+	}
+	b.WriteString("</dict>\n</plist>\n")
+	return b.Bytes()
+}
+
+func (l *launchdAgents) load(service string) error {
+	// A loaded agent keeps its old definition until it is booted out.
+	if l.loaded(service) {
+		if err := l.unload(service); err != nil {
+			return err
+		}
+	}
+	return runBootTool("launchctl", "bootstrap", l.domain, l.path(service))
+}
+
+func (l *launchdAgents) unload(service string) error {
+	if !l.loaded(service) {
+		return nil
+	}
+	return runBootTool("launchctl", "bootout", l.domain+"/"+l.label(service))
+}
+
+func (l *launchdAgents) loaded(service string) bool {
+	return exec.Command("launchctl", "print", l.domain+"/"+l.label(service)).Run() == nil
+}
+
+func (l *launchdAgents) journal(service string, lines int) *exec.Cmd { return nil }
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"fmt"
+	"math"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// systemdUnitPrefix starts the names of the units devpt boot installs.
+const systemdUnitPrefix = "devpt-"
+
+// systemdStopMargin is added to a service's stop timeout so that systemd
+// only kills what devpt failed to stop.
+const systemdStopMargin = 5
+
+// systemdRestartSec is how long systemd waits before restarting a service
+// that crashed.
+const systemdRestartSec = 2
+
+// systemdUnits installs systemd user units in ~/.config/systemd/user,
+// enabled for the user's service manager.
+type systemdUnits struct {
+	dir string
+}
+
+func (s *systemdUnits) kind() string { return "systemd user unit" }
+
+func (s *systemdUnits) unit(service string) string {
+	return systemdUnitPrefix + bootID(service) + ".service"
+}
+
+func (s *systemdUnits) path(service string) string {
+	return filepath.Join(s.dir, s.unit(service))
+}
+
+// render writes a unit that runs the service in the foreground with devpt
+// start --attach, so its output goes to the journal and systemd restarts it
+// when it crashes. systemd gives up after the crash-loop threshold, as devpt
+// would call it crash-looping. KillMode=mixed signals only devpt on stop,
+// which stops the service with its own stop signal and timeout.
+func (s *systemdUnits) render(spec bootSpec) []byte {
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	fmt.Fprintf(&b, "Description=devpt service %s\n", systemdEscape(spec.Service))
+	if window := spec.CrashLoop.Window.Std(); window > 0 && spec.CrashLoop.Threshold > 0 {
+		fmt.Fprintf(&b, "StartLimitIntervalSec=%d\n", int(math.Ceil(window.Seconds())))
+		fmt.Fprintf(&b, "StartLimitBurst=%d\n", spec.CrashLoop.Threshold)
+	}
+
+	b.WriteString("\n[Service]\nType=simple\n")
+	args := []string{spec.Exe, "start", spec.Service, "--attach"}
+	for i, arg := range args {
+		args[i] = systemdQuote(arg, true)
+	}
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(args, " "))
+	names := make([]string, 0, len(spec.Env))
+	for name := range spec.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "Environment=%s\n", systemdQuote(name+"="+spec.Env[name], false))
+	}
+	b.WriteString("Restart=on-failure\n")
+	fmt.Fprintf(&b, "RestartSec=%d\n", systemdRestartSec)
+	// devpt exits with 5 when the service is already running, which is no
+	// crash to retry.
+	b.WriteString("RestartPreventExitStatus=5\n")
+	b.WriteString("KillMode=mixed\n")
+	fmt.Fprintf(&b, "TimeoutStopSec=%d\n", int(math.Ceil(spec.StopTimeout.Seconds()))+systemdStopMargin)
+	b.WriteString("StandardOutput=journal\nStandardError=journal\n")
+	fmt.Fprintf(&b, "SyslogIdentifier=%s\n", strings.TrimSuffix(s.unit(spec.Service), ".service"))
+
+	b.WriteString("\n[Install]\nWantedBy=default.target\n")
+	return []byte(b.String())
+}
+
+func (s *systemdUnits) load(service string) error {
+	if err := runBootTool("systemctl", "--user", "daemon-reload"); err != nil {
+		return err
+	}
+	return runBootTool("systemctl", "--user", "enable", s.unit(service))
+}
+
+func (s *systemdUnits) unload(service string) error {
+	if !s.loaded(service) {
+		return nil
+	}
+	return runBootTool("systemctl", "--user", "disable", s.unit(service))
+}
+
+func (s *systemdUnits) loaded(service string) bool {
+	return exec.Command("systemctl", "--user", "is-enabled", "--quiet", s.unit(service)).Run() == nil
+}
+
+func (s *systemdUnits) journal(service string, lines int) *exec.Cmd {
+	return exec.Command("journalctl", "--user", "--unit", s.unit(service), "--lines", strconv.Itoa(lines), "--no-pager")
+}
+
+// systemdEscape escapes the specifiers systemd expands in unit values.
+func systemdEscape(s string) string {
+	return strings.ReplaceAll(s, "%", "%%")
+}
+
+// systemdQuote quotes a word of a unit value. Command lines also expand
+// $VARIABLES, which exec escapes.
+func systemdQuote(s string, exec bool) string {
+	s = systemdEscape(s)
+	if exec {
+		s = strings.ReplaceAll(s, "$", "$$")
+	}
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\;") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
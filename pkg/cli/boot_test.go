@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestBootID(t *testing.T) {
+	t.Parallel()
+
+	for name, want := range map[string]string{"web": "web", "api.v2_stub": "api.v2_stub", "db proxy/eu": "db-proxy-eu"} {
+		if got := bootID(name); got != want {
+			t.Errorf("bootID(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestLaunchdRender(t *testing.T) {
+	t.Parallel()
+
+	l := &launchdAgents{dir: "/Users/me/Library/LaunchAgents", domain: "gui/501"}
+	if got := l.path("db proxy"); got != "/Users/me/Library/LaunchAgents/com.devports.devpt.db-proxy.plist" {
+		t.Fatalf("path = %q", got)
+	}
+	plist := string(l.render(bootSpec{
+		Service: "db proxy",
+		Exe:     "/usr/local/bin/devpt",
+		Env:     map[string]string{"PATH": "/usr/bin:/opt/a&b/bin"},
+		LogPath: "/Users/me/.config/devpt/boot/db-proxy.log",
+	}))
+	if err := xml.Unmarshal([]byte(plist), new(struct{})); err != nil {
+		t.Fatalf("plist is not well-formed: %v\n%s", err, plist)
+	}
+	for _, want := range []string{
+		"<string>com.devports.devpt.db-proxy</string>",
+		"<string>/usr/local/bin/devpt</string>\n\t\t<string>start</string>\n\t\t<string>db proxy</string>\n\t</array>",
+		"<key>PATH</key>\n\t\t<string>/usr/bin:/opt/a&amp;b/bin</string>",
+		"<key>RunAtLoad</key>\n\t<true/>",
+		"<key>AbandonProcessGroup</key>\n\t<true/>",
+		"<key>StandardErrorPath</key>\n\t<string>/Users/me/.config/devpt/boot/db-proxy.log</string>",
+	} {
+		if !strings.Contains(plist, want) {
+			t.Errorf("plist lacks %q:\n%s", want, plist)
+		}
+	}
+}
+
+func TestSystemdRender(t *testing.T) {
+	t.Parallel()
+
+	s := &systemdUnits{dir: "/home/me/.config/systemd/user"}
+	if got := s.path("db proxy"); got != "/home/me/.config/systemd/user/devpt-db-proxy.service" {
+		t.Fatalf("path = %q", got)
+	}
+	unit := string(s.render(bootSpec{
+		Service:     "db proxy",
+		Exe:         "/home/me/go/bin/devpt",
+		Env:         map[string]string{"PATH": "/usr/bin:/home/me/my tools/100%"},
+		StopTimeout: 10 * time.Second,
+		CrashLoop:   models.CrashLoopSettings{Threshold: 3, Window: models.Duration(5 * time.Minute)},
+	}))
+	for _, want := range []string{
+		"StartLimitIntervalSec=300\nStartLimitBurst=3\n",
+		`ExecStart=/home/me/go/bin/devpt start "db proxy" --attach` + "\n",
+		`Environment="PATH=/usr/bin:/home/me/my tools/100%%"` + "\n",
+		"Restart=on-failure\n",
+		"KillMode=mixed\nTimeoutStopSec=15\n",
+		"StandardOutput=journal\n",
+		"SyslogIdentifier=devpt-db-proxy\n",
+		"[Install]\nWantedBy=default.target\n",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("unit lacks %q:\n%s", want, unit)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/devports/devpt/pkg/certs"
+	"github.com/devports/devpt/pkg/process"
+)
+
+// DefaultCertAddr is where devpt cert serve listens unless told otherwise.
+const DefaultCertAddr = "127.0.0.1:8443"
+
+// caTrustName is what the local authority is called in trust stores that
+// name their entries.
+const caTrustName = "devpt development CA"
+
+// certDir holds the local certificate authority and the certificates it
+// issued.
+func (a *App) certDir() string {
+	return filepath.Join(a.config.ConfigDir, "certs")
+}
+
+// loadAuthority returns the local certificate authority, explaining how to
+// create one when there is none.
+func (a *App) loadAuthority() (*certs.Authority, error) {
+	ca, err := certs.Load(a.certDir())
+	if errors.Is(err, certs.ErrNoAuthority) {
+		return nil, errors.New("no local certificate authority; create and trust one with devpt cert install")
+	}
+	return ca, err
+}
+
+// trustStep adds the local authority to one trust store by running cmds in
+// order.
+type trustStep struct {
+	store string
+	cmds  [][]string
+}
+
+// commandLine shows the commands of s as one shell line.
+func (s trustStep) commandLine() string {
+	lines := make([]string, len(s.cmds))
+	for i, argv := range s.cmds {
+		lines[i] = process.QuoteCommand(argv)
+	}
+	return strings.Join(lines, " && ")
+}
+
+// caTrustSteps returns how to trust the authority at caPath on goos: in the
+// macOS login keychain, or in the Linux system store and the NSS database
+// Chrome and Chromium read. exists and lookPath stand in for the file
+// system and PATH.
+func caTrustSteps(goos, home, caPath string, exists func(path string) bool, lookPath func(file string) bool) []trustStep {
+	var steps []trustStep
+	switch goos {
+	case "darwin":
+		steps = append(steps, trustStep{store: "your login keychain", cmds: [][]string{
+			{"security", "add-trusted-cert", "-r", "trustRoot", "-k", filepath.Join(home, "Library", "Keychains", "login.keychain-db"), caPath},
+		}})
+	case "linux":
+		switch {
+		case exists("/usr/local/share/ca-certificates") && lookPath("update-ca-certificates"):
+			steps = append(steps, trustStep{store: "the system trust store", cmds: [][]string{
+				{"sudo", "cp", caPath, "/usr/local/share/ca-certificates/devpt-ca.crt"},
+				{"sudo", "update-ca-certificates"},
+			}})
+		case exists("/etc/pki/ca-trust/source/anchors") && lookPath("update-ca-trust"):
+			steps = append(steps, trustStep{store: "the system trust store", cmds: [][]string{
+				{"sudo", "cp", caPath, "/etc/pki/ca-trust/source/anchors/devpt-ca.pem"},
+				{"sudo", "update-ca-trust", "extract"},
+			}})
+		case lookPath("trust"):
+			steps = append(steps, trustStep{store: "the system trust store", cmds: [][]string{
+				{"sudo", "trust", "anchor", "--store", caPath},
+			}})
+		}
+		if nssdb := filepath.Join(home, ".pki", "nssdb"); exists(nssdb) && lookPath("certutil") {
+			steps = append(steps, trustStep{store: "the Chrome and Chromium certificate database", cmds: [][]string{
+				{"certutil", "-d", "sql:" + nssdb, "-A", "-t", "C,,", "-n", caTrustName, "-i", caPath},
+			}})
+		}
+	}
+	return steps
+}
+
+// CertInstallCmd creates the local certificate authority if there is none
+// and offers to add it to this machine's trust stores, so that browsers and
+// tools accept the certificates it issues.
+func (a *App) CertInstallCmd() error {
+	ca, created, err := certs.LoadOrCreate(a.certDir())
+	if err != nil {
+		return fmt.Errorf("failed to set up the local certificate authority: %w", err)
+	}
+	if created {
+		fmt.Fprintf(a.out(), "Created a local certificate authority: %s\n", ca.CertPath())
+	} else {
+		fmt.Fprintf(a.out(), "Local certificate authority: %s\n", ca.CertPath())
+	}
+	home, _ := os.UserHomeDir()
+	exists := func(path string) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+	lookPath := func(file string) bool {
+		_, err := exec.LookPath(file)
+		return err == nil
+	}
+	steps := caTrustSteps(runtime.GOOS, home, ca.CertPath(), exists, lookPath)
+	if len(steps) == 0 {
+		fmt.Fprintf(a.out(), "devpt does not know this system's trust store; import %s as a trusted authority in your browser or system settings\n", ca.CertPath())
+	}
+	for _, step := range steps {
+		line := step.commandLine()
+		if !a.confirm(fmt.Sprintf("Trust it in %s? (runs %s)", step.store, line)) {
+			fmt.Fprintf(a.out(), "To trust it in %s later, run: %s\n", step.store, line)
+			continue
+		}
+		for _, argv := range step.cmds {
+			cmd := exec.Command(argv[0], argv[1:]...)
+			cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, a.out(), a.errOut()
+			if err := cmd.Run(); err != nil {
+				return fmt.Errorf("failed to trust the authority in %s: %s: %w", step.store, process.QuoteCommand(argv), err)
+			}
+		}
+		fmt.Fprintf(a.out(), "Trusted in %s\n", step.store)
+	}
+	fmt.Fprintln(a.out(), "Firefox keeps its own trust store; import the authority under Settings > Certificates if you test with it")
+	return nil
+}
+
+// certName is the file name of a certificate for hosts, in mkcert's style:
+// the first host, and how many more there are.
+func certName(hosts []string) string {
+	name := strings.NewReplacer("*", "_wildcard", ":", "_").Replace(hosts[0])
+	if len(hosts) > 1 {
+		name += fmt.Sprintf("+%d", len(hosts)-1)
+	}
+	return name
+}
+
+// CertIssueCmd writes a certificate and key for hosts, signed by the local
+// authority, to the certificate directory.
+func (a *App) CertIssueCmd(hosts []string, name string) error {
+	ca, err := a.loadAuthority()
+	if err != nil {
+		return err
+	}
+	leaf, err := ca.Issue(hosts)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		name = certName(hosts)
+	}
+	certPath, keyPath, err := ca.Write(name, leaf)
+	if err != nil {
+		return fmt.Errorf("failed to save the certificate: %w", err)
+	}
+	fmt.Fprintf(a.out(), "Certificate for %s\n", strings.Join(hosts, ", "))
+	fmt.Fprintf(a.out(), "  Cert: %s\n", certPath)
+	fmt.Fprintf(a.out(), "  Key:  %s\n", keyPath)
+	return nil
+}
+
+// CertListCmd shows the local authority and the certificates it issued.
+func (a *App) CertListCmd() error {
+	ca, err := a.loadAuthority()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out(), "Authority: %s (expires %s)\n", ca.CertPath(), ca.Cert.NotAfter.Format("2006-01-02"))
+	issued, err := certs.List(a.certDir())
+	if err != nil {
+		return err
+	}
+	if len(issued) == 0 {
+		fmt.Fprintln(a.out(), "No certificates issued; issue one with devpt cert issue <host>")
+		return nil
+	}
+	w := tabwriter.NewWriter(a.out(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Name\tHosts\tExpires\tFile")
+	for _, c := range issued {
+		expires := c.NotAfter.Format("2006-01-02")
+		if time.Now().After(c.NotAfter) {
+			expires += " (expired)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Name, strings.Join(c.Hosts, ","), expires, c.Path)
+	}
+	return w.Flush()
+}
+
+// certServes reports whether devpt cert serve presents a certificate for
+// name: localhost and names under it.
+func certServes(name string) bool {
+	return name == "localhost" || strings.HasSuffix(name, ".localhost")
+}
+
+// CertServeCmd terminates TLS on addr with a certificate of the local
+// authority and forwards the requests over plain HTTP to target, a port or
+// a managed service, until interrupted.
+func (a *App) CertServeCmd(target, addr string) error {
+	ca, err := a.loadAuthority()
+	if err != nil {
+		return err
+	}
+	handler, dest, err := a.targetHandler(target)
+	if err != nil {
+		return err
+	}
+	if addr == "" {
+		addr = DefaultCertAddr
+	}
+
+	srv := &http.Server{Handler: handler, TLSConfig: ca.TLSConfig(certServes)}
+	return a.serveLocal(addr, srv, "the TLS proxy", "forwards to "+dest, func(listening net.Addr) {
+		_, port, _ := net.SplitHostPort(listening.String())
+		fmt.Fprintf(a.out(), "Serving https://localhost:%s -> %s\n", port, dest)
+	})
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCATrustSteps(t *testing.T) {
+	t.Parallel()
+	has := func(items ...string) func(string) bool {
+		return func(s string) bool {
+			for _, item := range items {
+				if item == s {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	darwin := caTrustSteps("darwin", "/Users/me", "/cfg/certs/ca.pem", has(), has())
+	if len(darwin) != 1 || !strings.Contains(darwin[0].commandLine(), "security add-trusted-cert -r trustRoot -k /Users/me/Library/Keychains/login.keychain-db /cfg/certs/ca.pem") {
+		t.Errorf("darwin steps = %+v", darwin)
+	}
+
+	debian := caTrustSteps("linux", "/home/me", "/cfg/certs/ca.pem",
+		has("/usr/local/share/ca-certificates", "/home/me/.pki/nssdb"),
+		has("update-ca-certificates", "certutil"))
+	if len(debian) != 2 {
+		t.Fatalf("debian steps = %+v", debian)
+	}
+	if got := debian[0].commandLine(); got != "sudo cp /cfg/certs/ca.pem /usr/local/share/ca-certificates/devpt-ca.crt && sudo update-ca-certificates" {
+		t.Errorf("system step = %q", got)
+	}
+	if got := debian[1].commandLine(); !strings.Contains(got, "certutil -d sql:/home/me/.pki/nssdb") {
+		t.Errorf("NSS step = %q", got)
+	}
+
+	if steps := caTrustSteps("linux", "/home/me", "/ca.pem", has(), has()); len(steps) != 0 {
+		t.Errorf("a bare linux got steps %+v", steps)
+	}
+	if steps := caTrustSteps("windows", `C:\Users\me`, `C:\ca.pem`, has(), has()); len(steps) != 0 {
+		t.Errorf("windows got steps %+v", steps)
+	}
+}
+
+func TestCertName(t *testing.T) {
+	t.Parallel()
+	for hosts, want := range map[string]string{
+		"localhost":              "localhost",
+		"*.app.test":             "_wildcard.app.test",
+		"app.test,127.0.0.1,::1": "app.test+2",
+	} {
+		if got := certName(strings.Split(hosts, ",")); got != want {
+			t.Errorf("certName(%s) = %q, want %q", hosts, got, want)
+		}
+	}
+}
+
+func TestCertServes(t *testing.T) {
+	t.Parallel()
+	for name, want := range map[string]bool{"localhost": true, "api.localhost": true, "example.com": false, "localhost.evil.test": false} {
+		if got := certServes(name); got != want {
+			t.Errorf("certServes(%q) = %v", name, got)
+		}
+	}
+}
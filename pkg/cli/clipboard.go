@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardCommand returns the program that copies its stdin to the system
+// clipboard on goos, or "" when none is installed. has reports whether a
+// program is on PATH.
+func clipboardCommand(goos string, getenv func(string) string, has func(string) bool) (string, []string) {
+	if goos == "darwin" {
+		return "pbcopy", nil
+	}
+	if getenv("WAYLAND_DISPLAY") != "" && has("wl-copy") {
+		return "wl-copy", nil
+	}
+	if getenv("DISPLAY") != "" {
+		if has("xclip") {
+			return "xclip", []string{"-selection", "clipboard"}
+		}
+		if has("xsel") {
+			return "xsel", []string{"--clipboard", "--input"}
+		}
+	}
+	return "", nil
+}
+
+// osc52 returns the escape sequence that asks the terminal to put text on
+// the clipboard. It works over SSH in terminals that allow it.
+func osc52(text string) string {
+	return "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+}
+
+// copyToClipboard puts text on the clipboard with the platform's clipboard
+// program, or with OSC 52 over SSH and when there is none. It returns how
+// the text was copied.
+func copyToClipboard(text string, terminal io.Writer) (string, error) {
+	if os.Getenv("SSH_TTY") == "" {
+		if name, args := clipboardCommand(runtime.GOOS, os.Getenv, func(file string) bool {
+			_, err := exec.LookPath(file)
+			return err == nil
+		}); name != "" {
+			cmd := exec.Command(name, args...)
+			cmd.Stdin = strings.NewReader(text)
+			if err := cmd.Run(); err != nil {
+				return "", fmt.Errorf("%s: %w", name, err)
+			}
+			return name, nil
+		}
+	}
+	if _, err := io.WriteString(terminal, osc52(text)); err != nil {
+		return "", err
+	}
+	return "OSC 52", nil
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"testing"
+)
+
+func TestClipboardCommand(t *testing.T) {
+	t.Parallel()
+
+	env := func(vars map[string]string) func(string) string {
+		return func(k string) string { return vars[k] }
+	}
+	has := func(names ...string) func(string) bool {
+		return func(file string) bool {
+			for _, n := range names {
+				if n == file {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	if name, _ := clipboardCommand("darwin", env(nil), has()); name != "pbcopy" {
+		t.Fatalf("expected pbcopy on macOS, got %q", name)
+	}
+	if name, _ := clipboardCommand("linux", env(map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"}), has("wl-copy", "xclip")); name != "wl-copy" {
+		t.Fatalf("expected wl-copy on Wayland, got %q", name)
+	}
+	if name, args := clipboardCommand("linux", env(map[string]string{"DISPLAY": ":0"}), has("xclip")); name != "xclip" || len(args) != 2 {
+		t.Fatalf("expected xclip on X11, got %q %v", name, args)
+	}
+	if name, _ := clipboardCommand("linux", env(nil), has("xclip")); name != "" {
+		t.Fatalf("expected no program without a display, got %q", name)
+	}
+}
+
+func TestOSC52(t *testing.T) {
+	t.Parallel()
+
+	if got := osc52("3000"); got != "\x1b]52;c;MzAwMA==\a" {
+		t.Fatalf("unexpected sequence %q", got)
+	}
+}
@@ -0,0 +1,393 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+
+	"github.com/devports/devpt/pkg/health"
+	"github.com/devports/devpt/pkg/models"
+)
+
+// tableColumn is a column of the running table. sortable columns sort the
+// table by sort when their heading is clicked.
+type tableColumn struct {
+	name     string
+	heading  string
+	width    int
+	sort     sortMode
+	sortable bool
+}
+
+// tableColumns are the running table's columns in display order, with
+// their default widths. The command column takes the width the others
+// leave.
+var tableColumns = []tableColumn{
+	{name: "host", heading: "Host", width: 10},
+	{name: "name", heading: "Name", width: 14, sort: sortName, sortable: true},
+	{name: "port", heading: "Port", width: 7, sort: sortPort, sortable: true},
+	{name: "pid", heading: "PID", width: 7, sort: sortRecent, sortable: true},
+	{name: "project", heading: "Project", width: 14, sort: sortProject, sortable: true},
+	{name: "user", heading: "User", width: 10},
+	{name: "source", heading: "Source", width: 14},
+	{name: "framework", heading: "Framework", width: 24},
+	{name: "command", heading: "Command"},
+	{name: "uptime", heading: "Uptime", width: 6},
+	{name: "cpu", heading: "CPU", width: 6},
+	{name: "mem", heading: "Mem", width: 7},
+	{name: "net", heading: "Net ↓/↑", width: 12},
+	{name: "health", heading: "Health", width: 7, sort: sortHealth, sortable: true},
+	{name: "trend", heading: "Trend", width: 10},
+}
+
+// optionalColumns are the columns the column chooser shows and hides, in
+// its order; only the project column is shown by default.
+var optionalColumns = []string{"project", "user", "source", "framework", "uptime", "cpu", "mem", "net"}
+
+// Ways of fitting a command longer than its column.
+const (
+	overflowWrap     = "wrap"     // continue on the next lines (default)
+	overflowTruncate = "truncate" // cut the end off
+	overflowMiddle   = "middle"   // cut the middle out, keeping the script and its last arguments
+)
+
+var commandOverflows = []string{overflowWrap, overflowTruncate, overflowMiddle}
+
+// Bounds of the command column width set in the column chooser.
+const (
+	minCommandWidth  = 12
+	commandWidthStep = 4
+)
+
+// columnShown reports whether the running table shows a column. The host
+// column is shown when remote hosts are configured.
+func (m topModel) columnShown(name string) bool {
+	if name == "host" {
+		return len(m.app.hostConfigs()) > 0
+	}
+	optional := false
+	for _, c := range optionalColumns {
+		optional = optional || c == name
+	}
+	if !optional {
+		return true
+	}
+	if m.columns == nil {
+		return name == "project"
+	}
+	return m.columns[name]
+}
+
+// toggleColumn shows or hides an optional column.
+func (m *topModel) toggleColumn(name string) {
+	shown := make(map[string]bool, len(optionalColumns))
+	for _, c := range optionalColumns {
+		shown[c] = m.columnShown(c)
+	}
+	shown[name] = !shown[name]
+	m.columns = shown
+}
+
+// shownColumns lists the optional columns shown, for the saved state.
+func (m topModel) shownColumns() []string {
+	out := []string{}
+	for _, c := range optionalColumns {
+		if m.columnShown(c) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// tableLayout returns the columns the running table shows at width.
+// longest is the widest cell of each column. The command column takes the
+// width left, up to the width set in the chooser, or else its longest
+// command; what it does not need widens the name and project columns to
+// their longest cells, and with no width set goes back to the command.
+func (m topModel) tableLayout(width int, longest map[string]int) []tableColumn {
+	const sep = 2
+	var cols []tableColumn
+	used := 0
+	for _, c := range tableColumns {
+		if !m.columnShown(c.name) {
+			continue
+		}
+		cols = append(cols, c)
+		used += c.width
+	}
+	used += sep * (len(cols) - 1)
+	left := width - used
+	target := m.cmdWidth
+	if target <= 0 {
+		target = max(longest["command"], minCommandWidth)
+	}
+	cmdW := max(left, minCommandWidth)
+	if left > target {
+		cmdW = target
+		extra := left - target
+		for i := range cols {
+			if n := longest[cols[i].name]; (cols[i].name == "name" || cols[i].name == "project") && n > cols[i].width {
+				grow := min(n-cols[i].width, extra)
+				cols[i].width += grow
+				extra -= grow
+			}
+		}
+		if m.cmdWidth <= 0 {
+			cmdW += extra
+		}
+	}
+	for i := range cols {
+		if cols[i].name == "command" {
+			cols[i].width = cmdW
+		}
+	}
+	return cols
+}
+
+// commandLines fits a command into its column of width as overflow asks.
+func commandLines(cmd string, width int, overflow string) []string {
+	if runewidth.StringWidth(cmd) <= width {
+		return []string{cmd}
+	}
+	switch overflow {
+	case overflowTruncate:
+		return []string{runewidth.Truncate(cmd, width, "…")}
+	case overflowMiddle:
+		return []string{truncateMiddle(cmd, width)}
+	}
+	return wrapRunes(cmd, width)
+}
+
+// truncateMiddle cuts the middle out of s to fit width, marking the cut
+// with an ellipsis.
+func truncateMiddle(s string, width int) string {
+	if runewidth.StringWidth(s) <= width {
+		return s
+	}
+	if width < 3 {
+		return runewidth.Truncate(s, width, "")
+	}
+	head := (width - 1) / 2
+	tail := width - 1 - head
+	runes := []rune(s)
+	end := ""
+	for i := len(runes) - 1; i >= 0; i-- {
+		next := string(runes[i]) + end
+		if runewidth.StringWidth(next) > tail {
+			break
+		}
+		end = next
+	}
+	return runewidth.Truncate(s, head, "") + "…" + end
+}
+
+// serverRowCells returns the text of each running table column for srv.
+func (m topModel) serverRowCells(srv *models.ServerInfo, name string) map[string]string {
+	cells := map[string]string{
+		"host":      hostOf(srv),
+		"name":      name,
+		"port":      "-",
+		"pid":       "0",
+		"project":   "-",
+		"user":      "-",
+		"source":    sourceLabel(srv),
+		"framework": "-",
+		"command":   "-",
+		"uptime":    "-",
+		"cpu":       "-",
+		"mem":       "-",
+		"net":       "-",
+		"health":    "…",
+	}
+	if rec := srv.ProcessRecord; rec != nil {
+		if rec.ProjectRoot != "" {
+			cells["project"] = pathBase(rec.ProjectRoot)
+		} else if rec.CWD != "" {
+			cells["project"] = pathBase(rec.CWD)
+		}
+		cells["pid"] = fmt.Sprintf("%d", rec.PID)
+		cells["command"] = displayCommand(rec)
+		if rec.User != "" {
+			cells["user"] = rec.User
+		}
+		if s := rec.Stack(); s != "" {
+			cells["framework"] = s
+		}
+		if rec.StartTime != nil {
+			cells["uptime"] = formatUntil(time.Since(*rec.StartTime))
+		}
+		if rec.Port > 0 {
+			cells["port"] = fmt.Sprintf("%d", rec.Port)
+			if rec.Exposed() {
+				// Exposed on all interfaces: flag it as a security hint.
+				cells["port"] += " !"
+			}
+			if cached := m.healthIconOf(srv); cached != "" {
+				cells["health"] = cached
+			}
+		}
+	}
+	if cells["project"] == "-" && srv.ManagedService != nil && srv.ManagedService.CWD != "" {
+		cells["project"] = pathBase(srv.ManagedService.CWD)
+	}
+	if srv.Host != "" {
+		// Health is checked on this machine, which cannot reach the
+		// remote host's ports; its status stands in.
+		cells["health"] = "-"
+		if isCrashStatus(srv.Status) {
+			cells["health"] = srv.Status
+		}
+	}
+	if st, ok := m.stats[srv]; ok {
+		cells["cpu"] = fmt.Sprintf("%.1f%%", st.CPU)
+		cells["mem"] = st.Mem.String()
+	}
+	if rate, ok := m.trafficRate(srv); ok {
+		cells["net"] = formatRate(rate.In) + " " + formatRate(rate.Out)
+	}
+	return cells
+}
+
+// columnChooserRows is how many rows the chooser has: a row per optional
+// column, then the command width and overflow.
+var columnChooserRows = len(optionalColumns) + 2
+
+// updateColumnChooser handles a key in the column chooser.
+func (m *topModel) updateColumnChooser(key string) {
+	row := m.chooserSel
+	switch {
+	case m.keys.action(key) == "up":
+		m.chooserSel = max(row-1, 0)
+	case m.keys.action(key) == "down":
+		m.chooserSel = min(row+1, columnChooserRows-1)
+	case key == " " || key == "enter" || key == "right" || key == "left":
+		back := key == "left"
+		switch {
+		case row < len(optionalColumns):
+			m.toggleColumn(optionalColumns[row])
+		case row == len(optionalColumns):
+			m.adjustCommandWidth(back)
+		default:
+			m.cycleOverflow(back)
+		}
+	}
+}
+
+// adjustCommandWidth narrows the command column, or widens it. Widening it
+// past the terminal, or narrowing it below the minimum, sets it back to
+// taking the width left.
+func (m *topModel) adjustCommandWidth(narrower bool) {
+	w := m.cmdWidth
+	if w <= 0 {
+		w = m.commandWidth()
+	}
+	if narrower {
+		w -= commandWidthStep
+	} else {
+		w += commandWidthStep
+	}
+	if w < minCommandWidth || (m.width > 0 && w > m.width) {
+		w = 0
+	}
+	m.cmdWidth = w
+}
+
+// commandWidth is the width the command column has at the last render.
+func (m topModel) commandWidth() int {
+	width := m.width
+	if width <= 0 {
+		width = 120
+	}
+	for _, c := range m.tableLayout(m.mainWidth(width), nil) {
+		if c.name == "command" {
+			return c.width
+		}
+	}
+	return minCommandWidth
+}
+
+// cycleOverflow switches to the next way of fitting long commands, or the
+// previous one.
+func (m *topModel) cycleOverflow(back bool) {
+	i := 0
+	for j, o := range commandOverflows {
+		if o == m.overflow() {
+			i = j
+		}
+	}
+	step := 1
+	if back {
+		step = len(commandOverflows) - 1
+	}
+	m.cmdOverflow = commandOverflows[(i+step)%len(commandOverflows)]
+}
+
+// overflow is how long commands are fitted into their column.
+func (m topModel) overflow() string {
+	if m.cmdOverflow == "" {
+		return overflowWrap
+	}
+	return m.cmdOverflow
+}
+
+// renderColumnChooser renders the column chooser.
+func (m topModel) renderColumnChooser(width int) string {
+	th := m.styles()
+	headings := make(map[string]string)
+	for _, c := range tableColumns {
+		headings[c.name] = c.heading
+	}
+	var lines []string
+	lines = append(lines, th.title.Render(fitLine("Columns", width)), "")
+	row := func(i int, text string) {
+		marker := "  "
+		if i == m.chooserSel {
+			marker = "> "
+		}
+		line := fitLine(marker+text, width)
+		if i == m.chooserSel {
+			line = th.selected.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	for i, name := range optionalColumns {
+		box := "[ ]"
+		if m.columnShown(name) {
+			box = "[x]"
+		}
+		text := fmt.Sprintf("%s %s", box, headings[name])
+		if name == "net" && m.traffic != nil && m.traffic.err != nil {
+			text += " (unavailable: " + m.traffic.err.Error() + ")"
+		}
+		row(i, text)
+	}
+	cmdW := "fill the width left"
+	if m.cmdWidth > 0 {
+		cmdW = fmt.Sprintf("%d columns", m.cmdWidth)
+	}
+	row(len(optionalColumns), "Command width: ← "+cmdW+" →")
+	row(len(optionalColumns)+1, "Long commands: ← "+m.overflow()+" →")
+	lines = append(lines, "", th.muted.Render(fitLine("↑/↓ select, Space/Enter toggle, ←/→ change, Esc close; Name, Port, PID, Command, Health and Trend are always shown", width)))
+	return strings.Join(lines, "\n")
+}
+
+// healthIconOf is the icon of the last health check of srv's port, "" when
+// it has not been checked.
+func (m topModel) healthIconOf(srv *models.ServerInfo) string {
+	if rec := srv.ProcessRecord; rec != nil && rec.Port > 0 && srv.Host == "" {
+		return m.health[rec.Port]
+	}
+	return ""
+}
+
+// healthStatusOf is the status of the last health check of srv's port.
+func (m topModel) healthStatusOf(srv *models.ServerInfo) health.HealthStatus {
+	if rec := srv.ProcessRecord; rec != nil && rec.Port > 0 && srv.Host == "" {
+		if d := m.healthDetails[rec.Port]; d != nil {
+			return d.Status
+		}
+	}
+	return ""
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattn/go-runewidth"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestTableLayoutWidensNameWhenCommandIsShort(t *testing.T) {
+	t.Parallel()
+	m := newTestTopModel(t, nil)
+	cols := m.tableLayout(160, map[string]int{"name": 30, "command": 20})
+	total := 0
+	for _, c := range cols {
+		total += c.width + 2
+		switch c.name {
+		case "name":
+			if c.width != 30 {
+				t.Errorf("name width = %d, want its longest cell, 30", c.width)
+			}
+		case "project":
+			if c.width != 14 {
+				t.Errorf("project width = %d, want 14", c.width)
+			}
+		}
+	}
+	if total-2 != 160 {
+		t.Errorf("columns take %d, want the full width 160", total-2)
+	}
+
+	m.cmdWidth = 20
+	for _, c := range m.tableLayout(160, map[string]int{"command": 60}) {
+		if c.name == "command" && c.width != 20 {
+			t.Errorf("command width = %d, want the 20 set", c.width)
+		}
+	}
+}
+
+func TestCommandLinesOverflow(t *testing.T) {
+	t.Parallel()
+	cmd := "node ./node_modules/.bin/vite --port 5173"
+	if got := commandLines(cmd, 16, overflowWrap); len(got) != 3 {
+		t.Errorf("wrap = %q, want 3 lines", got)
+	}
+	if got := commandLines(cmd, 16, overflowTruncate); len(got) != 1 || !strings.HasPrefix(got[0], "node ./") || !strings.HasSuffix(got[0], "…") {
+		t.Errorf("truncate = %q", got)
+	}
+	got := commandLines(cmd, 16, overflowMiddle)
+	if len(got) != 1 || !strings.HasPrefix(got[0], "node ./") || !strings.HasSuffix(got[0], "5173") || runewidth.StringWidth(got[0]) != 16 {
+		t.Errorf("middle = %q", got)
+	}
+	if got := commandLines("vite", 16, overflowMiddle); got[0] != "vite" {
+		t.Errorf("short command changed to %q", got)
+	}
+}
+
+func TestColumnChooserTogglesColumns(t *testing.T) {
+	t.Parallel()
+	srv := &models.ServerInfo{ProcessRecord: &models.ProcessRecord{PID: 20, Port: 3000, Command: "vite", User: "alice"}}
+	m := newTestTopModel(t, []*models.ServerInfo{srv})
+	m.width = 120
+	if out := m.renderTable(120); strings.Contains(out, "alice") || !strings.Contains(out, "Project") {
+		t.Fatalf("default columns:\n%s", out)
+	}
+
+	m.mode = viewModeColumns
+	m.updateColumnChooser("enter") // Project
+	m.updateColumnChooser("down")
+	m.updateColumnChooser(" ") // User
+	out := m.renderTable(120)
+	if !strings.Contains(out, "alice") || strings.Contains(out, "Project") {
+		t.Errorf("after toggling Project and User:\n%s", out)
+	}
+
+	m.chooserSel = columnChooserRows - 1
+	m.updateColumnChooser("right")
+	if m.overflow() != overflowTruncate {
+		t.Errorf("overflow = %s, want truncate", m.overflow())
+	}
+	m.updateColumnChooser("left")
+	m.updateColumnChooser("left")
+	if m.overflow() != overflowMiddle {
+		t.Errorf("overflow = %s, want middle", m.overflow())
+	}
+}
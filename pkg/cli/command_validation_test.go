@@ -1,6 +1,12 @@
 package cli
 
-import "testing"
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/registry"
+)
 
 func TestValidateManagedCommand(t *testing.T) {
 	t.Parallel()
@@ -43,3 +49,43 @@ func TestFirstBlockedShellPattern(t *testing.T) {
 		t.Fatalf("expected no pattern, got %q (ok=%v)", p, ok)
 	}
 }
+
+func TestAddServiceAllowsShellPatternsOnlyWhenOptedIn(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	app := &App{registry: registry.NewRegistry(filepath.Join(dir, "registry.json"))}
+	cmd := "npm run dev -- --port $PORT | tee dev.log"
+	if err := app.AddServiceCmd(&models.ManagedService{Name: "direct", CWD: dir, Command: cmd}); err == nil {
+		t.Fatal("expected a shell pattern to be rejected without shell execution")
+	}
+	if err := app.AddServiceCmd(&models.ManagedService{Name: "shell", CWD: dir, Command: cmd, Shell: true}); err != nil {
+		t.Fatalf("expected shell service to be accepted: %v", err)
+	}
+	if err := app.AddServiceCmd(&models.ManagedService{Name: "empty", CWD: dir, Command: " ", Shell: true}); err == nil {
+		t.Fatal("expected an empty command to be rejected")
+	}
+}
+
+func TestAddServiceValidatesProcesses(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	app := &App{registry: registry.NewRegistry(filepath.Join(dir, "registry.json"))}
+	tests := []struct {
+		name    string
+		procs   []models.ServiceProcess
+		wantErr bool
+	}{
+		{"valid", []models.ServiceProcess{{Name: "web", Command: "npm run web"}, {Name: "worker", Command: "npm run worker"}}, false},
+		{"duplicate", []models.ServiceProcess{{Name: "web", Command: "npm run web"}, {Name: "web", Command: "npm run css"}}, true},
+		{"bad-name", []models.ServiceProcess{{Name: "web ui", Command: "npm run web"}}, true},
+		{"shell-pattern", []models.ServiceProcess{{Name: "web", Command: "npm run web && npm run css"}}, true},
+	}
+	for _, tt := range tests {
+		err := app.AddServiceCmd(&models.ManagedService{Name: tt.name, CWD: dir, Processes: tt.procs})
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: err = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -1,144 +1,586 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 	"text/tabwriter"
+	"time"
 
+	"github.com/devports/devpt/pkg/devpt"
+	"github.com/devports/devpt/pkg/events"
 	"github.com/devports/devpt/pkg/health"
+	"github.com/devports/devpt/pkg/history"
 	"github.com/devports/devpt/pkg/models"
 	"github.com/devports/devpt/pkg/process"
+	"github.com/devports/devpt/pkg/schedule"
+	"github.com/devports/devpt/pkg/telemetry"
 )
 
 // ListCmd handles the 'ls' command
-func (a *App) ListCmd(detailed bool) error {
+func (a *App) ListCmd(opts ListOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
 	servers, err := a.discoverServers()
 	if err != nil {
 		return err
 	}
 
-	return a.printServerTable(servers, detailed)
+	if opts.JSON {
+		for _, srv := range a.listedServers(servers, opts) {
+			if err := a.printJSON(newAPIServer(srv)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if opts.Format != "" && opts.Format != "table" {
+		return a.writeServerFeed(a.out(), servers, opts)
+	}
+	return a.printServerTable(servers, opts)
+}
+
+// SetIncludeUDP makes discovery report bound UDP sockets alongside TCP
+// listeners.
+func (a *App) SetIncludeUDP(on bool) {
+	a.includeUDP = on
+	a.scanner.SetIncludeUDP(on)
 }
 
-// printServerTable prints servers in tabular format
-func (a *App) printServerTable(servers []*models.ServerInfo, detailed bool) error {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+// SetShowAll makes discovery report every listener, not only those that
+// look like development servers.
+func (a *App) SetShowAll(on bool) {
+	a.showAll = on
+}
 
-	if detailed {
-		fmt.Fprintln(w, "Name\tPort\tPID\tProject\tCommand\tSource\tStatus")
-		for _, srv := range servers {
-			fmt.Fprintln(w, a.formatServerRow(srv, true))
+// printServerTable prints servers in tabular format, with local databases
+// and brokers in a separate Infrastructure section
+func (a *App) printServerTable(servers []*models.ServerInfo, opts ListOptions) error {
+	servers = a.filterServers(servers, opts)
+	var stats map[*models.ServerInfo]serverStats
+	if opts.needsStats() {
+		stats = a.collectServerStats(servers)
+	}
+	names := make(map[*models.ServerInfo]string, len(servers))
+	for _, srv := range servers {
+		names[srv] = a.serverCells(srv)["name"]
+	}
+
+	var apps, infra []*models.ServerInfo
+	for _, srv := range servers {
+		if isInfraServer(srv) {
+			infra = append(infra, srv)
+		} else {
+			apps = append(apps, srv)
 		}
-	} else {
-		fmt.Fprintln(w, "Name\tPort\tPID\tProject\tSource\tStatus")
-		for _, srv := range servers {
-			fmt.Fprintln(w, a.formatServerRow(srv, false))
+	}
+
+	if opts.Sort != "" {
+		for _, group := range [][]*models.ServerInfo{apps, infra} {
+			sortServers(group, opts, stats, names)
 		}
+	} else if a.nonInteractive {
+		// Scan order varies between runs; scripts diffing the output need a
+		// stable one.
+		for _, group := range [][]*models.ServerInfo{apps, infra} {
+			sort.SliceStable(group, func(i, j int) bool { return serverLess(group[i], group[j]) })
+		}
+	}
+	columns := opts.columns()
+	if err := a.writeServerRows(apps, columns, stats); err != nil {
+		return err
+	}
+	if len(infra) > 0 {
+		fmt.Fprintln(a.out(), "\nInfrastructure")
+		if err := a.writeServerRows(infra, columns, stats); err != nil {
+			return err
+		}
+	}
+	// The footnote explains the ! in the bind column.
+	for _, srv := range servers {
+		if contains(columns, "bind") && srv.ProcessRecord != nil && srv.ProcessRecord.Exposed() {
+			fmt.Fprintln(a.out(), "\n! listening on all interfaces: reachable from other machines on your network")
+			break
+		}
+	}
+	usage := a.logUsage()
+	if opts.Detailed {
+		if err := printLogUsage(a.out(), usage); err != nil {
+			return err
+		}
+	}
+	if warning := usage.Warning(); warning != "" {
+		fmt.Fprintf(a.out(), "\n! %s: run devpt gc to prune old logs\n", warning)
+	}
+	return nil
+}
+
+func (a *App) writeServerRows(servers []*models.ServerInfo, columns []string, stats map[*models.ServerInfo]serverStats) error {
+	w := tabwriter.NewWriter(a.out(), 0, 0, 2, ' ', 0)
+
+	headings := make([]string, len(columns))
+	for i, col := range columns {
+		headings[i] = columnHeading(col)
+	}
+	fmt.Fprintln(w, strings.Join(headings, "\t"))
+	for _, srv := range servers {
+		fmt.Fprintln(w, strings.Join(a.formatServerRow(srv, columns, stats), "\t"))
 	}
 
 	return w.Flush()
 }
 
-// formatServerRow formats a server as a table row
-func (a *App) formatServerRow(srv *models.ServerInfo, detailed bool) string {
-	name := "-"
-	port := "-"
-	pid := "-"
-	project := "-"
-	command := "-"
-	source := string(srv.Source)
-	status := srv.Status
+// serverLess orders servers by port, then PID, then managed service name.
+func serverLess(a, b *models.ServerInfo) bool {
+	var portA, portB, pidA, pidB int
+	if a.ProcessRecord != nil {
+		portA, pidA = a.ProcessRecord.Port, a.ProcessRecord.PID
+	}
+	if b.ProcessRecord != nil {
+		portB, pidB = b.ProcessRecord.Port, b.ProcessRecord.PID
+	}
+	if portA != portB {
+		return portA < portB
+	}
+	if pidA != pidB {
+		return pidA < pidB
+	}
+	var nameA, nameB string
+	if a.ManagedService != nil {
+		nameA = a.ManagedService.Name
+	}
+	if b.ManagedService != nil {
+		nameB = b.ManagedService.Name
+	}
+	return nameA < nameB
+}
 
-	if srv.ManagedService != nil {
-		name = srv.ManagedService.Name
-		if len(srv.ManagedService.Ports) > 0 {
-			port = fmt.Sprintf("%d", srv.ManagedService.Ports[0])
-		}
-		command = srv.ManagedService.Command
+// healthLabel renders a health status for command output, with its icon
+// unless output is kept plain; with text icons the marker says it all.
+func (a *App) healthLabel(status health.HealthStatus) string {
+	if a.nonInteractive {
+		return string(status)
+	}
+	if a.useTextIcons() {
+		return health.StatusText(status)
 	}
+	return health.StatusIcon(status) + " " + string(status)
+}
 
-	if srv.ProcessRecord != nil {
-		pid = fmt.Sprintf("%d", srv.ProcessRecord.PID)
-		port = fmt.Sprintf("%d", srv.ProcessRecord.Port)
-		project = srv.ProcessRecord.ProjectRoot
-		if command == "-" {
-			command = srv.ProcessRecord.Command
-		}
+// healthIcon is the marker of a health status: an emoji, or a word with
+// text icons.
+func (a *App) healthIcon(status health.HealthStatus) string {
+	if a.useTextIcons() {
+		return health.StatusText(status)
+	}
+	return health.StatusIcon(status)
+}
 
-		// Determine source
-		if srv.ProcessRecord.AgentTag != nil {
-			source = fmt.Sprintf("%s:%s", srv.ProcessRecord.AgentTag.Source, srv.ProcessRecord.AgentTag.AgentName)
-		} else {
-			source = string(models.SourceManual)
+// SetTextIcons shows health as OK, SLOW, TIMEOUT, DOWN and ? instead of
+// emoji, like health.icons set to "text".
+func (a *App) SetTextIcons() {
+	a.textIcons = true
+}
+
+// printJSON prints v as one line of JSON.
+func (a *App) printJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(a.out(), string(data))
+	return nil
+}
+
+// useTextIcons reports whether health is shown as text rather than emoji.
+func (a *App) useTextIcons() bool {
+	return a.textIcons || (a.settings != nil && a.settings.Health.Icons == "text")
+}
+
+// isInfraServer reports whether a server is an unmanaged database or broker.
+func isInfraServer(srv *models.ServerInfo) bool {
+	return srv != nil && srv.ManagedService == nil && srv.ProcessRecord != nil && srv.ProcessRecord.Infra != ""
+}
+
+// displayCommand returns the command shown for a listener. Container ports
+// show the container, or the VM, rather than the runtime's port proxy.
+func displayCommand(rec *models.ProcessRecord) string {
+	if rec.Container != nil {
+		return rec.Container.Runtime() + ": " + rec.Container.Label()
+	}
+	if rec.PortForward != nil {
+		return "k8s: " + rec.PortForward.Label()
+	}
+	return rec.Command
+}
+
+// containerStopError refuses to signal a container runtime's port proxy,
+// which would take down every container rather than the one on port.
+func containerStopError(port int, c *models.ContainerInfo) error {
+	if c.ID == "" && c.VM != "" {
+		shell := "limactl shell " + c.VM
+		if c.Runtime() == "colima" {
+			shell = "colima ssh"
+			if profile := strings.TrimPrefix(c.VM, "colima-"); profile != c.VM {
+				shell += " --profile " + profile
+			}
 		}
+		return fmt.Errorf("port %d is forwarded from VM %q; stop the process inside it, e.g. from: %s", port, c.VM, shell)
 	}
+	return fmt.Errorf("port %d is published by container %q; stop it with: docker stop %s", port, c.Name, c.Name)
+}
 
-	if detailed {
-		return fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s", name, port, pid, project, command, source, status)
+// bindLabel renders a listener's bind address, flagging servers exposed on
+// all interfaces with "!".
+func bindLabel(rec *models.ProcessRecord) string {
+	if rec == nil || rec.BindAddress == "" {
+		return "-"
 	}
+	if rec.Exposed() {
+		return rec.BindAddress + " !"
+	}
+	return rec.BindAddress
+}
 
-	return fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s", name, port, pid, project, source, status)
+// formatServerRow formats a server as the cells of a table row
+func (a *App) formatServerRow(srv *models.ServerInfo, columns []string, stats map[*models.ServerInfo]serverStats) []string {
+	cells := a.serverCells(srv)
+	st, sampled := stats[srv]
+	row := make([]string, len(columns))
+	for i, col := range columns {
+		switch col {
+		case "health":
+			row[i] = a.serverHealth(srv)
+		case "url":
+			row[i] = "-"
+			if statusMatches(srv.Status, "running") {
+				if target, err := serverURL(srv); err == nil {
+					row[i] = target
+				}
+			}
+		case "cpu", "mem", "uptime":
+			row[i] = "-"
+			if !sampled {
+				break
+			}
+			switch col {
+			case "cpu":
+				row[i] = fmt.Sprintf("%.1f%%", st.CPU)
+			case "mem":
+				row[i] = st.Mem.String()
+			case "uptime":
+				row[i] = formatUntil(st.Uptime)
+			}
+		default:
+			row[i] = cells[col]
+		}
+	}
+	return row
 }
 
 // AddCmd registers a new managed service
 func (a *App) AddCmd(name, cwd, command string, ports []int) error {
-	if err := validateManagedCommand(command); err != nil {
-		return err
-	}
-
-	svc := &models.ManagedService{
+	return a.AddServiceCmd(&models.ManagedService{
 		Name:    name,
 		CWD:     cwd,
 		Command: command,
 		Ports:   ports,
+	})
+}
+
+// AddServiceCmd registers a fully specified managed service
+func (a *App) AddServiceCmd(svc *models.ManagedService) error {
+	if err := assignProject(svc); err != nil {
+		return err
+	}
+	if err := a.validateService(svc); err != nil {
+		return err
 	}
 
 	if err := a.registry.AddService(svc); err != nil {
 		return err
 	}
+	if err := a.recordProject(svc); err != nil {
+		fmt.Fprintf(a.errOut(), "Warning: failed to record project %q: %v\n", svc.Project, err)
+	}
+	a.emit(events.Event{Type: events.ServiceAdded, Service: svc.Name, Message: svc.CommandSummary()})
+
+	fmt.Fprintf(a.out(), "Service %q registered successfully\n", svc.Name)
+	if svc.Shell {
+		fmt.Fprintf(a.errOut(), "Warning: %q runs through %s -c; the shell expands variables, globs and operators in its command, so register only commands you trust\n", svc.Name, process.UserShell())
+	}
+	return nil
+}
+
+// EditServiceCmd replaces the definition of a registered service. A running
+// service keeps its current process until it is restarted.
+func (a *App) EditServiceCmd(svc *models.ManagedService) error {
+	if err := a.validateService(svc); err != nil {
+		return err
+	}
+	if err := a.registry.UpdateService(svc); err != nil {
+		return err
+	}
+	a.emit(events.Event{Type: events.ServiceUpdated, Service: svc.Name, Message: svc.CommandSummary()})
+	return nil
+}
+
+// validateService checks a service before it is registered or updated.
+func (a *App) validateService(svc *models.ManagedService) error {
+	if svc.Compound() {
+		if err := validateProcesses(svc); err != nil {
+			return err
+		}
+	} else if err := validateServiceCommand(svc.Command, svc.Shell); err != nil {
+		return err
+	}
+	if svc.Health != nil && svc.Health.Command != "" {
+		if err := validateManagedCommand(svc.Health.Command); err != nil {
+			return fmt.Errorf("health command: %w", err)
+		}
+	}
+	for _, name := range svc.Requires {
+		if a.registry.GetJob(name) == nil {
+			return fmt.Errorf("required job %q is not registered; add it with devpt job add", name)
+		}
+	}
+	if svc.Schedule != "" {
+		if _, err := schedule.Parse(svc.Schedule); err != nil {
+			return err
+		}
+	}
+	if _, _, err := process.StopParams(svc); err != nil {
+		return err
+	}
+	if err := validateLimits(svc.Limits); err != nil {
+		return err
+	}
+	if err := validateServiceURLs(svc); err != nil {
+		return err
+	}
+	return validateEnv(svc.Env)
+}
+
+// validateServiceCommand checks a service or process command. Shell commands
+// are the user's to vouch for; only emptiness is checked.
+func validateServiceCommand(command string, shell bool) error {
+	if shell {
+		if strings.TrimSpace(command) == "" {
+			return fmt.Errorf("command cannot be empty")
+		}
+		return nil
+	}
+	return validateManagedCommand(command)
+}
 
-	fmt.Printf("Service %q registered successfully\n", name)
+// validateProcesses checks the processes of a compound service.
+func validateProcesses(svc *models.ManagedService) error {
+	if svc.Command != "" {
+		return fmt.Errorf("a service runs either a command or processes, not both")
+	}
+	if svc.PTY {
+		return fmt.Errorf("compound services cannot run in pty mode")
+	}
+	seen := make(map[string]bool, len(svc.Processes))
+	for _, p := range svc.Processes {
+		if p.Name == "" || strings.ContainsAny(p.Name, " \t|") {
+			return fmt.Errorf("invalid process name %q", p.Name)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("duplicate process name %q", p.Name)
+		}
+		seen[p.Name] = true
+		if err := validateServiceCommand(p.Command, svc.Shell); err != nil {
+			return fmt.Errorf("process %s: %w", p.Name, err)
+		}
+	}
 	return nil
 }
 
+// validateEnv checks "KEY=value" environment entries.
+func validateEnv(env []string) error {
+	for _, kv := range env {
+		key, _, ok := strings.Cut(kv, "=")
+		if !ok || !validEnvKey(key) {
+			return fmt.Errorf("invalid environment variable %q (want KEY=value)", kv)
+		}
+	}
+	return nil
+}
+
+func validEnvKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, r := range key {
+		if r != '_' && !(r >= 'A' && r <= 'Z') && !(r >= 'a' && r <= 'z') && !(i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
 // RemoveCmd removes a managed service
 func (a *App) RemoveCmd(name string) error {
-	return a.registry.RemoveService(name)
+	svc := a.registry.GetService(name)
+	if svc == nil {
+		return errServiceNotFound(name)
+	}
+	if err := a.registry.RemoveService(name); err != nil {
+		return err
+	}
+	a.forgetProjectIfEmpty(svc.Project)
+	a.removeBootAgent(name)
+	a.emit(events.Event{Type: events.ServiceRemoved, Service: name})
+	return nil
 }
 
 // StartCmd starts a managed service
 func (a *App) StartCmd(name string) error {
+	return a.StartServiceCmd(name, StartOptions{})
+}
+
+// StartServiceCmd starts a managed service with options
+func (a *App) StartServiceCmd(name string, opts StartOptions) error {
 	svc := a.registry.GetService(name)
 	if svc == nil {
-		return fmt.Errorf("service %q not found", name)
+		return errServiceNotFound(name)
+	}
+
+	fmt.Fprintf(a.out(), "Starting service %q...\n", name)
+	pid, err := a.launch(svc, opts)
+	if err != nil {
+		return err
+	}
+
+	a.notifyDaemon()
+	fmt.Fprintf(a.out(), "Service %q started with PID %d\n", name, pid)
+	return nil
+}
+
+// launch checks svc's ports, starts it, records its PID and emits a started event.
+func (a *App) launch(svc *models.ManagedService, opts StartOptions) (pid int, err error) {
+	ctx, span := a.tracer.Start(context.Background(), "devpt.start",
+		telemetry.String("devpt.service", svc.Name), telemetry.Bool("devpt.restart", opts.restart))
+	defer func() {
+		span.SetAttributes(telemetry.Int("devpt.pid", pid))
+		span.End(err)
+	}()
+	if svc.Ephemeral {
+		return 0, errEphemeral(svc.Name)
+	}
+	if !opts.restart && a.lastPIDState(svc) == pidLive {
+		return 0, fmt.Errorf("service %q is %w (PID %d)", svc.Name, ErrAlreadyRunning, *svc.LastPID)
+	}
+	if err := a.checkPorts(svc, opts); err != nil {
+		return 0, err
+	}
+	if len(svc.Requires) > 0 {
+		_, pre := a.tracer.Start(ctx, "devpt.prerequisites", telemetry.String("devpt.jobs", strings.Join(svc.Requires, ",")))
+		err := a.runPrerequisites(svc)
+		pre.End(err)
+		if err != nil {
+			return 0, err
+		}
+	}
+	a.noteCrashRestart(svc)
+
+	env := append([]string(nil), opts.env...)
+	runPort := 0
+	if svc.AutoPort || opts.AutoPort || opts.port > 0 {
+		port, err := a.reusePort(svc, opts.port)
+		if err != nil {
+			return 0, err
+		}
+		runPort = port
+		env = append(env, fmt.Sprintf("PORT=%d", port))
 	}
 
-	fmt.Printf("Starting service %q...\n", name)
-	pid, err := a.processManager.Start(svc)
+	if span != nil {
+		// Services that trace their own startup join the trace of the start.
+		env = append(env, telemetry.EnvTraceParent+"="+span.TraceParent())
+	}
+	pid, err = a.processManager.StartWithEnv(svc, env)
 	if err != nil {
-		return fmt.Errorf("failed to start service: %w", err)
+		return 0, fmt.Errorf("failed to start service: %w", err)
 	}
 
 	// Update registry with new PID
-	if err := a.registry.UpdateServicePID(name, pid); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to update registry: %v\n", err)
+	if err := a.registry.UpdateServicePID(svc.Name, pid); err != nil {
+		fmt.Fprintf(a.errOut(), "Warning: failed to update registry: %v\n", err)
+	}
+	if err := a.registry.SetRunPort(svc.Name, runPort); err != nil {
+		fmt.Fprintf(a.errOut(), "Warning: failed to update registry: %v\n", err)
 	}
 
-	fmt.Printf("Service %q started with PID %d\n", name, pid)
-	return nil
+	a.recordStart(svc, pid, runPort, opts)
+	if runPort > 0 {
+		fmt.Fprintf(a.out(), "Assigned port %d (PORT=%d)\n", runPort, runPort)
+	}
+	return pid, nil
+}
+
+// recordStart records a new run of svc in the registry and its history and
+// emits a started event.
+func (a *App) recordStart(svc *models.ManagedService, pid, runPort int, opts StartOptions) {
+	actor := a.runActor(opts.via)
+	run := models.RunRecord{PID: pid, StartedAt: time.Now(), Actor: actor, Restart: opts.restart}
+	if err := a.registry.RecordRun(svc.Name, run); err != nil {
+		fmt.Fprintf(a.errOut(), "Warning: failed to update registry: %v\n", err)
+	}
+
+	ev := events.Event{Type: events.ServiceStarted, Service: svc.Name, PID: pid, Port: runPort, Data: map[string]string{"actor": actor.String()}}
+	entry := history.Entry{Time: run.StartedAt, Action: history.Start, PID: pid, Port: runPort, Actor: actor.String()}
+	if opts.restart {
+		ev.Message = "restarted"
+		entry.Action = history.Restart
+	}
+	if entry.Port == 0 && len(svc.Ports) > 0 {
+		entry.Port = svc.Ports[0]
+	}
+	a.recordHistory(svc.Name, entry)
+	a.emit(ev)
+}
+
+// StopOptions overrides how a stop ends a process.
+type StopOptions struct {
+	// Signal replaces the service's stop signal (SIGTERM by default).
+	Signal syscall.Signal
+	// Timeout replaces how long the process gets to exit before it is killed.
+	Timeout time.Duration
+	// Cleanup stops processes of the service that outlive the stop, such as
+	// children that started their own session, without asking.
+	Cleanup bool
+	// Interactive asks on the terminal whether to stop such processes.
+	Interactive bool
 }
 
 // StopCmd stops a service by name or port
 func (a *App) StopCmd(identifier string) error {
+	return a.StopServiceCmd(identifier, StopOptions{})
+}
+
+// StopServiceCmd stops a service by name or port with options
+func (a *App) StopServiceCmd(identifier string, opts StopOptions) error {
+	if a.daemon != nil {
+		if handled, err := a.daemonCall(DaemonCallArgs{Op: DaemonStop, Target: identifier, Stop: opts}); handled {
+			return err
+		}
+	}
 	var targetPID int
 	targetServiceName := ""
+	var targetService *models.ManagedService
 
 	// Check if identifier is a service name
 	if svc := a.registry.GetService(identifier); svc != nil {
 		targetServiceName = svc.Name
+		targetService = svc
 		servers, err := a.discoverServers()
 		if err != nil {
 			return err
@@ -151,7 +593,7 @@ func (a *App) StopCmd(identifier string) error {
 		// Try parsing as port number
 		port, err := strconv.Atoi(identifier)
 		if err != nil {
-			return fmt.Errorf("invalid service name or port: %s", identifier)
+			return fmt.Errorf("invalid service name or port: %s: %w", identifier, ErrServiceNotFound)
 		}
 
 		// Find process by port
@@ -162,9 +604,13 @@ func (a *App) StopCmd(identifier string) error {
 
 		for _, srv := range servers {
 			if srv.ProcessRecord != nil && srv.ProcessRecord.Port == port {
+				if c := srv.ProcessRecord.Container; c != nil && srv.ManagedService == nil {
+					return containerStopError(port, c)
+				}
 				targetPID = srv.ProcessRecord.PID
 				if srv.ManagedService != nil {
 					targetServiceName = srv.ManagedService.Name
+					targetService = srv.ManagedService
 				}
 				break
 			}
@@ -175,84 +621,153 @@ func (a *App) StopCmd(identifier string) error {
 		}
 	}
 
+	if targetPID == 0 && targetService != nil && opts.Cleanup {
+		return a.cleanupLeftovers(targetService)
+	}
 	if targetPID == 0 {
 		return fmt.Errorf("cannot determine PID to stop")
 	}
 
+	var snap stopSnapshot
+	if targetService != nil {
+		snap = a.snapshotForStop(targetService, targetPID)
+	}
+
 	// Stop the process
-	fmt.Printf("Stopping PID %d...\n", targetPID)
-	if err := a.processManager.Stop(targetPID, 5000000000); err != nil { // 5 second timeout
+	fmt.Fprintf(a.out(), "Stopping PID %d...\n", targetPID)
+	if err := a.stopProcess(targetService, targetPID, opts); err != nil {
 		if errors.Is(err, process.ErrNeedSudo) {
-			return fmt.Errorf("requires sudo to terminate PID %d", targetPID)
+			return fmt.Errorf("%w (PID %d)", ErrNeedSudo, targetPID)
 		}
 		if isProcessFinishedErr(err) {
 			if targetServiceName != "" {
 				if clrErr := a.registry.ClearServicePID(targetServiceName); clrErr != nil {
-					fmt.Fprintf(os.Stderr, "Warning: failed to clear PID for %q: %v\n", targetServiceName, clrErr)
+					fmt.Fprintf(a.errOut(), "Warning: failed to clear PID for %q: %v\n", targetServiceName, clrErr)
 				}
 			}
+			a.emitStopped(targetServiceName, targetPID)
+			if targetService != nil {
+				a.reportLeftovers(targetService, snap, opts)
+			}
 			return nil
 		}
 		return fmt.Errorf("failed to stop process: %w", err)
 	}
 
-	fmt.Printf("Process %d stopped\n", targetPID)
+	fmt.Fprintf(a.out(), "Process %d stopped\n", targetPID)
 	if targetServiceName != "" {
 		if err := a.registry.ClearServicePID(targetServiceName); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to clear PID for %q: %v\n", targetServiceName, err)
+			fmt.Fprintf(a.errOut(), "Warning: failed to clear PID for %q: %v\n", targetServiceName, err)
 		}
 	}
+	a.emitStopped(targetServiceName, targetPID)
+	if targetService != nil {
+		a.reportLeftovers(targetService, snap, opts)
+	}
 	return nil
 }
 
+// stopProcess stops pid with the stop signal and timeout of svc, the managed
+// service it belongs to (nil for other processes), unless opts override them.
+func (a *App) stopProcess(svc *models.ManagedService, pid int, opts StopOptions) (err error) {
+	_, span := a.tracer.Start(context.Background(), "devpt.stop", telemetry.Int("devpt.pid", pid))
+	defer func() { span.End(err) }()
+	sig, timeout := syscall.SIGTERM, process.DefaultStopTimeout
+	if svc != nil {
+		span.SetAttributes(telemetry.String("devpt.service", svc.Name))
+		if sig, timeout, err = process.StopParams(svc); err != nil {
+			return err
+		}
+	}
+	if opts.Signal != 0 {
+		sig = opts.Signal
+	}
+	if opts.Timeout > 0 {
+		timeout = opts.Timeout
+	}
+	span.SetAttributes(telemetry.String("devpt.signal", sig.String()))
+	return a.processManager.StopWith(pid, sig, timeout)
+}
+
+// emitStopped records a stop requested through devpt. Unmanaged processes are
+// recorded by PID only.
+func (a *App) emitStopped(serviceName string, pid int) {
+	if serviceName != "" {
+		now := time.Now()
+		entry := history.Entry{Time: now, Action: history.Stop, PID: pid, Actor: a.runActor("").String()}
+		if start, ok := runStart(a.registry.GetService(serviceName), pid); ok {
+			entry.Duration = models.Duration(now.Sub(start))
+		}
+		a.recordHistory(serviceName, entry)
+		if err := a.registry.FinishRun(serviceName, pid, "stopped", time.Now()); err != nil {
+			fmt.Fprintf(a.errOut(), "Warning: failed to update registry: %v\n", err)
+		}
+	}
+	a.emit(events.Event{Type: events.ServiceStopped, Service: serviceName, PID: pid})
+}
+
 // RestartCmd restarts a managed service
 func (a *App) RestartCmd(name string) error {
 	svc := a.registry.GetService(name)
 	if svc == nil {
-		return fmt.Errorf("service %q not found", name)
+		return errServiceNotFound(name)
+	}
+	if svc.Ephemeral {
+		return errEphemeral(name)
 	}
 
+	a.noteCrashRestart(svc)
+
 	// Stop if running
 	if pid, err := a.validatedManagedPID(svc); err != nil {
 		return err
 	} else if pid > 0 {
-		fmt.Printf("Stopping service %q...\n", name)
-		if err := a.processManager.Stop(pid, 5000000000); err != nil { // 5 second timeout
-			fmt.Fprintf(os.Stderr, "Warning: failed to stop service: %v\n", err)
+		fmt.Fprintf(a.out(), "Stopping service %q...\n", name)
+		if err := a.stopProcess(svc, pid, StopOptions{}); err != nil {
+			fmt.Fprintf(a.errOut(), "Warning: failed to stop service: %v\n", err)
+		} else {
+			// Clear the PID so the deliberate stop is not counted as a crash.
+			if err := a.registry.ClearServicePID(name); err != nil {
+				fmt.Fprintf(a.errOut(), "Warning: failed to clear PID for %q: %v\n", name, err)
+			}
+			a.emitStopped(name, pid)
 		}
 	}
 
 	// Start
-	fmt.Printf("Starting service %q...\n", name)
-	pid, err := a.processManager.Start(svc)
+	fmt.Fprintf(a.out(), "Starting service %q...\n", name)
+	pid, err := a.launch(svc, StartOptions{restart: true})
 	if err != nil {
-		return fmt.Errorf("failed to start service: %w", err)
-	}
-
-	// Update registry
-	if err := a.registry.UpdateServicePID(name, pid); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to update registry: %v\n", err)
+		return err
 	}
 
-	fmt.Printf("Service %q restarted with PID %d\n", name, pid)
+	a.notifyDaemon()
+	fmt.Fprintf(a.out(), "Service %q restarted with PID %d\n", name, pid)
 	return nil
 }
 
-// LogsCmd displays recent logs for a service
-func (a *App) LogsCmd(name string, lines int) error {
+// LogsCmd displays recent logs for a service, or with asJSON prints them
+// as the REST API does.
+func (a *App) LogsCmd(name string, lines int, asJSON bool) error {
 	svc := a.registry.GetService(name)
 	if svc == nil {
-		return fmt.Errorf("service %q not found", name)
+		return errServiceNotFound(name)
 	}
 
 	logLines, err := a.processManager.Tail(svc.Name, lines)
 	if err != nil {
 		return err
 	}
+	if asJSON {
+		if logLines == nil {
+			logLines = []string{}
+		}
+		return a.printJSON(APILogs{Service: svc.Name, Lines: logLines})
+	}
 
-	fmt.Printf("Logs for service %q:\n", name)
+	fmt.Fprintf(a.out(), "Logs for service %q:\n", name)
 	for _, line := range logLines {
-		fmt.Println(line)
+		fmt.Fprintln(a.out(), line)
 	}
 
 	return nil
@@ -296,112 +811,394 @@ func (a *App) validatedManagedPID(svc *models.ManagedService) (int, error) {
 	return 0, nil
 }
 
-// StatusCmd shows detailed info for a specific server
-func (a *App) StatusCmd(identifier string) error {
+// StatusCmd shows detailed info for a specific server, or with asJSON
+// prints it with its health as the REST API does.
+func (a *App) StatusCmd(identifier string, asJSON bool) error {
 	servers, err := a.discoverServers()
 	if err != nil {
 		return err
 	}
 
-	var target *models.ServerInfo
+	target := findServer(servers, identifier)
+	if target == nil {
+		return &NotFoundError{Kind: "server", Name: identifier}
+	}
 
-	// Find by name or port
-	for _, srv := range servers {
-		if srv.ManagedService != nil && srv.ManagedService.Name == identifier {
-			target = srv
-			break
-		}
-		if srv.ProcessRecord != nil && fmt.Sprintf("%d", srv.ProcessRecord.Port) == identifier {
-			target = srv
-			break
+	if asJSON {
+		check := a.checkHealth(context.Background(), target)
+		out := newAPIServer(target)
+		out.Health = newAPIHealth(check)
+		if err := a.printJSON(out); err != nil {
+			return err
 		}
+		return serverStatusError(identifier, target, check)
 	}
+	check := a.printServerStatus(target)
+	return serverStatusError(identifier, target, check)
+}
 
-	if target == nil {
-		return fmt.Errorf("server %q not found", identifier)
+// serverStatusError returns the StatusError for a server that is stopped,
+// crashed or failing its health check, given the check printed for it (nil
+// when none ran). Starting and paused servers are up.
+func serverStatusError(name string, srv *models.ServerInfo, check *health.HealthCheck) error {
+	switch {
+	case srv.Status == "stopped":
+		return &StatusError{Name: name, Status: srv.Status, reason: ErrStopped}
+	case isCrashStatus(srv.Status):
+		return &StatusError{Name: name, Status: srv.Status, reason: ErrCrashed}
+	case check != nil && (check.Status == health.HealthDown || check.Status == health.HealthTimeout):
+		return &StatusError{Name: name, Status: string(check.Status), reason: ErrUnhealthy}
 	}
+	return nil
+}
 
-	return a.printServerStatus(target)
+// groupPIDs returns the PIDs of a running compound service's processes by
+// name, or nil when it is not running.
+func (a *App) groupPIDs(svc *models.ManagedService) map[string]int {
+	if !svc.Compound() || a.lastPIDState(svc) != pidLive {
+		return nil
+	}
+	pids, err := a.processManager.GroupPIDs(svc.Name)
+	if err != nil {
+		return nil
+	}
+	return pids
 }
 
-// printServerStatus prints detailed status for a server
-func (a *App) printServerStatus(srv *models.ServerInfo) error {
+// printServerStatus prints detailed status for a server and returns the
+// health check it ran, if any.
+func (a *App) printServerStatus(srv *models.ServerInfo) *health.HealthCheck {
+	var check *health.HealthCheck
 	line := "============================================================"
-	fmt.Println("\n" + line)
-	fmt.Println("SERVER DETAILS")
-	fmt.Println(line)
+	fmt.Fprintln(a.out(), "\n"+line)
+	fmt.Fprintln(a.out(), "SERVER DETAILS")
+	fmt.Fprintln(a.out(), line)
 
 	if srv.ManagedService != nil {
-		fmt.Printf("Name:    %s\n", srv.ManagedService.Name)
-		fmt.Printf("Command: %s\n", srv.ManagedService.Command)
-		fmt.Printf("CWD:     %s\n", srv.ManagedService.CWD)
-		fmt.Printf("Ports:   ")
+		fmt.Fprintf(a.out(), "Name:    %s\n", srv.ManagedService.Name)
+		if srv.ManagedService.Compound() {
+			fmt.Fprintln(a.out(), "Processes:")
+			pids := a.groupPIDs(srv.ManagedService)
+			for _, p := range srv.ManagedService.Processes {
+				pid := "-"
+				if pids[p.Name] > 0 {
+					pid = fmt.Sprintf("%d", pids[p.Name])
+				}
+				fmt.Fprintf(a.out(), "  %-12s PID %-8s %s\n", p.Name, pid, p.Command)
+			}
+			if srv.ManagedService.Shell {
+				fmt.Fprintf(a.out(), "         (via %s -c)\n", process.UserShell())
+			}
+		} else if srv.ManagedService.Shell {
+			fmt.Fprintf(a.out(), "Command: %s (via %s -c)\n", srv.ManagedService.Command, process.UserShell())
+		} else {
+			fmt.Fprintf(a.out(), "Command: %s\n", srv.ManagedService.Command)
+		}
+		fmt.Fprintf(a.out(), "CWD:     %s\n", srv.ManagedService.CWD)
+		if srv.ManagedService.Ephemeral {
+			fmt.Fprintln(a.out(), "Kind:    one-off (devpt run); unregistered when it exits")
+		}
+		for _, name := range srv.ManagedService.Requires {
+			last := "never run"
+			if runs := a.registry.RecentJobRuns(name, 1); len(runs) > 0 {
+				last = formatJobRun(runs[0], time.Now())
+			}
+			fmt.Fprintf(a.out(), "Requires: job %s (%s)\n", name, last)
+		}
+		fmt.Fprintf(a.out(), "Ports:   ")
 		for i, p := range srv.ManagedService.Ports {
 			if i > 0 {
-				fmt.Print(", ")
+				fmt.Fprint(a.out(), ", ")
+			}
+			fmt.Fprintf(a.out(), "%d", p)
+		}
+		if srv.ManagedService.RunPort > 0 {
+			if len(srv.ManagedService.Ports) > 0 {
+				fmt.Fprint(a.out(), ", ")
+			}
+			fmt.Fprintf(a.out(), "%d (auto)", srv.ManagedService.RunPort)
+		} else if srv.ManagedService.AutoPort {
+			fmt.Fprint(a.out(), "auto")
+		}
+		fmt.Fprintln(a.out())
+		if urls, err := serverURLs(srv); err == nil {
+			for i, u := range urls {
+				label := "URL:     "
+				if i > 0 {
+					label = "         "
+				}
+				if u.Name != "" {
+					fmt.Fprintf(a.out(), "%s%s (%s)\n", label, a.link(u.URL), u.Name)
+				} else {
+					fmt.Fprintf(a.out(), "%s%s\n", label, a.link(u.URL))
+				}
 			}
-			fmt.Printf("%d", p)
 		}
-		fmt.Println()
+		if hc := srv.ManagedService.Health; hc != nil {
+			fmt.Fprintf(a.out(), "Health:  %s\n", describeHealthConfig(hc))
+		}
+		if srv.ManagedService.StopSignal != "" || srv.ManagedService.StopTimeout > 0 {
+			if sig, timeout, err := process.StopParams(srv.ManagedService); err == nil {
+				fmt.Fprintf(a.out(), "Stop:    %s, killed after %s\n", process.SignalName(sig), timeout)
+			}
+		}
+		if limits := srv.ManagedService.Limits; limits != nil {
+			fmt.Fprintf(a.out(), "Limits:  %s\n", describeLimits(limits))
+		}
+		if use, ok := a.currentUsage(srv.ManagedService); ok {
+			line := fmt.Sprintf("Usage:   %s", use)
+			if use.CPU >= 0 {
+				line += " (cpu averaged since start)"
+			}
+			if use.Over != "" {
+				line += "; over limit: " + use.Over
+			}
+			fmt.Fprintln(a.out(), line)
+		}
+		if leftovers := a.liveLeftovers(srv.ManagedService); len(leftovers) > 0 {
+			fmt.Fprintf(a.out(), "Leftovers: still running after the last stop (devpt stop %s --cleanup)\n", srv.ManagedService.Name)
+			for _, l := range leftovers {
+				fmt.Fprintf(a.out(), "  %s\n", describeLeftover(l))
+			}
+		}
+		if len(srv.ManagedService.ReadyPatterns) > 0 {
+			fmt.Fprintf(a.out(), "Ready:   %s\n", strings.Join(quoteAll(srv.ManagedService.ReadyPatterns), " or "))
+			if srv.ReadyLine != "" {
+				fmt.Fprintf(a.out(), "Matched: %s\n", srv.ReadyLine)
+			}
+		}
+		if exit := srv.ManagedService.LastExit; exit != nil && srv.Status == "stopped" {
+			fmt.Fprintf(a.out(), "Exit:    %s\n", describeExit(exit, time.Now()))
+		}
+		if svc := srv.ManagedService; svc.VerifiedAt != nil && svc.LastPID != nil {
+			fmt.Fprintf(a.out(), "Verified: PID %d is still this service's process (checked %s)\n", *svc.LastPID, formatAgo(time.Since(*svc.VerifiedAt)))
+		}
 	}
 
 	if srv.ProcessRecord != nil {
-		fmt.Printf("\nPort:    %d\n", srv.ProcessRecord.Port)
-		fmt.Printf("PID:     %d\n", srv.ProcessRecord.PID)
-		fmt.Printf("PPID:    %d\n", srv.ProcessRecord.PPID)
-		fmt.Printf("User:    %s\n", srv.ProcessRecord.User)
-		fmt.Printf("Command: %s\n", srv.ProcessRecord.Command)
-		fmt.Printf("CWD:     %s\n", srv.ProcessRecord.CWD)
+		fmt.Fprintf(a.out(), "\nPort:    %d\n", srv.ProcessRecord.Port)
+		if rec := srv.ProcessRecord; rec.BindAddress != "" {
+			if rec.Exposed() {
+				fmt.Fprintf(a.out(), "Bind:    %s (all interfaces, reachable from your network)\n", rec.BindAddress)
+			} else {
+				fmt.Fprintf(a.out(), "Bind:    %s\n", rec.BindAddress)
+			}
+		}
+		fmt.Fprintf(a.out(), "PID:     %d\n", srv.ProcessRecord.PID)
+		if srv.ProcessRecord.PPID > 0 {
+			fmt.Fprintf(a.out(), "PPID:    %d\n", srv.ProcessRecord.PPID)
+		}
+		if srv.ProcessRecord.User != "" {
+			fmt.Fprintf(a.out(), "User:    %s\n", srv.ProcessRecord.User)
+		}
+		if srv.ProcessRecord.StartTime != nil {
+			fmt.Fprintf(a.out(), "Started: %s\n", describeStart(*srv.ProcessRecord.StartTime, time.Now()))
+		}
+		fmt.Fprintf(a.out(), "Command: %s\n", srv.ProcessRecord.Command)
+		fmt.Fprintf(a.out(), "CWD:     %s\n", srv.ProcessRecord.CWD)
 		if srv.ProcessRecord.ProjectRoot != "" {
-			fmt.Printf("Project: %s\n", srv.ProcessRecord.ProjectRoot)
+			fmt.Fprintf(a.out(), "Project: %s\n", srv.ProcessRecord.ProjectRoot)
+		}
+		if c := srv.ProcessRecord.Container; c != nil {
+			if c.ID != "" {
+				fmt.Fprintf(a.out(), "Docker:  %s (%s)\n", c.Name, c.ID)
+			}
+			if c.VM != "" {
+				fmt.Fprintf(a.out(), "VM:      %s (%s)\n", c.VM, c.Runtime())
+			}
+			if c.Workspace != "" {
+				fmt.Fprintf(a.out(), "Devcontainer: %s\n", c.Workspace)
+			}
+			if c.Image != "" {
+				fmt.Fprintf(a.out(), "Image:   %s\n", c.Image)
+			}
+			if c.ComposeService != "" {
+				fmt.Fprintf(a.out(), "Compose: %s/%s\n", c.ComposeProject, c.ComposeService)
+			}
+		}
+		if f := srv.ProcessRecord.PortForward; f != nil {
+			fmt.Fprintf(a.out(), "Forward: %s", f.Target)
+			if f.Namespace != "" {
+				fmt.Fprintf(a.out(), " in namespace %s", f.Namespace)
+			}
+			if f.Context != "" {
+				fmt.Fprintf(a.out(), " (context %s)", f.Context)
+			}
+			fmt.Fprintln(a.out())
+		}
+		if srv.ProcessRecord.Infra != "" {
+			fmt.Fprintf(a.out(), "Infra:   %s\n", srv.ProcessRecord.Infra)
+		}
+		if stack := srv.ProcessRecord.Stack(); stack != "" {
+			fmt.Fprintf(a.out(), "Stack:   %s\n", stack)
 		}
 
 		// Health check
 		dashes := "------------------------------------------------------------"
-		fmt.Println("\n" + dashes)
-		fmt.Println("HEALTH STATUS")
-		fmt.Println(dashes)
-		check := a.healthChecker.Check(srv.ProcessRecord.Port)
-		icon := health.StatusIcon(check.Status)
-		fmt.Printf("Status:   %s %s\n", icon, check.Status)
-		fmt.Printf("Response: %dms\n", check.ResponseMs)
-		fmt.Printf("Message:  %s\n", check.Message)
+		fmt.Fprintln(a.out(), "\n"+dashes)
+		fmt.Fprintln(a.out(), "HEALTH STATUS")
+		fmt.Fprintln(a.out(), dashes)
+		check = checkServerHealth(context.Background(), a.healthChecker, srv)
+		fmt.Fprintf(a.out(), "Status:   %s\n", a.healthLabel(check.Status))
+		fmt.Fprintf(a.out(), "Response: %dms\n", check.ResponseMs)
+		fmt.Fprintf(a.out(), "Message:  %s\n", check.Message)
 
 		// Agent detection
 		if srv.ProcessRecord.AgentTag != nil {
-			fmt.Println("\n" + dashes)
-			fmt.Println("AI AGENT DETECTION")
-			fmt.Println(dashes)
-			fmt.Printf("Source:     %s\n", srv.ProcessRecord.AgentTag.Source)
-			fmt.Printf("Agent:      %s\n", srv.ProcessRecord.AgentTag.AgentName)
-			fmt.Printf("Confidence: %s\n", srv.ProcessRecord.AgentTag.Confidence)
+			fmt.Fprintln(a.out(), "\n"+dashes)
+			fmt.Fprintln(a.out(), "AI AGENT DETECTION")
+			fmt.Fprintln(a.out(), dashes)
+			fmt.Fprintf(a.out(), "Source:     %s\n", srv.ProcessRecord.AgentTag.Source)
+			fmt.Fprintf(a.out(), "Agent:      %s\n", srv.ProcessRecord.AgentTag.AgentName)
+			fmt.Fprintf(a.out(), "Confidence: %s\n", srv.ProcessRecord.AgentTag.Confidence)
+			if tag := srv.ProcessRecord.AgentTag; tag.AncestorPID > 0 {
+				fmt.Fprintf(a.out(), "Ancestor:   %s (PID %d)\n", tag.AncestorName, tag.AncestorPID)
+			}
 		}
 	}
 
-	if srv.Status == "crashed" {
+	if srv.ProcessRecord == nil && srv.Status == "running" && srv.ManagedService != nil && srv.ManagedService.Health != nil && srv.ManagedService.Health.Command != "" {
 		dashes := "------------------------------------------------------------"
-		fmt.Println("\n" + dashes)
-		fmt.Println("CRASH DETAILS")
-		fmt.Println(dashes)
+		fmt.Fprintln(a.out(), "\n"+dashes)
+		fmt.Fprintln(a.out(), "HEALTH STATUS")
+		fmt.Fprintln(a.out(), dashes)
+		check = a.healthChecker.CheckService(srv.ManagedService, 0)
+		fmt.Fprintf(a.out(), "Status:   %s\n", a.healthLabel(check.Status))
+		fmt.Fprintf(a.out(), "Response: %dms\n", check.ResponseMs)
+		fmt.Fprintf(a.out(), "Message:  %s\n", check.Message)
+	}
+
+	if isCrashStatus(srv.Status) {
+		dashes := "------------------------------------------------------------"
+		fmt.Fprintln(a.out(), "\n"+dashes)
+		fmt.Fprintln(a.out(), "CRASH DETAILS")
+		fmt.Fprintln(a.out(), dashes)
+		if svc := srv.ManagedService; svc != nil && svc.RestartCount > 0 {
+			loop := a.crashLoopSettings()
+			recent := devpt.RecentCrashRestarts(svc, time.Now(), loop.Window.Std())
+			fmt.Fprintf(a.out(), "Restarts: %d total, %d in last %s\n", svc.RestartCount, recent, loop.Window.Std())
+			if srv.Status == "crash-looping" {
+				fmt.Fprintf(a.out(), "Backoff:  %s before the next automatic restart\n", process.CrashLoopBackoff(recent))
+			}
+		}
 		if srv.CrashReason != "" {
-			fmt.Printf("Reason: %s\n", srv.CrashReason)
+			fmt.Fprintf(a.out(), "Reason: %s\n", srv.CrashReason)
 		} else {
-			fmt.Println("Reason: unavailable")
+			fmt.Fprintln(a.out(), "Reason: unavailable")
 		}
 		if len(srv.CrashLogTail) > 0 {
-			fmt.Println("Recent logs:")
+			fmt.Fprintln(a.out(), "Recent logs:")
 			for _, line := range srv.CrashLogTail {
 				if strings.TrimSpace(line) == "" {
 					continue
 				}
-				fmt.Printf("  %s\n", line)
+				fmt.Fprintf(a.out(), "  %s\n", line)
 			}
 		}
 	}
 
-	fmt.Printf("\nStatus:   %s\n", srv.Status)
-	fmt.Printf("Source:   %s\n", srv.Source)
-	fmt.Println(line + "\n")
+	if svc := srv.ManagedService; svc != nil && len(svc.Runs) > 0 {
+		dashes := "------------------------------------------------------------"
+		fmt.Fprintln(a.out(), "\n"+dashes)
+		fmt.Fprintln(a.out(), "RECENT RUNS")
+		fmt.Fprintln(a.out(), dashes)
+		now := time.Now()
+		for _, run := range svc.RecentRuns(5) {
+			fmt.Fprintf(a.out(), "  %s\n", describeRun(run, runIsLive(svc, run, srv.Status), now))
+		}
+	}
+
+	fmt.Fprintf(a.out(), "\nStatus:   %s\n", srv.Status)
+	fmt.Fprintf(a.out(), "Source:   %s\n", srv.Source)
+	fmt.Fprintln(a.out(), line+"\n")
 
+	return check
+}
+
+// healthConfigOf returns the managed health settings for a server, falling
+// back to the probe tailored to its infra kind.
+func healthConfigOf(srv *models.ServerInfo) *models.HealthCheckConfig {
+	if srv == nil {
+		return nil
+	}
+	if srv.ManagedService != nil && srv.ManagedService.Health != nil {
+		return srv.ManagedService.Health
+	}
+	if srv.ProcessRecord != nil && srv.ProcessRecord.Infra != "" {
+		return health.InfraConfig(srv.ProcessRecord.Infra)
+	}
 	return nil
 }
+
+// checkServerHealth probes a listening server using healthConfigOf.
+func checkServerHealth(ctx context.Context, c *health.Checker, srv *models.ServerInfo) *health.HealthCheck {
+	if srv.ManagedService != nil && srv.ManagedService.Health != nil {
+		return c.CheckServiceContext(ctx, srv.ManagedService, srv.ProcessRecord.Port)
+	}
+	return c.CheckWithConfigContext(ctx, srv.ProcessRecord.Port, healthConfigOf(srv))
+}
+
+func describeHealthConfig(hc *models.HealthCheckConfig) string {
+	if hc.Command != "" {
+		parts := []string{fmt.Sprintf("command %q", hc.Command)}
+		if hc.Timeout > 0 {
+			parts = append(parts, "timeout "+hc.Timeout.Std().String())
+		}
+		if hc.Interval > 0 {
+			parts = append(parts, "every "+hc.Interval.Std().String())
+		}
+		return strings.Join(parts, ", ")
+	}
+	scheme := "http"
+	if hc.TLS {
+		scheme = "https"
+	}
+	path := hc.Path
+	if path == "" {
+		path = "/"
+	}
+	parts := []string{scheme + " " + path}
+	if hc.Protocol == models.HealthProtocolWebSocket {
+		parts[0] = "websocket " + path
+		if hc.TLS {
+			parts[0] = "websocket (tls) " + path
+		}
+	}
+	if hc.Protocol == models.HealthProtocolGRPC {
+		parts = []string{"grpc.health.v1"}
+		if hc.TLS {
+			parts[0] += " (tls)"
+		}
+		if hc.GRPCService != "" {
+			parts = append(parts, "service "+hc.GRPCService)
+		}
+	}
+	if len(hc.ExpectedStatus) > 0 {
+		codes := make([]string, 0, len(hc.ExpectedStatus))
+		for _, c := range hc.ExpectedStatus {
+			codes = append(codes, strconv.Itoa(c))
+		}
+		parts = append(parts, "status "+strings.Join(codes, ","))
+	}
+	if hc.ExpectBody != "" {
+		parts = append(parts, fmt.Sprintf("body %q", hc.ExpectBody))
+	}
+	if hc.Timeout > 0 {
+		parts = append(parts, "timeout "+hc.Timeout.Std().String())
+	}
+	if hc.Interval > 0 {
+		parts = append(parts, "every "+hc.Interval.Std().String())
+	}
+	if every := health.ProbeInterval(hc); every > 0 {
+		parts = append(parts, fmt.Sprintf("probe %s every %s", hc.ProbePath, every))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func quoteAll(in []string) []string {
+	out := make([]string, 0, len(in))
+	for _, s := range in {
+		out = append(out, strconv.Quote(s))
+	}
+	return out
+}
@@ -0,0 +1,242 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// conflictState is the port conflict of a managed service shown in
+// viewModeConflict; sel is the claim selected.
+type conflictState struct {
+	service string
+	sel     int
+}
+
+// portClaim is another managed service declaring a port, or a process
+// listening on it. pid is 0 for a service that is not running.
+type portClaim struct {
+	port    int
+	service string
+	state   string
+	pid     int
+	command string
+	srv     *models.ServerInfo
+}
+
+func (c portClaim) String() string {
+	var parts []string
+	if c.service != "" {
+		parts = append(parts, fmt.Sprintf("%s [%s]", c.service, c.state))
+	}
+	if c.pid > 0 {
+		parts = append(parts, fmt.Sprintf("PID %d", c.pid))
+	}
+	if c.command != "" {
+		parts = append(parts, c.command)
+	}
+	return strings.Join(parts, "  ")
+}
+
+// conflictKey identifies a conflict ignored with i in the conflict view.
+func conflictKey(service string, port int) string {
+	return fmt.Sprintf("%s:%d", service, port)
+}
+
+// conflictPorts lists the ports of svc that another managed service also
+// declares, leaving out those ignored.
+func (m topModel) conflictPorts(svc *models.ManagedService) []int {
+	var out []int
+	for _, p := range svc.ActivePorts() {
+		if m.ignoredConflicts[conflictKey(svc.Name, p)] || slices.Contains(out, p) {
+			continue
+		}
+		for _, other := range m.app.registry.ListServices() {
+			if other.Name != svc.Name && slices.Contains(other.ActivePorts(), p) {
+				out = append(out, p)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// portClaims lists who else claims the conflicting ports of svc: the other
+// managed services declaring them, and the processes listening on them.
+func (m topModel) portClaims(svc *models.ManagedService) []portClaim {
+	var out []portClaim
+	for _, p := range m.conflictPorts(svc) {
+		claimed := make(map[int]bool)
+		for _, other := range m.app.registry.ListServices() {
+			if other.Name == svc.Name || !slices.Contains(other.ActivePorts(), p) {
+				continue
+			}
+			c := portClaim{port: p, service: other.Name, state: m.serviceStatus(other.Name)}
+			if srv := m.managedServer(other); srv.ProcessRecord != nil && srv.ProcessRecord.PID > 0 {
+				c.pid = srv.ProcessRecord.PID
+				c.command = displayCommand(srv.ProcessRecord)
+				c.srv = srv
+				claimed[c.pid] = true
+			}
+			out = append(out, c)
+		}
+		for _, srv := range m.servers {
+			rec := srv.ProcessRecord
+			if rec == nil || rec.Port != p || rec.PID <= 0 || claimed[rec.PID] {
+				continue
+			}
+			if srv.ManagedService != nil && srv.ManagedService.Name == svc.Name {
+				continue
+			}
+			claimed[rec.PID] = true
+			c := portClaim{port: p, pid: rec.PID, command: displayCommand(rec), srv: srv}
+			if srv.ManagedService != nil {
+				c.service = srv.ManagedService.Name
+				c.state = m.serviceStatus(c.service)
+			}
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// openConflict shows the port conflict of the selected managed service.
+func (m *topModel) openConflict() string {
+	managed := m.managedServices()
+	if m.focus != focusManaged || m.managedSel < 0 || m.managedSel >= len(managed) {
+		return "Select a managed service flagged (port conflict) first"
+	}
+	svc := managed[m.managedSel]
+	if len(m.conflictPorts(svc)) == 0 {
+		return fmt.Sprintf("%q has no port conflict", svc.Name)
+	}
+	m.conflict = &conflictState{service: svc.Name}
+	m.mode = viewModeConflict
+	return ""
+}
+
+// updateConflict handles a key in the conflict view; it reports false for
+// keys it leaves to the keymap.
+func (m *topModel) updateConflict(key string) bool {
+	svc := m.app.registry.GetService(m.conflict.service)
+	if svc == nil {
+		m.closeConflict()
+		return true
+	}
+	claims := m.portClaims(svc)
+	if len(claims) == 0 {
+		m.closeConflict()
+		m.cmdStatus = fmt.Sprintf("%q has no port conflict anymore", svc.Name)
+		return true
+	}
+	sel := min(m.conflict.sel, len(claims)-1)
+	switch {
+	case key == "s":
+		m.stopClaim(claims[sel])
+	case key == "p":
+		m.cmdStatus = m.reassignPort(svc, claims[sel].port)
+		m.closeConflict()
+	case key == "i":
+		for _, p := range m.conflictPorts(svc) {
+			if m.ignoredConflicts == nil {
+				m.ignoredConflicts = make(map[string]bool)
+			}
+			m.ignoredConflicts[conflictKey(svc.Name, p)] = true
+		}
+		m.cmdStatus = fmt.Sprintf("Ignoring the port conflict of %q", svc.Name)
+		m.closeConflict()
+	case m.keys.action(key) == "up":
+		m.conflict.sel = max(sel-1, 0)
+	case m.keys.action(key) == "down":
+		m.conflict.sel = min(sel+1, len(claims)-1)
+	default:
+		return false
+	}
+	return true
+}
+
+func (m *topModel) closeConflict() {
+	m.conflict = nil
+	m.mode = viewModeTable
+}
+
+// stopClaim asks to stop the process of a claim, as the stop key does.
+func (m *topModel) stopClaim(c portClaim) {
+	if c.pid == 0 {
+		m.cmdStatus = fmt.Sprintf("%q is not running; give this service another port (p) instead", c.service)
+		return
+	}
+	if rec := c.srv.ProcessRecord; rec.Container != nil && c.service == "" {
+		m.cmdStatus = containerStopError(rec.Port, rec.Container).Error()
+		return
+	}
+	prompt := fmt.Sprintf("Stop PID %d holding port %d?", c.pid, c.port)
+	if c.service != "" {
+		prompt = fmt.Sprintf("Stop %q (PID %d) holding port %d?", c.service, c.pid, c.port)
+	}
+	m.conflict = nil
+	m.confirm = &confirmState{kind: confirmStopPID, prompt: prompt, pid: c.pid, serviceName: c.service}
+	m.mode = viewModeConfirm
+}
+
+// reassignPort declares a free port from ports.auto_min-auto_max for svc in
+// place of port.
+func (m *topModel) reassignPort(svc *models.ManagedService, port int) string {
+	i := slices.Index(svc.Ports, port)
+	if i < 0 {
+		return fmt.Sprintf("Port %d of %q is allocated per run; restart it to get another", port, svc.Name)
+	}
+	updated := *svc
+	updated.Ports = slices.Clone(svc.Ports)
+	free, err := m.app.allocatePort(&updated)
+	if err != nil {
+		return err.Error()
+	}
+	updated.Ports[i] = free
+	if err := m.app.EditServiceCmd(&updated); err != nil {
+		return err.Error()
+	}
+	msg := fmt.Sprintf("%q now declares port %d instead of %d; update its command if it hard-codes %d", svc.Name, free, port, port)
+	if m.isServiceRunning(svc.Name) {
+		msg += fmt.Sprintf(", then restart it (%s)", m.keys.short("restart"))
+	}
+	return msg
+}
+
+// renderConflict renders the port conflict view.
+func (m topModel) renderConflict(width int) string {
+	th := m.styles()
+	if m.conflict == nil {
+		return ""
+	}
+	svc := m.app.registry.GetService(m.conflict.service)
+	if svc == nil {
+		return fitLine("Service removed", width)
+	}
+	claims := m.portClaims(svc)
+	sel := min(m.conflict.sel, len(claims)-1)
+	lines := []string{th.title.Render(fitLine("Port conflict: "+svc.Name, width)), ""}
+	port := 0
+	for i, c := range claims {
+		if c.port != port {
+			port = c.port
+			lines = append(lines, fitLine(fmt.Sprintf("Port %d of %s is also claimed by:", port, svc.Name), width))
+		}
+		marker := "  "
+		if i == sel {
+			marker = "> "
+		}
+		line := fitLine(marker+c.String(), width)
+		if i == sel {
+			line = th.selected.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	if len(claims) == 0 {
+		lines = append(lines, fitLine("No conflict anymore", width))
+	}
+	lines = append(lines, "", th.muted.Render(fitLine(fmt.Sprintf("s stop selected  p give %s another port  i ignore this conflict  Esc back", svc.Name), width)))
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+func newConflictModel(t *testing.T) topModel {
+	t.Helper()
+	web := &models.ManagedService{Name: "web", CWD: "/src/web", Command: "npm run dev", Ports: []int{3000}}
+	m := newTestTopModel(t, []*models.ServerInfo{
+		{ManagedService: web, ProcessRecord: &models.ProcessRecord{PID: 42, Port: 3000, Command: "node server.js"}, Status: "running"},
+	})
+	for _, svc := range []*models.ManagedService{
+		{Name: "api", CWD: "/src/api", Command: "go run .", Ports: []int{3000, 9000}},
+		web,
+	} {
+		if err := m.app.registry.AddService(svc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m.focus = focusManaged
+	return m
+}
+
+func press(t *testing.T, m topModel, key string) topModel {
+	t.Helper()
+	msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+	if key == "esc" {
+		msg = tea.KeyMsg{Type: tea.KeyEsc}
+	}
+	next, _ := m.Update(msg)
+	return next.(topModel)
+}
+
+func TestConflictViewStopsOtherClaim(t *testing.T) {
+	t.Parallel()
+	m := newConflictModel(t)
+	if !strings.Contains(m.renderManaged(120), "(port conflict, R to resolve)") {
+		t.Fatalf("conflict not flagged:\n%s", m.renderManaged(120))
+	}
+
+	m = press(t, m, "R")
+	if m.mode != viewModeConflict {
+		t.Fatalf("mode = %v, status %q", m.mode, m.cmdStatus)
+	}
+	out := m.renderConflict(120)
+	if !strings.Contains(out, "Port 3000 of api") || !strings.Contains(out, "web [running]  PID 42") || strings.Contains(out, "9000") {
+		t.Errorf("conflict view:\n%s", out)
+	}
+
+	m = press(t, m, "s")
+	if m.mode != viewModeConfirm || m.confirm == nil || m.confirm.pid != 42 || m.confirm.serviceName != "web" {
+		t.Errorf("stop asked %+v in mode %v", m.confirm, m.mode)
+	}
+}
+
+func TestConflictViewIgnoresAndReassigns(t *testing.T) {
+	t.Parallel()
+	m := newConflictModel(t)
+	m = press(t, press(t, m, "R"), "i")
+	if m.mode != viewModeTable || strings.Contains(m.renderManaged(120), "api [stopped] (port conflict") {
+		t.Errorf("ignored conflict still flagged:\n%s", m.renderManaged(120))
+	}
+	if st := m.state(); len(st.IgnoredConflicts) != 1 || st.IgnoredConflicts[0] != "api:3000" {
+		t.Errorf("saved ignores = %q", st.IgnoredConflicts)
+	}
+
+	m.ignoredConflicts = nil
+	m = press(t, press(t, m, "R"), "p")
+	api := m.app.registry.GetService("api")
+	if api.Ports[0] == 3000 || api.Ports[1] != 9000 {
+		t.Errorf("api ports = %v, want 3000 replaced", api.Ports)
+	}
+	if len(m.conflictPorts(api)) != 0 {
+		t.Errorf("still conflicting: %s", m.cmdStatus)
+	}
+}
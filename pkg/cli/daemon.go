@@ -0,0 +1,501 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/scanner"
+)
+
+// DaemonProtocol is bumped whenever the daemon's RPC messages change.
+// Clients of a daemon speaking another version work locally instead.
+const DaemonProtocol = 2
+
+// NoDaemonEnv is the environment variable that keeps commands from using a
+// running daemon, set to anything but "", "0" or "false".
+const NoDaemonEnv = "DEVPT_NO_DAEMON"
+
+// DefaultDaemonInterval is how often the daemon re-discovers servers.
+const DefaultDaemonInterval = 2 * time.Second
+
+// daemonDialTimeout bounds how long a command waits for the daemon to
+// answer before working locally.
+const daemonDialTimeout = 300 * time.Millisecond
+
+// Operations on a service, as the API names them. Only stops run in the
+// daemon through DaemonService.Call: a started service inherits the
+// environment of the process that starts it (PATH, nvm, virtualenvs), so
+// starts and restarts run in the command that asks for them, which then
+// tells the daemon with Invalidate.
+const (
+	DaemonStart   = "start"
+	DaemonStop    = "stop"
+	DaemonRestart = "restart"
+)
+
+// DaemonHello is the daemon's answer to a connecting client.
+type DaemonHello struct {
+	Protocol  int
+	PID       int
+	StartedAt time.Time
+	Interval  time.Duration
+	ScannedAt time.Time
+	Clients   int
+}
+
+// DaemonServersArgs selects what Servers reports, like ls --all and --udp.
+type DaemonServersArgs struct {
+	All bool
+	UDP bool
+}
+
+// DaemonServersReply carries discovered servers as JSON, encoded while the
+// daemon holds its lock so that records it keeps updating are not raced.
+type DaemonServersReply struct {
+	Servers   json.RawMessage
+	Stats     scanner.ScanStats
+	ScannedAt time.Time
+}
+
+// DaemonCallArgs asks the daemon to stop a service on behalf of a client.
+type DaemonCallArgs struct {
+	Op     string
+	Target string // service name or port
+	Actor  models.RunActor
+	Stop   StopOptions
+}
+
+// DaemonCallReply is the output of a call and its error, if any. ErrKind
+// names the sentinel error the failure matched so the client can exit with
+// the same code.
+type DaemonCallReply struct {
+	Stdout  string
+	Stderr  string
+	Err     string
+	ErrKind string
+}
+
+// daemonErrKinds are the errors a call's failure is matched against.
+var daemonErrKinds = map[string]error{
+	"not_found":       ErrServiceNotFound,
+	"already_running": ErrAlreadyRunning,
+	"port_conflict":   ErrPortConflict,
+	"need_sudo":       ErrNeedSudo,
+}
+
+// daemonError is a failure reported by the daemon.
+type daemonError struct {
+	msg  string
+	kind error
+}
+
+func (e *daemonError) Error() string { return e.msg }
+func (e *daemonError) Unwrap() error { return e.kind }
+
+// DaemonService is the RPC service devpt daemon exposes. It owns the App:
+// every method runs under one lock, so concurrent clients see one
+// consistent registry and scan cache.
+type DaemonService struct {
+	app      *App
+	interval time.Duration
+	started  time.Time
+	shutdown context.CancelFunc
+
+	mu          sync.Mutex
+	servers     []*models.ServerInfo
+	scannedAt   time.Time
+	registryMod time.Time
+	clients     int
+}
+
+// Hello reports the daemon's protocol version and state.
+func (s *DaemonService) Hello(_ struct{}, reply *DaemonHello) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	*reply = DaemonHello{
+		Protocol:  DaemonProtocol,
+		PID:       os.Getpid(),
+		StartedAt: s.started,
+		Interval:  s.interval,
+		ScannedAt: s.scannedAt,
+		Clients:   s.clients,
+	}
+	return nil
+}
+
+// Servers returns the discovered servers, from the cache unless it is older
+// than the refresh interval or the registry changed since.
+func (s *DaemonService) Servers(args DaemonServersArgs, reply *DaemonServersReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	servers, scannedAt := s.servers, s.scannedAt
+	if args.All || args.UDP {
+		// Other views than the cached one are scanned on demand.
+		a := s.app
+		a.showAll = args.All
+		a.scanner.SetIncludeUDP(args.UDP)
+		var err error
+		servers, err = a.discoverServers()
+		a.showAll = false
+		a.scanner.SetIncludeUDP(false)
+		if err != nil {
+			return err
+		}
+		scannedAt = time.Now()
+	} else if s.stale(time.Now()) {
+		if err := s.refresh(); err != nil {
+			return err
+		}
+		servers, scannedAt = s.servers, s.scannedAt
+	}
+
+	data, err := json.Marshal(servers)
+	if err != nil {
+		return err
+	}
+	*reply = DaemonServersReply{Servers: data, Stats: s.app.scanStats, ScannedAt: scannedAt}
+	return nil
+}
+
+// Call runs a stop in the daemon and returns what it printed.
+func (s *DaemonService) Call(args DaemonCallArgs, reply *DaemonCallReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a := s.app
+	actor, via := a.actor, a.via
+	a.actor, a.via = &args.Actor, args.Actor.Via
+	defer func() { a.actor, a.via = actor, via }()
+
+	if args.Op != DaemonStop {
+		return fmt.Errorf("unsupported daemon operation: %s", args.Op)
+	}
+	opts := args.Stop
+	opts.Interactive = false
+	run := func() error { return a.StopServiceCmd(args.Target, opts) }
+
+	stdout, stderr, err := a.captureOutput(run)
+	reply.Stdout, reply.Stderr = stdout, stderr
+	if err != nil {
+		reply.Err = err.Error()
+		for kind, target := range daemonErrKinds {
+			if errors.Is(err, target) {
+				reply.ErrKind = kind
+				break
+			}
+		}
+	}
+	// The next Servers call should see the change.
+	s.scannedAt = time.Time{}
+	return nil
+}
+
+// Invalidate makes the next Servers call rescan, after a client started or
+// restarted a service itself.
+func (s *DaemonService) Invalidate(_ struct{}, _ *struct{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scannedAt = time.Time{}
+	return nil
+}
+
+// Shutdown stops the daemon.
+func (s *DaemonService) Shutdown(_ struct{}, _ *struct{}) error {
+	s.shutdown()
+	return nil
+}
+
+// stale reports whether the cache needs a new scan at now.
+func (s *DaemonService) stale(now time.Time) bool {
+	if now.Sub(s.scannedAt) >= s.interval {
+		return true
+	}
+	fi, err := os.Stat(s.app.config.RegistryFile)
+	return err == nil && !fi.ModTime().Equal(s.registryMod)
+}
+
+// refresh reloads the registry if it changed, runs due schedules like devpt
+// watch, and rescans. The caller holds s.mu.
+func (s *DaemonService) refresh() error {
+	a := s.app
+	if fi, err := os.Stat(a.config.RegistryFile); err == nil && !fi.ModTime().Equal(s.registryMod) {
+		a.reloadRegistry()
+		s.registryMod = fi.ModTime()
+	}
+	now := time.Now()
+	a.runDueSchedules(now)
+	a.sampleResources(now)
+	servers, err := a.discoverServers()
+	if err != nil {
+		return err
+	}
+	s.servers, s.scannedAt = servers, time.Now()
+	return nil
+}
+
+// captureOutput runs fn with the app's output going to buffers and returns
+// what it printed to each. The process's standard output and error are left
+// alone, so nothing printed elsewhere meanwhile ends up in the result.
+// Callers serialize the commands they run on the app, as fn sees the
+// buffers through it.
+func (a *App) captureOutput(fn func() error) (stdout, stderr string, err error) {
+	a.outputMu.Lock()
+	defer a.outputMu.Unlock()
+
+	var outBuf, errBuf bytes.Buffer
+	savedOut, savedErr := a.stdout, a.stderr
+	a.stdout, a.stderr = &outBuf, &errBuf
+	defer func() { a.stdout, a.stderr = savedOut, savedErr }()
+	err = fn()
+	return outBuf.String(), errBuf.String(), err
+}
+
+// DaemonRunCmd runs devpt daemon in the foreground: it serves clients on
+// the daemon socket and rescans every interval until interrupted or asked
+// to stop. Like the TUI and devpt watch, it runs scheduled jobs and
+// services while it runs.
+func (a *App) DaemonRunCmd(interval time.Duration) error {
+	if interval <= 0 {
+		interval = DefaultDaemonInterval
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ln, err := listenDaemon(a.config.DaemonSocket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(a.config.DaemonSocket)
+	defer ln.Close()
+
+	a.SetNonInteractive(false)
+	svc := &DaemonService{app: a, interval: interval, started: time.Now(), shutdown: stop}
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("Devpt", svc); err != nil {
+		return err
+	}
+
+	svc.mu.Lock()
+	if err := svc.refresh(); err != nil {
+		fmt.Fprintf(a.errOut(), "Warning: %v\n", err)
+	}
+	svc.mu.Unlock()
+	fmt.Fprintf(a.out(), "devpt daemon listening on %s (PID %d)\n", a.config.DaemonSocket, os.Getpid())
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				svc.mu.Lock()
+				svc.clients++
+				svc.mu.Unlock()
+				srv.ServeCodec(jsonrpc.NewServerCodec(conn))
+				svc.mu.Lock()
+				svc.clients--
+				svc.mu.Unlock()
+			}()
+		}
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(a.out(), "devpt daemon stopped")
+			return nil
+		case <-ticker.C:
+			svc.mu.Lock()
+			if svc.stale(time.Now()) {
+				if err := svc.refresh(); err != nil {
+					fmt.Fprintf(a.errOut(), "Warning: %v\n", err)
+				}
+			}
+			svc.mu.Unlock()
+		}
+	}
+}
+
+// listenDaemon listens on the daemon socket, replacing a socket left
+// behind by a daemon that is gone.
+func listenDaemon(path string) (net.Listener, error) {
+	if conn, err := net.DialTimeout("unix", path, daemonDialTimeout); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("devpt daemon is already running (%s)", path)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// daemonClient is a connection to a running devpt daemon.
+type daemonClient struct {
+	rpc   *rpc.Client
+	hello DaemonHello
+}
+
+// dialDaemon connects to the daemon on path and checks that it speaks
+// DaemonProtocol.
+func dialDaemon(path string) (*daemonClient, error) {
+	conn, err := net.DialTimeout("unix", path, daemonDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &daemonClient{rpc: jsonrpc.NewClient(conn)}
+	if err := c.rpc.Call("Devpt.Hello", struct{}{}, &c.hello); err != nil {
+		c.rpc.Close()
+		return nil, err
+	}
+	if c.hello.Protocol != DaemonProtocol {
+		c.rpc.Close()
+		return nil, fmt.Errorf("devpt daemon speaks protocol %d, want %d; restart it", c.hello.Protocol, DaemonProtocol)
+	}
+	return c, nil
+}
+
+// ConnectDaemon makes the app a client of a running devpt daemon, so that
+// discovery comes from its scan cache and stops go through it.
+// Without a daemon, or with NoDaemonEnv set, the app keeps working on its
+// own and ConnectDaemon returns false.
+func (a *App) ConnectDaemon() bool {
+	switch os.Getenv(NoDaemonEnv) {
+	case "", "0", "false":
+	default:
+		return false
+	}
+	c, err := dialDaemon(a.config.DaemonSocket)
+	if err != nil {
+		if _, statErr := os.Stat(a.config.DaemonSocket); statErr == nil && !errors.Is(err, syscall.ECONNREFUSED) {
+			fmt.Fprintf(a.errOut(), "Warning: not using devpt daemon: %v\n", err)
+		}
+		return false
+	}
+	a.daemon = c
+	return true
+}
+
+// dropDaemon closes the daemon connection after a failed call; the app
+// works locally from then on.
+func (a *App) dropDaemon(err error) {
+	fmt.Fprintf(a.errOut(), "Warning: devpt daemon unavailable, working locally: %v\n", err)
+	a.daemon.rpc.Close()
+	a.daemon = nil
+}
+
+// servers fetches discovered servers from the daemon.
+func (c *daemonClient) servers(ctx context.Context, args DaemonServersArgs) ([]*models.ServerInfo, scanner.ScanStats, error) {
+	var reply DaemonServersReply
+	if err := c.call(ctx, "Devpt.Servers", args, &reply); err != nil {
+		return nil, scanner.ScanStats{}, err
+	}
+	var servers []*models.ServerInfo
+	if err := json.Unmarshal(reply.Servers, &servers); err != nil {
+		return nil, scanner.ScanStats{}, err
+	}
+	return servers, reply.Stats, nil
+}
+
+// call is rpc.Client.Call, returning ctx's error without waiting for the
+// reply once ctx is done.
+func (c *daemonClient) call(ctx context.Context, method string, args, reply interface{}) error {
+	call := c.rpc.Go(method, args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-call.Done:
+		return call.Error
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// notifyDaemon tells the daemon, if any, that a service was started or
+// restarted, so that its next scan does not wait for the interval.
+func (a *App) notifyDaemon() {
+	if a.daemon == nil {
+		return
+	}
+	if err := a.daemon.rpc.Call("Devpt.Invalidate", struct{}{}, &struct{}{}); err != nil {
+		a.dropDaemon(err)
+	}
+}
+
+// daemonCall runs op on target in the daemon and prints its output.
+// handled is false when the daemon could not be reached, and the caller
+// should do the work itself.
+func (a *App) daemonCall(args DaemonCallArgs) (handled bool, err error) {
+	args.Actor = a.runActor("")
+	var reply DaemonCallReply
+	if err := a.daemon.rpc.Call("Devpt.Call", args, &reply); err != nil {
+		var serverErr rpc.ServerError
+		if errors.As(err, &serverErr) {
+			return true, err
+		}
+		a.dropDaemon(err)
+		return false, nil
+	}
+	fmt.Fprint(a.out(), reply.Stdout)
+	fmt.Fprint(a.errOut(), reply.Stderr)
+	if reply.Err != "" {
+		return true, &daemonError{msg: reply.Err, kind: daemonErrKinds[reply.ErrKind]}
+	}
+	return true, nil
+}
+
+// DaemonStatusCmd reports whether a daemon is running and what it serves.
+func (a *App) DaemonStatusCmd() error {
+	c, err := dialDaemon(a.config.DaemonSocket)
+	if err != nil {
+		fmt.Fprintln(a.out(), "devpt daemon is not running")
+		return &StatusError{Name: "devpt daemon", Status: "stopped", reason: ErrStopped}
+	}
+	defer c.rpc.Close()
+	h := c.hello
+	fmt.Fprintf(a.out(), "devpt daemon is running (PID %d)\n", h.PID)
+	fmt.Fprintf(a.out(), "  Socket:    %s\n", a.config.DaemonSocket)
+	fmt.Fprintf(a.out(), "  Started:   %s\n", describeStart(h.StartedAt, time.Now()))
+	fmt.Fprintf(a.out(), "  Interval:  %s\n", h.Interval)
+	if !h.ScannedAt.IsZero() {
+		fmt.Fprintf(a.out(), "  Last scan: %s\n", formatAgo(time.Since(h.ScannedAt)))
+	}
+	// Not counting this connection.
+	fmt.Fprintf(a.out(), "  Clients:   %d\n", h.Clients-1)
+	return nil
+}
+
+// DaemonStopCmd asks a running daemon to exit.
+func (a *App) DaemonStopCmd() error {
+	c, err := dialDaemon(a.config.DaemonSocket)
+	if err != nil {
+		return fmt.Errorf("devpt daemon is not running")
+	}
+	defer c.rpc.Close()
+	if err := c.rpc.Call("Devpt.Shutdown", struct{}{}, &struct{}{}); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out(), "Stopped devpt daemon (PID %d)\n", c.hello.PID)
+	return nil
+}
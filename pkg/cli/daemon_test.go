@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/registry"
+)
+
+// serveTestDaemon serves svc on a socket in a temp dir and returns a client
+// app connected to it.
+func serveTestDaemon(t *testing.T, svc *DaemonService) *App {
+	t.Helper()
+	// Unix socket paths are short; t.TempDir can exceed the limit on macOS.
+	dir, err := os.MkdirTemp("", "devpt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "d.sock")
+
+	ln, err := listenDaemon(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("Devpt", svc); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go srv.ServeCodec(jsonrpc.NewServerCodec(conn))
+		}
+	}()
+
+	client := &App{config: models.ConfigPaths{DaemonSocket: socket}}
+	if !client.ConnectDaemon() {
+		t.Fatal("expected the client to connect")
+	}
+	t.Cleanup(func() { client.daemon.rpc.Close() })
+	return client
+}
+
+func TestDaemonCallKeepsErrorKinds(t *testing.T) {
+	dir := t.TempDir()
+	server := &App{registry: registry.NewRegistry(filepath.Join(dir, "registry.json"))}
+	client := serveTestDaemon(t, &DaemonService{app: server, interval: time.Minute})
+
+	err := client.StopServiceCmd("missing", StopOptions{})
+	if !errors.Is(err, ErrServiceNotFound) {
+		t.Fatalf("expected ErrServiceNotFound through the daemon, got %v", err)
+	}
+	if err.Error() != "invalid service name or port: missing: service not found" {
+		t.Fatalf("unexpected message %q", err)
+	}
+}
+
+func TestStartRunsInTheClient(t *testing.T) {
+	dir := t.TempDir()
+	server := &App{registry: registry.NewRegistry(filepath.Join(dir, "server.json"))}
+	svc := &DaemonService{app: server, interval: time.Minute, scannedAt: time.Now()}
+	client := serveTestDaemon(t, svc)
+	client.registry = registry.NewRegistry(filepath.Join(dir, "client.json"))
+	if err := client.registry.AddService(&models.ManagedService{Name: "web", CWD: dir, Command: "npm start", Ephemeral: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The daemon does not know "web"; only a local start finds it.
+	err := client.StartServiceCmd("web", StartOptions{})
+	if err == nil || errors.Is(err, ErrServiceNotFound) {
+		t.Fatalf("expected the client's own refusal to start an ephemeral service, got %v", err)
+	}
+	var reply DaemonCallReply
+	if err := svc.Call(DaemonCallArgs{Op: DaemonStart, Target: "web"}, &reply); err == nil {
+		t.Fatal("expected the daemon to refuse starts")
+	}
+
+	client.notifyDaemon()
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	if !svc.scannedAt.IsZero() {
+		t.Fatal("expected notifyDaemon to invalidate the daemon's scan cache")
+	}
+}
+
+func TestListenDaemonRefusesWhileServing(t *testing.T) {
+	dir, err := os.MkdirTemp("", "devpt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "d.sock")
+
+	ln, err := listenDaemon(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listenDaemon(socket); err == nil {
+		t.Fatal("expected a second daemon to be refused")
+	}
+	ln.Close()
+
+	// A socket left behind by a daemon that is gone is replaced.
+	if err := os.WriteFile(socket, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ln, err = listenDaemon(socket)
+	if err != nil {
+		t.Fatalf("expected a stale socket to be replaced: %v", err)
+	}
+	ln.Close()
+}
+
+func TestCaptureOutputUsesTheAppsWriters(t *testing.T) {
+	saved := os.Stdout
+	app := &App{}
+	stdout, stderr, err := app.captureOutput(func() error {
+		fmt.Fprintln(app.out(), "hello")
+		fmt.Fprintln(app.errOut(), "warning")
+		return errors.New("boom")
+	})
+	if os.Stdout != saved {
+		t.Fatal("os.Stdout was replaced")
+	}
+	if stdout != "hello\n" || stderr != "warning\n" || err == nil || err.Error() != "boom" {
+		t.Fatalf("got stdout %q, stderr %q, err %v", stdout, stderr, err)
+	}
+
+	func() {
+		defer func() { _ = recover() }()
+		_, _, _ = app.captureOutput(func() error { panic("boom") })
+	}()
+	if app.out() != os.Stdout || app.errOut() != os.Stderr {
+		t.Fatal("the app's writers were not restored after a panic")
+	}
+}
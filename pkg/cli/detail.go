@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/devports/devpt/pkg/health"
+)
+
+// Detail panel layout: the panel takes detailPanelShare of the width,
+// within these bounds, and moves below the tables on terminals narrower
+// than detailPanelMinTotal.
+const (
+	detailPanelShare    = 0.4
+	detailPanelMinWidth = 36
+	detailPanelMaxWidth = 72
+	detailPanelMinTotal = 100
+)
+
+// detailPanelWidth returns the width of the detail panel beside content
+// totalling width, or 0 when it does not fit beside it.
+func detailPanelWidth(width int) int {
+	if width < detailPanelMinTotal {
+		return 0
+	}
+	w := int(float64(width) * detailPanelShare)
+	return min(max(w, detailPanelMinWidth), detailPanelMaxWidth)
+}
+
+// withDetailPanel lays out main, rendered at mainWidth, and the detail
+// panel of the selected row side by side, or one above the other when the
+// terminal is too narrow.
+func (m topModel) withDetailPanel(main string, width int) string {
+	panelW := detailPanelWidth(width)
+	if panelW == 0 {
+		return main + "\n\n" + m.renderDetail(width)
+	}
+	border := lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(m.styles().border).
+		PaddingLeft(1)
+	return lipgloss.JoinHorizontal(lipgloss.Top, main, " ", border.Render(m.renderDetail(panelW-3)))
+}
+
+// mainWidth is the width left for the tables beside the detail panel.
+func (m topModel) mainWidth(width int) int {
+	if !m.showDetail {
+		return width
+	}
+	if w := detailPanelWidth(width); w > 0 {
+		return width - w - 1
+	}
+	return width
+}
+
+// renderDetail renders everything known about the selected row, for the
+// detail panel: what devpt status prints, without leaving the TUI.
+func (m topModel) renderDetail(width int) string {
+	srv, msg := m.selectedServer()
+	if srv == nil {
+		return fitLine(msg, width)
+	}
+	titleStyle := m.styles().title
+	labelStyle := m.styles().muted
+	const labelW = 10
+	var lines []string
+	// field adds labelled values, wrapped, one per line; only the first
+	// line carries the label.
+	field := func(label string, values ...string) {
+		for _, value := range values {
+			if value == "" {
+				continue
+			}
+			for _, l := range wrapRunes(value, width-labelW) {
+				lines = append(lines, labelStyle.Render(fixedCell(label, labelW))+l)
+				label = ""
+			}
+		}
+	}
+	section := func(title string) {
+		lines = append(lines, "", titleStyle.Render(fitLine(title, width)))
+	}
+
+	lines = append(lines, titleStyle.Render(fitLine(fmt.Sprintf("%s (%s)", m.serviceNameFor(srv), srv.Status), width)))
+	now := time.Now()
+	svc, rec := srv.ManagedService, srv.ProcessRecord
+
+	if svc != nil {
+		if svc.Compound() {
+			procs := make([]string, len(svc.Processes))
+			for i, p := range svc.Processes {
+				procs[i] = p.Name + ": " + p.Command
+			}
+			field("Processes", procs...)
+		} else {
+			field("Command", svc.Command)
+		}
+		field("CWD", svc.CWD)
+		field("Env", svc.Env...)
+		field("Tags", strings.Join(svc.Tags, ", "))
+	}
+	if rec != nil {
+		if cmd := displayCommand(rec); svc == nil {
+			field("Command", cmd)
+		} else if cmd != svc.Command {
+			field("Running", cmd)
+		}
+		if svc == nil || rec.CWD != svc.CWD {
+			field("CWD", rec.CWD)
+		}
+		field("Project", rec.ProjectRoot)
+		field("Framework", rec.Stack())
+		field("PID", fmt.Sprintf("%d", rec.PID))
+		if rec.Port > 0 {
+			field("Port", fmt.Sprintf("%d %s", rec.Port, bindLabel(rec)))
+		}
+		if rec.StartTime != nil {
+			field("Started", describeStart(*rec.StartTime, now))
+		}
+		if tag := rec.AgentTag; tag != nil {
+			agent := fmt.Sprintf("%s via %s (%s confidence)", tag.AgentName, tag.Source, tag.Confidence)
+			if tag.AncestorPID > 0 {
+				agent += fmt.Sprintf(", %s PID %d", tag.AncestorName, tag.AncestorPID)
+			}
+			field("Agent", agent)
+		}
+	}
+	if urls, err := serverURLs(srv); err == nil {
+		label := "URL"
+		for _, u := range urls {
+			text := u.URL
+			if u.Name != "" {
+				text += " (" + u.Name + ")"
+			}
+			for _, l := range wrapRunes(text, width-labelW) {
+				lines = append(lines, labelStyle.Render(fixedCell(label, labelW))+m.linkURL(l, u.URL))
+				label = ""
+			}
+		}
+	}
+
+	section("Health")
+	port := portOf(srv)
+	check := m.healthDetails[port]
+	if check == nil && svc != nil {
+		check = m.serviceHealth[svc.Name]
+	}
+	if check == nil {
+		lines = append(lines, fitLine("not checked yet", width))
+	} else {
+		status := fmt.Sprintf("%s %s %dms %s", m.app.healthIcon(check.Status), check.Status, check.ResponseMs, check.Message)
+		for _, l := range wrapRunes(status, width) {
+			lines = append(lines, m.styles().healthStyle(check.Status).Render(l))
+		}
+	}
+	if svc != nil && health.ProbeInterval(svc.Health) > 0 {
+		field("Probe", m.describeProbe(svc, now))
+	}
+	if port > 0 {
+		if recent := m.healthHist.Recent(port, healthHistoryRows); len(recent) > 0 {
+			lines = append(lines, fitLine("Trend: "+health.Sparkline(m.healthHist.Recent(port, 0)), width))
+			for i := len(recent) - 1; i >= 0; i-- {
+				c := recent[i]
+				lines = append(lines, fitLine(fmt.Sprintf("%s %s %-7s %5dms", c.LastCheck.Format("15:04:05"), m.app.healthIcon(c.Status), c.Status, c.ResponseMs), width))
+			}
+		}
+	}
+
+	if svc != nil && (srv.CrashReason != "" || svc.LastExit != nil) {
+		if isCrashStatus(srv.Status) {
+			section("Last crash")
+		} else {
+			section("Last exit")
+		}
+		field("Reason", srv.CrashReason)
+		if svc.LastExit != nil {
+			field("Exit", describeExit(svc.LastExit, now))
+		}
+		if svc.RestartCount > 0 {
+			field("Restarts", fmt.Sprintf("%d", svc.RestartCount))
+		}
+		for _, l := range srv.CrashLogTail {
+			if strings.TrimSpace(l) != "" {
+				lines = append(lines, labelStyle.Render(fitLine("  "+l, width)))
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/devports/devpt/pkg/health"
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestDetailPanelWidth(t *testing.T) {
+	t.Parallel()
+	for width, want := range map[int]int{80: 0, 100: 40, 120: 48, 300: detailPanelMaxWidth} {
+		if got := detailPanelWidth(width); got != want {
+			t.Errorf("detailPanelWidth(%d) = %d, want %d", width, got, want)
+		}
+	}
+}
+
+func TestRenderDetail(t *testing.T) {
+	t.Parallel()
+	exited := time.Now().Add(-time.Minute)
+	srv := &models.ServerInfo{
+		ManagedService: &models.ManagedService{
+			Name:     "api",
+			CWD:      "/src/api",
+			Command:  "go run ./cmd/api",
+			Env:      []string{"APP_ENV=dev", "DEBUG=1"},
+			LastExit: &models.ExitStatus{Code: 1, ExitedAt: exited},
+		},
+		ProcessRecord: &models.ProcessRecord{
+			PID:         4242,
+			Port:        8080,
+			Command:     "/tmp/go-build/api",
+			CWD:         "/src/api",
+			ProjectRoot: "/src",
+			AgentTag:    &models.AgentTag{Source: "ancestor", AgentName: "aider", Confidence: "high"},
+		},
+		Status:      "crashed",
+		CrashReason: "panic: nil map",
+	}
+	m := topModel{
+		app:           &App{showAll: true},
+		servers:       []*models.ServerInfo{srv},
+		healthDetails: map[int]*health.HealthCheck{8080: {Port: 8080, Status: health.HealthDown, Message: "HTTP 500"}},
+		healthHist:    health.NewHistory(health.DefaultHistorySize),
+	}
+
+	out := m.renderDetail(60)
+	for _, want := range []string{"api (crashed)", "go run ./cmd/api", "/tmp/go-build/api", "APP_ENV=dev", "DEBUG=1", "/src/api", "Project", "aider", "HTTP 500", "Last crash", "panic: nil map"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("detail panel lacks %q:\n%s", want, out)
+		}
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if w := lipgloss.Width(line); w > 60 {
+			t.Errorf("line wider than the panel (%d): %q", w, line)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/registry"
+	"github.com/devports/devpt/pkg/scanner"
+)
+
+// Doctor finding levels
+const (
+	doctorOK   = "ok"
+	doctorWarn = "warn"
+	doctorFail = "fail"
+)
+
+// doctorFinding is one result of `devpt doctor`.
+type doctorFinding struct {
+	Level  string
+	Check  string
+	Detail string
+	Fix    string // what to do about it; empty for ok findings
+}
+
+func (f doctorFinding) String() string {
+	line := fmt.Sprintf("[%-4s] %s: %s", f.Level, f.Check, f.Detail)
+	if f.Fix != "" {
+		line += "\n       fix: " + f.Fix
+	}
+	return line
+}
+
+// DoctorCmd checks the environment devpt depends on and prints a fix for
+// every problem found. It fails when any check fails.
+func (a *App) DoctorCmd() error {
+	var findings []doctorFinding
+	findings = append(findings, a.checkTools()...)
+	findings = append(findings, a.checkScan()...)
+	findings = append(findings, a.checkRegistryFile()...)
+	findings = append(findings, a.checkConfigFile()...)
+	findings = append(findings, a.checkServices()...)
+	findings = append(findings, a.checkLogDirs()...)
+
+	problems, warnings := 0, 0
+	for _, f := range findings {
+		fmt.Fprintln(a.out(), f.String())
+		switch f.Level {
+		case doctorFail:
+			problems++
+		case doctorWarn:
+			warnings++
+		}
+	}
+	fmt.Fprintf(a.out(), "\n%d problem(s), %d warning(s)\n", problems, warnings)
+	if problems > 0 {
+		return fmt.Errorf("doctor found %d problem(s)", problems)
+	}
+	return nil
+}
+
+// checkTools reports whether lsof and ps are on PATH. Without a native
+// backend they are required; with one they are only the fallback.
+func (a *App) checkTools() []doctorFinding {
+	var out []doctorFinding
+	native := a.scanner.Backend() == scanner.BackendNative
+	for _, tool := range []string{"lsof", "ps"} {
+		path, err := exec.LookPath(tool)
+		switch {
+		case err == nil:
+			out = append(out, doctorFinding{Level: doctorOK, Check: tool, Detail: path})
+		case native && tool == "lsof":
+			out = append(out, doctorFinding{Level: doctorWarn, Check: tool, Detail: "not found; only needed when the native scanner fails",
+				Fix: "install lsof (e.g. apt install lsof)"})
+		default:
+			out = append(out, doctorFinding{Level: doctorFail, Check: tool, Detail: "not found in PATH",
+				Fix: fmt.Sprintf("install %s (e.g. apt install %s)", tool, toolPackage(tool))})
+		}
+	}
+	return out
+}
+
+func toolPackage(tool string) string {
+	if tool == "ps" {
+		return "procps"
+	}
+	return tool
+}
+
+// checkScan runs a scan and reports listeners that could not be inspected,
+// which usually means they belong to another user.
+func (a *App) checkScan() []doctorFinding {
+	records, err := a.scanner.ScanListeningPorts()
+	if err != nil {
+		return []doctorFinding{{Level: doctorFail, Check: "scan", Detail: err.Error(),
+			Fix: `check that lsof works: lsof -nP -iTCP -sTCP:LISTEN, or set "scan": {"backend": "exec"}`}}
+	}
+	hidden := 0
+	for _, rec := range records {
+		if rec.Command == "" {
+			hidden++
+		}
+	}
+	detail := fmt.Sprintf("%d listener(s) found with the %s backend", len(records), a.scanner.Backend())
+	if hidden > 0 {
+		return []doctorFinding{{Level: doctorWarn, Check: "scan", Detail: fmt.Sprintf("%s; %d could not be inspected", detail, hidden),
+			Fix: "they likely belong to another user; run devpt with sudo to see them"}}
+	}
+	return []doctorFinding{{Level: doctorOK, Check: "scan", Detail: detail}}
+}
+
+// checkRegistryFile loads the registry afresh, since NewApp only warns
+// about a corrupt file and continues with an empty registry.
+func (a *App) checkRegistryFile() []doctorFinding {
+	path := a.config.RegistryFile
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return []doctorFinding{{Level: doctorOK, Check: "registry", Detail: path + " does not exist yet"}}
+	}
+	if err := registry.NewRegistry(path).Load(); err != nil {
+		return []doctorFinding{{Level: doctorFail, Check: "registry", Detail: err.Error(),
+			Fix: fmt.Sprintf("fix the JSON by hand or move it aside (mv %s %s.bak) and re-add services", path, path)}}
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return []doctorFinding{{Level: doctorFail, Check: "registry", Detail: "not writable: " + err.Error(),
+			Fix: fmt.Sprintf("chown $USER %s", path)}}
+	}
+	f.Close()
+	return []doctorFinding{{Level: doctorOK, Check: "registry", Detail: fmt.Sprintf("%s (%d services)", path, len(a.registry.ListServices()))}}
+}
+
+func (a *App) checkConfigFile() []doctorFinding {
+	if _, err := models.LoadConfig(a.config.ConfigFile); err != nil {
+		return []doctorFinding{{Level: doctorFail, Check: "config", Detail: err.Error(),
+			Fix: fmt.Sprintf("correct %s; defaults are used until then", a.config.ConfigFile)}}
+	}
+	return []doctorFinding{{Level: doctorOK, Check: "config", Detail: a.config.ConfigFile}}
+}
+
+// checkServices looks for stale PIDs, missing working directories and
+// ports declared by more than one service.
+func (a *App) checkServices() []doctorFinding {
+	var out []doctorFinding
+	services := a.registry.ListServices()
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+
+	claims := make(map[int][]string)
+	for _, svc := range services {
+		check := "service " + svc.Name
+		if fi, err := os.Stat(svc.CWD); err != nil || !fi.IsDir() {
+			out = append(out, doctorFinding{Level: doctorFail, Check: check, Detail: fmt.Sprintf("working directory %s does not exist", svc.CWD),
+				Fix: fmt.Sprintf("update its cwd in %s or remove it (x in the TUI)", a.config.RegistryFile)})
+		}
+		switch a.lastPIDState(svc) {
+		case pidDead:
+			out = append(out, doctorFinding{Level: doctorWarn, Check: check, Detail: fmt.Sprintf("recorded PID %d is no longer running", *svc.LastPID),
+				Fix: fmt.Sprintf("devpt start %s, or devpt gc to clear it", svc.Name)})
+		case pidReused:
+			out = append(out, doctorFinding{Level: doctorFail, Check: check, Detail: fmt.Sprintf("recorded PID %d now belongs to another process", *svc.LastPID),
+				Fix: fmt.Sprintf("devpt start %s; devpt will not signal the unrelated process", svc.Name)})
+		}
+		for _, p := range svc.Ports {
+			claims[p] = append(claims[p], svc.Name)
+		}
+	}
+
+	ports := make([]int, 0, len(claims))
+	for p, names := range claims {
+		if len(names) > 1 {
+			ports = append(ports, p)
+		}
+	}
+	sort.Ints(ports)
+	for _, p := range ports {
+		out = append(out, doctorFinding{Level: doctorWarn, Check: fmt.Sprintf("port %d", p), Detail: "declared by " + strings.Join(claims[p], ", "),
+			Fix: "give all but one a different port, or use --port auto"})
+	}
+	if len(out) == 0 {
+		out = append(out, doctorFinding{Level: doctorOK, Check: "services", Detail: fmt.Sprintf("%d checked", len(services))})
+	}
+	return out
+}
+
+// checkLogDirs reports log directories of services that are no longer
+// registered.
+func (a *App) checkLogDirs() []doctorFinding {
+	entries, err := os.ReadDir(a.config.LogsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return []doctorFinding{{Level: doctorFail, Check: "logs", Detail: err.Error(),
+			Fix: fmt.Sprintf("check the permissions of %s", a.config.LogsDir)}}
+	}
+	var orphans []string
+	for _, e := range entries {
+		if e.IsDir() && a.registry.GetService(e.Name()) == nil {
+			orphans = append(orphans, e.Name())
+		}
+	}
+	var out []doctorFinding
+	if warning := a.logUsage().Warning(); warning != "" {
+		out = append(out, doctorFinding{Level: doctorWarn, Check: "log size", Detail: warning,
+			Fix: "devpt gc, or lower logs.keep_runs or logs.max_age in the config file"})
+	}
+	if len(orphans) == 0 {
+		return append(out, doctorFinding{Level: doctorOK, Check: "logs", Detail: a.config.LogsDir})
+	}
+	return append(out, doctorFinding{Level: doctorWarn, Check: "logs", Detail: fmt.Sprintf("%d log directories of unregistered services: %s", len(orphans), strings.Join(orphans, ", ")),
+		Fix: fmt.Sprintf("devpt gc, or delete them from %s", filepath.Clean(a.config.LogsDir))})
+}
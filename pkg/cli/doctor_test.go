@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/process"
+	"github.com/devports/devpt/pkg/registry"
+)
+
+func TestDoctorChecksServices(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	reg := registry.NewRegistry(filepath.Join(dir, "registry.json"))
+	for _, svc := range []*models.ManagedService{
+		{Name: "api", CWD: dir, Command: "x", Ports: []int{8080}},
+		{Name: "web", CWD: filepath.Join(dir, "gone"), Command: "x", Ports: []int{8080, 3000}},
+	} {
+		if err := reg.AddService(svc); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+	app := &App{registry: reg, processManager: process.NewManager(filepath.Join(dir, "logs"))}
+
+	var lines []string
+	for _, f := range app.checkServices() {
+		lines = append(lines, f.String())
+	}
+	out := strings.Join(lines, "\n")
+	for _, want := range []string{"[fail] service web: working directory", "[warn] port 8080: declared by api, web"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("findings missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "service api") || strings.Contains(out, "port 3000") {
+		t.Fatalf("unexpected findings:\n%s", out)
+	}
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// editorArgv returns the command that opens a directory: the configured
+// editor, else $VISUAL or $EDITOR, else code or cursor when installed. The
+// directory is appended as the last argument.
+func editorArgv(configured string, getenv func(string) string, has func(string) bool) ([]string, error) {
+	for _, candidate := range []string{configured, getenv("VISUAL"), getenv("EDITOR")} {
+		if argv := strings.Fields(candidate); len(argv) > 0 {
+			return argv, nil
+		}
+	}
+	for _, name := range []string{"code", "cursor"} {
+		if has(name) {
+			return []string{name}, nil
+		}
+	}
+	return nil, fmt.Errorf(`no editor configured; set "editor" in config.json or $EDITOR`)
+}
+
+// terminalEditor reports whether an editor runs in the terminal, so the
+// TUI must hand the terminal over to it, rather than opening a window.
+func terminalEditor(argv0 string) bool {
+	switch filepath.Base(argv0) {
+	case "vi", "vim", "nvim", "nano", "emacs", "emacsclient", "hx", "helix", "micro", "kak", "joe", "ne", "mg":
+		return true
+	}
+	return false
+}
+
+// projectDir returns the directory the editor opens for a server: its
+// project root, else its working directory.
+func (a *App) projectDir(srv *models.ServerInfo) string {
+	if rec := srv.ProcessRecord; rec != nil {
+		if rec.ProjectRoot != "" {
+			return rec.ProjectRoot
+		}
+		if srv.ManagedService == nil && rec.CWD != "" {
+			return rec.CWD
+		}
+	}
+	if svc := srv.ManagedService; svc != nil && svc.CWD != "" {
+		if root := a.resolver.FindProjectRoot(svc.CWD); root != "" {
+			return root
+		}
+		return svc.CWD
+	}
+	return ""
+}
+
+// editorCommand builds the command that opens srv's project in the
+// editor from the config.
+func (a *App) editorCommand(srv *models.ServerInfo) (*exec.Cmd, string, error) {
+	dir := a.projectDir(srv)
+	if dir == "" {
+		return nil, "", fmt.Errorf("no project directory known for this server")
+	}
+	configured := ""
+	if a.settings != nil {
+		configured = a.settings.Editor
+	}
+	argv, err := editorArgv(configured, os.Getenv, func(file string) bool {
+		_, err := exec.LookPath(file)
+		return err == nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	cmd := exec.Command(argv[0], append(argv[1:], dir)...)
+	cmd.Dir = dir
+	return cmd, dir, nil
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEditorArgv(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name       string
+		configured string
+		env        map[string]string
+		installed  []string
+		want       []string
+		wantErr    bool
+	}{
+		{name: "configured", configured: "code --new-window", env: map[string]string{"EDITOR": "vim"}, want: []string{"code", "--new-window"}},
+		{name: "visual before editor", env: map[string]string{"VISUAL": "nvim", "EDITOR": "vi"}, want: []string{"nvim"}},
+		{name: "editor", env: map[string]string{"EDITOR": "nano"}, want: []string{"nano"}},
+		{name: "code installed", installed: []string{"cursor", "code"}, want: []string{"code"}},
+		{name: "cursor installed", installed: []string{"cursor"}, want: []string{"cursor"}},
+		{name: "blank configured", configured: "  ", installed: []string{"code"}, want: []string{"code"}},
+		{name: "none", wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := editorArgv(tc.configured, func(k string) string { return tc.env[k] }, func(name string) bool {
+				return contains(tc.installed, name)
+			})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("argv = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTerminalEditor(t *testing.T) {
+	t.Parallel()
+	for argv0, want := range map[string]bool{
+		"vim":               true,
+		"/usr/bin/nvim":     true,
+		"code":              false,
+		"/usr/local/cursor": false,
+	} {
+		if got := terminalEditor(argv0); got != want {
+			t.Errorf("terminalEditor(%q) = %v, want %v", argv0, got, want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/devports/devpt/pkg/devpt"
+	"github.com/devports/devpt/pkg/process"
+)
+
+// Errors commands fail with, for callers to match with errors.Is. The devpt
+// command exits with a distinct code for each.
+var (
+	// ErrServiceNotFound is matched by every NotFoundError.
+	ErrServiceNotFound = devpt.ErrServiceNotFound
+	// ErrAlreadyRunning is returned when starting a service that is running.
+	ErrAlreadyRunning = devpt.ErrAlreadyRunning
+	// ErrPortConflict is returned when a declared port is taken and --force
+	// was not given.
+	ErrPortConflict = devpt.ErrPortConflict
+	// ErrNeedSudo is returned when a process belongs to another user.
+	ErrNeedSudo = process.ErrNeedSudo
+	// ErrStopped, ErrCrashed and ErrUnhealthy are matched by the
+	// StatusError `devpt status` returns for a server that is not up.
+	ErrStopped   = errors.New("stopped")
+	ErrCrashed   = errors.New("crashed")
+	ErrUnhealthy = errors.New("unhealthy")
+)
+
+// NotFoundError reports a managed service, or a server named in `devpt
+// status`, that does not exist.
+type NotFoundError struct {
+	Kind string // "service" or "server"
+	Name string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s %q not found", e.Kind, e.Name)
+}
+
+// Is makes errors.Is(err, ErrServiceNotFound) hold.
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrServiceNotFound
+}
+
+// errServiceNotFound returns the error for an unknown service name.
+func errServiceNotFound(name string) error {
+	return &NotFoundError{Kind: "service", Name: name}
+}
+
+// StatusError reports a server that `devpt status` found stopped, crashed
+// or failing its health check. The status output already says so, so the
+// CLI exits with its code without printing it again.
+type StatusError struct {
+	Name   string
+	Status string // the server status, or the health status when unhealthy
+	reason error  // ErrStopped, ErrCrashed or ErrUnhealthy
+}
+
+func (e *StatusError) Error() string {
+	if e.reason == ErrUnhealthy {
+		return fmt.Sprintf("%s is unhealthy (%s)", e.Name, e.Status)
+	}
+	return fmt.Sprintf("%s is %s", e.Name, e.Status)
+}
+
+// Is makes errors.Is match the sentinel for the status.
+func (e *StatusError) Is(target error) bool {
+	return target == e.reason
+}
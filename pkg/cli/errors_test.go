@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/devports/devpt/pkg/health"
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/process"
+	"github.com/devports/devpt/pkg/registry"
+)
+
+func TestCommandErrorsMatchSentinels(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	app := &App{
+		registry:       registry.NewRegistry(filepath.Join(dir, "registry.json")),
+		processManager: process.NewManager(filepath.Join(dir, "logs")),
+	}
+	for _, err := range []error{
+		app.StartCmd("missing"),
+		app.RestartCmd("missing"),
+		app.RemoveCmd("missing"),
+		app.LogsCmd("missing", 10, false),
+	} {
+		if !errors.Is(err, ErrServiceNotFound) {
+			t.Fatalf("expected ErrServiceNotFound, got %v", err)
+		}
+		if err.Error() != `service "missing" not found` {
+			t.Fatalf("unexpected message %q", err)
+		}
+	}
+
+	wrapped := fmt.Errorf("%w (PID 1)", ErrNeedSudo)
+	if !errors.Is(wrapped, process.ErrNeedSudo) {
+		t.Fatal("expected ErrNeedSudo to be the process package's error")
+	}
+}
+
+func TestServerStatusError(t *testing.T) {
+	t.Parallel()
+
+	down := &health.HealthCheck{Status: health.HealthDown}
+	slow := &health.HealthCheck{Status: health.HealthSlow}
+	tests := []struct {
+		status string
+		check  *health.HealthCheck
+		want   error
+	}{
+		{"running", nil, nil},
+		{"starting", nil, nil},
+		{"ready", slow, nil},
+		{"running", down, ErrUnhealthy},
+		{"stopped", nil, ErrStopped},
+		{"crashed", nil, ErrCrashed},
+		{"crash-looping", down, ErrCrashed},
+	}
+	for _, tt := range tests {
+		err := serverStatusError("api", &models.ServerInfo{Status: tt.status}, tt.check)
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("%s: expected no error, got %v", tt.status, err)
+			}
+			continue
+		}
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.status, tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/devports/devpt/pkg/events"
+)
+
+// EventsCmd prints recent events and, with follow, streams new ones until
+// interrupted.
+func (a *App) EventsCmd(lines int, follow, asJSON bool) error {
+	show := func(ev events.Event) error {
+		if asJSON {
+			data, err := json.Marshal(ev)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(a.out(), string(data))
+			return nil
+		}
+		fmt.Fprintln(a.out(), ev.String())
+		return nil
+	}
+
+	recent, offset, err := a.events.Recent(lines)
+	if err != nil {
+		return err
+	}
+	for _, ev := range recent {
+		if err := show(ev); err != nil {
+			return err
+		}
+	}
+	if !follow {
+		if len(recent) == 0 && !asJSON {
+			fmt.Fprintln(a.out(), "No events recorded yet")
+		}
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return a.events.Follow(ctx, offset, show)
+}
@@ -0,0 +1,355 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// The fields of the service form, in display order.
+const (
+	fieldName = iota
+	fieldCWD
+	fieldCommand
+	fieldPorts
+	fieldTags
+	fieldEnv
+	formFieldCount
+)
+
+var formFields = [formFieldCount]struct{ label, placeholder string }{
+	{"Name", "my-app"},
+	{"Directory", "~/projects/my-app"},
+	{"Command", "npm run dev"},
+	{"Ports", "3000, 3001 or auto"},
+	{"Tags", "frontend, web"},
+	{"Env", `NODE_ENV=development API_URL="http://localhost:8080"`},
+}
+
+// maxDirSuggestions bounds the directories offered for completion.
+const maxDirSuggestions = 50
+
+// serviceForm is the TUI form that adds a managed service, or edits one.
+type serviceForm struct {
+	inputs [formFieldCount]textinput.Model
+	// errs are the validation errors shown next to each field; err is the
+	// error registering the service returned.
+	errs  [formFieldCount]string
+	err   string
+	focus int
+	// editing is the service being edited, nil when adding one. Its name
+	// cannot be changed.
+	editing *models.ManagedService
+}
+
+// newServiceForm returns a form that adds a service, or edits svc when it
+// is not nil.
+func newServiceForm(svc *models.ManagedService) *serviceForm {
+	f := &serviceForm{editing: svc}
+	for i := range f.inputs {
+		in := textinput.New()
+		in.Prompt = ""
+		in.Placeholder = formFields[i].placeholder
+		in.Cursor.SetMode(cursor.CursorStatic)
+		// Up and down move between fields; completions cycle with Ctrl+N/P.
+		in.KeyMap.NextSuggestion = key.NewBinding(key.WithKeys("ctrl+n"))
+		in.KeyMap.PrevSuggestion = key.NewBinding(key.WithKeys("ctrl+p"))
+		f.inputs[i] = in
+	}
+	f.inputs[fieldCWD].ShowSuggestions = true
+	if svc != nil {
+		f.inputs[fieldName].SetValue(svc.Name)
+		f.inputs[fieldCWD].SetValue(svc.CWD)
+		f.inputs[fieldCommand].SetValue(svc.Command)
+		f.inputs[fieldPorts].SetValue(formatPorts(svc.Ports, svc.AutoPort))
+		f.inputs[fieldTags].SetValue(strings.Join(svc.Tags, ", "))
+		f.inputs[fieldEnv].SetValue(formatEnv(svc.Env))
+		f.focus = fieldCWD
+	} else if cwd, err := os.Getwd(); err == nil {
+		f.inputs[fieldCWD].Placeholder = cwd
+	}
+	f.inputs[f.focus].Focus()
+	return f
+}
+
+// update handles a key or input message for the focused field.
+func (f *serviceForm) update(msg tea.Msg) tea.Cmd {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "tab":
+			if f.focus == fieldCWD && f.canComplete() {
+				break
+			}
+			f.move(1)
+			return nil
+		case "down":
+			f.move(1)
+			return nil
+		case "shift+tab", "up":
+			f.move(-1)
+			return nil
+		}
+	}
+	in := &f.inputs[f.focus]
+	var cmd tea.Cmd
+	*in, cmd = in.Update(msg)
+	if f.focus == fieldCWD {
+		in.SetSuggestions(dirSuggestions(in.Value()))
+	}
+	return cmd
+}
+
+// canComplete reports whether Tab in the directory field would complete
+// it rather than move to the next field.
+func (f *serviceForm) canComplete() bool {
+	in := &f.inputs[fieldCWD]
+	s := in.CurrentSuggestion()
+	return s != "" && len(s) > len(in.Value())
+}
+
+// move focuses the field delta away, skipping the name while editing.
+func (f *serviceForm) move(delta int) {
+	f.inputs[f.focus].Blur()
+	for {
+		f.focus = (f.focus + delta + formFieldCount) % formFieldCount
+		if f.focus != fieldName || f.editing == nil {
+			break
+		}
+	}
+	f.inputs[f.focus].Focus()
+}
+
+// service validates the fields and returns the service they describe;
+// ok is false and the errors are shown when a field is invalid. exists
+// reports whether a service name is taken.
+func (f *serviceForm) service(exists func(string) bool) (svc *models.ManagedService, ok bool) {
+	f.errs = [formFieldCount]string{}
+	f.err = ""
+	value := func(i int) string { return strings.TrimSpace(f.inputs[i].Value()) }
+
+	if f.editing != nil {
+		edited := *f.editing
+		svc = &edited
+	} else {
+		svc = &models.ManagedService{Name: value(fieldName)}
+		switch {
+		case svc.Name == "":
+			f.errs[fieldName] = "required"
+		case strings.ContainsAny(svc.Name, " \t/\\"):
+			f.errs[fieldName] = "no spaces or slashes"
+		case exists(svc.Name):
+			f.errs[fieldName] = "already registered"
+		}
+	}
+
+	cwd := value(fieldCWD)
+	if cwd == "" {
+		cwd = f.inputs[fieldCWD].Placeholder
+	}
+	cwd = expandHome(cwd)
+	if abs, err := filepath.Abs(cwd); err == nil {
+		cwd = abs
+	}
+	if fi, err := os.Stat(cwd); err != nil || !fi.IsDir() {
+		f.errs[fieldCWD] = "not a directory"
+	}
+	svc.CWD = cwd
+
+	svc.Command = value(fieldCommand)
+	if err := validateServiceCommand(svc.Command, svc.Shell); err != nil {
+		f.errs[fieldCommand] = err.Error()
+	}
+
+	ports, autoPort, err := parsePorts(value(fieldPorts))
+	if err != nil {
+		f.errs[fieldPorts] = err.Error()
+	}
+	svc.Ports, svc.AutoPort = ports, autoPort
+	if !autoPort {
+		svc.RunPort = 0
+	}
+
+	svc.Tags = splitList(value(fieldTags))
+
+	env, _ := parseArgs(value(fieldEnv))
+	if err := validateEnv(env); err != nil {
+		f.errs[fieldEnv] = err.Error()
+	}
+	svc.Env = env
+
+	for i, e := range f.errs {
+		if e != "" {
+			f.inputs[f.focus].Blur()
+			f.focus = i
+			f.inputs[i].Focus()
+			return nil, false
+		}
+	}
+	return svc, true
+}
+
+// view renders the form at width in the styles of th.
+func (f *serviceForm) view(width int, th *theme) string {
+	labelStyle := th.muted
+	focusStyle := th.accent.Bold(true)
+	errStyle := th.danger
+
+	title := "Add service"
+	if f.editing != nil {
+		title = fmt.Sprintf("Edit %q", f.editing.Name)
+	}
+	var b strings.Builder
+	b.WriteString(focusStyle.Render(title))
+	b.WriteString("\n\n")
+	const labelW = 11
+	for i := range f.inputs {
+		label := labelStyle
+		marker := "  "
+		if i == f.focus {
+			label, marker = focusStyle, "> "
+		}
+		in := f.inputs[i]
+		in.Width = max(10, width-labelW-4)
+		field := in.View()
+		if i == fieldName && f.editing != nil {
+			field = f.editing.Name
+		}
+		b.WriteString(marker + label.Render(fmt.Sprintf("%-*s", labelW, formFields[i].label)) + field)
+		b.WriteString("\n")
+		if f.errs[i] != "" {
+			b.WriteString(errStyle.Render(fitLine(strings.Repeat(" ", labelW+2)+f.errs[i], width)))
+			b.WriteString("\n")
+		}
+	}
+	if i := f.focus; i == fieldCWD {
+		if matches := f.inputs[i].MatchedSuggestions(); len(matches) > 1 {
+			names := make([]string, len(matches))
+			for j, m := range matches {
+				names[j] = filepath.Base(m) + "/"
+			}
+			b.WriteString(labelStyle.Render(fitLine(strings.Repeat(" ", labelW+2)+strings.Join(names, "  "), width)))
+			b.WriteString("\n")
+		}
+	}
+	if f.err != "" {
+		b.WriteString("\n")
+		b.WriteString(errStyle.Render(fitLine(f.err, width)))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(labelStyle.Render(fitLine("Tab/↓ next field, Shift+Tab/↑ previous, Tab completes directories (Ctrl+N/P cycles), Enter save, Esc cancel", width)))
+	b.WriteString("\n")
+	return b.String()
+}
+
+// dirSuggestions lists the directories that complete a path typed into the
+// form, spelled as typed so they extend it; ~ is the home directory.
+func dirSuggestions(typed string) []string {
+	if typed == "" {
+		return nil
+	}
+	parent, prefix := "", typed
+	if i := strings.LastIndex(typed, "/"); i >= 0 {
+		parent, prefix = typed[:i+1], typed[i+1:]
+	}
+	dir := expandHome(parent)
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".") {
+			continue
+		}
+		if !strings.HasPrefix(strings.ToLower(name), strings.ToLower(prefix)) {
+			continue
+		}
+		isDir := e.IsDir()
+		if e.Type()&os.ModeSymlink != 0 {
+			fi, err := os.Stat(filepath.Join(dir, name))
+			isDir = err == nil && fi.IsDir()
+		}
+		if isDir {
+			out = append(out, parent+name+"/")
+		}
+	}
+	sort.Strings(out)
+	if len(out) > maxDirSuggestions {
+		out = out[:maxDirSuggestions]
+	}
+	return out
+}
+
+// expandHome replaces a leading ~ with the home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// parsePorts parses the ports field: ports separated by commas or spaces,
+// and "auto" to allocate one on every start.
+func parsePorts(s string) (ports []int, autoPort bool, err error) {
+	for _, raw := range splitList(s) {
+		if raw == "auto" {
+			autoPort = true
+			continue
+		}
+		port, err := strconv.Atoi(raw)
+		if err != nil || port < 1 || port > 65535 {
+			return nil, false, fmt.Errorf("invalid port %q", raw)
+		}
+		ports = append(ports, port)
+	}
+	return ports, autoPort, nil
+}
+
+func formatPorts(ports []int, autoPort bool) string {
+	out := make([]string, 0, len(ports)+1)
+	for _, p := range ports {
+		out = append(out, strconv.Itoa(p))
+	}
+	if autoPort {
+		out = append(out, "auto")
+	}
+	return strings.Join(out, ", ")
+}
+
+// splitList splits a field on commas and whitespace.
+func splitList(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+}
+
+// formatEnv renders env entries for the env field, quoting values so that
+// parseArgs reads them back.
+func formatEnv(env []string) string {
+	out := make([]string, len(env))
+	for i, kv := range env {
+		k, v, _ := strings.Cut(kv, "=")
+		if strings.ContainsAny(v, " \t\"'\\") {
+			v = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v) + `"`
+		}
+		out[i] = k + "=" + v
+	}
+	return strings.Join(out, " ")
+}
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestServiceFormService(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	f := newServiceForm(nil)
+	f.inputs[fieldName].SetValue("web")
+	f.inputs[fieldCWD].SetValue(dir)
+	f.inputs[fieldCommand].SetValue("npm run dev")
+	f.inputs[fieldPorts].SetValue("3000, 3001 auto")
+	f.inputs[fieldTags].SetValue("frontend, web")
+	f.inputs[fieldEnv].SetValue(`NODE_ENV=development GREETING="hello world"`)
+
+	svc, ok := f.service(func(string) bool { return false })
+	if !ok {
+		t.Fatalf("service() invalid: %q", f.errs)
+	}
+	want := &models.ManagedService{
+		Name:     "web",
+		CWD:      dir,
+		Command:  "npm run dev",
+		Ports:    []int{3000, 3001},
+		AutoPort: true,
+		Tags:     []string{"frontend", "web"},
+		Env:      []string{"NODE_ENV=development", "GREETING=hello world"},
+	}
+	if !reflect.DeepEqual(svc, want) {
+		t.Errorf("service() = %+v, want %+v", svc, want)
+	}
+}
+
+func TestServiceFormErrors(t *testing.T) {
+	t.Parallel()
+	f := newServiceForm(nil)
+	f.inputs[fieldName].SetValue("web")
+	f.inputs[fieldCWD].SetValue(filepath.Join(t.TempDir(), "missing"))
+	f.inputs[fieldPorts].SetValue("3000 http")
+	f.inputs[fieldEnv].SetValue("1BAD=x")
+
+	if _, ok := f.service(func(name string) bool { return name == "web" }); ok {
+		t.Fatal("service() accepted invalid fields")
+	}
+	for _, i := range []int{fieldName, fieldCWD, fieldCommand, fieldPorts, fieldEnv} {
+		if f.errs[i] == "" {
+			t.Errorf("no error for %s", formFields[i].label)
+		}
+	}
+	if f.errs[fieldTags] != "" {
+		t.Errorf("tags error = %q, want none", f.errs[fieldTags])
+	}
+	if f.focus != fieldName {
+		t.Errorf("focus = %d, want the first invalid field", f.focus)
+	}
+}
+
+func TestServiceFormEdit(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	orig := &models.ManagedService{Name: "api", CWD: dir, Command: "go run .", Ports: []int{8080}, Env: []string{`MSG=say "hi"`}, Shell: true}
+	f := newServiceForm(orig)
+	if got := f.inputs[fieldEnv].Value(); got != `MSG="say \"hi\""` {
+		t.Errorf("env field = %q", got)
+	}
+	f.inputs[fieldPorts].SetValue("9090")
+
+	svc, ok := f.service(func(string) bool { return true })
+	if !ok {
+		t.Fatalf("service() invalid: %q", f.errs)
+	}
+	if svc == orig || svc.Name != "api" || !svc.Shell || !reflect.DeepEqual(svc.Ports, []int{9090}) || !reflect.DeepEqual(svc.Env, orig.Env) {
+		t.Errorf("service() = %+v", svc)
+	}
+	if !reflect.DeepEqual(orig.Ports, []int{8080}) {
+		t.Errorf("original modified: %v", orig.Ports)
+	}
+}
+
+func TestDirSuggestions(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	for _, name := range []string{"api", "app", "Apps", ".hidden", "web"} {
+		if err := os.Mkdir(filepath.Join(dir, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "apple.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := dirSuggestions(dir + "/ap")
+	want := []string{dir + "/Apps/", dir + "/api/", dir + "/app/"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dirSuggestions = %q, want %q", got, want)
+	}
+	if got := dirSuggestions(dir + "/.h"); !reflect.DeepEqual(got, []string{dir + "/.hidden/"}) {
+		t.Errorf("dirSuggestions(.h) = %q", got)
+	}
+	if got := dirSuggestions(""); got != nil {
+		t.Errorf("dirSuggestions(\"\") = %q", got)
+	}
+}
+
+func TestValidateEnv(t *testing.T) {
+	t.Parallel()
+	if err := validateEnv([]string{"A=1", "_B=", "C_2=x=y"}); err != nil {
+		t.Errorf("validateEnv: %v", err)
+	}
+	for _, bad := range []string{"A", "=1", "1A=x", "A-B=x"} {
+		if err := validateEnv([]string{bad}); err == nil {
+			t.Errorf("validateEnv(%q) = nil", bad)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/process"
+)
+
+// forwardTag marks the managed services devpt forward registers.
+const forwardTag = "forward"
+
+// forwardDialTimeout bounds a connection attempt to the forward's target.
+const forwardDialTimeout = 3 * time.Second
+
+// ForwardSpec is what devpt forward forwards: a local port to a fixed
+// port, or to wherever a managed service listens.
+type ForwardSpec struct {
+	Port       int
+	TargetPort int
+	Service    string
+}
+
+// ParseForwardSpec reads "PORT:TARGET-PORT" or "SERVICE PORT".
+func ParseForwardSpec(args []string) (ForwardSpec, error) {
+	var spec ForwardSpec
+	var err error
+	switch len(args) {
+	case 1:
+		from, to, ok := strings.Cut(args[0], ":")
+		if !ok {
+			return spec, fmt.Errorf("invalid forward %q (want PORT:TARGET-PORT, or SERVICE PORT)", args[0])
+		}
+		if spec.Port, err = parsePort(from); err != nil {
+			return spec, err
+		}
+		if spec.TargetPort, err = parsePort(to); err != nil {
+			return spec, err
+		}
+		if spec.Port == spec.TargetPort {
+			return spec, fmt.Errorf("port %d cannot forward to itself", spec.Port)
+		}
+	case 2:
+		spec.Service = args[0]
+		if spec.Port, err = parsePort(args[1]); err != nil {
+			return spec, err
+		}
+	default:
+		return spec, fmt.Errorf("want PORT:TARGET-PORT, or SERVICE PORT")
+	}
+	return spec, nil
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(s)
+	if err != nil || port <= 0 || port > 65535 {
+		return 0, fmt.Errorf("invalid port: %s", s)
+	}
+	return port, nil
+}
+
+// args returns the arguments that ParseForwardSpec reads back into s.
+func (s ForwardSpec) args() []string {
+	if s.Service != "" {
+		return []string{s.Service, strconv.Itoa(s.Port)}
+	}
+	return []string{fmt.Sprintf("%d:%d", s.Port, s.TargetPort)}
+}
+
+// target describes where s leads, e.g. ":3000" or "api".
+func (s ForwardSpec) target() string {
+	if s.Service != "" {
+		return s.Service
+	}
+	return ":" + strconv.Itoa(s.TargetPort)
+}
+
+// ForwardName is the managed service devpt forward registers for a port.
+func ForwardName(port int) string {
+	return fmt.Sprintf("forward-%d", port)
+}
+
+// ForwardCmd registers the forward as a managed service running devpt
+// forward --foreground and starts it, so that it shows in devpt ls and the
+// TUI, keeps its log, and is stopped with devpt stop and removed with devpt
+// rm like any service. Forwarding an already forwarded port again replaces
+// its target.
+func (a *App) ForwardCmd(spec ForwardSpec, name string) error {
+	if name == "" {
+		name = ForwardName(spec.Port)
+	}
+	if spec.Service != "" {
+		svc := a.registry.GetService(spec.Service)
+		if svc == nil {
+			return errServiceNotFound(spec.Service)
+		}
+		if svc.Name == name {
+			return fmt.Errorf("%q cannot forward to itself", name)
+		}
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the devpt binary: %w", err)
+	}
+	svc := &models.ManagedService{
+		Name:    name,
+		CWD:     a.config.ConfigDir,
+		Command: process.QuoteCommand(append(append([]string{exe, "forward"}, spec.args()...), "--foreground")),
+		Ports:   []int{spec.Port},
+		Tags:    []string{forwardTag},
+	}
+	if prev := a.registry.GetService(name); prev != nil {
+		if !contains(prev.Tags, forwardTag) {
+			return fmt.Errorf("service %q exists and is not a forward; pick another name with --name", name)
+		}
+		// Keep the running forward's PID so that the restart stops it.
+		updated := *prev
+		updated.CWD, updated.Command, updated.Ports = svc.CWD, svc.Command, svc.Ports
+		if err := a.EditServiceCmd(&updated); err != nil {
+			return err
+		}
+		if err := a.RestartCmd(name); err != nil {
+			return err
+		}
+	} else {
+		if err := a.AddServiceCmd(svc); err != nil {
+			return err
+		}
+		if err := a.StartCmd(name); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(a.out(), "Forwarding localhost:%d to %s; stop it with devpt stop %s, remove it with devpt rm %s\n", spec.Port, spec.target(), name, name)
+	return nil
+}
+
+// ForwardForegroundCmd forwards connections to localhost:spec.Port until
+// interrupted. A service's port is looked up again when it cannot be
+// reached, so the forward follows the service to a new port.
+func (a *App) ForwardForegroundCmd(spec ForwardSpec) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	f := &forwarder{spec: spec, out: a.out(), errOut: a.errOut()}
+	if spec.Service != "" {
+		f.router = a.newProxyRouter(DefaultProxyDomain)
+	}
+	listeners, err := listenLoopback(spec.Port)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out(), "Forwarding localhost:%d to %s\n", spec.Port, spec.target())
+	return f.serve(ctx, listeners)
+}
+
+// forwarder copies connections between a local port and the forward's
+// target.
+type forwarder struct {
+	spec   ForwardSpec
+	router *proxyRouter // looks the service up by name; nil for a fixed port
+	// out and errOut are where connections are logged.
+	out, errOut io.Writer
+
+	mu   sync.Mutex
+	last int // target port of the last connection, logged when it changes
+}
+
+// listenLoopback listens on port of the IPv4 loopback address, and of the
+// IPv6 one where there is one.
+func listenLoopback(port int) ([]net.Listener, error) {
+	var listeners []net.Listener
+	for _, host := range []string{"127.0.0.1", "::1"} {
+		ln, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+		if err != nil {
+			if host == "::1" {
+				break // no IPv6 loopback
+			}
+			return nil, fmt.Errorf("failed to listen on port %d: %w", port, err)
+		}
+		listeners = append(listeners, ln)
+	}
+	return listeners, nil
+}
+
+// serve accepts connections on listeners until ctx is done, then closes
+// them.
+func (f *forwarder) serve(ctx context.Context, listeners []net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		for _, ln := range listeners {
+			ln.Close()
+		}
+	}()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(listeners))
+	for _, ln := range listeners {
+		wg.Add(1)
+		go func(ln net.Listener) {
+			defer wg.Done()
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					if ctx.Err() == nil {
+						errs <- err
+					}
+					return
+				}
+				go f.handle(ctx, conn)
+			}
+		}(ln)
+	}
+	wg.Wait()
+	close(errs)
+	if err := <-errs; err != nil && !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+	return nil
+}
+
+// handle connects conn to the target and copies between them until either
+// side closes.
+func (f *forwarder) handle(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	target, port, err := f.dial(ctx)
+	if err != nil {
+		fmt.Fprintf(f.errOut, "localhost:%d: %v\n", f.spec.Port, err)
+		return
+	}
+	defer target.Close()
+	f.mu.Lock()
+	if port != f.last {
+		fmt.Fprintf(f.out, "localhost:%d -> localhost:%d (%s)\n", f.spec.Port, port, f.spec.target())
+		f.last = port
+	}
+	f.mu.Unlock()
+
+	done := make(chan struct{}, 2)
+	pipe := func(dst, src net.Conn) {
+		_, _ = io.Copy(dst, src)
+		if tcp, ok := dst.(*net.TCPConn); ok {
+			_ = tcp.CloseWrite()
+		}
+		done <- struct{}{}
+	}
+	go pipe(target, conn)
+	go pipe(conn, target)
+	<-done
+	<-done
+}
+
+// dial connects to the target, looking the service's port up again when
+// its last known port refuses.
+func (f *forwarder) dial(ctx context.Context) (net.Conn, int, error) {
+	if f.router == nil {
+		conn, err := dialLocal(ctx, f.spec.TargetPort)
+		return conn, f.spec.TargetPort, err
+	}
+	var lastErr error
+	for _, fresh := range []bool{false, true} {
+		route, found, err := f.router.lookupService(ctx, f.spec.Service, fresh)
+		switch {
+		case err != nil:
+			return nil, 0, err
+		case !found:
+			lastErr = errServiceNotFound(f.spec.Service)
+			continue
+		case route.port == 0:
+			lastErr = fmt.Errorf("%q is not running", f.spec.Service)
+			continue
+		}
+		conn, err := dialLocal(ctx, route.port)
+		if err == nil {
+			return conn, route.port, nil
+		}
+		// The service may be restarting on another port.
+		f.router.invalidate()
+		lastErr = err
+	}
+	return nil, 0, lastErr
+}
+
+// dialLocal connects to port on localhost, over IPv4 or IPv6.
+func dialLocal(ctx context.Context, port int) (net.Conn, error) {
+	d := net.Dialer{Timeout: forwardDialTimeout}
+	return d.DialContext(ctx, "tcp", net.JoinHostPort("localhost", strconv.Itoa(port)))
+}
@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestParseForwardSpec(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		args []string
+		want ForwardSpec
+	}{
+		{[]string{"8080:3000"}, ForwardSpec{Port: 8080, TargetPort: 3000}},
+		{[]string{"api", "8080"}, ForwardSpec{Port: 8080, Service: "api"}},
+	} {
+		got, err := ParseForwardSpec(tc.args)
+		if err != nil || got != tc.want {
+			t.Errorf("ParseForwardSpec(%q) = %+v, %v; want %+v", tc.args, got, err, tc.want)
+		}
+		if back, _ := ParseForwardSpec(got.args()); back != got {
+			t.Errorf("args of %+v read back as %+v", got, back)
+		}
+	}
+	for _, args := range [][]string{{"8080"}, {"8080:8080"}, {"8080:x"}, {"api", "0"}, {"api", "70000"}} {
+		if _, err := ParseForwardSpec(args); err == nil {
+			t.Errorf("ParseForwardSpec(%q) succeeded", args)
+		}
+	}
+}
+
+// echoServer answers every line it reads with prefix and the line.
+func echoServer(t *testing.T, prefix string) (net.Listener, int) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				sc := bufio.NewScanner(conn)
+				for sc.Scan() {
+					fmt.Fprintf(conn, "%s %s\n", prefix, sc.Text())
+				}
+			}()
+		}
+	}()
+	return ln, ln.Addr().(*net.TCPAddr).Port
+}
+
+// startForwarder serves f on a free port and returns its address.
+func startForwarder(t *testing.T, f *forwarder) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- f.serve(ctx, []net.Listener{ln}) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("serve: %v", err)
+		}
+	})
+	return ln.Addr().String()
+}
+
+// roundTrip sends line through addr and returns the answer.
+func roundTrip(t *testing.T, addr, line string) string {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	fmt.Fprintln(conn, line)
+	answer, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(answer)
+}
+
+func TestForwarderForwardsToPort(t *testing.T) {
+	t.Parallel()
+	_, port := echoServer(t, "fixed")
+	addr := startForwarder(t, &forwarder{spec: ForwardSpec{Port: 1, TargetPort: port}, out: io.Discard, errOut: io.Discard})
+	if got := roundTrip(t, addr, "hello"); got != "fixed hello" {
+		t.Errorf("forwarded answer = %q", got)
+	}
+}
+
+func TestForwarderFollowsService(t *testing.T) {
+	t.Parallel()
+	old, oldPort := echoServer(t, "old")
+	_, newPort := echoServer(t, "new")
+	var mu sync.Mutex
+	port := oldPort
+	router := &proxyRouter{domain: DefaultProxyDomain, discover: func(context.Context) ([]*models.ServerInfo, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return []*models.ServerInfo{
+			{ManagedService: &models.ManagedService{Name: "api"}, ProcessRecord: &models.ProcessRecord{PID: 1, Port: port}, Status: "running"},
+		}, nil
+	}}
+	addr := startForwarder(t, &forwarder{spec: ForwardSpec{Port: 1, Service: "api"}, router: router, out: io.Discard, errOut: io.Discard})
+	if got := roundTrip(t, addr, "one"); got != "old one" {
+		t.Fatalf("forwarded answer = %q", got)
+	}
+
+	// The service restarts on another port; the cached route now refuses.
+	old.Close()
+	mu.Lock()
+	port = newPort
+	mu.Unlock()
+	if got := roundTrip(t, addr, "two"); got != "new two" {
+		t.Errorf("forwarded answer after the move = %q", got)
+	}
+}
+
+func TestForwarderMatchesServiceNameExactly(t *testing.T) {
+	t.Parallel()
+	_, dashPort := echoServer(t, "dash")
+	_, underscorePort := echoServer(t, "underscore")
+	router := &proxyRouter{domain: DefaultProxyDomain, discover: func(context.Context) ([]*models.ServerInfo, error) {
+		// Both names have the hostname label api-v2.
+		return []*models.ServerInfo{
+			{ManagedService: &models.ManagedService{Name: "api-v2"}, ProcessRecord: &models.ProcessRecord{PID: 1, Port: dashPort}, Status: "running"},
+			{ManagedService: &models.ManagedService{Name: "api_v2"}, ProcessRecord: &models.ProcessRecord{PID: 2, Port: underscorePort}, Status: "running"},
+		}, nil
+	}}
+	for name, want := range map[string]string{"api-v2": "dash one", "api_v2": "underscore one"} {
+		addr := startForwarder(t, &forwarder{spec: ForwardSpec{Port: 1, Service: name}, router: router, out: io.Discard, errOut: io.Discard})
+		if got := roundTrip(t, addr, "one"); got != want {
+			t.Errorf("forward to %s answered %q, want %q", name, got, want)
+		}
+	}
+
+	missing := &forwarder{spec: ForwardSpec{Port: 1, Service: "API.v2"}, router: router, out: io.Discard, errOut: io.Discard}
+	if _, _, err := missing.dial(context.Background()); !errors.Is(err, ErrServiceNotFound) {
+		t.Errorf("dial API.v2 = %v, want ErrServiceNotFound", err)
+	}
+}
@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/devports/devpt/pkg/process"
+)
+
+// GCCmd clears recorded PIDs that no longer identify their service, removes
+// the logs of services that are no longer registered and prunes old service
+// and job logs per the retention policy. With dryRun it only reports what it
+// would do.
+func (a *App) GCCmd(dryRun bool) error {
+	cleared, reclaimed, err := a.collectGarbage(dryRun, func(line string) { fmt.Fprintln(a.out(), line) })
+	if err != nil {
+		return err
+	}
+	if cleared == 0 && reclaimed == 0 {
+		fmt.Fprintln(a.out(), "Nothing to clean up")
+		return nil
+	}
+	if dryRun {
+		fmt.Fprintf(a.out(), "Would reclaim %s\n", formatBytes(reclaimed))
+	} else {
+		fmt.Fprintf(a.out(), "Reclaimed %s\n", formatBytes(reclaimed))
+	}
+	return nil
+}
+
+// collectGarbage does the work of GCCmd, passing a line per action to
+// report. It returns the number of PIDs cleared and the bytes reclaimed.
+func (a *App) collectGarbage(dryRun bool, report func(string)) (int, int64, error) {
+	verb := func(done, would string) string {
+		if dryRun {
+			return would
+		}
+		return done
+	}
+
+	services := a.registry.ListServices()
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	cleared := 0
+	for _, svc := range services {
+		state := a.lastPIDState(svc)
+		if state != pidDead && state != pidReused {
+			continue
+		}
+		why := "process is gone"
+		if state == pidReused {
+			why = "PID now belongs to another process"
+		}
+		report(fmt.Sprintf("%s stale PID %d of %q (%s)", verb("Cleared", "Would clear"), *svc.LastPID, svc.Name, why))
+		cleared++
+		if dryRun {
+			continue
+		}
+		if err := a.registry.ClearServicePID(svc.Name); err != nil {
+			return 0, 0, fmt.Errorf("failed to clear PID for %q: %w", svc.Name, err)
+		}
+	}
+
+	var reclaimed int64
+	entries, err := os.ReadDir(a.config.LogsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, 0, fmt.Errorf("failed to read logs directory: %w", err)
+	}
+	for _, e := range entries {
+		if !e.IsDir() || a.registry.GetService(e.Name()) != nil {
+			continue
+		}
+		dir := filepath.Join(a.config.LogsDir, e.Name())
+		size, files := dirUsage(dir)
+		report(fmt.Sprintf("%s logs of unregistered service %q (%d files, %s)", verb("Removed", "Would remove"), e.Name(), files, formatBytes(size)))
+		reclaimed += size
+		if dryRun {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return 0, 0, fmt.Errorf("failed to remove %s: %w", dir, err)
+		}
+	}
+
+	retention := a.logSettings()
+	now := time.Now()
+	prune := func(m *process.Manager, name, owner string) error {
+		expired, err := m.ExpiredLogs(name, retention.KeepRuns, retention.MaxAge.Std(), now)
+		if err != nil {
+			return err
+		}
+		if len(expired) == 0 {
+			return nil
+		}
+		var size int64
+		for _, path := range expired {
+			if info, err := os.Stat(path); err == nil {
+				size += info.Size()
+			}
+			if !dryRun {
+				if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+					return fmt.Errorf("failed to remove %s: %w", path, err)
+				}
+			}
+		}
+		report(fmt.Sprintf("%s %d old log files of %s (%s)", verb("Pruned", "Would prune"), len(expired), owner, formatBytes(size)))
+		reclaimed += size
+		return nil
+	}
+	for _, svc := range services {
+		if err := prune(a.processManager, svc.Name, fmt.Sprintf("%q", svc.Name)); err != nil {
+			return 0, 0, err
+		}
+	}
+	// Job logs are kept per retention too, ad-hoc jobs included.
+	jobDirs, err := os.ReadDir(a.config.JobLogsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, 0, fmt.Errorf("failed to read job logs directory: %w", err)
+	}
+	for _, e := range jobDirs {
+		if !e.IsDir() {
+			continue
+		}
+		if err := prune(a.jobLogs, e.Name(), fmt.Sprintf("job %q", e.Name())); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return cleared, reclaimed, nil
+}
+
+// dirUsage returns the total size and number of files under dir.
+func dirUsage(dir string) (int64, int) {
+	var size int64
+	files := 0
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+			files++
+		}
+		return nil
+	})
+	return size, files
+}
+
+// formatBytes renders a size such as "512 B", "1.2 KB" or "3.4 MB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGT"[exp])
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// HistoryCmd prints the most recent starts, stops, restarts and crashes of
+// a service. History outlives the registry entry, so removed services can
+// still be inspected.
+func (a *App) HistoryCmd(name string, lines int, asJSON bool) error {
+	entries, err := a.history.Recent(name, lines)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		if a.registry.GetService(name) == nil {
+			return errServiceNotFound(name)
+		}
+		if !asJSON {
+			fmt.Fprintf(a.out(), "No history recorded for %q yet\n", name)
+		}
+		return nil
+	}
+	for _, e := range entries {
+		if asJSON {
+			data, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(a.out(), string(data))
+			continue
+		}
+		fmt.Fprintln(a.out(), e.String())
+	}
+	return nil
+}
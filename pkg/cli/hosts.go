@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/devports/devpt/pkg/health"
+	"github.com/devports/devpt/pkg/models"
+)
+
+// defaultHostTimeout bounds a request to a remote host without a timeout.
+const defaultHostTimeout = 2 * time.Second
+
+// hostInterval is how often the TUI asks the remote hosts for their servers.
+const hostInterval = 3 * time.Second
+
+// hostScan is what a remote host answered when last asked for its servers.
+type hostScan struct {
+	name    string
+	servers []*models.ServerInfo
+	err     error
+	latency time.Duration
+}
+
+// hostsMsg carries the answers of the remote hosts, in config order.
+type hostsMsg struct {
+	hosts []hostScan
+}
+
+// hostConfigs returns the remote hosts of config.json.
+func (a *App) hostConfigs() []models.HostConfig {
+	if a.settings == nil {
+		return nil
+	}
+	return a.settings.Hosts
+}
+
+// scanHost fetches the servers of a remote host from its REST API.
+func scanHost(ctx context.Context, client *http.Client, h models.HostConfig) hostScan {
+	out := hostScan{name: h.Name}
+	timeout := h.Timeout.Std()
+	if timeout <= 0 {
+		timeout = defaultHostTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(h.URL, "/")+"/api/servers", nil)
+	if err != nil {
+		out.err = err
+		return out
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		out.err = err
+		return out
+	}
+	defer resp.Body.Close()
+	out.latency = time.Since(start)
+	if resp.StatusCode != http.StatusOK {
+		out.err = fmt.Errorf("%s answered %s", h.URL, resp.Status)
+		return out
+	}
+	var servers []APIServer
+	if err := json.NewDecoder(resp.Body).Decode(&servers); err != nil {
+		out.err = fmt.Errorf("%s sent an invalid server list: %w", h.URL, err)
+		return out
+	}
+	for _, s := range servers {
+		out.servers = append(out.servers, remoteServer(h.Name, s))
+	}
+	return out
+}
+
+// remoteServer turns a server a remote host reported into one the running
+// table can show.
+func remoteServer(host string, s APIServer) *models.ServerInfo {
+	srv := &models.ServerInfo{
+		ProcessRecord: &models.ProcessRecord{
+			PID:         s.PID,
+			Port:        s.Port,
+			Command:     s.Command,
+			CWD:         s.CWD,
+			ProjectRoot: s.Project,
+		},
+		Source:      s.Source,
+		Status:      s.Status,
+		CrashReason: s.CrashReason,
+		Host:        host,
+	}
+	if s.Managed {
+		srv.ManagedService = &models.ManagedService{Name: s.Name, CWD: s.CWD}
+	}
+	return srv
+}
+
+// hostsCmd asks every remote host for its servers at once.
+func (m topModel) hostsCmd() tea.Cmd {
+	hosts, ctx := m.app.hostConfigs(), m.context()
+	return func() tea.Msg {
+		client := &http.Client{}
+		out := make([]hostScan, len(hosts))
+		var wg sync.WaitGroup
+		for i, h := range hosts {
+			wg.Add(1)
+			go func(i int, h models.HostConfig) {
+				defer wg.Done()
+				out[i] = scanHost(ctx, client, h)
+			}(i, h)
+		}
+		wg.Wait()
+		return hostsMsg{hosts: out}
+	}
+}
+
+// remoteServers returns the servers of the remote hosts that answered,
+// leaving out stopped services, which the remote managed lists show.
+func (m topModel) remoteServers() []*models.ServerInfo {
+	var out []*models.ServerInfo
+	for _, h := range m.hosts {
+		for _, srv := range h.servers {
+			if srv.Status != "stopped" {
+				out = append(out, srv)
+			}
+		}
+	}
+	return out
+}
+
+// hostNames lists this machine and the remote hosts, in config order.
+func (m topModel) hostNames() []string {
+	names := []string{models.LocalHost}
+	for _, h := range m.app.hostConfigs() {
+		names = append(names, h.Name)
+	}
+	return names
+}
+
+// hostOf names the host srv runs on.
+func hostOf(srv *models.ServerInfo) string {
+	if srv.Host == "" {
+		return models.LocalHost
+	}
+	return srv.Host
+}
+
+// cycleHostFilter shows the servers of the next host only, then of all of
+// them again.
+func (m *topModel) cycleHostFilter() string {
+	if len(m.app.hostConfigs()) == 0 {
+		return "No remote hosts configured (add hosts to config.json)"
+	}
+	names := append(m.hostNames(), "")
+	next := names[0]
+	for i, n := range names {
+		if n == m.hostFilter && i+1 < len(names) {
+			next = names[i+1]
+		}
+	}
+	m.hostFilter = next
+	m.selected = 0
+	if next == "" {
+		return "Showing all hosts"
+	}
+	return "Showing host " + next
+}
+
+// remoteActionStatus explains why srv, on a remote host, cannot be acted on
+// from here.
+func remoteActionStatus(srv *models.ServerInfo) string {
+	return fmt.Sprintf("%s runs on host %s; manage it with devpt there", serverLabel(srv), srv.Host)
+}
+
+// serverLabel names srv in status messages.
+func serverLabel(srv *models.ServerInfo) string {
+	if srv.ManagedService != nil {
+		return fmt.Sprintf("%q", srv.ManagedService.Name)
+	}
+	return fmt.Sprintf("port %d", portOf(srv))
+}
+
+// renderHosts summarizes each host's health: how many servers are up and
+// crashed on it, or that it could not be reached.
+func (m topModel) renderHosts(width int) string {
+	if len(m.app.hostConfigs()) == 0 {
+		return ""
+	}
+	summary := func(name string, servers []*models.ServerInfo, latency string) string {
+		up, crashed, _ := statusCounts(servers)
+		part := fmt.Sprintf("%s %s %d up%s", name, m.app.healthIcon(health.HealthOK), up, latency)
+		if crashed > 0 {
+			part += fmt.Sprintf(", %d crashed", crashed)
+		}
+		return part
+	}
+	parts := []string{summary(models.LocalHost, m.servers, "")}
+	scans := make(map[string]hostScan, len(m.hosts))
+	for _, h := range m.hosts {
+		scans[h.name] = h
+	}
+	for _, cfg := range m.app.hostConfigs() {
+		h, ok := scans[cfg.Name]
+		switch {
+		case !ok:
+			parts = append(parts, fmt.Sprintf("%s %s", cfg.Name, m.app.healthIcon(health.HealthUnknown)))
+		case h.err != nil:
+			parts = append(parts, fmt.Sprintf("%s %s unreachable", cfg.Name, m.app.healthIcon(health.HealthDown)))
+		default:
+			parts = append(parts, summary(cfg.Name, h.servers, fmt.Sprintf(" in %dms", h.latency.Milliseconds())))
+		}
+	}
+	return m.styles().muted.Render(fitLine("Hosts: "+strings.Join(parts, " · "), width))
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestScanHostReadsRemoteServers(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/servers" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]APIServer{
+			{Name: "api", Managed: true, Status: "running", Port: 8080, PID: 42, CWD: "/workspaces/api"},
+			{Name: "vite", Status: "running", Port: 5173, PID: 43, Command: "node vite"},
+		})
+	}))
+	defer srv.Close()
+
+	scan := scanHost(context.Background(), srv.Client(), models.HostConfig{Name: "devbox", URL: srv.URL + "/"})
+	if scan.err != nil {
+		t.Fatal(scan.err)
+	}
+	if len(scan.servers) != 2 {
+		t.Fatalf("got %d servers, want 2", len(scan.servers))
+	}
+	api := scan.servers[0]
+	if api.Host != "devbox" || api.ManagedService == nil || api.ManagedService.Name != "api" || api.ProcessRecord.Port != 8080 {
+		t.Errorf("api = %+v", api)
+	}
+	if scan.servers[1].ManagedService != nil {
+		t.Error("unmanaged server became managed")
+	}
+
+	srv.Close()
+	if scan := scanHost(context.Background(), http.DefaultClient, models.HostConfig{Name: "devbox", URL: srv.URL}); scan.err == nil {
+		t.Error("want an error from a host that is down")
+	}
+}
+
+func TestTUIAggregatesHosts(t *testing.T) {
+	t.Parallel()
+	local := &models.ServerInfo{ProcessRecord: &models.ProcessRecord{PID: 10, Port: 3000, Command: "node server.js", CWD: "/src/web"}, Status: "running"}
+	m := newTestTopModel(t, []*models.ServerInfo{local})
+	m.app.settings = &models.Config{Hosts: []models.HostConfig{
+		{Name: "devbox", URL: "http://127.0.0.1:7071"},
+		{Name: "vm", URL: "http://10.0.0.5:7070"},
+	}}
+	next, _ := m.Update(hostsMsg{hosts: []hostScan{
+		{name: "devbox", servers: []*models.ServerInfo{
+			remoteServer("devbox", APIServer{Name: "api", Managed: true, Status: "running", Port: 3000, PID: 7}),
+			remoteServer("devbox", APIServer{Name: "worker", Managed: true, Status: "stopped"}),
+		}},
+		{name: "vm", err: context.DeadlineExceeded},
+	}})
+	m = next.(topModel)
+
+	if got := len(m.visibleServers()); got != 2 {
+		t.Fatalf("visible = %d, want the local server and the running remote one", got)
+	}
+	table := m.renderTable(120)
+	if !strings.Contains(table, "Host") || !strings.Contains(table, "devbox") || !strings.Contains(table, "local") {
+		t.Errorf("table lacks the host column:\n%s", table)
+	}
+	hosts := m.renderHosts(200)
+	if !strings.Contains(hosts, "devbox") || !strings.Contains(hosts, "vm") || !strings.Contains(hosts, "unreachable") {
+		t.Errorf("hosts line = %q", hosts)
+	}
+
+	for _, want := range []string{"local", "devbox", "vm", ""} {
+		m.cycleHostFilter()
+		if m.hostFilter != want {
+			t.Fatalf("host filter = %q, want %q", m.hostFilter, want)
+		}
+		for _, srv := range m.visibleServers() {
+			if want != "" && hostOf(srv) != want {
+				t.Errorf("filter %q shows a server of %s", want, hostOf(srv))
+			}
+		}
+		if want == "vm" && !strings.Contains(m.renderTable(120), "unreachable") {
+			t.Error("an unreachable host's empty table does not say so")
+		}
+	}
+
+	for i, srv := range m.visibleServers() {
+		if srv.Host == "" {
+			continue
+		}
+		m.selected = i
+		if got, msg := m.selectedServer(); got != nil || !strings.Contains(msg, "runs on host devbox") {
+			t.Errorf("selecting a remote server = %v, %q", got, msg)
+		}
+	}
+}
+
+func TestConfigValidatesHosts(t *testing.T) {
+	t.Parallel()
+	for _, hosts := range [][]models.HostConfig{
+		{{Name: "", URL: "http://127.0.0.1:7071"}},
+		{{Name: "local", URL: "http://127.0.0.1:7071"}},
+		{{Name: "vm", URL: "10.0.0.5:7070"}},
+		{{Name: "vm", URL: "http://a"}, {Name: "vm", URL: "http://b"}},
+	} {
+		cfg := &models.Config{Hosts: hosts}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("hosts %+v passed validation", hosts)
+		}
+	}
+}
@@ -0,0 +1,434 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// DefaultIDEInterval is how often devpt ide looks for changes to the server
+// list unless told otherwise.
+const DefaultIDEInterval = 2 * time.Second
+
+// maxIDEMessage bounds the messages devpt ide reads.
+const maxIDEMessage = 16 * 1024 * 1024
+
+// ideParams are the parameters requests take; each uses a few.
+type ideParams struct {
+	Name   string `json:"name"`
+	Port   int    `json:"port"`
+	Lines  int    `json:"lines"`
+	All    bool   `json:"all"`
+	Force  bool   `json:"force"`
+	Follow bool   `json:"follow"`
+	// InitializationOptions are sent with initialize.
+	InitializationOptions struct {
+		All bool `json:"all"`
+	} `json:"initializationOptions"`
+}
+
+// ideServer serves an editor extension from an App. Requests are answered
+// one at a time; server list updates and followed logs are pushed as
+// notifications from their own goroutines.
+type ideServer struct {
+	app      *App
+	version  string // devpt's version, reported in serverInfo
+	interval time.Duration
+
+	// mu guards the App, which is not safe for concurrent use, and the
+	// fields below.
+	mu       sync.Mutex
+	all      bool   // servers/changed reports every listener
+	last     []byte // the server list last sent, to skip unchanged ones
+	watching bool
+	follows  map[string]func() // stops the log streams, by service
+
+	writeMu sync.Mutex
+	out     io.Writer
+	wg      sync.WaitGroup
+}
+
+// IDEServeCmd serves JSON-RPC 2.0 over stdin and stdout, framed with
+// Content-Length headers as in the Language Server Protocol, until stdin is
+// closed or the client sends exit. Editor extensions use it to show a devpt
+// panel: the server list is pushed whenever it changes, checked every
+// interval, and services can be started, stopped and tailed. Anything
+// commands print goes to stderr, keeping stdout for the protocol.
+func (a *App) IDEServeCmd(version string, interval time.Duration) error {
+	a.SetOutput(os.Stderr, os.Stderr)
+	a.SetVia(models.ViaIDE)
+	a.SetNonInteractive(false)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	s := &ideServer{app: a, version: version, interval: interval, out: os.Stdout}
+	return s.serve(ctx, os.Stdin)
+}
+
+// serve answers the messages read from in until it ends, the client sends
+// exit or ctx is done.
+func (s *ideServer) serve(ctx context.Context, in io.Reader) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer func() {
+		cancel()
+		s.stopFollows()
+		s.wg.Wait()
+	}()
+
+	messages := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		r := bufio.NewReader(in)
+		for {
+			msg, err := readRPCFrame(r)
+			if err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case messages <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-readErr:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		case msg := <-messages:
+			exit, err := s.handle(ctx, msg)
+			if exit || err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// handle answers one message. It reports whether the client asked devpt
+// to exit.
+func (s *ideServer) handle(ctx context.Context, msg []byte) (bool, error) {
+	var req rpcRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		return false, s.write(&rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: rpcParseError, Message: err.Error()}})
+	}
+	if len(req.ID) == 0 {
+		switch req.Method {
+		case "initialized":
+			s.watch(ctx)
+		case "exit":
+			return true, nil
+		}
+		return false, nil
+	}
+
+	resp := &rpcResponse{JSONRPC: "2.0", ID: req.ID}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		resp.Error = &rpcError{Code: rpcInvalidRequest, Message: "invalid request"}
+	} else {
+		resp.Result, resp.Error = s.call(ctx, req.Method, req.Params)
+	}
+	return false, s.write(resp)
+}
+
+// call answers a request.
+func (s *ideServer) call(ctx context.Context, method string, raw json.RawMessage) (interface{}, *rpcError) {
+	var p ideParams
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+	}
+	switch method {
+	case "initialize":
+		s.mu.Lock()
+		s.all = p.InitializationOptions.All
+		s.mu.Unlock()
+		return map[string]interface{}{
+			"serverInfo": map[string]string{"name": "devpt", "version": s.version},
+			"capabilities": map[string]interface{}{
+				"serversChanged": true,
+				"logs":           true,
+			},
+		}, nil
+	case "shutdown":
+		s.stopFollows()
+		return map[string]interface{}{}, nil
+	case "devpt/servers":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		servers, err := s.app.apiServers(ctx, p.All)
+		if err != nil {
+			return nil, ideFailed(err, "")
+		}
+		return servers, nil
+	case "devpt/start", "devpt/stop", "devpt/restart":
+		return s.action(ctx, method, p)
+	case "devpt/logs":
+		return s.logs(ctx, p)
+	case "devpt/unfollowLogs":
+		s.mu.Lock()
+		if stop, ok := s.follows[p.Name]; ok {
+			stop()
+			delete(s.follows, p.Name)
+		}
+		s.mu.Unlock()
+		return map[string]interface{}{}, nil
+	case "devpt/health":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		check, err := s.app.apiHealth(ctx, p.Name)
+		if err != nil {
+			return nil, ideFailed(err, "")
+		}
+		return check, nil
+	}
+	return nil, &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("method not found: %s", method)}
+}
+
+// ideFailed reports a request that failed, with what the command printed.
+func ideFailed(err error, output string) *rpcError {
+	e := &rpcError{Code: rpcRequestFailed, Message: err.Error()}
+	if output != "" {
+		e.Data = map[string]string{"output": output}
+	}
+	return e
+}
+
+// action starts, stops or restarts the service p names; devpt/stop also
+// takes a port instead.
+func (s *ideServer) action(ctx context.Context, method string, p ideParams) (interface{}, *rpcError) {
+	a := s.app
+	var fn func() error
+	switch {
+	case method == "devpt/stop" && p.Name == "" && p.Port != 0:
+		if p.Port < 0 || p.Port > 65535 {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("invalid port: %d", p.Port)}
+		}
+		fn = func() error { return a.StopServiceCmd(strconv.Itoa(p.Port), StopOptions{}) }
+	case p.Name == "":
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "name is required"}
+	case method == "devpt/start":
+		fn = func() error { return a.StartServiceCmd(p.Name, StartOptions{Force: p.Force}) }
+	case method == "devpt/stop":
+		fn = func() error { return a.StopServiceCmd(p.Name, StopOptions{}) }
+	default:
+		fn = func() error { return a.RestartCmd(p.Name) }
+	}
+
+	s.mu.Lock()
+	if p.Name != "" && a.registry.GetService(p.Name) == nil {
+		s.mu.Unlock()
+		return nil, ideFailed(errServiceNotFound(p.Name), "")
+	}
+	stdout, stderr, err := a.captureOutput(fn)
+	watching := s.watching
+	s.mu.Unlock()
+	if err != nil {
+		return nil, ideFailed(err, stdout+stderr)
+	}
+	if watching {
+		// Show the change now rather than at the next check.
+		s.publish(ctx)
+	}
+	return apiActionResult{OK: true, Output: stdout + stderr}, nil
+}
+
+// logs returns the last lines of a service's log and, with follow set,
+// sends the lines written after as devpt/log notifications until
+// devpt/unfollowLogs.
+func (s *ideServer) logs(ctx context.Context, p ideParams) (interface{}, *rpcError) {
+	if p.Name == "" {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "name is required"}
+	}
+	lines := p.Lines
+	if lines <= 0 {
+		lines = 50
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.app.registry.GetService(p.Name) == nil {
+		return nil, ideFailed(errServiceNotFound(p.Name), "")
+	}
+	tail, err := s.app.processManager.TailContext(ctx, p.Name, lines)
+	if err != nil && !p.Follow {
+		return nil, ideFailed(err, "")
+	}
+	if tail == nil {
+		tail = []string{}
+	}
+	if _, ok := s.follows[p.Name]; p.Follow && !ok {
+		s.follow(p.Name)
+	}
+	return map[string]interface{}{"service": p.Name, "lines": tail}, nil
+}
+
+// follow streams a service's new log lines to the client. s.mu is held.
+func (s *ideServer) follow(name string) {
+	updates, stop := s.app.processManager.Follow(name)
+	if s.follows == nil {
+		s.follows = make(map[string]func())
+	}
+	s.follows[name] = stop
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for line := range updates {
+			lines := []string{line}
+			// Send what has piled up in one notification.
+		drain:
+			for {
+				select {
+				case more, ok := <-updates:
+					if !ok {
+						break drain
+					}
+					lines = append(lines, more)
+				default:
+					break drain
+				}
+			}
+			_ = s.notify("devpt/log", map[string]interface{}{"service": name, "lines": lines})
+		}
+	}()
+}
+
+func (s *ideServer) stopFollows() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, stop := range s.follows {
+		stop()
+		delete(s.follows, name)
+	}
+}
+
+// watch starts sending devpt/serversChanged: once now, then whenever the
+// server list changes.
+func (s *ideServer) watch(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.watching {
+		return
+	}
+	s.watching = true
+	interval := s.interval
+	if interval <= 0 {
+		interval = DefaultIDEInterval
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			s.publish(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// publish sends the server list if it changed since it was last sent.
+func (s *ideServer) publish(ctx context.Context) {
+	s.mu.Lock()
+	servers, err := s.app.apiServers(ctx, s.all)
+	if err != nil {
+		if ctx.Err() == nil {
+			fmt.Fprintf(s.app.errOut(), "Warning: failed to list servers: %v\n", err)
+		}
+		s.mu.Unlock()
+		return
+	}
+	data, err := json.Marshal(servers)
+	changed := err == nil && !bytes.Equal(data, s.last)
+	if changed {
+		s.last = data
+	}
+	s.mu.Unlock()
+	if changed {
+		_ = s.notify("devpt/serversChanged", map[string]interface{}{"servers": servers})
+	}
+}
+
+func (s *ideServer) notify(method string, params interface{}) error {
+	return s.write(&rpcNotification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// write sends one message, framed with its Content-Length.
+func (s *ideServer) write(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if _, err := fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n", len(data)); err != nil {
+		return err
+	}
+	_, err = s.out.Write(data)
+	return err
+}
+
+// readRPCFrame reads one message framed with a Content-Length header. It
+// returns io.EOF when r ends between messages.
+func readRPCFrame(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	headers := false
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) && (headers || line != "") {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if !headers {
+				continue
+			}
+			break
+		}
+		headers = true
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || !strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n < 0 || n > maxIDEMessage {
+			return nil, fmt.Errorf("invalid Content-Length: %s", strings.TrimSpace(value))
+		}
+		length = n
+	}
+	if length < 0 {
+		return nil, errors.New("message has no Content-Length header")
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
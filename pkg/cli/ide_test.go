@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ideFrame frames a message as devpt ide reads it.
+func ideFrame(msg string) string {
+	return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(msg), msg)
+}
+
+// ideRead reads the next message devpt ide wrote.
+func ideRead(t *testing.T, r *bufio.Reader) map[string]interface{} {
+	t.Helper()
+	data, err := readRPCFrame(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg map[string]interface{}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestIDERequests(t *testing.T) {
+	t.Parallel()
+
+	var in strings.Builder
+	for _, msg := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"capabilities":{}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"devpt/logs","params":{"name":"web","lines":2}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"devpt/start","params":{"name":"missing"}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"devpt/stop","params":{}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"textDocument/hover"}`,
+		`{"jsonrpc":"2.0","id":6,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+		`{"jsonrpc":"2.0","id":7,"method":"devpt/servers"}`,
+	} {
+		in.WriteString(ideFrame(msg))
+	}
+	pr, pw := io.Pipe()
+	s := &ideServer{app: newAPITestApp(t, "one", "two", "three"), version: "test", out: pw}
+	go func() { pw.CloseWithError(s.serve(context.Background(), strings.NewReader(in.String()))) }()
+
+	out := bufio.NewReader(pr)
+	var responses []map[string]interface{}
+	for {
+		data, err := readRPCFrame(out)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		var resp map[string]interface{}
+		if err := json.Unmarshal(data, &resp); err != nil {
+			t.Fatal(err)
+		}
+		responses = append(responses, resp)
+	}
+	if len(responses) != 6 {
+		t.Fatalf("expected 6 responses (none after exit), got %d: %v", len(responses), responses)
+	}
+	info := responses[0]["result"].(map[string]interface{})["serverInfo"].(map[string]interface{})
+	if info["name"] != "devpt" || info["version"] != "test" {
+		t.Fatalf("unexpected serverInfo %v", info)
+	}
+	lines := responses[1]["result"].(map[string]interface{})["lines"].([]interface{})
+	if len(lines) != 2 || lines[0] != "two" || lines[1] != "three" {
+		t.Fatalf("unexpected log lines %v", lines)
+	}
+	errCode := func(resp map[string]interface{}) (float64, string) {
+		e := resp["error"].(map[string]interface{})
+		return e["code"].(float64), e["message"].(string)
+	}
+	if code, msg := errCode(responses[2]); code != rpcRequestFailed || msg != `service "missing" not found` {
+		t.Fatalf("start of a missing service: %v %q", code, msg)
+	}
+	if code, _ := errCode(responses[3]); code != rpcInvalidParams {
+		t.Fatalf("stop without a name or port: %v", code)
+	}
+	if code, _ := errCode(responses[4]); code != rpcMethodNotFound {
+		t.Fatalf("unknown method: %v", code)
+	}
+	if _, ok := responses[5]["result"]; !ok {
+		t.Fatalf("shutdown should succeed, got %v", responses[5])
+	}
+}
+
+func TestIDEFollowsLogs(t *testing.T) {
+	t.Parallel()
+
+	app := newAPITestApp(t, "one")
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	s := &ideServer{app: app, version: "test", out: outW}
+	done := make(chan error, 1)
+	go func() { done <- s.serve(context.Background(), inR) }()
+	out := bufio.NewReader(outR)
+
+	go io.WriteString(inW, ideFrame(`{"jsonrpc":"2.0","id":1,"method":"devpt/logs","params":{"name":"web","follow":true}}`))
+	if resp := ideRead(t, out); resp["id"].(float64) != 1 {
+		t.Fatalf("unexpected response %v", resp)
+	}
+
+	path, err := app.processManager.LatestLogPath("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString("ready on :3000\n"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	note := ideRead(t, out)
+	params := note["params"].(map[string]interface{})
+	if note["method"] != "devpt/log" || params["service"] != "web" || params["lines"].([]interface{})[0] != "ready on :3000" {
+		t.Fatalf("unexpected notification %v", note)
+	}
+
+	inW.Close()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve did not return after stdin closed")
+	}
+}
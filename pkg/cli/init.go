@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/workspace"
+)
+
+// InitCmd proposes managed services for the repository dir belongs to,
+// found in its workspace files, and registers those the user confirms.
+// With dryRun, or without a terminal to ask on and no --yes, it only lists
+// them.
+func (a *App) InitCmd(dir string, dryRun bool) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if fi, err := os.Stat(abs); err != nil || !fi.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	root := workspace.Root(abs)
+	proposed, skipped := a.initProposals(workspace.Discover(root))
+	for _, note := range skipped {
+		fmt.Fprintln(a.out(), note)
+	}
+	if len(proposed) == 0 {
+		fmt.Fprintf(a.out(), "No new services found in %s\n", root)
+		return nil
+	}
+
+	fmt.Fprintf(a.out(), "Found %d service(s) in %s:\n", len(proposed), root)
+	w := tabwriter.NewWriter(a.out(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tDIR\tCOMMAND\tPORT\tFROM")
+	for _, s := range proposed {
+		rel, err := filepath.Rel(root, s.Dir)
+		if err != nil {
+			rel = s.Dir
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.Name, rel, s.Command, describeInitPort(s), s.Source)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	for _, warning := range initPortConflicts(proposed, a.registry.ListServices()) {
+		fmt.Fprintf(a.errOut(), "Warning: %s\n", warning)
+	}
+
+	if dryRun {
+		return nil
+	}
+	if !a.assumeYes && !a.canPrompt() {
+		fmt.Fprintln(a.out(), "Nothing registered; run devpt init in a terminal to pick services, or with --yes to register them all")
+		return nil
+	}
+	added := 0
+	for _, s := range proposed {
+		if !a.confirm(fmt.Sprintf("Register %s (%s)?", s.Name, s.Command)) {
+			continue
+		}
+		svc := &models.ManagedService{
+			Name:       s.Name,
+			CWD:        s.Dir,
+			Command:    s.Command,
+			AutoPort:   s.AutoPort,
+			StopSignal: s.StopSignal,
+		}
+		if s.Port > 0 {
+			svc.Ports = []int{s.Port}
+		}
+		if err := a.AddServiceCmd(svc); err != nil {
+			fmt.Fprintf(a.errOut(), "Warning: %s: %v\n", s.Name, err)
+			continue
+		}
+		added++
+	}
+	fmt.Fprintf(a.out(), "Registered %d of %d service(s)\n", added, len(proposed))
+	return nil
+}
+
+// initProposals names the discovered services within the current project,
+// drops those that are already registered, with the same directory and
+// command, and renames those whose name is taken by another service. It
+// returns notes on the ones dropped.
+func (a *App) initProposals(found []workspace.Service) (proposed []workspace.Service, notes []string) {
+	registered := a.registry.ListServices()
+	taken := make(map[string]bool, len(registered))
+	for _, svc := range registered {
+		taken[svc.Name] = true
+	}
+	for _, s := range found {
+		s.Name = a.ScopedName(s.Name)
+		if name := registeredAs(s, registered); name != "" {
+			notes = append(notes, fmt.Sprintf("Skipping %s (%s): already registered as %q", s.Name, s.Command, name))
+			continue
+		}
+		if taken[s.Name] {
+			base := s.Name
+			for n := 2; taken[s.Name]; n++ {
+				s.Name = base + "-" + strconv.Itoa(n)
+			}
+		}
+		taken[s.Name] = true
+		proposed = append(proposed, s)
+	}
+	return proposed, notes
+}
+
+// registeredAs returns the name of the registered service running the same
+// command in the same directory as s, if any.
+func registeredAs(s workspace.Service, registered []*models.ManagedService) string {
+	for _, svc := range registered {
+		if filepath.Clean(svc.CWD) == filepath.Clean(s.Dir) && strings.TrimSpace(svc.Command) == s.Command {
+			return svc.Name
+		}
+	}
+	return ""
+}
+
+// initPortConflicts describes the ports of proposed services that another
+// proposed or registered service declares too.
+func initPortConflicts(proposed []workspace.Service, registered []*models.ManagedService) []string {
+	owners := make(map[int]string)
+	for _, svc := range registered {
+		for _, p := range svc.Ports {
+			owners[p] = svc.Name
+		}
+	}
+	var out []string
+	for _, s := range proposed {
+		if s.Port <= 0 {
+			continue
+		}
+		if owner, ok := owners[s.Port]; ok {
+			out = append(out, fmt.Sprintf("%s and %s both use port %d; change one before running them together", owner, s.Name, s.Port))
+			continue
+		}
+		owners[s.Port] = s.Name
+	}
+	return out
+}
+
+// describeInitPort renders the port of a proposed service: the port,
+// "auto" for an automatic one, or "-".
+func describeInitPort(s workspace.Service) string {
+	switch {
+	case s.Port > 0:
+		return strconv.Itoa(s.Port)
+	case s.AutoPort:
+		return "auto"
+	}
+	return "-"
+}
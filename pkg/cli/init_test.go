@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/registry"
+	"github.com/devports/devpt/pkg/workspace"
+)
+
+func TestInitProposalsSkipRegisteredServices(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	reg := registry.NewRegistry(filepath.Join(dir, "registry.json"))
+	for _, svc := range []*models.ManagedService{
+		{Name: "web", CWD: filepath.Join(dir, "apps", "web") + "/", Command: "pnpm run dev", Ports: []int{3000}},
+		{Name: "api", CWD: "/elsewhere", Command: "npm start", Ports: []int{5432}},
+	} {
+		if err := reg.AddService(svc); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+	app := &App{registry: reg}
+
+	proposed, notes := app.initProposals([]workspace.Service{
+		{Name: "web", Dir: filepath.Join(dir, "apps", "web"), Command: "pnpm run dev", Port: 3000},
+		{Name: "api", Dir: filepath.Join(dir, "apps", "api"), Command: "pnpm run start", AutoPort: true},
+		{Name: "db", Dir: dir, Command: "docker compose up db", Port: 5432},
+	})
+	var names []string
+	for _, s := range proposed {
+		names = append(names, s.Name)
+	}
+	if want := []string{"api-2", "db"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("proposed %q, want %q", names, want)
+	}
+	if len(notes) != 1 {
+		t.Fatalf("notes = %q, want one for web", notes)
+	}
+
+	conflicts := initPortConflicts(proposed, reg.ListServices())
+	if len(conflicts) != 1 {
+		t.Fatalf("conflicts = %q, want db against api", conflicts)
+	}
+}
@@ -0,0 +1,305 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// DefaultInspectAddr is where devpt inspect listens unless told otherwise:
+// a free port of the loopback address.
+const DefaultInspectAddr = "127.0.0.1:0"
+
+// inspectLogMax is the size at which a request feed starts over, keeping
+// the previous one as <name>.jsonl.1.
+const inspectLogMax = 1 << 20
+
+// inspectViewTail is how many requests the TUI's requests view reads.
+const inspectViewTail = 200
+
+// inspectRecord is one request devpt inspect forwarded, as stored in the
+// request feed.
+type inspectRecord struct {
+	Time       time.Time `json:"time"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Status     int       `json:"status"`
+	DurationMS float64   `json:"duration_ms"`
+	Bytes      int64     `json:"bytes"`
+}
+
+// inspectName names the feed of target: the service, or port-<n>.
+func inspectName(target string) string {
+	if _, err := strconv.Atoi(target); err == nil {
+		return "port-" + target
+	}
+	return target
+}
+
+// inspectLogPath is the request feed of target.
+func (a *App) inspectLogPath(target string) string {
+	return filepath.Join(a.config.ConfigDir, "inspect", bootID(inspectName(target))+".jsonl")
+}
+
+// statusRecorder remembers the status and size of a response. Unwrap lets
+// http.ResponseController reach the flusher and hijacker of w, so that
+// streamed responses and WebSockets pass through.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(p)
+	r.bytes += int64(n)
+	return n, err
+}
+
+func (r *statusRecorder) Flush() {
+	_ = http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// inspector forwards requests to next and records each one.
+type inspector struct {
+	next   http.Handler
+	record func(inspectRecord)
+}
+
+func (i *inspector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w}
+	i.next.ServeHTTP(rec, r)
+	status := rec.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	i.record(inspectRecord{
+		Time:       start,
+		Method:     r.Method,
+		Path:       r.URL.RequestURI(),
+		Status:     status,
+		DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+		Bytes:      rec.bytes,
+	})
+}
+
+// inspectFeed appends records to a request feed, starting it over when it
+// grows past inspectLogMax.
+type inspectFeed struct {
+	path string
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// openInspectFeed starts the feed at path over.
+func openInspectFeed(path string) (*inspectFeed, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &inspectFeed{path: path, f: f}, nil
+}
+
+func (l *inspectFeed) write(rec inspectRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.size+int64(len(line)) >= inspectLogMax {
+		l.f.Close()
+		_ = os.Rename(l.path, l.path+".1")
+		if l.f, err = os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC|os.O_APPEND, 0o644); err != nil {
+			return err
+		}
+		l.size = 0
+	}
+	n, err := l.f.Write(append(line, '\n'))
+	l.size += int64(n)
+	return err
+}
+
+func (l *inspectFeed) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
+
+// readInspectRecords returns the last n requests of the feed at path.
+func readInspectRecords(path string, n int) ([]inspectRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []inspectRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec inspectRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		out = append(out, rec)
+		if len(out) > 2*n {
+			out = append([]inspectRecord(nil), out[len(out)-n:]...)
+		}
+	}
+	if len(out) > n {
+		out = out[len(out)-n:]
+	}
+	return out, scanner.Err()
+}
+
+// formatInspectRecord is the access log line of rec.
+func formatInspectRecord(rec inspectRecord) string {
+	return fmt.Sprintf("%s %-7s %3d %8s %8s %s", rec.Time.Format("15:04:05"), rec.Method, rec.Status,
+		formatInspectDuration(rec.DurationMS), formatBytes(rec.Bytes), rec.Path)
+}
+
+func formatInspectDuration(ms float64) string {
+	if ms < 10 {
+		return fmt.Sprintf("%.1fms", ms)
+	}
+	if ms < 10000 {
+		return fmt.Sprintf("%.0fms", ms)
+	}
+	return fmt.Sprintf("%.1fs", ms/1000)
+}
+
+// InspectCmd serves target, a managed service or a port, on addr until
+// interrupted, printing the method, path, status and duration of every
+// request and recording them for the TUI's requests view.
+func (a *App) InspectCmd(target, addr string) error {
+	next, dest, err := a.targetHandler(target)
+	if err != nil {
+		return err
+	}
+	if addr == "" {
+		addr = DefaultInspectAddr
+	}
+	feed, err := openInspectFeed(a.inspectLogPath(target))
+	if err != nil {
+		return fmt.Errorf("failed to open the request feed: %w", err)
+	}
+	defer feed.Close()
+
+	var mu sync.Mutex
+	handler := &inspector{next: next, record: func(rec inspectRecord) {
+		if err := feed.write(rec); err != nil {
+			fmt.Fprintf(a.errOut(), "Warning: failed to record request: %v\n", err)
+		}
+		mu.Lock()
+		fmt.Fprintln(a.out(), formatInspectRecord(rec))
+		mu.Unlock()
+	}}
+	srv := &http.Server{Handler: handler}
+	return a.serveLocal(addr, srv, "the inspector", "forwards to "+dest, func(listening net.Addr) {
+		_, port, _ := net.SplitHostPort(listening.String())
+		fmt.Fprintf(a.out(), "Inspecting %s at http://localhost:%s (requests view: I in the TUI)\n", dest, port)
+	})
+}
+
+// requestsMsg carries the requests read for the requests view.
+type requestsMsg struct {
+	target  string
+	records []inspectRecord
+	err     error
+}
+
+// requestsCmd reads the request feed shown in the requests view.
+func (m topModel) requestsCmd() tea.Cmd {
+	target, path := m.reqTarget, m.app.inspectLogPath(m.reqTarget)
+	return func() tea.Msg {
+		records, err := readInspectRecords(path, inspectViewTail)
+		return requestsMsg{target: target, records: records, err: err}
+	}
+}
+
+// openRequests shows the requests devpt inspect recorded for the selected
+// server.
+func (m *topModel) openRequests() (string, tea.Cmd) {
+	srv, msg := m.selectedServer()
+	if srv == nil {
+		return msg, nil
+	}
+	target := strconv.Itoa(portOf(srv))
+	if srv.ManagedService != nil {
+		target = srv.ManagedService.Name
+	}
+	m.mode = viewModeRequests
+	m.reqTarget = target
+	m.requests, m.reqErr = nil, nil
+	return "", m.requestsCmd()
+}
+
+// renderRequests shows the latest requests, newest last, in as many lines
+// as the terminal has.
+func (m topModel) renderRequests(width int) string {
+	th := m.styles()
+	if m.reqErr != nil && !errors.Is(m.reqErr, os.ErrNotExist) {
+		return th.danger.Render(fitLine("Failed to read requests: "+m.reqErr.Error(), width)) + "\n"
+	}
+	if len(m.requests) == 0 {
+		return th.muted.Render(fitLine(fmt.Sprintf("No requests recorded; run devpt inspect %s and send requests to the port it prints", m.reqTarget), width)) + "\n"
+	}
+	var errs int
+	var total float64
+	for _, rec := range m.requests {
+		if rec.Status >= 500 {
+			errs++
+		}
+		total += rec.DurationMS
+	}
+	var b strings.Builder
+	b.WriteString(th.muted.Render(fitLine(fmt.Sprintf("%d requests, %d server errors, %s average", len(m.requests), errs, formatInspectDuration(total/float64(len(m.requests)))), width)))
+	b.WriteString("\n\n")
+	rows := m.requests
+	if m.height > 0 {
+		if room := max(m.height-m.chromeHeight(width)-2, 3); len(rows) > room {
+			rows = rows[len(rows)-room:]
+		}
+	}
+	for _, rec := range rows {
+		style := th.text
+		switch {
+		case rec.Status >= 500:
+			style = th.danger
+		case rec.Status >= 400:
+			style = th.warn
+		}
+		b.WriteString(style.Render(fitLine(formatInspectRecord(rec), width)))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
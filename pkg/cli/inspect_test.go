@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/devports/devpt/internal/testutil"
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestInspectorRecordsRequests(t *testing.T) {
+	t.Parallel()
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, "hello")
+	}))
+	defer backend.Close()
+	route := proxyRoute{port: testutil.ServerPort(t, backend)}
+
+	var mu sync.Mutex
+	var got []inspectRecord
+	srv := httptest.NewServer(&inspector{
+		next: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { forwardHTTP(w, r, route, nil) }),
+		record: func(rec inspectRecord) {
+			mu.Lock()
+			got = append(got, rec)
+			mu.Unlock()
+		},
+	})
+	defer srv.Close()
+
+	for _, path := range []string{"/users?page=2", "/missing"} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 2 {
+		t.Fatalf("recorded %d requests, want 2", len(got))
+	}
+	if got[0].Method != "GET" || got[0].Path != "/users?page=2" || got[0].Status != 200 || got[0].Bytes != 5 {
+		t.Errorf("first request = %+v", got[0])
+	}
+	if got[1].Status != http.StatusNotFound {
+		t.Errorf("second request = %+v", got[1])
+	}
+}
+
+func TestInspectFeedRoundTrips(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "inspect", "api.jsonl")
+	feed, err := openInspectFeed(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer feed.Close()
+	at := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		if err := feed.write(inspectRecord{Time: at, Method: "GET", Path: "/" + strings.Repeat("x", i), Status: 200, DurationMS: 1.5}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	records, err := readInspectRecords(path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[0].Path != "/xx" || records[2].Path != "/xxxx" {
+		t.Errorf("last 3 records = %+v", records)
+	}
+	if line := formatInspectRecord(records[0]); !strings.Contains(line, "GET") || !strings.Contains(line, "1.5ms") || !strings.HasSuffix(line, "/xx") {
+		t.Errorf("access log line = %q", line)
+	}
+}
+
+func TestRequestsView(t *testing.T) {
+	t.Parallel()
+	api := &models.ServerInfo{
+		ManagedService: &models.ManagedService{Name: "api"},
+		ProcessRecord:  &models.ProcessRecord{PID: 10, Port: 3000},
+		Status:         "running",
+	}
+	m := newTestTopModel(t, []*models.ServerInfo{api})
+	feed, err := openInspectFeed(m.app.inspectLogPath("api"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer feed.Close()
+	if err := feed.write(inspectRecord{Time: time.Now(), Method: "POST", Path: "/login", Status: 500, DurationMS: 42}); err != nil {
+		t.Fatal(err)
+	}
+
+	status, cmd := m.openRequests()
+	if cmd == nil || m.mode != viewModeRequests {
+		t.Fatalf("openRequests = %q, mode %v", status, m.mode)
+	}
+	next, _ := m.Update(cmd())
+	m = next.(topModel)
+	view := m.render()
+	for _, want := range []string{"Requests: api", "POST", "/login", "1 requests, 1 server errors"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("requests view lacks %q:\n%s", want, view)
+		}
+	}
+}
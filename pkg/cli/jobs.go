@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/devports/devpt/pkg/devpt"
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/process"
+	"github.com/devports/devpt/pkg/schedule"
+)
+
+// JobAddCmd registers a job: a command that is expected to exit, such as a
+// migration or seed.
+func (a *App) JobAddCmd(job *models.Job) error {
+	if job.Name == "" || strings.ContainsAny(job.Name, `/\`) || strings.HasPrefix(job.Name, ".") {
+		return fmt.Errorf("invalid job name %q", job.Name)
+	}
+	if err := validateServiceCommand(job.Command, job.Shell); err != nil {
+		return err
+	}
+	if job.Schedule != "" {
+		if _, err := schedule.Parse(job.Schedule); err != nil {
+			return err
+		}
+	}
+	if err := a.registry.AddJob(job); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out(), "Job %q registered successfully\n", job.Name)
+	return nil
+}
+
+// JobRemoveCmd unregisters a job. Jobs that services require are kept.
+func (a *App) JobRemoveCmd(name string) error {
+	for _, svc := range a.registry.ListServices() {
+		for _, req := range svc.Requires {
+			if req == name {
+				return fmt.Errorf("job %q is required by service %q", name, svc.Name)
+			}
+		}
+	}
+	return a.registry.RemoveJob(name)
+}
+
+// JobRunCmd runs the registered job name in the foreground. Otherwise the
+// command argv, or name as a command line when argv is empty, runs as an
+// ad-hoc job in the current directory, named after the command unless name
+// is given with argv. Output goes to the terminal and to the job's log;
+// devpt exits with the job's exit code.
+func (a *App) JobRunCmd(name string, argv []string) error {
+	var job *models.Job
+	if len(argv) == 0 {
+		job = a.registry.GetJob(name)
+		if job == nil {
+			if err := validateManagedCommand(name); err != nil {
+				return fmt.Errorf("job %q not found, and it is not a command either: %w", name, err)
+			}
+			argv, _ = process.ParseCommand(name)
+			if _, err := exec.LookPath(argv[0]); err != nil {
+				return fmt.Errorf("job %q not found, and it is not a command either: %w", name, err)
+			}
+			name = ""
+		}
+	}
+	if job == nil {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+		if name == "" {
+			base := filepath.Base(argv[0])
+			name = base
+			// Keep ad-hoc runs apart from the registered job of that name.
+			for i := 2; a.registry.GetJob(name) != nil; i++ {
+				name = fmt.Sprintf("%s-%d", base, i)
+			}
+		}
+		job = &models.Job{Name: name, CWD: cwd, Command: process.QuoteCommand(argv)}
+	}
+
+	// Ctrl+C reaches the job through the terminal; devpt stays to record
+	// how it ended.
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	defer signal.Stop(sigs)
+
+	fmt.Fprintf(a.errOut(), "devpt: running job %q: %s\n", job.Name, job.Command)
+	run, err := a.runJob(job, argv, models.JobRun{}, a.out())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.errOut(), "devpt: job %q %s in %s\n", job.Name, run.Exit.Describe(), formatJobDuration(run.Duration.Std()))
+	if !run.Succeeded() {
+		return &ExitError{Code: run.Exit.Code, Reason: fmt.Sprintf("job %q %s", job.Name, run.Exit.Describe())}
+	}
+	return nil
+}
+
+// runJob runs job to completion and records the run. argv overrides the
+// job's command for ad-hoc runs; origin carries how the run was triggered
+// (its For and Scheduled fields).
+func (a *App) runJob(job *models.Job, argv []string, origin models.JobRun, out io.Writer) (models.JobRun, error) {
+	if len(argv) == 0 {
+		var err error
+		if argv, err = process.CommandArgv(job.Command, job.Shell); err != nil {
+			return models.JobRun{}, err
+		}
+	}
+	started := time.Now()
+	logPath, exit, err := a.jobLogs.RunLogged(job.Name, argv, job.CWD, out)
+	if err != nil {
+		return models.JobRun{}, fmt.Errorf("job %q: %w", job.Name, err)
+	}
+	run := origin
+	run.Job = job.Name
+	run.Command = job.Command
+	run.StartedAt = started
+	run.Duration = models.Duration(time.Since(started))
+	run.Exit = exit
+	run.Log = logPath
+	if err := a.registry.RecordJobRun(run); err != nil {
+		fmt.Fprintf(a.errOut(), "Warning: failed to record job run: %v\n", err)
+	}
+	return run, nil
+}
+
+// runPrerequisites runs the jobs svc requires, in order, and stops at the
+// first one that fails.
+func (a *App) runPrerequisites(svc *models.ManagedService) error {
+	return devpt.RunPrerequisites(context.Background(), svc, a.registry, appJobs{a}, func(job string) {
+		fmt.Fprintf(a.out(), "Running job %q before %q...\n", job, svc.Name)
+	})
+}
+
+// appJobs runs prerequisites the way devpt job run does.
+type appJobs struct{ a *App }
+
+func (j appJobs) Run(ctx context.Context, job *models.Job, origin models.JobRun) (models.JobRun, error) {
+	if err := ctx.Err(); err != nil {
+		return models.JobRun{}, err
+	}
+	return j.a.runJob(job, nil, origin, nil)
+}
+
+func (j appJobs) Tail(ctx context.Context, job string, lines int) ([]string, error) {
+	return j.a.jobLogs.TailContext(ctx, job, lines)
+}
+
+// JobListCmd prints the registered jobs and the most recent job runs.
+func (a *App) JobListCmd(runs int) error {
+	jobs := a.registry.ListJobs()
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+	w := tabwriter.NewWriter(a.out(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Name\tCWD\tCommand\tLast run")
+	now := time.Now()
+	for _, job := range jobs {
+		last := "never"
+		if recent := a.registry.RecentJobRuns(job.Name, 1); len(recent) > 0 {
+			last = formatJobRun(recent[0], now)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", job.Name, job.CWD, job.Command, last)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	recent := a.registry.RecentJobRuns("", runs)
+	if len(recent) == 0 {
+		return nil
+	}
+	fmt.Fprintln(a.out(), "\nRecent runs")
+	w = tabwriter.NewWriter(a.out(), 0, 0, 2, ' ', 0)
+	for _, run := range recent {
+		line := fmt.Sprintf("%s\t%s", run.Job, formatJobRun(run, now))
+		if run.For != "" {
+			line += fmt.Sprintf("\tbefore %s", run.For)
+		} else if run.Scheduled {
+			line += "\tscheduled"
+		}
+		fmt.Fprintln(w, line)
+	}
+	return w.Flush()
+}
+
+// JobLogsCmd prints the log of a job's latest run.
+func (a *App) JobLogsCmd(name string, lines int) error {
+	logLines, err := a.jobLogs.Tail(name, lines)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out(), "Logs for job %q:\n", name)
+	for _, line := range logLines {
+		fmt.Fprintln(a.out(), line)
+	}
+	return nil
+}
+
+// formatJobRun renders a run as e.g. "✓ exited 0 in 1.2s, 3m ago".
+func formatJobRun(run models.JobRun, now time.Time) string {
+	icon := "✗"
+	if run.Succeeded() {
+		icon = "✓"
+	}
+	return fmt.Sprintf("%s %s in %s, %s", icon, run.Exit.Describe(), formatJobDuration(run.Duration.Std()), formatAgo(now.Sub(run.StartedAt)))
+}
+
+func formatJobDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	case d < time.Minute:
+		return d.Round(100 * time.Millisecond).String()
+	default:
+		return d.Round(time.Second).String()
+	}
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/process"
+	"github.com/devports/devpt/pkg/registry"
+)
+
+func TestRunPrerequisitesStopsAtFailedJob(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	app := &App{
+		registry: registry.NewRegistry(filepath.Join(dir, "registry.json")),
+		jobLogs:  process.NewManager(filepath.Join(dir, "job-logs")),
+	}
+	for _, job := range []*models.Job{
+		{Name: "migrate", CWD: dir, Command: "echo migrated"},
+		{Name: "seed", CWD: dir, Command: "ls missing-seed-file"},
+		{Name: "codegen", CWD: dir, Command: "echo generated"},
+	} {
+		if err := app.registry.AddJob(job); err != nil {
+			t.Fatalf("AddJob: %v", err)
+		}
+	}
+
+	svc := &models.ManagedService{Name: "web", CWD: dir, Requires: []string{"migrate", "seed", "codegen"}}
+	err := app.runPrerequisites(svc)
+	if err == nil || !strings.Contains(err.Error(), `job "seed" exited`) || !strings.Contains(err.Error(), "missing-seed-file") {
+		t.Fatalf("expected the seed failure with its output, got %v", err)
+	}
+
+	runs := app.registry.RecentJobRuns("", 10)
+	if len(runs) != 2 {
+		t.Fatalf("expected codegen to be skipped after seed failed, got %d runs", len(runs))
+	}
+	if runs[0].Job != "seed" || runs[0].Succeeded() || runs[0].For != "web" {
+		t.Fatalf("unexpected latest run: %+v", runs[0])
+	}
+	if runs[1].Job != "migrate" || !runs[1].Succeeded() {
+		t.Fatalf("unexpected first run: %+v", runs[1])
+	}
+	if lines, err := app.jobLogs.Tail("migrate", 5); err != nil || len(lines) != 1 || lines[0] != "migrated" {
+		t.Fatalf("expected migrate's output in its log, got %v (%v)", lines, err)
+	}
+}
@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// keyMap resolves the keys pressed in the TUI to the actions they are bound
+// to in tui.keys.
+type keyMap struct {
+	bindings map[string]models.KeyBinding
+	actions  map[string]string
+}
+
+// defaultKeys is the keymap without tui.keys, used by models built without
+// one.
+var defaultKeys = newKeyMap(models.DefaultKeyBindings)
+
+func newKeyMap(bindings map[string]models.KeyBinding) keyMap {
+	k := keyMap{bindings: bindings, actions: make(map[string]string)}
+	for action, keys := range bindings {
+		for _, key := range keys {
+			k.actions[key] = action
+		}
+	}
+	return k
+}
+
+// keyMapFor returns the keymap of the config; LoadConfig has validated it.
+func keyMapFor(cfg *models.Config) keyMap {
+	if cfg == nil {
+		return defaultKeys
+	}
+	bindings, err := cfg.TUI.KeyBindings()
+	if err != nil {
+		return defaultKeys
+	}
+	return newKeyMap(bindings)
+}
+
+// action returns the action key is bound to, or "".
+func (k keyMap) action(key string) string {
+	if k.actions == nil {
+		k = defaultKeys
+	}
+	return k.actions[key]
+}
+
+// label describes the keys bound to action for the help and footer, e.g.
+// "x/Delete/^D".
+func (k keyMap) label(action string) string {
+	if k.bindings == nil {
+		k = defaultKeys
+	}
+	keys := k.bindings[action]
+	out := make([]string, len(keys))
+	for i, key := range keys {
+		out[i] = keyLabel(key)
+	}
+	return strings.Join(out, "/")
+}
+
+// short describes the first key bound to action, for hints.
+func (k keyMap) short(action string) string {
+	if k.bindings == nil {
+		k = defaultKeys
+	}
+	if keys := k.bindings[action]; len(keys) > 0 {
+		return keyLabel(keys[0])
+	}
+	return ""
+}
+
+// keyLabel spells a key the way the TUI shows it: ^E for ctrl+e, Enter for
+// enter.
+func keyLabel(key string) string {
+	if rest, ok := strings.CutPrefix(key, "ctrl+"); ok && len(rest) == 1 {
+		return "^" + strings.ToUpper(rest)
+	}
+	switch key {
+	case " ":
+		return "Space"
+	case "up":
+		return "↑"
+	case "down":
+		return "↓"
+	case "pgup":
+		return "PgUp"
+	case "pgdown":
+		return "PgDn"
+	}
+	if len(key) > 1 {
+		parts := strings.Split(key, "+")
+		for i, p := range parts {
+			if len(p) > 1 {
+				parts[i] = strings.ToUpper(p[:1]) + p[1:]
+			}
+		}
+		return strings.Join(parts, "+")
+	}
+	return key
+}
+
+// keyHelp describes the actions in the order the help view lists them.
+var keyHelp = []struct{ action, desc string }{
+	{"quit", "quit"},
+	{"focus", "switch between the running and managed lists"},
+	{"up", "move the selection up"},
+	{"down", "move the selection down"},
+	{"logs", "open logs (running list), start (managed list)"},
+	{"stop", "stop selected"},
+	{"restart", "restart selected (or the service in logs)"},
+	{"add", "add service"},
+	{"edit", "edit service"},
+	{"remove", "remove selected managed service"},
+	{"conflict", "resolve the port conflict of the selected managed service"},
+	{"filter", "filter"},
+	{"clear_filter", "clear filter"},
+	{"sort", "cycle sort mode"},
+	{"health_detail", "health detail"},
+	{"all_listeners", "all listeners"},
+	{"host", "cycle the host filter (remote hosts)"},
+	{"refresh", "refresh"},
+	{"framework", "framework column"},
+	{"columns", "choose columns and command width"},
+	{"debug", "scan timing"},
+	{"runs", "recent runs"},
+	{"requests", "requests recorded by devpt inspect for the selected server"},
+	{"detail", "detail panel"},
+	{"pin_logs", "pin logs below (two side by side)"},
+	{"open", "open in browser"},
+	{"next_url", "next URL"},
+	{"edit_project", "edit project"},
+	{"copy", "copy (then p PID, P port, u URL, c command)"},
+	{"log_usage", "log usage"},
+	{"gc", "clean up logs"},
+	{"theme", "next color theme"},
+	{"command", "command input"},
+	{"help", "help"},
+	{"back", "back from logs and help (Esc works too)"},
+	{"follow", "toggle follow in logs"},
+	{"page_up", "scroll logs a page up"},
+	{"page_down", "scroll logs a page down"},
+	{"top", "jump to the start of the logs"},
+	{"bottom", "jump to the end of the logs and follow"},
+}
+
+// helpLines lists every action with the keys bound to it, in columns of
+// width.
+func (k keyMap) helpLines(width int) []string {
+	keyW, descW := 0, 0
+	for _, h := range keyHelp {
+		keyW = max(keyW, len([]rune(k.label(h.action))))
+		descW = max(descW, len([]rune(h.desc)))
+	}
+	colW := keyW + 2 + descW
+	cols := max(1, (width+2)/(colW+2))
+	var lines []string
+	for i := 0; i < len(keyHelp); i += cols {
+		var cells []string
+		for _, h := range keyHelp[i:min(i+cols, len(keyHelp))] {
+			cells = append(cells, fixedCell(fmt.Sprintf("%-*s  %s", keyW, k.label(h.action), h.desc), colW))
+		}
+		lines = append(lines, strings.TrimRight(strings.Join(cells, "  "), " "))
+	}
+	return lines
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestKeyBindingsValidation(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name string
+		keys string
+		err  string
+	}{
+		{"defaults", `{}`, ""},
+		{"rebind", `{"stop": "ctrl+x", "up": ["up", "t"]}`, ""},
+		{"swap", `{"remove": "d", "debug": "D"}`, ""},
+		{"unknown action", `{"explode": "z"}`, `unknown action "explode"`},
+		{"conflict", `{"stop": "x"}`, `"x" is bound to both remove and stop`},
+		{"empty", `{"stop": []}`, "no keys given"},
+		{"blank key", `{"stop": ""}`, "invalid key"},
+	}
+	for _, tc := range cases {
+		var tui models.TUISettings
+		if err := json.Unmarshal([]byte(`{"keys": `+tc.keys+`}`), &tui); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		_, err := tui.KeyBindings()
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+		case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+			t.Errorf("%s: error = %v, want %q", tc.name, err, tc.err)
+		}
+	}
+}
+
+func TestKeyHelpCoversEveryAction(t *testing.T) {
+	t.Parallel()
+	listed := make(map[string]bool)
+	for _, h := range keyHelp {
+		listed[h.action] = true
+	}
+	for action := range models.DefaultKeyBindings {
+		if !listed[action] {
+			t.Errorf("action %q is missing from the help view", action)
+		}
+	}
+}
+
+func TestReboundKeys(t *testing.T) {
+	t.Parallel()
+	cfg := &models.Config{TUI: models.TUISettings{Keys: map[string]models.KeyBinding{
+		"sort": {"o"},
+		"open": {"O"},
+	}}}
+	m := newTestTopModel(t, nil)
+	m.keys = keyMapFor(cfg)
+
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	m = next.(topModel)
+	if m.sortBy != sortRecent {
+		t.Errorf("unbound s still sorts")
+	}
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("o")})
+	m = next.(topModel)
+	if m.sortBy != sortName {
+		t.Errorf("o does not sort once bound to sort")
+	}
+	help := strings.Join(strings.Fields(strings.Join(m.keys.helpLines(200), " ")), " ")
+	if !strings.Contains(help, " o cycle sort mode") || !strings.Contains(help, " O open in browser") {
+		t.Errorf("help does not show the active bindings:\n%s", help)
+	}
+}
@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/process"
+)
+
+// leftoverSettle is how long a stop waits for the rest of the process tree
+// to exit before reporting what is left.
+const leftoverSettle = time.Second
+
+// stopSnapshot is what a service looked like right before a stop: its
+// process tree, which loses its links to the service once the processes are
+// reparented, and its ports.
+type stopSnapshot struct {
+	tree  []process.Proc
+	ports []int
+}
+
+func (a *App) snapshotForStop(svc *models.ManagedService, pid int) stopSnapshot {
+	root := pid
+	if a.lastPIDState(svc) == pidLive {
+		root = *svc.LastPID
+	}
+	tree, err := a.processManager.Descendants(root)
+	if err != nil {
+		fmt.Fprintf(a.errOut(), "Warning: failed to list the processes of %q: %v\n", svc.Name, err)
+	}
+	if root != pid {
+		// The stop targets the listener; the process devpt started may
+		// outlive it.
+		tree = append([]process.Proc{{PID: root, Command: svc.CommandSummary()}}, tree...)
+	}
+	return stopSnapshot{tree: tree, ports: append([]int(nil), svc.ActivePorts()...)}
+}
+
+// findLeftovers returns the processes from snap that are still running,
+// and whatever listens on the service's ports, once the stop settled.
+func (a *App) findLeftovers(snap stopSnapshot) []models.Leftover {
+	deadline := time.Now().Add(leftoverSettle)
+	for {
+		leftovers := a.scanLeftovers(snap)
+		if len(leftovers) == 0 || time.Now().After(deadline) {
+			return leftovers
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func (a *App) scanLeftovers(snap stopSnapshot) []models.Leftover {
+	var out []models.Leftover
+	seen := make(map[int]int) // PID -> index in out
+	if len(snap.ports) > 0 {
+		records, err := a.scanner.ScanListeningPorts()
+		if err == nil {
+			for _, rec := range records {
+				if rec.Container != nil || !containsInt(snap.ports, rec.Port) {
+					continue
+				}
+				if _, ok := seen[rec.PID]; ok {
+					continue
+				}
+				seen[rec.PID] = len(out)
+				out = append(out, models.Leftover{PID: rec.PID, Port: rec.Port, Command: rec.Command})
+			}
+		}
+	}
+	for _, p := range snap.tree {
+		if _, ok := seen[p.PID]; ok || !a.processManager.IsRunning(p.PID) {
+			continue
+		}
+		seen[p.PID] = len(out)
+		out = append(out, models.Leftover{PID: p.PID, Command: p.Command})
+	}
+	for i := range out {
+		if started, err := a.processManager.StartTime(out[i].PID); err == nil {
+			out[i].Started = started
+		}
+	}
+	return out
+}
+
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// liveLeftovers returns the recorded leftovers of svc that are still running.
+func (a *App) liveLeftovers(svc *models.ManagedService) []models.Leftover {
+	var live []models.Leftover
+	for _, l := range svc.Leftovers {
+		if !a.processManager.IsRunning(l.PID) {
+			continue
+		}
+		if started, err := a.processManager.StartTime(l.PID); err == nil && !l.Started.IsZero() && !startMatches(started, l.Started) {
+			continue
+		}
+		live = append(live, l)
+	}
+	return live
+}
+
+// reportLeftovers looks for processes that outlived the stop of svc, offers
+// to stop them and records the ones left running.
+func (a *App) reportLeftovers(svc *models.ManagedService, snap stopSnapshot, opts StopOptions) {
+	a.settleLeftovers(svc, a.findLeftovers(snap), opts)
+}
+
+func (a *App) settleLeftovers(svc *models.ManagedService, leftovers []models.Leftover, opts StopOptions) {
+	if len(leftovers) > 0 {
+		fmt.Fprintf(a.errOut(), "Warning: processes of %q are still running after the stop:\n", svc.Name)
+		for _, l := range leftovers {
+			fmt.Fprintf(a.errOut(), "  %s\n", describeLeftover(l))
+		}
+		if opts.Cleanup || (opts.Interactive && a.confirm("Stop them?")) {
+			leftovers = a.stopLeftovers(leftovers)
+		} else {
+			fmt.Fprintf(a.errOut(), "Stop them with: devpt stop %s --cleanup\n", svc.Name)
+		}
+	}
+	if len(leftovers) == 0 && len(svc.Leftovers) == 0 {
+		return
+	}
+	if err := a.registry.SetLeftovers(svc.Name, leftovers); err != nil {
+		fmt.Fprintf(a.errOut(), "Warning: failed to update registry: %v\n", err)
+	}
+}
+
+// stopLeftovers stops each leftover and returns those still running.
+func (a *App) stopLeftovers(leftovers []models.Leftover) []models.Leftover {
+	var remaining []models.Leftover
+	for _, l := range leftovers {
+		if err := a.processManager.Stop(l.PID, process.DefaultStopTimeout); err != nil && !isProcessFinishedErr(err) {
+			fmt.Fprintf(a.errOut(), "Warning: failed to stop PID %d: %v\n", l.PID, err)
+			remaining = append(remaining, l)
+			continue
+		}
+		fmt.Fprintf(a.out(), "Process %d stopped\n", l.PID)
+	}
+	return remaining
+}
+
+// cleanupLeftovers stops the recorded leftovers of a service that is no
+// longer running.
+func (a *App) cleanupLeftovers(svc *models.ManagedService) error {
+	leftovers := a.liveLeftovers(svc)
+	if len(leftovers) == 0 {
+		if len(svc.Leftovers) > 0 {
+			if err := a.registry.SetLeftovers(svc.Name, nil); err != nil {
+				fmt.Fprintf(a.errOut(), "Warning: failed to update registry: %v\n", err)
+			}
+		}
+		return fmt.Errorf("service %q is not running and left no processes behind", svc.Name)
+	}
+	a.settleLeftovers(svc, leftovers, StopOptions{Cleanup: true})
+	return nil
+}
+
+// describeLeftover renders a leftover as e.g. "PID 4242 on port 3000: node server.js".
+func describeLeftover(l models.Leftover) string {
+	s := fmt.Sprintf("PID %d", l.PID)
+	if l.Port > 0 {
+		s += fmt.Sprintf(" on port %d", l.Port)
+	}
+	return s + ": " + truncateCommand(l.Command, 80)
+}
+
+// confirm asks a yes/no question on the terminal; without one, or in
+// non-interactive mode, the answer is no unless --yes was given.
+func (a *App) confirm(question string) bool {
+	if a.assumeYes {
+		fmt.Fprintf(a.errOut(), "%s yes (--yes)\n", question)
+		return true
+	}
+	if !a.canPrompt() {
+		return false
+	}
+	fmt.Fprintf(a.errOut(), "%s [y/N] ", question)
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes"
+}
+
+// canPrompt reports whether confirm can ask on the terminal.
+func (a *App) canPrompt() bool {
+	return !a.nonInteractive && term.IsTerminal(os.Stdin.Fd())
+}
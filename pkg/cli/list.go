@@ -0,0 +1,353 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devports/devpt/pkg/health"
+	"github.com/devports/devpt/pkg/models"
+)
+
+// ListOptions select, order and lay out the rows of devpt ls.
+type ListOptions struct {
+	Detailed bool
+	// Sort is "port", "name", "cpu" (busiest first) or "uptime" (longest
+	// running first); empty keeps the scan order.
+	Sort string
+	// Filter keeps servers whose name, project, command, directory or port
+	// contains it, ignoring case.
+	Filter string
+	// Source keeps servers of one origin: "managed" (registered with
+	// devpt), "agent", "manual", "container" or "port-forward".
+	Source string
+	// Status keeps servers in one state. "running" includes starting, ready
+	// and paused services; "crashed" includes crash-looping ones.
+	Status string
+	// Columns replaces the default columns; see listColumns.
+	Columns []string
+	// Format is "table" (the default), "alfred" for script filter JSON,
+	// "raycast" for script command output, or "template=TEXT" to print each
+	// server with a Go template over APIServer.
+	Format string
+	// JSON prints each server as a line of REST API JSON instead.
+	JSON bool
+}
+
+// listColumns are the columns devpt ls --columns accepts, with their
+// headings.
+var listColumns = []struct{ name, heading string }{
+	{"name", "Name"},
+	{"port", "Port"},
+	{"bind", "Bind"},
+	{"pid", "PID"},
+	{"project", "Project"},
+	{"framework", "Framework"},
+	{"command", "Command"},
+	{"source", "Source"},
+	{"status", "Status"},
+	{"health", "Health"},
+	{"cpu", "CPU"},
+	{"mem", "Mem"},
+	{"uptime", "Uptime"},
+	{"url", "URL"},
+}
+
+var (
+	defaultColumns  = []string{"name", "port", "bind", "pid", "project", "source", "status"}
+	detailedColumns = []string{"name", "port", "bind", "pid", "project", "framework", "command", "source", "status"}
+)
+
+// ListSorts are the orders devpt ls --sort accepts.
+var ListSorts = []string{"port", "name", "cpu", "uptime"}
+
+var (
+	listSources  = []string{"managed", "agent", "manual", "container", "port-forward"}
+	listStatuses = []string{"running", "starting", "ready", "paused", "stopped", "crashed", "crash-looping"}
+)
+
+// Validate checks the options before anything is scanned.
+func (o ListOptions) Validate() error {
+	if o.Sort != "" && !contains(ListSorts, o.Sort) {
+		return fmt.Errorf("invalid sort %q (want %s)", o.Sort, strings.Join(ListSorts, ", "))
+	}
+	if o.Source != "" && !contains(listSources, o.Source) {
+		return fmt.Errorf("invalid source %q (want %s)", o.Source, strings.Join(listSources, ", "))
+	}
+	if o.Status != "" && !contains(listStatuses, o.Status) {
+		return fmt.Errorf("invalid status %q (want %s)", o.Status, strings.Join(listStatuses, ", "))
+	}
+	for _, col := range o.Columns {
+		if columnHeading(col) == "" {
+			names := make([]string, len(listColumns))
+			for i, c := range listColumns {
+				names[i] = c.name
+			}
+			return fmt.Errorf("invalid column %q (want %s)", col, strings.Join(names, ", "))
+		}
+	}
+	if o.JSON && o.Format != "" && o.Format != "table" {
+		return fmt.Errorf("--json and --format %s cannot be combined", o.Format)
+	}
+	return o.validateFormat()
+}
+
+// columns returns the columns to print.
+func (o ListOptions) columns() []string {
+	switch {
+	case len(o.Columns) > 0:
+		return o.Columns
+	case o.Detailed:
+		return detailedColumns
+	}
+	return defaultColumns
+}
+
+// needsStats reports whether the sort or columns show resource use.
+func (o ListOptions) needsStats() bool {
+	if o.Sort == "cpu" || o.Sort == "uptime" {
+		return true
+	}
+	for _, col := range o.columns() {
+		if col == "cpu" || col == "mem" || col == "uptime" {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func columnHeading(name string) string {
+	for _, c := range listColumns {
+		if c.name == name {
+			return c.heading
+		}
+	}
+	return ""
+}
+
+// serverSource is the origin of a server as --source names it.
+func serverSource(srv *models.ServerInfo) string {
+	if srv.ManagedService != nil {
+		return string(models.SourceManaged)
+	}
+	if rec := srv.ProcessRecord; rec != nil {
+		switch {
+		case rec.Container != nil:
+			return string(models.SourceContainer)
+		case rec.PortForward != nil:
+			return string(models.SourceForward)
+		case rec.AgentTag != nil:
+			return string(models.SourceAgent)
+		}
+	}
+	return string(models.SourceManual)
+}
+
+// statusMatches reports whether status is in the state want names.
+func statusMatches(status, want string) bool {
+	switch want {
+	case "running":
+		return status == "running" || status == "starting" || status == "ready" || status == "paused"
+	case "crashed":
+		return isCrashStatus(status)
+	}
+	return status == want
+}
+
+// filterServers returns the servers opts keeps.
+func (a *App) filterServers(servers []*models.ServerInfo, opts ListOptions) []*models.ServerInfo {
+	q := strings.ToLower(strings.TrimSpace(opts.Filter))
+	var out []*models.ServerInfo
+	for _, srv := range servers {
+		if opts.Source != "" && serverSource(srv) != opts.Source {
+			continue
+		}
+		if opts.Status != "" && !statusMatches(srv.Status, opts.Status) {
+			continue
+		}
+		if q != "" {
+			cells := a.serverCells(srv)
+			hay := []string{cells["name"], cells["port"], cells["project"], cells["command"]}
+			if srv.ProcessRecord != nil {
+				hay = append(hay, srv.ProcessRecord.CWD)
+			}
+			if srv.ManagedService != nil {
+				hay = append(hay, srv.ManagedService.CWD)
+			}
+			if !strings.Contains(strings.ToLower(strings.Join(hay, " ")), q) {
+				continue
+			}
+		}
+		out = append(out, srv)
+	}
+	return out
+}
+
+// serverStats is a server's resource use, for the cpu, mem and uptime
+// columns and sorts.
+type serverStats struct {
+	CPU    float64 // average percent of one core since the process started
+	Mem    models.ByteSize
+	Uptime time.Duration
+}
+
+// collectServerStats samples the live servers once. A managed service
+// counts its whole process group, as resource limits do.
+func (a *App) collectServerStats(servers []*models.ServerInfo) map[*models.ServerInfo]serverStats {
+	procs, err := a.processManager.Stats()
+	if err != nil {
+		return nil
+	}
+	groups, _ := a.processManager.GroupUsage()
+	out := make(map[*models.ServerInfo]serverStats)
+	for _, srv := range servers {
+		pid := 0
+		if srv.ProcessRecord != nil {
+			pid = srv.ProcessRecord.PID
+		}
+		mem, cpuTime := int64(0), time.Duration(0)
+		if svc := srv.ManagedService; svc != nil && svc.LastPID != nil && statusMatches(srv.Status, "running") {
+			if g, ok := groups[*svc.LastPID]; ok {
+				pid, mem, cpuTime = *svc.LastPID, g.RSS, g.CPUTime
+			}
+		}
+		p, ok := procs[pid]
+		if !ok {
+			continue
+		}
+		if mem == 0 {
+			mem, cpuTime = p.RSS, p.CPUTime
+		}
+		st := serverStats{Mem: models.ByteSize(mem), Uptime: p.Elapsed}
+		if p.Elapsed > 0 {
+			st.CPU = cpuPercent(cpuTime, p.Elapsed)
+		}
+		out[srv] = st
+	}
+	return out
+}
+
+// sortServers orders servers as opts asks; servers without resource use
+// go last in the cpu and uptime orders.
+func sortServers(servers []*models.ServerInfo, opts ListOptions, stats map[*models.ServerInfo]serverStats, names map[*models.ServerInfo]string) {
+	sort.SliceStable(servers, func(i, j int) bool {
+		a, b := servers[i], servers[j]
+		switch opts.Sort {
+		case "name":
+			na, nb := strings.ToLower(names[a]), strings.ToLower(names[b])
+			if na != nb {
+				return na < nb
+			}
+		case "cpu", "uptime":
+			sa, okA := stats[a]
+			sb, okB := stats[b]
+			if okA != okB {
+				return okA
+			}
+			if opts.Sort == "cpu" && sa.CPU != sb.CPU {
+				return sa.CPU > sb.CPU
+			}
+			if opts.Sort == "uptime" && sa.Uptime != sb.Uptime {
+				return sa.Uptime > sb.Uptime
+			}
+		}
+		return serverLess(a, b)
+	})
+}
+
+// serverCells returns the text of each column of a server's row, except
+// the health and resource columns.
+func (a *App) serverCells(srv *models.ServerInfo) map[string]string {
+	cells := map[string]string{
+		"name":      "-",
+		"port":      "-",
+		"bind":      bindLabel(srv.ProcessRecord),
+		"pid":       "-",
+		"project":   "-",
+		"framework": "-",
+		"command":   "-",
+		"source":    string(srv.Source),
+		"status":    srv.Status,
+	}
+
+	if srv.ManagedService != nil {
+		cells["name"] = srv.ManagedService.Name
+		if ports := srv.ManagedService.ActivePorts(); len(ports) > 0 {
+			cells["port"] = strconv.Itoa(ports[0])
+		}
+		cells["command"] = srv.ManagedService.CommandSummary()
+	}
+
+	if rec := srv.ProcessRecord; rec != nil {
+		if cells["name"] == "-" && rec.Infra != "" {
+			cells["name"] = rec.Infra
+		}
+		cells["pid"] = strconv.Itoa(rec.PID)
+		cells["port"] = strconv.Itoa(rec.Port)
+		if rec.Protocol == "udp" {
+			cells["port"] += "/udp"
+		}
+		project := rec.ProjectRoot
+		if stack := rec.Stack(); stack != "" {
+			cells["framework"] = stack
+		}
+		if cells["command"] == "-" {
+			cells["command"] = displayCommand(rec)
+		}
+		cells["source"] = sourceLabel(srv)
+		if c := rec.Container; c != nil && project == "" {
+			project = c.ComposeProject
+		}
+		cells["project"] = project
+	}
+	return cells
+}
+
+// sourceLabel describes where a server comes from: its container, port
+// forward or agent, else it was started by hand.
+func sourceLabel(srv *models.ServerInfo) string {
+	rec := srv.ProcessRecord
+	switch {
+	case rec == nil:
+		return string(srv.Source)
+	case rec.Container != nil:
+		return string(models.SourceContainer)
+	case rec.PortForward != nil:
+		return string(models.SourceForward)
+	case rec.AgentTag != nil:
+		return fmt.Sprintf("%s:%s", rec.AgentTag.Source, rec.AgentTag.AgentName)
+	}
+	return string(models.SourceManual)
+}
+
+// serverHealth checks a live server for the health column.
+func (a *App) serverHealth(srv *models.ServerInfo) string {
+	check := a.checkHealth(context.Background(), srv)
+	if check == nil {
+		return "-"
+	}
+	return a.healthLabel(check.Status)
+}
+
+// checkHealth probes a live server; nil when there is nothing to probe.
+func (a *App) checkHealth(ctx context.Context, srv *models.ServerInfo) *health.HealthCheck {
+	switch {
+	case srv.ProcessRecord != nil && srv.ProcessRecord.Port > 0:
+		return checkServerHealth(ctx, a.healthChecker, srv)
+	case srv.ManagedService != nil && srv.ManagedService.Health != nil && srv.ManagedService.Health.Command != "" && statusMatches(srv.Status, "running"):
+		return a.healthChecker.CheckServiceContext(ctx, srv.ManagedService, 0)
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/devports/devpt/pkg/health"
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/registry"
+)
+
+func TestListOptionsValidate(t *testing.T) {
+	t.Parallel()
+
+	valid := []ListOptions{
+		{},
+		{Sort: "cpu", Source: "managed", Status: "crashed", Columns: []string{"name", "health", "uptime"}},
+		{Format: "alfred"},
+		{Format: "raycast"},
+		{Format: "template={{.Name}} {{.Port}}"},
+	}
+	for _, opts := range valid {
+		if err := opts.Validate(); err != nil {
+			t.Errorf("%+v: %v", opts, err)
+		}
+	}
+	invalid := []ListOptions{{Sort: "size"}, {Source: "docker"}, {Status: "gone"}, {Columns: []string{"name", "color"}}, {Format: "xml"}, {Format: "template={{.Name"}}
+	for _, opts := range invalid {
+		if err := opts.Validate(); err == nil {
+			t.Errorf("%+v: expected an error", opts)
+		}
+	}
+}
+
+func TestFilterAndSortServers(t *testing.T) {
+	t.Parallel()
+
+	api := &models.ServerInfo{ManagedService: &models.ManagedService{Name: "api", CWD: "/src/api"}, ProcessRecord: &models.ProcessRecord{PID: 30, Port: 8080}, Status: "ready"}
+	web := &models.ServerInfo{ProcessRecord: &models.ProcessRecord{PID: 20, Port: 3000, Command: "vite", AgentTag: &models.AgentTag{Source: models.SourceAgent}}, Status: "running"}
+	worker := &models.ServerInfo{ManagedService: &models.ManagedService{Name: "Worker", CWD: "/src/worker"}, Status: "crash-looping"}
+	vite := &models.ServerInfo{ProcessRecord: &models.ProcessRecord{PID: 10, Port: 5173, Command: "vite"}, Status: "running"}
+	servers := []*models.ServerInfo{api, web, worker, vite}
+	app := &App{}
+
+	names := func(got []*models.ServerInfo) []string {
+		var out []string
+		for _, srv := range got {
+			out = append(out, app.serverCells(srv)["port"]+"/"+serverSource(srv))
+		}
+		return out
+	}
+	check := func(opts ListOptions, want ...*models.ServerInfo) {
+		t.Helper()
+		got := app.filterServers(servers, opts)
+		if len(got) != len(want) {
+			t.Fatalf("%+v: got %v", opts, names(got))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("%+v: got %v", opts, names(got))
+			}
+		}
+	}
+	check(ListOptions{Source: "managed"}, api, worker)
+	check(ListOptions{Source: "agent"}, web)
+	check(ListOptions{Source: "manual"}, vite)
+	check(ListOptions{Status: "running"}, api, web, vite)
+	check(ListOptions{Status: "crashed"}, worker)
+	check(ListOptions{Filter: "VITE"}, web, vite)
+	check(ListOptions{Filter: "/src/worker"}, worker)
+	check(ListOptions{Filter: "8080"}, api)
+
+	sorted := append([]*models.ServerInfo(nil), servers...)
+	byName := map[*models.ServerInfo]string{api: "api", web: "web", worker: "Worker", vite: "vite"}
+	sortServers(sorted, ListOptions{Sort: "name"}, nil, byName)
+	if sorted[0] != api || sorted[1] != vite || sorted[2] != web || sorted[3] != worker {
+		t.Fatalf("unexpected name order: %v", names(sorted))
+	}
+
+	stats := map[*models.ServerInfo]serverStats{api: {CPU: 5}, web: {CPU: 40}, vite: {CPU: 5}}
+	sortServers(sorted, ListOptions{Sort: "cpu"}, stats, byName)
+	if sorted[0] != web || sorted[1] != vite || sorted[2] != api || sorted[3] != worker {
+		t.Fatalf("unexpected cpu order: %v", names(sorted))
+	}
+}
+
+func TestFormatServerRowColumns(t *testing.T) {
+	t.Parallel()
+
+	srv := &models.ServerInfo{ManagedService: &models.ManagedService{Name: "api"}, Status: "stopped", Source: models.SourceManaged}
+	app := &App{}
+	row := app.formatServerRow(srv, []string{"name", "status", "cpu", "pid"}, nil)
+	want := []string{"api", "stopped", "-", "-"}
+	for i := range want {
+		if row[i] != want[i] {
+			t.Fatalf("got %q, want %q", row, want)
+		}
+	}
+}
+
+func TestExposedFootnoteFollowsBindColumn(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	servers := []*models.ServerInfo{
+		{ProcessRecord: &models.ProcessRecord{PID: 10, Port: 5173, Command: "vite", BindAddress: "*"}, Status: "running", Source: models.SourceManual},
+	}
+	const footnote = "listening on all interfaces"
+	for _, tc := range []struct {
+		columns []string
+		want    bool
+	}{
+		{nil, true},
+		{[]string{"name", "port", "bind"}, true},
+		{[]string{"name", "port"}, false},
+	} {
+		var out bytes.Buffer
+		app := &App{config: models.ConfigPathsIn(dir), registry: registry.NewRegistry(filepath.Join(dir, "registry.json"))}
+		app.SetOutput(&out, io.Discard)
+		if err := app.printServerTable(servers, ListOptions{Columns: tc.columns}); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Contains(out.String(), footnote); got != tc.want {
+			t.Errorf("--columns %s: footnote shown = %v, want %v:\n%s", strings.Join(tc.columns, ","), got, tc.want, out.String())
+		}
+	}
+}
+
+func TestHealthLabelText(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		app  *App
+		want string
+	}{
+		{&App{}, "✅ ok"},
+		{&App{textIcons: true}, "OK"},
+		{&App{settings: &models.Config{Health: models.HealthSettings{Icons: "text"}}}, "OK"},
+		{&App{nonInteractive: true, textIcons: true}, "ok"},
+	}
+	for _, tc := range cases {
+		if got := tc.app.healthLabel(health.HealthOK); got != tc.want {
+			t.Errorf("healthLabel = %q, want %q", got, tc.want)
+		}
+	}
+	if got := (&App{textIcons: true}).healthIcon(health.HealthDown); got != "DOWN" {
+		t.Errorf("healthIcon = %q, want DOWN", got)
+	}
+}
+
+func TestWriteServerFeed(t *testing.T) {
+	t.Parallel()
+
+	api := &models.ServerInfo{ManagedService: &models.ManagedService{Name: "api", CWD: "/src/api"}, ProcessRecord: &models.ProcessRecord{PID: 30, Port: 8080}, Status: "ready", Source: models.SourceManaged}
+	worker := &models.ServerInfo{ManagedService: &models.ManagedService{Name: "worker", CWD: "/src/worker"}, Status: "stopped", Source: models.SourceManaged}
+	vite := &models.ServerInfo{ProcessRecord: &models.ProcessRecord{PID: 10, Port: 5173, Command: "vite"}, Status: "running", Source: models.SourceManual}
+	servers := []*models.ServerInfo{api, worker, vite}
+	app := &App{}
+
+	var out bytes.Buffer
+	if err := app.writeServerFeed(&out, servers, ListOptions{Format: "template={{.Name}} {{.Port}} {{.Status}}"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "worker 0 stopped\nvite 5173 running\napi 8080 ready\n"; got != want {
+		t.Fatalf("template output = %q, want %q", got, want)
+	}
+
+	out.Reset()
+	if err := app.writeServerFeed(&out, servers, ListOptions{Format: "alfred", Source: "managed"}); err != nil {
+		t.Fatal(err)
+	}
+	var feed struct {
+		Items []alfredItem `json:"items"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &feed); err != nil {
+		t.Fatal(err)
+	}
+	if len(feed.Items) != 2 {
+		t.Fatalf("expected the 2 managed services, got %+v", feed.Items)
+	}
+	stopped, running := feed.Items[0], feed.Items[1]
+	if stopped.Arg != "worker" || stopped.Variables["action"] != "start" || stopped.Mods["cmd"].Arg != "" {
+		t.Fatalf("stopped service item = %+v", stopped)
+	}
+	if running.Variables["action"] != "stop" || running.Mods["cmd"].Variables["action"] != "restart" || running.Subtitle != ":8080 · ready · api" {
+		t.Fatalf("running service item = %+v", running)
+	}
+
+	out.Reset()
+	if err := app.writeServerFeed(&out, servers, ListOptions{Format: "raycast"}); err != nil {
+		t.Fatal(err)
+	}
+	want := "● 2 up :5173 :8080\n" +
+		"○ worker  stopped · worker  → devpt start worker\n" +
+		"● vite  :5173 · running  http://localhost:5173  → devpt stop 5173\n" +
+		"● api  :8080 · ready · api  http://localhost:8080  → devpt stop api\n"
+	if got := out.String(); got != want {
+		t.Fatalf("raycast output = %q, want %q", got, want)
+	}
+
+	item := newAlfredItem(vite)
+	if item.Arg != "5173" || item.Variables["action"] != "stop" || item.Mods != nil {
+		t.Fatalf("unmanaged item = %+v", item)
+	}
+}
@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// ListFormats are the formats devpt ls --format accepts, besides
+// template=TEXT.
+var ListFormats = []string{"table", "alfred", "raycast"}
+
+// formatTemplate parses the template of a template=TEXT format; nil for
+// other formats.
+func (o ListOptions) formatTemplate() (*template.Template, error) {
+	text, ok := strings.CutPrefix(o.Format, "template=")
+	if !ok {
+		return nil, nil
+	}
+	tmpl, err := template.New("format").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid format template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// validateFormat checks --format.
+func (o ListOptions) validateFormat() error {
+	if strings.HasPrefix(o.Format, "template=") {
+		_, err := o.formatTemplate()
+		return err
+	}
+	if o.Format != "" && !contains(ListFormats, o.Format) {
+		return fmt.Errorf("invalid format %q (want %s or template=TEXT)", o.Format, strings.Join(ListFormats, ", "))
+	}
+	return nil
+}
+
+// alfredItem is a server as an Alfred script filter lists it.
+type alfredItem struct {
+	UID          string               `json:"uid"`
+	Title        string               `json:"title"`
+	Subtitle     string               `json:"subtitle"`
+	Arg          string               `json:"arg"`
+	QuickLookURL string               `json:"quicklookurl,omitempty"`
+	Text         map[string]string    `json:"text,omitempty"`
+	Variables    map[string]string    `json:"variables"`
+	Mods         map[string]alfredMod `json:"mods,omitempty"`
+}
+
+// alfredMod is what an item does with a modifier key held.
+type alfredMod struct {
+	Arg       string            `json:"arg"`
+	Subtitle  string            `json:"subtitle"`
+	Variables map[string]string `json:"variables"`
+}
+
+// launcherEntry is a server as the launcher formats offer it.
+type launcherEntry struct {
+	APIServer
+	target  string // the service name, or the port of an unmanaged server
+	action  string // the devpt command Enter runs on target
+	running bool
+	details []string // port, status, framework and project, for subtitles
+}
+
+// newLauncherEntry describes srv for a launcher. Its action is stop for a
+// running server and start for a stopped service.
+func newLauncherEntry(srv *models.ServerInfo) launcherEntry {
+	e := launcherEntry{APIServer: newAPIServer(srv)}
+	e.running = e.Status != "stopped" && !isCrashStatus(e.Status)
+	e.target = e.Name
+	if !e.Managed && e.Port > 0 {
+		e.target = strconv.Itoa(e.Port)
+	}
+	e.action = "stop"
+	if !e.running {
+		e.action = "start"
+	}
+
+	if e.Port > 0 {
+		e.details = append(e.details, ":"+strconv.Itoa(e.Port))
+	}
+	e.details = append(e.details, e.Status)
+	if e.Framework != "" {
+		e.details = append(e.details, e.Framework)
+	}
+	if e.Project != "" {
+		e.details = append(e.details, filepath.Base(e.Project))
+	} else if e.CWD != "" {
+		e.details = append(e.details, filepath.Base(e.CWD))
+	}
+	return e
+}
+
+// newAlfredItem describes srv for Alfred. Its action variable is the
+// devpt command Enter runs on target, e.g. `devpt "$action" "$target"`:
+// stop for a running server, start for a stopped service. Cmd restarts and
+// Alt shows the logs of managed services.
+func newAlfredItem(srv *models.ServerInfo) alfredItem {
+	e := newLauncherEntry(srv)
+	item := alfredItem{
+		UID:       string(e.Source) + ":" + e.target,
+		Title:     e.Name,
+		Subtitle:  strings.Join(e.details, " · "),
+		Arg:       e.target,
+		Variables: map[string]string{"action": e.action, "target": e.target},
+	}
+	if e.URL != "" {
+		item.QuickLookURL = e.URL
+		item.Text = map[string]string{"copy": e.URL}
+	}
+	if e.Managed {
+		mod := func(action, subtitle string) alfredMod {
+			return alfredMod{Arg: e.target, Subtitle: subtitle, Variables: map[string]string{"action": action, "target": e.target}}
+		}
+		item.Mods = map[string]alfredMod{"alt": mod("logs", "Show the logs of "+e.Name)}
+		if e.running {
+			item.Mods["cmd"] = mod("restart", "Restart "+e.Name)
+		}
+	}
+	return item
+}
+
+// writeRaycast writes servers as the output of a Raycast script command.
+// The first line is the devpt statusline summary, which inline mode shows
+// in the root search; full output mode shows a line per server after it,
+// ending with the command that starts or stops the server.
+func (a *App) writeRaycast(w io.Writer, servers []*models.ServerInfo) error {
+	text := a.useTextIcons()
+	lines := []string{statusLine(servers, false, text)}
+	for _, srv := range servers {
+		e := newLauncherEntry(srv)
+		line := e.Name + "  " + strings.Join(e.details, " · ")
+		if !text {
+			line = launcherMark(e) + " " + line
+		}
+		if e.URL != "" {
+			line += "  " + e.URL
+		}
+		lines = append(lines, line+"  → devpt "+e.action+" "+e.target)
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+// launcherMark is the symbol devpt statusline uses for the state of e.
+func launcherMark(e launcherEntry) string {
+	switch {
+	case isCrashStatus(e.Status):
+		return "▲"
+	case e.running:
+		return "●"
+	}
+	return "○"
+}
+
+// listedServers filters and orders servers for the formats programs read:
+// by --sort, otherwise by port.
+func (a *App) listedServers(servers []*models.ServerInfo, opts ListOptions) []*models.ServerInfo {
+	servers = a.filterServers(servers, opts)
+	if opts.Sort == "" {
+		sort.SliceStable(servers, func(i, j int) bool { return serverLess(servers[i], servers[j]) })
+		return servers
+	}
+	var stats map[*models.ServerInfo]serverStats
+	if opts.needsStats() {
+		stats = a.collectServerStats(servers)
+	}
+	names := make(map[*models.ServerInfo]string, len(servers))
+	for _, srv := range servers {
+		names[srv] = a.serverCells(srv)["name"]
+	}
+	sortServers(servers, opts, stats, names)
+	return servers
+}
+
+// writeServerFeed writes servers in a format other than the table.
+func (a *App) writeServerFeed(w io.Writer, servers []*models.ServerInfo, opts ListOptions) error {
+	servers = a.listedServers(servers, opts)
+	tmpl, err := opts.formatTemplate()
+	if err != nil {
+		return err
+	}
+	if tmpl != nil {
+		for _, srv := range servers {
+			if err := tmpl.Execute(w, newAPIServer(srv)); err != nil {
+				return fmt.Errorf("failed to apply format template: %w", err)
+			}
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if opts.Format == "raycast" {
+		return a.writeRaycast(w, servers)
+	}
+
+	items := make([]alfredItem, 0, len(servers))
+	for _, srv := range servers {
+		items = append(items, newAlfredItem(srv))
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]interface{}{"items": items})
+}
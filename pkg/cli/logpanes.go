@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/process"
+)
+
+// maxLogPanes is how many logs can be pinned below the tables at once;
+// pinning another replaces the oldest.
+const maxLogPanes = 2
+
+// logPane is the log of a managed service, or of an unmanaged process,
+// pinned below the tables and tailed on every refresh.
+type logPane struct {
+	svc    *models.ManagedService
+	pid    int
+	lines  []string
+	err    error
+	path   string // log file the last tail read
+	follow logFollow
+}
+
+// key identifies the pane's log.
+func (p logPane) key() string {
+	if p.svc != nil {
+		return p.svc.Name
+	}
+	return fmt.Sprintf("pid:%d", p.pid)
+}
+
+type logPanesMsg struct {
+	panes []logPane
+}
+
+// tailLogs returns the last n lines of a service's log, or of an unmanaged
+// process's when svc is nil, and the service's log file they were read
+// from.
+func (a *App) tailLogs(ctx context.Context, svc *models.ManagedService, pid, n int) ([]string, string, error) {
+	if svc != nil {
+		path, lines, err := a.processManager.TailLatestContext(ctx, svc.Name, n)
+		return lines, path, err
+	}
+	if pid > 0 {
+		lines, err := a.processManager.TailProcessContext(ctx, pid, n)
+		return lines, "", err
+	}
+	return nil, "", fmt.Errorf("no service selected")
+}
+
+// logFollow remembers the log file a followed log was read from. When a
+// restart starts a newer one, the lines shown of the previous run are kept
+// above process.RestartMarker, as Manager.Follow does.
+type logFollow struct {
+	path string
+	prev []string
+}
+
+// update returns the lines to show, at most limit, after the tail of the
+// log file at path read lines; shown is what was shown before.
+func (f *logFollow) update(shown []string, path string, lines []string, limit int) []string {
+	if path != "" && f.path != "" && path != f.path {
+		f.prev = append(append([]string(nil), shown...), process.RestartMarker)
+	}
+	if path != "" {
+		f.path = path
+	}
+	out := append(append([]string(nil), f.prev...), lines...)
+	if len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	f.prev = f.prev[len(f.prev)-max(len(out)-len(lines), 0):]
+	return out
+}
+
+// logErrorText explains why a log cannot be shown.
+func logErrorText(err error) string {
+	if errors.Is(err, process.ErrNoLogs) {
+		return "No devpt logs for this service yet.\nLogs are only captured when started by devpt.\n"
+	}
+	if errors.Is(err, process.ErrNoProcessLogs) {
+		return "No accessible logs for this process.\nIf it writes only to a terminal, there may be nothing to tail here.\n"
+	}
+	return fmt.Sprintf("Error: %v\n", err)
+}
+
+// togglePin pins the selected row's log below the tables, or unpins it.
+func (m *topModel) togglePin() (string, tea.Cmd) {
+	srv, msg := m.selectedServer()
+	if srv == nil {
+		return msg, nil
+	}
+	pane := logPane{svc: srv.ManagedService}
+	if pane.svc == nil {
+		if srv.ProcessRecord == nil {
+			return "No process to tail", nil
+		}
+		pane.pid = srv.ProcessRecord.PID
+	}
+	for i, p := range m.logPanes {
+		if p.key() == pane.key() {
+			m.logPanes = append(m.logPanes[:i:i], m.logPanes[i+1:]...)
+			return fmt.Sprintf("Unpinned logs of %s", pane.key()), nil
+		}
+	}
+	if len(m.logPanes) >= maxLogPanes {
+		m.logPanes = m.logPanes[1:]
+	}
+	m.logPanes = append(m.logPanes, pane)
+	return fmt.Sprintf("Pinned logs of %s (l again unpins)", pane.key()), m.logPanesCmd()
+}
+
+// logPanesCmd tails the pinned logs.
+func (m topModel) logPanesCmd() tea.Cmd {
+	panes := append([]logPane(nil), m.logPanes...)
+	ctx := m.context()
+	return func() tea.Msg {
+		for i := range panes {
+			panes[i].lines, panes[i].path, panes[i].err = m.app.tailLogs(ctx, panes[i].svc, panes[i].pid, logPaneTail)
+		}
+		return logPanesMsg{panes: panes}
+	}
+}
+
+// logPaneTail is how many lines are read for each pinned log.
+const logPaneTail = 200
+
+// updateLogPanes stores tailed logs in the panes still pinned.
+func (m *topModel) updateLogPanes(tailed []logPane) {
+	for _, t := range tailed {
+		for i := range m.logPanes {
+			if p := &m.logPanes[i]; p.key() == t.key() {
+				p.err = t.err
+				if t.err == nil {
+					p.lines = p.follow.update(p.lines, t.path, t.lines, logPaneTail)
+				}
+			}
+		}
+	}
+}
+
+// logPaneRows is the height of the pinned logs: a third of the terminal.
+func (m topModel) logPaneRows() int {
+	if m.height <= 0 {
+		return 10
+	}
+	return max(5, m.height/3)
+}
+
+// renderLogPanes renders the pinned logs side by side, or "" when none are
+// pinned.
+func (m topModel) renderLogPanes(width int) string {
+	if len(m.logPanes) == 0 {
+		return ""
+	}
+	gap := 2
+	paneW := (width - gap*(len(m.logPanes)-1)) / len(m.logPanes)
+	rows := m.logPaneRows()
+	titleStyle := m.styles().title
+	cols := make([]string, 0, 2*len(m.logPanes))
+	for i, p := range m.logPanes {
+		var lines []string
+		if p.err != nil {
+			for _, l := range strings.Split(strings.TrimSpace(logErrorText(p.err)), "\n") {
+				lines = append(lines, wrapRunes(l, paneW)...)
+			}
+		} else {
+			for _, l := range p.lines {
+				lines = append(lines, wrapRunes(l, paneW)...)
+			}
+			if len(lines) == 0 {
+				lines = []string{"(no logs yet)"}
+			}
+		}
+		if len(lines) > rows {
+			lines = lines[len(lines)-rows:]
+		}
+		for j, l := range lines {
+			lines[j] = fixedCell(l, paneW)
+		}
+		title := titleStyle.Render(fixedCell(fmt.Sprintf("Logs: %s", p.key()), paneW))
+		if i > 0 {
+			cols = append(cols, strings.Repeat(" ", gap))
+		}
+		cols = append(cols, title+"\n"+strings.Join(lines, "\n"))
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, cols...)
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/process"
+)
+
+func TestTogglePin(t *testing.T) {
+	t.Parallel()
+	var servers []*models.ServerInfo
+	for i, name := range []string{"api", "web", "worker"} {
+		servers = append(servers, &models.ServerInfo{
+			ManagedService: &models.ManagedService{Name: name},
+			ProcessRecord:  &models.ProcessRecord{PID: 100 + i, Port: 3000 + i},
+			Status:         "running",
+		})
+	}
+	m := topModel{app: &App{showAll: true}, servers: servers}
+	pin := func(name string) {
+		t.Helper()
+		for i, srv := range m.visibleServers() {
+			if srv.ManagedService.Name == name {
+				m.selected = i
+			}
+		}
+		m.togglePin()
+	}
+	keys := func() string {
+		var out []string
+		for _, p := range m.logPanes {
+			out = append(out, p.key())
+		}
+		return strings.Join(out, ",")
+	}
+
+	pin("api")
+	pin("web")
+	if got := keys(); got != "api,web" {
+		t.Fatalf("panes = %s, want api,web", got)
+	}
+	pin("worker")
+	if got := keys(); got != "web,worker" {
+		t.Fatalf("panes = %s, want the oldest replaced", got)
+	}
+	pin("web")
+	if got := keys(); got != "worker" {
+		t.Fatalf("panes = %s, want web unpinned", got)
+	}
+}
+
+func TestRenderLogPanes(t *testing.T) {
+	t.Parallel()
+	m := topModel{height: 30, logPanes: []logPane{
+		{svc: &models.ManagedService{Name: "api"}, lines: []string{"GET /users 200", "GET /orders 500"}},
+		{pid: 4242, err: process.ErrNoProcessLogs},
+	}}
+	out := m.renderLogPanes(80)
+	for _, want := range []string{"Logs: api", "Logs: pid:4242", "GET /orders 500", "No accessible logs"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("panes lack %q:\n%s", want, out)
+		}
+	}
+	lines := strings.Split(out, "\n")
+	if !strings.Contains(lines[0], "Logs: api") || !strings.Contains(lines[0], "Logs: pid:4242") {
+		t.Errorf("panes not side by side: %q", lines[0])
+	}
+	for _, l := range lines {
+		if w := lipgloss.Width(l); w > 80 {
+			t.Errorf("line wider than 80 (%d): %q", w, l)
+		}
+	}
+
+	m.logPanes[0].lines = make([]string, 50)
+	if got := len(strings.Split(m.renderLogPanes(80), "\n")); got != m.logPaneRows()+1 {
+		t.Errorf("pane height = %d, want %d", got, m.logPaneRows()+1)
+	}
+	if !errors.Is(m.logPanes[1].err, process.ErrNoProcessLogs) {
+		t.Fatal("pane error changed")
+	}
+}
+
+func TestLogFollowMarksRestart(t *testing.T) {
+	t.Parallel()
+	var f logFollow
+	shown := f.update(nil, "/logs/api/1.log", []string{"booting", "crashed"}, 5)
+	shown = f.update(shown, "/logs/api/1.log", []string{"booting", "crashed"}, 5)
+	if strings.Join(shown, "|") != "booting|crashed" {
+		t.Fatalf("same file: %q", shown)
+	}
+
+	shown = f.update(shown, "/logs/api/2.log", []string{"booting again"}, 5)
+	if want := "booting|crashed|" + process.RestartMarker + "|booting again"; strings.Join(shown, "|") != want {
+		t.Fatalf("after restart: %q, want %q", shown, want)
+	}
+	shown = f.update(shown, "/logs/api/2.log", []string{"booting again", "ready", "GET /"}, 5)
+	if want := "crashed|" + process.RestartMarker + "|booting again|ready|GET /"; strings.Join(shown, "|") != want {
+		t.Fatalf("new run growing: %q, want %q", shown, want)
+	}
+	if len(f.prev) != 2 {
+		t.Errorf("kept %d lines of the previous run, want the 2 shown", len(f.prev))
+	}
+
+	m := topModel{logPanes: []logPane{{svc: &models.ManagedService{Name: "api"}, lines: []string{"old run"}, follow: logFollow{path: "/logs/api/1.log"}}}}
+	m.updateLogPanes([]logPane{{svc: &models.ManagedService{Name: "api"}, path: "/logs/api/2.log", lines: []string{"new run"}}})
+	if got := strings.Join(m.logPanes[0].lines, "|"); got != "old run|"+process.RestartMarker+"|new run" {
+		t.Errorf("pinned log after restart: %q", got)
+	}
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// serviceLogUsage is the disk space taken by one service's logs.
+type serviceLogUsage struct {
+	Name       string
+	Size       int64
+	Files      int
+	Registered bool
+}
+
+// logUsage is the disk space taken by service and job logs.
+type logUsage struct {
+	Services []serviceLogUsage // largest first
+	Jobs     int64
+	Total    int64
+	Max      int64 // logs.max_size
+}
+
+// Over reports whether the logs together exceed logs.max_size.
+func (u logUsage) Over() bool {
+	return u.Max > 0 && u.Total > u.Max
+}
+
+// Warning describes an exceeded logs.max_size, or returns "".
+func (u logUsage) Warning() string {
+	if !u.Over() {
+		return ""
+	}
+	return fmt.Sprintf("logs use %s, more than logs.max_size (%s)", formatBytes(u.Total), formatBytes(u.Max))
+}
+
+// logUsage measures the logs directories. Directories of services that are
+// no longer registered are included; `devpt gc` removes them.
+func (a *App) logUsage() logUsage {
+	u := logUsage{Max: int64(a.logSettings().MaxSize)}
+	entries, _ := os.ReadDir(a.config.LogsDir)
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		size, files := dirUsage(filepath.Join(a.config.LogsDir, e.Name()))
+		u.Services = append(u.Services, serviceLogUsage{
+			Name:       e.Name(),
+			Size:       size,
+			Files:      files,
+			Registered: a.registry.GetService(e.Name()) != nil,
+		})
+		u.Total += size
+	}
+	sort.SliceStable(u.Services, func(i, j int) bool {
+		if u.Services[i].Size != u.Services[j].Size {
+			return u.Services[i].Size > u.Services[j].Size
+		}
+		return u.Services[i].Name < u.Services[j].Name
+	})
+	u.Jobs, _ = dirUsage(a.config.JobLogsDir)
+	u.Total += u.Jobs
+	return u
+}
+
+// printLogUsage prints the per-service log usage table of `devpt ls --details`
+// to w.
+func printLogUsage(w io.Writer, u logUsage) error {
+	if len(u.Services) == 0 && u.Jobs == 0 {
+		return nil
+	}
+	fmt.Fprintln(w, "\nLogs")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, s := range u.Services {
+		name := s.Name
+		if !s.Registered {
+			name += " (unregistered)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d files\n", name, formatBytes(s.Size), s.Files)
+	}
+	if u.Jobs > 0 {
+		fmt.Fprintf(tw, "jobs\t%s\t\n", formatBytes(u.Jobs))
+	}
+	fmt.Fprintf(tw, "total\t%s\t\n", formatBytes(u.Total))
+	return tw.Flush()
+}
+
+// gcPreview describes what `devpt gc` would clean up, e.g. "reclaim 12.3 MB
+// and clear 1 stale PID", or returns "" when there is nothing to do.
+func (a *App) gcPreview() (string, error) {
+	cleared, reclaimed, err := a.collectGarbage(true, func(string) {})
+	if err != nil || (cleared == 0 && reclaimed == 0) {
+		return "", err
+	}
+	var parts []string
+	if reclaimed > 0 {
+		parts = append(parts, "reclaim "+formatBytes(reclaimed))
+	}
+	if cleared == 1 {
+		parts = append(parts, "clear 1 stale PID")
+	} else if cleared > 1 {
+		parts = append(parts, fmt.Sprintf("clear %d stale PIDs", cleared))
+	}
+	return strings.Join(parts, " and "), nil
+}
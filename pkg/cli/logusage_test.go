@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/registry"
+)
+
+func TestLogUsage(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	paths := models.ConfigPaths{LogsDir: filepath.Join(dir, "logs"), JobLogsDir: filepath.Join(dir, "job-logs")}
+	write := func(path string, size int) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(paths.LogsDir, "api", "1.log"), 300)
+	write(filepath.Join(paths.LogsDir, "api", "2.log"), 200)
+	write(filepath.Join(paths.LogsDir, "old", "1.log"), 1000)
+	write(filepath.Join(paths.JobLogsDir, "migrate", "1.log"), 50)
+
+	reg := registry.NewRegistry(filepath.Join(dir, "registry.json"))
+	if err := reg.AddService(&models.ManagedService{Name: "api", CWD: dir, Command: "x"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	app := &App{config: paths, registry: reg, settings: &models.Config{Logs: models.LogSettings{MaxSize: 1024}}}
+
+	u := app.logUsage()
+	if u.Total != 1550 || u.Jobs != 50 || u.Max != 1024 {
+		t.Fatalf("unexpected totals: %+v", u)
+	}
+	if len(u.Services) != 2 || u.Services[0].Name != "old" || u.Services[0].Registered {
+		t.Fatalf("expected the unregistered service first, got %+v", u.Services)
+	}
+	if api := u.Services[1]; api.Size != 500 || api.Files != 2 || !api.Registered {
+		t.Fatalf("unexpected usage for api: %+v", api)
+	}
+	if w := u.Warning(); !strings.Contains(w, "1.5 KB") || !strings.Contains(w, "1.0 KB") {
+		t.Fatalf("expected a warning over logs.max_size, got %q", w)
+	}
+
+	app.settings = nil
+	if w := app.logUsage().Warning(); w != "" {
+		t.Fatalf("expected no warning under the default limit, got %q", w)
+	}
+}
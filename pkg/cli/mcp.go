@@ -0,0 +1,293 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// mcpProtocolVersions are the Model Context Protocol revisions devpt mcp
+// speaks, newest first.
+var mcpProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// mcpTool describes a tool in tools/list.
+type mcpTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// mcpToolResult is the result of tools/call. Failures of the tool itself
+// are results with IsError set, so the agent can read them.
+type mcpToolResult struct {
+	Content []mcpContent `json:"content"`
+	IsError bool         `json:"isError,omitempty"`
+}
+
+type mcpContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// mcpArgs are the arguments tools take; each uses a few.
+type mcpArgs struct {
+	Name  string `json:"name"`
+	Port  int    `json:"port"`
+	Lines int    `json:"lines"`
+	All   bool   `json:"all"`
+	Force bool   `json:"force"`
+}
+
+// mcpSchema builds a JSON schema for an object with the given properties.
+func mcpSchema(required []string, props map[string]interface{}) map[string]interface{} {
+	schema := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+var (
+	mcpNameProp   = map[string]interface{}{"type": "string", "description": "Managed service name"}
+	mcpTargetProp = map[string]interface{}{"type": "string", "description": "Managed service name, or a port number"}
+	mcpPortProp   = map[string]interface{}{"type": "integer", "description": "TCP port", "minimum": 1, "maximum": 65535}
+)
+
+// mcpTools are the tools devpt mcp offers.
+var mcpTools = []mcpTool{
+	{
+		Name:        "list_servers",
+		Description: "List running dev servers and registered services, with status, port, PID and owner (including servers started by AI agents). Check this before starting a server yourself.",
+		InputSchema: mcpSchema(nil, map[string]interface{}{
+			"all": map[string]interface{}{"type": "boolean", "description": "Include every listener, not only dev servers"},
+		}),
+	},
+	{
+		Name:        "start_service",
+		Description: "Start a service registered with devpt. Its output is logged and can be read with tail_logs.",
+		InputSchema: mcpSchema([]string{"name"}, map[string]interface{}{
+			"name":  mcpNameProp,
+			"force": map[string]interface{}{"type": "boolean", "description": "Stop processes already listening on the service's ports"},
+		}),
+	},
+	{
+		Name:        "stop_service",
+		Description: "Stop a service registered with devpt.",
+		InputSchema: mcpSchema([]string{"name"}, map[string]interface{}{"name": mcpNameProp}),
+	},
+	{
+		Name:        "restart_service",
+		Description: "Restart a service registered with devpt.",
+		InputSchema: mcpSchema([]string{"name"}, map[string]interface{}{"name": mcpNameProp}),
+	},
+	{
+		Name:        "stop_port",
+		Description: "Stop whatever process listens on a port.",
+		InputSchema: mcpSchema([]string{"port"}, map[string]interface{}{"port": mcpPortProp}),
+	},
+	{
+		Name:        "tail_logs",
+		Description: "Return the latest log lines of a registered service.",
+		InputSchema: mcpSchema([]string{"name"}, map[string]interface{}{
+			"name":  mcpNameProp,
+			"lines": map[string]interface{}{"type": "integer", "description": "Number of lines (default 50)", "minimum": 1},
+		}),
+	},
+	{
+		Name:        "health_check",
+		Description: "Probe a server's health now, as devpt status does.",
+		InputSchema: mcpSchema([]string{"name"}, map[string]interface{}{"name": mcpTargetProp}),
+	},
+}
+
+// mcpServer answers MCP requests from an App.
+type mcpServer struct {
+	app     *App
+	version string // devpt's version, reported in serverInfo
+}
+
+// MCPServeCmd serves the Model Context Protocol over stdin and stdout until
+// stdin is closed, so that AI agents can list, start and stop dev servers
+// through devpt. Anything commands print goes to stderr, keeping stdout for
+// the protocol. version is devpt's, reported to the client.
+func (a *App) MCPServeCmd(version string) error {
+	a.SetOutput(os.Stderr, os.Stderr)
+	a.SetVia(models.ViaMCP)
+	a.SetNonInteractive(false)
+	return (&mcpServer{app: a, version: version}).serve(os.Stdin, os.Stdout)
+}
+
+// serve reads newline-delimited JSON-RPC messages from in and writes the
+// responses to out.
+func (s *mcpServer) serve(in io.Reader, out io.Writer) error {
+	enc := json.NewEncoder(out)
+	sc := bufio.NewScanner(in)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		resp := s.handle(line)
+		if resp == nil {
+			continue
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// handle answers one message; nil for notifications.
+func (s *mcpServer) handle(line []byte) *rpcResponse {
+	var req rpcRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		return &rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: rpcParseError, Message: err.Error()}}
+	}
+	if len(req.ID) == 0 {
+		// Notifications such as notifications/initialized need no answer.
+		return nil
+	}
+	resp := &rpcResponse{JSONRPC: "2.0", ID: req.ID}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		resp.Error = &rpcError{Code: rpcInvalidRequest, Message: "invalid request"}
+		return resp
+	}
+
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(req.Params, &params)
+		version := mcpProtocolVersions[0]
+		for _, v := range mcpProtocolVersions {
+			if v == params.ProtocolVersion {
+				version = v
+			}
+		}
+		resp.Result = map[string]interface{}{
+			"protocolVersion": version,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]string{"name": "devpt", "version": s.version},
+			"instructions":    "devpt tracks the dev servers on this machine. Use list_servers before starting a server, and start_service for servers registered with devpt instead of spawning your own.",
+		}
+	case "ping":
+		resp.Result = map[string]interface{}{}
+	case "tools/list":
+		resp.Result = map[string]interface{}{"tools": mcpTools}
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			resp.Error = &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+			return resp
+		}
+		var args mcpArgs
+		if len(params.Arguments) > 0 {
+			if err := json.Unmarshal(params.Arguments, &args); err != nil {
+				resp.Error = &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+				return resp
+			}
+		}
+		result, err := s.call(params.Name, args)
+		if errors.Is(err, errUnknownTool) {
+			resp.Error = &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("unknown tool: %s", params.Name)}
+			return resp
+		}
+		resp.Result = result
+	default:
+		resp.Error = &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("method not found: %s", req.Method)}
+	}
+	return resp
+}
+
+var errUnknownTool = errors.New("unknown tool")
+
+// call runs a tool. Errors other than errUnknownTool are reported in the
+// result.
+func (s *mcpServer) call(tool string, args mcpArgs) (*mcpToolResult, error) {
+	a := s.app
+	var (
+		v   interface{}
+		err error
+	)
+	switch tool {
+	case "list_servers":
+		v, err = a.apiServers(context.Background(), args.All)
+	case "start_service":
+		v, err = s.run(args.Name, func() error {
+			return a.StartServiceCmd(args.Name, StartOptions{Force: args.Force})
+		})
+	case "stop_service":
+		v, err = s.run(args.Name, func() error { return a.StopServiceCmd(args.Name, StopOptions{}) })
+	case "restart_service":
+		v, err = s.run(args.Name, func() error { return a.RestartCmd(args.Name) })
+	case "stop_port":
+		if args.Port <= 0 || args.Port > 65535 {
+			err = fmt.Errorf("invalid port: %d", args.Port)
+			break
+		}
+		v, err = s.run("", func() error { return a.StopServiceCmd(strconv.Itoa(args.Port), StopOptions{}) })
+	case "tail_logs":
+		v, err = s.tailLogs(args.Name, args.Lines)
+	case "health_check":
+		v, err = a.apiHealth(context.Background(), args.Name)
+	default:
+		return nil, errUnknownTool
+	}
+	if err != nil {
+		return &mcpToolResult{Content: []mcpContent{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+	}
+	text, ok := v.(string)
+	if !ok {
+		data, jsonErr := json.MarshalIndent(v, "", "  ")
+		if jsonErr != nil {
+			return nil, jsonErr
+		}
+		text = string(data)
+	}
+	return &mcpToolResult{Content: []mcpContent{{Type: "text", Text: text}}}, nil
+}
+
+// run runs a command for a tool and returns what it printed. name, when
+// set, must be a registered service.
+func (s *mcpServer) run(name string, fn func() error) (string, error) {
+	if name != "" && s.app.registry.GetService(name) == nil {
+		return "", errServiceNotFound(name)
+	}
+	stdout, stderr, err := s.app.captureOutput(fn)
+	output := stdout + stderr
+	if err != nil {
+		if output != "" {
+			return "", fmt.Errorf("%s%w", output, err)
+		}
+		return "", err
+	}
+	return output, nil
+}
+
+func (s *mcpServer) tailLogs(name string, lines int) (string, error) {
+	if s.app.registry.GetService(name) == nil {
+		return "", errServiceNotFound(name)
+	}
+	if lines <= 0 {
+		lines = 50
+	}
+	out, err := s.app.processManager.Tail(name, lines)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(out, "\n"), nil
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// mcpExchange sends requests, one per line, and decodes the responses.
+func mcpExchange(t *testing.T, app *App, requests ...string) []map[string]interface{} {
+	t.Helper()
+	var out bytes.Buffer
+	s := &mcpServer{app: app, version: "test"}
+	if err := s.serve(strings.NewReader(strings.Join(requests, "\n")+"\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	var responses []map[string]interface{}
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var resp map[string]interface{}
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func TestMCPInitializeAndListTools(t *testing.T) {
+	t.Parallel()
+
+	responses := mcpExchange(t, newAPITestApp(t),
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"resources/list"}`,
+	)
+	if len(responses) != 3 {
+		t.Fatalf("expected 3 responses (none for the notification), got %d: %v", len(responses), responses)
+	}
+	init := responses[0]["result"].(map[string]interface{})
+	if init["protocolVersion"] != "2024-11-05" {
+		t.Fatalf("expected the client's protocol version to be kept, got %v", init["protocolVersion"])
+	}
+	tools := responses[1]["result"].(map[string]interface{})["tools"].([]interface{})
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.(map[string]interface{})["name"].(string))
+	}
+	if got := strings.Join(names, ","); got != "list_servers,start_service,stop_service,restart_service,stop_port,tail_logs,health_check" {
+		t.Fatalf("unexpected tools %s", got)
+	}
+	if code := responses[2]["error"].(map[string]interface{})["code"].(float64); code != rpcMethodNotFound {
+		t.Fatalf("expected method not found, got %v", code)
+	}
+}
+
+func TestMCPToolCalls(t *testing.T) {
+	t.Parallel()
+
+	responses := mcpExchange(t, newAPITestApp(t, "one", "two", "three"),
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"tail_logs","arguments":{"name":"web","lines":2}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"start_service","arguments":{"name":"missing"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"stop_port","arguments":{"port":0}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"format_disk"}}`,
+	)
+	text := func(resp map[string]interface{}) (string, bool) {
+		result := resp["result"].(map[string]interface{})
+		content := result["content"].([]interface{})[0].(map[string]interface{})
+		isErr, _ := result["isError"].(bool)
+		return content["text"].(string), isErr
+	}
+	if got, isErr := text(responses[0]); isErr || got != "two\nthree" {
+		t.Fatalf("tail_logs = %q (error %v)", got, isErr)
+	}
+	if got, isErr := text(responses[1]); !isErr || got != `service "missing" not found` {
+		t.Fatalf("start_service = %q (error %v)", got, isErr)
+	}
+	if got, isErr := text(responses[2]); !isErr || got != "invalid port: 0" {
+		t.Fatalf("stop_port = %q (error %v)", got, isErr)
+	}
+	if _, ok := responses[3]["error"]; !ok {
+		t.Fatalf("expected a protocol error for an unknown tool, got %v", responses[3])
+	}
+}
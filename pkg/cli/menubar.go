@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// MenubarCmd prints the dev servers in the xbar plugin format, which
+// SwiftBar reads too: the status line as the menu bar title, then a menu
+// with a submenu per server whose items run devpt to start, stop, restart
+// or tail it.
+func (a *App) MenubarCmd() error {
+	servers, err := a.discoverServers()
+	if err != nil {
+		return err
+	}
+	// xbar runs plugins with a minimal PATH, so actions name this binary.
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "devpt"
+	}
+	return writeMenubar(a.out(), servers, exe, a.useTextIcons())
+}
+
+// writeMenubar writes the menu for servers, with actions that run exe.
+func writeMenubar(w io.Writer, servers []*models.ServerInfo, exe string, text bool) error {
+	var b strings.Builder
+	_, crashed, _ := statusCounts(servers)
+	b.WriteString(statusLine(servers, true, text))
+	if crashed > 0 {
+		b.WriteString(" | color=red")
+	}
+	b.WriteString("\n---\n")
+
+	sorted := append([]*models.ServerInfo(nil), servers...)
+	sort.SliceStable(sorted, func(i, j int) bool { return serverLess(sorted[i], sorted[j]) })
+	var infra []*models.ServerInfo
+	for _, srv := range sorted {
+		if isInfraServer(srv) {
+			infra = append(infra, srv)
+			continue
+		}
+		writeMenubarServer(&b, srv, exe)
+	}
+	if len(sorted) == len(infra) {
+		b.WriteString("No dev servers | color=gray\n")
+	}
+	if len(infra) > 0 {
+		b.WriteString("---\nInfrastructure\n")
+		for _, srv := range infra {
+			writeMenubarServer(&b, srv, exe)
+		}
+	}
+	b.WriteString("---\n")
+	b.WriteString("Open devpt | " + menubarAction(exe, true) + "\n")
+	b.WriteString("Refresh | refresh=true\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeMenubarServer writes srv's line and its submenu.
+func writeMenubarServer(b *strings.Builder, srv *models.ServerInfo, exe string) {
+	s := newAPIServer(srv)
+	running := s.Status != "stopped" && !isCrashStatus(s.Status)
+	label := menubarText(s.Name)
+	if s.Port > 0 {
+		label += " :" + strconv.Itoa(s.Port)
+	}
+	label += " · " + s.Status
+	switch {
+	case isCrashStatus(s.Status):
+		label += " | color=red"
+	case !running:
+		label += " | color=gray"
+	}
+	b.WriteString(label + "\n")
+
+	item := func(title string, terminal bool, args ...string) {
+		b.WriteString("--" + title + " | " + menubarAction(exe, terminal, args...) + "\n")
+	}
+	switch {
+	case s.Managed && running:
+		item("Stop", false, "stop", s.Name)
+		item("Restart", false, "restart", s.Name)
+	case s.Managed:
+		item("Start", false, "start", s.Name)
+	case s.Port > 0:
+		item("Stop", false, "stop", strconv.Itoa(s.Port))
+	}
+	if s.Managed {
+		item("Logs", true, "logs", s.Name, "--lines", "200")
+	}
+	if s.URL != "" && running && !isInfraServer(srv) {
+		b.WriteString("--Open " + menubarText(s.URL) + " | href=" + s.URL + "\n")
+	}
+}
+
+// menubarAction returns the parameters of an item that runs exe with args,
+// in a terminal or in the background followed by a refresh.
+func menubarAction(exe string, terminal bool, args ...string) string {
+	parts := []string{"bash=" + menubarQuote(exe)}
+	for i, arg := range args {
+		parts = append(parts, "param"+strconv.Itoa(i+1)+"="+menubarQuote(arg))
+	}
+	parts = append(parts, "terminal="+strconv.FormatBool(terminal))
+	if !terminal {
+		parts = append(parts, "refresh=true")
+	}
+	return strings.Join(parts, " ")
+}
+
+// menubarQuote quotes a parameter value that has spaces or quotes.
+func menubarQuote(s string) string {
+	if !strings.ContainsAny(s, " \t\"'") {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+// menubarText keeps text from ending a line or starting its parameters.
+func menubarText(s string) string {
+	return strings.NewReplacer("|", "¦", "\n", " ").Replace(s)
+}
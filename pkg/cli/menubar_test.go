@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestWriteMenubar(t *testing.T) {
+	t.Parallel()
+
+	pid := 30
+	servers := []*models.ServerInfo{
+		{ProcessRecord: &models.ProcessRecord{PID: 9, Port: 5432, Command: "postgres", Infra: "postgres"}, Status: "running"},
+		{ManagedService: &models.ManagedService{Name: "api", LastPID: &pid}, ProcessRecord: &models.ProcessRecord{PID: 30, Port: 8080}, Status: "ready", Source: models.SourceManaged},
+		{ManagedService: &models.ManagedService{Name: "worker"}, Status: "crashed", Source: models.SourceManaged},
+	}
+	var out strings.Builder
+	if err := writeMenubar(&out, servers, "/Applications/My Tools/devpt", false); err != nil {
+		t.Fatal(err)
+	}
+	want := `●1 ▲1 | color=red
+---
+worker · crashed | color=red
+--Start | bash="/Applications/My Tools/devpt" param1=start param2=worker terminal=false refresh=true
+--Logs | bash="/Applications/My Tools/devpt" param1=logs param2=worker param3=--lines param4=200 terminal=true
+api :8080 · ready
+--Stop | bash="/Applications/My Tools/devpt" param1=stop param2=api terminal=false refresh=true
+--Restart | bash="/Applications/My Tools/devpt" param1=restart param2=api terminal=false refresh=true
+--Logs | bash="/Applications/My Tools/devpt" param1=logs param2=api param3=--lines param4=200 terminal=true
+--Open http://localhost:8080 | href=http://localhost:8080
+---
+Infrastructure
+postgres :5432 · running
+--Stop | bash="/Applications/My Tools/devpt" param1=stop param2=5432 terminal=false refresh=true
+---
+Open devpt | bash="/Applications/My Tools/devpt" terminal=true
+Refresh | refresh=true
+`
+	if got := out.String(); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
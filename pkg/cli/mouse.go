@@ -0,0 +1,100 @@
+package cli
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// mouseWheelLines is how many lines a wheel notch scrolls the logs.
+const mouseWheelLines = 3
+
+// handleMouse selects the clicked row, sorts by the clicked column header
+// and scrolls with the wheel: the logs, or the selection of the list under
+// the pointer.
+func (m *topModel) handleMouse(msg tea.MouseMsg) {
+	if msg.Action != tea.MouseActionPress {
+		return
+	}
+	switch m.mode {
+	case viewModeLogs:
+		switch msg.Button {
+		case tea.MouseButtonWheelUp:
+			m.logView.ScrollUp(mouseWheelLines)
+		case tea.MouseButtonWheelDown:
+			m.logView.ScrollDown(mouseWheelLines)
+		default:
+			return
+		}
+		m.followLogs = m.logView.AtBottom()
+	case viewModeTable:
+		focus, row, inList := m.scroll.rowAt(msg.X, msg.Y)
+		switch msg.Button {
+		case tea.MouseButtonWheelUp, tea.MouseButtonWheelDown:
+			if inList {
+				m.focus = focus
+			}
+			if msg.Button == tea.MouseButtonWheelUp {
+				m.moveSelection(-1)
+			} else {
+				m.moveSelection(1)
+			}
+		case tea.MouseButtonLeft:
+			if sort, ok := m.scroll.columnAt(msg.X, msg.Y); ok {
+				m.sortBy = sort
+				return
+			}
+			if row < 0 {
+				return
+			}
+			m.focus = focus
+			if focus == focusRunning {
+				m.selected = row
+			} else {
+				m.managedSel = row
+			}
+		}
+	}
+}
+
+// moveSelection moves the selection of the focused list by delta rows,
+// staying within the list.
+func (m *topModel) moveSelection(delta int) {
+	if m.focus == focusRunning {
+		m.selected = min(max(m.selected+delta, 0), max(len(m.visibleServers())-1, 0))
+	} else {
+		m.managedSel = min(max(m.managedSel+delta, 0), max(len(m.managedServices())-1, 0))
+	}
+}
+
+// rowAt returns the list and row drawn at screen position x, y; inList is
+// false outside the lists, and row is -1 there and on a list's header and
+// "x–y of N" lines.
+func (s *scrollState) rowAt(x, y int) (focus viewFocus, row int, inList bool) {
+	if s == nil || x < 0 || x >= s.listWidth {
+		return focusRunning, -1, false
+	}
+	for _, list := range []struct {
+		focus viewFocus
+		top   int
+		rows  []int
+	}{
+		{focusRunning, s.runningTop, s.runningRows},
+		{focusManaged, s.managedTop, s.managedRows},
+	} {
+		if i := y - list.top; i >= 0 && i < len(list.rows) {
+			return list.focus, list.rows[i], true
+		}
+	}
+	return focusRunning, -1, false
+}
+
+// columnAt returns the sort mode of the running table's column header at
+// screen position x, y.
+func (s *scrollState) columnAt(x, y int) (sortMode, bool) {
+	if s == nil || y != s.runningTop || x >= s.listWidth {
+		return 0, false
+	}
+	for _, c := range s.columns {
+		if x >= c.from && x < c.to {
+			return c.sort, true
+		}
+	}
+	return 0, false
+}
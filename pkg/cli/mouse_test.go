@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestMouseClickSelectsRow(t *testing.T) {
+	t.Parallel()
+	var servers []*models.ServerInfo
+	for i := 0; i < 5; i++ {
+		servers = append(servers, &models.ServerInfo{
+			ProcessRecord: &models.ProcessRecord{PID: 1000 + i, Port: 3000 + i, Command: "node server.js"},
+			Status:        "running",
+		})
+	}
+	m := newTestTopModel(t, servers)
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = next.(topModel)
+
+	want := m.visibleServers()[3].ProcessRecord.PID
+	y := -1
+	for i, line := range strings.Split(m.View(), "\n") {
+		if strings.Contains(line, fmt.Sprintf("%d", want)) {
+			y = i
+			break
+		}
+	}
+	if y < 0 {
+		t.Fatalf("PID %d not shown", want)
+	}
+	next, _ = m.Update(tea.MouseMsg{X: 2, Y: y, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft})
+	m = next.(topModel)
+	if got := m.visibleServers()[m.selected].ProcessRecord.PID; got != want {
+		t.Errorf("clicked PID %d, selected %d", want, got)
+	}
+
+	next, _ = m.Update(tea.MouseMsg{X: 2, Y: y, Action: tea.MouseActionPress, Button: tea.MouseButtonWheelDown})
+	m = next.(topModel)
+	if m.selected != 4 {
+		t.Errorf("wheel down selected row %d, want 4", m.selected)
+	}
+
+	// The Port header starts after the 14-wide Name column and its gap.
+	next, _ = m.Update(tea.MouseMsg{X: 17, Y: m.scroll.runningTop, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft})
+	m = next.(topModel)
+	if m.sortBy != sortPort {
+		t.Errorf("clicking the Port header sorted by %s", sortModeLabel(m.sortBy))
+	}
+}
+
+func TestMouseWheelScrollsLogs(t *testing.T) {
+	t.Parallel()
+	m := newTestTopModel(t, nil)
+	m.mode = viewModeLogs
+	m.followLogs = true
+	m.logSvc = &models.ManagedService{Name: "api"}
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 30})
+	m = next.(topModel)
+	var lines []string
+	for i := 1; i <= 100; i++ {
+		lines = append(lines, fmt.Sprintf("log line %03d", i))
+	}
+	next, _ = m.Update(logMsg{lines: lines})
+	m = next.(topModel)
+
+	next, _ = m.Update(tea.MouseMsg{Action: tea.MouseActionPress, Button: tea.MouseButtonWheelUp})
+	m = next.(topModel)
+	if m.followLogs || m.logView.AtBottom() {
+		t.Error("wheel up did not scroll away from the end")
+	}
+	next, _ = m.Update(tea.MouseMsg{Action: tea.MouseActionPress, Button: tea.MouseButtonWheelDown})
+	m = next.(topModel)
+	if !m.followLogs {
+		t.Error("wheel back down to the end did not resume following")
+	}
+}
@@ -0,0 +1,255 @@
+package cli
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// OpenCmd opens a server's URL in the default browser. identifier is a
+// managed service name or a port; urlName picks one of the service's
+// named URLs instead of the primary one.
+func (a *App) OpenCmd(identifier, urlName string) error {
+	servers, err := a.discoverServers()
+	if err != nil {
+		return err
+	}
+	srv := findServer(servers, identifier)
+	if srv == nil {
+		return &NotFoundError{Kind: "server", Name: identifier}
+	}
+	urls, err := serverURLs(srv)
+	if err != nil {
+		return err
+	}
+	target := urls[0]
+	if urlName != "" {
+		found := false
+		for _, u := range urls {
+			if u.Name == urlName {
+				target, found = u, true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s has no URL named %q (has: %s)", identifier, urlName, describeURLNames(urls))
+		}
+	}
+	if err := a.openServer(srv, target.URL); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out(), "Opened %s\n", target.URL)
+	return nil
+}
+
+// findServer returns the server of the managed service named identifier,
+// or else the one listening on the port identifier.
+func findServer(servers []*models.ServerInfo, identifier string) *models.ServerInfo {
+	for _, srv := range servers {
+		if srv.ManagedService != nil && srv.ManagedService.Name == identifier {
+			return srv
+		}
+		if srv.ProcessRecord != nil && fmt.Sprintf("%d", srv.ProcessRecord.Port) == identifier {
+			return srv
+		}
+	}
+	return nil
+}
+
+// openServer opens target, one of srv's URLs, in the browser.
+func (a *App) openServer(srv *models.ServerInfo, target string) error {
+	if srv.ManagedService != nil && !statusMatches(srv.Status, "running") {
+		return fmt.Errorf("%s is %s; start it with devpt start %s", srv.ManagedService.Name, srv.Status, srv.ManagedService.Name)
+	}
+	if err := openURL(target); err != nil {
+		return fmt.Errorf("failed to open %s: %w", target, err)
+	}
+	return nil
+}
+
+// serverURLs resolves the URLs a server is browsed at, the primary first:
+// the managed service's URLs, with paths appended to
+// http(s)://localhost:<port>, or that address alone when it declares none.
+// Services probed over TLS use https.
+func serverURLs(srv *models.ServerInfo) ([]models.ServiceURL, error) {
+	svc := srv.ManagedService
+	var declared []models.ServiceURL
+	if svc != nil {
+		declared = svc.ServiceURLs()
+	}
+	port := 0
+	if srv.ProcessRecord != nil && srv.ProcessRecord.Protocol != "udp" {
+		port = srv.ProcessRecord.Port
+	} else if svc != nil {
+		if ports := svc.ActivePorts(); len(ports) > 0 {
+			port = ports[0]
+		}
+	}
+	scheme := "http"
+	if svc != nil && svc.Health != nil && svc.Health.TLS {
+		scheme = "https"
+	}
+	base := fmt.Sprintf("%s://localhost:%d", scheme, port)
+	if len(declared) == 0 {
+		declared = []models.ServiceURL{{URL: "/"}}
+	}
+
+	out := make([]models.ServiceURL, 0, len(declared))
+	for _, u := range declared {
+		if strings.Contains(u.URL, "://") {
+			out = append(out, u)
+			continue
+		}
+		if port == 0 {
+			if svc != nil {
+				return nil, fmt.Errorf("service %q has no port to open; set one with --port or a full --url", svc.Name)
+			}
+			return nil, fmt.Errorf("server has no TCP port to open")
+		}
+		resolved := base
+		if u.URL != "/" {
+			resolved += u.URL
+		}
+		out = append(out, models.ServiceURL{Name: u.Name, URL: resolved})
+	}
+	return out, nil
+}
+
+// serverURL returns the primary URL of a server.
+func serverURL(srv *models.ServerInfo) (string, error) {
+	urls, err := serverURLs(srv)
+	if err != nil {
+		return "", err
+	}
+	return urls[0].URL, nil
+}
+
+// describeURLNames lists the names of urls, e.g. "app, docs".
+func describeURLNames(urls []models.ServiceURL) string {
+	var names []string
+	for _, u := range urls {
+		if u.Name != "" {
+			names = append(names, u.Name)
+		}
+	}
+	if len(names) == 0 {
+		return "no named URLs"
+	}
+	return strings.Join(names, ", ")
+}
+
+// ParseServiceURL parses a --url argument: "[NAME=]PATH" or "[NAME=]URL",
+// e.g. "docs=/docs" or "https://app.localhost:3000".
+func ParseServiceURL(raw string) (models.ServiceURL, error) {
+	u := models.ServiceURL{URL: raw}
+	if name, rest, ok := strings.Cut(raw, "="); ok && name != "" && !strings.ContainsAny(name, "/:?#") {
+		u = models.ServiceURL{Name: name, URL: rest}
+	}
+	return u, validateServiceURL(u.URL)
+}
+
+// validateServiceURL checks a service URL: a path such as "/docs", or a
+// full http(s) URL.
+func validateServiceURL(raw string) error {
+	if !strings.Contains(raw, "://") {
+		if !strings.HasPrefix(raw, "/") {
+			return fmt.Errorf("invalid URL %q: want a path starting with / or a full http(s) URL", raw)
+		}
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid URL %q: want a path starting with / or a full http(s) URL", raw)
+	}
+	return nil
+}
+
+// validateServiceURLs checks the URLs of a service being added.
+func validateServiceURLs(svc *models.ManagedService) error {
+	seen := make(map[string]bool)
+	for _, u := range svc.ServiceURLs() {
+		if err := validateServiceURL(u.URL); err != nil {
+			return err
+		}
+		if u.Name != "" {
+			if seen[u.Name] {
+				return fmt.Errorf("duplicate URL name %q", u.Name)
+			}
+			seen[u.Name] = true
+		}
+	}
+	return nil
+}
+
+// browserCommand returns the command that opens target in the default
+// browser on goos.
+func browserCommand(goos, target string) (string, []string) {
+	switch goos {
+	case "darwin":
+		return "open", []string{target}
+	case "windows":
+		return "rundll32", []string{"url.dll,FileProtocolHandler", target}
+	}
+	return "xdg-open", []string{target}
+}
+
+// openURL opens target in the default browser without waiting for it.
+var openURL = func(target string) error {
+	name, args := browserCommand(runtime.GOOS, target)
+	cmd := exec.Command(name, args...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// HyperlinksEnv forces OSC 8 hyperlinks on ("1") or off ("0").
+const HyperlinksEnv = "DEVPT_HYPERLINKS"
+
+// hyperlinksSupported reports whether the terminal described by getenv
+// renders OSC 8 hyperlinks. Terminals that do not would print the escape
+// sequence's text, so only known ones are trusted.
+func hyperlinksSupported(getenv func(string) string) bool {
+	switch getenv(HyperlinksEnv) {
+	case "1", "true", "yes":
+		return true
+	case "0", "false", "no":
+		return false
+	}
+	switch getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty", "Hyper", "Tabby", "rio":
+		return true
+	}
+	if getenv("WT_SESSION") != "" || getenv("KITTY_WINDOW_ID") != "" {
+		return true
+	}
+	// GNOME Terminal and other VTE terminals support them since VTE 0.50.
+	if v, err := strconv.Atoi(getenv("VTE_VERSION")); err == nil && v >= 5000 {
+		return true
+	}
+	term := getenv("TERM")
+	return strings.Contains(term, "kitty") || strings.Contains(term, "foot") || strings.Contains(term, "alacritty")
+}
+
+// hyperlink wraps text in an OSC 8 hyperlink to target.
+func hyperlink(target, text string) string {
+	return "\x1b]8;;" + target + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// link renders target for command output: a hyperlink when stdout is a
+// terminal that supports them, else the plain URL.
+func (a *App) link(target string) string {
+	if a.nonInteractive || !term.IsTerminal(os.Stdout.Fd()) || !hyperlinksSupported(os.Getenv) {
+		return target
+	}
+	return hyperlink(target, target)
+}
@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestServerURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		srv  *models.ServerInfo
+		want string
+	}{
+		{&models.ServerInfo{ProcessRecord: &models.ProcessRecord{Port: 5173}}, "http://localhost:5173"},
+		{&models.ServerInfo{ManagedService: &models.ManagedService{Name: "api", Ports: []int{8080}, URL: "/docs"}}, "http://localhost:8080/docs"},
+		{&models.ServerInfo{ManagedService: &models.ManagedService{Name: "api", Ports: []int{8080}, Health: &models.HealthCheckConfig{TLS: true}}, ProcessRecord: &models.ProcessRecord{Port: 8443}}, "https://localhost:8443"},
+		{&models.ServerInfo{ManagedService: &models.ManagedService{Name: "api", URL: "http://api.localhost:3000/"}}, "http://api.localhost:3000/"},
+	}
+	for _, tt := range tests {
+		got, err := serverURL(tt.srv)
+		if err != nil || got != tt.want {
+			t.Errorf("got %q, %v; want %q", got, err, tt.want)
+		}
+	}
+	if _, err := serverURL(&models.ServerInfo{ManagedService: &models.ManagedService{Name: "worker"}}); err == nil {
+		t.Error("expected an error for a service without a port")
+	}
+}
+
+func TestServerURLs(t *testing.T) {
+	t.Parallel()
+
+	srv := &models.ServerInfo{
+		ManagedService: &models.ManagedService{Name: "web", URLs: []models.ServiceURL{
+			{Name: "app", URL: "/"},
+			{Name: "storybook", URL: "http://localhost:6006"},
+			{Name: "docs", URL: "/docs"},
+		}},
+		ProcessRecord: &models.ProcessRecord{Port: 3000},
+	}
+	urls, err := serverURLs(srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"http://localhost:3000", "http://localhost:6006", "http://localhost:3000/docs"}
+	for i, u := range urls {
+		if u.URL != want[i] {
+			t.Fatalf("url %d: got %q, want %q", i, u.URL, want[i])
+		}
+	}
+	if urls[2].Name != "docs" {
+		t.Fatalf("expected names to be kept, got %+v", urls[2])
+	}
+}
+
+func TestParseServiceURL(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]models.ServiceURL{
+		"/":                        {URL: "/"},
+		"docs=/docs":               {Name: "docs", URL: "/docs"},
+		"/search?q=x":              {URL: "/search?q=x"},
+		"sb=http://localhost:6006": {Name: "sb", URL: "http://localhost:6006"},
+		"https://a.localhost/?x=1": {URL: "https://a.localhost/?x=1"},
+	}
+	for raw, want := range tests {
+		got, err := ParseServiceURL(raw)
+		if err != nil || got != want {
+			t.Errorf("%q: got %+v, %v; want %+v", raw, got, err, want)
+		}
+	}
+	if _, err := ParseServiceURL("docs=docs"); err == nil {
+		t.Error("expected an error for a relative path")
+	}
+	svc := &models.ManagedService{URLs: []models.ServiceURL{{Name: "a", URL: "/"}, {Name: "a", URL: "/x"}}}
+	if err := validateServiceURLs(svc); err == nil {
+		t.Error("expected an error for duplicate names")
+	}
+}
+
+func TestHyperlinksSupported(t *testing.T) {
+	t.Parallel()
+
+	env := func(vars map[string]string) func(string) string {
+		return func(k string) string { return vars[k] }
+	}
+	if !hyperlinksSupported(env(map[string]string{"TERM_PROGRAM": "iTerm.app"})) {
+		t.Error("expected iTerm2 to support hyperlinks")
+	}
+	if !hyperlinksSupported(env(map[string]string{"VTE_VERSION": "7200"})) {
+		t.Error("expected a recent VTE to support hyperlinks")
+	}
+	if hyperlinksSupported(env(map[string]string{"TERM_PROGRAM": "Apple_Terminal"})) {
+		t.Error("expected Terminal.app not to be trusted")
+	}
+	if hyperlinksSupported(env(map[string]string{"TERM_PROGRAM": "iTerm.app", HyperlinksEnv: "0"})) {
+		t.Error("expected the environment override to win")
+	}
+	if got := hyperlink("http://x", "x"); got != "\x1b]8;;http://x\x1b\\x\x1b]8;;\x1b\\" {
+		t.Errorf("unexpected hyperlink %q", got)
+	}
+}
+
+func TestValidateServiceURL(t *testing.T) {
+	t.Parallel()
+
+	for _, raw := range []string{"/", "/docs?x=1", "https://app.localhost:3000"} {
+		if err := validateServiceURL(raw); err != nil {
+			t.Errorf("%q: %v", raw, err)
+		}
+	}
+	for _, raw := range []string{"docs", "ftp://host", "http://"} {
+		if err := validateServiceURL(raw); err == nil {
+			t.Errorf("%q: expected an error", raw)
+		}
+	}
+}
+
+func TestBrowserCommand(t *testing.T) {
+	t.Parallel()
+
+	if name, args := browserCommand("darwin", "http://localhost:3000"); name != "open" || args[0] != "http://localhost:3000" {
+		t.Fatalf("unexpected macOS command %s %v", name, args)
+	}
+	if name, _ := browserCommand("linux", "http://localhost:3000"); name != "xdg-open" {
+		t.Fatalf("unexpected Linux command %s", name)
+	}
+}
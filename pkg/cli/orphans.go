@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/devports/devpt/pkg/process"
+)
+
+// orphan is a dev process whose devpt service or parent is gone.
+type orphan struct {
+	PID     int
+	Port    int
+	Command string
+	Reason  string
+}
+
+// findOrphans lists dev servers that outlived what started them: leftovers
+// of stopped services, and listeners whose parent exited (a node server
+// surviving a crashed npm, a server started from a closed terminal).
+func (a *App) findOrphans() ([]orphan, error) {
+	servers, err := a.discoverServers()
+	if err != nil {
+		return nil, err
+	}
+	var out []orphan
+	seen := make(map[int]bool)
+	for _, svc := range a.registry.ListServices() {
+		for _, l := range a.liveLeftovers(svc) {
+			if seen[l.PID] {
+				continue
+			}
+			seen[l.PID] = true
+			out = append(out, orphan{PID: l.PID, Port: l.Port, Command: l.Command, Reason: fmt.Sprintf("left running after %q was stopped", svc.Name)})
+		}
+	}
+	for _, srv := range servers {
+		rec := srv.ProcessRecord
+		if rec == nil || seen[rec.PID] || isInfraServer(srv) || rec.Container != nil || rec.PortForward != nil {
+			continue
+		}
+		if rec.PPID > 1 && a.processManager.IsRunning(rec.PPID) {
+			continue
+		}
+		if svc := srv.ManagedService; svc != nil && svc.LastPID != nil && *svc.LastPID == rec.PID {
+			continue
+		}
+		seen[rec.PID] = true
+		reason := "parent process exited"
+		if svc := srv.ManagedService; svc != nil {
+			reason = fmt.Sprintf("parent process exited; serves %q", svc.Name)
+		}
+		out = append(out, orphan{PID: rec.PID, Port: rec.Port, Command: rec.Command, Reason: reason})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].PID < out[j].PID })
+	return out, nil
+}
+
+// OrphansCmd lists orphaned dev processes and stops them with kill, or
+// after asking when interactive is set and stdin is a terminal.
+func (a *App) OrphansCmd(kill, interactive bool) error {
+	orphans, err := a.findOrphans()
+	if err != nil {
+		return err
+	}
+	if len(orphans) == 0 {
+		fmt.Fprintln(a.out(), "No orphaned dev processes")
+		return nil
+	}
+	w := tabwriter.NewWriter(a.out(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PID\tPort\tCommand\tWhy")
+	for _, o := range orphans {
+		port := "-"
+		if o.Port > 0 {
+			port = fmt.Sprintf("%d", o.Port)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", o.PID, port, truncateCommand(o.Command, 60), o.Reason)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if !kill && !(interactive && a.confirm(fmt.Sprintf("Stop %d process(es)?", len(orphans)))) {
+		fmt.Fprintln(a.out(), "Stop them with: devpt orphans --kill")
+		return nil
+	}
+
+	failed := 0
+	for _, o := range orphans {
+		if err := a.processManager.Stop(o.PID, process.DefaultStopTimeout); err != nil && !isProcessFinishedErr(err) {
+			fmt.Fprintf(a.errOut(), "Warning: failed to stop PID %d: %v\n", o.PID, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(a.out(), "Process %d stopped\n", o.PID)
+	}
+	a.pruneLeftovers()
+	if failed > 0 {
+		return fmt.Errorf("%d process(es) could not be stopped", failed)
+	}
+	return nil
+}
+
+// pruneLeftovers forgets recorded leftovers that are no longer running.
+func (a *App) pruneLeftovers() {
+	for _, svc := range a.registry.ListServices() {
+		if len(svc.Leftovers) == 0 {
+			continue
+		}
+		live := a.liveLeftovers(svc)
+		if len(live) == len(svc.Leftovers) {
+			continue
+		}
+		if len(live) == 0 {
+			live = nil
+		}
+		if err := a.registry.SetLeftovers(svc.Name, live); err != nil {
+			fmt.Fprintf(a.errOut(), "Warning: failed to update registry: %v\n", err)
+		}
+	}
+}
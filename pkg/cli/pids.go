@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/devports/devpt/pkg/history"
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/process"
+)
+
+// pidState says what a service's recorded LastPID refers to.
+type pidState int
+
+const (
+	pidNone   pidState = iota // no PID recorded
+	pidLive                   // the process devpt started is still running
+	pidDead                   // nothing runs under the PID any more
+	pidReused                 // the PID now belongs to a different process
+)
+
+// pidStartSlack is how far a process's start time may be from the
+// recorded LastStart and still be the process devpt launched.
+const pidStartSlack = 10 * time.Second
+
+// lastPIDState checks whether svc's LastPID still identifies the process
+// devpt started, comparing its start time with LastStart so that a PID
+// reused after a reboot or a long sleep is not mistaken for the service.
+func (a *App) lastPIDState(svc *models.ManagedService) pidState {
+	if svc == nil || svc.LastPID == nil || *svc.LastPID <= 0 {
+		return pidNone
+	}
+	pid := *svc.LastPID
+	if !a.processManager.IsRunning(pid) {
+		return pidDead
+	}
+	if svc.LastStart != nil {
+		if started, err := a.processManager.StartTime(pid); err == nil && !startMatches(started, *svc.LastStart) {
+			return pidReused
+		}
+	}
+	return pidLive
+}
+
+func startMatches(started, recorded time.Time) bool {
+	d := started.Sub(recorded)
+	return d > -pidStartSlack && d < pidStartSlack
+}
+
+// reconcileInterval is how often long-running commands re-check recorded
+// PIDs; a jump of the wall clock past the monotonic one (the machine slept)
+// triggers a check right away.
+const (
+	reconcileInterval = 5 * time.Minute
+	sleepGap          = 30 * time.Second
+)
+
+// maybeReconcilePIDs reconciles recorded PIDs when the interval has passed
+// or the machine slept since the last reconciliation.
+func (a *App) maybeReconcilePIDs(now time.Time) {
+	if a.reconciledAt.IsZero() {
+		return
+	}
+	elapsed := now.Sub(a.reconciledAt)
+	slept := now.Round(0).Sub(a.reconciledAt.Round(0))-elapsed > sleepGap
+	if elapsed < reconcileInterval && !slept {
+		return
+	}
+	boot, _ := process.BootTime()
+	a.reconcilePIDs(now, boot)
+}
+
+// reconcilePIDs checks every recorded LastPID after a reboot or sleep may
+// have invalidated it. PIDs that still identify their service are marked
+// verified. PIDs now used by another process, and PIDs of runs started
+// before the last boot, are cleared and their runs recorded as lost rather
+// than crashed. Other dead PIDs are left for crash detection. It returns a
+// note for each cleared PID.
+func (a *App) reconcilePIDs(now, boot time.Time) []string {
+	a.reconciledAt = now
+	services := a.registry.ListServices()
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+
+	var notes []string
+	for _, svc := range services {
+		if svc.Ephemeral {
+			if note := a.reconcileEphemeral(svc, now); note != "" {
+				notes = append(notes, note)
+			}
+			continue
+		}
+		var reason string
+		switch a.lastPIDState(svc) {
+		case pidLive:
+			if err := a.registry.MarkPIDVerified(svc.Name, *svc.LastPID, now); err != nil {
+				fmt.Fprintf(a.errOut(), "Warning: failed to update registry: %v\n", err)
+			}
+			continue
+		case pidReused:
+			reason = "PID reused by another process"
+		case pidDead:
+			if svc.LastStart == nil || boot.IsZero() || !svc.LastStart.Before(boot) {
+				continue
+			}
+			if exit, err := a.processManager.LastExit(svc.Name); err == nil && exit != nil && exit.PID == *svc.LastPID {
+				continue // the supervisor saw it end; report that instead
+			}
+			reason = "system restarted"
+		default:
+			continue
+		}
+
+		pid := *svc.LastPID
+		entry := history.Entry{Time: now, Action: history.Lost, PID: pid, Reason: reason}
+		if start, ok := runStart(svc, pid); ok && reason == "system restarted" {
+			entry.Duration = models.Duration(boot.Sub(start))
+		}
+		a.recordHistory(svc.Name, entry)
+		if err := a.registry.FinishRun(svc.Name, pid, "lost ("+reason+")", now); err != nil {
+			fmt.Fprintf(a.errOut(), "Warning: failed to update registry: %v\n", err)
+		}
+		if err := a.registry.ClearServicePID(svc.Name); err != nil {
+			fmt.Fprintf(a.errOut(), "Warning: failed to clear PID for %q: %v\n", svc.Name, err)
+			continue
+		}
+		notes = append(notes, fmt.Sprintf("cleared stale PID %d of %q (%s)", pid, svc.Name, reason))
+	}
+	return notes
+}
+
+// ephemeralGrace covers the moment between `devpt run` registering a
+// service and recording its PID.
+const ephemeralGrace = time.Minute
+
+// reconcileEphemeral removes a `devpt run` service whose run is over but
+// was not unregistered, e.g. because devpt was killed.
+func (a *App) reconcileEphemeral(svc *models.ManagedService, now time.Time) string {
+	state := a.lastPIDState(svc)
+	if state == pidLive || (state == pidNone && now.Sub(svc.CreatedAt) < ephemeralGrace) {
+		return ""
+	}
+	if err := a.registry.RemoveService(svc.Name); err != nil {
+		fmt.Fprintf(a.errOut(), "Warning: failed to remove %q: %v\n", svc.Name, err)
+		return ""
+	}
+	return fmt.Sprintf("removed leftover devpt run service %q", svc.Name)
+}
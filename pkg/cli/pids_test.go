@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/process"
+	"github.com/devports/devpt/pkg/registry"
+)
+
+func TestReconcilePIDs(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	pm := process.NewManager(filepath.Join(dir, "logs"))
+	self := os.Getpid()
+	selfStart, err := pm.StartTime(self)
+	if err != nil {
+		t.Skipf("ps unavailable: %v", err)
+	}
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Skipf("cannot run true: %v", err)
+	}
+	dead := cmd.Process.Pid
+
+	now := time.Now()
+	boot := now.Add(-time.Hour)
+	beforeBoot := boot.Add(-time.Hour)
+	afterBoot := boot.Add(time.Minute)
+	hourOff := selfStart.Add(-time.Hour)
+	reg := registry.NewRegistry(filepath.Join(dir, "registry.json"))
+	for _, svc := range []*models.ManagedService{
+		{Name: "live", LastPID: &self, LastStart: &selfStart},
+		{Name: "reused", LastPID: &self, LastStart: &hourOff},
+		{Name: "rebooted", LastPID: &dead, LastStart: &beforeBoot, Runs: []models.RunRecord{{PID: dead, StartedAt: beforeBoot}}},
+		{Name: "crashed", LastPID: &dead, LastStart: &afterBoot},
+	} {
+		if err := reg.AddService(svc); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+	app := &App{registry: reg, processManager: pm}
+
+	notes := app.reconcilePIDs(now, boot)
+	if len(notes) != 2 {
+		t.Fatalf("expected notes for rebooted and reused, got %q", notes)
+	}
+	if svc := reg.GetService("live"); svc.LastPID == nil || svc.VerifiedAt == nil || !svc.VerifiedAt.Equal(now) {
+		t.Fatalf("live service should keep its PID and be verified: %+v", svc)
+	}
+	onDisk := registry.NewRegistry(filepath.Join(dir, "registry.json"))
+	if err := onDisk.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if svc := onDisk.GetService("live"); svc.VerifiedAt != nil {
+		t.Fatalf("verifying a PID should not write the registry: %+v", svc)
+	}
+	if svc := reg.GetService("reused"); svc.LastPID != nil {
+		t.Fatalf("reused PID should be cleared, got %d", *svc.LastPID)
+	}
+	svc := reg.GetService("rebooted")
+	if svc.LastPID != nil {
+		t.Fatalf("PID from before boot should be cleared, got %d", *svc.LastPID)
+	}
+	if got := svc.Runs[0].Outcome; got != "lost (system restarted)" {
+		t.Fatalf("run outcome = %q", got)
+	}
+	if svc := reg.GetService("crashed"); svc.LastPID == nil || *svc.LastPID != dead {
+		t.Fatal("a dead PID from this boot should be left for crash detection")
+	}
+}
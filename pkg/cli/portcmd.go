@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/process"
+)
+
+// portOwners returns the servers listening on port. Dev servers come from
+// discovery so they carry managed-service and agent details; other listeners
+// (databases, system services) come from the raw scan.
+func (a *App) portOwners(port int) ([]*models.ServerInfo, error) {
+	servers, err := a.discoverServers()
+	if err != nil {
+		return nil, err
+	}
+	var out []*models.ServerInfo
+	seen := make(map[int]bool)
+	for _, srv := range servers {
+		if srv.ProcessRecord != nil && srv.ProcessRecord.Port == port && !seen[srv.ProcessRecord.PID] {
+			seen[srv.ProcessRecord.PID] = true
+			out = append(out, srv)
+		}
+	}
+
+	records, err := a.scanner.ScanListeningPorts()
+	if err != nil {
+		return out, nil
+	}
+	for _, rec := range records {
+		if rec.Port != port || seen[rec.PID] {
+			continue
+		}
+		seen[rec.PID] = true
+		if rec.CWD != "" {
+			rec.ProjectRoot = a.resolver.FindProjectRoot(rec.CWD)
+		}
+		a.detector.EnrichProcessRecord(rec)
+		out = append(out, &models.ServerInfo{ProcessRecord: rec, Source: models.SourceUnknown, Status: "running"})
+	}
+	return out, nil
+}
+
+// PortCmd prints who owns a port, or with asJSON prints each owner as a
+// line of REST API JSON.
+func (a *App) PortCmd(port int, asJSON bool) error {
+	owners, err := a.portOwners(port)
+	if err != nil {
+		return err
+	}
+	if len(owners) == 0 {
+		return fmt.Errorf("nothing is listening on port %d", port)
+	}
+	if asJSON {
+		for _, srv := range owners {
+			if err := a.printJSON(newAPIServer(srv)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for i, srv := range owners {
+		if i > 0 {
+			fmt.Fprintln(a.out())
+		}
+		rec := srv.ProcessRecord
+		fmt.Fprintf(a.out(), "Port %d\n", port)
+		fmt.Fprintf(a.out(), "  PID:      %d\n", rec.PID)
+		if rec.PPID > 0 {
+			fmt.Fprintf(a.out(), "  PPID:     %d\n", rec.PPID)
+		}
+		if rec.User != "" {
+			fmt.Fprintf(a.out(), "  User:     %s\n", rec.User)
+		}
+		if rec.StartTime != nil {
+			fmt.Fprintf(a.out(), "  Started:  %s\n", describeStart(*rec.StartTime, time.Now()))
+		}
+		fmt.Fprintf(a.out(), "  Command:  %s\n", rec.Command)
+		if c := rec.Container; c != nil {
+			if c.ID != "" {
+				fmt.Fprintf(a.out(), "  Docker:   %s\n", c.Label())
+			}
+			if c.VM != "" {
+				fmt.Fprintf(a.out(), "  VM:       %s (%s)\n", c.VM, c.Runtime())
+			}
+		}
+		if f := rec.PortForward; f != nil {
+			fmt.Fprintf(a.out(), "  Forward:  %s\n", f.Label())
+		}
+		if rec.ProjectRoot != "" {
+			fmt.Fprintf(a.out(), "  Project:  %s\n", rec.ProjectRoot)
+		} else if rec.CWD != "" {
+			fmt.Fprintf(a.out(), "  CWD:      %s\n", rec.CWD)
+		}
+		if srv.ManagedService != nil {
+			fmt.Fprintf(a.out(), "  Managed:  %s\n", srv.ManagedService.Name)
+		}
+		if tag := rec.AgentTag; tag != nil && tag.Source == models.SourceAgent {
+			if tag.AncestorPID > 0 {
+				fmt.Fprintf(a.out(), "  Agent:    %s (%s confidence, via %s PID %d)\n", tag.AgentName, tag.Confidence, tag.AncestorName, tag.AncestorPID)
+			} else {
+				fmt.Fprintf(a.out(), "  Agent:    %s (%s confidence)\n", tag.AgentName, tag.Confidence)
+			}
+		}
+	}
+	return nil
+}
+
+// KillPortCmd stops whatever listens on port: SIGTERM with a grace period
+// before SIGKILL, or SIGKILL right away with force.
+func (a *App) KillPortCmd(port int, force bool) error {
+	owners, err := a.portOwners(port)
+	if err != nil {
+		return err
+	}
+	if len(owners) == 0 {
+		return fmt.Errorf("nothing is listening on port %d", port)
+	}
+	for _, srv := range owners {
+		if c := srv.ProcessRecord.Container; c != nil {
+			return containerStopError(port, c)
+		}
+	}
+	for _, srv := range owners {
+		pid := srv.ProcessRecord.PID
+		if force {
+			fmt.Fprintf(a.out(), "Killing PID %d (%s)...\n", pid, truncateCommand(srv.ProcessRecord.Command, 60))
+			err = a.processManager.Kill(pid)
+		} else {
+			fmt.Fprintf(a.out(), "Stopping PID %d (%s)...\n", pid, truncateCommand(srv.ProcessRecord.Command, 60))
+			err = a.processManager.Stop(pid, 5*time.Second)
+		}
+		if err != nil && !isProcessFinishedErr(err) {
+			if errors.Is(err, process.ErrNeedSudo) || errors.Is(err, syscall.EPERM) {
+				return fmt.Errorf("%w (try: sudo kill -9 %d)", ErrNeedSudo, pid)
+			}
+			return fmt.Errorf("failed to stop PID %d: %w", pid, err)
+		}
+		name := ""
+		if srv.ManagedService != nil {
+			name = srv.ManagedService.Name
+			if err := a.registry.ClearServicePID(name); err != nil {
+				fmt.Fprintf(a.errOut(), "Warning: failed to clear PID for %q: %v\n", name, err)
+			}
+		}
+		a.emitStopped(name, pid)
+		fmt.Fprintf(a.out(), "Port %d is free\n", port)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/devports/devpt/pkg/devpt"
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/process"
+)
+
+// StartOptions tunes how a managed service is started.
+type StartOptions struct {
+	// Force stops processes that hold the service's declared ports.
+	Force bool
+	// AutoPort allocates a free port for this run and passes it as $PORT,
+	// even when the service is not configured for automatic ports.
+	AutoPort bool
+
+	restart bool     // started as part of a restart
+	via     string   // overrides the App's interface in the run's actor
+	env     []string // "KEY=value" entries overriding the service's env
+	port    int      // automatic port to reuse when still free; implies AutoPort
+}
+
+// checkPorts fails fast when svc's declared ports are already bound. With
+// force, the owning processes are stopped instead.
+func (a *App) checkPorts(svc *models.ManagedService, opts StartOptions) error {
+	conflicts := a.portConflicts(svc)
+	if len(conflicts) == 0 {
+		return nil
+	}
+	if !opts.Force {
+		lines := make([]string, len(conflicts))
+		for i, c := range conflicts {
+			lines[i] = c.String()
+		}
+		return fmt.Errorf("%w: %s; stop it first or use --force", ErrPortConflict, strings.Join(lines, "; "))
+	}
+
+	stopped := make(map[int]bool)
+	for _, c := range conflicts {
+		if c.PID == 0 {
+			return fmt.Errorf("%s and its owner could not be identified", c)
+		}
+		if stopped[c.PID] {
+			continue
+		}
+		fmt.Fprintf(a.out(), "Stopping PID %d holding port %d...\n", c.PID, c.Port)
+		if err := a.processManager.Stop(c.PID, 5*time.Second); err != nil {
+			if errors.Is(err, process.ErrNeedSudo) {
+				return fmt.Errorf("%w (PID %d holding port %d)", ErrNeedSudo, c.PID, c.Port)
+			}
+			return fmt.Errorf("failed to stop PID %d: %w", c.PID, err)
+		}
+		stopped[c.PID] = true
+		if c.Service != "" {
+			if err := a.registry.ClearServicePID(c.Service); err != nil {
+				fmt.Fprintf(a.errOut(), "Warning: failed to clear PID for %q: %v\n", c.Service, err)
+			}
+		}
+		a.emitStopped(c.Service, c.PID)
+	}
+	return nil
+}
+
+// portConflicts lists svc's declared ports that another process is listening
+// on. When the listener scan is unavailable it falls back to a bind test,
+// which cannot name the owner.
+func (a *App) portConflicts(svc *models.ManagedService) []devpt.PortConflict {
+	if len(svc.Ports) == 0 {
+		return nil
+	}
+	records, err := a.scanner.ScanListeningPorts()
+	if err != nil {
+		return devpt.BoundPorts(svc)
+	}
+	return devpt.PortConflicts(svc, a.registry.ListServices(), records)
+}
+
+// allocatePort picks the first free port in the configured range that no
+// other managed service declares or is currently using.
+func (a *App) allocatePort(svc *models.ManagedService) (int, error) {
+	return devpt.AllocatePort(svc, a.registry.ListServices(), a.portSettings())
+}
+
+// reusePort returns preferred when it is free and no other managed service
+// claims it, and allocates another port otherwise, so a restored run keeps
+// the port it had when it can.
+func (a *App) reusePort(svc *models.ManagedService, preferred int) (int, error) {
+	return devpt.ReusePort(svc, a.registry.ListServices(), a.portSettings(), preferred)
+}
+
+func (a *App) portSettings() models.PortSettings {
+	if a.settings == nil {
+		return models.PortSettings{}
+	}
+	return a.settings.Ports
+}
+
+// truncateCommand shortens long command lines for messages.
+func truncateCommand(cmd string, max int) string {
+	if len(cmd) <= max {
+		return cmd
+	}
+	return cmd[:max-3] + "..."
+}
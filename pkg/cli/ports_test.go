@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/devports/devpt/pkg/devpt"
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/registry"
+)
+
+func TestAllocatePortSkipsBoundAndClaimedPorts(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	busy := ln.Addr().(*net.TCPAddr).Port
+	if busy > 65000 {
+		t.Skip("ephemeral port too close to the end of the range")
+	}
+
+	reg := registry.NewRegistry(filepath.Join(t.TempDir(), "registry.json"))
+	if err := reg.AddService(&models.ManagedService{Name: "other", CWD: "/tmp", Command: "x", Ports: []int{busy + 1}}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	app := &App{
+		registry: reg,
+		settings: &models.Config{Ports: models.PortSettings{AutoMin: busy, AutoMax: busy + 20}},
+	}
+
+	port, err := app.allocatePort(&models.ManagedService{Name: "web"})
+	if err != nil {
+		t.Fatalf("allocatePort: %v", err)
+	}
+	if port == busy || port == busy+1 {
+		t.Fatalf("allocated a taken port: %d", port)
+	}
+}
+
+func TestReusePortKeepsAFreePort(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	busy := ln.Addr().(*net.TCPAddr).Port
+	if busy > 65000 {
+		t.Skip("ephemeral port too close to the end of the range")
+	}
+
+	reg := registry.NewRegistry(filepath.Join(t.TempDir(), "registry.json"))
+	if err := reg.AddService(&models.ManagedService{Name: "other", CWD: "/tmp", Command: "x", Ports: []int{busy + 1}}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	app := &App{
+		registry: reg,
+		settings: &models.Config{Ports: models.PortSettings{AutoMin: busy, AutoMax: busy + 20}},
+	}
+	web := &models.ManagedService{Name: "web"}
+
+	for _, taken := range []int{busy, busy + 1} {
+		port, err := app.reusePort(web, taken)
+		if err != nil {
+			t.Fatalf("reusePort(%d): %v", taken, err)
+		}
+		if port == busy || port == busy+1 {
+			t.Fatalf("reusePort(%d) = %d, a taken port", taken, port)
+		}
+	}
+	if !devpt.PortFree(busy + 5) {
+		t.Skip("port in the range is taken")
+	}
+	if port, err := app.reusePort(web, busy+5); err != nil || port != busy+5 {
+		t.Fatalf("reusePort(%d) = %d, %v; want the free port kept", busy+5, port, err)
+	}
+}
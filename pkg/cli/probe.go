@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/devports/devpt/pkg/health"
+	"github.com/devports/devpt/pkg/models"
+)
+
+// probeMsg carries the results of a round of availability probes, by
+// service name.
+type probeMsg struct {
+	results map[string]health.ProbeResult
+}
+
+// probedServices returns the running local services with a probe endpoint,
+// by name.
+func (m topModel) probedServices() map[string]*models.ServerInfo {
+	probed := make(map[string]*models.ServerInfo)
+	for _, srv := range m.servers {
+		svc := srv.ManagedService
+		if svc == nil || srv.ProcessRecord == nil || srv.ProcessRecord.Port <= 0 || srv.Host != "" {
+			continue
+		}
+		if health.ProbeInterval(svc.Health) > 0 {
+			probed[svc.Name] = srv
+		}
+	}
+	return probed
+}
+
+// probeCmd probes, in parallel, the services whose probe is due at now; nil
+// when none is.
+func (m topModel) probeCmd(now time.Time) tea.Cmd {
+	due := make(map[string]*models.ServerInfo)
+	for name, srv := range m.probedServices() {
+		if now.Sub(m.probes.Last(name)) >= health.ProbeInterval(srv.ManagedService.Health) {
+			due[name] = srv
+		}
+	}
+	if len(due) == 0 {
+		return nil
+	}
+	ctx, chk := m.context(), m.healthChk
+	return func() tea.Msg {
+		var (
+			mu      sync.Mutex
+			wg      sync.WaitGroup
+			results = make(map[string]health.ProbeResult, len(due))
+		)
+		for name, srv := range due {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r := chk.Probe(ctx, srv.ProcessRecord.Port, srv.ManagedService.Health)
+				mu.Lock()
+				results[name] = r
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+		return probeMsg{results: results}
+	}
+}
+
+// recordProbes adds a round of results to the log and forgets services no
+// longer probed. Results of a TUI that is quitting are dropped.
+func (m *topModel) recordProbes(msg probeMsg) {
+	if m.context().Err() != nil {
+		return
+	}
+	probed := m.probedServices()
+	keep := make(map[string]bool, len(probed))
+	for name := range probed {
+		keep[name] = true
+	}
+	for name, r := range msg.results {
+		if keep[name] {
+			m.probes.Record(name, r)
+		}
+	}
+	m.probes.Forget(keep)
+}
+
+// describeProbe summarizes the probes of svc over the last window, e.g.
+// "/ping every 5s: 99.3% available, 0.7% errors, 12ms avg (60 in 5m)".
+// Latency is left out while nothing answers.
+func (m topModel) describeProbe(svc *models.ManagedService, now time.Time) string {
+	interval := health.ProbeInterval(svc.Health)
+	head := fmt.Sprintf("%s every %s", svc.Health.ProbePath, interval)
+	st := m.probes.Stats(svc.Name, now)
+	if st.Probes == 0 {
+		return head + ": no probes yet"
+	}
+	out := fmt.Sprintf("%s: %s available, %s errors", head, formatPercent(st.Availability), formatPercent(st.ErrorRate))
+	if st.Unavailable < st.Probes {
+		out += fmt.Sprintf(", %dms avg", st.AvgLatency.Milliseconds())
+	}
+	return out + fmt.Sprintf(" (%d in %.0fm)", st.Probes, health.ProbeWindow.Minutes())
+}
+
+// formatPercent shows a share from 0 to 1 with one decimal, dropping it at
+// the ends: "100%", "99.3%", "0%".
+func formatPercent(share float64) string {
+	pct := share * 100
+	if pct == 100 || pct == 0 {
+		return fmt.Sprintf("%.0f%%", pct)
+	}
+	return fmt.Sprintf("%.1f%%", pct)
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devports/devpt/pkg/health"
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestDetailShowsProbeStats(t *testing.T) {
+	t.Parallel()
+	svc := &models.ManagedService{Name: "api", Command: "go run .", Health: &models.HealthCheckConfig{ProbePath: "/ping"}}
+	srv := &models.ServerInfo{ManagedService: svc, ProcessRecord: &models.ProcessRecord{PID: 10, Port: 8080}, Status: "running"}
+	m := newTestTopModel(t, []*models.ServerInfo{srv})
+	m.probes = health.NewProbeLog()
+
+	if out := m.renderDetail(100); !strings.Contains(out, "/ping every 5s: no probes yet") {
+		t.Errorf("detail panel lacks the pending probe:\n%s", out)
+	}
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		m.probes.Record("api", health.ProbeResult{At: now.Add(-time.Duration(i) * time.Second), Status: 200, Latency: 12 * time.Millisecond})
+	}
+	m.probes.Record("api", health.ProbeResult{At: now, Status: 502, Latency: 12 * time.Millisecond})
+	if out := m.renderDetail(100); !strings.Contains(out, "100% available, 25.0% errors, 12ms avg (4 in 5m)") {
+		t.Errorf("detail panel lacks the probe stats:\n%s", out)
+	}
+}
+
+func TestProbeCmdOnlyWhenDue(t *testing.T) {
+	t.Parallel()
+	svc := &models.ManagedService{Name: "api", Health: &models.HealthCheckConfig{ProbePath: "/ping", ProbeInterval: models.Duration(10 * time.Second)}}
+	plain := &models.ManagedService{Name: "web", Health: &models.HealthCheckConfig{Path: "/"}}
+	m := newTestTopModel(t, []*models.ServerInfo{
+		{ManagedService: svc, ProcessRecord: &models.ProcessRecord{PID: 10, Port: 8080}, Status: "running"},
+		{ManagedService: plain, ProcessRecord: &models.ProcessRecord{PID: 11, Port: 3000}, Status: "running"},
+	})
+	m.probes = health.NewProbeLog()
+	now := time.Now()
+	if m.probeCmd(now) == nil {
+		t.Fatal("a service never probed is due")
+	}
+	m.probes.Record("api", health.ProbeResult{At: now.Add(-5 * time.Second), Status: 200})
+	if m.probeCmd(now) != nil {
+		t.Error("probed again before its interval")
+	}
+	if m.probeCmd(now.Add(5*time.Second)) == nil {
+		t.Error("not probed once its interval passed")
+	}
+}
@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// ParseProcfile reads processes in Procfile format: one "name: command" per
+// line. Blank lines and lines starting with # are skipped.
+func ParseProcfile(r io.Reader) ([]models.ServiceProcess, error) {
+	var procs []models.ServiceProcess
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, command, ok := strings.Cut(line, ":")
+		name, command = strings.TrimSpace(name), strings.TrimSpace(command)
+		if !ok || name == "" || command == "" {
+			return nil, fmt.Errorf("line %d: expected \"name: command\"", n)
+		}
+		procs = append(procs, models.ServiceProcess{Name: name, Command: command})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return procs, nil
+}
@@ -0,0 +1,21 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseProcfile(t *testing.T) {
+	t.Parallel()
+
+	procs, err := ParseProcfile(strings.NewReader("# dev\nweb: npm run web -- --port 3000\n\nworker:bundle exec sidekiq\n"))
+	if err != nil {
+		t.Fatalf("ParseProcfile: %v", err)
+	}
+	if len(procs) != 2 || procs[0].Name != "web" || procs[0].Command != "npm run web -- --port 3000" || procs[1].Name != "worker" || procs[1].Command != "bundle exec sidekiq" {
+		t.Fatalf("unexpected processes: %+v", procs)
+	}
+	if _, err := ParseProcfile(strings.NewReader("web npm run web\n")); err == nil {
+		t.Fatal("expected a line without a name to be rejected")
+	}
+}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/workspace"
+)
+
+// SetProject makes name the current project: services added from now on
+// belong to it, and short names given to commands find its services first.
+// An empty name picks the recorded project whose root holds the working
+// directory, if any.
+func (a *App) SetProject(name string) error {
+	if name != "" {
+		if err := validateProjectName(name); err != nil {
+			return err
+		}
+		a.project = name
+		return nil
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	if p := a.registry.ProjectForDir(wd); p != nil {
+		a.project = p.Name
+	}
+	return nil
+}
+
+// validateProjectName rejects names that cannot prefix a service name.
+func validateProjectName(name string) error {
+	if name == "" || strings.ContainsAny(name, models.ProjectSeparator+" \t/\\") {
+		return fmt.Errorf("invalid project name %q: no spaces, slashes or %q", name, models.ProjectSeparator)
+	}
+	return nil
+}
+
+// ScopedName returns the registry name a new service called name gets: name
+// qualified with the current project, or name itself when it is already
+// qualified or there is no current project.
+func (a *App) ScopedName(name string) string {
+	if a.project == "" || strings.Contains(name, models.ProjectSeparator) {
+		return name
+	}
+	return models.QualifiedName(a.project, name)
+}
+
+// ResolveService returns the registry name of the service a command was
+// given as name: the current project's service of that name when there is
+// one, or name as given, which then may be qualified or a global service.
+func (a *App) ResolveService(name string) string {
+	if scoped := a.ScopedName(name); scoped != name && a.registry.GetService(scoped) != nil {
+		return scoped
+	}
+	return name
+}
+
+// assignProject sets the project of svc from its qualified name.
+func assignProject(svc *models.ManagedService) error {
+	project, short, ok := strings.Cut(svc.Name, models.ProjectSeparator)
+	if !ok {
+		svc.Project = ""
+		return nil
+	}
+	if err := validateProjectName(project); err != nil {
+		return err
+	}
+	if short == "" || strings.Contains(short, models.ProjectSeparator) {
+		return fmt.Errorf("invalid service name %q: want PROJECT%sNAME", svc.Name, models.ProjectSeparator)
+	}
+	svc.Project = project
+	return nil
+}
+
+// recordProject records the project of svc, rooted at the repository of
+// its directory, unless it is known already.
+func (a *App) recordProject(svc *models.ManagedService) error {
+	if svc.Project == "" || a.registry.GetProject(svc.Project) != nil {
+		return nil
+	}
+	root, err := filepath.Abs(svc.CWD)
+	if err != nil {
+		return err
+	}
+	return a.registry.AddProject(svc.Project, workspace.Root(root))
+}
+
+// forgetProjectIfEmpty drops project once none of its services is left.
+func (a *App) forgetProjectIfEmpty(project string) {
+	if project == "" || a.registry.GetProject(project) == nil {
+		return
+	}
+	for _, svc := range a.registry.ListServices() {
+		if svc.Project == project {
+			return
+		}
+	}
+	if err := a.registry.RemoveProject(project); err != nil {
+		fmt.Fprintf(a.errOut(), "Warning: %v\n", err)
+	}
+}
+
+// ProjectListCmd lists the recorded projects, marking the current one.
+func (a *App) ProjectListCmd() error {
+	projects := a.registry.ListProjects()
+	if len(projects) == 0 {
+		fmt.Fprintln(a.out(), "No projects; add services with --project NAME to create one")
+		return nil
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Name < projects[j].Name })
+	counts := make(map[string]int)
+	for _, svc := range a.registry.ListServices() {
+		counts[svc.Project]++
+	}
+	w := tabwriter.NewWriter(a.out(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tROOT\tSERVICES")
+	for _, p := range projects {
+		name := p.Name
+		if name == a.project {
+			name += " *"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\n", name, p.Root, counts[p.Name])
+	}
+	return w.Flush()
+}
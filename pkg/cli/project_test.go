@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/registry"
+)
+
+func TestProjectsKeepServiceNamesApart(t *testing.T) {
+	dir := t.TempDir()
+	shop := filepath.Join(dir, "shop")
+	blog := filepath.Join(dir, "blog")
+	for _, root := range []string{shop, blog} {
+		if err := os.MkdirAll(filepath.Join(root, ".git"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	app := &App{registry: registry.NewRegistry(filepath.Join(dir, "registry.json"))}
+
+	for _, p := range []struct{ project, cwd string }{{"shop", shop}, {"blog", blog}} {
+		if err := app.SetProject(p.project); err != nil {
+			t.Fatalf("SetProject(%q): %v", p.project, err)
+		}
+		svc := &models.ManagedService{Name: app.ScopedName("api"), CWD: filepath.Join(p.cwd, "api"), Command: "npm start"}
+		if err := app.AddServiceCmd(svc); err != nil {
+			t.Fatalf("add %s api: %v", p.project, err)
+		}
+		if svc.Name != p.project+":api" || svc.Project != p.project {
+			t.Fatalf("added %q in project %q, want %s:api", svc.Name, svc.Project, p.project)
+		}
+	}
+	if err := app.AddServiceCmd(&models.ManagedService{Name: "api", CWD: dir, Command: "npm start"}); err != nil {
+		t.Fatalf("add global api: %v", err)
+	}
+
+	if p := app.registry.GetProject("shop"); p == nil || p.Root != shop {
+		t.Fatalf("shop project = %+v, want root %s", p, shop)
+	}
+	if p := app.registry.ProjectForDir(filepath.Join(blog, "api", "src")); p == nil || p.Name != "blog" {
+		t.Fatalf("ProjectForDir(blog/api/src) = %+v, want blog", p)
+	}
+	if p := app.registry.ProjectForDir(dir); p != nil {
+		t.Fatalf("ProjectForDir(%s) = %+v, want none", dir, p)
+	}
+
+	app.project = "shop"
+	for name, want := range map[string]string{"api": "shop:api", "blog:api": "blog:api", "web": "web"} {
+		if got := app.ResolveService(name); got != want {
+			t.Errorf("ResolveService(%q) in shop = %q, want %q", name, got, want)
+		}
+	}
+	app.project = ""
+	if got := app.ResolveService("api"); got != "api" {
+		t.Errorf("ResolveService(api) without a project = %q, want the global api", got)
+	}
+
+	if err := app.RemoveCmd("blog:api"); err != nil {
+		t.Fatalf("remove blog:api: %v", err)
+	}
+	if p := app.registry.GetProject("blog"); p != nil {
+		t.Fatalf("blog project kept after its last service was removed: %+v", p)
+	}
+}
+
+func TestProjectNamesAreChecked(t *testing.T) {
+	t.Parallel()
+
+	app := &App{registry: registry.NewRegistry(filepath.Join(t.TempDir(), "registry.json"))}
+	for _, name := range []string{"a:b", "my shop", "a/b"} {
+		if err := app.SetProject(name); err == nil {
+			t.Errorf("SetProject(%q) accepted", name)
+		}
+	}
+	for _, name := range []string{"shop:", ":api", "shop:api:v2"} {
+		if err := assignProject(&models.ManagedService{Name: name}); err == nil {
+			t.Errorf("assignProject(%q) accepted", name)
+		}
+	}
+}
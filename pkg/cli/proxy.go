@@ -0,0 +1,360 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// DefaultProxyAddr is where devpt proxy serve listens unless told otherwise.
+const DefaultProxyAddr = "127.0.0.1:7080"
+
+// DefaultProxyDomain is the domain service hostnames end with. Browsers
+// resolve every *.localhost name to this machine.
+const DefaultProxyDomain = "localhost"
+
+// proxyRefresh is how long the proxy trusts the ports it last discovered.
+const proxyRefresh = 2 * time.Second
+
+// proxyRetry is how long after a discovery a request for an unknown or
+// stopped service is answered from it instead of rediscovering, so that
+// requests for made-up hostnames cannot keep the proxy scanning.
+const proxyRetry = 500 * time.Millisecond
+
+// proxyRoute is where a service hostname leads: the port of the service,
+// 0 while it is not running.
+type proxyRoute struct {
+	service string
+	port    int
+}
+
+// proxyRouter routes requests for <service>.<domain> to the port the
+// managed service listens on, rediscovering ports every proxyRefresh and
+// whenever a service cannot be reached, so that routes follow services
+// restarted on other ports.
+type proxyRouter struct {
+	domain   string
+	discover func(ctx context.Context) ([]*models.ServerInfo, error)
+
+	// refreshing is held while discovering, so discoveries take turns;
+	// mu is only held to read or replace the routes.
+	refreshing sync.Mutex
+	mu         sync.Mutex
+	routes     *proxyRoutes // replaced, never modified
+	at         time.Time
+}
+
+// proxyRoutes are the routes of one discovery.
+type proxyRoutes struct {
+	byLabel map[string]proxyRoute // by hostname label; the running service wins a shared label
+	byName  map[string]proxyRoute // by service name
+}
+
+// newProxyRouter returns the router of the App's managed services. The App
+// is not safe for concurrent use, so discoveries take turns.
+func (a *App) newProxyRouter(domain string) *proxyRouter {
+	return &proxyRouter{domain: domain, discover: a.discoverServersContext}
+}
+
+// proxyHostLabel turns a service name into the hostname label it is
+// reached at: lowercase letters, digits and dashes.
+func proxyHostLabel(service string) string {
+	label := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return '-'
+	}, service)
+	return strings.Trim(label, "-")
+}
+
+// label returns the service label of a request's Host, false when the
+// host is not a name under the proxy's domain.
+func (p *proxyRouter) label(host string) (string, bool) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	label, ok := strings.CutSuffix(host, "."+p.domain)
+	if !ok || label == "" || strings.Contains(label, ".") {
+		return "", false
+	}
+	return label, true
+}
+
+// lookup returns the route of label, rediscovering the services first when
+// the routes are stale. With fresh set, for a label that was missing or not
+// running, routes older than proxyRetry count as stale.
+func (p *proxyRouter) lookup(ctx context.Context, label string, fresh bool) (proxyRoute, bool, error) {
+	return p.find(ctx, fresh, func(routes *proxyRoutes) (proxyRoute, bool) {
+		route, ok := routes.byLabel[label]
+		return route, ok
+	})
+}
+
+// lookupService is lookup for the service named name. Names that share a
+// hostname label keep their own routes.
+func (p *proxyRouter) lookupService(ctx context.Context, name string, fresh bool) (proxyRoute, bool, error) {
+	return p.find(ctx, fresh, func(routes *proxyRoutes) (proxyRoute, bool) {
+		route, ok := routes.byName[name]
+		return route, ok
+	})
+}
+
+// find picks a route from the current routes, which are at most
+// proxyRetry old with fresh set and proxyRefresh old otherwise.
+func (p *proxyRouter) find(ctx context.Context, fresh bool, pick func(*proxyRoutes) (proxyRoute, bool)) (proxyRoute, bool, error) {
+	maxAge := proxyRefresh
+	if fresh {
+		maxAge = proxyRetry
+	}
+	routes, err := p.current(ctx, maxAge)
+	if err != nil {
+		return proxyRoute{}, false, err
+	}
+	route, ok := pick(routes)
+	return route, ok, nil
+}
+
+// current returns the routes, rediscovering them first when they
+// are older than maxAge. Requests that find the routes stale together wait
+// for one discovery; the others keep using the routes meanwhile.
+func (p *proxyRouter) current(ctx context.Context, maxAge time.Duration) (*proxyRoutes, error) {
+	if routes, ok := p.cached(maxAge); ok {
+		return routes, nil
+	}
+	p.refreshing.Lock()
+	defer p.refreshing.Unlock()
+	if routes, ok := p.cached(maxAge); ok {
+		return routes, nil // discovered while waiting
+	}
+	routes, err := p.discoverRoutes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.routes, p.at = routes, time.Now()
+	p.mu.Unlock()
+	return routes, nil
+}
+
+// cached returns the routes when they are at most maxAge old.
+func (p *proxyRouter) cached(maxAge time.Duration) (*proxyRoutes, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.routes == nil || time.Since(p.at) > maxAge {
+		return nil, false
+	}
+	return p.routes, true
+}
+
+// discoverRoutes discovers the services and their ports.
+func (p *proxyRouter) discoverRoutes(ctx context.Context) (*proxyRoutes, error) {
+	servers, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	routes := &proxyRoutes{byLabel: make(map[string]proxyRoute), byName: make(map[string]proxyRoute)}
+	for _, srv := range servers {
+		if srv.ManagedService == nil {
+			continue
+		}
+		s := newAPIServer(srv)
+		route := proxyRoute{service: s.Name}
+		if s.Status != "stopped" && !isCrashStatus(s.Status) {
+			route.port = s.Port
+		}
+		routes.byName[s.Name] = route
+		label := proxyHostLabel(s.Name)
+		if label == "" {
+			continue
+		}
+		if prev, ok := routes.byLabel[label]; ok && prev.port > 0 {
+			continue // two names with the same label; the running one wins
+		}
+		routes.byLabel[label] = route
+	}
+	return routes, nil
+}
+
+// invalidate makes the next lookup rediscover the services.
+func (p *proxyRouter) invalidate() {
+	p.mu.Lock()
+	p.routes = nil
+	p.mu.Unlock()
+}
+
+func (p *proxyRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	label, ok := p.label(r.Host)
+	if !ok {
+		p.index(w, r)
+		return
+	}
+	p.serveLabel(w, r, label)
+}
+
+// serveLabel forwards r to the service reached at label.
+func (p *proxyRouter) serveLabel(w http.ResponseWriter, r *http.Request, label string) {
+	route, found, err := p.lookup(r.Context(), label, false)
+	if err == nil && (!found || route.port == 0) {
+		// The service may have been added or started since.
+		route, found, err = p.lookup(r.Context(), label, true)
+	}
+	if err == nil && !found {
+		http.Error(w, fmt.Sprintf("devpt proxy: no managed service is reached at %s; open http://%s/ for the list", r.Host, p.withPort(p.domain, r.Host)), http.StatusNotFound)
+		return
+	}
+	p.serveRoute(w, r, route, err)
+}
+
+// serveService forwards r to the service named name.
+func (p *proxyRouter) serveService(w http.ResponseWriter, r *http.Request, name string) {
+	route, found, err := p.lookupService(r.Context(), name, false)
+	if err == nil && (!found || route.port == 0) {
+		route, found, err = p.lookupService(r.Context(), name, true)
+	}
+	if err == nil && !found {
+		http.Error(w, "devpt proxy: "+errServiceNotFound(name).Error(), http.StatusNotFound)
+		return
+	}
+	p.serveRoute(w, r, route, err)
+}
+
+// serveRoute forwards r to route, which a lookup returned with err.
+func (p *proxyRouter) serveRoute(w http.ResponseWriter, r *http.Request, route proxyRoute, err error) {
+	switch {
+	case err != nil:
+		http.Error(w, "devpt proxy: "+err.Error(), http.StatusBadGateway)
+		return
+	case route.port == 0:
+		http.Error(w, fmt.Sprintf("devpt proxy: %q is not running; start it with: devpt start %s", route.service, route.service), http.StatusBadGateway)
+		return
+	}
+	forwardHTTP(w, r, route, p.invalidate)
+}
+
+// forwardHTTP forwards r to the port of route, calling failed when the
+// service cannot be reached.
+func forwardHTTP(w http.ResponseWriter, r *http.Request, route proxyRoute, failed func()) {
+	target := &url.URL{Scheme: "http", Host: "localhost:" + strconv.Itoa(route.port)}
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			// Dev servers often only answer requests for localhost.
+			pr.SetURL(target)
+			pr.SetXForwarded()
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			// The service may be restarting on another port.
+			if failed != nil {
+				failed()
+			}
+			what := fmt.Sprintf("port %d", route.port)
+			if route.service != "" {
+				what = fmt.Sprintf("%q on port %d", route.service, route.port)
+			}
+			http.Error(w, fmt.Sprintf("devpt proxy: %s: %v", what, err), http.StatusBadGateway)
+		},
+	}
+	proxy.ServeHTTP(w, r)
+}
+
+// targetHandler forwards to target: a port, or the managed service of that
+// name wherever it listens. It also returns how to name the target.
+func (a *App) targetHandler(target string) (http.Handler, string, error) {
+	if port, err := strconv.Atoi(target); err == nil {
+		if port <= 0 || port > 65535 {
+			return nil, "", fmt.Errorf("invalid port: %s", target)
+		}
+		route := proxyRoute{port: port}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			forwardHTTP(w, r, route, nil)
+		}), "localhost:" + target, nil
+	}
+	if a.registry.GetService(target) == nil {
+		return nil, "", errServiceNotFound(target)
+	}
+	router := a.newProxyRouter(DefaultProxyDomain)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		router.serveService(w, r, target)
+	}), fmt.Sprintf("%q", target), nil
+}
+
+// index lists the service hostnames and where they lead.
+func (p *proxyRouter) index(w http.ResponseWriter, r *http.Request) {
+	routes, err := p.current(r.Context(), proxyRefresh)
+	if err != nil {
+		http.Error(w, "devpt proxy: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	writeProxyRoutes(w, routes.byLabel, func(label string) string { return p.withPort(label+"."+p.domain, r.Host) })
+}
+
+// withPort adds the port of requestHost to host.
+func (p *proxyRouter) withPort(host, requestHost string) string {
+	if _, port, err := net.SplitHostPort(requestHost); err == nil && port != "80" {
+		return net.JoinHostPort(host, port)
+	}
+	return host
+}
+
+// writeProxyRoutes lists routes in name order, with host naming the
+// hostname of a label.
+func writeProxyRoutes(w io.Writer, routes map[string]proxyRoute, host func(label string) string) {
+	labels := make([]string, 0, len(routes))
+	for label := range routes {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	if len(labels) == 0 {
+		fmt.Fprintln(w, "No managed services to route to; add one with devpt add")
+		return
+	}
+	for _, label := range labels {
+		route := routes[label]
+		dest := "not running"
+		if route.port > 0 {
+			dest = "localhost:" + strconv.Itoa(route.port)
+		}
+		fmt.Fprintf(w, "http://%s -> %s (%s)\n", host(label), dest, route.service)
+	}
+}
+
+// ProxyServeCmd serves http://<service>.<domain> for every managed service
+// on addr until interrupted, forwarding to the port the service listens on.
+func (a *App) ProxyServeCmd(addr, domain string) error {
+	if addr == "" {
+		addr = DefaultProxyAddr
+	}
+	domain = strings.Trim(strings.ToLower(domain), ".")
+	if domain == "" {
+		domain = DefaultProxyDomain
+	}
+	if domain != DefaultProxyDomain {
+		fmt.Fprintf(a.errOut(), "Note: *.%s must resolve to this machine, e.g. with dnsmasq or /etc/hosts entries\n", domain)
+	}
+
+	router := a.newProxyRouter(domain)
+	srv := &http.Server{Handler: router}
+	return a.serveLocal(addr, srv, "the proxy", "forwards to your dev servers", func(listening net.Addr) {
+		listenHost := listening.String()
+		fmt.Fprintf(a.out(), "devpt proxy listening on http://%s\n", listenHost)
+		if routes, err := router.current(context.Background(), proxyRefresh); err == nil {
+			writeProxyRoutes(a.out(), routes.byLabel, func(label string) string { return router.withPort(label+"."+domain, listenHost) })
+		}
+	})
+}
@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/devports/devpt/internal/testutil"
+	"github.com/devports/devpt/pkg/models"
+)
+
+// newTestProxy returns a proxy in front of the servers that servers
+// returns, and counts its discoveries.
+func newTestProxy(t *testing.T, servers func() []*models.ServerInfo) (*httptest.Server, *int) {
+	t.Helper()
+	scans := 0
+	router := &proxyRouter{domain: DefaultProxyDomain, discover: func(context.Context) ([]*models.ServerInfo, error) {
+		scans++
+		return servers(), nil
+	}}
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	return srv, &scans
+}
+
+// proxyGet requests path from the proxy as host.
+func proxyGet(t *testing.T, proxy *httptest.Server, host, path string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, proxy.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Host = host
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestProxyRoutesServiceHostnames(t *testing.T) {
+	t.Parallel()
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "api "+r.URL.Path+" for "+r.Header.Get("X-Forwarded-Host"))
+	}))
+	defer backend.Close()
+	port := testutil.ServerPort(t, backend)
+	servers := []*models.ServerInfo{
+		{ManagedService: &models.ManagedService{Name: "API"}, ProcessRecord: &models.ProcessRecord{PID: 1, Port: port}, Status: "running"},
+		{ManagedService: &models.ManagedService{Name: "web"}, Status: "stopped"},
+	}
+	proxy, _ := newTestProxy(t, func() []*models.ServerInfo { return servers })
+
+	if code, body := proxyGet(t, proxy, "api.localhost:7080", "/users"); code != http.StatusOK || body != "api /users for api.localhost:7080" {
+		t.Errorf("api.localhost = %d %q", code, body)
+	}
+	if code, body := proxyGet(t, proxy, "web.localhost:7080", "/"); code != http.StatusBadGateway || !strings.Contains(body, "devpt start web") {
+		t.Errorf("stopped service = %d %q", code, body)
+	}
+	if code, _ := proxyGet(t, proxy, "nope.localhost:7080", "/"); code != http.StatusNotFound {
+		t.Errorf("unknown service = %d, want 404", code)
+	}
+	code, body := proxyGet(t, proxy, "localhost:7080", "/")
+	if code != http.StatusOK || !strings.Contains(body, "http://api.localhost:7080 -> localhost:"+strconv.Itoa(port)+" (API)") || !strings.Contains(body, "web.localhost:7080 -> not running") {
+		t.Errorf("index = %d %q", code, body)
+	}
+}
+
+func TestProxyFollowsServicesToNewPorts(t *testing.T) {
+	t.Parallel()
+	first := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, "first") }))
+	second := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, "second") }))
+	defer second.Close()
+	port := testutil.ServerPort(t, first)
+	proxy, scans := newTestProxy(t, func() []*models.ServerInfo {
+		return []*models.ServerInfo{{ManagedService: &models.ManagedService{Name: "api"}, ProcessRecord: &models.ProcessRecord{PID: 1, Port: port}, Status: "running"}}
+	})
+	if _, body := proxyGet(t, proxy, "api.localhost", "/"); body != "first" {
+		t.Fatalf("body = %q", body)
+	}
+
+	// The service restarts on another port: the first request fails and
+	// makes the proxy look again.
+	first.Close()
+	port = testutil.ServerPort(t, second)
+	if code, _ := proxyGet(t, proxy, "api.localhost", "/"); code != http.StatusBadGateway {
+		t.Errorf("request to the old port = %d, want 502", code)
+	}
+	if _, body := proxyGet(t, proxy, "api.localhost", "/"); body != "second" {
+		t.Errorf("after the restart body = %q, want second", body)
+	}
+	if *scans < 2 {
+		t.Errorf("discovered %d times, want a rediscovery", *scans)
+	}
+}
+
+func TestProxyRediscoversOutsideTheLock(t *testing.T) {
+	t.Parallel()
+	var scans atomic.Int32
+	unblock := make(chan struct{})
+	router := &proxyRouter{domain: DefaultProxyDomain, discover: func(context.Context) ([]*models.ServerInfo, error) {
+		if scans.Add(1) > 1 {
+			<-unblock
+		}
+		return []*models.ServerInfo{{ManagedService: &models.ManagedService{Name: "api"}, ProcessRecord: &models.ProcessRecord{PID: 1, Port: 3000}, Status: "running"}}, nil
+	}}
+	ctx := context.Background()
+	if _, found, err := router.lookup(ctx, "api", false); err != nil || !found {
+		t.Fatalf("lookup(api) = %v, %v", found, err)
+	}
+
+	// Misses right after a discovery are answered from it.
+	for i := 0; i < 10; i++ {
+		if _, found, err := router.lookup(ctx, fmt.Sprintf("made-up-%d", i), true); err != nil || found {
+			t.Fatalf("lookup(made-up-%d) = %v, %v", i, found, err)
+		}
+	}
+	if n := scans.Load(); n != 1 {
+		t.Fatalf("discovered %d times for made-up names, want 1", n)
+	}
+
+	// Later a miss rediscovers, and known names are served meanwhile.
+	router.mu.Lock()
+	router.at = time.Now().Add(-time.Second)
+	router.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		router.lookup(ctx, "nope", true)
+	}()
+	for scans.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	if route, found, err := router.lookup(ctx, "api", false); err != nil || !found || route.port != 3000 {
+		t.Errorf("lookup(api) during a discovery = %+v, %v, %v", route, found, err)
+	}
+	close(unblock)
+	<-done
+}
+
+func TestProxyHostLabel(t *testing.T) {
+	t.Parallel()
+	for name, want := range map[string]string{"api": "api", "My App": "my-app", "web_v2": "web-v2", "--x--": "x"} {
+		if got := proxyHostLabel(name); got != want {
+			t.Errorf("proxyHostLabel(%q) = %q, want %q", name, got, want)
+		}
+	}
+	p := &proxyRouter{domain: "localhost"}
+	for host, want := range map[string]string{"api.localhost:7080": "api", "API.localhost.": "api", "localhost": "", "a.b.localhost": "", "api.test": ""} {
+		if got, _ := p.label(host); got != want {
+			t.Errorf("label(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
+
+func TestServeServiceMatchesNameExactly(t *testing.T) {
+	t.Parallel()
+	backend := func(name string) int {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, name) }))
+		t.Cleanup(srv.Close)
+		return testutil.ServerPort(t, srv)
+	}
+	dashPort, underscorePort := backend("dash"), backend("underscore")
+	router := &proxyRouter{domain: DefaultProxyDomain, discover: func(context.Context) ([]*models.ServerInfo, error) {
+		// Both names have the hostname label api-v2.
+		return []*models.ServerInfo{
+			{ManagedService: &models.ManagedService{Name: "api-v2"}, ProcessRecord: &models.ProcessRecord{PID: 1, Port: dashPort}, Status: "running"},
+			{ManagedService: &models.ManagedService{Name: "api_v2"}, ProcessRecord: &models.ProcessRecord{PID: 2, Port: underscorePort}, Status: "running"},
+		}, nil
+	}}
+	for name, want := range map[string]string{"api-v2": "dash", "api_v2": "underscore", "API.v2": ""} {
+		rec := httptest.NewRecorder()
+		router.serveService(rec, httptest.NewRequest(http.MethodGet, "/", nil), name)
+		if want == "" {
+			if rec.Code != http.StatusNotFound {
+				t.Errorf("%s: status %d, want 404", name, rec.Code)
+			}
+			continue
+		}
+		if rec.Code != http.StatusOK || rec.Body.String() != want {
+			t.Errorf("%s: %d %q, want %q", name, rec.Code, rec.Body.String(), want)
+		}
+	}
+}
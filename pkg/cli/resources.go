@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/devports/devpt/pkg/events"
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/notify"
+)
+
+// resourceUse is one sample of the resources used by a running service's
+// process group.
+type resourceUse struct {
+	PID int
+	At  time.Time
+	Mem models.ByteSize
+	CPU float64 // percent of one core over the last cpuWindow; -1 until measured
+	// The CPU percentage is measured from base, a sample at least
+	// cpuWindow old, since ps reports CPU time in whole seconds.
+	baseAt  time.Time
+	baseCPU time.Duration
+	// Over names the exceeded limits, e.g. "mem 2.1GB > 2GB"; empty when
+	// the service is within its limits.
+	Over string
+}
+
+// String renders the sample as e.g. "cpu 12% mem 340MB".
+func (u resourceUse) String() string {
+	if u.CPU < 0 {
+		return fmt.Sprintf("mem %s", u.Mem)
+	}
+	return fmt.Sprintf("cpu %.0f%% mem %s", u.CPU, u.Mem)
+}
+
+// cpuWindow is the shortest span CPU use is measured over.
+const cpuWindow = 5 * time.Second
+
+// resourceMonitor keeps what CPU percentages and sustained limits need
+// between samples.
+type resourceMonitor struct {
+	last  map[string]resourceUse
+	over  map[string]time.Time // since when each service exceeds a limit
+	acted map[string]bool      // services whose limit action was taken for the current excess
+}
+
+// sampleResources samples the running managed services and acts on limits
+// exceeded for long enough. It is called from the TUI and `devpt watch`
+// loops; like schedules, only the process holding the scheduler lock acts.
+func (a *App) sampleResources(now time.Time) map[string]resourceUse {
+	usage, err := a.processManager.GroupUsage()
+	if err != nil {
+		return nil
+	}
+	mon := &a.resources
+	if mon.last == nil {
+		mon.last = make(map[string]resourceUse)
+		mon.over = make(map[string]time.Time)
+		mon.acted = make(map[string]bool)
+	}
+	out := make(map[string]resourceUse)
+	for _, svc := range a.registry.ListServices() {
+		if svc.LastPID == nil {
+			continue
+		}
+		u, ok := usage[*svc.LastPID]
+		if !ok {
+			continue
+		}
+		use := resourceUse{PID: *svc.LastPID, At: now, Mem: models.ByteSize(u.RSS), CPU: -1, baseAt: now, baseCPU: u.CPUTime}
+		if prev, ok := mon.last[svc.Name]; ok && prev.PID == use.PID {
+			use.CPU, use.baseAt, use.baseCPU = prev.CPU, prev.baseAt, prev.baseCPU
+			if span := now.Sub(prev.baseAt); span >= cpuWindow {
+				use.CPU = cpuPercent(u.CPUTime-prev.baseCPU, span)
+				use.baseAt, use.baseCPU = now, u.CPUTime
+			}
+		}
+		use.Over = exceededLimits(svc.Limits, use)
+		out[svc.Name] = use
+		a.trackLimit(svc, use, now)
+	}
+	for name := range mon.last {
+		if _, ok := out[name]; !ok {
+			delete(mon.over, name)
+			delete(mon.acted, name)
+		}
+	}
+	mon.last = out
+	return out
+}
+
+// cpuPercent converts CPU time used over span to a percentage of one core.
+func cpuPercent(used, span time.Duration) float64 {
+	if used < 0 || span <= 0 {
+		return 0
+	}
+	return 100 * float64(used) / float64(span)
+}
+
+// exceededLimits describes which of limits use exceeds.
+func exceededLimits(limits *models.ResourceLimits, use resourceUse) string {
+	if limits == nil {
+		return ""
+	}
+	var over []string
+	if limits.Memory > 0 && use.Mem > limits.Memory {
+		over = append(over, fmt.Sprintf("mem %s > %s", use.Mem, limits.Memory))
+	}
+	if limits.CPU > 0 && use.CPU > limits.CPU {
+		over = append(over, fmt.Sprintf("cpu %.0f%% > %.0f%%", use.CPU, limits.CPU))
+	}
+	return strings.Join(over, ", ")
+}
+
+func (a *App) trackLimit(svc *models.ManagedService, use resourceUse, now time.Time) {
+	mon := &a.resources
+	if use.Over == "" {
+		delete(mon.over, svc.Name)
+		delete(mon.acted, svc.Name)
+		return
+	}
+	since, ok := mon.over[svc.Name]
+	if !ok {
+		since = now
+		mon.over[svc.Name] = now
+	}
+	if mon.acted[svc.Name] || now.Sub(since) < svc.Limits.For.Std() || !a.holdSchedulerLock() {
+		return
+	}
+	mon.acted[svc.Name] = true
+	a.limitAction(svc, use, now)
+}
+
+// limitAction records that svc stayed above its limits and takes its
+// configured action.
+func (a *App) limitAction(svc *models.ManagedService, use resourceUse, now time.Time) {
+	action := svc.Limits.Action
+	if action == "" {
+		action = models.LimitHighlight
+	}
+	ev := events.Event{
+		Type:    events.LimitExceeded,
+		Service: svc.Name,
+		PID:     use.PID,
+		Message: use.Over,
+		Data:    map[string]string{"action": action},
+	}
+	switch action {
+	case models.LimitNotify:
+		if a.notifying() {
+			a.deliver(notify.Notification{
+				Event:   models.WebhookOnOverLimit,
+				Service: svc.Name,
+				Status:  "over-limit",
+				Reason:  use.Over,
+				Time:    now,
+			})
+		}
+	case models.LimitRestart:
+		if err := a.restartFor(svc, models.ViaLimit); err != nil {
+			ev.Data["error"] = err.Error()
+		}
+	}
+	a.emit(ev)
+}
+
+// currentUsage reads the resource use of svc's process group once, with
+// CPU averaged since the service started, for one-off commands such as
+// `devpt status`.
+func (a *App) currentUsage(svc *models.ManagedService) (resourceUse, bool) {
+	if svc.LastPID == nil {
+		return resourceUse{}, false
+	}
+	usage, err := a.processManager.GroupUsage()
+	if err != nil {
+		return resourceUse{}, false
+	}
+	u, ok := usage[*svc.LastPID]
+	if !ok {
+		return resourceUse{}, false
+	}
+	use := resourceUse{PID: *svc.LastPID, At: time.Now(), Mem: models.ByteSize(u.RSS), CPU: -1}
+	if svc.LastStart != nil {
+		use.CPU = cpuPercent(u.CPUTime, use.At.Sub(*svc.LastStart))
+	}
+	use.Over = exceededLimits(svc.Limits, use)
+	return use, true
+}
+
+// validateLimits checks a service's resource limits.
+func validateLimits(limits *models.ResourceLimits) error {
+	if limits == nil {
+		return nil
+	}
+	if limits.Memory < 0 || limits.CPU < 0 || limits.For < 0 {
+		return fmt.Errorf("resource limits cannot be negative")
+	}
+	switch limits.Action {
+	case "", models.LimitHighlight, models.LimitNotify, models.LimitRestart:
+	default:
+		return fmt.Errorf("invalid limit action %q (want highlight, notify or restart)", limits.Action)
+	}
+	return nil
+}
+
+// describeLimits renders limits as e.g. "mem 2GB, cpu 150% for 30s: restart".
+func describeLimits(limits *models.ResourceLimits) string {
+	var parts []string
+	if limits.Memory > 0 {
+		parts = append(parts, "mem "+limits.Memory.String())
+	}
+	if limits.CPU > 0 {
+		parts = append(parts, fmt.Sprintf("cpu %.0f%%", limits.CPU))
+	}
+	s := strings.Join(parts, ", ")
+	if limits.For > 0 {
+		s += " for " + limits.For.Std().String()
+	}
+	action := limits.Action
+	if action == "" {
+		action = models.LimitHighlight
+	}
+	return s + ": " + action
+}
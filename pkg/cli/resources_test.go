@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestExceededLimits(t *testing.T) {
+	t.Parallel()
+
+	limits := &models.ResourceLimits{Memory: 2 << 30, CPU: 150}
+	cases := []struct {
+		use  resourceUse
+		want string
+	}{
+		{resourceUse{Mem: 1 << 30, CPU: 50}, ""},
+		{resourceUse{Mem: 3 << 30, CPU: 50}, "mem 3GB > 2GB"},
+		{resourceUse{Mem: 1 << 30, CPU: 200}, "cpu 200% > 150%"},
+		// CPU is not compared before it has been measured.
+		{resourceUse{Mem: 3 << 30, CPU: -1}, "mem 3GB > 2GB"},
+		{resourceUse{Mem: 3 << 30, CPU: 151}, "mem 3GB > 2GB, cpu 151% > 150%"},
+	}
+	for _, tc := range cases {
+		if got := exceededLimits(limits, tc.use); got != tc.want {
+			t.Errorf("exceededLimits(%+v) = %q, want %q", tc.use, got, tc.want)
+		}
+	}
+	if got := exceededLimits(nil, resourceUse{Mem: 3 << 30}); got != "" {
+		t.Fatalf("expected no excess without limits, got %q", got)
+	}
+}
+
+func TestResourceLimitsJSON(t *testing.T) {
+	t.Parallel()
+
+	var limits models.ResourceLimits
+	if err := json.Unmarshal([]byte(`{"memory": "1.5G", "cpu": 80, "for": "30s", "action": "restart"}`), &limits); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if limits.Memory != 3<<29 {
+		t.Fatalf("expected 1.5GiB, got %d", limits.Memory)
+	}
+	if err := validateLimits(&limits); err != nil {
+		t.Fatalf("expected valid limits, got %v", err)
+	}
+	if got := describeLimits(&limits); got != "mem 1.5GB, cpu 80% for 30s: restart" {
+		t.Fatalf("unexpected description %q", got)
+	}
+	out, err := json.Marshal(limits)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(out) != `{"memory":"1.5GB","cpu":80,"for":"30s","action":"restart"}` {
+		t.Fatalf("unexpected JSON %s", out)
+	}
+
+	if err := validateLimits(&models.ResourceLimits{Memory: 1, Action: "kill"}); err == nil {
+		t.Fatal("expected an unknown action to be rejected")
+	}
+	if _, err := models.ParseByteSize("lots"); err == nil {
+		t.Fatal("expected an invalid size to be rejected")
+	}
+}
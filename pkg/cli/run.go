@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/process"
+)
+
+// ExitError carries the exit code of a foreground command so that the CLI
+// can exit with it.
+type ExitError struct {
+	Code   int
+	Reason string
+}
+
+func (e *ExitError) Error() string {
+	return e.Reason
+}
+
+// RunCmd runs argv in the current directory in the foreground, with output
+// to the terminal. For the duration of the run it is registered as an
+// ephemeral service named name (derived from the command when empty), so
+// discovery, health checks and port tracking see it like any other service.
+func (a *App) RunCmd(name string, ports []int, autoPort bool, argv []string) error {
+	if len(argv) == 0 {
+		return fmt.Errorf("command required")
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+	if name == "" {
+		name = a.runName(filepath.Base(argv[0]))
+	}
+
+	svc := &models.ManagedService{
+		Name:      name,
+		CWD:       cwd,
+		Command:   process.QuoteCommand(argv),
+		Ports:     ports,
+		AutoPort:  autoPort,
+		Ephemeral: true,
+	}
+	if err := a.checkPorts(svc, StartOptions{}); err != nil {
+		return err
+	}
+	var env []string
+	runPort := 0
+	if autoPort {
+		if runPort, err = a.allocatePort(svc); err != nil {
+			return err
+		}
+		env = append(env, fmt.Sprintf("PORT=%d", runPort))
+	}
+	if err := a.registry.AddService(svc); err != nil {
+		return err
+	}
+	defer func() {
+		if err := a.registry.RemoveService(name); err != nil {
+			fmt.Fprintf(a.errOut(), "Warning: failed to unregister %q: %v\n", name, err)
+		}
+	}()
+
+	// Ctrl+C reaches the command directly through the terminal; devpt only
+	// notes it and waits. Other termination signals are passed on.
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigs)
+
+	pid, wait, err := a.processManager.StartForeground(argv, cwd, env)
+	if err != nil {
+		return err
+	}
+	var interrupted atomic.Bool
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-sigs:
+				interrupted.Store(true)
+				if sig != os.Interrupt {
+					_ = syscall.Kill(pid, sig.(syscall.Signal))
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	if err := a.registry.UpdateServicePID(name, pid); err != nil {
+		fmt.Fprintf(a.errOut(), "Warning: failed to update registry: %v\n", err)
+	}
+	if err := a.registry.SetRunPort(name, runPort); err != nil {
+		fmt.Fprintf(a.errOut(), "Warning: failed to update registry: %v\n", err)
+	}
+	a.recordStart(svc, pid, runPort, StartOptions{})
+	msg := fmt.Sprintf("devpt: running %q as %s (PID %d)", name, svc.Command, pid)
+	if runPort > 0 {
+		msg += fmt.Sprintf(" on port %d (PORT=%d)", runPort, runPort)
+	}
+	fmt.Fprintln(a.errOut(), msg)
+
+	exit := wait()
+	if interrupted.Load() {
+		a.emitStopped(name, pid)
+		return nil
+	}
+	status := "stopped"
+	if exit.Code != 0 {
+		status = "crashed"
+	}
+	a.emitExit(svc, exit, status)
+	if exit.Code != 0 {
+		return &ExitError{Code: exit.Code, Reason: fmt.Sprintf("%s %s", svc.Command, exit.Describe())}
+	}
+	return nil
+}
+
+// runName picks the first of base, base-2, base-3, ... that is not a
+// registered service.
+func (a *App) runName(base string) string {
+	name := base
+	for i := 2; a.registry.GetService(name) != nil; i++ {
+		name = base + "-" + strconv.Itoa(i)
+	}
+	return name
+}
+
+// errEphemeral is returned when a `devpt run` service is to be started again.
+func errEphemeral(name string) error {
+	return fmt.Errorf("service %q was registered by devpt run and ends with it; register it with devpt add to manage it", name)
+}
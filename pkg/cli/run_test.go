@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/process"
+	"github.com/devports/devpt/pkg/registry"
+)
+
+func TestRunServicesAreNamedAndCleanedUp(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Skipf("cannot run true: %v", err)
+	}
+	dead := cmd.Process.Pid
+	reg := registry.NewRegistry(filepath.Join(dir, "registry.json"))
+	for _, svc := range []*models.ManagedService{
+		{Name: "node", Command: "node server.js"},
+		{Name: "node-2", Command: "node server.js", LastPID: &dead, Ephemeral: true},
+		{Name: "node-3", Command: "node server.js", Ephemeral: true},
+	} {
+		if err := reg.AddService(svc); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+	app := &App{registry: reg, processManager: process.NewManager(filepath.Join(dir, "logs"))}
+
+	if got := app.runName("python3"); got != "python3" {
+		t.Fatalf("runName(python3) = %q", got)
+	}
+	if got := app.runName("node"); got != "node-4" {
+		t.Fatalf("runName(node) = %q, want node-4", got)
+	}
+	if _, err := app.launch(reg.GetService("node-2"), StartOptions{}); err == nil {
+		t.Fatal("expected devpt run services to refuse devpt start")
+	}
+
+	notes := app.reconcilePIDs(time.Now(), time.Time{})
+	if len(notes) != 1 || reg.GetService("node-2") != nil {
+		t.Fatalf("expected the finished run to be unregistered, notes %q", notes)
+	}
+	if reg.GetService("node-3") == nil {
+		t.Fatal("a run that has not recorded its PID yet must be kept")
+	}
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/registry"
+)
+
+func TestRunHistoryKeepsStopOutcome(t *testing.T) {
+	t.Parallel()
+
+	reg := registry.NewRegistry(filepath.Join(t.TempDir(), "registry.json"))
+	if err := reg.AddService(&models.ManagedService{Name: "api", CWD: "/tmp", Command: "x"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	start := time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)
+	for i := 0; i < models.MaxRuns+2; i++ {
+		run := models.RunRecord{PID: 100 + i, StartedAt: start.Add(time.Duration(i) * time.Minute), Actor: models.RunActor{Via: models.ViaCLI, User: "alice"}}
+		if err := reg.RecordRun("api", run); err != nil {
+			t.Fatalf("RecordRun: %v", err)
+		}
+	}
+	last := 100 + models.MaxRuns + 1
+	_ = reg.FinishRun("api", last, "stopped", start.Add(20*time.Minute))
+	// The supervisor's exit record for the same PID arrives afterwards.
+	_ = reg.FinishRun("api", last, "exited 143 (SIGTERM)", start.Add(21*time.Minute))
+
+	svc := reg.GetService("api")
+	if len(svc.Runs) != models.MaxRuns || svc.Runs[0].PID != 102 {
+		t.Fatalf("runs = %d starting at PID %d, want %d starting at 102", len(svc.Runs), svc.Runs[0].PID, models.MaxRuns)
+	}
+	got := describeRun(svc.RecentRuns(1)[0], false, start.Add(30*time.Minute))
+	want := "started 19m ago by alice via cli, PID 111: stopped after 9m0s"
+	if got != want {
+		t.Fatalf("describeRun = %q, want %q", got, want)
+	}
+}
+
+func TestRunActorString(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		actor models.RunActor
+		want  string
+	}{
+		{models.RunActor{Via: models.ViaCLI, User: "alice"}, "alice via cli"},
+		{models.RunActor{Via: models.ViaTUI, User: "alice", Agent: "claude"}, "claude (agent) as alice via tui"},
+		{models.RunActor{Via: models.ViaWatch}, "via watch"},
+	}
+	for _, tc := range cases {
+		if got := tc.actor.String(); got != tc.want {
+			t.Fatalf("String() = %q, want %q", got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/schedule"
+)
+
+// schedulerLockFile is held by the one devpt process that runs schedules,
+// so that a TUI and `devpt watch` open at the same time don't both start
+// the same run.
+const schedulerLockFile = "scheduler.lock"
+
+// scheduler is the state of the schedules run by this process.
+type scheduler struct {
+	lock *os.File
+
+	mu   sync.Mutex
+	busy map[string]bool   // jobs whose scheduled run is still going
+	errs map[string]string // why the last scheduled run could not start
+}
+
+func (s *scheduler) claim(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.busy[key] {
+		return false
+	}
+	if s.busy == nil {
+		s.busy = make(map[string]bool)
+	}
+	s.busy[key] = true
+	return true
+}
+
+func (s *scheduler) done(key string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.busy, key)
+	if s.errs == nil {
+		s.errs = make(map[string]string)
+	}
+	if err != nil {
+		s.errs[key] = err.Error()
+	} else {
+		delete(s.errs, key)
+	}
+}
+
+func (s *scheduler) running(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.busy[key]
+}
+
+func (s *scheduler) lastErr(key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.errs[key]
+}
+
+func (s *scheduler) release() {
+	if s.lock != nil {
+		s.lock.Close()
+		s.lock = nil
+	}
+}
+
+// holdSchedulerLock takes the scheduler lock unless another devpt process
+// holds it. Once taken it is kept until Close.
+func (a *App) holdSchedulerLock() bool {
+	if a.sched.lock != nil {
+		return true
+	}
+	f, err := os.OpenFile(filepath.Join(a.config.ConfigDir, schedulerLockFile), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return false
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		return false
+	}
+	a.sched.lock = f
+	return true
+}
+
+// nextScheduled returns when a schedule fires next: after its last run, or
+// after created when it never ran. It returns the zero time for invalid or
+// never-matching schedules.
+func nextScheduled(spec string, last *time.Time, created time.Time) time.Time {
+	s, err := schedule.Parse(spec)
+	if err != nil {
+		return time.Time{}
+	}
+	base := created
+	if last != nil {
+		base = *last
+	}
+	return s.Next(base)
+}
+
+// runDueSchedules starts the scheduled jobs and services that are due at
+// now. It is called from the TUI and `devpt watch` loops; only the process
+// holding the scheduler lock acts. Jobs run in the background, and a job
+// whose previous scheduled run is still going is not started again.
+// Services are restarted in place.
+func (a *App) runDueSchedules(now time.Time) {
+	if !a.holdSchedulerLock() {
+		return
+	}
+	a.startDueSchedules(now)
+}
+
+func (a *App) startDueSchedules(now time.Time) {
+	for _, job := range a.registry.ListJobs() {
+		if job.Schedule == "" {
+			continue
+		}
+		next := nextScheduled(job.Schedule, job.LastScheduled, job.CreatedAt)
+		key := "job:" + job.Name
+		if next.IsZero() || now.Before(next) || !a.sched.claim(key) {
+			continue
+		}
+		if err := a.registry.MarkJobScheduled(job.Name, now); err != nil {
+			a.sched.done(key, err)
+			continue
+		}
+		go func(job *models.Job) {
+			_, err := a.runJob(job, nil, models.JobRun{Scheduled: true}, nil)
+			a.sched.done(key, err)
+		}(job)
+	}
+
+	for _, svc := range a.registry.ListServices() {
+		if svc.Schedule == "" || svc.Ephemeral {
+			continue
+		}
+		next := nextScheduled(svc.Schedule, svc.LastScheduled, svc.CreatedAt)
+		key := "service:" + svc.Name
+		if next.IsZero() || now.Before(next) || !a.sched.claim(key) {
+			continue
+		}
+		err := a.registry.MarkServiceScheduled(svc.Name, now)
+		if err == nil {
+			err = a.restartFor(svc, models.ViaSchedule)
+		}
+		a.sched.done(key, err)
+	}
+}
+
+// restartFor restarts svc on behalf of via (its schedule or a resource
+// limit), or starts it when it is not running.
+func (a *App) restartFor(svc *models.ManagedService, via string) error {
+	restart := false
+	if a.lastPIDState(svc) == pidLive {
+		pid := *svc.LastPID
+		if err := a.stopProcess(svc, pid, StopOptions{}); err != nil {
+			return fmt.Errorf("failed to stop service: %w", err)
+		}
+		if err := a.registry.ClearServicePID(svc.Name); err != nil {
+			return fmt.Errorf("failed to clear PID: %w", err)
+		}
+		a.emitStopped(svc.Name, pid)
+		restart = true
+	}
+	_, err := a.launch(svc, StartOptions{restart: restart, via: via})
+	return err
+}
+
+// scheduledEntry describes a scheduled job or service.
+type scheduledEntry struct {
+	Kind     string // "job" or "service"
+	Name     string
+	Schedule string
+	Next     time.Time
+	Last     string // outcome of the last scheduled run; empty if none
+}
+
+// scheduledEntries lists the scheduled jobs and services, soonest first.
+func (a *App) scheduledEntries(now time.Time) []scheduledEntry {
+	var entries []scheduledEntry
+	for _, job := range a.registry.ListJobs() {
+		if job.Schedule == "" {
+			continue
+		}
+		e := scheduledEntry{Kind: "job", Name: job.Name, Schedule: job.Schedule, Next: nextScheduled(job.Schedule, job.LastScheduled, job.CreatedAt)}
+		switch {
+		case a.sched.running("job:" + job.Name):
+			e.Last = "running"
+		case a.sched.lastErr("job:"+job.Name) != "":
+			e.Last = "✗ " + a.sched.lastErr("job:"+job.Name)
+		default:
+			for _, run := range a.registry.RecentJobRuns(job.Name, models.MaxJobRuns) {
+				if run.Scheduled {
+					e.Last = formatJobRun(run, now)
+					break
+				}
+			}
+		}
+		entries = append(entries, e)
+	}
+	for _, svc := range a.registry.ListServices() {
+		if svc.Schedule == "" || svc.Ephemeral {
+			continue
+		}
+		e := scheduledEntry{Kind: "service", Name: svc.Name, Schedule: svc.Schedule, Next: nextScheduled(svc.Schedule, svc.LastScheduled, svc.CreatedAt)}
+		if msg := a.sched.lastErr("service:" + svc.Name); msg != "" {
+			e.Last = "✗ " + msg
+		} else {
+			for _, run := range svc.RecentRuns(models.MaxRuns) {
+				if run.Actor.Via != models.ViaSchedule {
+					continue
+				}
+				e.Last = "restarted " + formatAgo(now.Sub(run.StartedAt))
+				if run.Outcome != "" {
+					e.Last += ", " + run.Outcome
+				}
+				break
+			}
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].Next.Equal(entries[j].Next) {
+			return entries[i].Next.Before(entries[j].Next)
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+// formatScheduledEntry renders an entry as e.g.
+// "job warm-cache (every 15m): next in 4m; last ✓ exited 0 in 1.2s, 11m ago".
+func formatScheduledEntry(e scheduledEntry, now time.Time) string {
+	next := "never"
+	switch {
+	case e.Next.IsZero():
+	case e.Next.After(now):
+		next = "in " + formatUntil(e.Next.Sub(now))
+	default:
+		next = "due"
+	}
+	line := fmt.Sprintf("%s %s (%s): next %s", e.Kind, e.Name, e.Schedule, next)
+	if e.Last != "" {
+		line += "; last " + e.Last
+	}
+	return line
+}
+
+// formatUntil renders a coarse duration such as "45s", "4m" or "3h".
+func formatUntil(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+// ScheduleCmd sets the schedule of a registered job or service; "off"
+// clears it. A name that is both a job and a service refers to the job.
+func (a *App) ScheduleCmd(name, spec string) error {
+	if spec == "off" {
+		spec = ""
+	} else if _, err := schedule.Parse(spec); err != nil {
+		return err
+	}
+	switch {
+	case a.registry.GetJob(name) != nil:
+		if err := a.registry.SetJobSchedule(name, spec); err != nil {
+			return err
+		}
+	case a.registry.GetService(name) != nil:
+		if a.registry.GetService(name).Ephemeral {
+			return errEphemeral(name)
+		}
+		if err := a.registry.SetServiceSchedule(name, spec); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("no job or service named %q: %w", name, ErrServiceNotFound)
+	}
+	if spec == "" {
+		fmt.Fprintf(a.out(), "Schedule of %q cleared\n", name)
+	} else {
+		fmt.Fprintf(a.out(), "%q scheduled %s; schedules run while the TUI or devpt watch is open\n", name, spec)
+	}
+	return nil
+}
+
+// ScheduleListCmd prints the scheduled jobs and services.
+func (a *App) ScheduleListCmd() error {
+	now := time.Now()
+	entries := a.scheduledEntries(now)
+	if len(entries) == 0 {
+		fmt.Fprintln(a.out(), "Nothing is scheduled")
+		return nil
+	}
+	for _, e := range entries {
+		fmt.Fprintln(a.out(), formatScheduledEntry(e, now))
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/process"
+	"github.com/devports/devpt/pkg/registry"
+)
+
+func TestStartDueSchedulesRunsDueJobsOnce(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	app := &App{
+		registry: registry.NewRegistry(filepath.Join(dir, "registry.json")),
+		jobLogs:  process.NewManager(filepath.Join(dir, "job-logs")),
+	}
+	for _, job := range []*models.Job{
+		{Name: "warm", CWD: dir, Command: "echo warmed", Schedule: "every 10m"},
+		{Name: "nightly", CWD: dir, Command: "echo seeded", Schedule: "0 3 * * *"},
+	} {
+		if err := app.registry.AddJob(job); err != nil {
+			t.Fatalf("AddJob: %v", err)
+		}
+	}
+	// Both jobs were registered at created; warm is due 10 minutes later,
+	// nightly not before 03:00.
+	created := time.Date(2026, 3, 11, 10, 0, 0, 0, time.Local)
+	for _, job := range app.registry.ListJobs() {
+		job.CreatedAt = created
+	}
+
+	now := created.Add(11 * time.Minute)
+	app.startDueSchedules(now)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(app.registry.RecentJobRuns("", 10)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("scheduled job did not run")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for app.sched.running("job:warm") {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	runs := app.registry.RecentJobRuns("", 10)
+	if len(runs) != 1 || runs[0].Job != "warm" || !runs[0].Scheduled || !runs[0].Succeeded() {
+		t.Fatalf("expected one scheduled run of warm, got %+v", runs)
+	}
+	if last := app.registry.GetJob("warm").LastScheduled; last == nil || !last.Equal(now) {
+		t.Fatalf("expected LastScheduled %s, got %v", now, last)
+	}
+
+	// Not due again until 10 minutes after that run.
+	app.startDueSchedules(now.Add(5 * time.Minute))
+	time.Sleep(50 * time.Millisecond)
+	if n := len(app.registry.RecentJobRuns("", 10)); n != 1 {
+		t.Fatalf("expected no new run before the interval passed, got %d runs", n)
+	}
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// serveShutdownTimeout is how long an interrupted server waits for the
+// requests in flight.
+const serveShutdownTimeout = 2 * time.Second
+
+// serveLocal listens on addr and serves srv there, over HTTPS when it has a
+// TLSConfig, until interrupted. It warns when addr is reachable from the
+// network, saying that what does risk, e.g. "the API" and "anyone there can
+// read logs". ready, when set, is called with the address listened on
+// before serving, e.g. to print it.
+func (a *App) serveLocal(addr string, srv *http.Server, what, risk string, ready func(net.Addr)) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		if ip := net.ParseIP(host); host == "" || (ip != nil && !ip.IsLoopback()) {
+			fmt.Fprintf(a.errOut(), "Warning: %s is reachable from your network on %s and %s\n", what, addr, risk)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	if ready != nil {
+		ready(ln.Addr())
+	}
+	if srv.ReadHeaderTimeout == 0 {
+		srv.ReadHeaderTimeout = 10 * time.Second
+	}
+	if srv.TLSConfig != nil {
+		err = srv.ServeTLS(ln, "", "")
+	} else {
+		err = srv.Serve(ln)
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
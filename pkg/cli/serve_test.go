@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestServeLocalWarnsWhenExposed(t *testing.T) {
+	t.Parallel()
+	for addr, exposed := range map[string]bool{"127.0.0.1:0": false, "0.0.0.0:0": true} {
+		var errOut bytes.Buffer
+		app := &App{}
+		app.SetOutput(io.Discard, &errOut)
+		srv := &http.Server{Handler: http.NotFoundHandler()}
+		listened := false
+		err := app.serveLocal(addr, srv, "the test server", "answers 404", func(net.Addr) {
+			listened = true
+			srv.Close() // Serve returns right away
+		})
+		if err != nil || !listened {
+			t.Fatalf("%s: serveLocal = %v, listened %v", addr, err, listened)
+		}
+		want := "Warning: the test server is reachable from your network on " + addr + " and answers 404"
+		if got := strings.Contains(errOut.String(), want); got != exposed {
+			t.Errorf("%s: warned = %v, want %v: %q", addr, got, exposed, errOut.String())
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"syscall"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/process"
+)
+
+// signalTarget resolves a service name or PID to the process to signal. For
+// managed services that is the process group devpt started, so the signal
+// reaches every process of the service.
+func (a *App) signalTarget(target string) (int, string, error) {
+	if svc := a.registry.GetService(target); svc != nil {
+		if a.lastPIDState(svc) == pidLive {
+			return *svc.LastPID, svc.Name, nil
+		}
+		pid, err := a.validatedManagedPID(svc)
+		if err != nil {
+			return 0, "", err
+		}
+		if pid == 0 {
+			return 0, "", fmt.Errorf("service %q is not running", svc.Name)
+		}
+		return pid, svc.Name, nil
+	}
+	pid, err := strconv.Atoi(target)
+	if err != nil || pid <= 0 {
+		return 0, "", fmt.Errorf("invalid service name or PID: %s: %w", target, ErrServiceNotFound)
+	}
+	if !a.processManager.IsRunning(pid) {
+		return 0, "", fmt.Errorf("no process with PID %d", pid)
+	}
+	return pid, "", nil
+}
+
+// isPaused reports whether a live managed service was paused.
+func (a *App) isPaused(srv *models.ServerInfo) bool {
+	switch srv.Status {
+	case "running", "starting", "ready":
+	default:
+		return false
+	}
+	svc := srv.ManagedService
+	if svc.LastPID != nil && *svc.LastPID > 0 && a.processManager.IsRunning(*svc.LastPID) {
+		return a.processManager.IsPaused(*svc.LastPID)
+	}
+	return srv.ProcessRecord != nil && a.processManager.IsPaused(srv.ProcessRecord.PID)
+}
+
+// SignalCmd sends sig to a service or PID, e.g. SIGHUP to servers that
+// reload their configuration on it.
+func (a *App) SignalCmd(target string, sig syscall.Signal) error {
+	pid, name, err := a.signalTarget(target)
+	if err != nil {
+		return err
+	}
+	if err := a.processManager.Signal(pid, sig); err != nil {
+		return err
+	}
+	if name != "" {
+		fmt.Fprintf(a.out(), "Sent %s to %q (PID %d)\n", process.SignalName(sig), name, pid)
+	} else {
+		fmt.Fprintf(a.out(), "Sent %s to PID %d\n", process.SignalName(sig), pid)
+	}
+	return nil
+}
+
+// PauseCmd freezes a service or PID with SIGSTOP until ResumeCmd.
+func (a *App) PauseCmd(target string) error {
+	pid, err := a.setPaused(target, true)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out(), "Paused %s (PID %d); resume it with devpt resume %s\n", target, pid, target)
+	return nil
+}
+
+// ResumeCmd continues a service or PID paused by PauseCmd.
+func (a *App) ResumeCmd(target string) error {
+	pid, err := a.setPaused(target, false)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out(), "Resumed %s (PID %d)\n", target, pid)
+	return nil
+}
+
+func (a *App) setPaused(target string, paused bool) (int, error) {
+	pid, _, err := a.signalTarget(target)
+	if err != nil {
+		return 0, err
+	}
+	if a.processManager.IsPaused(pid) == paused {
+		if paused {
+			return 0, fmt.Errorf("%s is already paused", target)
+		}
+		return 0, fmt.Errorf("%s is not paused", target)
+	}
+	sig := syscall.SIGCONT
+	if paused {
+		sig = syscall.SIGSTOP
+	}
+	if err := a.processManager.Signal(pid, sig); err != nil {
+		return 0, err
+	}
+	return pid, nil
+}
@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// snapshot is the set of managed services that were running when it was
+// saved, so the same set can be brought back up later.
+type snapshot struct {
+	Name     string            `json:"name"`
+	SavedAt  time.Time         `json:"saved_at"`
+	Services []snapshotService `json:"services"`
+}
+
+// snapshotService is a service that was running, with the ports and env of
+// its run.
+type snapshotService struct {
+	Name    string   `json:"name"`
+	Ports   []int    `json:"ports,omitempty"`    // declared ports
+	RunPort int      `json:"run_port,omitempty"` // automatic port, reused when still free
+	Env     []string `json:"env,omitempty"`
+}
+
+// validSnapshotName rejects names that cannot be a file in the snapshots
+// directory.
+func validSnapshotName(name string) error {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("invalid snapshot name %q", name)
+	}
+	return nil
+}
+
+func (a *App) snapshotPath(name string) string {
+	return filepath.Join(a.config.SnapshotsDir, name+".json")
+}
+
+// takeSnapshot records the managed services running now.
+func (a *App) takeSnapshot(name string, now time.Time) snapshot {
+	snap := snapshot{Name: name, SavedAt: now}
+	services := a.registry.ListServices()
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	for _, svc := range services {
+		if svc.Ephemeral || a.lastPIDState(svc) != pidLive {
+			continue
+		}
+		snap.Services = append(snap.Services, snapshotService{
+			Name:    svc.Name,
+			Ports:   svc.Ports,
+			RunPort: svc.RunPort,
+			Env:     svc.Env,
+		})
+	}
+	return snap
+}
+
+// saveSnapshot writes snap to the snapshots directory, replacing a
+// snapshot of the same name.
+func (a *App) saveSnapshot(snap snapshot) error {
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(a.config.SnapshotsDir, 0755); err != nil {
+		return err
+	}
+	path := a.snapshotPath(snap.Name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadSnapshot reads the snapshot called name.
+func (a *App) loadSnapshot(name string) (snapshot, error) {
+	var snap snapshot
+	if err := validSnapshotName(name); err != nil {
+		return snap, err
+	}
+	data, err := os.ReadFile(a.snapshotPath(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return snap, fmt.Errorf("snapshot %q not found", name)
+	}
+	if err != nil {
+		return snap, err
+	}
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return snap, fmt.Errorf("snapshot %q: %w", name, err)
+	}
+	snap.Name = name
+	return snap, nil
+}
+
+// SnapshotSaveCmd saves which managed services are running, with their
+// ports and env, as the snapshot called name.
+func (a *App) SnapshotSaveCmd(name string) error {
+	if err := validSnapshotName(name); err != nil {
+		return err
+	}
+	snap := a.takeSnapshot(name, time.Now())
+	if len(snap.Services) == 0 {
+		return fmt.Errorf("no managed services are running")
+	}
+	if err := a.saveSnapshot(snap); err != nil {
+		return fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	names := make([]string, len(snap.Services))
+	for i, s := range snap.Services {
+		names[i] = s.Name
+	}
+	fmt.Fprintf(a.out(), "Snapshot %q saved with %d service(s): %s\n", name, len(names), strings.Join(names, ", "))
+	return nil
+}
+
+// SnapshotRestoreCmd starts the services of the snapshot called name that
+// are not running, with the env they had and, for automatic ports, the
+// same port when it is free. Services no longer registered are skipped.
+func (a *App) SnapshotRestoreCmd(name string) error {
+	snap, err := a.loadSnapshot(name)
+	if err != nil {
+		return err
+	}
+	started, running := 0, 0
+	var failed []string
+	for _, s := range snap.Services {
+		svc := a.registry.GetService(s.Name)
+		if svc == nil {
+			fmt.Fprintf(a.errOut(), "Warning: service %q is no longer registered; skipped\n", s.Name)
+			continue
+		}
+		if a.lastPIDState(svc) == pidLive {
+			fmt.Fprintf(a.out(), "Service %q is already running (PID %d)\n", svc.Name, *svc.LastPID)
+			running++
+			continue
+		}
+		if !slices.Equal(s.Ports, svc.Ports) {
+			fmt.Fprintf(a.errOut(), "Warning: ports of %q changed from %v to %v since the snapshot\n", svc.Name, s.Ports, svc.Ports)
+		}
+		fmt.Fprintf(a.out(), "Starting service %q...\n", svc.Name)
+		opts := StartOptions{env: envOverrides(s.Env, svc.Env), port: s.RunPort, via: models.ViaSnapshot}
+		pid, err := a.launch(svc, opts)
+		if err != nil {
+			fmt.Fprintf(a.errOut(), "Error: %s: %v\n", svc.Name, err)
+			failed = append(failed, svc.Name)
+			continue
+		}
+		fmt.Fprintf(a.out(), "Service %q started with PID %d\n", svc.Name, pid)
+		started++
+	}
+	fmt.Fprintf(a.out(), "Snapshot %q restored: %d started, %d already running\n", name, started, running)
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to start %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// envOverrides returns the entries of saved that the current env of the
+// service does not have, so the values of the snapshot win.
+func envOverrides(saved, current []string) []string {
+	var out []string
+	for _, kv := range saved {
+		if !slices.Contains(current, kv) {
+			out = append(out, kv)
+		}
+	}
+	return out
+}
+
+// SnapshotListCmd prints the saved snapshots.
+func (a *App) SnapshotListCmd() error {
+	entries, err := os.ReadDir(a.config.SnapshotsDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	var snaps []snapshot
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() {
+			continue
+		}
+		snap, err := a.loadSnapshot(name)
+		if err != nil {
+			fmt.Fprintf(a.errOut(), "Warning: %v\n", err)
+			continue
+		}
+		snaps = append(snaps, snap)
+	}
+	if len(snaps) == 0 {
+		fmt.Fprintln(a.out(), "No snapshots")
+		return nil
+	}
+	now := time.Now()
+	w := tabwriter.NewWriter(a.out(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSAVED\tSERVICES")
+	for _, snap := range snaps {
+		names := make([]string, len(snap.Services))
+		for i, s := range snap.Services {
+			names[i] = s.Name
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", snap.Name, formatAgo(now.Sub(snap.SavedAt)), strings.Join(names, ", "))
+	}
+	return w.Flush()
+}
+
+// SnapshotRemoveCmd deletes the snapshot called name.
+func (a *App) SnapshotRemoveCmd(name string) error {
+	if _, err := a.loadSnapshot(name); err != nil {
+		return err
+	}
+	if err := os.Remove(a.snapshotPath(name)); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out(), "Snapshot %q removed\n", name)
+	return nil
+}
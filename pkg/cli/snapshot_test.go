@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/process"
+	"github.com/devports/devpt/pkg/registry"
+)
+
+func TestSnapshotRecordsRunningServices(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	live := os.Getpid()
+	reg := registry.NewRegistry(filepath.Join(dir, "registry.json"))
+	for _, svc := range []*models.ManagedService{
+		{Name: "web", CWD: dir, Command: "x", AutoPort: true, RunPort: 4123, Env: []string{"MODE=dev"}, LastPID: &live},
+		{Name: "api", CWD: dir, Command: "x", Ports: []int{3000}, LastPID: &live},
+		{Name: "db", CWD: dir, Command: "x", Ports: []int{5432}},
+		{Name: "node", CWD: dir, Command: "x", Ephemeral: true, LastPID: &live},
+	} {
+		if err := reg.AddService(svc); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+	app := &App{
+		registry:       reg,
+		processManager: process.NewManager(filepath.Join(dir, "logs")),
+		config:         models.ConfigPaths{SnapshotsDir: filepath.Join(dir, "snapshots")},
+	}
+
+	saved := app.takeSnapshot("work", time.Now().Truncate(time.Second))
+	want := []snapshotService{
+		{Name: "api", Ports: []int{3000}},
+		{Name: "web", RunPort: 4123, Env: []string{"MODE=dev"}},
+	}
+	if !reflect.DeepEqual(saved.Services, want) {
+		t.Fatalf("services = %+v, want %+v", saved.Services, want)
+	}
+
+	if err := app.saveSnapshot(saved); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	loaded, err := app.loadSnapshot("work")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !loaded.SavedAt.Equal(saved.SavedAt) || !reflect.DeepEqual(loaded.Services, saved.Services) {
+		t.Fatalf("loaded %+v, saved %+v", loaded, saved)
+	}
+	if _, err := app.loadSnapshot("home"); err == nil {
+		t.Fatal("expected an error for a missing snapshot")
+	}
+	for _, name := range []string{"", "../work", ".hidden"} {
+		if err := validSnapshotName(name); err == nil {
+			t.Fatalf("expected %q to be rejected", name)
+		}
+	}
+}
+
+func TestEnvOverrides(t *testing.T) {
+	t.Parallel()
+
+	got := envOverrides([]string{"MODE=dev", "DEBUG=1"}, []string{"MODE=dev", "DEBUG=0"})
+	if want := []string{"DEBUG=1"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("envOverrides = %q, want %q", got, want)
+	}
+	if got := envOverrides([]string{"MODE=dev"}, []string{"MODE=dev"}); got != nil {
+		t.Fatalf("envOverrides of the same env = %q", got)
+	}
+}
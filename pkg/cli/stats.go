@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/devports/devpt/pkg/events"
+	"github.com/devports/devpt/pkg/health"
+	"github.com/devports/devpt/pkg/history"
+	"github.com/devports/devpt/pkg/models"
+)
+
+// DefaultStatsPeriod is how far back devpt stats looks by default.
+const DefaultStatsPeriod = 7 * 24 * time.Hour
+
+// serviceStats are the totals of one service over a period.
+type serviceStats struct {
+	Service  string `json:"service"`
+	Runs     int    `json:"runs"`
+	Restarts int    `json:"restarts"`
+	Crashes  int    `json:"crashes"`
+	// AvgUptime is the mean length of the runs that ended in the period
+	// and of the current one; zero when there were none.
+	AvgUptime time.Duration `json:"-"`
+	LogBytes  int64         `json:"log_bytes"`
+	// HealthMs is the mean response time of the health checks recorded in
+	// the events log; zero without any.
+	HealthMs      int `json:"health_ms,omitempty"`
+	HealthSamples int `json:"health_samples"`
+
+	uptimes []time.Duration
+}
+
+// MarshalJSON writes AvgUptime in seconds.
+func (s serviceStats) MarshalJSON() ([]byte, error) {
+	type plain serviceStats
+	return json.Marshal(struct {
+		plain
+		AvgUptimeSeconds int64 `json:"avg_uptime_seconds,omitempty"`
+	}{plain(s), int64(s.AvgUptime.Seconds())})
+}
+
+// addHistory counts the entries of the service's history.
+func (s *serviceStats) addHistory(entries []history.Entry) {
+	for _, e := range entries {
+		switch e.Action {
+		case history.Start:
+			s.Runs++
+		case history.Restart:
+			s.Runs++
+			s.Restarts++
+		case history.Crash:
+			s.Crashes++
+		}
+		switch e.Action {
+		case history.Stop, history.Exit, history.Crash, history.Lost:
+			if e.Duration > 0 {
+				s.uptimes = append(s.uptimes, e.Duration.Std())
+			}
+		}
+	}
+}
+
+// addHealth averages the latency of health.changed events for the service
+// that found it responding.
+func (s *serviceStats) addHealth(evs []events.Event) {
+	total := 0
+	for _, ev := range evs {
+		if ev.Type != events.HealthChanged || ev.Service != s.Service {
+			continue
+		}
+		switch health.HealthStatus(ev.Data["to"]) {
+		case health.HealthOK, health.HealthSlow:
+		default:
+			continue
+		}
+		ms, err := strconv.Atoi(ev.Data["ms"])
+		if err != nil {
+			continue
+		}
+		total += ms
+		s.HealthSamples++
+	}
+	if s.HealthSamples > 0 {
+		s.HealthMs = total / s.HealthSamples
+	}
+}
+
+// finish computes the averages.
+func (s *serviceStats) finish() {
+	if len(s.uptimes) == 0 {
+		return
+	}
+	var total time.Duration
+	for _, d := range s.uptimes {
+		total += d
+	}
+	s.AvgUptime = total / time.Duration(len(s.uptimes))
+}
+
+// StatsCmd prints the restarts, runs, crashes, average uptime, log volume
+// and mean health latency of each registered service, or of name, over
+// the period before now.
+func (a *App) StatsCmd(name string, period time.Duration, asJSON bool) error {
+	services := a.registry.ListServices()
+	if name != "" {
+		svc := a.registry.GetService(name)
+		if svc == nil {
+			return errServiceNotFound(name)
+		}
+		services = []*models.ManagedService{svc}
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+
+	now := time.Now()
+	since := now.Add(-period)
+	evs, err := a.events.Since(since)
+	if err != nil {
+		fmt.Fprintf(a.errOut(), "Warning: %v\n", err)
+	}
+	logs := make(map[string]int64)
+	for _, u := range a.logUsage().Services {
+		logs[u.Name] = u.Size
+	}
+
+	var stats []serviceStats
+	for _, svc := range services {
+		s := serviceStats{Service: svc.Name, LogBytes: logs[svc.Name]}
+		entries, err := a.history.Since(svc.Name, since)
+		if err != nil {
+			fmt.Fprintf(a.errOut(), "Warning: %s: %v\n", svc.Name, err)
+		}
+		s.addHistory(entries)
+		if a.lastPIDState(svc) == pidLive {
+			if start, ok := runStart(svc, *svc.LastPID); ok {
+				if start.Before(since) {
+					start = since
+				}
+				s.uptimes = append(s.uptimes, now.Sub(start))
+			}
+		}
+		s.addHealth(evs)
+		s.finish()
+		stats = append(stats, s)
+	}
+
+	if asJSON {
+		for _, s := range stats {
+			data, err := json.Marshal(s)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(a.out(), string(data))
+		}
+		return nil
+	}
+	if len(stats) == 0 {
+		fmt.Fprintln(a.out(), "No managed services")
+		return nil
+	}
+	fmt.Fprintf(a.out(), "Last %s\n", describePeriod(period))
+	w := tabwriter.NewWriter(a.out(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tRUNS\tRESTARTS\tCRASHES\tAVG UPTIME\tLOGS\tHEALTH")
+	var total serviceStats
+	for _, s := range stats {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\t%s\t%s\n", s.Service, s.Runs, s.Restarts, s.Crashes, formatUptime(s.AvgUptime), formatBytes(s.LogBytes), formatHealthMean(s))
+		total.Runs += s.Runs
+		total.Restarts += s.Restarts
+		total.Crashes += s.Crashes
+		total.LogBytes += s.LogBytes
+	}
+	if len(stats) > 1 {
+		fmt.Fprintf(w, "total\t%d\t%d\t%d\t\t%s\n", total.Runs, total.Restarts, total.Crashes, formatBytes(total.LogBytes))
+	}
+	return w.Flush()
+}
+
+// describePeriod renders a period as e.g. "7 days", "day" or "12h0m0s".
+func describePeriod(d time.Duration) string {
+	switch {
+	case d == 24*time.Hour:
+		return "day"
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%d days", d/(24*time.Hour))
+	}
+	return d.String()
+}
+
+// formatUptime renders a mean uptime coarsely, e.g. "45s", "12m", "3h20m"
+// or "2d4h"; "-" when there were no runs.
+func formatUptime(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "-"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%dd%dh", int(d.Hours()/24), int(d.Hours())%24)
+}
+
+// formatHealthMean renders the mean health latency with its sample count,
+// e.g. "42ms (12)".
+func formatHealthMean(s serviceStats) string {
+	if s.HealthSamples == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%dms (%d)", s.HealthMs, s.HealthSamples)
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/devports/devpt/pkg/events"
+	"github.com/devports/devpt/pkg/history"
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestServiceStats(t *testing.T) {
+	t.Parallel()
+	s := serviceStats{Service: "api"}
+	s.addHistory([]history.Entry{
+		{Action: history.Start},
+		{Action: history.Crash, Duration: models.Duration(10 * time.Minute)},
+		{Action: history.Restart},
+		{Action: history.Stop, Duration: models.Duration(30 * time.Minute)},
+		{Action: history.Restart},
+	})
+	s.addHealth([]events.Event{
+		{Type: events.HealthChanged, Service: "api", Data: map[string]string{"to": "ok", "ms": "20"}},
+		{Type: events.HealthChanged, Service: "api", Data: map[string]string{"to": "slow", "ms": "2500"}},
+		{Type: events.HealthChanged, Service: "api", Data: map[string]string{"to": "down", "ms": "0"}},
+		{Type: events.HealthChanged, Service: "api", Data: map[string]string{"to": "ok"}},
+		{Type: events.HealthChanged, Service: "web", Data: map[string]string{"to": "ok", "ms": "5"}},
+		{Type: events.ServiceStarted, Service: "api"},
+	})
+	s.finish()
+	if s.Runs != 3 || s.Restarts != 2 || s.Crashes != 1 {
+		t.Errorf("runs %d, restarts %d, crashes %d; want 3, 2, 1", s.Runs, s.Restarts, s.Crashes)
+	}
+	if s.AvgUptime != 20*time.Minute {
+		t.Errorf("avg uptime = %v, want 20m", s.AvgUptime)
+	}
+	if s.HealthSamples != 2 || s.HealthMs != 1260 {
+		t.Errorf("health = %dms over %d checks, want 1260ms over 2", s.HealthMs, s.HealthSamples)
+	}
+	if got := formatHealthMean(s); got != "1260ms (2)" {
+		t.Errorf("formatHealthMean = %q", got)
+	}
+}
+
+func TestFormatUptime(t *testing.T) {
+	t.Parallel()
+	for d, want := range map[time.Duration]string{
+		0:                            "-",
+		45 * time.Second:             "45s",
+		12 * time.Minute:             "12m",
+		3*time.Hour + 20*time.Minute: "3h20m",
+		52 * time.Hour:               "2d4h",
+	} {
+		if got := formatUptime(d); got != want {
+			t.Errorf("formatUptime(%v) = %q, want %q", d, got, want)
+		}
+	}
+	for d, want := range map[time.Duration]string{DefaultStatsPeriod: "7 days", 24 * time.Hour: "day", 12 * time.Hour: "12h0m0s"} {
+		if got := describePeriod(d); got != want {
+			t.Errorf("describePeriod(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
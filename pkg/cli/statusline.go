@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// StatusLineFormats are the formats devpt statusline --format accepts.
+var StatusLineFormats = []string{"full", "compact"}
+
+// maxStatusPorts is how many ports devpt statusline lists before "+N".
+const maxStatusPorts = 5
+
+// StatusLineCmd prints a one-line summary of the dev servers, such as
+// "● 3 up ▲1 crashed :3000 :8080", for tmux's status-right or a starship
+// custom module. It reads the daemon's cached scan when one is running, so
+// it returns in milliseconds. The compact format leaves out the ports and
+// labels.
+func (a *App) StatusLineCmd(format string) error {
+	if format != "" && !contains(StatusLineFormats, format) {
+		return fmt.Errorf("invalid format %q (want %s)", format, strings.Join(StatusLineFormats, ", "))
+	}
+	servers, err := a.discoverServers()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(a.out(), statusLine(servers, format == "compact", a.useTextIcons()))
+	return nil
+}
+
+// statusCounts counts the servers that are up and those that crashed, and
+// returns the ports of the running ones, leaving out databases and brokers.
+func statusCounts(servers []*models.ServerInfo) (up, crashed int, ports []int) {
+	seen := make(map[int]bool)
+	for _, srv := range servers {
+		if isInfraServer(srv) {
+			continue
+		}
+		switch {
+		case isCrashStatus(srv.Status):
+			crashed++
+			continue
+		case srv.Status == "stopped":
+			continue
+		}
+		up++
+		if rec := srv.ProcessRecord; rec != nil && rec.Port > 0 && !seen[rec.Port] {
+			seen[rec.Port] = true
+			ports = append(ports, rec.Port)
+		}
+	}
+	sort.Ints(ports)
+	return up, crashed, ports
+}
+
+// statusLine summarizes servers. text drops the symbols, for status bars
+// without them.
+func statusLine(servers []*models.ServerInfo, compact, text bool) string {
+	up, crashed, ports := statusCounts(servers)
+	mark := "●"
+	if up == 0 {
+		mark = "○"
+	}
+	var parts []string
+	switch {
+	case text:
+		parts = append(parts, fmt.Sprintf("%d up", up))
+		if crashed > 0 {
+			parts = append(parts, fmt.Sprintf("%d crashed", crashed))
+		}
+	case compact:
+		parts = append(parts, mark+strconv.Itoa(up))
+		if crashed > 0 {
+			parts = append(parts, "▲"+strconv.Itoa(crashed))
+		}
+	default:
+		parts = append(parts, fmt.Sprintf("%s %d up", mark, up))
+		if crashed > 0 {
+			parts = append(parts, fmt.Sprintf("▲%d crashed", crashed))
+		}
+	}
+	if !compact {
+		for i, port := range ports {
+			if i == maxStatusPorts {
+				parts = append(parts, fmt.Sprintf("+%d", len(ports)-i))
+				break
+			}
+			parts = append(parts, ":"+strconv.Itoa(port))
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestStatusLine(t *testing.T) {
+	t.Parallel()
+
+	listener := func(port int, status string) *models.ServerInfo {
+		return &models.ServerInfo{ProcessRecord: &models.ProcessRecord{PID: port, Port: port}, Status: status}
+	}
+	servers := []*models.ServerInfo{
+		listener(8080, "running"),
+		listener(3000, "ready"),
+		{ManagedService: &models.ManagedService{Name: "worker"}, Status: "running"},
+		{ManagedService: &models.ManagedService{Name: "api"}, Status: "crash-looping"},
+		{ManagedService: &models.ManagedService{Name: "docs"}, Status: "stopped"},
+		{ProcessRecord: &models.ProcessRecord{PID: 9, Port: 5432, Infra: "postgres"}, Status: "running"},
+	}
+	cases := []struct {
+		compact, text bool
+		want          string
+	}{
+		{want: "● 3 up ▲1 crashed :3000 :8080"},
+		{compact: true, want: "●3 ▲1"},
+		{text: true, want: "3 up 1 crashed :3000 :8080"},
+		{compact: true, text: true, want: "3 up 1 crashed"},
+	}
+	for _, tc := range cases {
+		if got := statusLine(servers, tc.compact, tc.text); got != tc.want {
+			t.Errorf("compact=%v text=%v: got %q, want %q", tc.compact, tc.text, got, tc.want)
+		}
+	}
+
+	if got := statusLine(nil, false, false); got != "○ 0 up" {
+		t.Errorf("no servers: got %q", got)
+	}
+	var many []*models.ServerInfo
+	for port := 3000; port < 3007; port++ {
+		many = append(many, listener(port, "running"))
+	}
+	if got, want := statusLine(many, false, false), "● 7 up :3000 :3001 :3002 :3003 :3004 +2"; got != want {
+		t.Errorf("many ports: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/devports/devpt/pkg/health"
+	"github.com/devports/devpt/pkg/telemetry"
+)
+
+// TraceCommand starts the span of a command, the parent of the spans of
+// the scans, starts, stops and health checks it makes; the caller ends it
+// with the command's error. Nil while trace export is off.
+func (a *App) TraceCommand(name string) *telemetry.Span {
+	return a.tracer.Root(name)
+}
+
+// traceHealth records a finished health check as a span. Checks that
+// found the server down or timing out are marked failed.
+func (a *App) traceHealth(ctx context.Context, started time.Time, check *health.HealthCheck) {
+	var err error
+	switch check.Status {
+	case health.HealthDown, health.HealthTimeout:
+		err = fmt.Errorf("%s", check.Message)
+	}
+	attrs := []telemetry.Attr{
+		telemetry.String("devpt.health.status", string(check.Status)),
+		telemetry.Int("devpt.health.response_ms", check.ResponseMs),
+	}
+	if check.Port > 0 {
+		attrs = append(attrs, telemetry.Int("devpt.port", check.Port))
+	}
+	a.tracer.Record(ctx, "devpt.health_check", started, err, attrs...)
+}
@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/devports/devpt/pkg/health"
+	"github.com/devports/devpt/pkg/models"
+)
+
+// theme holds the styles the TUI draws with.
+type theme struct {
+	name     string
+	title    lipgloss.Style // screen, panel and form titles
+	text     lipgloss.Style // table rows
+	muted    lipgloss.Style // hints, labels, status and footer
+	accent   lipgloss.Style // command and filter input, focused form field
+	warn     lipgloss.Style // confirmations and warnings
+	danger   lipgloss.Style // errors and services over their limits
+	selected lipgloss.Style // the selected row
+	border   lipgloss.TerminalColor
+	// status colors health results; nil leaves them uncolored.
+	status func(health.HealthStatus) lipgloss.TerminalColor
+}
+
+// healthStyle is the style of a health result.
+func (t *theme) healthStyle(status health.HealthStatus) lipgloss.Style {
+	if t.status == nil {
+		return lipgloss.NewStyle()
+	}
+	return lipgloss.NewStyle().Foreground(t.status(status))
+}
+
+func fg(c lipgloss.TerminalColor) lipgloss.Style { return lipgloss.NewStyle().Foreground(c) }
+
+// adaptive picks light on light terminal backgrounds and dark on dark ones.
+func adaptive(light, dark string) lipgloss.AdaptiveColor {
+	return lipgloss.AdaptiveColor{Light: light, Dark: dark}
+}
+
+// themes are the themes of models.TUIThemes by name. The ANSI colors of the
+// default theme are darkened on light backgrounds, where white rows and
+// bright yellow warnings cannot be read.
+var themes = map[string]*theme{
+	"default": {
+		name:     "default",
+		title:    fg(lipgloss.Color("12")).Bold(true),
+		text:     fg(adaptive("0", "15")),
+		muted:    fg(adaptive("243", "8")),
+		accent:   fg(adaptive("2", "10")),
+		warn:     fg(adaptive("3", "11")),
+		danger:   fg(adaptive("1", "9")),
+		selected: lipgloss.NewStyle().Background(lipgloss.Color("57")).Foreground(lipgloss.Color("15")),
+		border:   adaptive("250", "8"),
+		status: func(s health.HealthStatus) lipgloss.TerminalColor {
+			return lipgloss.Color(health.StatusColor(s))
+		},
+	},
+	"solarized": {
+		name:     "solarized",
+		title:    fg(lipgloss.Color("#268bd2")).Bold(true),
+		text:     fg(adaptive("#657b83", "#839496")),
+		muted:    fg(adaptive("#93a1a1", "#586e75")),
+		accent:   fg(lipgloss.Color("#859900")),
+		warn:     fg(lipgloss.Color("#b58900")),
+		danger:   fg(lipgloss.Color("#dc322f")),
+		selected: lipgloss.NewStyle().Background(adaptive("#eee8d5", "#073642")).Foreground(adaptive("#586e75", "#93a1a1")).Bold(true),
+		border:   adaptive("#eee8d5", "#073642"),
+		status: func(s health.HealthStatus) lipgloss.TerminalColor {
+			switch s {
+			case health.HealthOK:
+				return lipgloss.Color("#859900")
+			case health.HealthSlow:
+				return lipgloss.Color("#b58900")
+			case health.HealthTimeout:
+				return lipgloss.Color("#cb4b16")
+			case health.HealthDown:
+				return lipgloss.Color("#dc322f")
+			}
+			return adaptive("#93a1a1", "#586e75")
+		},
+	},
+	"high-contrast": {
+		name:     "high-contrast",
+		title:    fg(adaptive("4", "14")).Bold(true).Underline(true),
+		text:     fg(adaptive("0", "15")),
+		muted:    fg(adaptive("0", "15")),
+		accent:   fg(adaptive("0", "15")).Bold(true),
+		warn:     fg(adaptive("1", "11")).Bold(true),
+		danger:   fg(adaptive("1", "9")).Bold(true),
+		selected: lipgloss.NewStyle().Reverse(true).Bold(true),
+		border:   adaptive("0", "15"),
+		status: func(s health.HealthStatus) lipgloss.TerminalColor {
+			switch s {
+			case health.HealthOK:
+				return adaptive("2", "10")
+			case health.HealthSlow, health.HealthTimeout:
+				return adaptive("5", "11")
+			case health.HealthDown:
+				return adaptive("1", "9")
+			}
+			return adaptive("0", "15")
+		},
+	},
+	// monochrome sets no colors at all, only bold, underline and reverse.
+	"monochrome": {
+		name:     "monochrome",
+		title:    lipgloss.NewStyle().Bold(true),
+		text:     lipgloss.NewStyle(),
+		muted:    lipgloss.NewStyle(),
+		accent:   lipgloss.NewStyle().Bold(true),
+		warn:     lipgloss.NewStyle().Bold(true),
+		danger:   lipgloss.NewStyle().Bold(true),
+		selected: lipgloss.NewStyle().Reverse(true),
+		border:   lipgloss.NoColor{},
+	},
+}
+
+// noColorRequested reports whether the NO_COLOR convention asks for output
+// without colors: NO_COLOR set to anything but "".
+func noColorRequested(getenv func(string) string) bool {
+	return getenv("NO_COLOR") != ""
+}
+
+// SetTheme selects the TUI theme by name over tui.theme, and turns colors
+// off when noColor is set, as NO_COLOR does.
+func (a *App) SetTheme(name string, noColor bool) error {
+	if !models.ValidTheme(name) {
+		return fmt.Errorf("unknown theme %q (use %s)", name, strings.Join(models.TUIThemes, ", "))
+	}
+	a.theme = name
+	a.noColor = noColor
+	return nil
+}
+
+// tuiTheme returns the theme the TUI draws with: monochrome when colors are
+// off, by --no-color or NO_COLOR in getenv, else the one from --theme, else
+// the one picked in the TUI last time, saved, else tui.theme.
+func (a *App) tuiTheme(getenv func(string) string, saved string) *theme {
+	if a.colorsOff(getenv) {
+		return themes["monochrome"]
+	}
+	name := a.theme
+	if name == "" {
+		name = saved
+	}
+	if name == "" && a.settings != nil {
+		name = a.settings.TUI.Theme
+	}
+	if t, ok := themes[name]; ok {
+		return t
+	}
+	return themes["default"]
+}
+
+// colorsOff reports whether --no-color or NO_COLOR in getenv turned the
+// colors off.
+func (a *App) colorsOff(getenv func(string) string) bool {
+	return a.noColor || noColorRequested(getenv)
+}
+
+// nextTheme switches to the theme after the current one in
+// models.TUIThemes, and remembers it for the next session.
+func (m *topModel) nextTheme() string {
+	if m.app.colorsOff(os.Getenv) {
+		return "Colors are off (--no-color or NO_COLOR)"
+	}
+	name := m.styles().name
+	for i, t := range models.TUIThemes {
+		if t == name {
+			name = models.TUIThemes[(i+1)%len(models.TUIThemes)]
+			break
+		}
+	}
+	m.theme = themes[name]
+	m.savedTheme = name
+	return "Theme: " + name
+}
+
+// styles returns the model's theme; models built without one use the
+// default theme.
+func (m topModel) styles() *theme {
+	if m.theme == nil {
+		return themes["default"]
+	}
+	return m.theme
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestThemesCoverConfigNames(t *testing.T) {
+	t.Parallel()
+	for _, name := range models.TUIThemes {
+		if themes[name] == nil {
+			t.Errorf("theme %q has no styles", name)
+		}
+	}
+}
+
+func TestTUITheme(t *testing.T) {
+	t.Parallel()
+	app := &App{settings: &models.Config{TUI: models.TUISettings{Theme: "solarized"}}}
+	if app.tuiTheme(getenvNone, "") != themes["solarized"] {
+		t.Error("tui.theme is not used")
+	}
+	if app.tuiTheme(getenvNone, "monochrome") != themes["monochrome"] {
+		t.Error("the theme picked last session is not used")
+	}
+	if err := app.SetTheme("high-contrast", false); err != nil {
+		t.Fatal(err)
+	}
+	if app.tuiTheme(getenvNone, "monochrome") != themes["high-contrast"] {
+		t.Error("--theme does not override tui.theme")
+	}
+	if err := app.SetTheme("high-contrast", true); err != nil {
+		t.Fatal(err)
+	}
+	if app.tuiTheme(getenvNone, "monochrome") != themes["monochrome"] {
+		t.Error("--no-color does not turn colors off")
+	}
+	if err := app.SetTheme("neon", false); err == nil {
+		t.Error("unknown theme accepted")
+	}
+}
+
+func getenvNone(string) string { return "" }
+
+func TestNoColorRequested(t *testing.T) {
+	t.Parallel()
+	env := map[string]string{"NO_COLOR": "1"}
+	if !noColorRequested(func(k string) string { return env[k] }) {
+		t.Error("NO_COLOR=1 not honored")
+	}
+	if noColorRequested(getenvNone) {
+		t.Error("colors off without NO_COLOR")
+	}
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/scanner"
+)
+
+// trafficTimeout bounds a read of the traffic counters.
+const trafficTimeout = 2 * time.Second
+
+// trafficState is the sampler of the Net column and its last rates, by
+// port. Nil until the column is shown.
+type trafficState struct {
+	sampler *scanner.TrafficSampler
+	rates   map[int]scanner.Throughput
+	err     error
+}
+
+// sampleTraffic samples the throughput of the listening ports of this
+// machine. The first sample after the column is shown only sets the
+// baseline.
+func (m *topModel) sampleTraffic() {
+	if m.traffic == nil {
+		m.traffic = &trafficState{sampler: scanner.NewTrafficSampler()}
+	}
+	listeners := make(map[int]int)
+	for _, srv := range m.servers {
+		if srv.ProcessRecord != nil && srv.ProcessRecord.Port > 0 && srv.Host == "" {
+			listeners[srv.ProcessRecord.Port] = srv.ProcessRecord.PID
+		}
+	}
+	ctx, cancel := context.WithTimeout(m.context(), trafficTimeout)
+	defer cancel()
+	m.traffic.rates, m.traffic.err = m.traffic.sampler.Sample(ctx, listeners)
+}
+
+// trafficRate returns the throughput of the port srv listens on, once two
+// samples have been taken.
+func (m topModel) trafficRate(srv *models.ServerInfo) (scanner.Throughput, bool) {
+	if m.traffic == nil || srv.Host != "" {
+		return scanner.Throughput{}, false
+	}
+	rate, ok := m.traffic.rates[portOf(srv)]
+	return rate, ok
+}
+
+// formatRate shows bytes per second compactly, e.g. "0", "512", "1.2K",
+// "34M".
+func formatRate(perSec float64) string {
+	units := []string{"", "K", "M", "G"}
+	i := 0
+	for perSec >= 1000 && i < len(units)-1 {
+		perSec /= 1024
+		i++
+	}
+	switch {
+	case perSec < 0.5:
+		return "0"
+	case i == 0 || perSec >= 10:
+		return fmt.Sprintf("%.0f%s", perSec, units[i])
+	}
+	return fmt.Sprintf("%.1f%s", perSec, units[i])
+}
@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/scanner"
+)
+
+func TestFormatRate(t *testing.T) {
+	t.Parallel()
+	for in, want := range map[float64]string{0: "0", 0.2: "0", 512: "512", 1536: "1.5K", 20 * 1024: "20K", 3 * 1024 * 1024: "3.0M"} {
+		if got := formatRate(in); got != want {
+			t.Errorf("formatRate(%v) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNetColumnShowsThroughput(t *testing.T) {
+	t.Parallel()
+	srv := &models.ServerInfo{ProcessRecord: &models.ProcessRecord{PID: 10, Port: 3000, Command: "node server.js"}, Status: "running"}
+	m := newTestTopModel(t, []*models.ServerInfo{srv})
+	m.columns = map[string]bool{"net": true}
+	m.traffic = &trafficState{rates: map[int]scanner.Throughput{3000: {In: 2048, Out: 512}}}
+	if table := m.renderTable(160); !strings.Contains(table, "Net ↓/↑") || !strings.Contains(table, "2.0K 512") {
+		t.Errorf("table lacks the throughput:\n%s", table)
+	}
+}
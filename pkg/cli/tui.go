@@ -1,17 +1,22 @@
 package cli
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/mattn/go-runewidth"
 
+	"github.com/devports/devpt/pkg/devpt"
 	"github.com/devports/devpt/pkg/health"
 	"github.com/devports/devpt/pkg/models"
 	"github.com/devports/devpt/pkg/process"
@@ -19,9 +24,21 @@ import (
 
 // TopCmd starts the interactive TUI mode (like 'top')
 func (a *App) TopCmd() error {
+	if a.nonInteractive {
+		return fmt.Errorf("the TUI is not available in non-interactive mode; run a command such as devpt ls")
+	}
+	a.SetVia(models.ViaTUI)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // abandon scans and checks still running on quit
 	model := newTopModel(a)
-	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseCellMotion())
-	_, err := p.Run()
+	model.ctx = ctx
+	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseCellMotion(), tea.WithReportFocus())
+	final, err := p.Run()
+	if m, ok := final.(topModel); ok && err == nil {
+		if err := saveTUIState(a.config.TUIStateFile, m.state()); err != nil {
+			fmt.Fprintf(a.errOut(), "Warning: failed to save TUI preferences: %v\n", err)
+		}
+	}
 	return err
 }
 
@@ -37,6 +54,10 @@ const (
 	viewModeSearch
 	viewModeHelp
 	viewModeConfirm
+	viewModeForm
+	viewModeColumns
+	viewModeConflict
+	viewModeRequests
 )
 
 const (
@@ -57,6 +78,7 @@ const (
 	confirmStopPID confirmKind = iota
 	confirmRemoveService
 	confirmSudoKill
+	confirmGC
 )
 
 type confirmState struct {
@@ -84,9 +106,14 @@ type topModel struct {
 
 	logLines   []string
 	logErr     error
+	logView    viewport.Model
 	logSvc     *models.ManagedService
 	logPID     int
 	followLogs bool
+	// logStream streams the log of logSvc while it is shown; logStop ends
+	// the stream.
+	logStream <-chan string
+	logStop   func()
 
 	cmdInput    string
 	searchQuery string
@@ -94,17 +121,102 @@ type topModel struct {
 
 	health           map[int]string
 	healthDetails    map[int]*health.HealthCheck
+	serviceHealth    map[string]*health.HealthCheck
+	healthHist       *health.History
 	showHealthDetail bool
 	healthBusy       bool
 	healthLast       time.Time
 	healthChk        *health.Checker
-
-	sortBy sortMode
+	probes           *health.ProbeLog
+	probeBusy        bool
+
+	// ctx is canceled when the TUI exits, abandoning the scans, health
+	// sweeps and log reads still in flight. healthStop cancels the current
+	// health sweep and tailStop the current read of a process's log, when
+	// the view they are for is left.
+	ctx        context.Context
+	healthStop context.CancelFunc
+	tailStop   context.CancelFunc
+
+	sortBy     sortMode
+	showDebug  bool
+	showRuns   bool
+	showDetail bool
+	unfocused  bool
+
+	// columns are the optional running table columns shown, nil for the
+	// defaults; cmdWidth caps the command column, 0 letting it fill the
+	// width left, and cmdOverflow is how longer commands are fitted.
+	// chooserSel is the row selected in viewModeColumns.
+	columns     map[string]bool
+	cmdWidth    int
+	cmdOverflow string
+	chooserSel  int
+	// stats is the resource use of the servers while the CPU or Mem
+	// column is shown.
+	stats map[*models.ServerInfo]serverStats
+	// traffic samples the throughput of the listening ports while the Net
+	// column is shown.
+	traffic *trafficState
 
 	starting map[string]time.Time
 	removed  map[string]*models.ManagedService
 
+	// resources holds the latest resource sample of each running service.
+	resources map[string]resourceUse
+
+	// logs is the disk usage of the logs directories, measured every
+	// logUsageInterval.
+	logs         logUsage
+	logsAt       time.Time
+	showLogUsage bool
+
 	confirm *confirmState
+
+	// conflict is the port conflict shown in viewModeConflict;
+	// ignoredConflicts are those ignored there, by conflictKey.
+	conflict         *conflictState
+	ignoredConflicts map[string]bool
+
+	// urlIndex is the URL each service has been cycled to with u, by
+	// urlKey; hyperlinks makes URLs clickable.
+	urlIndex   map[string]int
+	hyperlinks bool
+
+	// copyPending is set by y until the key naming what to copy.
+	copyPending bool
+
+	// form is the add/edit service form shown in viewModeForm.
+	form *serviceForm
+
+	// logPanes are the logs pinned below the tables with l.
+	logPanes []logPane
+
+	// scroll keeps the lists' scroll positions between renders;
+	// tableBudget and managedBudget are the lines View lets them take, 0
+	// for all.
+	scroll        *scrollState
+	tableBudget   int
+	managedBudget int
+	// keys are the bindings from tui.keys; the zero value has the defaults.
+	keys keyMap
+	// theme is the styles the TUI draws with; nil is the default theme.
+	// savedTheme is the one picked with T, remembered for the next session.
+	theme      *theme
+	savedTheme string
+
+	// hosts are the last answers of the remote hosts in config.json, asked
+	// every hostInterval; hostFilter shows one host only, "" all of them.
+	hosts      []hostScan
+	hostsBusy  bool
+	hostsLast  time.Time
+	hostFilter string
+
+	// reqTarget is the service or port whose requests viewModeRequests
+	// shows, as devpt inspect recorded them.
+	reqTarget string
+	requests  []inspectRecord
+	reqErr    error
 }
 
 func newTopModel(app *App) topModel {
@@ -117,11 +229,21 @@ func newTopModel(app *App) topModel {
 		followLogs:    true,
 		health:        make(map[int]string),
 		healthDetails: make(map[int]*health.HealthCheck),
-		healthChk:     health.NewChecker(800 * time.Millisecond),
+		serviceHealth: make(map[string]*health.HealthCheck),
+		healthHist:    health.NewHistory(health.DefaultHistorySize),
+		healthChk:     app.newHealthChecker(800 * time.Millisecond),
+		probes:        health.NewProbeLog(),
 		sortBy:        sortRecent,
 		starting:      make(map[string]time.Time),
 		removed:       make(map[string]*models.ManagedService),
-	}
+		urlIndex:      make(map[string]int),
+		hyperlinks:    hyperlinksSupported(os.Getenv),
+		scroll:        &scrollState{},
+		keys:          keyMapFor(app.settings),
+	}
+	st := loadTUIState(app.config.TUIStateFile)
+	m.applyState(st)
+	m.theme = app.tuiTheme(os.Getenv, st.Theme)
 	if servers, err := app.discoverServers(); err == nil {
 		m.servers = servers
 	}
@@ -129,13 +251,45 @@ func newTopModel(app *App) topModel {
 }
 
 func (m topModel) Init() tea.Cmd {
-	return tickCmd()
+	return m.tickCmd()
+}
+
+// context returns the TUI's context; models built in tests have none.
+func (m topModel) context() context.Context {
+	if m.ctx == nil {
+		return context.Background()
+	}
+	return m.ctx
 }
 
 func (m topModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	if nm, ok := next.(topModel); ok && nm.mode != viewModeTable && nm.healthStop != nil {
+		// Health is only shown in the table; a slow sweep need not finish.
+		nm.healthStop()
+		nm.healthStop = nil
+		return nm, cmd
+	}
+	return next, cmd
+}
+
+func (m topModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		m.lastInput = time.Now()
+		if m.mode == viewModeForm && m.form != nil {
+			switch msg.String() {
+			case "esc":
+				m.mode = viewModeTable
+				m.form = nil
+				m.cmdStatus = "Cancelled"
+				return m, nil
+			case "enter":
+				m.submitForm()
+				return m, nil
+			}
+			return m, m.form.update(msg)
+		}
 		if m.mode == viewModeCommand {
 			switch msg.String() {
 			case "esc":
@@ -161,6 +315,16 @@ func (m topModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 		}
+		if m.mode == viewModeLogs && m.scrollLogs(msg.String()) {
+			return m, nil
+		}
+		if m.copyPending {
+			m.copyPending = false
+			if m.mode == viewModeTable {
+				m.cmdStatus = m.copySelected(msg.String())
+				return m, nil
+			}
+		}
 		if m.mode == viewModeSearch {
 			switch msg.String() {
 			case "esc":
@@ -183,10 +347,50 @@ func (m topModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 		}
-		switch msg.String() {
-		case "q", "ctrl+c":
+		if m.mode == viewModeConfirm {
+			// Confirmations keep their keys whatever tui.keys binds.
+			switch msg.String() {
+			case "y", "enter":
+				return m, m.executeConfirm(true)
+			case "n":
+				return m, m.executeConfirm(false)
+			case "esc":
+				m.mode = viewModeTable
+				m.confirm = nil
+				return m, nil
+			}
+		}
+		action := m.keys.action(msg.String())
+		if msg.String() == "esc" {
+			action = "back"
+		}
+		if m.mode == viewModeConflict && m.conflict != nil {
+			if m.updateConflict(msg.String()) {
+				return m, nil
+			}
+			switch action {
+			case "quit":
+				return m, tea.Quit
+			case "back":
+				m.closeConflict()
+			}
+			return m, nil
+		}
+		if m.mode == viewModeColumns {
+			switch action {
+			case "quit":
+				return m, tea.Quit
+			case "columns", "back":
+				m.mode = viewModeTable
+			default:
+				m.updateColumnChooser(msg.String())
+			}
+			return m, nil
+		}
+		switch action {
+		case "quit":
 			return m, tea.Quit
-		case "tab":
+		case "focus":
 			if m.mode == viewModeTable {
 				if m.focus == focusRunning {
 					m.focus = focusManaged
@@ -195,55 +399,164 @@ func (m topModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 			return m, nil
-		case "?", "f1":
+		case "help":
 			if m.mode == viewModeTable {
 				m.mode = viewModeHelp
 			}
 			return m, nil
-		case "/":
+		case "filter":
 			if m.mode == viewModeTable {
 				m.mode = viewModeSearch
 			}
 			return m, nil
-		case "ctrl+l":
+		case "clear_filter":
 			if m.mode == viewModeTable {
 				m.searchQuery = ""
 				m.cmdStatus = "Filter cleared"
 			}
 			return m, nil
-		case "s":
+		case "sort":
 			if m.mode == viewModeTable {
 				m.sortBy = (m.sortBy + 1) % sortModeCount
 			}
 			return m, nil
-		case "h":
+		case "health_detail":
 			if m.mode == viewModeTable {
 				m.showHealthDetail = !m.showHealthDetail
 			}
 			return m, nil
-		case "f":
+		case "refresh":
+			if m.mode == viewModeTable {
+				m.refresh()
+				m.cmdStatus = "Refreshed"
+			}
+			return m, nil
+		case "framework":
+			if m.mode == viewModeTable {
+				m.toggleColumn("framework")
+			}
+			return m, nil
+		case "debug":
+			if m.mode == viewModeTable {
+				m.showDebug = !m.showDebug
+			}
+			return m, nil
+		case "log_usage":
+			if m.mode == viewModeTable {
+				m.showLogUsage = !m.showLogUsage
+			}
+			return m, nil
+		case "theme":
+			if m.mode == viewModeTable {
+				m.cmdStatus = m.nextTheme()
+			}
+			return m, nil
+		case "columns":
+			if m.mode == viewModeTable {
+				m.mode = viewModeColumns
+			}
+			return m, nil
+		case "conflict":
+			if m.mode == viewModeTable {
+				m.cmdStatus = m.openConflict()
+			}
+			return m, nil
+		case "gc":
+			if m.mode == viewModeTable {
+				m.prepareGCConfirm()
+			}
+			return m, nil
+		case "runs":
+			if m.mode == viewModeTable {
+				m.showRuns = !m.showRuns
+			}
+			return m, nil
+		case "detail":
+			if m.mode == viewModeTable {
+				m.showDetail = !m.showDetail
+			}
+			return m, nil
+		case "pin_logs":
+			if m.mode == viewModeTable {
+				var cmd tea.Cmd
+				m.cmdStatus, cmd = m.togglePin()
+				return m, cmd
+			}
+			return m, nil
+		case "open":
+			if m.mode == viewModeTable {
+				m.cmdStatus = m.openSelected()
+			}
+			return m, nil
+		case "next_url":
+			if m.mode == viewModeTable {
+				m.cmdStatus = m.cycleURL()
+			}
+			return m, nil
+		case "edit_project":
+			if m.mode == viewModeTable {
+				var cmd tea.Cmd
+				m.cmdStatus, cmd = m.editSelected()
+				return m, cmd
+			}
+			return m, nil
+		case "host":
+			if m.mode == viewModeTable {
+				m.cmdStatus = m.cycleHostFilter()
+			}
+			return m, nil
+		case "requests":
+			if m.mode == viewModeTable {
+				var cmd tea.Cmd
+				m.cmdStatus, cmd = m.openRequests()
+				return m, cmd
+			}
+			return m, nil
+		case "all_listeners":
+			if m.mode == viewModeTable {
+				m.app.SetShowAll(!m.app.showAll)
+				if m.app.showAll {
+					m.cmdStatus = "Showing all listeners"
+				} else {
+					m.cmdStatus = "Showing dev servers only"
+				}
+				m.refresh()
+			}
+			return m, nil
+		case "follow":
 			if m.mode == viewModeLogs {
 				m.followLogs = !m.followLogs
+				if m.followLogs {
+					m.logView.GotoBottom()
+				}
 			}
 			return m, nil
-		case "ctrl+a":
+		case "add":
 			if m.mode == viewModeTable {
-				m.mode = viewModeCommand
-				m.cmdInput = "add "
+				m.form = newServiceForm(nil)
+				m.mode = viewModeForm
 			}
 			return m, nil
-		case "ctrl+r":
+		case "edit":
 			if m.mode == viewModeTable {
+				m.cmdStatus = m.editForm()
+			}
+			return m, nil
+		case "restart":
+			switch m.mode {
+			case viewModeTable:
 				m.cmdStatus = m.restartSelected()
 				m.refresh()
+			case viewModeLogs:
+				return m, m.restartFromLogs()
 			}
 			return m, nil
-		case "ctrl+e":
+		case "stop":
 			if m.mode == viewModeTable {
 				m.prepareStopConfirm()
 			}
 			return m, nil
-		case "x", "delete", "ctrl+d":
+		case "remove":
 			if m.mode == viewModeTable && m.focus == focusManaged {
 				managed := m.managedServices()
 				if m.managedSel >= 0 && m.managedSel < len(managed) {
@@ -259,78 +572,38 @@ func (m topModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 			return m, nil
-		case ":", "shift+;", ";", "c":
+		case "command":
 			if m.mode == viewModeTable {
 				m.mode = viewModeCommand
 				m.cmdInput = ""
 			}
 			return m, nil
-		case "esc":
+		case "back":
 			switch m.mode {
 			case viewModeLogs:
+				m.closeLogs()
+			case viewModeHelp, viewModeRequests:
 				m.mode = viewModeTable
-				m.logLines = nil
-				m.logErr = nil
-				m.logSvc = nil
-				m.logPID = 0
-			case viewModeHelp, viewModeConfirm:
-				m.mode = viewModeTable
-				m.confirm = nil
-			}
-			return m, nil
-		case "b":
-			if m.mode == viewModeLogs {
-				m.mode = viewModeTable
-				m.logLines = nil
-				m.logErr = nil
-				m.logSvc = nil
-				m.logPID = 0
-				return m, nil
 			}
 			return m, nil
-		case "backspace":
-			return m, nil
-		case "up", "k":
+		case "up":
 			if m.mode == viewModeTable {
-				if m.focus == focusRunning && m.selected > 0 {
-					m.selected--
-				}
-				if m.focus == focusManaged && m.managedSel > 0 {
-					m.managedSel--
-				}
+				m.moveSelection(-1)
 			}
 			return m, nil
-		case "down", "j":
+		case "down":
 			if m.mode == viewModeTable {
-				if m.focus == focusRunning {
-					if m.selected < len(m.visibleServers())-1 {
-						m.selected++
-					}
-				}
-				if m.focus == focusManaged {
-					if m.managedSel < len(m.managedServices())-1 {
-						m.managedSel++
-					}
-				}
+				m.moveSelection(1)
 			}
 			return m, nil
-		case "y":
-			if m.mode == viewModeConfirm {
-				cmd := m.executeConfirm(true)
-				return m, cmd
-			}
-			return m, nil
-		case "n":
-			if m.mode == viewModeConfirm {
-				cmd := m.executeConfirm(false)
-				return m, cmd
+		case "copy":
+			if m.mode == viewModeTable {
+				m.copyPending = true
+				m.cmdStatus = "Copy: p PID, P port, u URL, c command (any other key cancels)"
 			}
 			return m, nil
-		case "enter":
+		case "logs":
 			switch m.mode {
-			case viewModeConfirm:
-				cmd := m.executeConfirm(true)
-				return m, cmd
 			case viewModeTable:
 				if m.focus == focusManaged {
 					managed := m.managedServices()
@@ -350,16 +623,14 @@ func (m topModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					visible := m.visibleServers()
 					if m.selected >= 0 && m.selected < len(visible) {
 						srv := visible[m.selected]
+						if srv.Host != "" {
+							m.cmdStatus = remoteActionStatus(srv)
+							return m, nil
+						}
 						if srv.ManagedService == nil {
-							m.mode = viewModeLogs
-							m.logSvc = nil
-							m.logPID = srv.ProcessRecord.PID
-							return m, m.tailLogsCmd()
+							return m, m.openLogs(nil, srv.ProcessRecord.PID)
 						}
-						m.mode = viewModeLogs
-						m.logSvc = srv.ManagedService
-						m.logPID = 0
-						return m, m.tailLogsCmd()
+						return m, m.openLogs(srv.ManagedService, 0)
 					}
 				}
 				return m, nil
@@ -382,49 +653,192 @@ func (m topModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 		}
+	case tea.MouseMsg:
+		m.lastInput = time.Now()
+		m.handleMouse(msg)
+		return m, nil
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		m.syncLogView()
 		return m, nil
 	case tickMsg:
-		m.refresh()
-		if m.mode == viewModeLogs && m.followLogs {
-			return m, m.tailLogsCmd()
+		return m, m.scanCmd()
+	case scanMsg:
+		if m.context().Err() != nil {
+			return m, nil // quitting
+		}
+		m.refreshFrom(msg.scan, msg.err)
+		if m.mode == viewModeLogs && m.followLogs && m.logStream == nil {
+			cmd := m.tailLogsCmd()
+			return m, cmd
+		}
+		next := m.tickCmd()
+		if m.mode == viewModeRequests {
+			next = tea.Batch(next, m.requestsCmd())
 		}
 		if m.mode == viewModeTable && !m.healthBusy && time.Since(m.healthLast) > 2*time.Second && time.Since(m.lastInput) > 900*time.Millisecond {
 			m.healthBusy = true
-			return m, m.healthCmd()
+			ctx, cancel := context.WithCancel(m.context())
+			m.healthStop = cancel
+			next = m.healthCmd(ctx)
+		}
+		if m.mode == viewModeTable && len(m.logPanes) > 0 {
+			next = tea.Batch(next, m.logPanesCmd())
 		}
-		return m, tickCmd()
+		if !m.probeBusy {
+			if cmd := m.probeCmd(time.Now()); cmd != nil {
+				m.probeBusy = true
+				next = tea.Batch(next, cmd)
+			}
+		}
+		if len(m.app.hostConfigs()) > 0 && !m.hostsBusy && time.Since(m.hostsLast) >= hostInterval {
+			m.hostsBusy = true
+			next = tea.Batch(next, m.hostsCmd())
+		}
+		return m, next
+	case hostsMsg:
+		m.hostsBusy = false
+		m.hostsLast = time.Now()
+		m.hosts = msg.hosts
+		if n := len(m.visibleServers()); m.selected >= n && n > 0 {
+			m.selected = n - 1
+		}
+		return m, nil
+	case probeMsg:
+		m.probeBusy = false
+		m.recordProbes(msg)
+		return m, nil
+	case requestsMsg:
+		if m.mode == viewModeRequests && msg.target == m.reqTarget {
+			m.requests, m.reqErr = msg.records, msg.err
+		}
+		return m, nil
+	case logPanesMsg:
+		m.updateLogPanes(msg.panes)
+		return m, nil
+	case editorMsg:
+		if msg.err != nil {
+			m.cmdStatus = fmt.Sprintf("Editor failed: %v", msg.err)
+		} else {
+			m.cmdStatus = "Closed editor for " + msg.dir
+		}
+		return m, nil
 	case logMsg:
 		m.logLines = msg.lines
 		m.logErr = msg.err
-		return m, tickCmd()
+		m.syncLogView()
+		return m, m.tickCmd()
+	case logLinesMsg:
+		if msg.stream != m.logStream || msg.closed {
+			return m, nil
+		}
+		m.logErr = nil
+		m.logLines = append(m.logLines, msg.lines...)
+		if n := len(m.logLines) - logViewTail; n > 0 {
+			m.logLines = append([]string(nil), m.logLines[n:]...)
+		}
+		m.syncLogView()
+		return m, waitLogLines(m.logStream)
+	case tea.FocusMsg:
+		m.app.desktopArmed = false
+		m.unfocused = false
+		// Ticks may have slowed down while away; catch up right away.
+		m.refresh()
+		return m, nil
+	case tea.BlurMsg:
+		m.app.desktopArmed = true
+		m.unfocused = true
+		return m, nil
 	case healthMsg:
 		m.healthBusy = false
+		if m.healthStop != nil {
+			m.healthStop()
+			m.healthStop = nil
+		}
 		if msg.err == nil {
+			m.recordHealthTransitions(msg)
 			m.health = msg.icons
 			m.healthDetails = msg.details
+			m.serviceHealth = msg.services
+			keep := make(map[int]bool, len(msg.details))
+			for port, check := range msg.details {
+				keep[port] = true
+				m.healthHist.Record(check)
+			}
+			m.healthHist.Forget(keep)
 			m.healthLast = time.Now()
 		}
-		return m, tickCmd()
+		return m, m.tickCmd()
+	}
+	if m.mode == viewModeForm && m.form != nil {
+		// Pastes and other input for the focused field.
+		return m, m.form.update(msg)
 	}
 	return m, nil
 }
 
+// logUsageInterval is how often the TUI measures the logs directories.
+const logUsageInterval = 30 * time.Second
+
 func (m *topModel) refresh() {
-	if servers, err := m.app.discoverServers(); err == nil {
+	m.refreshFrom(m.app.scanServers(m.context(), m.app.daemon))
+}
+
+// scanCmd scans for servers off the UI goroutine, so that a slow scan
+// neither freezes the screen nor outlives the TUI.
+func (m topModel) scanCmd() tea.Cmd {
+	app, ctx, daemon := m.app, m.context(), m.app.daemon
+	return func() tea.Msg {
+		scan, err := app.scanServers(ctx, daemon)
+		return scanMsg{scan: scan, err: err}
+	}
+}
+
+// refreshFrom updates the servers shown from a scan.
+func (m *topModel) refreshFrom(scan *serverScan, err error) {
+	m.app.runDueSchedules(time.Now())
+	m.resources = m.app.sampleResources(time.Now())
+	if time.Since(m.logsAt) >= logUsageInterval {
+		m.logs = m.app.logUsage()
+		m.logsAt = time.Now()
+	}
+	var servers []*models.ServerInfo
+	if err == nil {
+		servers, err = m.app.assembleServers(m.context(), scan)
+	}
+	if err == nil {
 		m.servers = servers
 		m.lastUpdate = time.Now()
+		m.stats = nil
+		if m.columnShown("cpu") || m.columnShown("mem") {
+			m.stats = m.app.collectServerStats(servers)
+		}
+		if m.columnShown("net") {
+			m.sampleTraffic()
+		} else {
+			m.traffic = nil
+		}
 		if m.selected >= len(m.visibleServers()) && len(m.visibleServers()) > 0 {
 			m.selected = len(m.visibleServers()) - 1
 		}
 		if m.managedSel >= len(m.managedServices()) && len(m.managedServices()) > 0 {
 			m.managedSel = len(m.managedServices()) - 1
 		}
-		for name, at := range m.starting {
-			if m.isServiceRunning(name) || time.Since(at) > 45*time.Second {
+		// Discovery reports "starting" itself once the new PID is recorded; the
+		// overlay only bridges the gap until then and ends with readiness or exit.
+		for name := range m.starting {
+			switch m.serviceStatus(name) {
+			case "starting":
+			case "ready", "running":
 				delete(m.starting, name)
+				if line := m.readyLineForService(name); line != "" {
+					m.cmdStatus = fmt.Sprintf("%q ready: %s", name, line)
+				}
+			default:
+				if !m.isServiceRunning(name) {
+					delete(m.starting, name)
+				}
 			}
 		}
 	} else {
@@ -432,7 +846,24 @@ func (m *topModel) refresh() {
 	}
 }
 
+// View renders the screen. When it is taller than the terminal, the running
+// table and the managed list scroll so that the rest still fits.
 func (m topModel) View() string {
+	out := m.render()
+	if m.height <= 0 || m.scroll == nil {
+		return out
+	}
+	if m.mode != viewModeTable && m.mode != viewModeCommand && m.mode != viewModeSearch && m.mode != viewModeConfirm {
+		return out
+	}
+	if over := strings.Count(out, "\n") - m.height; over > 0 {
+		m.tableBudget, m.managedBudget = listBudgets(m.scroll.runningLines, m.scroll.managedLines, over)
+		out = m.render()
+	}
+	return clampLines(out, m.height)
+}
+
+func (m topModel) render() string {
 	if m.err != nil {
 		return fmt.Sprintf("Error: %v\nPress 'q' to quit\n", m.err)
 	}
@@ -443,23 +874,8 @@ func (m topModel) View() string {
 	}
 
 	var b strings.Builder
-	headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("12")).Bold(true)
-
-	// Ensure stale lines are removed when viewport shrinks/resizes.
-	b.WriteString("\x1b[H\x1b[2J")
-	b.WriteString("\n")
-	if m.mode == viewModeLogs {
-		name := "-"
-		if m.logSvc != nil {
-			name = m.logSvc.Name
-		} else if m.logPID > 0 {
-			name = fmt.Sprintf("pid:%d", m.logPID)
-		}
-		b.WriteString(headerStyle.Render(fmt.Sprintf("Logs: %s (b back, f follow:%t)", name, m.followLogs)))
-	} else {
-		b.WriteString(headerStyle.Render("Dev Process Tracker - Health Monitor (q quit)"))
-	}
-	b.WriteString("\n\n")
+	th := m.styles()
+	b.WriteString(m.renderHeader(width))
 	if m.mode == viewModeTable || m.mode == viewModeCommand || m.mode == viewModeSearch || m.mode == viewModeConfirm {
 		focus := "running"
 		if m.focus == focusManaged {
@@ -470,8 +886,19 @@ func (m topModel) View() string {
 			filter = "none"
 		}
 		ctx := fmt.Sprintf("Focus: %s | Sort: %s | Filter: %s", focus, sortModeLabel(m.sortBy), filter)
-		b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(fitLine(ctx, width)))
-		b.WriteString("\n\n")
+		if m.hostFilter != "" {
+			ctx += " | Host: " + m.hostFilter
+		}
+		if m.app.showAll {
+			ctx += " | All listeners"
+		}
+		b.WriteString(th.muted.Render(fitLine(ctx, width)))
+		b.WriteString("\n")
+		if hosts := m.renderHosts(width); hosts != "" {
+			b.WriteString(hosts)
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
 	}
 
 	switch m.mode {
@@ -479,142 +906,227 @@ func (m topModel) View() string {
 		b.WriteString(m.renderHelp(width))
 	case viewModeLogs:
 		b.WriteString(m.renderLogs(width))
+	case viewModeRequests:
+		b.WriteString(m.renderRequests(width))
+	case viewModeColumns:
+		b.WriteString(m.renderColumnChooser(width))
+	case viewModeConflict:
+		b.WriteString(m.renderConflict(width))
+	case viewModeForm:
+		if m.form != nil {
+			b.WriteString(m.form.view(width, th))
+		}
 	default:
-		rowStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("15"))
-		b.WriteString(rowStyle.Render(m.renderTable(width)))
-		b.WriteString("\n\n")
-		b.WriteString(m.renderManaged(width))
+		var main strings.Builder
+		mainW := m.mainWidth(width)
+		rowStyle := th.text
+		top := strings.Count(b.String(), "\n")
+		main.WriteString(rowStyle.Render(m.renderTable(mainW)))
+		main.WriteString("\n\n")
+		if infra := m.renderInfra(mainW); infra != "" {
+			main.WriteString(infra)
+			main.WriteString("\n")
+		}
+		if m.scroll != nil {
+			m.scroll.runningTop = top
+			m.scroll.managedTop = top + strings.Count(main.String(), "\n")
+			m.scroll.listWidth = mainW
+		}
+		main.WriteString(m.renderManaged(mainW))
+		if jobs := m.renderJobs(mainW); jobs != "" {
+			main.WriteString("\n")
+			main.WriteString(jobs)
+		}
+		if logs := m.renderLogUsage(mainW); logs != "" {
+			main.WriteString("\n")
+			main.WriteString(logs)
+		}
+		if m.showDetail {
+			b.WriteString(m.withDetailPanel(main.String(), width))
+		} else {
+			b.WriteString(main.String())
+		}
+		if panes := m.renderLogPanes(width); panes != "" {
+			b.WriteString("\n\n")
+			b.WriteString(panes)
+		}
 	}
 
 	if m.mode == viewModeCommand {
 		b.WriteString("\n")
-		b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Render(fitLine(":"+m.cmdInput, width)))
+		b.WriteString(th.accent.Render(fitLine(":"+m.cmdInput, width)))
 		b.WriteString("\n")
 		hint := `Example: add my-app ~/projects/my-app "npm run dev" 3000`
 		if strings.HasPrefix(strings.TrimSpace(m.cmdInput), "add") {
-			b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(fitLine(hint, width)))
+			b.WriteString(th.muted.Render(fitLine(hint, width)))
 			b.WriteString("\n")
 		}
-		b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(fitLine("Esc to go back", width)))
+		b.WriteString(th.muted.Render(fitLine("Esc to go back", width)))
 		b.WriteString("\n")
 	}
 	if m.mode == viewModeSearch {
 		b.WriteString("\n")
-		b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Render(fitLine("/"+m.searchQuery, width)))
+		b.WriteString(th.accent.Render(fitLine("/"+m.searchQuery, width)))
 		b.WriteString("\n")
 	}
 	if m.mode == viewModeConfirm && m.confirm != nil {
 		b.WriteString("\n")
-		b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Bold(true).Render(fitLine(m.confirm.prompt+" [y/N]", width)))
+		b.WriteString(th.warn.Bold(true).Render(fitLine(m.confirm.prompt+" [y/N]", width)))
 		b.WriteString("\n")
 	}
+	b.WriteString(m.renderFooter(width))
+	return b.String()
+}
+
+// renderHeader renders the title above every view and the blank line
+// below it.
+func (m topModel) renderHeader(width int) string {
+	k := m.keys
+	title := "Dev Process Tracker - Health Monitor (q quit)"
+	switch m.mode {
+	case viewModeLogs:
+		name := "-"
+		if m.logSvc != nil {
+			name = m.logSvc.Name
+		} else if m.logPID > 0 {
+			name = fmt.Sprintf("pid:%d", m.logPID)
+		}
+		restart := ""
+		if m.logSvc != nil {
+			restart = ", " + k.short("restart") + " restart"
+		}
+		title = fmt.Sprintf("Logs: %s (%s back, %s follow:%t%s, %s/%s %s/%s scroll) %3.0f%%", name, k.short("back"), k.short("follow"), m.followLogs, restart,
+			k.short("up"), k.short("down"), k.short("page_up"), k.short("page_down"), m.logView.ScrollPercent()*100)
+	case viewModeRequests:
+		title = fmt.Sprintf("Requests: %s (%s back)", m.reqTarget, k.short("back"))
+	}
+	var b strings.Builder
+	b.WriteString("\n")
+	for i, line := range wrapWords(title, width) {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(m.styles().title.Render(line))
+	}
+	b.WriteString("\n\n")
+	return b.String()
+}
+
+// renderFooter renders the status message, the key hints and the debug
+// line below every view.
+func (m topModel) renderFooter(width int) string {
+	var b strings.Builder
+	th := m.styles()
 	if m.cmdStatus != "" {
 		b.WriteString("\n")
-		b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(fitLine(m.cmdStatus, width)))
+		b.WriteString(th.muted.Render(fitLine(m.cmdStatus, width)))
 		b.WriteString("\n")
 	}
 
 	b.WriteString("\n")
-	footer := fmt.Sprintf("Last updated: %s | Services: %d | Tab switch | Enter logs/start | x remove managed | / filter | ^L clear filter | s sort | ? help | ^A add ^R restart ^E stop", m.lastUpdate.Format("15:04:05"), m.countVisible())
-	footerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Italic(true)
+	k := m.keys
+	footer := fmt.Sprintf("Last updated: %s | Services: %d | %s switch | %s logs/start | %s remove managed | %s filter | %s clear filter | %s sort | %s help | %s add %s restart %s stop",
+		m.lastUpdate.Format("15:04:05"), m.countVisible(),
+		k.short("focus"), k.short("logs"), k.short("remove"), k.short("filter"), k.short("clear_filter"),
+		k.short("sort"), k.short("help"), k.short("add"), k.short("restart"), k.short("stop"))
+	footerStyle := th.muted.Italic(true)
 	for _, line := range wrapWords(footer, width) {
 		b.WriteString(footerStyle.Render(fitLine(line, width)))
 		b.WriteString("\n")
 	}
+	if m.showDebug {
+		stats := m.app.scanStats
+		debug := fmt.Sprintf("Scan: %s | %d listeners, %d new", stats.Duration.Round(time.Millisecond), stats.Listeners, stats.New)
+		b.WriteString(footerStyle.Render(fitLine(debug, width)))
+		b.WriteString("\n")
+	}
 	return b.String()
 }
 
 func (m topModel) renderTable(width int) string {
 	visible := m.visibleServers()
 	displayNames := m.displayNames(visible)
-	nameW, portW, pidW, projectW, healthW := 14, 6, 7, 14, 7
-	sep := 2
-	used := nameW + sep + portW + sep + pidW + sep + projectW + sep + healthW + sep
-	cmdW := width - used
-	if cmdW < 12 {
-		cmdW = 12
-	}
-
-	var lines []string
-	header := fmt.Sprintf("%s%s%s%s%s%s%s%s%s%s%s",
-		fixedCell("Name", nameW), strings.Repeat(" ", sep),
-		fixedCell("Port", portW), strings.Repeat(" ", sep),
-		fixedCell("PID", pidW), strings.Repeat(" ", sep),
-		fixedCell("Project", projectW), strings.Repeat(" ", sep),
-		fixedCell("Command", cmdW), strings.Repeat(" ", sep),
-		fixedCell("Health", healthW),
-	)
-	divider := fmt.Sprintf("%s%s%s%s%s%s%s%s%s%s%s",
-		fixedCell(strings.Repeat("─", nameW), nameW), strings.Repeat(" ", sep),
-		fixedCell(strings.Repeat("─", portW), portW), strings.Repeat(" ", sep),
-		fixedCell(strings.Repeat("─", pidW), pidW), strings.Repeat(" ", sep),
-		fixedCell(strings.Repeat("─", projectW), projectW), strings.Repeat(" ", sep),
-		fixedCell(strings.Repeat("─", cmdW), cmdW), strings.Repeat(" ", sep),
-		fixedCell(strings.Repeat("─", healthW), healthW),
-	)
-	lines = append(lines, fitLine(header, width))
-	lines = append(lines, fitLine(divider, width))
+	const sep = 2
+	gap := strings.Repeat(" ", sep)
 
-	rowFirstLineIdx := make([]int, len(visible))
+	cells := make([]map[string]string, len(visible))
+	longest := make(map[string]int)
 	for i, srv := range visible {
-		project := "-"
-		if srv.ProcessRecord != nil {
-			if srv.ProcessRecord.ProjectRoot != "" {
-				project = pathBase(srv.ProcessRecord.ProjectRoot)
-			} else if srv.ProcessRecord.CWD != "" {
-				project = pathBase(srv.ProcessRecord.CWD)
-			}
-		}
-		if project == "-" && srv.ManagedService != nil && srv.ManagedService.CWD != "" {
-			project = pathBase(srv.ManagedService.CWD)
+		cells[i] = m.serverRowCells(srv, displayNames[i])
+		for name, text := range cells[i] {
+			longest[name] = max(longest[name], runewidth.StringWidth(text))
 		}
+	}
+	cols := m.tableLayout(width, longest)
 
-		port := "-"
-		pid := 0
-		cmd := "-"
-		icon := "…"
-		if srv.ProcessRecord != nil {
-			pid = srv.ProcessRecord.PID
-			cmd = srv.ProcessRecord.Command
-			if srv.ProcessRecord.Port > 0 {
-				port = fmt.Sprintf("%d", srv.ProcessRecord.Port)
-				if cached := m.health[srv.ProcessRecord.Port]; cached != "" {
-					icon = cached
-				}
+	var header, divider []string
+	for _, c := range cols {
+		header = append(header, fixedCell(c.heading, c.width))
+		divider = append(divider, strings.Repeat("─", c.width))
+	}
+	lines := []string{fitLine(strings.Join(header, gap), width), fitLine(strings.Join(divider, gap), width)}
+	if m.scroll != nil {
+		m.scroll.columns = nil
+		x := 0
+		for _, c := range cols {
+			if c.sortable {
+				m.scroll.columns = append(m.scroll.columns, columnHit{from: x, to: x + c.width, sort: c.sort})
 			}
+			x += c.width + sep
 		}
+		m.scroll.runningRows = nil
+	}
 
-		cmdLines := wrapRunes(cmd, cmdW)
-		if len(cmdLines) == 0 {
-			cmdLines = []string{"-"}
+	rowFirstLineIdx := make([]int, len(visible))
+	for i, srv := range visible {
+		var cmdLines []string
+		row := make([]string, len(cols))
+		for j, c := range cols {
+			text := cells[i][c.name]
+			switch c.name {
+			case "command":
+				cmdLines = commandLines(text, c.width, m.overflow())
+				text = cmdLines[0]
+			case "trend":
+				if rec := srv.ProcessRecord; rec != nil && rec.Port > 0 && srv.Host == "" {
+					text = health.Sparkline(m.healthHist.Recent(rec.Port, c.width))
+				} else {
+					text = ""
+				}
+			}
+			row[j] = fixedCell(text, c.width)
+			if i == m.selected {
+				continue
+			}
+			switch {
+			case c.name == "health" && m.healthStatusOf(srv) != "":
+				row[j] = m.styles().healthStyle(m.healthStatusOf(srv)).Render(row[j])
+			case c.name == "port" && strings.HasSuffix(text, " !"):
+				row[j] = m.styles().warn.Render(row[j])
+			}
 		}
 		rowFirstLineIdx[i] = len(lines)
-		for j, c := range cmdLines {
-			if j == 0 {
-				line := fmt.Sprintf("%s%s%s%s%s%s%s%s%s%s%s",
-					fixedCell(displayNames[i], nameW), strings.Repeat(" ", sep),
-					fixedCell(port, portW), strings.Repeat(" ", sep),
-					fixedCell(fmt.Sprintf("%d", pid), pidW), strings.Repeat(" ", sep),
-					fixedCell(project, projectW), strings.Repeat(" ", sep),
-					fixedCell(c, cmdW), strings.Repeat(" ", sep),
-					fixedCell(icon, healthW),
-				)
-				lines = append(lines, fitLine(line, width))
-			} else {
-				line := fmt.Sprintf("%s%s%s%s%s%s%s%s%s%s%s",
-					fixedCell("", nameW), strings.Repeat(" ", sep),
-					fixedCell("", portW), strings.Repeat(" ", sep),
-					fixedCell("", pidW), strings.Repeat(" ", sep),
-					fixedCell("", projectW), strings.Repeat(" ", sep),
-					fixedCell(c, cmdW), strings.Repeat(" ", sep),
-					fixedCell("", healthW),
-				)
-				lines = append(lines, fitLine(line, width))
+		lines = append(lines, fitLine(strings.Join(row, gap), width))
+		for _, more := range cmdLines[1:] {
+			for j, c := range cols {
+				row[j] = strings.Repeat(" ", c.width)
+				if c.name == "command" {
+					row[j] = fixedCell(more, c.width)
+				}
 			}
+			lines = append(lines, fitLine(strings.Join(row, gap), width))
 		}
 	}
 
 	if len(visible) == 0 {
+		if m.scroll != nil {
+			m.scroll.columns = nil
+		}
+		for _, h := range m.hosts {
+			if h.name == m.hostFilter && h.err != nil {
+				return fitLine(fmt.Sprintf("(host %s is unreachable: %v)", h.name, h.err), width)
+			}
+		}
 		if m.searchQuery != "" {
 			return fitLine("(no matching servers for filter)", width)
 		}
@@ -623,18 +1135,53 @@ func (m topModel) renderTable(width int) string {
 
 	selectedLine := rowFirstLineIdx[m.selected]
 	if selectedLine >= 2 && selectedLine < len(lines) {
-		lines[selectedLine] = lipgloss.NewStyle().Background(lipgloss.Color("57")).Foreground(lipgloss.Color("15")).Render(lines[selectedLine])
+		lines[selectedLine] = m.styles().selected.Render(lines[selectedLine])
+	}
+	rows := make([][]string, len(visible))
+	for i := range visible {
+		end := len(lines)
+		if i+1 < len(visible) {
+			end = rowFirstLineIdx[i+1]
+		}
+		rows[i] = lines[rowFirstLineIdx[i]:end]
+	}
+	var offset *int
+	if m.scroll != nil {
+		offset = &m.scroll.running
+		m.scroll.runningLines = len(lines) - 2
+	}
+	shown, rowAt := scrollRows(rows, m.selected, offset, m.tableBudget, width)
+	lines = append(lines[:2:2], shown...)
+	if m.scroll != nil {
+		m.scroll.runningRows = append([]int{-1, -1}, rowAt...)
 	}
 
 	out := strings.Join(lines, "\n")
+	if m.selected >= 0 && m.selected < len(visible) && m.focus == focusRunning && visible[m.selected].Host == "" {
+		if u, i, n, err := m.currentURL(visible[m.selected]); err == nil {
+			hint := "o open"
+			if n > 1 {
+				hint = "u next, o open"
+			}
+			out += "\n" + m.linkURL(fitLine(fmt.Sprintf("URL: %s (%s)", describeURL(u, i, n), hint), width), u.URL)
+		}
+	}
 	if m.showHealthDetail {
-		if m.selected >= 0 && m.selected < len(visible) {
+		if m.selected >= 0 && m.selected < len(visible) && visible[m.selected].Host == "" {
 			port := 0
 			if visible[m.selected].ProcessRecord != nil {
 				port = visible[m.selected].ProcessRecord.Port
 			}
 			if d := m.healthDetails[port]; d != nil {
-				out += "\n" + fitLine(fmt.Sprintf("Health detail: %s %dms %s", health.StatusIcon(d.Status), d.ResponseMs, d.Message), width)
+				detail := fitLine(fmt.Sprintf("Health detail: %s %s %dms %s", m.app.healthIcon(d.Status), d.Status, d.ResponseMs, d.Message), width)
+				out += "\n" + m.styles().healthStyle(d.Status).Render(detail)
+			}
+			if recent := m.healthHist.Recent(port, healthHistoryRows); len(recent) > 1 {
+				out += "\n" + fitLine(fmt.Sprintf("Recent checks (%s):", health.Sparkline(m.healthHist.Recent(port, 0))), width)
+				for i := len(recent) - 1; i >= 0; i-- {
+					c := recent[i]
+					out += "\n" + fitLine(fmt.Sprintf("  %s  %s %-7s %5dms  %s", c.LastCheck.Format("15:04:05"), m.app.healthIcon(c.Status), c.Status, c.ResponseMs, c.Message), width)
+				}
 			}
 		}
 	}
@@ -704,26 +1251,129 @@ func wrapWords(s string, width int) []string {
 			cur = w
 		}
 	}
-	lines = append(lines, cur)
-	return lines
+	lines = append(lines, cur)
+	return lines
+}
+
+// renderInfra lists local databases and brokers with their health, or
+// returns "" when there are none.
+func (m topModel) renderInfra(width int) string {
+	infra := m.infraServers()
+	if len(infra) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(fitLine("Infrastructure", width))
+	b.WriteString("\n")
+	for _, srv := range infra {
+		rec := srv.ProcessRecord
+		icon := "…"
+		if cached := m.health[rec.Port]; cached != "" {
+			icon = cached
+		}
+		line := fmt.Sprintf("%s %s :%d", icon, m.serviceNameFor(srv), rec.Port)
+		if rec.Container != nil {
+			line += "  " + displayCommand(rec)
+		} else {
+			line += fmt.Sprintf("  pid %d", rec.PID)
+		}
+		if rec.Exposed() {
+			line += "  ! all interfaces"
+		}
+		if d := m.healthDetails[rec.Port]; d != nil && m.showHealthDetail {
+			line += "  " + d.Message
+		}
+		b.WriteString(fitLine(line, width))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// tuiJobRuns is how many recent job runs the TUI lists.
+const tuiJobRuns = 5
+
+func (m topModel) renderJobs(width int) string {
+	now := time.Now()
+	var b strings.Builder
+	if entries := m.app.scheduledEntries(now); len(entries) > 0 {
+		b.WriteString(fitLine("Schedules", width))
+		b.WriteString("\n")
+		for _, e := range entries {
+			b.WriteString(fitLine(formatScheduledEntry(e, now), width))
+			b.WriteString("\n")
+		}
+	}
+	runs := m.app.registry.RecentJobRuns("", tuiJobRuns)
+	if len(runs) == 0 {
+		return b.String()
+	}
+	if b.Len() > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString(fitLine("Recent Jobs", width))
+	b.WriteString("\n")
+	for _, run := range runs {
+		line := fmt.Sprintf("%s %s", run.Job, formatJobRun(run, now))
+		if run.For != "" {
+			line += fmt.Sprintf(" (before %s)", run.For)
+		} else if run.Scheduled {
+			line += " (scheduled)"
+		}
+		b.WriteString(fitLine(line, width))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// tuiLogServices is how many services the log usage panel lists.
+const tuiLogServices = 5
+
+// renderLogUsage shows the log usage panel while it is toggled on, and a
+// warning line while the logs exceed logs.max_size.
+func (m topModel) renderLogUsage(width int) string {
+	var b strings.Builder
+	if warning := m.logs.Warning(); warning != "" {
+		b.WriteString(m.styles().warn.Render(fitLine("⚠ "+warning+" (G to clean up)", width)))
+		b.WriteString("\n")
+	}
+	if !m.showLogUsage {
+		return b.String()
+	}
+	b.WriteString(fitLine(fmt.Sprintf("Logs: %s of %s (G clean up, L hide)", formatBytes(m.logs.Total), formatBytes(m.logs.Max)), width))
+	b.WriteString("\n")
+	for i, s := range m.logs.Services {
+		if i == tuiLogServices {
+			b.WriteString(fitLine(fmt.Sprintf("  … %d more", len(m.logs.Services)-i), width))
+			b.WriteString("\n")
+			break
+		}
+		line := fmt.Sprintf("  %-20s %10s  %d files", s.Name, formatBytes(s.Size), s.Files)
+		if !s.Registered {
+			line += "  (unregistered)"
+		}
+		b.WriteString(fitLine(line, width))
+		b.WriteString("\n")
+	}
+	if m.logs.Jobs > 0 {
+		b.WriteString(fitLine(fmt.Sprintf("  %-20s %10s", "jobs", formatBytes(m.logs.Jobs)), width))
+		b.WriteString("\n")
+	}
+	return b.String()
 }
 
 func (m topModel) renderManaged(width int) string {
 	managed := m.managedServices()
-	if len(managed) == 0 {
-		return fitLine(`No managed services yet. Use ^A then: add myapp /path/to/app "npm run dev" 3000`, width)
+	if m.scroll != nil {
+		m.scroll.managedRows = nil
 	}
-
-	portOwners := make(map[int]int)
-	for _, svc := range managed {
-		for _, p := range svc.Ports {
-			portOwners[p]++
-		}
+	if len(managed) == 0 {
+		return fitLine(`No managed services yet. Press ^A to add one`, width)
 	}
 
 	var b strings.Builder
 	b.WriteString(fitLine("Managed Services (Tab focus, Enter start)", width))
 	b.WriteString("\n")
+	rows := make([][]string, 0, len(managed))
 	for i, svc := range managed {
 		state := m.serviceStatus(svc.Name)
 		if state == "stopped" {
@@ -732,24 +1382,72 @@ func (m topModel) renderManaged(width int) string {
 			}
 		}
 		line := fmt.Sprintf("%s [%s]", svc.Name, state)
-
-		conflicting := false
-		for _, p := range svc.Ports {
-			if portOwners[p] > 1 {
-				conflicting = true
-				break
-			}
+		if check := m.serviceHealth[svc.Name]; check != nil && state == "running" {
+			line = fmt.Sprintf("%s %s", line, m.app.healthIcon(check.Status))
 		}
-		if conflicting {
-			line = fmt.Sprintf("%s (port conflict)", line)
+
+		if len(m.conflictPorts(svc)) > 0 {
+			line = fmt.Sprintf("%s (port conflict, %s to resolve)", line, m.keys.short("conflict"))
+		} else if svc.RunPort > 0 {
+			line = fmt.Sprintf("%s (port %d auto)", line, svc.RunPort)
 		} else if len(svc.Ports) > 1 {
 			line = fmt.Sprintf("%s (ports: %v)", line, svc.Ports)
 		}
+		use, sampled := m.resources[svc.Name]
+		if sampled && state != "stopped" {
+			line = fmt.Sprintf("%s %s", line, use)
+		}
+		if n := len(m.app.liveLeftovers(svc)); n > 0 {
+			line = fmt.Sprintf("%s ⚠ %d left running after stop", line, n)
+		}
+		if svc.RestartCount > 0 {
+			loop := m.app.crashLoopSettings()
+			line = fmt.Sprintf("%s ↻%d (%d in %s)", line, svc.RestartCount, devpt.RecentCrashRestarts(svc, time.Now(), loop.Window.Std()), loop.Window.Std())
+		}
+		target := ""
+		if state != "stopped" && !isCrashStatus(state) {
+			if u, _, _, err := m.currentURL(m.managedServer(svc)); err == nil {
+				target = u.URL
+				line = fmt.Sprintf("%s %s", line, target)
+			}
+		}
 
-		line = fitLine(line, width)
+		line = m.linkURL(fitLine(line, width), target)
 		if m.focus == focusManaged && i == m.managedSel {
-			line = lipgloss.NewStyle().Background(lipgloss.Color("57")).Foreground(lipgloss.Color("15")).Render(line)
+			line = m.styles().selected.Render(line)
+		} else if sampled && use.Over != "" {
+			line = m.styles().danger.Render(line)
+		}
+		row := []string{line}
+		if svc.Compound() {
+			var pids map[string]int
+			if state == "running" {
+				pids = m.app.groupPIDs(svc)
+			}
+			for j, p := range svc.Processes {
+				branch := "├"
+				if j == len(svc.Processes)-1 {
+					branch = "└"
+				}
+				sub := fmt.Sprintf("  %s %s", branch, p.Name)
+				if pids[p.Name] > 0 {
+					sub = fmt.Sprintf("%s  PID %d", sub, pids[p.Name])
+				}
+				row = append(row, fitLine(fmt.Sprintf("%s  %s", sub, p.Command), width))
+			}
 		}
+		rows = append(rows, row)
+	}
+	var offset *int
+	if m.scroll != nil {
+		offset = &m.scroll.managed
+		m.scroll.managedLines = len(flatten(rows))
+	}
+	shown, rowAt := scrollRows(rows, m.managedSel, offset, m.managedBudget, width)
+	if m.scroll != nil {
+		m.scroll.managedRows = append([]int{-1}, rowAt...)
+	}
+	for _, line := range shown {
 		b.WriteString(line)
 		b.WriteString("\n")
 	}
@@ -759,40 +1457,101 @@ func (m topModel) renderManaged(width int) string {
 			b.WriteString(fitLine("Crash reason: "+reason, width))
 			b.WriteString("\n")
 		}
+		if check := m.serviceHealth[svc.Name]; check != nil && m.showHealthDetail {
+			b.WriteString(fitLine(fmt.Sprintf("Health detail: %s %dms %s", m.app.healthIcon(check.Status), check.ResponseMs, check.Message), width))
+			b.WriteString("\n")
+		}
+		if m.showRuns {
+			if len(svc.Runs) == 0 {
+				b.WriteString(fitLine("Recent runs: none recorded", width))
+				b.WriteString("\n")
+			} else {
+				b.WriteString(fitLine("Recent runs:", width))
+				b.WriteString("\n")
+				status := m.serviceStatus(svc.Name)
+				now := time.Now()
+				for _, run := range svc.RecentRuns(3) {
+					b.WriteString(fitLine("  "+describeRun(run, runIsLive(svc, run, status), now), width))
+					b.WriteString("\n")
+				}
+			}
+		}
 	}
 	return b.String()
 }
 
 func (m topModel) renderLogs(width int) string {
 	if m.logErr != nil {
-		if errors.Is(m.logErr, process.ErrNoLogs) {
-			return "No devpt logs for this service yet.\nLogs are only captured when started by devpt.\n"
-		}
-		if errors.Is(m.logErr, process.ErrNoProcessLogs) {
-			return "No accessible logs for this process.\nIf it writes only to a terminal, there may be nothing to tail here.\n"
-		}
-		return fmt.Sprintf("Error: %v\n", m.logErr)
+		return logErrorText(m.logErr)
 	}
 	if len(m.logLines) == 0 {
 		return "(no logs yet)\n"
 	}
-	var b strings.Builder
+	return m.logView.View() + "\n"
+}
+
+// chromeHeight is how many lines the logs and requests views spend around
+// their content at width: the header and the footer. Both end in a newline,
+// which lipgloss.Height counts as one more line.
+func (m topModel) chromeHeight(width int) int {
+	return lipgloss.Height(m.renderHeader(width)) - 1 + lipgloss.Height(m.renderFooter(width)) - 1
+}
+
+// syncLogView sizes the log viewport to the terminal and fills it with the
+// tailed lines, wrapped to its width, staying at the end while following.
+func (m *topModel) syncLogView() {
+	width := m.width
+	if width <= 0 {
+		width = 120
+	}
+	var lines []string
 	for _, line := range m.logLines {
-		b.WriteString(fitLine(line, width))
-		b.WriteString("\n")
+		for _, l := range wrapRunes(line, width) {
+			lines = append(lines, fitLine(l, width))
+		}
+	}
+	height := len(lines)
+	if m.height > 0 {
+		height = max(m.height-m.chromeHeight(width), 3)
+	}
+	m.logView.Width, m.logView.Height = width, height
+	m.logView.SetContent(strings.Join(lines, "\n"))
+	if m.followLogs {
+		m.logView.GotoBottom()
 	}
-	return b.String()
 }
 
-func (m topModel) renderHelp(width int) string {
-	lines := []string{
-		"Keymap",
-		"q quit, Tab switch list, Enter logs/start, / filter, Ctrl+L clear filter, s sort, h health detail, ? help",
-		"Ctrl+A add command, Ctrl+R restart selected, Ctrl+E stop selected",
-		"Logs: b back, f toggle follow",
-		"Managed list: x remove selected service",
-		"Commands: add, start, stop, remove, restore, list, help",
+// scrollLogs scrolls the logs view for key and reports whether it did.
+// Scrolling up stops following the log; reaching the end resumes it.
+func (m *topModel) scrollLogs(key string) bool {
+	switch m.keys.action(key) {
+	case "up":
+		m.logView.ScrollUp(1)
+	case "down":
+		m.logView.ScrollDown(1)
+	case "page_up":
+		m.logView.PageUp()
+	case "page_down":
+		m.logView.PageDown()
+	case "top":
+		m.logView.GotoTop()
+	case "bottom":
+		m.logView.GotoBottom()
+	default:
+		return false
 	}
+	m.followLogs = m.logView.AtBottom()
+	return true
+}
+
+func (m topModel) renderHelp(width int) string {
+	lines := []string{"Keymap (rebind keys with tui.keys in config.json)", ""}
+	lines = append(lines, m.keys.helpLines(width)...)
+	lines = append(lines,
+		"",
+		"Mouse: click a row to select it, click a column header to sort, wheel to scroll",
+		"Commands: add, start, stop, pause, resume, signal, remove, restore, list, help",
+	)
 	var out []string
 	for _, l := range lines {
 		out = append(out, fitLine(l, width))
@@ -804,35 +1563,60 @@ func (m topModel) countVisible() int { return len(m.visibleServers()) }
 
 func (m topModel) visibleServers() []*models.ServerInfo {
 	var visible []*models.ServerInfo
-	q := strings.ToLower(strings.TrimSpace(m.searchQuery))
-	for _, srv := range m.servers {
-		if srv == nil || srv.ProcessRecord == nil {
+	all := append(append([]*models.ServerInfo(nil), m.servers...), m.remoteServers()...)
+	for _, srv := range all {
+		if srv == nil || srv.ProcessRecord == nil || isInfraServer(srv) {
 			continue
 		}
-		if srv.ManagedService == nil {
-			if srv.ProcessRecord.Port == 0 || !isRuntimeCommand(srv.ProcessRecord.Command) {
-				continue
-			}
+		if m.hostFilter != "" && hostOf(srv) != m.hostFilter {
+			continue
 		}
-		if q != "" {
-			hay := strings.ToLower(fmt.Sprintf("%s %s %s %d %s %s",
-				m.serviceNameFor(srv), projectOf(srv), srv.ProcessRecord.Command, srv.ProcessRecord.Port, srv.ProcessRecord.CWD, srv.ProcessRecord.ProjectRoot))
-			if !strings.Contains(hay, q) {
+		// Remote hosts have filtered their listeners already.
+		if srv.Host == "" && !m.app.showAll && srv.ManagedService == nil && srv.ProcessRecord.Container == nil && srv.ProcessRecord.PortForward == nil {
+			if srv.ProcessRecord.Port == 0 || (!isRuntimeCommand(srv.ProcessRecord.Command) && !m.app.filter.Custom(srv.ProcessRecord.Command)) {
 				continue
 			}
 		}
+		if !m.matchesSearch(srv) {
+			continue
+		}
 		visible = append(visible, srv)
 	}
 	m.sortServers(visible)
 	return visible
 }
 
+// infraServers returns the unmanaged databases and brokers, ordered by port.
+func (m topModel) infraServers() []*models.ServerInfo {
+	var infra []*models.ServerInfo
+	for _, srv := range m.servers {
+		if m.hostFilter != "" && m.hostFilter != models.LocalHost {
+			break
+		}
+		if isInfraServer(srv) && m.matchesSearch(srv) {
+			infra = append(infra, srv)
+		}
+	}
+	sort.Slice(infra, func(i, j int) bool { return portOf(infra[i]) < portOf(infra[j]) })
+	return infra
+}
+
+func (m topModel) matchesSearch(srv *models.ServerInfo) bool {
+	q := strings.ToLower(strings.TrimSpace(m.searchQuery))
+	if q == "" {
+		return true
+	}
+	hay := strings.ToLower(fmt.Sprintf("%s %s %s %d %s %s %s %s",
+		m.serviceNameFor(srv), projectOf(srv), srv.ProcessRecord.Command, srv.ProcessRecord.Port, srv.ProcessRecord.CWD, srv.ProcessRecord.ProjectRoot, srv.ProcessRecord.Stack(), srv.Host))
+	return strings.Contains(hay, q)
+}
+
 func (m topModel) managedServices() []*models.ManagedService {
 	services := m.app.registry.ListServices()
 	q := strings.ToLower(strings.TrimSpace(m.searchQuery))
 	var filtered []*models.ManagedService
 	for _, svc := range services {
-		if q == "" || strings.Contains(strings.ToLower(svc.Name+" "+svc.CWD+" "+svc.Command), q) {
+		if q == "" || strings.Contains(strings.ToLower(svc.Name+" "+svc.CWD+" "+svc.CommandSummary()), q) {
 			filtered = append(filtered, svc)
 		}
 	}
@@ -851,7 +1635,7 @@ func (m topModel) displayNames(servers []*models.ServerInfo) []string {
 	}
 	for i, srv := range servers {
 		base[i] = m.serviceNameFor(srv)
-		if base[i] == "-" && srv.ProcessRecord != nil {
+		if base[i] == "-" && srv.ProcessRecord != nil && srv.Host == "" {
 			root := strings.TrimRight(strings.TrimSpace(srv.ProcessRecord.ProjectRoot), "/")
 			cwd := strings.TrimRight(strings.TrimSpace(srv.ProcessRecord.CWD), "/")
 			if mapped := projectToSvc[root]; mapped != "" {
@@ -862,18 +1646,18 @@ func (m topModel) displayNames(servers []*models.ServerInfo) []string {
 		}
 	}
 
-	count := make(map[string]int)
-	for _, n := range base {
-		count[n]++
-	}
+	// Names repeat on different hosts, which the Host column tells apart.
 	type row struct{ idx, pid int }
-	group := make(map[string][]row)
+	type key struct{ host, name string }
+	group := make(map[key][]row)
 	for i, n := range base {
-		group[n] = append(group[n], row{idx: i, pid: pidOf(servers[i])})
+		k := key{servers[i].Host, n}
+		group[k] = append(group[k], row{idx: i, pid: pidOf(servers[i])})
 	}
 	out := make([]string, len(base))
-	for name, rows := range group {
-		if count[name] <= 1 || name == "-" {
+	for k, rows := range group {
+		name := k.name
+		if len(rows) <= 1 || name == "-" {
 			for _, r := range rows {
 				out[r.idx] = name
 			}
@@ -901,7 +1685,7 @@ func (m topModel) sortServers(servers []*models.ServerInfo) {
 		sort.Slice(servers, func(i, j int) bool { return portOf(servers[i]) < portOf(servers[j]) })
 	case sortHealth:
 		sort.Slice(servers, func(i, j int) bool {
-			return strings.Compare(m.health[portOf(servers[i])], m.health[portOf(servers[j])]) < 0
+			return strings.Compare(m.healthIconOf(servers[i]), m.healthIconOf(servers[j])) < 0
 		})
 	default:
 		sort.Slice(servers, func(i, j int) bool { return pidOf(servers[i]) > pidOf(servers[j]) })
@@ -916,6 +1700,21 @@ func (m topModel) serviceNameFor(srv *models.ServerInfo) string {
 		return srv.ManagedService.Name
 	}
 	if srv.ProcessRecord != nil {
+		if c := srv.ProcessRecord.Container; c != nil {
+			switch {
+			case c.Workspace != "":
+				return pathBase(c.Workspace)
+			case c.ID == "" && c.VM != "":
+				return c.VM
+			}
+			return c.Name
+		}
+		if f := srv.ProcessRecord.PortForward; f != nil {
+			return pathBase(f.Target)
+		}
+		if srv.ProcessRecord.Infra != "" {
+			return srv.ProcessRecord.Infra
+		}
 		if srv.ProcessRecord.ProjectRoot != "" {
 			return pathBase(srv.ProcessRecord.ProjectRoot)
 		}
@@ -1019,6 +1818,33 @@ func (m topModel) runCommand(input string) string {
 			return err.Error()
 		}
 		return fmt.Sprintf("Stopped %q", args[1])
+	case "pause", "resume":
+		if len(args) < 2 {
+			return fmt.Sprintf("Usage: %s <name|pid>", args[0])
+		}
+		if _, err := m.app.setPaused(args[1], args[0] == "pause"); err != nil {
+			return err.Error()
+		}
+		if args[0] == "pause" {
+			return fmt.Sprintf("Paused %s", args[1])
+		}
+		return fmt.Sprintf("Resumed %s", args[1])
+	case "signal":
+		if len(args) < 3 {
+			return "Usage: signal <name|pid> <SIGNAL>"
+		}
+		sig, err := process.ParseSignal(args[2])
+		if err != nil {
+			return err.Error()
+		}
+		pid, _, err := m.app.signalTarget(args[1])
+		if err != nil {
+			return err.Error()
+		}
+		if err := m.app.processManager.Signal(pid, sig); err != nil {
+			return err.Error()
+		}
+		return fmt.Sprintf("Sent %s to %s", process.SignalName(sig), args[1])
 	default:
 		return "Unknown command (type :help)"
 	}
@@ -1030,6 +1856,9 @@ func (m topModel) startSelected() string {
 		return "No service selected"
 	}
 	srv := visible[m.selected]
+	if srv.Host != "" {
+		return remoteActionStatus(srv)
+	}
 	if srv.ManagedService == nil {
 		return "Selected process is not a managed service"
 	}
@@ -1040,12 +1869,35 @@ func (m topModel) startSelected() string {
 	return fmt.Sprintf("Started %q", srv.ManagedService.Name)
 }
 
+// restartFromLogs restarts the service whose logs are shown and follows
+// the log of its new run, which its log stream switches to.
+func (m *topModel) restartFromLogs() tea.Cmd {
+	if m.logSvc == nil {
+		m.cmdStatus = "Only managed services can be restarted"
+		return nil
+	}
+	name := m.logSvc.Name
+	if err := m.app.RestartCmd(name); err != nil {
+		m.cmdStatus = err.Error()
+		return nil
+	}
+	m.starting[name] = time.Now()
+	m.cmdStatus = fmt.Sprintf("Restarted %q, following its new log", name)
+	m.followLogs = true
+	m.syncLogView()
+	m.refresh()
+	return nil
+}
+
 func (m topModel) restartSelected() string {
 	visible := m.visibleServers()
 	if m.selected < 0 || m.selected >= len(visible) {
 		return "No service selected"
 	}
 	srv := visible[m.selected]
+	if srv.Host != "" {
+		return remoteActionStatus(srv)
+	}
 	if srv.ManagedService == nil {
 		return "Selected process is not a managed service"
 	}
@@ -1056,6 +1908,230 @@ func (m topModel) restartSelected() string {
 	return fmt.Sprintf("Restarted %q", srv.ManagedService.Name)
 }
 
+// selectedServer returns the server of the selected row in the focused
+// list, or a status message when nothing is selected.
+func (m topModel) selectedServer() (*models.ServerInfo, string) {
+	if m.focus == focusManaged {
+		managed := m.managedServices()
+		if m.managedSel < 0 || m.managedSel >= len(managed) {
+			return nil, "No managed service selected"
+		}
+		return m.managedServer(managed[m.managedSel]), ""
+	}
+	visible := m.visibleServers()
+	if m.selected < 0 || m.selected >= len(visible) {
+		return nil, "No service selected"
+	}
+	if srv := visible[m.selected]; srv.Host != "" {
+		return nil, remoteActionStatus(srv)
+	}
+	return visible[m.selected], ""
+}
+
+// managedServer returns the discovered server of svc, or a stopped one when
+// it is not running.
+func (m topModel) managedServer(svc *models.ManagedService) *models.ServerInfo {
+	for _, srv := range m.servers {
+		if srv.ManagedService != nil && srv.ManagedService.Name == svc.Name {
+			return srv
+		}
+	}
+	return &models.ServerInfo{ManagedService: svc, Status: "stopped"}
+}
+
+// urlKey identifies a server in urlIndex.
+func urlKey(srv *models.ServerInfo) string {
+	if srv.ManagedService != nil {
+		return srv.ManagedService.Name
+	}
+	return fmt.Sprintf(":%d", portOf(srv))
+}
+
+// currentURL returns the URL of srv that u has cycled to, with its
+// position and the number of URLs.
+func (m topModel) currentURL(srv *models.ServerInfo) (models.ServiceURL, int, int, error) {
+	urls, err := serverURLs(srv)
+	if err != nil {
+		return models.ServiceURL{}, 0, 0, err
+	}
+	i := m.urlIndex[urlKey(srv)] % len(urls)
+	return urls[i], i, len(urls), nil
+}
+
+// describeURL renders a URL for the status line, e.g. "docs
+// http://localhost:3000/docs (2/3)".
+func describeURL(u models.ServiceURL, i, n int) string {
+	text := u.URL
+	if u.Name != "" {
+		text = u.Name + " " + text
+	}
+	if n > 1 {
+		text = fmt.Sprintf("%s (%d/%d)", text, i+1, n)
+	}
+	return text
+}
+
+// openSelected opens the current URL of the selected row in the browser.
+func (m topModel) openSelected() string {
+	srv, msg := m.selectedServer()
+	if srv == nil {
+		return msg
+	}
+	u, _, _, err := m.currentURL(srv)
+	if err != nil {
+		return err.Error()
+	}
+	if err := m.app.openServer(srv, u.URL); err != nil {
+		return err.Error()
+	}
+	return "Opened " + u.URL
+}
+
+// editSelected opens the selected row's project in the editor. Terminal
+// editors take over the screen until they exit; others open a window.
+func (m topModel) editSelected() (string, tea.Cmd) {
+	srv, msg := m.selectedServer()
+	if srv == nil {
+		return msg, nil
+	}
+	cmd, dir, err := m.app.editorCommand(srv)
+	if err != nil {
+		return err.Error(), nil
+	}
+	if terminalEditor(cmd.Path) {
+		return "", tea.ExecProcess(cmd, func(err error) tea.Msg { return editorMsg{dir: dir, err: err} })
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Sprintf("Editor failed: %v", err), nil
+	}
+	go cmd.Wait()
+	return "Opened " + dir + " in " + filepath.Base(cmd.Args[0]), nil
+}
+
+// editForm opens the form that edits the selected managed service.
+func (m *topModel) editForm() string {
+	srv, msg := m.selectedServer()
+	if srv == nil {
+		return msg
+	}
+	if srv.ManagedService == nil {
+		return "Not a managed service; press Ctrl+A to add one"
+	}
+	svc := m.app.registry.GetService(srv.ManagedService.Name)
+	if svc == nil {
+		return errServiceNotFound(srv.ManagedService.Name).Error()
+	}
+	if svc.Compound() {
+		return fmt.Sprintf("%q runs several processes; edit it with devpt add --proc", svc.Name)
+	}
+	m.form = newServiceForm(svc)
+	m.mode = viewModeForm
+	return ""
+}
+
+// submitForm registers the service the form describes, or updates the one
+// it edits, and closes the form. It stays open on errors.
+func (m *topModel) submitForm() {
+	f := m.form
+	svc, ok := f.service(func(name string) bool { return m.app.registry.GetService(m.app.ScopedName(name)) != nil })
+	if !ok {
+		return
+	}
+	if f.editing != nil {
+		if err := m.app.EditServiceCmd(svc); err != nil {
+			f.err = err.Error()
+			return
+		}
+		m.cmdStatus = fmt.Sprintf("Updated %q", svc.Name)
+		if statusMatches(m.managedServer(svc).Status, "running") {
+			m.cmdStatus += " (Ctrl+R restarts it with the changes)"
+		}
+	} else {
+		svc.Name = m.app.ScopedName(svc.Name)
+		if err := m.app.AddServiceCmd(svc); err != nil {
+			f.err = err.Error()
+			return
+		}
+		m.cmdStatus = fmt.Sprintf("Added %q", svc.Name)
+	}
+	m.form = nil
+	m.mode = viewModeTable
+	m.refresh()
+}
+
+// cycleURL moves the selected row to its next URL.
+func (m topModel) cycleURL() string {
+	srv, msg := m.selectedServer()
+	if srv == nil {
+		return msg
+	}
+	m.urlIndex[urlKey(srv)]++
+	u, i, n, err := m.currentURL(srv)
+	if err != nil {
+		return err.Error()
+	}
+	return "URL: " + describeURL(u, i, n) + " (o open)"
+}
+
+// copySelected copies a field of the selected row, named by key, to the
+// clipboard.
+func (m topModel) copySelected(key string) string {
+	srv, msg := m.selectedServer()
+	if srv == nil {
+		return msg
+	}
+	var what, text string
+	switch key {
+	case "p":
+		what = "PID"
+		if srv.ProcessRecord != nil && srv.ProcessRecord.PID > 0 {
+			text = strconv.Itoa(srv.ProcessRecord.PID)
+		} else if srv.ManagedService != nil && srv.ManagedService.LastPID != nil && statusMatches(srv.Status, "running") {
+			text = strconv.Itoa(*srv.ManagedService.LastPID)
+		}
+	case "P":
+		what = "port"
+		if port := portOf(srv); port > 0 {
+			text = strconv.Itoa(port)
+		} else if srv.ManagedService != nil {
+			if ports := srv.ManagedService.ActivePorts(); len(ports) > 0 {
+				text = strconv.Itoa(ports[0])
+			}
+		}
+	case "u":
+		what = "URL"
+		if u, _, _, err := m.currentURL(srv); err == nil {
+			text = u.URL
+		}
+	case "c":
+		what = "command"
+		if srv.ManagedService != nil {
+			text = srv.ManagedService.CommandSummary()
+		} else if srv.ProcessRecord != nil {
+			text = srv.ProcessRecord.Command
+		}
+	default:
+		return "Copy cancelled"
+	}
+	if text == "" {
+		return fmt.Sprintf("No %s to copy", what)
+	}
+	via, err := copyToClipboard(text, os.Stdout)
+	if err != nil {
+		return "Copy failed: " + err.Error()
+	}
+	return fmt.Sprintf("Copied %s %s (%s)", what, text, via)
+}
+
+// linkURL renders target as a clickable hyperlink when the terminal
+// supports them.
+func (m topModel) linkURL(line, target string) string {
+	if !m.hyperlinks || target == "" {
+		return line
+	}
+	return strings.Replace(line, target, hyperlink(target, target), 1)
+}
+
 func (m *topModel) prepareStopConfirm() {
 	visible := m.visibleServers()
 	if m.selected < 0 || m.selected >= len(visible) {
@@ -1063,10 +2139,18 @@ func (m *topModel) prepareStopConfirm() {
 		return
 	}
 	srv := visible[m.selected]
+	if srv.Host != "" {
+		m.cmdStatus = remoteActionStatus(srv)
+		return
+	}
 	if srv.ProcessRecord == nil || srv.ProcessRecord.PID == 0 {
 		m.cmdStatus = "No PID to stop"
 		return
 	}
+	if c := srv.ProcessRecord.Container; c != nil && srv.ManagedService == nil {
+		m.cmdStatus = containerStopError(srv.ProcessRecord.Port, c).Error()
+		return
+	}
 	prompt := fmt.Sprintf("Stop PID %d?", srv.ProcessRecord.PID)
 	serviceName := ""
 	if srv.ManagedService != nil {
@@ -1077,6 +2161,21 @@ func (m *topModel) prepareStopConfirm() {
 	m.mode = viewModeConfirm
 }
 
+// prepareGCConfirm asks before doing what `devpt gc` does.
+func (m *topModel) prepareGCConfirm() {
+	preview, err := m.app.gcPreview()
+	if err != nil {
+		m.cmdStatus = err.Error()
+		return
+	}
+	if preview == "" {
+		m.cmdStatus = "Nothing to clean up"
+		return
+	}
+	m.confirm = &confirmState{kind: confirmGC, prompt: fmt.Sprintf("Clean up logs and stale PIDs (%s)?", preview)}
+	m.mode = viewModeConfirm
+}
+
 func (m *topModel) executeConfirm(yes bool) tea.Cmd {
 	if m.confirm == nil {
 		m.mode = viewModeTable
@@ -1102,6 +2201,7 @@ func (m *topModel) executeConfirm(yes bool) tea.Cmd {
 				if c.serviceName != "" {
 					_ = m.app.registry.ClearServicePID(c.serviceName)
 				}
+				m.app.emitStopped(c.serviceName, c.pid)
 			} else {
 				m.cmdStatus = err.Error()
 			}
@@ -1112,6 +2212,7 @@ func (m *topModel) executeConfirm(yes bool) tea.Cmd {
 					m.cmdStatus = fmt.Sprintf("Stopped PID %d (warning: %v)", c.pid, clrErr)
 				}
 			}
+			m.app.emitStopped(c.serviceName, c.pid)
 		}
 	case confirmRemoveService:
 		svc := m.app.registry.GetService(c.name)
@@ -1126,55 +2227,212 @@ func (m *topModel) executeConfirm(yes bool) tea.Cmd {
 		}
 	case confirmSudoKill:
 		m.cmdStatus = fmt.Sprintf("Run manually: sudo kill -9 %d", c.pid)
+	case confirmGC:
+		if _, reclaimed, err := m.app.collectGarbage(false, func(string) {}); err != nil {
+			m.cmdStatus = err.Error()
+		} else {
+			m.cmdStatus = fmt.Sprintf("Reclaimed %s", formatBytes(reclaimed))
+		}
+		m.logsAt = time.Time{}
 	}
 	m.refresh()
 	return nil
 }
 
-func (m topModel) tailLogsCmd() tea.Cmd {
+// logViewTail is how many lines of a log the logs view shows.
+const logViewTail = 200
+
+// openLogs shows the log of a managed service, or of an unmanaged process
+// when svc is nil. A service's log is read once and then streamed; a
+// process's is tailed on every refresh while followed.
+func (m *topModel) openLogs(svc *models.ManagedService, pid int) tea.Cmd {
+	m.closeLogs()
+	m.mode = viewModeLogs
+	m.logSvc, m.logPID = svc, pid
+	if svc == nil {
+		return m.tailLogsCmd()
+	}
+	// Stream from before the first read, so that no line is missed between.
+	m.logStream, m.logStop = m.app.processManager.Follow(svc.Name)
+	m.logLines, _, m.logErr = m.app.tailLogs(m.context(), svc, 0, logViewTail)
+	m.syncLogView()
+	return waitLogLines(m.logStream)
+}
+
+// closeLogs leaves the logs view and ends its log stream.
+func (m *topModel) closeLogs() {
+	if m.logStop != nil {
+		m.logStop()
+	}
+	if m.tailStop != nil {
+		m.tailStop()
+	}
+	m.logStream, m.logStop, m.tailStop = nil, nil, nil
+	m.mode = viewModeTable
+	m.logLines = nil
+	m.logErr = nil
+	m.logSvc = nil
+	m.logPID = 0
+}
+
+// waitLogLines waits for the next line of stream and sends it along with
+// those already buffered behind it.
+func waitLogLines(stream <-chan string) tea.Cmd {
 	return func() tea.Msg {
-		if m.logSvc != nil {
-			lines, err := m.app.processManager.Tail(m.logSvc.Name, 200)
-			return logMsg{lines: lines, err: err}
-		}
-		if m.logPID > 0 {
-			lines, err := m.app.processManager.TailProcess(m.logPID, 200)
-			return logMsg{lines: lines, err: err}
+		line, ok := <-stream
+		if !ok {
+			return logLinesMsg{stream: stream, closed: true}
+		}
+		lines := []string{line}
+		for len(lines) < logViewTail {
+			select {
+			case line, ok := <-stream:
+				if !ok {
+					return logLinesMsg{stream: stream, lines: lines}
+				}
+				lines = append(lines, line)
+			default:
+				return logLinesMsg{stream: stream, lines: lines}
+			}
 		}
-		return logMsg{err: fmt.Errorf("no service selected")}
+		return logLinesMsg{stream: stream, lines: lines}
 	}
 }
 
-func (m topModel) healthCmd() tea.Cmd {
-	visible := m.visibleServers()
+// tailLogsCmd reads the log shown in the logs view, canceling the previous
+// read if it is still going: tailing a process can mean asking lsof or the
+// system log.
+func (m *topModel) tailLogsCmd() tea.Cmd {
+	if m.tailStop != nil {
+		m.tailStop()
+	}
+	ctx, cancel := context.WithCancel(m.context())
+	m.tailStop = cancel
+	app, svc, pid := m.app, m.logSvc, m.logPID
+	return func() tea.Msg {
+		lines, _, err := app.tailLogs(ctx, svc, pid, logViewTail)
+		return logMsg{lines: lines, err: err}
+	}
+}
+
+// healthCmd checks the health of the servers shown until ctx is canceled,
+// in which case the results are dropped.
+func (m topModel) healthCmd(ctx context.Context) tea.Cmd {
+	visible := append(m.visibleServers(), m.infraServers()...)
+	var workers []*models.ManagedService
+	for _, srv := range m.servers {
+		if srv.ProcessRecord == nil && srv.Status == "running" && srv.ManagedService != nil &&
+			srv.ManagedService.Health != nil && srv.ManagedService.Health.Command != "" {
+			workers = append(workers, srv.ManagedService)
+		}
+	}
 	return func() tea.Msg {
 		icons := make(map[int]string)
 		details := make(map[int]*health.HealthCheck)
+		services := make(map[string]*health.HealthCheck)
 		for _, srv := range visible {
-			if srv.ProcessRecord == nil || srv.ProcessRecord.Port <= 0 {
+			if srv.ProcessRecord == nil || srv.ProcessRecord.Port <= 0 || srv.Host != "" {
+				continue
+			}
+			port := srv.ProcessRecord.Port
+			cfg := healthConfigOf(srv)
+			if prev := m.healthDetails[port]; prev != nil && healthFresh(prev, cfg) {
+				icons[port] = m.app.healthIcon(prev.Status)
+				details[port] = prev
 				continue
 			}
-			check := m.healthChk.Check(srv.ProcessRecord.Port)
-			icons[srv.ProcessRecord.Port] = health.StatusIcon(check.Status)
+			if ctx.Err() != nil {
+				break
+			}
+			check := checkServerHealth(ctx, m.healthChk, srv)
+			icons[srv.ProcessRecord.Port] = m.app.healthIcon(check.Status)
 			details[srv.ProcessRecord.Port] = check
 		}
-		return healthMsg{icons: icons, details: details}
+		for _, svc := range workers {
+			if prev := m.serviceHealth[svc.Name]; prev != nil && healthFresh(prev, svc.Health) {
+				services[svc.Name] = prev
+				continue
+			}
+			services[svc.Name] = m.healthChk.CheckServiceContext(ctx, svc, 0)
+		}
+		if err := ctx.Err(); err != nil {
+			return healthMsg{err: err}
+		}
+		return healthMsg{icons: icons, details: details, services: services}
+	}
+}
+
+// recordHealthTransitions emits an event for every check whose status differs
+// from the previous result for the same port or worker.
+func (m *topModel) recordHealthTransitions(msg healthMsg) {
+	names := make(map[int]string)
+	for _, srv := range m.servers {
+		if srv.ProcessRecord != nil && srv.ManagedService != nil {
+			names[srv.ProcessRecord.Port] = srv.ManagedService.Name
+		}
+	}
+	for port, check := range msg.details {
+		m.app.emitHealthChange(names[port], port, m.healthDetails[port], check)
 	}
+	for name, check := range msg.services {
+		m.app.emitHealthChange(name, 0, m.serviceHealth[name], check)
+	}
+}
+
+// healthFresh reports whether a previous result is still within the configured interval.
+func healthFresh(prev *health.HealthCheck, cfg *models.HealthCheckConfig) bool {
+	return cfg != nil && cfg.Interval > 0 && time.Since(prev.LastCheck) < cfg.Interval.Std()
 }
 
+// healthHistoryRows is how many past checks the health detail panel lists.
+const healthHistoryRows = 8
+
 type tickMsg time.Time
+
+// scanMsg carries a scan made off the UI goroutine by scanCmd.
+type scanMsg struct {
+	scan *serverScan
+	err  error
+}
 type logMsg struct {
 	lines []string
 	err   error
 }
+
+// logLinesMsg carries the lines streamed from the log of stream; closed is
+// set once the stream has ended.
+type logLinesMsg struct {
+	stream <-chan string
+	lines  []string
+	closed bool
+}
+type editorMsg struct {
+	dir string
+	err error
+}
 type healthMsg struct {
-	icons   map[int]string
-	details map[int]*health.HealthCheck
-	err     error
+	icons    map[int]string
+	details  map[int]*health.HealthCheck
+	services map[string]*health.HealthCheck
+	err      error
+}
+
+func (m topModel) tickCmd() tea.Cmd {
+	return tea.Tick(m.refreshInterval(), func(t time.Time) tea.Msg { return tickMsg(t) })
 }
 
-func tickCmd() tea.Cmd {
-	return tea.Tick(time.Second, func(t time.Time) tea.Msg { return tickMsg(t) })
+// refreshInterval returns the configured rescan interval, slowed down while
+// the terminal is unfocused or nobody has pressed a key for a while.
+func (m topModel) refreshInterval() time.Duration {
+	var cfg models.TUISettings
+	if m.app != nil && m.app.settings != nil {
+		cfg = m.app.settings.TUI
+	}
+	cfg = cfg.Effective()
+	if m.unfocused || time.Since(m.lastInput) > cfg.IdleAfter.Std() {
+		return cfg.IdleInterval.Std()
+	}
+	return cfg.RefreshInterval.Std()
 }
 
 func parseArgs(input string) ([]string, error) {
@@ -1333,9 +2591,18 @@ func (m topModel) serviceStatus(name string) string {
 	return "stopped"
 }
 
+func (m topModel) readyLineForService(name string) string {
+	for _, srv := range m.servers {
+		if srv.ManagedService != nil && srv.ManagedService.Name == name {
+			return srv.ReadyLine
+		}
+	}
+	return ""
+}
+
 func (m topModel) crashReasonForService(name string) string {
 	for _, srv := range m.servers {
-		if srv.ManagedService != nil && srv.ManagedService.Name == name && srv.Status == "crashed" {
+		if srv.ManagedService != nil && srv.ManagedService.Name == name && isCrashStatus(srv.Status) {
 			return srv.CrashReason
 		}
 	}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -41,3 +42,31 @@ func TestSearchModeAcceptsRuneKeys(t *testing.T) {
 		t.Fatalf("expected search query to include rune key, got %q", updated.searchQuery)
 	}
 }
+
+func TestLeavingTableCancelsHealthSweep(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := topModel{
+		mode:       viewModeTable,
+		healthBusy: true,
+		healthStop: cancel,
+	}
+
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("?")})
+	updated := next.(topModel)
+	if updated.mode != viewModeHelp {
+		t.Fatalf("expected the help view, got mode %d", updated.mode)
+	}
+	if ctx.Err() == nil || updated.healthStop != nil {
+		t.Fatal("expected leaving the table to cancel the health sweep")
+	}
+
+	// The canceled sweep reports back without replacing what is shown.
+	updated.health = map[int]string{3000: "ok"}
+	next, _ = updated.Update(healthMsg{err: context.Canceled})
+	if updated = next.(topModel); updated.healthBusy || updated.health[3000] != "ok" {
+		t.Fatalf("canceled sweep: busy=%v health=%v", updated.healthBusy, updated.health)
+	}
+}
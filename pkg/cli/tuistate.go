@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// tuiState is what the TUI remembers between sessions, in tui-state.json:
+// how the lists are sorted, focused and filtered, the host shown, the optional columns and
+// panels shown, the command column's width and overflow, the theme picked
+// with T, and the port conflicts ignored. Columns is nil in state saved before the column chooser,
+// which shows the default columns.
+type tuiState struct {
+	Sort            string   `json:"sort,omitempty"`
+	Focus           string   `json:"focus,omitempty"`
+	Filter          string   `json:"filter,omitempty"`
+	Host            string   `json:"host,omitempty"`
+	Columns         []string `json:"columns"`
+	CommandWidth    int      `json:"command_width,omitempty"`
+	CommandOverflow string   `json:"command_overflow,omitempty"`
+	HealthDetail    bool     `json:"health_detail,omitempty"`
+	Detail          bool     `json:"detail,omitempty"`
+	Runs            bool     `json:"runs,omitempty"`
+	LogUsage        bool     `json:"log_usage,omitempty"`
+	Theme           string   `json:"theme,omitempty"`
+	// IgnoredConflicts are the port conflicts ignored in the conflict
+	// view, as "service:port".
+	IgnoredConflicts []string `json:"ignored_conflicts,omitempty"`
+}
+
+// loadTUIState reads the saved state; a missing or unreadable file yields
+// the defaults.
+func loadTUIState(path string) tuiState {
+	var st tuiState
+	if path == "" {
+		return st
+	}
+	if content, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(content, &st); err != nil {
+			return tuiState{}
+		}
+	}
+	return st
+}
+
+// saveTUIState writes st to path.
+func saveTUIState(path string, st tuiState) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// state returns the preferences of m to save.
+func (m topModel) state() tuiState {
+	st := tuiState{
+		Sort:            sortModeLabel(m.sortBy),
+		Focus:           "running",
+		Filter:          m.searchQuery,
+		Host:            m.hostFilter,
+		Columns:         m.shownColumns(),
+		CommandWidth:    m.cmdWidth,
+		CommandOverflow: m.cmdOverflow,
+		HealthDetail:    m.showHealthDetail,
+		Detail:          m.showDetail,
+		Runs:            m.showRuns,
+		LogUsage:        m.showLogUsage,
+		Theme:           m.savedTheme,
+	}
+	if m.focus == focusManaged {
+		st.Focus = "managed"
+	}
+	for key, ignored := range m.ignoredConflicts {
+		if ignored {
+			st.IgnoredConflicts = append(st.IgnoredConflicts, key)
+		}
+	}
+	sort.Strings(st.IgnoredConflicts)
+	return st
+}
+
+// applyState restores saved preferences; the theme is applied by
+// App.tuiTheme.
+func (m *topModel) applyState(st tuiState) {
+	for s := sortMode(0); s < sortModeCount; s++ {
+		if sortModeLabel(s) == st.Sort {
+			m.sortBy = s
+		}
+	}
+	if st.Focus == "managed" {
+		m.focus = focusManaged
+	}
+	m.searchQuery = st.Filter
+	m.hostFilter = st.Host
+	if st.Columns != nil {
+		m.columns = make(map[string]bool, len(st.Columns))
+		for _, c := range st.Columns {
+			m.columns[c] = true
+		}
+	}
+	m.cmdWidth = max(st.CommandWidth, 0)
+	for _, o := range commandOverflows {
+		if o == st.CommandOverflow {
+			m.cmdOverflow = o
+		}
+	}
+	m.showHealthDetail = st.HealthDetail
+	m.showDetail = st.Detail
+	m.showRuns = st.Runs
+	m.showLogUsage = st.LogUsage
+	m.savedTheme = st.Theme
+	for _, key := range st.IgnoredConflicts {
+		if m.ignoredConflicts == nil {
+			m.ignoredConflicts = make(map[string]bool)
+		}
+		m.ignoredConflicts[key] = true
+	}
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTUIStateRoundTrip(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "config", "tui-state.json")
+	if st := loadTUIState(path); !reflect.DeepEqual(st, tuiState{}) {
+		t.Errorf("missing file loaded as %+v", st)
+	}
+
+	m := newTestTopModel(t, nil)
+	m.sortBy = sortPort
+	m.focus = focusManaged
+	m.searchQuery = "api"
+	m.toggleColumn("framework")
+	m.toggleColumn("project")
+	m.cmdWidth = 40
+	m.cmdOverflow = overflowMiddle
+	m.showDetail = true
+	m.nextTheme()
+	if err := saveTUIState(path, m.state()); err != nil {
+		t.Fatal(err)
+	}
+
+	st := loadTUIState(path)
+	restored := newTestTopModel(t, nil)
+	restored.applyState(st)
+	if restored.sortBy != sortPort || restored.focus != focusManaged || restored.searchQuery != "api" {
+		t.Errorf("sort, focus or filter not restored: %+v", st)
+	}
+	if !restored.columnShown("framework") || restored.columnShown("project") || !restored.showDetail || restored.showHealthDetail {
+		t.Errorf("toggles not restored: %+v", st)
+	}
+	if restored.cmdWidth != 40 || restored.overflow() != overflowMiddle {
+		t.Errorf("command column = %d %s, want 40 middle", restored.cmdWidth, restored.overflow())
+	}
+	if st.Theme != "solarized" || restored.savedTheme != "solarized" {
+		t.Errorf("theme = %q, want the one after default", st.Theme)
+	}
+}
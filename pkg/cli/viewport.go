@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// minListLines is the fewest lines a scrolled list is shrunk to, including
+// its "x–y of N" line.
+const minListLines = 4
+
+// scrollState remembers the first row each list showed, so that moving the
+// selection scrolls only as far as needed to keep it in view, and where the
+// lists were drawn, so that mouse clicks can be mapped back to rows. It is
+// shared by the copies of topModel that View renders from.
+type scrollState struct {
+	running int
+	managed int
+	// runningLines and managedLines are how many lines the lists took in
+	// full at the last render.
+	runningLines int
+	managedLines int
+	// runningTop and managedTop are the screen lines the lists started on,
+	// and runningRows and managedRows the row drawn on each of their lines,
+	// -1 for headers. Both lists were drawn within the first listWidth
+	// columns.
+	runningTop  int
+	managedTop  int
+	runningRows []int
+	managedRows []int
+	listWidth   int
+	// columns are the sortable columns of the running table's header,
+	// which is its first line.
+	columns []columnHit
+}
+
+// columnHit is the screen columns [from, to) a table header takes, and the
+// sort mode clicking it selects.
+type columnHit struct {
+	from, to int
+	sort     sortMode
+}
+
+// scrollWindow returns the rows [start, end) of a list that fit in height
+// lines, given each row's height. It keeps the selected row in view,
+// scrolling from offset, the previous first row, only as far as needed.
+func scrollWindow(heights []int, selected, offset, height int) (start, end int) {
+	n := len(heights)
+	if n == 0 {
+		return 0, 0
+	}
+	selected = min(max(selected, 0), n-1)
+	start = min(max(offset, 0), selected)
+	used := 0
+	for i := start; i <= selected; i++ {
+		used += heights[i]
+	}
+	for start < selected && used > height {
+		used -= heights[start]
+		start++
+	}
+	used = 0
+	end = start
+	for end < n && used+heights[end] <= height {
+		used += heights[end]
+		end++
+	}
+	if end == start {
+		// The selected row alone is taller than the window.
+		end = start + 1
+	}
+	// Fill the window from above when the end of the list is reached.
+	for end == n && start > 0 && used+heights[start-1] <= height {
+		start--
+		used += heights[start]
+	}
+	return start, end
+}
+
+// scrollRows limits rows, each made of one or more lines, to budget lines
+// around the selected row, ending with an "x–y of N" line. A budget of 0,
+// or one the rows fit in, keeps them all. offset is the list's first row
+// from the previous scrolled render and is updated. rowAt is the row each
+// returned line belongs to, -1 for the "x–y of N" line.
+func scrollRows(rows [][]string, selected int, offset *int, budget, width int) (lines []string, rowAt []int) {
+	heights := make([]int, len(rows))
+	total := 0
+	for i, r := range rows {
+		heights[i] = len(r)
+		total += len(r)
+	}
+	if budget <= 0 || total <= budget {
+		return flatten(rows), rowIndex(heights, 0, len(rows))
+	}
+	prev := 0
+	if offset != nil {
+		prev = *offset
+	}
+	start, end := scrollWindow(heights, selected, prev, max(budget-1, 1))
+	if offset != nil {
+		*offset = start
+	}
+	lines = flatten(rows[start:end])
+	more := ""
+	switch {
+	case start > 0 && end < len(rows):
+		more = " (↑/↓ for more)"
+	case start > 0:
+		more = " (↑ for more)"
+	case end < len(rows):
+		more = " (↓ for more)"
+	}
+	lines = append(lines, fitLine(fmt.Sprintf("%d–%d of %d%s", start+1, end, len(rows), more), width))
+	return lines, append(rowIndex(heights, start, end), -1)
+}
+
+// rowIndex maps each line of the rows [start, end) to its row.
+func rowIndex(heights []int, start, end int) []int {
+	var out []int
+	for i := start; i < end; i++ {
+		for range heights[i] {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+func flatten(rows [][]string) []string {
+	var out []string
+	for _, r := range rows {
+		out = append(out, r...)
+	}
+	return out
+}
+
+// listBudgets shrinks the running table and the managed list, which take
+// tableLines and managedLines in full, by overflow lines between them,
+// taking from the longer one first. It returns their line budgets; 0
+// leaves a list whole.
+func listBudgets(tableLines, managedLines, overflow int) (table, managed int) {
+	table, managed = tableLines, managedLines
+	for overflow > 0 {
+		switch {
+		case table >= managed && table > minListLines:
+			table--
+		case managed > minListLines:
+			managed--
+		case table > minListLines:
+			table--
+		default:
+			overflow = 0
+			continue
+		}
+		overflow--
+	}
+	if table == tableLines {
+		table = 0
+	}
+	if managed == managedLines {
+		managed = 0
+	}
+	return table, managed
+}
+
+// clampLines cuts s to at most height lines.
+func clampLines(s string, height int) string {
+	if height <= 0 {
+		return s
+	}
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	if len(lines) <= height {
+		return s
+	}
+	return strings.Join(lines[:height], "\n")
+}
@@ -0,0 +1,307 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/devports/devpt/pkg/health"
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/registry"
+)
+
+func TestScrollWindow(t *testing.T) {
+	t.Parallel()
+	ones := func(n int) []int {
+		h := make([]int, n)
+		for i := range h {
+			h[i] = 1
+		}
+		return h
+	}
+	cases := []struct {
+		name                     string
+		heights                  []int
+		selected, offset, height int
+		start, end               int
+	}{
+		{"top", ones(10), 0, 0, 4, 0, 4},
+		{"selection in view keeps offset", ones(10), 5, 3, 4, 3, 7},
+		{"scrolls down only as needed", ones(10), 7, 3, 4, 4, 8},
+		{"scrolls up to selection", ones(10), 2, 5, 4, 2, 6},
+		{"fills from above at the end", ones(10), 9, 9, 4, 6, 10},
+		{"multi-line rows", []int{1, 3, 1, 2, 1}, 3, 0, 4, 2, 5},
+		{"row taller than window", []int{1, 6, 1}, 1, 0, 4, 1, 2},
+		{"empty", nil, 0, 0, 4, 0, 0},
+	}
+	for _, tc := range cases {
+		start, end := scrollWindow(tc.heights, tc.selected, tc.offset, tc.height)
+		if start != tc.start || end != tc.end {
+			t.Errorf("%s: scrollWindow = [%d, %d), want [%d, %d)", tc.name, start, end, tc.start, tc.end)
+		}
+	}
+}
+
+func TestScrollRows(t *testing.T) {
+	t.Parallel()
+	var rows [][]string
+	for i := 1; i <= 40; i++ {
+		rows = append(rows, []string{fmt.Sprintf("row %d", i)})
+	}
+	offset := 0
+	got, rowAt := scrollRows(rows, 20, &offset, 6, 40)
+	if len(got) != 6 {
+		t.Fatalf("got %d lines, want 6: %q", len(got), got)
+	}
+	if got[4] != "row 21" || !strings.HasPrefix(got[5], "17–21 of 40 (↑/↓ for more)") {
+		t.Errorf("window = %q", got)
+	}
+	if offset != 16 {
+		t.Errorf("offset = %d, want 16", offset)
+	}
+	if want := []int{16, 17, 18, 19, 20, -1}; !slices.Equal(rowAt, want) {
+		t.Errorf("rowAt = %v, want %v", rowAt, want)
+	}
+	if got, _ := scrollRows(rows[:3], 0, &offset, 6, 40); len(got) != 3 {
+		t.Errorf("rows that fit were cut: %q", got)
+	}
+}
+
+func TestListBudgets(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		table, managed, overflow int
+		wantTable, wantManaged   int
+	}{
+		{40, 5, 10, 30, 0},
+		{10, 10, 6, 7, 7},
+		{6, 5, 20, minListLines, minListLines},
+		{2, 30, 5, 0, 25},
+	}
+	for _, tc := range cases {
+		table, managed := listBudgets(tc.table, tc.managed, tc.overflow)
+		if table != tc.wantTable || managed != tc.wantManaged {
+			t.Errorf("listBudgets(%d, %d, %d) = %d, %d, want %d, %d", tc.table, tc.managed, tc.overflow, table, managed, tc.wantTable, tc.wantManaged)
+		}
+	}
+}
+
+func TestViewFitsTerminal(t *testing.T) {
+	t.Parallel()
+	var servers []*models.ServerInfo
+	for i := 0; i < 45; i++ {
+		servers = append(servers, &models.ServerInfo{
+			ProcessRecord: &models.ProcessRecord{PID: 1000 + i, Port: 3000 + i, Command: "node server.js"},
+			Status:        "running",
+		})
+	}
+	m := newTestTopModel(t, servers)
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	m = next.(topModel)
+	for i := 0; i < 40; i++ {
+		next, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+		m = next.(topModel)
+	}
+	out := m.View()
+	if n := strings.Count(out, "\n"); n > 30 {
+		t.Errorf("view is %d lines tall, want at most 30", n)
+	}
+	if !strings.Contains(out, "of 45") {
+		t.Errorf("view lacks the row range:\n%s", out)
+	}
+	selected := m.visibleServers()[m.selected]
+	if !strings.Contains(out, fmt.Sprintf("%d", selected.ProcessRecord.PID)) {
+		t.Errorf("selected row PID %d scrolled out of view", selected.ProcessRecord.PID)
+	}
+}
+
+// newTestTopModel returns a TUI model over servers with an empty registry.
+func newTestTopModel(t *testing.T, servers []*models.ServerInfo) topModel {
+	t.Helper()
+	dir := t.TempDir()
+	app := &App{
+		// Refreshes take the scheduler lock in ConfigDir.
+		config:   models.ConfigPaths{ConfigDir: dir},
+		registry: registry.NewRegistry(filepath.Join(dir, "registry.json")),
+		showAll:  true,
+	}
+	return topModel{
+		app:        app,
+		servers:    servers,
+		mode:       viewModeTable,
+		health:     make(map[int]string),
+		healthHist: health.NewHistory(health.DefaultHistorySize),
+		urlIndex:   make(map[string]int),
+		scroll:     &scrollState{},
+	}
+}
+
+func TestLogsViewScrolls(t *testing.T) {
+	t.Parallel()
+	m := newTestTopModel(t, nil)
+	m.mode = viewModeLogs
+	m.followLogs = true
+	m.logSvc = &models.ManagedService{Name: "api"}
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 30})
+	m = next.(topModel)
+	var lines []string
+	for i := 1; i <= 100; i++ {
+		lines = append(lines, fmt.Sprintf("log line %03d", i))
+	}
+	next, _ = m.Update(logMsg{lines: lines})
+	m = next.(topModel)
+
+	out := m.View()
+	if !strings.Contains(out, "log line 100") || strings.Contains(out, "log line 001") {
+		t.Errorf("following view does not end at the last line:\n%s", out)
+	}
+	if n := strings.Count(out, "\n"); n != 30 {
+		t.Errorf("view is %d lines tall, want the terminal's 30", n)
+	}
+
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyPgUp})
+	m = next.(topModel)
+	if m.followLogs {
+		t.Error("still following after scrolling up")
+	}
+	if strings.Contains(m.View(), "log line 100") {
+		t.Error("last line still shown after scrolling up")
+	}
+	next, _ = m.Update(logMsg{lines: append(lines, "log line 101")})
+	m = next.(topModel)
+	if strings.Contains(m.View(), "log line 101") {
+		t.Error("new line scrolled into view while not following")
+	}
+
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnd})
+	m = next.(topModel)
+	if !m.followLogs || !strings.Contains(m.View(), "log line 101") {
+		t.Error("End did not resume following")
+	}
+}
+
+func TestLogsViewFitsNarrowTerminals(t *testing.T) {
+	t.Parallel()
+	m := newTestTopModel(t, nil)
+	m.mode = viewModeLogs
+	m.followLogs = true
+	m.logSvc = &models.ManagedService{Name: "api"}
+	m.cmdStatus = "Restarted api"
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 40, Height: 20})
+	m = next.(topModel)
+	var lines []string
+	for i := 1; i <= 100; i++ {
+		lines = append(lines, fmt.Sprintf("log line %03d", i))
+	}
+	next, _ = m.Update(logMsg{lines: lines})
+	m = next.(topModel)
+
+	out := m.View()
+	if n := strings.Count(out, "\n"); n != 20 {
+		t.Errorf("view is %d lines tall with a wrapped header and footer, want 20:\n%s", n, out)
+	}
+	if !strings.Contains(out, "log line 100") {
+		t.Errorf("last line not shown:\n%s", out)
+	}
+}
+
+func TestLogsViewUsesReboundScrollKeys(t *testing.T) {
+	t.Parallel()
+	m := newTestTopModel(t, nil)
+	m.keys = keyMapFor(&models.Config{TUI: models.TUISettings{Keys: map[string]models.KeyBinding{
+		"page_up": {"ctrl+b"},
+		"top":     {"g"},
+	}}})
+	m.mode = viewModeLogs
+	m.followLogs = true
+	m.logSvc = &models.ManagedService{Name: "api"}
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 30})
+	m = next.(topModel)
+	var lines []string
+	for i := 1; i <= 100; i++ {
+		lines = append(lines, fmt.Sprintf("log line %03d", i))
+	}
+	next, _ = m.Update(logMsg{lines: lines})
+	m = next.(topModel)
+
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyPgUp})
+	m = next.(topModel)
+	if !m.followLogs {
+		t.Error("PgUp still scrolls after page_up was rebound")
+	}
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlB})
+	m = next.(topModel)
+	if m.followLogs {
+		t.Error("ctrl+b did not scroll up once bound to page_up")
+	}
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("g")})
+	m = next.(topModel)
+	if !strings.Contains(m.View(), "log line 001") {
+		t.Error("g did not jump to the top once bound to top")
+	}
+}
+
+func TestRestartFromLogsView(t *testing.T) {
+	t.Parallel()
+	m := newTestTopModel(t, nil)
+	m.mode = viewModeLogs
+	m.logPID = 42
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	m = next.(topModel)
+	if cmd != nil || m.mode != viewModeLogs || m.cmdStatus != "Only managed services can be restarted" {
+		t.Errorf("unmanaged logs: mode %v, status %q", m.mode, m.cmdStatus)
+	}
+
+	svc := &models.ManagedService{Name: "api", CWD: t.TempDir(), Command: "go run .", Ephemeral: true}
+	if err := m.app.registry.AddService(svc); err != nil {
+		t.Fatal(err)
+	}
+	m.logSvc, m.logPID = svc, 0
+	m.followLogs = false
+	if !strings.Contains(m.View(), "^R restart") {
+		t.Errorf("logs header does not offer restart:\n%s", m.View())
+	}
+	next, cmd = m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	m = next.(topModel)
+	if cmd != nil || m.mode != viewModeLogs || !strings.Contains(m.cmdStatus, "registered by devpt run") {
+		t.Errorf("failed restart: mode %v, status %q", m.mode, m.cmdStatus)
+	}
+}
+
+func TestLogsViewAppendsStreamedLines(t *testing.T) {
+	t.Parallel()
+	m := newTestTopModel(t, nil)
+	m.mode = viewModeLogs
+	m.followLogs = true
+	m.logSvc = &models.ManagedService{Name: "api"}
+	stream := make(chan string, 1)
+	stopped := false
+	m.logStream, m.logStop = stream, func() { stopped = true }
+	m.logLines = []string{"booting"}
+
+	next, cmd := m.Update(logLinesMsg{stream: stream, lines: []string{"ready", "GET / 200"}})
+	m = next.(topModel)
+	if got := strings.Join(m.logLines, "|"); got != "booting|ready|GET / 200" || cmd == nil {
+		t.Fatalf("log lines = %q, waiting again: %v", got, cmd != nil)
+	}
+	stream <- "GET /users 200"
+	if msg := cmd().(logLinesMsg); len(msg.lines) != 1 || msg.lines[0] != "GET /users 200" {
+		t.Errorf("next streamed lines = %+v", msg)
+	}
+
+	next, _ = m.Update(logLinesMsg{stream: make(chan string), lines: []string{"stale"}})
+	m = next.(topModel)
+	if strings.Contains(strings.Join(m.logLines, "|"), "stale") {
+		t.Error("lines of an earlier stream shown")
+	}
+
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b")})
+	m = next.(topModel)
+	if !stopped || m.logStream != nil || m.mode != viewModeTable {
+		t.Errorf("leaving the logs view: stopped %v, mode %v", stopped, m.mode)
+	}
+}
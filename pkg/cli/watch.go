@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// DefaultWatchInterval is how often watch re-discovers servers.
+const DefaultWatchInterval = 2 * time.Second
+
+// watchEntry is the part of a server's state that watch reports on.
+type watchEntry struct {
+	Name   string
+	Status string
+	Port   int
+	PID    int
+	Source models.Source
+}
+
+// Watch change kinds
+const (
+	watchAdded   = "added"
+	watchRemoved = "removed"
+	watchChanged = "changed"
+)
+
+// watchChange is one line of watch output.
+type watchChange struct {
+	Time     time.Time     `json:"time"`
+	Type     string        `json:"type"`
+	Name     string        `json:"name"`
+	Status   string        `json:"status"`
+	Previous string        `json:"previous_status,omitempty"`
+	Port     int           `json:"port,omitempty"`
+	PID      int           `json:"pid,omitempty"`
+	Source   models.Source `json:"source,omitempty"`
+}
+
+// WatchCmd prints the current servers and then one line per change until
+// interrupted. name restricts output to one managed service; all includes
+// unmanaged listeners, which are otherwise skipped. Like the TUI, it runs
+// scheduled jobs and services while it is open.
+func (a *App) WatchCmd(name string, all, asJSON bool, interval time.Duration) error {
+	if name != "" && a.registry.GetService(name) == nil {
+		return errServiceNotFound(name)
+	}
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	show := func(c watchChange) error {
+		if asJSON {
+			data, err := json.Marshal(c)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(a.out(), string(data))
+			return nil
+		}
+		fmt.Fprintln(a.out(), formatWatchChange(c))
+		return nil
+	}
+
+	prev := map[string]watchEntry{}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		a.reloadRegistry()
+		a.runDueSchedules(time.Now())
+		a.sampleResources(time.Now())
+		servers, err := a.discoverServers()
+		if err != nil {
+			fmt.Fprintf(a.errOut(), "Warning: %v\n", err)
+		} else {
+			next := watchSnapshot(servers, name, all)
+			for _, c := range diffWatch(prev, next, time.Now()) {
+				if err := show(c); err != nil {
+					return err
+				}
+			}
+			prev = next
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// reloadRegistry picks up services added or changed by other devpt processes.
+func (a *App) reloadRegistry() {
+	if err := a.registry.Load(); err != nil {
+		fmt.Fprintf(a.errOut(), "Warning: failed to reload registry: %v\n", err)
+	}
+}
+
+// watchSnapshot reduces discovered servers to the entries watch tracks, keyed
+// by managed service name or, for unmanaged listeners, by port.
+func watchSnapshot(servers []*models.ServerInfo, name string, all bool) map[string]watchEntry {
+	out := make(map[string]watchEntry)
+	for _, srv := range servers {
+		e := watchEntry{Status: srv.Status, Source: srv.Source}
+		if srv.ProcessRecord != nil {
+			e.Port = srv.ProcessRecord.Port
+			e.PID = srv.ProcessRecord.PID
+		}
+		var key string
+		switch {
+		case srv.ManagedService != nil:
+			e.Name = srv.ManagedService.Name
+			e.Source = models.SourceManaged
+			if ports := srv.ManagedService.ActivePorts(); e.Port == 0 && len(ports) > 0 {
+				e.Port = ports[0]
+			}
+			if e.PID == 0 && srv.ManagedService.LastPID != nil && !isCrashStatus(e.Status) && e.Status != "stopped" {
+				e.PID = *srv.ManagedService.LastPID
+			}
+			key = e.Name
+		case all && srv.ProcessRecord != nil:
+			e.Name = unmanagedName(srv.ProcessRecord)
+			key = "port:" + strconv.Itoa(e.Port)
+		default:
+			continue
+		}
+		if name != "" && e.Name != name {
+			continue
+		}
+		// A managed service listening on several ports is reported once, on its lowest port.
+		if existing, ok := out[key]; ok && existing.Port != 0 && existing.Port < e.Port {
+			continue
+		}
+		out[key] = e
+	}
+	return out
+}
+
+// unmanagedName labels a listener that is not a managed service.
+func unmanagedName(rec *models.ProcessRecord) string {
+	switch {
+	case rec.ProjectRoot != "":
+		return pathBase(rec.ProjectRoot)
+	case rec.CWD != "":
+		return pathBase(rec.CWD)
+	case rec.Command != "":
+		return pathBase(rec.Command)
+	}
+	return "-"
+}
+
+// diffWatch lists what changed between two snapshots, ordered by name.
+func diffWatch(prev, next map[string]watchEntry, now time.Time) []watchChange {
+	var out []watchChange
+	for key, e := range next {
+		old, existed := prev[key]
+		switch {
+		case !existed:
+			out = append(out, newWatchChange(now, watchAdded, e, ""))
+		case old.Status != e.Status || old.PID != e.PID || old.Port != e.Port:
+			out = append(out, newWatchChange(now, watchChanged, e, old.Status))
+		}
+	}
+	for key, old := range prev {
+		if _, ok := next[key]; !ok {
+			c := newWatchChange(now, watchRemoved, old, old.Status)
+			c.Status = "gone"
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Name != out[j].Name {
+			return out[i].Name < out[j].Name
+		}
+		return out[i].Port < out[j].Port
+	})
+	return out
+}
+
+func newWatchChange(now time.Time, kind string, e watchEntry, previous string) watchChange {
+	return watchChange{
+		Time:     now,
+		Type:     kind,
+		Name:     e.Name,
+		Status:   e.Status,
+		Previous: previous,
+		Port:     e.Port,
+		PID:      e.PID,
+		Source:   e.Source,
+	}
+}
+
+// formatWatchChange renders a change as e.g.
+// "15:04:05  api  running  :8080  pid=123  (was starting)".
+func formatWatchChange(c watchChange) string {
+	port, pid := "-", "-"
+	if c.Port > 0 {
+		port = ":" + strconv.Itoa(c.Port)
+	}
+	if c.PID > 0 {
+		pid = "pid=" + strconv.Itoa(c.PID)
+	}
+	line := fmt.Sprintf("%s  %-20s %-14s %-7s %-10s", c.Time.Format("15:04:05"), c.Name, c.Status, port, pid)
+	if c.Previous != "" && c.Previous != c.Status {
+		line += fmt.Sprintf("  (was %s)", c.Previous)
+	}
+	return line
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestDiffWatchReportsAddedChangedAndRemoved(t *testing.T) {
+	t.Parallel()
+
+	pid := 42
+	api := &models.ManagedService{Name: "api", Ports: []int{8080}, LastPID: &pid}
+	web := &models.ManagedService{Name: "web", Ports: []int{3000}}
+	before := []*models.ServerInfo{
+		{ManagedService: api, Source: models.SourceManaged, Status: "starting"},
+		{ManagedService: web, Source: models.SourceManaged, Status: "stopped"},
+		{ProcessRecord: &models.ProcessRecord{PID: 7, Port: 5432, Command: "/usr/bin/postgres"}, Source: models.SourceManual, Status: "running"},
+	}
+	after := []*models.ServerInfo{
+		{ManagedService: api, ProcessRecord: &models.ProcessRecord{PID: 42, Port: 8080}, Source: models.SourceManaged, Status: "running"},
+		{ManagedService: web, Source: models.SourceManaged, Status: "stopped"},
+	}
+
+	now := time.Now()
+	initial := diffWatch(nil, watchSnapshot(before, "", true), now)
+	if len(initial) != 3 || initial[0].Type != watchAdded {
+		t.Fatalf("expected every server to be reported as added, got %+v", initial)
+	}
+
+	changes := diffWatch(watchSnapshot(before, "", true), watchSnapshot(after, "", true), now)
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %+v", changes)
+	}
+	if c := changes[0]; c.Name != "api" || c.Type != watchChanged || c.Status != "running" || c.Previous != "starting" {
+		t.Fatalf("unexpected api change: %+v", c)
+	}
+	if c := changes[1]; c.Name != "postgres" || c.Type != watchRemoved || c.Port != 5432 {
+		t.Fatalf("unexpected removal: %+v", c)
+	}
+
+	if got := watchSnapshot(before, "", false); len(got) != 2 {
+		t.Fatalf("unmanaged listeners should be skipped without --all, got %+v", got)
+	}
+	if got := watchSnapshot(before, "web", false); len(got) != 1 || got["web"].Status != "stopped" {
+		t.Fatalf("name filter failed: %+v", got)
+	}
+}
@@ -0,0 +1,303 @@
+package devpt
+
+import (
+	"context"
+	"fmt"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devports/devpt/pkg/health"
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/registry"
+	"github.com/devports/devpt/pkg/scanner"
+)
+
+// Options configures a Client. Zero values use what the devpt command
+// uses: the registry, logs and config.json under ~/.config/devpt.
+type Options struct {
+	// ConfigDir replaces ~/.config/devpt.
+	ConfigDir string
+	// All reports every listener, not only those that look like dev
+	// servers.
+	All bool
+	// Via names the program in the runs it starts, e.g. "my-dashboard".
+	Via string
+
+	Scanner   Scanner
+	Registry  Registry
+	Processes ProcessManager
+	Jobs      JobRunner
+	Health    HealthChecker
+}
+
+// Client discovers dev servers and controls registered services. It is not
+// safe for concurrent use.
+type Client struct {
+	scanner    Scanner
+	registry   Registry
+	processes  ProcessManager
+	jobs       JobRunner
+	health     HealthChecker
+	ports      models.PortSettings
+	crashLoop  models.CrashLoopSettings
+	resolver   *scanner.ProjectResolver
+	detector   *scanner.AgentDetector
+	filter     *scanner.DevFilter
+	frameworks *scanner.ProcessScanner
+	all        bool
+	via        string
+}
+
+// New creates a Client.
+func New(opts Options) (*Client, error) {
+	paths := models.ConfigPathsIn(opts.ConfigDir)
+	if opts.ConfigDir == "" {
+		var err error
+		if paths, err = models.GetConfigPaths(); err != nil {
+			return nil, fmt.Errorf("failed to get config paths: %w", err)
+		}
+	}
+	settings, err := models.LoadConfig(paths.ConfigFile)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		scanner:    opts.Scanner,
+		registry:   opts.Registry,
+		processes:  opts.Processes,
+		jobs:       opts.Jobs,
+		health:     opts.Health,
+		ports:      settings.Ports,
+		crashLoop:  settings.CrashLoop,
+		resolver:   scanner.NewProjectResolver(),
+		detector:   scanner.NewAgentDetector(settings.Agents),
+		filter:     scanner.NewDevFilter(settings.Scan.Include, settings.Scan.Exclude),
+		frameworks: scanner.NewProcessScanner(),
+		all:        opts.All,
+		via:        opts.Via,
+	}
+	if c.scanner == nil {
+		c.scanner = NewScanner()
+	}
+	if c.registry == nil {
+		reg := registry.NewRegistry(paths.RegistryFile)
+		if err := reg.Load(); err != nil {
+			return nil, err
+		}
+		c.registry = reg
+	}
+	if c.processes == nil {
+		c.processes = NewProcessManager(paths.LogsDir)
+	}
+	if c.jobs == nil {
+		c.jobs = NewJobRunner(paths.JobLogsDir, c.registry)
+	}
+	if c.health == nil {
+		c.health = NewHealthChecker(health.Thresholds{
+			Slow:    settings.Health.SlowThreshold.Std(),
+			Timeout: settings.Health.TimeoutThreshold.Std(),
+		})
+	}
+	if c.via == "" {
+		c.via = "library"
+	}
+	return c, nil
+}
+
+// Servers lists the dev servers listening on this machine and every
+// registered service, matched up where a service is running. Services that
+// are not listening have the status IdleStatus gives them.
+func (c *Client) Servers(ctx context.Context) ([]*models.ServerInfo, error) {
+	records, err := c.scanner.Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan processes: %w", err)
+	}
+	if !c.all {
+		records = c.filter.Filter(records)
+	}
+	for _, rec := range records {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		Enrich(rec, c.resolver, c.detector, c.frameworks)
+	}
+
+	servers := scanner.NewServers(records)
+	for _, svc := range scanner.MatchManaged(servers, c.registry.ListServices(), c.resolver) {
+		servers = append(servers, &models.ServerInfo{
+			ManagedService: svc,
+			Source:         models.SourceManaged,
+			Status:         c.idleStatus(svc),
+		})
+	}
+	return servers, nil
+}
+
+// idleStatus is the status of a service no listener matched.
+func (c *Client) idleStatus(svc *models.ManagedService) string {
+	alive := svc.LastPID != nil && *svc.LastPID > 0 && c.processes.IsRunning(*svc.LastPID)
+	return IdleStatus(svc, alive, svc.LastExit, c.crashLoop, time.Now())
+}
+
+// Server returns the server named by id: a registered service's name or a
+// port.
+func (c *Client) Server(ctx context.Context, id string) (*models.ServerInfo, error) {
+	servers, err := c.Servers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	port, _ := strconv.Atoi(id)
+	for _, srv := range servers {
+		if srv.ManagedService != nil && srv.ManagedService.Name == id {
+			return srv, nil
+		}
+		if port > 0 && srv.ProcessRecord != nil && srv.ProcessRecord.Port == port {
+			return srv, nil
+		}
+	}
+	return nil, fmt.Errorf("server %q: %w", id, ErrServiceNotFound)
+}
+
+// service returns the registered service called name.
+func (c *Client) service(name string) (*models.ManagedService, error) {
+	svc := c.registry.GetService(name)
+	if svc == nil {
+		return nil, fmt.Errorf("service %q: %w", name, ErrServiceNotFound)
+	}
+	return svc, nil
+}
+
+// Start starts a registered service and returns its PID. Like devpt
+// start, it fails with ErrPortConflict while another process holds one of
+// the service's ports, runs the jobs the service requires first, and gives
+// services with automatic ports a free one as $PORT. Unlike devpt start, it
+// never stops the processes holding its ports.
+func (c *Client) Start(ctx context.Context, name string) (int, error) {
+	svc, err := c.service(name)
+	if err != nil {
+		return 0, err
+	}
+	if svc.Ephemeral {
+		return 0, fmt.Errorf("service %q was registered by devpt run and ends with it", name)
+	}
+	if svc.LastPID != nil && *svc.LastPID > 0 && c.processes.IsRunning(*svc.LastPID) {
+		return 0, fmt.Errorf("service %q is %w (PID %d)", name, ErrAlreadyRunning, *svc.LastPID)
+	}
+	if err := c.checkPorts(ctx, svc); err != nil {
+		return 0, err
+	}
+	if err := RunPrerequisites(ctx, svc, c.registry, c.jobs, nil); err != nil {
+		return 0, err
+	}
+
+	var env []string
+	runPort := 0
+	if svc.AutoPort {
+		if runPort, err = AllocatePort(svc, c.registry.ListServices(), c.ports); err != nil {
+			return 0, err
+		}
+		env = append(env, fmt.Sprintf("PORT=%d", runPort))
+	}
+	pid, err := c.processes.Start(ctx, svc, env)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start service: %w", err)
+	}
+	if err := c.registry.UpdateServicePID(name, pid); err != nil {
+		return pid, err
+	}
+	if err := c.registry.SetRunPort(name, runPort); err != nil {
+		return pid, err
+	}
+	run := models.RunRecord{PID: pid, StartedAt: time.Now(), Actor: c.actor()}
+	return pid, c.registry.RecordRun(name, run)
+}
+
+// checkPorts fails with ErrPortConflict when svc's declared ports are
+// already bound.
+func (c *Client) checkPorts(ctx context.Context, svc *models.ManagedService) error {
+	if len(svc.Ports) == 0 {
+		return nil
+	}
+	var conflicts []PortConflict
+	if records, err := c.scanner.Scan(ctx); err == nil {
+		conflicts = PortConflicts(svc, c.registry.ListServices(), records)
+	} else if ctx.Err() != nil {
+		return ctx.Err()
+	} else {
+		conflicts = BoundPorts(svc)
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+	lines := make([]string, len(conflicts))
+	for i, conflict := range conflicts {
+		lines[i] = conflict.String()
+	}
+	return fmt.Errorf("%w: %s", ErrPortConflict, strings.Join(lines, "; "))
+}
+
+// Stop stops a registered service that is running.
+func (c *Client) Stop(ctx context.Context, name string) error {
+	svc, err := c.service(name)
+	if err != nil {
+		return err
+	}
+	pid := 0
+	if srv, err := c.Server(ctx, name); err == nil && srv.ProcessRecord != nil {
+		pid = srv.ProcessRecord.PID
+	} else if svc.LastPID != nil && c.processes.IsRunning(*svc.LastPID) {
+		pid = *svc.LastPID
+	}
+	if pid == 0 {
+		return fmt.Errorf("service %q is %w", name, ErrNotRunning)
+	}
+	if err := c.processes.Stop(ctx, svc, pid); err != nil {
+		return fmt.Errorf("failed to stop process: %w", err)
+	}
+	if err := c.registry.ClearServicePID(name); err != nil {
+		return err
+	}
+	return c.registry.FinishRun(name, pid, "stopped", time.Now())
+}
+
+// Logs returns the last lines of a registered service's newest log.
+func (c *Client) Logs(ctx context.Context, name string, lines int) ([]string, error) {
+	if _, err := c.service(name); err != nil {
+		return nil, err
+	}
+	return c.processes.Tail(ctx, name, lines)
+}
+
+// Health probes the server named by id, a service name or a port.
+func (c *Client) Health(ctx context.Context, id string) (*health.HealthCheck, error) {
+	srv, err := c.Server(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	svc := srv.ManagedService
+	switch {
+	case srv.ProcessRecord != nil && svc != nil:
+		return c.health.CheckService(ctx, svc, srv.ProcessRecord.Port), nil
+	case srv.ProcessRecord != nil:
+		var cfg *models.HealthCheckConfig
+		if srv.ProcessRecord.Infra != "" {
+			cfg = health.InfraConfig(srv.ProcessRecord.Infra)
+		}
+		return c.health.Check(ctx, srv.ProcessRecord.Port, cfg), nil
+	case svc != nil && svc.Health != nil && svc.Health.Command != "" && srv.Status == "running":
+		return c.health.CheckService(ctx, svc, 0), nil
+	}
+	return nil, fmt.Errorf("%s is %w", id, ErrNotRunning)
+}
+
+// actor describes the runs this client starts.
+func (c *Client) actor() models.RunActor {
+	actor := models.RunActor{Via: c.via}
+	if u, err := user.Current(); err == nil {
+		actor.User = u.Username
+	}
+	return actor
+}
@@ -0,0 +1,211 @@
+package devpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/devports/devpt/pkg/health"
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/registry"
+)
+
+type fakeScanner struct {
+	records []*models.ProcessRecord
+}
+
+func (s *fakeScanner) Scan(ctx context.Context) ([]*models.ProcessRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.records, nil
+}
+
+type fakeProcesses struct {
+	running map[int]bool
+	nextPID int
+	stopped []int
+	env     []string // of the last start
+}
+
+func (p *fakeProcesses) Start(ctx context.Context, svc *models.ManagedService, env []string) (int, error) {
+	p.running[p.nextPID] = true
+	p.env = env
+	return p.nextPID, nil
+}
+
+func (p *fakeProcesses) Stop(ctx context.Context, svc *models.ManagedService, pid int) error {
+	delete(p.running, pid)
+	p.stopped = append(p.stopped, pid)
+	return nil
+}
+
+func (p *fakeProcesses) IsRunning(pid int) bool { return p.running[pid] }
+
+func (p *fakeProcesses) Tail(ctx context.Context, name string, lines int) ([]string, error) {
+	return []string{"listening on :3000"}, nil
+}
+
+type fakeHealth struct{}
+
+func (fakeHealth) Check(ctx context.Context, port int, cfg *models.HealthCheckConfig) *health.HealthCheck {
+	return &health.HealthCheck{Port: port, Status: health.HealthOK}
+}
+
+func (fakeHealth) CheckService(ctx context.Context, svc *models.ManagedService, port int) *health.HealthCheck {
+	return &health.HealthCheck{Port: port, Status: health.HealthOK, Message: svc.Name}
+}
+
+func newTestClient(t *testing.T, records ...*models.ProcessRecord) (*Client, *fakeProcesses) {
+	t.Helper()
+	dir := t.TempDir()
+	reg := registry.NewRegistry(filepath.Join(dir, "registry.json"))
+	if err := reg.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.AddService(&models.ManagedService{Name: "web", CWD: dir, Command: "npm run dev", Ports: []int{3000}}); err != nil {
+		t.Fatal(err)
+	}
+	procs := &fakeProcesses{running: map[int]bool{}, nextPID: 4242}
+	c, err := New(Options{
+		ConfigDir: dir,
+		All:       true,
+		Scanner:   &fakeScanner{records: records},
+		Registry:  reg,
+		Processes: procs,
+		Health:    fakeHealth{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, procs
+}
+
+func TestClientServersReportsStoppedServices(t *testing.T) {
+	t.Parallel()
+	c, _ := newTestClient(t, &models.ProcessRecord{PID: 99, Port: 8080, Command: "python -m http.server"})
+
+	servers, err := c.Servers(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(servers) != 2 {
+		t.Fatalf("got %d servers, want 2", len(servers))
+	}
+	web, err := c.Server(context.Background(), "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if web.Status != "stopped" || web.ProcessRecord != nil {
+		t.Fatalf("web = %+v, want stopped without a process", web)
+	}
+	if _, err := c.Server(context.Background(), "8080"); err != nil {
+		t.Fatalf("Server(8080): %v", err)
+	}
+	if _, err := c.Server(context.Background(), "api"); !errors.Is(err, ErrServiceNotFound) {
+		t.Fatalf("Server(api) error = %v, want ErrServiceNotFound", err)
+	}
+}
+
+func TestClientStartStopRecordsRuns(t *testing.T) {
+	t.Parallel()
+	c, procs := newTestClient(t)
+	ctx := context.Background()
+
+	pid, err := c.Start(ctx, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pid != 4242 {
+		t.Fatalf("pid = %d, want 4242", pid)
+	}
+	svc := c.registry.GetService("web")
+	if len(svc.Runs) != 1 || svc.Runs[0].Actor.Via != "library" {
+		t.Fatalf("runs = %+v, want one run via library", svc.Runs)
+	}
+	if _, err := c.Start(ctx, "web"); !errors.Is(err, ErrAlreadyRunning) {
+		t.Fatalf("second Start error = %v, want ErrAlreadyRunning", err)
+	}
+
+	if err := c.Stop(ctx, "web"); err != nil {
+		t.Fatal(err)
+	}
+	if len(procs.stopped) != 1 || procs.stopped[0] != 4242 {
+		t.Fatalf("stopped = %v, want [4242]", procs.stopped)
+	}
+	if err := c.Stop(ctx, "web"); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("second Stop error = %v, want ErrNotRunning", err)
+	}
+}
+
+func TestClientHonorsCanceledContext(t *testing.T) {
+	t.Parallel()
+	c, _ := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := c.Servers(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Servers error = %v, want context.Canceled", err)
+	}
+}
+
+func TestClientStartRefusesTakenPorts(t *testing.T) {
+	t.Parallel()
+	c, procs := newTestClient(t, &models.ProcessRecord{PID: 99, Port: 3000, Command: "node other.js"})
+
+	_, err := c.Start(context.Background(), "web")
+	if !errors.Is(err, ErrPortConflict) || !strings.Contains(err.Error(), "PID 99") {
+		t.Fatalf("Start error = %v, want ErrPortConflict naming PID 99", err)
+	}
+	if len(procs.running) != 0 {
+		t.Fatalf("started %v despite the conflict", procs.running)
+	}
+}
+
+func TestClientStartRunsPrerequisitesAndAllocatesPorts(t *testing.T) {
+	t.Parallel()
+	c, procs := newTestClient(t)
+	dir := c.registry.GetService("web").CWD
+	for _, job := range []*models.Job{
+		{Name: "migrate", CWD: dir, Command: "echo migrated"},
+		{Name: "seed", CWD: dir, Command: "ls missing-seed-file"},
+	} {
+		if err := c.registry.(*registry.Registry).AddJob(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, svc := range []*models.ManagedService{
+		{Name: "api", CWD: dir, Command: "go run .", AutoPort: true, Requires: []string{"migrate"}},
+		{Name: "worker", CWD: dir, Command: "go run ./worker", Requires: []string{"seed"}},
+	} {
+		if err := c.registry.AddService(svc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := context.Background()
+
+	if _, err := c.Start(ctx, "worker"); err == nil || !strings.Contains(err.Error(), `job "seed" exited`) || !strings.Contains(err.Error(), "missing-seed-file") {
+		t.Fatalf("Start(worker) error = %v, want the seed failure with its output", err)
+	}
+	if len(procs.running) != 0 {
+		t.Fatalf("started %v after a failed prerequisite", procs.running)
+	}
+
+	if _, err := c.Start(ctx, "api"); err != nil {
+		t.Fatal(err)
+	}
+	if len(procs.env) != 1 || !strings.HasPrefix(procs.env[0], "PORT=") {
+		t.Fatalf("env = %v, want an allocated PORT", procs.env)
+	}
+	api := c.registry.GetService("api")
+	if api.RunPort == 0 || procs.env[0] != fmt.Sprintf("PORT=%d", api.RunPort) {
+		t.Fatalf("run port = %d, env = %v", api.RunPort, procs.env)
+	}
+	runs := c.registry.(*registry.Registry).RecentJobRuns("", 10)
+	if len(runs) != 2 || runs[0].Job != "migrate" || runs[0].For != "api" || !runs[0].Succeeded() {
+		t.Fatalf("job runs = %+v, want migrate's run for api last", runs)
+	}
+}
@@ -0,0 +1,157 @@
+// Package devpt lets Go programs embed Dev Process Tracker. A Client finds
+// the dev servers listening on this machine, matches them to the services
+// registered with devpt, and starts, stops, tails and health-checks those
+// services. It shares the registry and logs with the devpt command and
+// never writes to the terminal; problems are returned as errors.
+//
+//	c, err := devpt.New(devpt.Options{})
+//	if err != nil {
+//		return err
+//	}
+//	servers, err := c.Servers(ctx)
+//
+// Each part a Client uses is an interface, so programs can supply their
+// own Scanner, Registry, ProcessManager, JobRunner or HealthChecker through
+// Options. The devpt command is built on the same parts and on the helpers
+// here that decide ports, prerequisites and statuses.
+package devpt
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/devports/devpt/pkg/health"
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/process"
+	"github.com/devports/devpt/pkg/registry"
+	"github.com/devports/devpt/pkg/scanner"
+)
+
+// Errors Client methods fail with, for callers to match with errors.Is.
+var (
+	ErrServiceNotFound = errors.New("service not found")
+	ErrAlreadyRunning  = errors.New("already running")
+	ErrNotRunning      = errors.New("not running")
+)
+
+// Scanner lists the processes listening on TCP ports.
+type Scanner interface {
+	Scan(ctx context.Context) ([]*models.ProcessRecord, error)
+}
+
+// Registry stores the services registered with devpt.
+type Registry interface {
+	ListServices() []*models.ManagedService
+	GetService(name string) *models.ManagedService
+	AddService(svc *models.ManagedService) error
+	UpdateService(svc *models.ManagedService) error
+	RemoveService(name string) error
+	UpdateServicePID(name string, pid int) error
+	ClearServicePID(name string) error
+	SetRunPort(name string, port int) error
+	RecordRun(name string, run models.RunRecord) error
+	FinishRun(name string, pid int, outcome string, at time.Time) error
+	GetJob(name string) *models.Job
+	RecordJobRun(run models.JobRun) error
+}
+
+// ProcessManager runs services in the background and reads their logs.
+type ProcessManager interface {
+	// Start starts svc with its output logged and returns its PID. env
+	// holds "KEY=value" entries added to the service's environment. ctx
+	// bounds only the start; the service outlives it.
+	Start(ctx context.Context, svc *models.ManagedService, env []string) (int, error)
+	// Stop stops pid, the process of svc, with the service's stop signal,
+	// killing it if it has not exited by the service's stop timeout. When
+	// ctx is done first, Stop returns its error without killing.
+	Stop(ctx context.Context, svc *models.ManagedService, pid int) error
+	IsRunning(pid int) bool
+	// Tail returns the last lines of the newest log of a service.
+	Tail(ctx context.Context, serviceName string, lines int) ([]string, error)
+}
+
+// HealthChecker probes servers.
+type HealthChecker interface {
+	// Check probes the server on port the way cfg says; nil cfg probes GET /
+	// and falls back to a TCP connect.
+	Check(ctx context.Context, port int, cfg *models.HealthCheckConfig) *health.HealthCheck
+	// CheckService probes svc with its health command when it has one,
+	// otherwise on port.
+	CheckService(ctx context.Context, svc *models.ManagedService, port int) *health.HealthCheck
+}
+
+// Registry is implemented by *registry.Registry as is.
+var _ Registry = (*registry.Registry)(nil)
+
+// NewScanner returns the Scanner devpt itself uses: the platform's native
+// backend where there is one, lsof and ps otherwise. Listeners are
+// annotated with the containers, port-forwards and databases behind them.
+func NewScanner() Scanner {
+	return &processScanner{
+		scanner:    scanner.NewProcessScanner(),
+		containers: scanner.NewContainerResolver(),
+	}
+}
+
+type processScanner struct {
+	scanner    *scanner.ProcessScanner
+	containers *scanner.ContainerResolver
+}
+
+func (s *processScanner) Scan(ctx context.Context) ([]*models.ProcessRecord, error) {
+	records, err := s.scanner.ScanListeningPortsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.containers.AnnotateContext(ctx, records)
+	scanner.AnnotatePortForwards(records)
+	scanner.AnnotateInfra(records)
+	return records, ctx.Err()
+}
+
+// NewProcessManager returns the ProcessManager devpt itself uses, logging
+// to logsDir.
+func NewProcessManager(logsDir string) ProcessManager {
+	return &processManager{m: process.NewManager(logsDir)}
+}
+
+type processManager struct {
+	m *process.Manager
+}
+
+func (p *processManager) Start(ctx context.Context, svc *models.ManagedService, env []string) (int, error) {
+	return p.m.StartWithEnvContext(ctx, svc, env)
+}
+
+func (p *processManager) Stop(ctx context.Context, svc *models.ManagedService, pid int) error {
+	return p.m.StopServiceContext(ctx, svc, pid)
+}
+
+func (p *processManager) IsRunning(pid int) bool {
+	return p.m.IsRunning(pid)
+}
+
+func (p *processManager) Tail(ctx context.Context, serviceName string, lines int) ([]string, error) {
+	return p.m.TailContext(ctx, serviceName, lines)
+}
+
+// NewHealthChecker returns the HealthChecker devpt itself uses, with the
+// given latency thresholds; zero values use the defaults.
+func NewHealthChecker(t health.Thresholds) HealthChecker {
+	c := health.NewChecker(0)
+	c.SetThresholds(t)
+	return &healthChecker{c: c}
+}
+
+type healthChecker struct {
+	c *health.Checker
+}
+
+func (h *healthChecker) Check(ctx context.Context, port int, cfg *models.HealthCheckConfig) *health.HealthCheck {
+	return h.c.CheckWithConfigContext(ctx, port, cfg)
+}
+
+func (h *healthChecker) CheckService(ctx context.Context, svc *models.ManagedService, port int) *health.HealthCheck {
+	return h.c.CheckServiceContext(ctx, svc, port)
+}
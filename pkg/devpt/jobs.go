@@ -0,0 +1,90 @@
+package devpt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/process"
+)
+
+// prerequisiteTailLines is how much of a failed prerequisite's log its
+// error shows.
+const prerequisiteTailLines = 10
+
+// JobRunner runs the jobs services require before they start.
+type JobRunner interface {
+	// Run runs job to completion with its output logged and records the
+	// run; origin carries how it was triggered (its For and Scheduled
+	// fields). ctx bounds only the start of the job.
+	Run(ctx context.Context, job *models.Job, origin models.JobRun) (models.JobRun, error)
+	// Tail returns the last lines of the newest log of a job.
+	Tail(ctx context.Context, job string, lines int) ([]string, error)
+}
+
+// NewJobRunner returns the JobRunner devpt itself uses, logging to logsDir
+// and recording runs in reg.
+func NewJobRunner(logsDir string, reg Registry) JobRunner {
+	return &jobRunner{m: process.NewManager(logsDir), reg: reg}
+}
+
+type jobRunner struct {
+	m   *process.Manager
+	reg Registry
+}
+
+func (j *jobRunner) Run(ctx context.Context, job *models.Job, origin models.JobRun) (models.JobRun, error) {
+	if err := ctx.Err(); err != nil {
+		return models.JobRun{}, err
+	}
+	argv, err := process.CommandArgv(job.Command, job.Shell)
+	if err != nil {
+		return models.JobRun{}, err
+	}
+	started := time.Now()
+	logPath, exit, err := j.m.RunLogged(job.Name, argv, job.CWD, nil)
+	if err != nil {
+		return models.JobRun{}, fmt.Errorf("job %q: %w", job.Name, err)
+	}
+	run := origin
+	run.Job = job.Name
+	run.Command = job.Command
+	run.StartedAt = started
+	run.Duration = models.Duration(time.Since(started))
+	run.Exit = exit
+	run.Log = logPath
+	return run, j.reg.RecordJobRun(run)
+}
+
+func (j *jobRunner) Tail(ctx context.Context, job string, lines int) ([]string, error) {
+	return j.m.TailContext(ctx, job, lines)
+}
+
+// RunPrerequisites runs the jobs svc requires, in order, and stops at the
+// first one that fails; its error ends with the job's last output. before,
+// when set, is called as each job starts.
+func RunPrerequisites(ctx context.Context, svc *models.ManagedService, reg Registry, jobs JobRunner, before func(job string)) error {
+	for _, name := range svc.Requires {
+		job := reg.GetJob(name)
+		if job == nil {
+			return fmt.Errorf("service %q requires job %q, which is not registered", svc.Name, name)
+		}
+		if before != nil {
+			before(name)
+		}
+		run, err := jobs.Run(ctx, job, models.JobRun{For: svc.Name})
+		if err != nil {
+			return err
+		}
+		if !run.Succeeded() {
+			msg := fmt.Sprintf("job %q %s; not starting %q", name, run.Exit.Describe(), svc.Name)
+			if lines, err := jobs.Tail(ctx, name, prerequisiteTailLines); err == nil && len(lines) > 0 {
+				msg += "\n" + strings.Join(lines, "\n")
+			}
+			return fmt.Errorf("%s", msg)
+		}
+	}
+	return nil
+}
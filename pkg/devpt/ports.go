@@ -0,0 +1,147 @@
+package devpt
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"syscall"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// ErrPortConflict is returned when a service's declared port is taken by
+// another process.
+var ErrPortConflict = errors.New("port conflict")
+
+// PortConflict is a declared port that is already bound by another process.
+type PortConflict struct {
+	Port    int
+	PID     int // 0 when the owner could not be identified
+	Command string
+	Service string // registered service owning the PID, if any
+}
+
+func (c PortConflict) String() string {
+	if c.PID == 0 {
+		return fmt.Sprintf("port %d is already in use", c.Port)
+	}
+	owner := fmt.Sprintf("PID %d", c.PID)
+	if c.Command != "" {
+		owner += fmt.Sprintf(" (%s)", truncateCommand(c.Command, 60))
+	}
+	if c.Service != "" {
+		owner += fmt.Sprintf(", managed service %q", c.Service)
+	}
+	return fmt.Sprintf("port %d is already in use by %s", c.Port, owner)
+}
+
+// PortConflicts lists svc's declared ports that one of records, the
+// listeners found by a scan, is bound to. Owners are named after services,
+// the registered services.
+func PortConflicts(svc *models.ManagedService, services []*models.ManagedService, records []*models.ProcessRecord) []PortConflict {
+	if len(svc.Ports) == 0 {
+		return nil
+	}
+	owners := make(map[int]string)
+	for _, s := range services {
+		if s.LastPID != nil && *s.LastPID > 0 {
+			owners[*s.LastPID] = s.Name
+		}
+	}
+	wanted := make(map[int]bool, len(svc.Ports))
+	for _, p := range svc.Ports {
+		wanted[p] = true
+	}
+	var out []PortConflict
+	seen := make(map[int]bool)
+	for _, rec := range records {
+		if rec == nil || !wanted[rec.Port] || seen[rec.Port] {
+			continue
+		}
+		seen[rec.Port] = true
+		out = append(out, PortConflict{
+			Port:    rec.Port,
+			PID:     rec.PID,
+			Command: rec.Command,
+			Service: owners[rec.PID],
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Port < out[j].Port })
+	return out
+}
+
+// BoundPorts is PortConflicts for when listeners cannot be scanned: it
+// tries to bind each of svc's declared ports, and cannot name the owners.
+func BoundPorts(svc *models.ManagedService) []PortConflict {
+	var out []PortConflict
+	for _, port := range svc.Ports {
+		if !PortFree(port) {
+			out = append(out, PortConflict{Port: port})
+		}
+	}
+	return out
+}
+
+// AllocatePort picks the first free port in the range r that none of
+// services other than svc declares or is currently using.
+func AllocatePort(svc *models.ManagedService, services []*models.ManagedService, r models.PortSettings) (int, error) {
+	r = r.Effective()
+	claimed := claimedPorts(svc, services)
+	for port := r.AutoMin; port <= r.AutoMax; port++ {
+		if !claimed[port] && PortFree(port) {
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("no free port in %d-%d (configure ports.auto_min/auto_max)", r.AutoMin, r.AutoMax)
+}
+
+// ReusePort returns preferred when it is free and no other service claims
+// it, and allocates another port otherwise, so a restored run keeps the
+// port it had when it can.
+func ReusePort(svc *models.ManagedService, services []*models.ManagedService, r models.PortSettings, preferred int) (int, error) {
+	if preferred > 0 && !claimedPorts(svc, services)[preferred] && PortFree(preferred) {
+		return preferred, nil
+	}
+	return AllocatePort(svc, services, r)
+}
+
+// claimedPorts returns the ports that services other than svc declare or
+// are currently using.
+func claimedPorts(svc *models.ManagedService, services []*models.ManagedService) map[int]bool {
+	claimed := make(map[int]bool)
+	for _, other := range services {
+		if other.Name == svc.Name {
+			continue
+		}
+		for _, p := range other.ActivePorts() {
+			claimed[p] = true
+		}
+	}
+	return claimed
+}
+
+// PortFree reports whether a TCP port can be bound both on loopback and on
+// all interfaces. Some platforms allow a wildcard bind to shadow a loopback
+// listener, so both are tried.
+func PortFree(port int) bool {
+	for _, addr := range []string{fmt.Sprintf("127.0.0.1:%d", port), fmt.Sprintf(":%d", port)} {
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			if errors.Is(err, syscall.EADDRINUSE) {
+				return false
+			}
+			continue
+		}
+		ln.Close()
+	}
+	return true
+}
+
+// truncateCommand shortens long command lines for messages.
+func truncateCommand(cmd string, max int) string {
+	if len(cmd) <= max {
+		return cmd
+	}
+	return cmd[:max-3] + "..."
+}
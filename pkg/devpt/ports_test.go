@@ -0,0 +1,48 @@
+package devpt
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestPortFreeDetectsBoundPort(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	if PortFree(port) {
+		t.Fatalf("expected port %d to be reported as taken", port)
+	}
+	if conflicts := BoundPorts(&models.ManagedService{Ports: []int{port}}); len(conflicts) != 1 || conflicts[0].Port != port {
+		t.Fatalf("BoundPorts = %+v, want port %d", conflicts, port)
+	}
+}
+
+func TestPortConflictsNameTheOwner(t *testing.T) {
+	t.Parallel()
+
+	pid := 42
+	services := []*models.ManagedService{{Name: "web", LastPID: &pid}}
+	records := []*models.ProcessRecord{
+		{PID: 42, Port: 3000, Command: "node server.js"},
+		{PID: 7, Port: 5432, Command: "postgres"},
+	}
+	conflicts := PortConflicts(&models.ManagedService{Name: "api", Ports: []int{3000, 3001}}, services, records)
+	if len(conflicts) != 1 {
+		t.Fatalf("conflicts = %+v, want port 3000 only", conflicts)
+	}
+	msg := conflicts[0].String()
+	for _, want := range []string{"port 3000", "PID 42", "node server.js", `"web"`} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("message %q missing %q", msg, want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package devpt
+
+import (
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/scanner"
+)
+
+// Enrich fills in what a scan leaves out of a dev server's record: its
+// project root, the AI agent that started it and its framework. Records of
+// containers, port-forwards and databases are left alone.
+func Enrich(rec *models.ProcessRecord, resolver *scanner.ProjectResolver, detector *scanner.AgentDetector, frameworks *scanner.ProcessScanner) {
+	if rec.Container != nil || rec.PortForward != nil || rec.Infra != "" {
+		return
+	}
+	if rec.CWD != "" {
+		rec.ProjectRoot = resolver.FindProjectRoot(rec.CWD)
+	}
+	detector.EnrichProcessRecord(rec)
+	frameworks.AnnotateFramework(rec)
+}
+
+// IdleStatus is the status of a registered service no listener matched.
+// alive reports whether its last process still runs, and exit is how that
+// process ended, when known. While alive, services without ports are
+// "running" and the rest "starting". A service that never ran or whose last
+// run exited cleanly is "stopped"; otherwise it is "crashed", or
+// "crash-looping" once it crashed loop.Threshold times within loop.Window.
+func IdleStatus(svc *models.ManagedService, alive bool, exit *models.ExitStatus, loop models.CrashLoopSettings, now time.Time) string {
+	if svc.LastPID == nil || *svc.LastPID <= 0 {
+		return "stopped"
+	}
+	if alive {
+		if len(svc.ActivePorts()) == 0 {
+			// Workers without ports never show up as listeners; trust the live PID.
+			return "running"
+		}
+		return "starting"
+	}
+	if exit != nil && exit.PID == *svc.LastPID && exit.Code == 0 {
+		return "stopped"
+	}
+	loop = loop.Effective()
+	if RecentCrashRestarts(svc, now, loop.Window.Std())+1 >= loop.Threshold {
+		return "crash-looping"
+	}
+	return "crashed"
+}
+
+// RecentCrashRestarts counts svc's crash restarts within window before now.
+func RecentCrashRestarts(svc *models.ManagedService, now time.Time, window time.Duration) int {
+	n := 0
+	for _, t := range svc.CrashRestarts {
+		if now.Sub(t) <= window {
+			n++
+		}
+	}
+	return n
+}
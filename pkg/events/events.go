@@ -0,0 +1,234 @@
+// Package events records service state changes to an append-only JSON-lines
+// log so that scripts and other tools can react to them.
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Type identifies what happened.
+type Type string
+
+const (
+	ServiceAdded   Type = "service.added"
+	ServiceRemoved Type = "service.removed"
+	ServiceUpdated Type = "service.updated"
+	ServiceStarted Type = "service.started"
+	ServiceStopped Type = "service.stopped"
+	ServiceExited  Type = "service.exited"  // the process ended on its own with exit code 0
+	ServiceCrashed Type = "service.crashed" // the process ended on its own with a failure
+	HealthChanged  Type = "health.changed"
+	LimitExceeded  Type = "limit.exceeded" // a service stayed above a resource limit
+)
+
+// Event is a single line of the events log.
+type Event struct {
+	Time    time.Time         `json:"time"`
+	Type    Type              `json:"type"`
+	Service string            `json:"service,omitempty"`
+	Port    int               `json:"port,omitempty"`
+	PID     int               `json:"pid,omitempty"`
+	Message string            `json:"message,omitempty"`
+	Data    map[string]string `json:"data,omitempty"` // type-specific details, e.g. "from"/"to" for health changes
+}
+
+// String renders the event as a single human-readable line.
+func (e Event) String() string {
+	var b strings.Builder
+	b.WriteString(e.Time.Local().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "  %-16s", e.Type)
+	if e.Service != "" {
+		b.WriteString("  " + e.Service)
+	}
+	if e.Port > 0 {
+		fmt.Fprintf(&b, "  :%d", e.Port)
+	}
+	if e.PID > 0 {
+		fmt.Fprintf(&b, "  pid=%d", e.PID)
+	}
+	if e.Message != "" {
+		b.WriteString("  " + e.Message)
+	}
+	if len(e.Data) > 0 {
+		keys := make([]string, 0, len(e.Data))
+		for k := range e.Data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, "  %s=%s", k, e.Data[k])
+		}
+	}
+	return b.String()
+}
+
+// followPollInterval is how often Follow checks the log for new lines.
+const followPollInterval = 250 * time.Millisecond
+
+// Log is an append-only events file shared by every devpt process.
+type Log struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewLog creates a log backed by path. The file is created on first append.
+func NewLog(path string) *Log {
+	return &Log{path: path}
+}
+
+// Path returns the file backing the log.
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append writes ev as one line. A zero Time is set to now.
+func (l *Log) Append(ev Event) error {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	line, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create events directory: %w", err)
+	}
+	// O_APPEND keeps concurrent writers from interleaving within a line.
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open events log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(line); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	return nil
+}
+
+// Recent returns up to n of the newest events, oldest first, together with
+// the file offset just past them for use with Follow.
+func (l *Log) Recent(n int) ([]Event, int64, error) {
+	f, err := os.Open(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, nil
+		}
+		return nil, 0, fmt.Errorf("failed to open events log: %w", err)
+	}
+	defer f.Close()
+
+	var out []Event
+	offset, err := readEvents(f, 0, func(ev Event) error {
+		if n <= 0 {
+			return nil
+		}
+		if len(out) == n {
+			copy(out, out[1:])
+			out = out[:n-1]
+		}
+		out = append(out, ev)
+		return nil
+	})
+	return out, offset, err
+}
+
+// Since returns the events logged at or after t, oldest first.
+func (l *Log) Since(t time.Time) ([]Event, error) {
+	f, err := os.Open(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open events log: %w", err)
+	}
+	defer f.Close()
+
+	var out []Event
+	_, err = readEvents(f, 0, func(ev Event) error {
+		if !ev.Time.Before(t) {
+			out = append(out, ev)
+		}
+		return nil
+	})
+	return out, err
+}
+
+// Follow calls fn for every event appended after offset until ctx is done or
+// fn returns an error. A log that shrinks (e.g. after pruning) is re-read from
+// the start.
+func (l *Log) Follow(ctx context.Context, offset int64, fn func(Event) error) error {
+	ticker := time.NewTicker(followPollInterval)
+	defer ticker.Stop()
+	for {
+		if fi, err := os.Stat(l.path); err == nil {
+			if fi.Size() < offset {
+				offset = 0
+			}
+			if fi.Size() > offset {
+				f, err := os.Open(l.path)
+				if err != nil {
+					return fmt.Errorf("failed to open events log: %w", err)
+				}
+				offset, err = readEvents(f, offset, fn)
+				f.Close()
+				if err != nil {
+					return err
+				}
+			}
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to stat events log: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// readEvents decodes complete lines starting at offset and returns the offset
+// after the last complete line. Malformed lines are skipped.
+func readEvents(f *os.File, offset int64, fn func(Event) error) (int64, error) {
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return offset, fmt.Errorf("failed to seek events log: %w", err)
+	}
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			// Leave a partially written line for the next read.
+			return offset, nil
+		}
+		if err != nil {
+			return offset, fmt.Errorf("failed to read events log: %w", err)
+		}
+		offset += int64(len(line))
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var ev Event
+		if json.Unmarshal(line, &ev) != nil {
+			continue
+		}
+		if err := fn(ev); err != nil {
+			return offset, err
+		}
+	}
+}
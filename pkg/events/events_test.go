@@ -0,0 +1,90 @@
+package events
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecentReturnsNewestEventsInOrder(t *testing.T) {
+	t.Parallel()
+
+	log := NewLog(filepath.Join(t.TempDir(), "events.jsonl"))
+	for _, name := range []string{"a", "b", "c"} {
+		if err := log.Append(Event{Type: ServiceStarted, Service: name}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	// A torn trailing line must not be returned or counted in the offset.
+	f, err := os.OpenFile(log.Path(), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	_, _ = f.WriteString(`{"type":"service.st`)
+	f.Close()
+
+	got, offset, err := log.Recent(2)
+	if err != nil {
+		t.Fatalf("recent: %v", err)
+	}
+	if len(got) != 2 || got[0].Service != "b" || got[1].Service != "c" {
+		t.Fatalf("unexpected events: %+v", got)
+	}
+	fi, _ := os.Stat(log.Path())
+	if offset >= fi.Size() {
+		t.Fatalf("offset %d should stop before the partial line (size %d)", offset, fi.Size())
+	}
+}
+
+func TestFollowDeliversAppendedEvents(t *testing.T) {
+	t.Parallel()
+
+	log := NewLog(filepath.Join(t.TempDir(), "events.jsonl"))
+	if err := log.Append(Event{Type: ServiceAdded, Service: "old"}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	_, offset, err := log.Recent(0)
+	if err != nil {
+		t.Fatalf("recent: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	got := make(chan Event, 4)
+	go func() {
+		_ = log.Follow(ctx, offset, func(ev Event) error {
+			got <- ev
+			return nil
+		})
+	}()
+
+	if err := log.Append(Event{Type: ServiceCrashed, Service: "web", Data: map[string]string{"code": "1"}}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	select {
+	case ev := <-got:
+		if ev.Type != ServiceCrashed || ev.Service != "web" || ev.Data["code"] != "1" {
+			t.Fatalf("unexpected event: %+v", ev)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for followed event")
+	}
+}
+
+func TestSinceReturnsEventsAfterTime(t *testing.T) {
+	t.Parallel()
+
+	log := NewLog(filepath.Join(t.TempDir(), "events.jsonl"))
+	now := time.Now()
+	for i, age := range []time.Duration{48 * time.Hour, time.Hour, time.Minute} {
+		if err := log.Append(Event{Time: now.Add(-age), Type: ServiceStarted, PID: i + 1}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	got, err := log.Since(now.Add(-2 * time.Hour))
+	if err != nil || len(got) != 2 || got[0].PID != 2 || got[1].PID != 3 {
+		t.Fatalf("since = %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,58 @@
+package filewatch
+
+import (
+	"path"
+	"strings"
+)
+
+// Match reports whether the slash-separated relative path rel matches
+// pattern. "**" matches any number of directories, and a pattern without a
+// slash is matched against the base name only, so "*.go" matches every Go
+// file in the tree.
+func Match(pattern, rel string) bool {
+	pattern = strings.TrimPrefix(strings.TrimSpace(pattern), "./")
+	rel = strings.TrimPrefix(rel, "./")
+	if pattern == "" {
+		return false
+	}
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+func matchSegments(pat, parts []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			rest := pat[1:]
+			if len(rest) == 0 {
+				return true
+			}
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(rest, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], parts[0]); !ok {
+			return false
+		}
+		pat, parts = pat[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// matchAny reports whether rel matches any of patterns.
+func matchAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		if Match(p, rel) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+// Package filewatch watches a project tree and reports batches of changed
+// files, for restarting services when their sources change.
+package filewatch
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDebounce is the quiet period after the last change before a batch
+// is reported.
+const DefaultDebounce = 300 * time.Millisecond
+
+// DefaultIgnore lists paths that are never watched.
+var DefaultIgnore = []string{".git/**", "node_modules/**", "**/.git/**", "**/node_modules/**", "**/*.swp", "**/*~", "**/.DS_Store"}
+
+// Options configures a Watcher.
+type Options struct {
+	Root     string        // directory to watch recursively
+	Patterns []string      // files that trigger a change; empty means every file
+	Ignore   []string      // paths to skip, in addition to DefaultIgnore
+	Debounce time.Duration // zero uses DefaultDebounce
+	// OnError is called with the errors the watcher reports while running,
+	// such as fsnotify.ErrEventOverflow when changes were dropped; nil
+	// ignores them.
+	OnError func(err error)
+}
+
+// Watcher reports debounced batches of changed files under a root.
+type Watcher struct {
+	opts   Options
+	ignore []string
+	fsw    *fsnotify.Watcher
+}
+
+// New starts watching opts.Root and all directories below it.
+func New(opts Options) (*Watcher, error) {
+	root, err := filepath.Abs(opts.Root)
+	if err != nil {
+		return nil, err
+	}
+	opts.Root = root
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultDebounce
+	}
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	w := &Watcher{
+		opts:   opts,
+		ignore: append(append([]string{}, DefaultIgnore...), opts.Ignore...),
+		fsw:    fsw,
+	}
+	if err := w.addTree(root); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Close stops watching.
+func (w *Watcher) Close() error {
+	return w.fsw.Close()
+}
+
+// Run calls onChange with the relative paths changed in each batch until ctx
+// is done or the watcher is closed. Errors the watcher reports go to
+// Options.OnError and do not stop it.
+func (w *Watcher) Run(ctx context.Context, onChange func(paths []string)) error {
+	pending := make(map[string]bool)
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return nil
+			}
+			if w.opts.OnError != nil {
+				w.opts.OnError(fmt.Errorf("file watcher: %w", err))
+			}
+		case ev, ok := <-w.fsw.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Has(fsnotify.Create) {
+				if fi, err := os.Stat(ev.Name); err == nil && fi.IsDir() {
+					_ = w.addTree(ev.Name)
+					continue
+				}
+			}
+			if ev.Has(fsnotify.Chmod) && !ev.Has(fsnotify.Write) {
+				continue
+			}
+			rel, ok := w.relevant(ev.Name)
+			if !ok {
+				continue
+			}
+			pending[rel] = true
+			timer.Reset(w.opts.Debounce)
+		case <-timer.C:
+			if len(pending) == 0 {
+				continue
+			}
+			paths := make([]string, 0, len(pending))
+			for p := range pending {
+				paths = append(paths, p)
+			}
+			sort.Strings(paths)
+			pending = make(map[string]bool)
+			onChange(paths)
+		}
+	}
+}
+
+// relevant returns the path relative to the root when it should trigger a change.
+func (w *Watcher) relevant(name string) (string, bool) {
+	rel, err := filepath.Rel(w.opts.Root, name)
+	if err != nil {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	if matchAny(w.ignore, rel) {
+		return "", false
+	}
+	if len(w.opts.Patterns) > 0 && !matchAny(w.opts.Patterns, rel) {
+		return "", false
+	}
+	return rel, true
+}
+
+// addTree watches dir and every non-ignored directory below it.
+func (w *Watcher) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == dir {
+				return err
+			}
+			return nil // vanished or unreadable subdirectory
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != w.opts.Root {
+			rel, _ := filepath.Rel(w.opts.Root, p)
+			rel = filepath.ToSlash(rel)
+			if matchAny(w.ignore, rel+"/") || matchAny(w.ignore, rel) {
+				return filepath.SkipDir
+			}
+		}
+		if err := w.fsw.Add(p); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", p, err)
+		}
+		return nil
+	})
+}
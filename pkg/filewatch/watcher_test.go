@@ -0,0 +1,125 @@
+package filewatch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		pattern, rel string
+		want         bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "pkg/cli/app.go", true},
+		{"src/**/*.go", "src/a/b/c.go", true},
+		{"src/**/*.go", "src/c.go", true},
+		{"src/**/*.go", "test/c.go", false},
+		{"src/*.ts", "src/deep/x.ts", false},
+		{"node_modules/**", "node_modules/", true},
+		{"**/node_modules/**", "web/node_modules/react/index.js", true},
+		{"./cmd/**", "cmd/devpt/main.go", true},
+	}
+	for _, tc := range cases {
+		if got := Match(tc.pattern, tc.rel); got != tc.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tc.pattern, tc.rel, got, tc.want)
+		}
+	}
+}
+
+func TestWatcherDebouncesMatchingChanges(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "src"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	w, err := New(Options{Root: root, Patterns: []string{"src/**/*.go"}, Ignore: []string{"src/gen/**"}, Debounce: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer w.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	batches := make(chan []string, 4)
+	go func() { _ = w.Run(ctx, func(paths []string) { batches <- paths }) }()
+
+	write := func(rel string) {
+		p := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(p, []byte("package x\n"), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	write("src/a.go")
+	write("src/b.go")
+	write("README.md")
+	write("src/gen/skip.go")
+
+	select {
+	case got := <-batches:
+		if len(got) != 2 || got[0] != "src/a.go" || got[1] != "src/b.go" {
+			t.Fatalf("unexpected batch: %v", got)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for change batch")
+	}
+}
+
+func TestWatcherKeepsRunningAfterErrors(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	errs := make(chan error, 1)
+	w, err := New(Options{Root: root, Debounce: 50 * time.Millisecond, OnError: func(err error) { errs <- err }})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer w.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	batches := make(chan []string, 4)
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx, func(paths []string) { batches <- paths }) }()
+
+	w.fsw.Errors <- fsnotify.ErrEventOverflow
+	select {
+	case err := <-errs:
+		if !errors.Is(err, fsnotify.ErrEventOverflow) {
+			t.Fatalf("OnError got %v, want the overflow", err)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for OnError")
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	select {
+	case got := <-batches:
+		if len(got) != 1 || got[0] != "main.go" {
+			t.Fatalf("unexpected batch: %v", got)
+		}
+	case err := <-done:
+		t.Fatalf("Run returned %v after an error", err)
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for change batch")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run = %v after cancel, want nil", err)
+	}
+}
@@ -1,132 +1,423 @@
 package health
 
 import (
-"fmt"
-"net"
-"net/http"
-"time"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
 )
 
 // Health status levels
 type HealthStatus string
 
 const (
-HealthOK      HealthStatus = "ok"
-HealthSlow    HealthStatus = "slow"
-HealthTimeout HealthStatus = "timeout"
-HealthDown    HealthStatus = "down"
-HealthUnknown HealthStatus = "unknown"
+	HealthOK      HealthStatus = "ok"
+	HealthSlow    HealthStatus = "slow"
+	HealthTimeout HealthStatus = "timeout"
+	HealthDown    HealthStatus = "down"
+	HealthUnknown HealthStatus = "unknown"
 )
 
+// maxBodyBytes caps how much of a response body is read when matching ExpectBody.
+const maxBodyBytes = 64 * 1024
+
 // HealthCheck represents the result of a health check
 type HealthCheck struct {
-Port       int
-Status     HealthStatus
-ResponseMs int
-Message    string
-LastCheck  time.Time
+	Port       int
+	Status     HealthStatus
+	ResponseMs int
+	Message    string
+	LastCheck  time.Time
+}
+
+// Default latency thresholds used when none are configured.
+const (
+	DefaultSlowThreshold    = 2 * time.Second
+	DefaultTimeoutThreshold = 5 * time.Second
+)
+
+// Thresholds controls how response latency maps to a status.
+type Thresholds struct {
+	Slow    time.Duration // slower than this is HealthSlow
+	Timeout time.Duration // slower than this is HealthTimeout
 }
 
 // Checker performs health checks on services
 type Checker struct {
-timeout time.Duration
+	timeout    time.Duration
+	thresholds Thresholds
+	observe    Observer
 }
 
+// Observer is told about every check a Checker finishes, with when it
+// started, e.g. to trace it.
+type Observer func(ctx context.Context, started time.Time, check *HealthCheck)
+
 // NewChecker creates a new health checker
 func NewChecker(timeout time.Duration) *Checker {
-if timeout == 0 {
-timeout = 5 * time.Second
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	return &Checker{
+		timeout:    timeout,
+		thresholds: Thresholds{Slow: DefaultSlowThreshold, Timeout: DefaultTimeoutThreshold},
+	}
 }
-return &Checker{timeout: timeout}
+
+// SetThresholds overrides the latency cutoffs. Zero values keep the defaults.
+func (c *Checker) SetThresholds(t Thresholds) {
+	if t.Slow > 0 {
+		c.thresholds.Slow = t.Slow
+	}
+	if t.Timeout > 0 {
+		c.thresholds.Timeout = t.Timeout
+	}
+}
+
+// SetObserver makes o see every check from now on.
+func (c *Checker) SetObserver(o Observer) {
+	c.observe = o
+}
+
+// observed passes check, started at started, to the observer and returns it.
+func (c *Checker) observed(ctx context.Context, started time.Time, check *HealthCheck) *HealthCheck {
+	if c.observe != nil {
+		c.observe(ctx, started, check)
+	}
+	return check
 }
 
 // Check performs a health check on a port
 func (c *Checker) Check(port int) *HealthCheck {
-result := &HealthCheck{
-Port:      port,
-LastCheck: time.Now(),
+	return c.CheckContext(context.Background(), port)
 }
 
-// Try HTTP first
-if ok, ms := c.checkHTTP(port); ok {
-result.Status = categorizeResponse(ms)
-result.ResponseMs = ms
-result.Message = fmt.Sprintf("HTTP responding in %dms", ms)
-return result
+// CheckContext is Check, giving up when ctx is done.
+func (c *Checker) CheckContext(ctx context.Context, port int) *HealthCheck {
+	return c.CheckWithConfigContext(ctx, port, nil)
 }
 
-// Fall back to TCP
-if ok, ms := c.checkTCP(port); ok {
-result.Status = categorizeResponse(ms)
-result.ResponseMs = ms
-result.Message = fmt.Sprintf("TCP responding in %dms", ms)
-return result
+// CheckWithConfig performs a health check on a port using per-service settings.
+// When cfg declares an HTTP expectation (status codes or body), a failed
+// expectation reports the service as down instead of falling back to TCP.
+func (c *Checker) CheckWithConfig(port int, cfg *models.HealthCheckConfig) *HealthCheck {
+	return c.CheckWithConfigContext(context.Background(), port, cfg)
 }
 
-// Port is listening but not responding
-result.Status = HealthDown
-result.Message = "Port listening but no response"
-return result
+// CheckWithConfigContext is CheckWithConfig, giving up when ctx is done. A
+// deadline on ctx that expires mid-probe reports a timeout; a canceled
+// check reports HealthUnknown, since nothing was learned about the server.
+func (c *Checker) CheckWithConfigContext(ctx context.Context, port int, cfg *models.HealthCheckConfig) *HealthCheck {
+	started := time.Now()
+	result := c.checkWithConfig(ctx, port, cfg)
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return canceledCheck(port)
+	}
+	return c.observed(ctx, started, result)
 }
 
-// checkHTTP attempts an HTTP connection
-func (c *Checker) checkHTTP(port int) (bool, int) {
-url := fmt.Sprintf("http://localhost:%d", port)
-client := &http.Client{
-Timeout: c.timeout,
+// canceledCheck is the result of a check abandoned because its context was
+// canceled.
+func canceledCheck(port int) *HealthCheck {
+	return &HealthCheck{Port: port, Status: HealthUnknown, Message: "Check canceled", LastCheck: time.Now()}
+}
+
+func (c *Checker) checkWithConfig(ctx context.Context, port int, cfg *models.HealthCheckConfig) *HealthCheck {
+	result := &HealthCheck{
+		Port:      port,
+		LastCheck: time.Now(),
+	}
+	if ctx.Err() != nil {
+		result.Status = HealthTimeout
+		result.Message = "Check deadline exceeded"
+		return result
+	}
+
+	if isGRPC(cfg) {
+		return c.apply(result, c.checkGRPC(ctx, port, cfg))
+	}
+	if isWebSocket(cfg) {
+		return c.apply(result, c.checkWebSocket(ctx, port, cfg))
+	}
+	if isInfra(cfg) {
+		return c.apply(result, c.checkInfra(ctx, port, cfg))
+	}
+
+	// Try HTTP first
+	probe := c.checkHTTP(ctx, port, cfg)
+	if probe.status == http.StatusUpgradeRequired {
+		// Endpoints such as the vite HMR socket only answer upgrade requests.
+		if ws := c.checkWebSocket(ctx, port, cfg); ws.ok {
+			return c.apply(result, ws)
+		}
+	}
+	if probe.err == nil {
+		result.Status = c.categorize(probe.ms)
+		result.ResponseMs = probe.ms
+		result.Message = fmt.Sprintf("%s responding in %dms", probe.scheme, probe.ms)
+		return result
+	}
+	if probe.responded && hasHTTPExpectations(cfg) {
+		result.Status = HealthDown
+		result.ResponseMs = probe.ms
+		result.Message = probe.err.Error()
+		return result
+	}
+	if isTimeout(probe.err) {
+		// The socket accepted the request but never answered; a TCP fallback would hide that.
+		result.Status = HealthTimeout
+		result.ResponseMs = probe.ms
+		result.Message = fmt.Sprintf("%s timed out after %dms", probe.scheme, probe.ms)
+		return result
+	}
+
+	// Fall back to TCP
+	if ok, ms := c.checkTCP(ctx, port, c.timeoutFor(cfg)); ok {
+		result.Status = c.categorize(ms)
+		result.ResponseMs = ms
+		result.Message = fmt.Sprintf("TCP responding in %dms", ms)
+		return result
+	}
+
+	// Port is listening but not responding
+	result.Status = HealthDown
+	result.Message = "Port listening but no response"
+	return result
 }
 
-start := time.Now()
-resp, err := client.Get(url)
-elapsed := int(time.Since(start).Milliseconds())
+// probeResult is the outcome of a protocol-specific probe.
+type probeResult struct {
+	ok       bool
+	ms       int
+	msg      string
+	timedOut bool
+}
 
-if err != nil {
-return false, 0
+// apply records a probe outcome on result.
+func (c *Checker) apply(result *HealthCheck, p probeResult) *HealthCheck {
+	result.ResponseMs = p.ms
+	result.Message = p.msg
+	switch {
+	case p.ok:
+		result.Status = c.categorize(p.ms)
+	case p.timedOut:
+		result.Status = HealthTimeout
+	default:
+		result.Status = HealthDown
+	}
+	return result
 }
-defer resp.Body.Close()
 
-return true, elapsed
+// httpProbe is the outcome of a single HTTP request.
+type httpProbe struct {
+	scheme    string
+	ms        int
+	status    int
+	responded bool // a response was received, even if it failed expectations
+	err       error
+}
+
+// checkHTTP attempts an HTTP request and validates it against cfg
+func (c *Checker) checkHTTP(ctx context.Context, port int, cfg *models.HealthCheckConfig) httpProbe {
+	scheme := "http"
+	if cfg != nil && cfg.TLS {
+		scheme = "https"
+	}
+	path := probePath(cfg)
+	probe := httpProbe{scheme: strings.ToUpper(scheme)}
+
+	url := fmt.Sprintf("%s://localhost:%d%s", scheme, port, path)
+	client := &http.Client{
+		Timeout: c.timeoutFor(cfg),
+		Transport: &http.Transport{
+			// Local dev certificates are rarely trusted; reachability is what matters here.
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			// Each check has its own transport, so an idle connection would
+			// never be reused, only leaked along with its goroutines.
+			DisableKeepAlives: true,
+		},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		probe.err = err
+		return probe
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	probe.ms = int(time.Since(start).Milliseconds())
+
+	if err != nil {
+		probe.err = err
+		return probe
+	}
+	defer resp.Body.Close()
+	probe.responded = true
+	probe.status = resp.StatusCode
+
+	if cfg != nil && len(cfg.ExpectedStatus) > 0 && !containsStatus(cfg.ExpectedStatus, resp.StatusCode) {
+		probe.err = fmt.Errorf("%s %s returned %d, expected %s", probe.scheme, path, resp.StatusCode, formatStatuses(cfg.ExpectedStatus))
+		return probe
+	}
+	if cfg != nil && cfg.ExpectBody != "" {
+		body, readErr := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+		if readErr != nil {
+			probe.err = fmt.Errorf("%s %s body unreadable: %v", probe.scheme, path, readErr)
+			return probe
+		}
+		if !strings.Contains(string(body), cfg.ExpectBody) {
+			probe.err = fmt.Errorf("%s %s body missing %q", probe.scheme, path, cfg.ExpectBody)
+			return probe
+		}
+	}
+
+	return probe
 }
 
 // checkTCP attempts a TCP connection
-func (c *Checker) checkTCP(port int) (bool, int) {
-addr := fmt.Sprintf("localhost:%d", port)
+func (c *Checker) checkTCP(ctx context.Context, port int, timeout time.Duration) (bool, int) {
+	addr := fmt.Sprintf("localhost:%d", port)
+
+	start := time.Now()
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	elapsed := int(time.Since(start).Milliseconds())
+
+	if err != nil {
+		return false, 0
+	}
+	defer conn.Close()
+
+	return true, elapsed
+}
 
-start := time.Now()
-conn, err := net.DialTimeout("tcp", addr, c.timeout)
-elapsed := int(time.Since(start).Milliseconds())
+func (c *Checker) timeoutFor(cfg *models.HealthCheckConfig) time.Duration {
+	if cfg != nil && cfg.Timeout > 0 {
+		return cfg.Timeout.Std()
+	}
+	return c.timeout
+}
+
+// probePath returns the configured request path, defaulting to "/".
+func probePath(cfg *models.HealthCheckConfig) string {
+	if cfg == nil {
+		return "/"
+	}
+	p := strings.TrimSpace(cfg.Path)
+	if p == "" {
+		return "/"
+	}
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return p
+}
+
+func hasHTTPExpectations(cfg *models.HealthCheckConfig) bool {
+	return cfg != nil && (len(cfg.ExpectedStatus) > 0 || cfg.ExpectBody != "")
+}
 
-if err != nil {
-return false, 0
+func containsStatus(statuses []int, code int) bool {
+	for _, s := range statuses {
+		if s == code {
+			return true
+		}
+	}
+	return false
 }
-defer conn.Close()
 
-return true, elapsed
+func formatStatuses(statuses []int) string {
+	parts := make([]string, 0, len(statuses))
+	for _, s := range statuses {
+		parts = append(parts, fmt.Sprintf("%d", s))
+	}
+	return strings.Join(parts, "/")
 }
 
-// categorizeResponse categorizes response time into status
-func categorizeResponse(ms int) HealthStatus {
-if ms > 2000 {
-return HealthSlow
+// categorize maps a successful response time onto a status using the
+// configured thresholds.
+func (c *Checker) categorize(ms int) HealthStatus {
+	return categorizeResponse(ms, c.thresholds)
 }
-if ms > 5000 {
-return HealthTimeout
+
+// categorizeResponse categorizes response time into status. The timeout
+// cutoff is checked first so that both categories are reachable.
+func categorizeResponse(ms int, t Thresholds) HealthStatus {
+	elapsed := time.Duration(ms) * time.Millisecond
+	if t.Timeout > 0 && elapsed > t.Timeout {
+		return HealthTimeout
+	}
+	if t.Slow > 0 && elapsed > t.Slow {
+		return HealthSlow
+	}
+	return HealthOK
 }
-return HealthOK
+
+// isTimeout reports whether err is a network or deadline timeout.
+func isTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
 }
 
 // StatusIcon returns an emoji for the health status
 func StatusIcon(status HealthStatus) string {
-switch status {
-case HealthOK:
-return "✅"
-case HealthSlow:
-return "⚠️"
-case HealthTimeout:
-return "🐢"
-case HealthDown:
-return "❌"
-default:
-return "❓"
+	switch status {
+	case HealthOK:
+		return "✅"
+	case HealthSlow:
+		return "⚠️"
+	case HealthTimeout:
+		return "🐢"
+	case HealthDown:
+		return "❌"
+	default:
+		return "❓"
+	}
 }
+
+// StatusText returns a plain text marker for the health status, for
+// terminals and screen readers that do not handle emoji well.
+func StatusText(status HealthStatus) string {
+	switch status {
+	case HealthOK:
+		return "OK"
+	case HealthSlow:
+		return "SLOW"
+	case HealthTimeout:
+		return "TIMEOUT"
+	case HealthDown:
+		return "DOWN"
+	default:
+		return "?"
+	}
+}
+
+// StatusColor returns an ANSI 256-color code for the health status, so each
+// category is distinguishable even where emoji render poorly.
+func StatusColor(status HealthStatus) string {
+	switch status {
+	case HealthOK:
+		return "10" // green
+	case HealthSlow:
+		return "11" // yellow
+	case HealthTimeout:
+		return "208" // orange
+	case HealthDown:
+		return "9" // red
+	default:
+		return "8" // grey
+	}
 }
@@ -0,0 +1,216 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devports/devpt/internal/testutil"
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestCheckWithConfigUsesPathAndExpectations(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"ready"}`))
+	}))
+	defer srv.Close()
+	port := testutil.ServerPort(t, srv)
+	c := NewChecker(time.Second)
+
+	ok := c.CheckWithConfig(port, &models.HealthCheckConfig{
+		Path:           "healthz",
+		ExpectedStatus: []int{200},
+		ExpectBody:     "ready",
+	})
+	if ok.Status != HealthOK {
+		t.Fatalf("expected ok status, got %s (%s)", ok.Status, ok.Message)
+	}
+
+	wrongPath := c.CheckWithConfig(port, &models.HealthCheckConfig{Path: "/", ExpectedStatus: []int{200}})
+	if wrongPath.Status != HealthDown {
+		t.Fatalf("expected down for unexpected status, got %s (%s)", wrongPath.Status, wrongPath.Message)
+	}
+
+	wrongBody := c.CheckWithConfig(port, &models.HealthCheckConfig{Path: "/healthz", ExpectBody: "degraded"})
+	if wrongBody.Status != HealthDown {
+		t.Fatalf("expected down for missing body substring, got %s (%s)", wrongBody.Status, wrongBody.Message)
+	}
+
+	// Without expectations any HTTP response counts as reachable.
+	if got := c.Check(port); got.Status != HealthOK {
+		t.Fatalf("expected default check to be ok, got %s (%s)", got.Status, got.Message)
+	}
+}
+
+// assertNoGoroutineLeak runs check n times and fails if the goroutines it
+// leaves behind grow with n. Callers must not run in parallel, so that
+// other tests do not skew the count.
+func assertNoGoroutineLeak(t *testing.T, n int, check func()) {
+	t.Helper()
+	before := runtime.NumGoroutine()
+	for i := 0; i < n; i++ {
+		check()
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		after := runtime.NumGoroutine()
+		if after <= before+5 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d checks left %d goroutines running, %d before", n, after, before)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestCheckWithConfigClosesConnections(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	port := testutil.ServerPort(t, srv)
+	c := NewChecker(time.Second)
+	cfg := &models.HealthCheckConfig{ExpectBody: "ok"}
+	assertNoGoroutineLeak(t, 50, func() {
+		if check := c.CheckWithConfig(port, cfg); check.Status != HealthOK {
+			t.Fatalf("expected ok, got %s (%s)", check.Status, check.Message)
+		}
+	})
+}
+
+func TestCheckDetectsWebSocketOnlyEndpoints(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			w.WriteHeader(http.StatusUpgradeRequired)
+			return
+		}
+		w.Header().Set("Upgrade", "websocket")
+		w.Header().Set("Connection", "Upgrade")
+		w.Header().Set("Sec-WebSocket-Accept", websocketAccept(r.Header.Get("Sec-WebSocket-Key")))
+		w.WriteHeader(http.StatusSwitchingProtocols)
+	}))
+	defer srv.Close()
+	port := testutil.ServerPort(t, srv)
+	c := NewChecker(time.Second)
+
+	for _, cfg := range []*models.HealthCheckConfig{nil, {Protocol: models.HealthProtocolWebSocket}} {
+		got := c.CheckWithConfig(port, cfg)
+		if got.Status != HealthOK || !strings.HasPrefix(got.Message, "WebSocket handshake") {
+			t.Fatalf("expected websocket handshake to succeed (cfg=%v), got %s (%s)", cfg, got.Status, got.Message)
+		}
+	}
+}
+
+func TestCheckServiceRunsHealthCommand(t *testing.T) {
+	t.Parallel()
+
+	c := NewChecker(time.Second)
+	dir := t.TempDir()
+
+	ok := c.CheckService(&models.ManagedService{CWD: dir, Health: &models.HealthCheckConfig{Command: "true"}}, 0)
+	if ok.Status != HealthOK {
+		t.Fatalf("expected ok for exit 0, got %s (%s)", ok.Status, ok.Message)
+	}
+
+	failed := c.CheckService(&models.ManagedService{CWD: dir, Health: &models.HealthCheckConfig{Command: "sh -c 'echo queue stalled; exit 3'"}}, 0)
+	if failed.Status != HealthDown || failed.Message != "Health command exited 3: queue stalled" {
+		t.Fatalf("expected down with exit code and output, got %s (%s)", failed.Status, failed.Message)
+	}
+
+	if none := c.CheckService(&models.ManagedService{CWD: dir}, 0); none.Status != HealthUnknown {
+		t.Fatalf("expected unknown without port or command, got %s", none.Status)
+	}
+}
+
+func TestCategorizeResponseReachesEveryCategory(t *testing.T) {
+	t.Parallel()
+
+	defaults := Thresholds{Slow: DefaultSlowThreshold, Timeout: DefaultTimeoutThreshold}
+	cases := []struct {
+		ms   int
+		t    Thresholds
+		want HealthStatus
+	}{
+		{ms: 150, t: defaults, want: HealthOK},
+		{ms: 2500, t: defaults, want: HealthSlow},
+		{ms: 6000, t: defaults, want: HealthTimeout},
+		{ms: 150, t: Thresholds{Slow: 100 * time.Millisecond, Timeout: 300 * time.Millisecond}, want: HealthSlow},
+		{ms: 400, t: Thresholds{Slow: 100 * time.Millisecond, Timeout: 300 * time.Millisecond}, want: HealthTimeout},
+	}
+	for _, tc := range cases {
+		if got := categorizeResponse(tc.ms, tc.t); got != tc.want {
+			t.Fatalf("categorizeResponse(%d, %+v) = %s, want %s", tc.ms, tc.t, got, tc.want)
+		}
+	}
+}
+
+func TestCheckReportsTimeoutForHangingHTTP(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	got := NewChecker(100 * time.Millisecond).Check(testutil.ServerPort(t, srv))
+	if got.Status != HealthTimeout {
+		t.Fatalf("expected timeout for hanging server, got %s (%s)", got.Status, got.Message)
+	}
+}
+
+func TestCheckContextCanceledIsUnknown(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	got := NewChecker(5*time.Second).CheckContext(ctx, testutil.ServerPort(t, srv))
+	if got.Status != HealthUnknown {
+		t.Fatalf("expected unknown for canceled check, got %s (%s)", got.Status, got.Message)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("canceled check took %s", elapsed)
+	}
+}
+
+func TestObserverSeesChecks(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	c := NewChecker(time.Second)
+	var seen []*HealthCheck
+	c.SetObserver(func(ctx context.Context, started time.Time, check *HealthCheck) {
+		if started.IsZero() || started.After(time.Now()) {
+			t.Errorf("started = %v", started)
+		}
+		seen = append(seen, check)
+	})
+	check := c.Check(testutil.ServerPort(t, srv))
+	c.CheckCommand("true", "", time.Second)
+	if len(seen) != 2 || seen[0] != check || seen[1].Status != HealthOK {
+		t.Errorf("observed %+v", seen)
+	}
+}
@@ -0,0 +1,113 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/process"
+)
+
+// CheckService checks a managed service using its configured strategy: an exec
+// command when one is declared, otherwise a probe of the given port.
+func (c *Checker) CheckService(svc *models.ManagedService, port int) *HealthCheck {
+	return c.CheckServiceContext(context.Background(), svc, port)
+}
+
+// CheckServiceContext is CheckService, giving up when ctx is done.
+func (c *Checker) CheckServiceContext(ctx context.Context, svc *models.ManagedService, port int) *HealthCheck {
+	var cfg *models.HealthCheckConfig
+	dir := ""
+	if svc != nil {
+		cfg = svc.Health
+		dir = svc.CWD
+	}
+	if cfg != nil && strings.TrimSpace(cfg.Command) != "" {
+		return c.CheckCommandContext(ctx, cfg.Command, dir, c.timeoutFor(cfg))
+	}
+	if port <= 0 {
+		return &HealthCheck{Status: HealthUnknown, Message: "No port or health command to check", LastCheck: time.Now()}
+	}
+	return c.CheckWithConfigContext(ctx, port, cfg)
+}
+
+// CheckCommand runs command in dir and reports healthy when it exits 0.
+// The command is executed directly (no shell), like managed service commands.
+func (c *Checker) CheckCommand(command, dir string, timeout time.Duration) *HealthCheck {
+	return c.CheckCommandContext(context.Background(), command, dir, timeout)
+}
+
+// CheckCommandContext is CheckCommand, killing the command when ctx is done.
+func (c *Checker) CheckCommandContext(parent context.Context, command, dir string, timeout time.Duration) *HealthCheck {
+	if parent.Err() != nil {
+		return canceledCheck(0)
+	}
+	result := c.checkCommand(parent, command, dir, timeout)
+	if errors.Is(parent.Err(), context.Canceled) {
+		return result
+	}
+	return c.observed(parent, result.LastCheck, result)
+}
+
+func (c *Checker) checkCommand(parent context.Context, command, dir string, timeout time.Duration) *HealthCheck {
+	result := &HealthCheck{LastCheck: time.Now()}
+	if timeout <= 0 {
+		timeout = c.timeout
+	}
+
+	argv, err := process.ParseCommand(command)
+	if err != nil || len(argv) == 0 {
+		result.Status = HealthUnknown
+		result.Message = "Invalid health command"
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = dir
+
+	start := time.Now()
+	out, err := cmd.CombinedOutput()
+	result.ResponseMs = int(time.Since(start).Milliseconds())
+
+	if errors.Is(parent.Err(), context.Canceled) {
+		return canceledCheck(0)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		result.Status = HealthTimeout
+		result.Message = fmt.Sprintf("Health command timed out after %s", timeout)
+		return result
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		result.Status = HealthDown
+		if errors.As(err, &exitErr) {
+			result.Message = fmt.Sprintf("Health command exited %d", exitErr.ExitCode())
+		} else {
+			result.Message = fmt.Sprintf("Health command failed: %v", err)
+		}
+		if last := lastOutputLine(out); last != "" {
+			result.Message += ": " + last
+		}
+		return result
+	}
+
+	result.Status = c.categorize(result.ResponseMs)
+	result.Message = fmt.Sprintf("Command succeeded in %dms", result.ResponseMs)
+	return result
+}
+
+func lastOutputLine(out []byte) string {
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if l := strings.TrimSpace(lines[i]); l != "" {
+			return l
+		}
+	}
+	return ""
+}
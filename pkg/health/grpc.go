@@ -0,0 +1,186 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// grpcHealthPath is the method path of the standard grpc.health.v1.Health/Check RPC.
+const grpcHealthPath = "/grpc.health.v1.Health/Check"
+
+// grpc.health.v1.HealthCheckResponse.ServingStatus values
+const (
+	grpcStatusUnknown        = 0
+	grpcStatusServing        = 1
+	grpcStatusNotServing     = 2
+	grpcStatusServiceUnknown = 3
+)
+
+// checkGRPC calls grpc.health.v1.Health/Check over HTTP/2 (h2c unless TLS is set).
+// The protobuf messages are tiny, so they are encoded by hand rather than pulling
+// in the full gRPC stack.
+func (c *Checker) checkGRPC(ctx context.Context, port int, cfg *models.HealthCheckConfig) probeResult {
+	scheme := "http"
+	protocols := new(http.Protocols)
+	if cfg != nil && cfg.TLS {
+		scheme = "https"
+		protocols.SetHTTP2(true)
+	} else {
+		protocols.SetUnencryptedHTTP2(true)
+	}
+	service := ""
+	if cfg != nil {
+		service = cfg.GRPCService
+	}
+
+	client := &http.Client{
+		Timeout: c.timeoutFor(cfg),
+		Transport: &http.Transport{
+			Protocols:       protocols,
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			// The transport is not reused, so its connection must not outlive
+			// the probe.
+			DisableKeepAlives: true,
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeoutFor(cfg))
+	defer cancel()
+	url := fmt.Sprintf("%s://localhost:%d%s", scheme, port, grpcHealthPath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(encodeGRPCHealthRequest(service)))
+	if err != nil {
+		return probeResult{msg: err.Error()}
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		if isTimeout(err) {
+			return probeResult{ms: int(time.Since(start).Milliseconds()), msg: "gRPC timed out", timedOut: true}
+		}
+		return probeResult{msg: "gRPC unreachable"}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	elapsed := int(time.Since(start).Milliseconds())
+	if err != nil {
+		return probeResult{ms: elapsed, msg: fmt.Sprintf("gRPC read failed: %v", err), timedOut: isTimeout(err)}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return probeResult{ms: elapsed, msg: fmt.Sprintf("gRPC endpoint returned HTTP %d", resp.StatusCode)}
+	}
+
+	// Trailers-only responses carry grpc-status in the headers.
+	code := resp.Trailer.Get("Grpc-Status")
+	msg := resp.Trailer.Get("Grpc-Message")
+	if code == "" {
+		code = resp.Header.Get("Grpc-Status")
+		msg = resp.Header.Get("Grpc-Message")
+	}
+	if code != "" && code != "0" {
+		if code == "12" {
+			return probeResult{ms: elapsed, msg: "gRPC health service not implemented"}
+		}
+		if msg == "" {
+			msg = "no message"
+		}
+		return probeResult{ms: elapsed, msg: fmt.Sprintf("gRPC error %s: %s", code, msg)}
+	}
+
+	status, err := decodeGRPCHealthResponse(body)
+	if err != nil {
+		return probeResult{ms: elapsed, msg: fmt.Sprintf("gRPC response invalid: %v", err)}
+	}
+	label := grpcServingStatusLabel(status)
+	if status != grpcStatusServing {
+		return probeResult{ms: elapsed, msg: fmt.Sprintf("gRPC %s", label)}
+	}
+	return probeResult{ok: true, ms: elapsed, msg: fmt.Sprintf("gRPC %s in %dms", label, elapsed)}
+}
+
+// encodeGRPCHealthRequest frames a HealthCheckRequest{service} message.
+func encodeGRPCHealthRequest(service string) []byte {
+	var msg []byte
+	if service != "" {
+		msg = append(msg, 0x0a) // field 1, wire type 2 (length-delimited)
+		msg = binary.AppendUvarint(msg, uint64(len(service)))
+		msg = append(msg, service...)
+	}
+	frame := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(msg)))
+	return append(frame, msg...)
+}
+
+// decodeGRPCHealthResponse extracts the status field from a framed HealthCheckResponse.
+func decodeGRPCHealthResponse(body []byte) (int, error) {
+	if len(body) < 5 {
+		return 0, fmt.Errorf("short frame")
+	}
+	if body[0] != 0 {
+		return 0, fmt.Errorf("compressed responses are not supported")
+	}
+	n := binary.BigEndian.Uint32(body[1:5])
+	if int(n) > len(body)-5 {
+		return 0, fmt.Errorf("truncated message")
+	}
+	msg := body[5 : 5+n]
+
+	status := grpcStatusUnknown
+	for len(msg) > 0 {
+		key, kn := binary.Uvarint(msg)
+		if kn <= 0 {
+			return 0, fmt.Errorf("malformed field key")
+		}
+		msg = msg[kn:]
+		field, wire := key>>3, key&0x7
+		switch wire {
+		case 0:
+			v, vn := binary.Uvarint(msg)
+			if vn <= 0 {
+				return 0, fmt.Errorf("malformed varint")
+			}
+			msg = msg[vn:]
+			if field == 1 {
+				status = int(v)
+			}
+		case 2:
+			l, ln := binary.Uvarint(msg)
+			if ln <= 0 || int(l) > len(msg)-ln {
+				return 0, fmt.Errorf("malformed length")
+			}
+			msg = msg[ln+int(l):]
+		default:
+			return 0, fmt.Errorf("unsupported wire type %d", wire)
+		}
+	}
+	return status, nil
+}
+
+func grpcServingStatusLabel(status int) string {
+	switch status {
+	case grpcStatusServing:
+		return "SERVING"
+	case grpcStatusNotServing:
+		return "NOT_SERVING"
+	case grpcStatusServiceUnknown:
+		return "SERVICE_UNKNOWN"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func isGRPC(cfg *models.HealthCheckConfig) bool {
+	return cfg != nil && strings.EqualFold(cfg.Protocol, models.HealthProtocolGRPC)
+}
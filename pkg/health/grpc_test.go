@@ -0,0 +1,90 @@
+package health
+
+import (
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/devports/devpt/internal/testutil"
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestCheckGRPCHealthProtocol(t *testing.T) {
+	t.Parallel()
+
+	statuses := map[string]byte{"": grpcStatusServing, "payments": grpcStatusNotServing}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != grpcHealthPath || r.Header.Get("Content-Type") != "application/grpc" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		service := ""
+		if len(body) > 7 {
+			service = string(body[7:])
+		}
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status")
+		msg := []byte{0x08, statuses[service]}
+		frame := make([]byte, 5)
+		binary.BigEndian.PutUint32(frame[1:], uint32(len(msg)))
+		_, _ = w.Write(append(frame, msg...))
+		w.Header().Set("Grpc-Status", "0")
+	}))
+	srv.Config.Protocols = new(http.Protocols)
+	srv.Config.Protocols.SetUnencryptedHTTP2(true)
+	srv.Start()
+	defer srv.Close()
+
+	port := testutil.ServerPort(t, srv)
+	c := NewChecker(time.Second)
+
+	serving := c.CheckWithConfig(port, &models.HealthCheckConfig{Protocol: models.HealthProtocolGRPC})
+	if serving.Status != HealthOK {
+		t.Fatalf("expected ok for SERVING, got %s (%s)", serving.Status, serving.Message)
+	}
+
+	notServing := c.CheckWithConfig(port, &models.HealthCheckConfig{Protocol: models.HealthProtocolGRPC, GRPCService: "payments"})
+	if notServing.Status != HealthDown || notServing.Message != "gRPC NOT_SERVING" {
+		t.Fatalf("expected down NOT_SERVING, got %s (%s)", notServing.Status, notServing.Message)
+	}
+}
+
+func TestCheckGRPCClosesConnections(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status")
+		_, _ = w.Write([]byte{0, 0, 0, 0, 2, 0x08, grpcStatusServing})
+		w.Header().Set("Grpc-Status", "0")
+	}))
+	srv.Config.Protocols = new(http.Protocols)
+	srv.Config.Protocols.SetUnencryptedHTTP2(true)
+	srv.Start()
+	defer srv.Close()
+
+	port := testutil.ServerPort(t, srv)
+	c := NewChecker(time.Second)
+	cfg := &models.HealthCheckConfig{Protocol: models.HealthProtocolGRPC}
+	assertNoGoroutineLeak(t, 50, func() {
+		if check := c.CheckWithConfig(port, cfg); check.Status != HealthOK {
+			t.Fatalf("expected ok, got %s (%s)", check.Status, check.Message)
+		}
+	})
+}
+
+func TestEncodeGRPCHealthRequest(t *testing.T) {
+	t.Parallel()
+
+	got := encodeGRPCHealthRequest("api")
+	want := []byte{0, 0, 0, 0, 5, 0x0a, 3, 'a', 'p', 'i'}
+	if string(got) != string(want) {
+		t.Fatalf("encodeGRPCHealthRequest(api) = %v, want %v", got, want)
+	}
+	if empty := encodeGRPCHealthRequest(""); len(empty) != 5 {
+		t.Fatalf("expected empty request to be a bare 5-byte frame, got %v", empty)
+	}
+}
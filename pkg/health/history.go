@@ -0,0 +1,131 @@
+package health
+
+import (
+	"strings"
+	"sync"
+)
+
+// DefaultHistorySize is the number of checks kept per port.
+const DefaultHistorySize = 30
+
+// sparkLevels are the bar glyphs used by Sparkline, lowest to highest latency.
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// History keeps the most recent checks per port in fixed-size ring buffers.
+type History struct {
+	size  int
+	rings map[int]*ring
+	mu    sync.RWMutex
+}
+
+type ring struct {
+	buf  []*HealthCheck
+	next int
+	full bool
+}
+
+// NewHistory creates a history holding up to size checks per port.
+func NewHistory(size int) *History {
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+	return &History{size: size, rings: make(map[int]*ring)}
+}
+
+// Record appends a check for its port, evicting the oldest when full.
+// Recording the same result twice in a row is a no-op, so callers may pass
+// cached results without skewing the trend.
+func (h *History) Record(check *HealthCheck) {
+	if h == nil || check == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r := h.rings[check.Port]
+	if r == nil {
+		r = &ring{buf: make([]*HealthCheck, h.size)}
+		h.rings[check.Port] = r
+	}
+	last := r.next - 1
+	if last < 0 {
+		last = len(r.buf) - 1
+	}
+	if prev := r.buf[last]; prev != nil && (prev == check || prev.LastCheck.Equal(check.LastCheck)) {
+		return
+	}
+	r.buf[r.next] = check
+	r.next = (r.next + 1) % len(r.buf)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Recent returns up to n checks for port, oldest first. n <= 0 returns all.
+func (h *History) Recent(port int, n int) []*HealthCheck {
+	if h == nil {
+		return nil
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	r := h.rings[port]
+	if r == nil {
+		return nil
+	}
+	var out []*HealthCheck
+	if r.full {
+		out = append(out, r.buf[r.next:]...)
+	}
+	out = append(out, r.buf[:r.next]...)
+	if n > 0 && len(out) > n {
+		out = out[len(out)-n:]
+	}
+	return out
+}
+
+// Forget drops the history for ports that are no longer being watched.
+func (h *History) Forget(keep map[int]bool) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for port := range h.rings {
+		if !keep[port] {
+			delete(h.rings, port)
+		}
+	}
+}
+
+// Sparkline renders checks as a compact latency trend. Healthy checks become
+// bars scaled to the slowest response in the window; failures are marked with
+// "×" (down) or "·" (unknown) so flapping is visible at a glance.
+func Sparkline(checks []*HealthCheck) string {
+	maxMs := 1
+	for _, c := range checks {
+		if c != nil && isUp(c.Status) && c.ResponseMs > maxMs {
+			maxMs = c.ResponseMs
+		}
+	}
+	var b strings.Builder
+	for _, c := range checks {
+		if c == nil {
+			continue
+		}
+		switch {
+		case isUp(c.Status):
+			idx := c.ResponseMs * (len(sparkLevels) - 1) / maxMs
+			b.WriteRune(sparkLevels[idx])
+		case c.Status == HealthDown || c.Status == HealthTimeout:
+			b.WriteRune('×')
+		default:
+			b.WriteRune('·')
+		}
+	}
+	return b.String()
+}
+
+func isUp(status HealthStatus) bool {
+	return status == HealthOK || status == HealthSlow
+}
@@ -0,0 +1,48 @@
+package health
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistoryKeepsMostRecentChecks(t *testing.T) {
+	t.Parallel()
+
+	h := NewHistory(3)
+	base := time.Now()
+	for i := 0; i < 5; i++ {
+		h.Record(&HealthCheck{Port: 3000, Status: HealthOK, ResponseMs: i, LastCheck: base.Add(time.Duration(i) * time.Second)})
+	}
+
+	got := h.Recent(3000, 0)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 checks, got %d", len(got))
+	}
+	for i, want := range []int{2, 3, 4} {
+		if got[i].ResponseMs != want {
+			t.Fatalf("Recent()[%d].ResponseMs = %d, want %d", i, got[i].ResponseMs, want)
+		}
+	}
+
+	h.Record(got[2])
+	if again := h.Recent(3000, 0); len(again) != 3 || again[2] != got[2] {
+		t.Fatal("expected re-recording the latest check to be a no-op")
+	}
+	if last := h.Recent(3000, 1); len(last) != 1 || last[0].ResponseMs != 4 {
+		t.Fatalf("expected Recent(n=1) to return the newest check, got %+v", last)
+	}
+}
+
+func TestSparklineMarksFailures(t *testing.T) {
+	t.Parallel()
+
+	checks := []*HealthCheck{
+		{Status: HealthOK, ResponseMs: 0},
+		{Status: HealthOK, ResponseMs: 100},
+		{Status: HealthDown},
+		{Status: HealthUnknown},
+	}
+	if got, want := Sparkline(checks), "▁█×·"; got != want {
+		t.Fatalf("Sparkline() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,137 @@
+package health
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// checkInfra speaks just enough of a database or broker protocol to tell a
+// live server from a port that merely accepts connections.
+func (c *Checker) checkInfra(ctx context.Context, port int, cfg *models.HealthCheckConfig) probeResult {
+	name, exchange := infraProbe(cfg.Protocol)
+	timeout := c.timeoutFor(cfg)
+
+	start := time.Now()
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", fmt.Sprintf("localhost:%d", port))
+	if err != nil {
+		if isTimeout(err) {
+			return probeResult{ms: int(time.Since(start).Milliseconds()), msg: name + " connect timed out", timedOut: true}
+		}
+		return probeResult{msg: name + " unreachable"}
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	defer context.AfterFunc(ctx, func() { conn.Close() })()
+
+	detail, err := exchange(conn)
+	elapsed := int(time.Since(start).Milliseconds())
+	if err != nil {
+		return probeResult{ms: elapsed, msg: fmt.Sprintf("%s: %v", name, err), timedOut: isTimeout(err)}
+	}
+	return probeResult{ok: true, ms: elapsed, msg: fmt.Sprintf("%s %s in %dms", name, detail, elapsed)}
+}
+
+func infraProbe(protocol string) (string, func(net.Conn) (string, error)) {
+	switch strings.ToLower(protocol) {
+	case models.HealthProtocolRedis:
+		return "Redis", redisPing
+	case models.HealthProtocolPostgres:
+		return "Postgres", postgresSSLRequest
+	default:
+		return "MySQL", mysqlGreeting
+	}
+}
+
+// redisPing sends an inline PING. An auth error still proves the server is
+// up and speaking RESP.
+func redisPing(conn net.Conn) (string, error) {
+	if _, err := conn.Write([]byte("PING\r\n")); err != nil {
+		return "", err
+	}
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSpace(line)
+	switch {
+	case line == "+PONG":
+		return "PONG", nil
+	case strings.HasPrefix(line, "-NOAUTH"):
+		return "answered (auth required)", nil
+	case strings.HasPrefix(line, "-"):
+		return "", fmt.Errorf("PING failed: %s", strings.TrimPrefix(line, "-"))
+	}
+	return "", fmt.Errorf("unexpected PING reply %q", line)
+}
+
+// postgresSSLRequest sends the 8-byte SSLRequest that every Postgres server
+// answers with a single 'S' or 'N' before any authentication.
+func postgresSSLRequest(conn net.Conn) (string, error) {
+	req := make([]byte, 8)
+	binary.BigEndian.PutUint32(req[0:4], 8)
+	binary.BigEndian.PutUint32(req[4:8], 80877103)
+	if _, err := conn.Write(req); err != nil {
+		return "", err
+	}
+	reply := make([]byte, 1)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return "", err
+	}
+	if reply[0] != 'S' && reply[0] != 'N' {
+		return "", fmt.Errorf("unexpected SSLRequest reply %q", reply[0])
+	}
+	return "accepting connections", nil
+}
+
+// mysqlGreeting reads the handshake packet MySQL and MariaDB send on connect.
+func mysqlGreeting(conn net.Conn) (string, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", err
+	}
+	switch header[4] {
+	case 0x0a:
+		return "handshake", nil
+	case 0xff:
+		return "answered (connection refused by server)", nil
+	}
+	return "", fmt.Errorf("unexpected handshake byte 0x%02x", header[4])
+}
+
+func isInfra(cfg *models.HealthCheckConfig) bool {
+	if cfg == nil {
+		return false
+	}
+	switch strings.ToLower(cfg.Protocol) {
+	case models.HealthProtocolRedis, models.HealthProtocolPostgres, models.HealthProtocolMySQL:
+		return true
+	}
+	return false
+}
+
+// InfraConfig returns the probe suited to an infra kind from the scanner,
+// or nil when the default HTTP/TCP probe is the best available.
+func InfraConfig(kind string) *models.HealthCheckConfig {
+	switch kind {
+	case models.InfraRedis:
+		return &models.HealthCheckConfig{Protocol: models.HealthProtocolRedis}
+	case models.InfraPostgres:
+		return &models.HealthCheckConfig{Protocol: models.HealthProtocolPostgres}
+	case models.InfraMySQL:
+		return &models.HealthCheckConfig{Protocol: models.HealthProtocolMySQL}
+	case models.InfraElasticsearch:
+		return &models.HealthCheckConfig{Path: "/_cluster/health"}
+	case models.InfraMinio:
+		return &models.HealthCheckConfig{Path: "/minio/health/live", ExpectedStatus: []int{200}}
+	}
+	return nil
+}
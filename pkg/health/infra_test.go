@@ -0,0 +1,74 @@
+package health
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// serveOnce accepts connections on a loopback port and answers each with
+// handle. It returns the port.
+func serveOnce(t *testing.T, handle func(net.Conn)) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			handle(conn)
+			conn.Close()
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestCheckInfraProtocols(t *testing.T) {
+	t.Parallel()
+
+	redis := serveOnce(t, func(c net.Conn) {
+		line, _ := bufio.NewReader(c).ReadString('\n')
+		if line == "PING\r\n" {
+			_, _ = c.Write([]byte("+PONG\r\n"))
+		}
+	})
+	postgres := serveOnce(t, func(c net.Conn) {
+		buf := make([]byte, 8)
+		if _, err := io.ReadFull(c, buf); err == nil {
+			_, _ = c.Write([]byte("N"))
+		}
+	})
+	mysql := serveOnce(t, func(c net.Conn) {
+		_, _ = c.Write([]byte{0x4a, 0x00, 0x00, 0x00, 0x0a, '8', '.', '0'})
+	})
+	silent := serveOnce(t, func(c net.Conn) {
+		time.Sleep(300 * time.Millisecond)
+	})
+
+	checker := NewChecker(200 * time.Millisecond)
+	cases := []struct {
+		protocol string
+		port     int
+		want     HealthStatus
+	}{
+		{models.HealthProtocolRedis, redis, HealthOK},
+		{models.HealthProtocolPostgres, postgres, HealthOK},
+		{models.HealthProtocolMySQL, mysql, HealthOK},
+		{models.HealthProtocolRedis, silent, HealthTimeout},
+	}
+	for _, tc := range cases {
+		check := checker.CheckWithConfig(tc.port, &models.HealthCheckConfig{Protocol: tc.protocol, Timeout: models.Duration(200 * time.Millisecond)})
+		if check.Status != tc.want {
+			t.Fatalf("%s on %d: status %s (%s), want %s", tc.protocol, tc.port, check.Status, check.Message, tc.want)
+		}
+	}
+}
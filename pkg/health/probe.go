@@ -0,0 +1,189 @@
+package health
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// Probe defaults: how often a configured endpoint is hit and how far back
+// its availability is computed.
+const (
+	DefaultProbeInterval = 5 * time.Second
+	ProbeWindow          = 5 * time.Minute
+)
+
+// ProbeResult is one request to a service's probe endpoint. Status is 0
+// when no response was received, and Err says why.
+type ProbeResult struct {
+	At      time.Time
+	Latency time.Duration
+	Status  int
+	Err     error
+}
+
+// Unavailable reports whether the service gave no response.
+func (r ProbeResult) Unavailable() bool {
+	return r.Status == 0
+}
+
+// Failed reports whether the request did not get a successful response: no
+// response at all, or a 5xx status.
+func (r ProbeResult) Failed() bool {
+	return r.Unavailable() || r.Status >= 500
+}
+
+// ProbeInterval returns how often the probe endpoint of cfg is hit, or 0
+// when none is configured.
+func ProbeInterval(cfg *models.HealthCheckConfig) time.Duration {
+	if cfg == nil || cfg.ProbePath == "" {
+		return 0
+	}
+	if cfg.ProbeInterval > 0 {
+		return cfg.ProbeInterval.Std()
+	}
+	return DefaultProbeInterval
+}
+
+// Probe sends a GET to the probe endpoint of cfg on port, over https when
+// cfg.TLS is set. Any response counts; its status decides whether it was
+// an error.
+func (c *Checker) Probe(ctx context.Context, port int, cfg *models.HealthCheckConfig) ProbeResult {
+	scheme := "http"
+	if cfg.TLS {
+		scheme = "https"
+	}
+	client := &http.Client{
+		Timeout: c.timeoutFor(cfg),
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			DisableKeepAlives: true,
+		},
+	}
+	path := strings.TrimSpace(cfg.ProbePath)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	result := ProbeResult{At: time.Now()}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s://localhost:%d%s", scheme, port, path), nil)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	resp, err := client.Do(req)
+	if err == nil {
+		// Read the body so the latency covers the whole response.
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxBodyBytes))
+		resp.Body.Close()
+		result.Status = resp.StatusCode
+	}
+	result.Latency = time.Since(result.At)
+	result.Err = err
+	return result
+}
+
+// ProbeStats summarizes the probes of a window.
+type ProbeStats struct {
+	Probes       int
+	Unavailable  int           // probes without a response
+	Errors       int           // probes answered with a 5xx status
+	AvgLatency   time.Duration // of the probes that got a response
+	Availability float64       // share of probes that got a response, 0 to 1
+	ErrorRate    float64       // share of probes that failed, 0 to 1
+}
+
+// ProbeLog keeps the probe results of each service for ProbeWindow.
+type ProbeLog struct {
+	mu      sync.Mutex
+	results map[string][]ProbeResult
+}
+
+// NewProbeLog creates an empty log.
+func NewProbeLog() *ProbeLog {
+	return &ProbeLog{results: make(map[string][]ProbeResult)}
+}
+
+// Record adds a result for name, dropping those older than the window.
+func (l *ProbeLog) Record(name string, r ProbeResult) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.results[name] = append(prune(l.results[name], r.At), r)
+}
+
+// Last returns when name was last probed; zero when it never was.
+func (l *ProbeLog) Last(name string) time.Time {
+	if l == nil {
+		return time.Time{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if rs := l.results[name]; len(rs) > 0 {
+		return rs[len(rs)-1].At
+	}
+	return time.Time{}
+}
+
+// Stats summarizes the results for name within the window ending at now.
+func (l *ProbeLog) Stats(name string, now time.Time) ProbeStats {
+	var s ProbeStats
+	if l == nil {
+		return s
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var latency time.Duration
+	for _, r := range prune(l.results[name], now) {
+		s.Probes++
+		switch {
+		case r.Unavailable():
+			s.Unavailable++
+			continue
+		case r.Failed():
+			s.Errors++
+		}
+		latency += r.Latency
+	}
+	if s.Probes == 0 {
+		return s
+	}
+	if answered := s.Probes - s.Unavailable; answered > 0 {
+		s.AvgLatency = latency / time.Duration(answered)
+	}
+	s.Availability = float64(s.Probes-s.Unavailable) / float64(s.Probes)
+	s.ErrorRate = float64(s.Unavailable+s.Errors) / float64(s.Probes)
+	return s
+}
+
+// Forget drops the results of services that are no longer probed.
+func (l *ProbeLog) Forget(keep map[string]bool) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for name := range l.results {
+		if !keep[name] {
+			delete(l.results, name)
+		}
+	}
+}
+
+// prune returns the results of rs within ProbeWindow before now.
+func prune(rs []ProbeResult, now time.Time) []ProbeResult {
+	cutoff := now.Add(-ProbeWindow)
+	i := 0
+	for i < len(rs) && !rs[i].At.After(cutoff) {
+		i++
+	}
+	return rs[i:]
+}
@@ -0,0 +1,80 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/devports/devpt/internal/testutil"
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestProbeLogStats(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	l := NewProbeLog()
+	l.Record("api", ProbeResult{At: now.Add(-6 * time.Minute), Status: 500})
+	for i := 0; i < 7; i++ {
+		l.Record("api", ProbeResult{At: now.Add(-time.Duration(10-i) * time.Second), Status: 200, Latency: 10 * time.Millisecond})
+	}
+	l.Record("api", ProbeResult{At: now.Add(-2 * time.Second), Status: 503, Latency: 40 * time.Millisecond})
+	l.Record("api", ProbeResult{At: now.Add(-time.Second), Err: errors.New("connection refused")})
+	l.Record("api", ProbeResult{At: now, Status: 404, Latency: 10 * time.Millisecond})
+
+	s := l.Stats("api", now)
+	if s.Probes != 10 || s.Unavailable != 1 || s.Errors != 1 {
+		t.Fatalf("stats = %+v, want the 10 probes of the last 5 minutes", s)
+	}
+	if s.Availability != 0.9 || s.ErrorRate != 0.2 {
+		t.Errorf("availability %v, error rate %v; want 0.9 and 0.2", s.Availability, s.ErrorRate)
+	}
+	if want := (10*8 + 40) * time.Millisecond / 9; s.AvgLatency != want {
+		t.Errorf("avg latency = %v, want %v", s.AvgLatency, want)
+	}
+	if !l.Last("api").Equal(now) {
+		t.Errorf("last = %v, want %v", l.Last("api"), now)
+	}
+
+	l.Forget(map[string]bool{})
+	if s := l.Stats("api", now); s.Probes != 0 {
+		t.Errorf("forgotten service still has %+v", s)
+	}
+}
+
+func TestProbe(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ping" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	port := testutil.ServerPort(t, srv)
+	c := NewChecker(time.Second)
+
+	if r := c.Probe(context.Background(), port, &models.HealthCheckConfig{ProbePath: "ping"}); r.Status != 200 || r.Failed() {
+		t.Errorf("probe of /ping = %+v", r)
+	}
+	if r := c.Probe(context.Background(), port, &models.HealthCheckConfig{ProbePath: "/other"}); r.Status != 500 || !r.Failed() || r.Unavailable() {
+		t.Errorf("probe of a failing endpoint = %+v", r)
+	}
+	srv.Close()
+	if r := c.Probe(context.Background(), port, &models.HealthCheckConfig{ProbePath: "/ping"}); !r.Unavailable() || r.Err == nil {
+		t.Errorf("probe of a closed server = %+v", r)
+	}
+}
+
+func TestProbeInterval(t *testing.T) {
+	t.Parallel()
+	if got := ProbeInterval(&models.HealthCheckConfig{Path: "/healthz"}); got != 0 {
+		t.Errorf("no probe path: %v", got)
+	}
+	if got := ProbeInterval(&models.HealthCheckConfig{ProbePath: "/ping"}); got != DefaultProbeInterval {
+		t.Errorf("default interval: %v", got)
+	}
+	if got := ProbeInterval(&models.HealthCheckConfig{ProbePath: "/ping", ProbeInterval: models.Duration(time.Second)}); got != time.Second {
+		t.Errorf("configured interval: %v", got)
+	}
+}
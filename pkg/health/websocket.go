@@ -0,0 +1,89 @@
+package health
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// websocketGUID is the fixed GUID from RFC 6455 used to derive Sec-WebSocket-Accept.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// checkWebSocket performs an RFC 6455 opening handshake and closes the connection
+// as soon as the server answers, so no frames are exchanged.
+func (c *Checker) checkWebSocket(ctx context.Context, port int, cfg *models.HealthCheckConfig) probeResult {
+	timeout := c.timeoutFor(cfg)
+	path := probePath(cfg)
+	addr := fmt.Sprintf("localhost:%d", port)
+
+	start := time.Now()
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	var err error
+	if cfg != nil && cfg.TLS {
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{InsecureSkipVerify: true}}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		if isTimeout(err) {
+			return probeResult{ms: int(time.Since(start).Milliseconds()), msg: "WebSocket connect timed out", timedOut: true}
+		}
+		return probeResult{msg: "WebSocket unreachable"}
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	defer context.AfterFunc(ctx, func() { conn.Close() })()
+
+	key, err := websocketKey()
+	if err != nil {
+		return probeResult{msg: err.Error()}
+	}
+	req := fmt.Sprintf("GET %s HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\n\r\n", path, addr, key)
+	if _, err := conn.Write([]byte(req)); err != nil {
+		return probeResult{msg: fmt.Sprintf("WebSocket write failed: %v", err)}
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	elapsed := int(time.Since(start).Milliseconds())
+	if err != nil {
+		return probeResult{ms: elapsed, msg: fmt.Sprintf("WebSocket handshake failed: %v", err), timedOut: isTimeout(err)}
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return probeResult{ms: elapsed, msg: fmt.Sprintf("WebSocket %s rejected upgrade with HTTP %d", path, resp.StatusCode)}
+	}
+	if !strings.EqualFold(resp.Header.Get("Upgrade"), "websocket") || resp.Header.Get("Sec-WebSocket-Accept") != websocketAccept(key) {
+		return probeResult{ms: elapsed, msg: "WebSocket handshake returned an invalid accept key"}
+	}
+	return probeResult{ok: true, ms: elapsed, msg: fmt.Sprintf("WebSocket handshake in %dms", elapsed)}
+}
+
+func websocketKey() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate websocket key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(raw), nil
+}
+
+func websocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func isWebSocket(cfg *models.HealthCheckConfig) bool {
+	return cfg != nil && strings.EqualFold(cfg.Protocol, models.HealthProtocolWebSocket)
+}
@@ -0,0 +1,203 @@
+// Package history keeps a per-service audit trail of starts, stops,
+// restarts and crashes, so that "why did my API restart at 3pm" can be
+// answered after the fact.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// Action is what happened to a service.
+type Action string
+
+const (
+	Start   Action = "start"
+	Restart Action = "restart"
+	Stop    Action = "stop"  // stopped through devpt
+	Exit    Action = "exit"  // ended on its own with exit code 0
+	Crash   Action = "crash" // ended on its own with a failure
+	Lost    Action = "lost"  // ended unobserved, e.g. in a reboot
+)
+
+// Entry is one line of a service's history.
+type Entry struct {
+	Time   time.Time `json:"time"`
+	Action Action    `json:"action"`
+	PID    int       `json:"pid,omitempty"`
+	Port   int       `json:"port,omitempty"`
+	// Duration is how long the run lasted, for entries that end one.
+	Duration models.Duration `json:"duration,omitempty"`
+	// Reason explains how a run ended, e.g. "exited 137 (SIGKILL)".
+	Reason string `json:"reason,omitempty"`
+	// Actor is who caused the entry, e.g. "alice via cli".
+	Actor string `json:"actor,omitempty"`
+}
+
+// String renders the entry as a single human-readable line.
+func (e Entry) String() string {
+	var b strings.Builder
+	b.WriteString(e.Time.Local().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "  %-7s", e.Action)
+	if e.PID > 0 {
+		fmt.Fprintf(&b, "  pid=%d", e.PID)
+	}
+	if e.Port > 0 {
+		fmt.Fprintf(&b, "  :%d", e.Port)
+	}
+	if e.Duration > 0 {
+		fmt.Fprintf(&b, "  ran %s", e.Duration.Std().Round(time.Second))
+	}
+	if e.Reason != "" {
+		b.WriteString("  " + e.Reason)
+	}
+	if e.Actor != "" {
+		b.WriteString("  by " + e.Actor)
+	}
+	return b.String()
+}
+
+// maxFileSize is the size past which a service's history is compacted to
+// its newest entries: at most keepEntries, filling at most half the limit
+// so compaction doesn't repeat on every append.
+const (
+	maxFileSize = 512 << 10
+	keepEntries = 1000
+)
+
+// Store keeps one JSON-lines file per service in a directory shared by
+// every devpt process.
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewStore creates a store in dir. The directory is created on first append.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+func (s *Store) path(service string) string {
+	return filepath.Join(s.dir, url.PathEscape(service)+".jsonl")
+}
+
+// Append adds e to the service's history. A zero Time is set to now.
+func (s *Store) Append(service string, e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode history entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	path := s.path(service)
+	// O_APPEND keeps concurrent writers from interleaving within a line.
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	_, err = f.Write(line)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if fi, err := os.Stat(path); err == nil && fi.Size() > maxFileSize {
+		return s.compact(service)
+	}
+	return nil
+}
+
+// compact rewrites the service's history with only its newest entries.
+func (s *Store) compact(service string) error {
+	entries, err := s.read(service, keepEntries)
+	if err != nil {
+		return err
+	}
+	var lines [][]byte
+	size := 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		line, err := json.Marshal(entries[i])
+		if err != nil {
+			return fmt.Errorf("failed to encode history entry: %w", err)
+		}
+		if size += len(line) + 1; size > maxFileSize/2 {
+			break
+		}
+		lines = append(lines, line)
+	}
+	var b strings.Builder
+	for i := len(lines) - 1; i >= 0; i-- {
+		b.Write(lines[i])
+		b.WriteByte('\n')
+	}
+	tmp := s.path(service) + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to compact history: %w", err)
+	}
+	return os.Rename(tmp, s.path(service))
+}
+
+// Recent returns up to n of the service's newest entries, oldest first.
+func (s *Store) Recent(service string, n int) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read(service, n)
+}
+
+// Since returns the service's entries made at or after t, oldest first.
+func (s *Store) Since(service string, t time.Time) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := s.read(service, keepEntries)
+	i := sort.Search(len(entries), func(i int) bool { return !entries[i].Time.Before(t) })
+	return entries[i:], err
+}
+
+func (s *Store) read(service string, n int) ([]Entry, error) {
+	f, err := os.Open(s.path(service))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+
+	var out []Entry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e Entry
+		if json.Unmarshal(sc.Bytes(), &e) != nil {
+			continue // malformed or partially written line
+		}
+		if n <= 0 {
+			continue
+		}
+		if len(out) == n {
+			copy(out, out[1:])
+			out = out[:n-1]
+		}
+		out = append(out, e)
+	}
+	if err := sc.Err(); err != nil {
+		return out, fmt.Errorf("failed to read history: %w", err)
+	}
+	return out, nil
+}
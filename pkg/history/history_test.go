@@ -0,0 +1,89 @@
+package history
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestRecentReturnsNewestEntriesPerService(t *testing.T) {
+	t.Parallel()
+
+	store := NewStore(t.TempDir())
+	for pid := 1; pid <= 3; pid++ {
+		if err := store.Append("api", Entry{Action: Start, PID: pid}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	if err := store.Append("web/ui", Entry{Action: Crash, PID: 9, Duration: models.Duration(90 * time.Second), Reason: "exited 1"}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	got, err := store.Recent("api", 2)
+	if err != nil {
+		t.Fatalf("recent: %v", err)
+	}
+	if len(got) != 2 || got[0].PID != 2 || got[1].PID != 3 {
+		t.Fatalf("unexpected entries: %+v", got)
+	}
+
+	web, err := store.Recent("web/ui", 10)
+	if err != nil || len(web) != 1 {
+		t.Fatalf("web history = %+v, %v", web, err)
+	}
+	line := web[0].String()
+	for _, want := range []string{"crash", "pid=9", "ran 1m30s", "exited 1"} {
+		if !strings.Contains(line, want) {
+			t.Fatalf("String() = %q, missing %q", line, want)
+		}
+	}
+
+	if none, err := store.Recent("missing", 5); err != nil || len(none) != 0 {
+		t.Fatalf("missing service = %+v, %v", none, err)
+	}
+}
+
+func TestAppendCompactsLargeHistory(t *testing.T) {
+	t.Parallel()
+
+	store := NewStore(t.TempDir())
+	reason := strings.Repeat("x", 300)
+	for i := 0; i < 2000; i++ {
+		if err := store.Append("api", Entry{Action: Start, PID: i + 1, Reason: reason}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	fi, err := os.Stat(store.path("api"))
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if fi.Size() > maxFileSize {
+		t.Fatalf("history is %d bytes, want at most %d", fi.Size(), maxFileSize)
+	}
+	got, err := store.Recent("api", 1)
+	if err != nil || len(got) != 1 || got[0].PID != 2000 {
+		t.Fatalf("newest entry = %+v, %v", got, err)
+	}
+}
+
+func TestSinceReturnsEntriesAfterTime(t *testing.T) {
+	t.Parallel()
+
+	store := NewStore(t.TempDir())
+	now := time.Now()
+	for i, age := range []time.Duration{72 * time.Hour, 2 * time.Hour, time.Hour} {
+		if err := store.Append("api", Entry{Time: now.Add(-age), Action: Start, PID: i + 1}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	got, err := store.Since("api", now.Add(-24*time.Hour))
+	if err != nil || len(got) != 2 || got[0].PID != 2 {
+		t.Fatalf("since = %+v, %v", got, err)
+	}
+	if none, err := store.Since("web", now); err != nil || len(none) != 0 {
+		t.Fatalf("unknown service = %+v, %v", none, err)
+	}
+}
@@ -1,15 +1,32 @@
 package models
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 )
 
 // ConfigPaths provides paths for config and data directories
 type ConfigPaths struct {
-	ConfigDir string
+	ConfigDir    string
 	RegistryFile string
-	LogsDir   string
+	ConfigFile   string
+	EventsFile   string
+	HistoryDir   string
+	LogsDir      string
+	JobLogsDir   string
+	TUIStateFile string
+	// SnapshotsDir holds the snapshots saved by devpt snapshot save.
+	SnapshotsDir string
+	// DaemonSocket is the unix socket devpt daemon serves clients on.
+	DaemonSocket string
+	// APITokenFile holds the token the REST API requires for actions.
+	APITokenFile string
 }
 
 // GetConfigPaths returns paths for devpt configuration
@@ -18,13 +35,25 @@ func GetConfigPaths() (ConfigPaths, error) {
 	if err != nil {
 		return ConfigPaths{}, err
 	}
+	return ConfigPathsIn(filepath.Join(home, ".config", "devpt")), nil
+}
 
-	configDir := filepath.Join(home, ".config", "devpt")
+// ConfigPathsIn returns the paths devpt uses when its configuration lives
+// in configDir instead of ~/.config/devpt.
+func ConfigPathsIn(configDir string) ConfigPaths {
 	return ConfigPaths{
 		ConfigDir:    configDir,
 		RegistryFile: filepath.Join(configDir, "registry.json"),
+		ConfigFile:   filepath.Join(configDir, "config.json"),
+		EventsFile:   filepath.Join(configDir, "events.jsonl"),
+		HistoryDir:   filepath.Join(configDir, "history"),
 		LogsDir:      filepath.Join(configDir, "logs"),
-	}, nil
+		JobLogsDir:   filepath.Join(configDir, "job-logs"),
+		TUIStateFile: filepath.Join(configDir, "tui-state.json"),
+		SnapshotsDir: filepath.Join(configDir, "snapshots"),
+		DaemonSocket: filepath.Join(configDir, "daemon.sock"),
+		APITokenFile: filepath.Join(configDir, "api-token"),
+	}
 }
 
 // EnsureDirs creates necessary configuration directories
@@ -37,3 +66,502 @@ func (cp ConfigPaths) EnsureDirs() error {
 	}
 	return nil
 }
+
+// Config holds user settings read from config.json. Every field is optional;
+// zero values fall back to built-in defaults.
+type Config struct {
+	Health    HealthSettings    `json:"health,omitempty"`
+	CrashLoop CrashLoopSettings `json:"crash_loop,omitempty"`
+	Webhooks  []WebhookConfig   `json:"webhooks,omitempty"`
+	// Notifications configures native desktop alerts (macOS only).
+	Notifications DesktopNotifications `json:"notifications,omitempty"`
+	Ports         PortSettings         `json:"ports,omitempty"`
+	Scan          ScanSettings         `json:"scan,omitempty"`
+	TUI           TUISettings          `json:"tui,omitempty"`
+	// Agents teaches agent detection about AI tools beyond the built-in ones.
+	Agents []AgentSignature `json:"agents,omitempty"`
+	Logs   LogSettings      `json:"logs,omitempty"`
+	// Editor is the command the TUI opens project directories with, e.g.
+	// "code" or "nvim"; empty falls back to $VISUAL, $EDITOR, then code or
+	// cursor when installed.
+	Editor string `json:"editor,omitempty"`
+	// Hosts are other machines running devpt api serve, such as a
+	// devcontainer or a VM, whose servers the TUI shows next to these.
+	Hosts []HostConfig `json:"hosts,omitempty"`
+	// Telemetry exports spans of scans, starts, stops and health checks.
+	Telemetry TelemetrySettings `json:"telemetry,omitempty"`
+}
+
+// TelemetrySettings configures the OTLP trace export. The standard
+// OTEL_EXPORTER_OTLP_* and OTEL_SERVICE_NAME environment variables
+// override them.
+type TelemetrySettings struct {
+	// Endpoint is the base URL of an OTLP/HTTP collector, e.g.
+	// "http://localhost:4318"; traces are posted to /v1/traces under it.
+	// Export is off while it is empty.
+	Endpoint    string            `json:"endpoint,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	ServiceName string            `json:"service_name,omitempty"` // default "devpt"
+}
+
+// LocalHost is the name the TUI gives this machine among Hosts.
+const LocalHost = "local"
+
+// HostConfig is a remote devpt whose servers the TUI aggregates.
+type HostConfig struct {
+	Name    string   `json:"name"`
+	URL     string   `json:"url"`               // its REST API, e.g. "http://127.0.0.1:7071"
+	Timeout Duration `json:"timeout,omitempty"` // default 2s
+}
+
+// LogSettings is the retention policy `devpt gc` applies to service logs.
+type LogSettings struct {
+	KeepRuns int      `json:"keep_runs,omitempty"` // logs of at most this many runs per service (default 10)
+	MaxAge   Duration `json:"max_age,omitempty"`   // logs older than this are pruned (default 720h)
+	MaxSize  ByteSize `json:"max_size,omitempty"`  // warn when all logs together exceed this (default 1GB)
+}
+
+// Default log retention
+const (
+	DefaultLogKeepRuns = 10
+	DefaultLogMaxAge   = Duration(30 * 24 * time.Hour)
+	DefaultLogMaxSize  = ByteSize(1 << 30)
+)
+
+// Effective returns the settings with defaults applied.
+func (l LogSettings) Effective() LogSettings {
+	if l.KeepRuns <= 0 {
+		l.KeepRuns = DefaultLogKeepRuns
+	}
+	if l.MaxAge <= 0 {
+		l.MaxAge = DefaultLogMaxAge
+	}
+	if l.MaxSize <= 0 {
+		l.MaxSize = DefaultLogMaxSize
+	}
+	return l
+}
+
+// AgentSignature describes how to recognize an AI agent among a server's
+// ancestor processes or in its environment.
+type AgentSignature struct {
+	Name        string   `json:"name"`
+	Processes   []string `json:"processes,omitempty"`    // process name substrings, e.g. "windsurf"
+	Commands    []string `json:"commands,omitempty"`     // command line substrings, for agents run by an interpreter, e.g. "aider"
+	EnvPrefixes []string `json:"env_prefixes,omitempty"` // environment variable prefixes, e.g. "WINDSURF_"
+}
+
+// TUISettings controls how often the interactive view rescans, and its keys.
+type TUISettings struct {
+	RefreshInterval Duration `json:"refresh_interval,omitempty"` // default 1s
+	IdleInterval    Duration `json:"idle_interval,omitempty"`    // used while unfocused or idle (default 5s)
+	IdleAfter       Duration `json:"idle_after,omitempty"`       // time without input before slowing down (default 1m)
+	// Keys rebinds actions, e.g. {"stop": "ctrl+x", "up": ["up", "t"]};
+	// actions left out keep their DefaultKeyBindings.
+	Keys  map[string]KeyBinding `json:"keys,omitempty"`
+	Theme string                `json:"theme,omitempty"` // one of TUIThemes (default "default")
+}
+
+// TUIThemes are the color themes the TUI can use.
+var TUIThemes = []string{"default", "solarized", "high-contrast", "monochrome"}
+
+// ValidTheme reports whether name is one of TUIThemes; "" is the default.
+func ValidTheme(name string) bool {
+	if name == "" {
+		return true
+	}
+	for _, t := range TUIThemes {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyBinding is the keys that trigger a TUI action, named as the terminal
+// reports them: "q", "G", "enter", "ctrl+e", "shift+tab", "f1". In
+// config.json it is a key or a list of keys.
+type KeyBinding []string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (k *KeyBinding) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*k = KeyBinding{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("invalid key binding %s: want a key or a list of keys", string(data))
+	}
+	*k = many
+	return nil
+}
+
+// DefaultKeyBindings are the TUI's keys for each action.
+var DefaultKeyBindings = map[string]KeyBinding{
+	"quit":          {"q", "ctrl+c"},
+	"focus":         {"tab"},
+	"help":          {"?", "f1"},
+	"filter":        {"/"},
+	"clear_filter":  {"ctrl+l"},
+	"sort":          {"s"},
+	"health_detail": {"h"},
+	"refresh":       {"r"},
+	"framework":     {"F"},
+	"debug":         {"d"},
+	"log_usage":     {"L"},
+	"gc":            {"G"},
+	"runs":          {"i"},
+	"detail":        {"p"},
+	"pin_logs":      {"l"},
+	"open":          {"o"},
+	"next_url":      {"u"},
+	"edit_project":  {"e"},
+	"all_listeners": {"a"},
+	"follow":        {"f"},
+	"add":           {"ctrl+a"},
+	"edit":          {"E"},
+	"restart":       {"ctrl+r"},
+	"stop":          {"ctrl+e"},
+	"remove":        {"x", "delete", "ctrl+d"},
+	"command":       {":", "shift+;", ";", "c"},
+	"back":          {"b"},
+	"up":            {"up", "k"},
+	"down":          {"down", "j"},
+	"page_up":       {"pgup"},
+	"page_down":     {"pgdown", " "},
+	"top":           {"home"},
+	"bottom":        {"end"},
+	"copy":          {"y"},
+	"logs":          {"enter"},
+	"theme":         {"T"},
+	"columns":       {"C"},
+	"conflict":      {"R"},
+	"host":          {"H"},
+	"requests":      {"I"},
+}
+
+// KeyBindings returns the keys of every TUI action, with Keys applied over
+// the defaults. It fails on unknown actions, empty bindings, and keys bound
+// to more than one action.
+func (t TUISettings) KeyBindings() (map[string]KeyBinding, error) {
+	out := make(map[string]KeyBinding, len(DefaultKeyBindings))
+	for action, keys := range DefaultKeyBindings {
+		out[action] = keys
+	}
+	for action, keys := range t.Keys {
+		if _, ok := DefaultKeyBindings[action]; !ok {
+			return nil, fmt.Errorf("tui.keys: unknown action %q", action)
+		}
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("tui.keys.%s: no keys given", action)
+		}
+		for _, k := range keys {
+			// The space bar is " "; no other key name has whitespace.
+			if k == "" || (k != " " && strings.ContainsAny(k, " \t\n")) {
+				return nil, fmt.Errorf("tui.keys.%s: invalid key %q", action, k)
+			}
+		}
+		out[action] = keys
+	}
+	actions := make([]string, 0, len(out))
+	for action := range out {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	owner := make(map[string]string)
+	for _, action := range actions {
+		for _, k := range out[action] {
+			if other, ok := owner[k]; ok && other != action {
+				return nil, fmt.Errorf("tui.keys: %q is bound to both %s and %s", k, other, action)
+			}
+			owner[k] = action
+		}
+	}
+	return out, nil
+}
+
+// Default TUI refresh settings
+const (
+	DefaultRefreshInterval = Duration(time.Second)
+	DefaultIdleInterval    = Duration(5 * time.Second)
+	DefaultIdleAfter       = Duration(time.Minute)
+)
+
+// Effective returns the settings with defaults applied.
+func (t TUISettings) Effective() TUISettings {
+	if t.RefreshInterval <= 0 {
+		t.RefreshInterval = DefaultRefreshInterval
+	}
+	if t.IdleInterval <= 0 {
+		t.IdleInterval = DefaultIdleInterval
+	}
+	if t.IdleInterval < t.RefreshInterval {
+		t.IdleInterval = t.RefreshInterval
+	}
+	if t.IdleAfter <= 0 {
+		t.IdleAfter = DefaultIdleAfter
+	}
+	return t
+}
+
+// ScanSettings extends the built-in patterns that decide which listeners are
+// development servers, and selects how listeners are found. Patterns are
+// case-insensitive command substrings.
+type ScanSettings struct {
+	Include []string `json:"include,omitempty"` // extra runtimes, e.g. "mix", "zig", "swift run"
+	Exclude []string `json:"exclude,omitempty"` // commands never listed; checked before Include
+	Backend string   `json:"backend,omitempty"` // "native" (default where available) or "exec" (lsof and ps)
+}
+
+// PortSettings controls automatic port allocation.
+type PortSettings struct {
+	AutoMin int `json:"auto_min,omitempty"` // first port tried for auto allocation (default 4000)
+	AutoMax int `json:"auto_max,omitempty"` // last port tried (default 4999)
+}
+
+// Default automatic port range
+const (
+	DefaultAutoPortMin = 4000
+	DefaultAutoPortMax = 4999
+)
+
+// Effective returns the settings with defaults applied.
+func (p PortSettings) Effective() PortSettings {
+	if p.AutoMin <= 0 {
+		p.AutoMin = DefaultAutoPortMin
+	}
+	if p.AutoMax <= 0 {
+		p.AutoMax = DefaultAutoPortMax
+	}
+	return p
+}
+
+// HealthSettings tunes how probe latency is categorized and shown.
+type HealthSettings struct {
+	SlowThreshold    Duration `json:"slow_threshold,omitempty"`    // responses slower than this are "slow"
+	TimeoutThreshold Duration `json:"timeout_threshold,omitempty"` // responses slower than this (or timing out) are "timeout"
+	Icons            string   `json:"icons,omitempty"`             // "emoji" (default) or "text" for OK, SLOW, TIMEOUT, DOWN and ?
+}
+
+// CrashLoopSettings controls when repeated crashes count as a crash loop.
+type CrashLoopSettings struct {
+	Threshold int      `json:"threshold,omitempty"` // crashes within Window (default 3)
+	Window    Duration `json:"window,omitempty"`    // default 5m
+}
+
+// Default crash-loop detection settings
+const (
+	DefaultCrashLoopThreshold = 3
+	DefaultCrashLoopWindow    = Duration(5 * time.Minute)
+)
+
+// Effective returns the settings with defaults applied.
+func (c CrashLoopSettings) Effective() CrashLoopSettings {
+	if c.Threshold <= 0 {
+		c.Threshold = DefaultCrashLoopThreshold
+	}
+	if c.Window <= 0 {
+		c.Window = DefaultCrashLoopWindow
+	}
+	return c
+}
+
+// Webhook kinds
+const (
+	WebhookGeneric = "generic"
+	WebhookSlack   = "slack"
+	WebhookDiscord = "discord"
+)
+
+// Webhook trigger events
+const (
+	WebhookOnCrashed      = "crashed"
+	WebhookOnCrashLooping = "crash-looping"
+	WebhookOnHealthDown   = "health-down"
+	WebhookOnOverLimit    = "over-limit" // a resource limit with the notify action
+)
+
+// WebhookConfig describes an HTTP endpoint notified about service failures.
+type WebhookConfig struct {
+	URL      string            `json:"url"`
+	Kind     string            `json:"kind,omitempty"`     // "generic" (default), "slack" or "discord"
+	Events   []string          `json:"events,omitempty"`   // subset of crashed, crash-looping, health-down, over-limit; empty means all
+	Template string            `json:"template,omitempty"` // text/template for the message (slack/discord) or whole body (generic)
+	Headers  map[string]string `json:"headers,omitempty"`
+	Timeout  Duration          `json:"timeout,omitempty"` // default 5s
+}
+
+// Wants reports whether the webhook subscribes to event.
+func (w WebhookConfig) Wants(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// DesktopNotifications controls native alerts shown while the TUI runs in an
+// unfocused terminal.
+type DesktopNotifications struct {
+	Enabled bool     `json:"enabled,omitempty"`
+	Events  []string `json:"events,omitempty"` // same names as webhook events; empty means all
+	Muted   []string `json:"muted,omitempty"`  // service names that never raise an alert
+}
+
+// Wants reports whether an alert should be raised for event on service.
+func (d DesktopNotifications) Wants(event, service string) bool {
+	if !d.Enabled {
+		return false
+	}
+	for _, m := range d.Muted {
+		if m == service {
+			return false
+		}
+	}
+	return WebhookConfig{Events: d.Events}.Wants(event)
+}
+
+// LoadConfig reads user settings from path. A missing file yields defaults.
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := json.Unmarshal(content, cfg); err != nil {
+		return &Config{}, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return &Config{}, err
+	}
+	return cfg, nil
+}
+
+// Validate checks settings for contradictory values.
+func (c *Config) Validate() error {
+	if c.CrashLoop.Threshold < 0 || c.CrashLoop.Window < 0 {
+		return fmt.Errorf("crash_loop threshold and window must not be negative")
+	}
+	h := c.Health
+	if h.SlowThreshold < 0 || h.TimeoutThreshold < 0 {
+		return fmt.Errorf("health thresholds must not be negative")
+	}
+	if h.SlowThreshold > 0 && h.TimeoutThreshold > 0 && h.SlowThreshold >= h.TimeoutThreshold {
+		return fmt.Errorf("health.slow_threshold (%s) must be lower than health.timeout_threshold (%s)", h.SlowThreshold.Std(), h.TimeoutThreshold.Std())
+	}
+	switch h.Icons {
+	case "", "emoji", "text":
+	default:
+		return fmt.Errorf("health.icons must be emoji or text, got %q", h.Icons)
+	}
+	if c.Logs.KeepRuns < 0 || c.Logs.MaxAge < 0 || c.Logs.MaxSize < 0 {
+		return fmt.Errorf("logs.keep_runs, logs.max_age and logs.max_size must not be negative")
+	}
+	if t := c.TUI; t.RefreshInterval < 0 || t.IdleInterval < 0 || t.IdleAfter < 0 {
+		return fmt.Errorf("tui intervals must not be negative")
+	}
+	if _, err := c.TUI.KeyBindings(); err != nil {
+		return err
+	}
+	if !ValidTheme(c.TUI.Theme) {
+		return fmt.Errorf("tui.theme must be one of %s, got %q", strings.Join(TUIThemes, ", "), c.TUI.Theme)
+	}
+	if e := c.Telemetry.Endpoint; e != "" {
+		if u, err := url.Parse(e); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("telemetry.endpoint must be an http(s) URL, got %q", e)
+		}
+	}
+	if p := c.Ports; p.AutoMin < 0 || p.AutoMax > 65535 || (p.AutoMin > 0 && p.AutoMax > 0 && p.AutoMin > p.AutoMax) {
+		return fmt.Errorf("ports.auto_min and ports.auto_max must form a range within 1-65535")
+	}
+	for _, p := range append(append([]string{}, c.Scan.Include...), c.Scan.Exclude...) {
+		if strings.TrimSpace(p) == "" {
+			return fmt.Errorf("scan.include and scan.exclude must not contain empty patterns")
+		}
+	}
+	for _, a := range c.Agents {
+		if strings.TrimSpace(a.Name) == "" {
+			return fmt.Errorf("agents entries need a name")
+		}
+		patterns := append(append(append([]string{}, a.Processes...), a.Commands...), a.EnvPrefixes...)
+		if len(patterns) == 0 {
+			return fmt.Errorf("agent %q needs at least one of processes, commands or env_prefixes", a.Name)
+		}
+		for _, p := range patterns {
+			if strings.TrimSpace(p) == "" {
+				return fmt.Errorf("agent %q must not contain empty patterns", a.Name)
+			}
+		}
+	}
+	switch c.Scan.Backend {
+	case "", "native", "exec":
+	default:
+		return fmt.Errorf("scan.backend must be native or exec, got %q", c.Scan.Backend)
+	}
+	for _, e := range c.Notifications.Events {
+		if !isWebhookEvent(e) {
+			return fmt.Errorf("notifications: unknown event %q (use crashed, crash-looping, health-down or over-limit)", e)
+		}
+	}
+	for i, w := range c.Webhooks {
+		if err := w.validate(); err != nil {
+			return fmt.Errorf("webhooks[%d]: %w", i, err)
+		}
+	}
+	names := map[string]bool{LocalHost: true}
+	for i, h := range c.Hosts {
+		if strings.TrimSpace(h.Name) == "" {
+			return fmt.Errorf("hosts[%d] needs a name", i)
+		}
+		if names[h.Name] {
+			return fmt.Errorf("hosts[%d]: name %q is taken", i, h.Name)
+		}
+		names[h.Name] = true
+		u, err := url.Parse(h.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("hosts[%d]: url must be an http(s) URL, got %q", i, h.URL)
+		}
+		if h.Timeout < 0 {
+			return fmt.Errorf("hosts[%d]: timeout must not be negative", i)
+		}
+	}
+	return nil
+}
+
+func isWebhookEvent(e string) bool {
+	switch e {
+	case WebhookOnCrashed, WebhookOnCrashLooping, WebhookOnHealthDown, WebhookOnOverLimit:
+		return true
+	}
+	return false
+}
+
+func (w WebhookConfig) validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an http(s) URL, got %q", w.URL)
+	}
+	switch w.Kind {
+	case "", WebhookGeneric, WebhookSlack, WebhookDiscord:
+	default:
+		return fmt.Errorf("unknown kind %q (use generic, slack or discord)", w.Kind)
+	}
+	for _, e := range w.Events {
+		if !isWebhookEvent(e) {
+			return fmt.Errorf("unknown event %q (use crashed, crash-looping, health-down or over-limit)", e)
+		}
+	}
+	if w.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	return nil
+}
@@ -1,6 +1,12 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
 
 // Confidence level for detection heuristics
 type Confidence string
@@ -15,10 +21,12 @@ const (
 type Source string
 
 const (
-	SourceManual  Source = "manual"
-	SourceManaged Source = "managed"
-	SourceAgent   Source = "agent"
-	SourceUnknown Source = "unknown"
+	SourceManual    Source = "manual"
+	SourceManaged   Source = "managed"
+	SourceAgent     Source = "agent"
+	SourceContainer Source = "container"
+	SourceForward   Source = "port-forward"
+	SourceUnknown   Source = "unknown"
 )
 
 // ProcessRecord represents a discovered listening process
@@ -28,11 +36,137 @@ type ProcessRecord struct {
 	User        string     `json:"user"`
 	Command     string     `json:"command"`
 	Port        int        `json:"port"`
-	Protocol    string     `json:"protocol"` // "tcp"
+	Protocol    string     `json:"protocol"`               // "tcp"
+	BindAddress string     `json:"bind_address,omitempty"` // e.g. "127.0.0.1", "::1", "*"
 	CWD         string     `json:"cwd"`
 	StartTime   *time.Time `json:"start_time,omitempty"`
 	ProjectRoot string     `json:"project_root,omitempty"`
 	AgentTag    *AgentTag  `json:"agent_tag,omitempty"`
+	// Container is set when the port is published by a container and the
+	// listening process is only the runtime's port proxy.
+	Container *ContainerInfo `json:"container,omitempty"`
+	// PortForward is set for `kubectl port-forward` listeners.
+	PortForward *PortForwardInfo `json:"port_forward,omitempty"`
+	// Language and Framework are detected from the command and project
+	// files, e.g. "Node.js" and "Next.js", with versions from the project's
+	// manifests and version files where known.
+	Language         string `json:"language,omitempty"`
+	RuntimeVersion   string `json:"runtime_version,omitempty"`
+	Framework        string `json:"framework,omitempty"`
+	FrameworkVersion string `json:"framework_version,omitempty"`
+	// Infra names the local database or broker serving the port, e.g.
+	// "postgres". Such listeners are listed apart from dev servers.
+	Infra string `json:"infra,omitempty"`
+}
+
+// Infra kinds recognized by the scanner
+const (
+	InfraPostgres      = "postgres"
+	InfraMySQL         = "mysql"
+	InfraRedis         = "redis"
+	InfraMongo         = "mongodb"
+	InfraElasticsearch = "elasticsearch"
+	InfraMinio         = "minio"
+	InfraRabbitMQ      = "rabbitmq"
+)
+
+// PortForwardInfo describes the cluster target of a kubectl port-forward.
+type PortForwardInfo struct {
+	Target    string   `json:"target"` // e.g. "svc/api" or "pod/web-0"
+	Namespace string   `json:"namespace,omitempty"`
+	Context   string   `json:"context,omitempty"`
+	Ports     []string `json:"ports,omitempty"` // as given, e.g. "8080:80"
+}
+
+// Label renders the forward as e.g. "staging/svc/api".
+func (f *PortForwardInfo) Label() string {
+	label := f.Target
+	if f.Namespace != "" {
+		label = f.Namespace + "/" + label
+	}
+	if f.Context != "" {
+		label = f.Context + ":" + label
+	}
+	return label
+}
+
+// ContainerInfo identifies the container behind a published port.
+type ContainerInfo struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Image          string `json:"image,omitempty"`
+	ComposeProject string `json:"compose_project,omitempty"`
+	ComposeService string `json:"compose_service,omitempty"`
+	// Workspace is the host folder a devcontainer was opened from.
+	Workspace string `json:"workspace,omitempty"`
+	// VM is the Lima VM, such as colima's, that forwards the port to the
+	// host. Without an ID the port is served by a process in the VM rather
+	// than by a container.
+	VM string `json:"vm,omitempty"`
+}
+
+// Label renders the container as e.g. "db (postgres:16)", preferring the
+// compose service name, or the project of a devcontainer, e.g. "shop
+// devcontainer (node:20)".
+func (c *ContainerInfo) Label() string {
+	if c.ID == "" && c.VM != "" {
+		return "process in VM " + c.VM
+	}
+	name := c.Name
+	switch {
+	case c.Workspace != "":
+		name = filepath.Base(c.Workspace) + " devcontainer"
+	case c.ComposeService != "":
+		name = c.ComposeService
+	}
+	if c.Image != "" {
+		return fmt.Sprintf("%s (%s)", name, c.Image)
+	}
+	return name
+}
+
+// Runtime names what runs the container: "colima" or "lima" for a VM,
+// else "docker".
+func (c *ContainerInfo) Runtime() string {
+	switch {
+	case c.VM == "colima" || strings.HasPrefix(c.VM, "colima-"):
+		return "colima"
+	case c.VM != "":
+		return "lima"
+	}
+	return "docker"
+}
+
+// Stack renders framework and language with their major versions, e.g.
+// "Next.js 14 / Node.js 20", or just the language when the framework is
+// generic.
+func (r *ProcessRecord) Stack() string {
+	if r.Language == "" {
+		return ""
+	}
+	runtime := withMajor(r.Language, r.RuntimeVersion)
+	if r.Framework == "" || strings.HasSuffix(r.Framework, "(generic)") || strings.HasSuffix(r.Framework, "(custom)") {
+		return runtime
+	}
+	return withMajor(r.Framework, r.FrameworkVersion) + " / " + runtime
+}
+
+func withMajor(name, version string) string {
+	if version == "" {
+		return name
+	}
+	major, _, _ := strings.Cut(version, ".")
+	return name + " " + major
+}
+
+// Exposed reports whether the listener accepts connections on all
+// interfaces rather than loopback only.
+func (r *ProcessRecord) Exposed() bool {
+	switch r.BindAddress {
+	case "*", "0.0.0.0", "::":
+		return true
+	}
+	return false
 }
 
 // AgentTag identifies servers likely started by AI agents
@@ -40,11 +174,18 @@ type AgentTag struct {
 	Source     Source     `json:"source"`
 	AgentName  string     `json:"agent_name,omitempty"`
 	Confidence Confidence `json:"confidence"`
+	// AncestorName and AncestorPID identify the agent process found among
+	// the server's ancestors, when that is how it was detected.
+	AncestorName string `json:"ancestor_name,omitempty"`
+	AncestorPID  int    `json:"ancestor_pid,omitempty"`
 }
 
 // ManagedService represents an explicitly registered server
 type ManagedService struct {
-	Name      string     `json:"name"`
+	Name string `json:"name"`
+	// Project is the project the service belongs to, if any; Name is then
+	// qualified with it, as in "shop:api".
+	Project   string     `json:"project,omitempty"`
 	CWD       string     `json:"cwd"`
 	Command   string     `json:"command"`
 	Ports     []int      `json:"ports"`
@@ -52,22 +193,454 @@ type ManagedService struct {
 	LastStart *time.Time `json:"last_start,omitempty"`
 	LastStop  *time.Time `json:"last_stop,omitempty"`
 	Tags      []string   `json:"tags,omitempty"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
+	// Env holds "KEY=value" entries added to the environment of every run.
+	Env       []string  `json:"env,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// VerifiedAt is when LastPID was last confirmed to still be the process
+	// devpt started, e.g. after a reboot or sleep. It is only kept in memory,
+	// so that checking never writes the registry.
+	VerifiedAt *time.Time `json:"-"`
+
+	// URLs are where the service is browsed, e.g. its app, API docs and
+	// storybook; the first is the primary one `devpt open` uses.
+	URLs []ServiceURL `json:"urls,omitempty"`
+	// URL is the single URL registries written before URLs hold;
+	// ServiceURLs lists it first.
+	URL string `json:"url,omitempty"`
+
+	// Health customizes health probes; nil uses the default HTTP/TCP probe.
+	Health *HealthCheckConfig `json:"health,omitempty"`
+	// ReadyPatterns are case-insensitive substrings that mark the service as
+	// ready when they appear in its output (e.g. "compiled successfully").
+	ReadyPatterns []string `json:"ready_patterns,omitempty"`
+
+	// CrashRestarts holds recent start times that followed an unexpected exit;
+	// RestartCount is the lifetime total of such restarts.
+	CrashRestarts []time.Time `json:"crash_restarts,omitempty"`
+	RestartCount  int         `json:"restart_count,omitempty"`
+	// LastExit describes how the most recent run ended, when it was observed.
+	LastExit *ExitStatus `json:"last_exit,omitempty"`
+
+	// AutoPort allocates a free port on every start and passes it as $PORT;
+	// RunPort is the port allocated for the current run.
+	AutoPort bool `json:"auto_port,omitempty"`
+	RunPort  int  `json:"run_port,omitempty"`
+
+	// Runs lists the most recent runs, oldest first, at most MaxRuns.
+	Runs []RunRecord `json:"runs,omitempty"`
+
+	// Processes makes this a compound service: its processes (e.g. web,
+	// worker, css watcher) run together in place of Command, Procfile-style.
+	Processes []ServiceProcess `json:"processes,omitempty"`
+
+	// Requires names jobs that must succeed before each start, e.g. a
+	// database migration.
+	Requires []string `json:"requires,omitempty"`
+
+	// StopSignal is sent to stop the service gracefully (e.g. "SIGINT");
+	// StopTimeout is how long it gets to exit before it is killed. Empty
+	// and zero mean SIGTERM and 5s.
+	StopSignal  string   `json:"stop_signal,omitempty"`
+	StopTimeout Duration `json:"stop_timeout,omitempty"`
+
+	// Limits are thresholds on the service's memory and CPU use.
+	Limits *ResourceLimits `json:"limits,omitempty"`
+
+	// Leftovers are processes that outlived the last stop: descendants that
+	// left the process group, or whatever still held the service's ports.
+	Leftovers []Leftover `json:"leftovers,omitempty"`
+
+	// Schedule restarts the service periodically ("every 15m" or a cron
+	// expression) while the TUI or `devpt watch` runs; LastScheduled is when
+	// that last happened.
+	Schedule      string     `json:"schedule,omitempty"`
+	LastScheduled *time.Time `json:"last_scheduled,omitempty"`
+
+	// Shell runs Command through the user's shell instead of executing it
+	// directly, so it may use variables, pipes and &&. Off by default.
+	Shell bool `json:"shell,omitempty"`
+
+	// PTY runs the service on a pseudo-terminal that `devpt attach` can
+	// connect to, for dev servers that read keypresses.
+	PTY bool `json:"pty,omitempty"`
+
+	// Ephemeral services are registered by `devpt run` for the duration of
+	// one foreground run and removed when it ends.
+	Ephemeral bool `json:"ephemeral,omitempty"`
+}
+
+// ResourceLimits are thresholds on the resources used by a service's
+// processes, and what to do when they are exceeded.
+type ResourceLimits struct {
+	Memory ByteSize `json:"memory,omitempty"` // resident memory, e.g. "2GB"
+	CPU    float64  `json:"cpu,omitempty"`    // percent of one core, e.g. 150
+	// For is how long a limit must be exceeded before the action is taken.
+	For    Duration `json:"for,omitempty"`
+	Action string   `json:"action,omitempty"` // LimitHighlight (default), LimitNotify or LimitRestart
+}
+
+// Actions on exceeded resource limits. Exceeded limits are always
+// highlighted; notify and restart add to that.
+const (
+	LimitHighlight = "highlight"
+	LimitNotify    = "notify"
+	LimitRestart   = "restart"
+)
+
+// Leftover is a process found still running after its service was stopped.
+type Leftover struct {
+	PID     int       `json:"pid"`
+	Port    int       `json:"port,omitempty"` // listening port, if it holds one of the service's
+	Command string    `json:"command"`
+	Started time.Time `json:"started"` // tells the process apart from a later one reusing its PID
+}
+
+// ServiceProcess is one named process of a compound service.
+type ServiceProcess struct {
+	Name    string `json:"name"`
+	Command string `json:"command"`
+}
+
+// ServiceURL is a named address of a service.
+type ServiceURL struct {
+	Name string `json:"name,omitempty"` // e.g. "docs"
+	// URL is a path such as "/docs" on the service's port, or a full URL.
+	URL string `json:"url"`
+}
+
+// ServiceURLs returns the URLs the service declares, the primary first.
+func (s *ManagedService) ServiceURLs() []ServiceURL {
+	if s.URL == "" {
+		return s.URLs
+	}
+	return append([]ServiceURL{{URL: s.URL}}, s.URLs...)
+}
+
+// Compound reports whether the service runs several processes.
+func (s *ManagedService) Compound() bool {
+	return len(s.Processes) > 0
+}
+
+// CommandSummary describes what the service runs: its command, or the
+// names of its processes for a compound service.
+func (s *ManagedService) CommandSummary() string {
+	if !s.Compound() {
+		return s.Command
+	}
+	names := make([]string, len(s.Processes))
+	for i, p := range s.Processes {
+		names[i] = p.Name
+	}
+	return strings.Join(names, " + ")
+}
+
+// MaxRuns is how many runs are kept per managed service.
+const MaxRuns = 10
+
+// Ways a run can be started
+const (
+	ViaCLI      = "cli"
+	ViaTUI      = "tui"
+	ViaAPI      = "api"      // started through devpt api serve
+	ViaMCP      = "mcp"      // started by an AI agent through devpt mcp
+	ViaIDE      = "ide"      // started from an editor through devpt ide
+	ViaWatch    = "watch"    // restarted by `devpt start --watch` after a file change
+	ViaSchedule = "schedule" // restarted by the service's schedule
+	ViaLimit    = "limit"    // restarted for exceeding a resource limit
+	ViaSnapshot = "snapshot" // started by devpt snapshot restore
+)
+
+// RunActor identifies who started a run.
+type RunActor struct {
+	Via   string `json:"via"`             // ViaCLI, ViaTUI, ViaWatch, ...
+	User  string `json:"user,omitempty"`  // OS user running devpt
+	Agent string `json:"agent,omitempty"` // AI agent devpt was invoked from, if any
+}
+
+// String renders the actor as e.g. "alice via cli" or
+// "claude (agent) as alice via tui".
+func (a RunActor) String() string {
+	who := a.User
+	if a.Agent != "" {
+		who = a.Agent + " (agent)"
+		if a.User != "" {
+			who += " as " + a.User
+		}
+	}
+	if who == "" {
+		return "via " + a.Via
+	}
+	return who + " via " + a.Via
+}
+
+// RunRecord describes one run of a managed service.
+type RunRecord struct {
+	PID       int        `json:"pid"`
+	StartedAt time.Time  `json:"started_at"`
+	Actor     RunActor   `json:"actor"`
+	Restart   bool       `json:"restart,omitempty"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	// Outcome is how the run ended, e.g. "stopped" or "exited 1"; empty
+	// while it is running or when its end was not observed.
+	Outcome string `json:"outcome,omitempty"`
+}
+
+// RecentRuns returns up to n runs, newest first.
+func (s *ManagedService) RecentRuns(n int) []RunRecord {
+	var out []RunRecord
+	for i := len(s.Runs) - 1; i >= 0 && len(out) < n; i-- {
+		out = append(out, s.Runs[i])
+	}
+	return out
+}
+
+// ActivePorts returns the declared ports plus the port allocated for the
+// current run, if any.
+func (s *ManagedService) ActivePorts() []int {
+	if s.RunPort <= 0 {
+		return s.Ports
+	}
+	for _, p := range s.Ports {
+		if p == s.RunPort {
+			return s.Ports
+		}
+	}
+	return append(append([]int{}, s.Ports...), s.RunPort)
 }
 
+// ExitStatus records how a supervised process terminated.
+type ExitStatus struct {
+	PID      int       `json:"pid"`
+	Code     int       `json:"code"`             // exit code; 128+N when killed by signal N
+	Signal   string    `json:"signal,omitempty"` // e.g. "SIGKILL" when terminated by a signal
+	ExitedAt time.Time `json:"exited_at"`
+}
+
+// Describe renders the exit status as e.g. "exited 137 (SIGKILL)".
+func (e *ExitStatus) Describe() string {
+	if e == nil {
+		return ""
+	}
+	if e.Signal != "" {
+		return fmt.Sprintf("exited %d (%s)", e.Code, e.Signal)
+	}
+	return fmt.Sprintf("exited %d", e.Code)
+}
+
+// Job is a registered task that is expected to exit, such as a migration,
+// a seed or code generation.
+type Job struct {
+	Name      string    `json:"name"`
+	CWD       string    `json:"cwd"`
+	Command   string    `json:"command"`
+	Shell     bool      `json:"shell,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Schedule runs the job periodically ("every 15m" or a cron expression)
+	// while the TUI or `devpt watch` runs; LastScheduled is when it was last
+	// started that way.
+	Schedule      string     `json:"schedule,omitempty"`
+	LastScheduled *time.Time `json:"last_scheduled,omitempty"`
+}
+
+// JobRun records one run of a job, registered or ad hoc.
+type JobRun struct {
+	Job       string      `json:"job"`
+	Command   string      `json:"command"`
+	StartedAt time.Time   `json:"started_at"`
+	Duration  Duration    `json:"duration"`
+	Exit      *ExitStatus `json:"exit"`
+	Log       string      `json:"log,omitempty"`
+	// For names the service whose start ran the job as a prerequisite;
+	// Scheduled marks runs started by the job's schedule.
+	For       string `json:"for,omitempty"`
+	Scheduled bool   `json:"scheduled,omitempty"`
+}
+
+// Succeeded reports whether the run exited 0.
+func (r JobRun) Succeeded() bool {
+	return r.Exit != nil && r.Exit.Code == 0
+}
+
+// MaxJobRuns is how many job runs are kept in the registry.
+const MaxJobRuns = 50
+
 // Registry holds all managed services
 type Registry struct {
 	Services map[string]*ManagedService `json:"services"`
+	Jobs     map[string]*Job            `json:"jobs,omitempty"`
+	JobRuns  []JobRun                   `json:"job_runs,omitempty"`
+	Projects map[string]*Project        `json:"projects,omitempty"`
 	Version  string                     `json:"version"`
 }
 
+// Project groups the services of one repository so that two repositories
+// can both have, say, an "api" service. Commands run inside Root, or with
+// --project Name, find the project's services by their short names.
+type Project struct {
+	Name string `json:"name"`
+	Root string `json:"root"`
+}
+
+// ProjectSeparator separates the project from the service in a qualified
+// service name.
+const ProjectSeparator = ":"
+
+// QualifiedName returns the registry name of service name in project.
+func QualifiedName(project, name string) string {
+	if project == "" {
+		return name
+	}
+	return project + ProjectSeparator + name
+}
+
 // ServerInfo combines discovered and managed server data
 type ServerInfo struct {
 	ProcessRecord  *ProcessRecord
 	ManagedService *ManagedService
 	Source         Source
-	Status         string // "running", "stopped", etc.
+	Status         string // "running", "starting", "ready", "paused", "stopped", "crashed", "crash-looping"
+	ReadyLine      string // output line that matched a ready pattern, if any
 	CrashReason    string
 	CrashLogTail   []string
+	// Host is the configured remote host the server runs on; empty for
+	// this machine.
+	Host string
+}
+
+// Health check protocols
+const (
+	HealthProtocolHTTP      = "http"
+	HealthProtocolGRPC      = "grpc"
+	HealthProtocolWebSocket = "websocket"
+	HealthProtocolRedis     = "redis"
+	HealthProtocolPostgres  = "postgres"
+	HealthProtocolMySQL     = "mysql"
+)
+
+// HealthCheckConfig customizes how a managed service is probed.
+// A nil config means the default probe: GET / over plain HTTP, then TCP.
+type HealthCheckConfig struct {
+	Protocol       string   `json:"protocol,omitempty"`        // "http" (default), "grpc", "websocket", "redis", "postgres" or "mysql"
+	GRPCService    string   `json:"grpc_service,omitempty"`    // service name sent in grpc.health.v1 requests
+	Path           string   `json:"path,omitempty"`            // HTTP path, e.g. "/healthz"
+	Command        string   `json:"command,omitempty"`         // exec check run in the service CWD; exit 0 means healthy
+	ExpectedStatus []int    `json:"expected_status,omitempty"` // accepted status codes; empty accepts any response
+	ExpectBody     string   `json:"expect_body,omitempty"`     // substring the response body must contain
+	TLS            bool     `json:"tls,omitempty"`             // probe over https (certificate is not verified)
+	Timeout        Duration `json:"timeout,omitempty"`
+	Interval       Duration `json:"interval,omitempty"`
+	ProbePath      string   `json:"probe_path,omitempty"`     // HTTP path hit often to measure availability and error rate
+	ProbeInterval  Duration `json:"probe_interval,omitempty"` // how often ProbePath is hit; 5s when unset
+}
+
+// Duration is a time.Duration that reads and writes human-friendly strings
+// such as "5s" or "250ms" in JSON. Plain numbers are accepted as nanoseconds.
+type Duration time.Duration
+
+// ByteSize is a size in bytes that reads and writes as e.g. "512MB" in JSON.
+type ByteSize int64
+
+// byteUnits are the accepted suffixes, largest first; K, M and G are
+// powers of 1024 as in ps and top.
+var byteUnits = []struct {
+	suffix string
+	size   ByteSize
+}{
+	{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+	{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1},
+}
+
+// ParseByteSize parses sizes such as "2GB", "512M", "1.5G" or "1048576".
+func ParseByteSize(s string) (ByteSize, error) {
+	raw := strings.ToUpper(strings.TrimSpace(s))
+	mult := ByteSize(1)
+	for _, u := range byteUnits {
+		if strings.HasSuffix(raw, u.suffix) {
+			raw = strings.TrimSpace(strings.TrimSuffix(raw, u.suffix))
+			mult = u.size
+			break
+		}
+	}
+	var n float64
+	if _, err := fmt.Sscanf(raw, "%g", &n); err != nil || n < 0 || raw == "" {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return ByteSize(n * float64(mult)), nil
+}
+
+// String renders the size with one decimal in the largest fitting unit,
+// e.g. "1.5GB" or "340MB".
+func (b ByteSize) String() string {
+	for _, u := range byteUnits[:4] {
+		if b >= u.size {
+			v := float64(b) / float64(u.size)
+			if v >= 10 || v == float64(int64(v)) {
+				return fmt.Sprintf("%d%s", int64(v+0.5), u.suffix)
+			}
+			return fmt.Sprintf("%.1f%s", v, u.suffix)
+		}
+	}
+	return fmt.Sprintf("%dB", int64(b))
+}
+
+// MarshalJSON implements json.Marshaler.
+func (b ByteSize) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *ByteSize) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	switch v := raw.(type) {
+	case float64:
+		*b = ByteSize(v)
+	case string:
+		parsed, err := ParseByteSize(v)
+		if err != nil {
+			return err
+		}
+		*b = parsed
+	case nil:
+		*b = 0
+	default:
+		return fmt.Errorf("invalid size: %s", string(data))
+	}
+	return nil
+}
+
+// Std returns the value as a time.Duration.
+func (d Duration) Std() time.Duration { return time.Duration(d) }
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	switch v := raw.(type) {
+	case float64:
+		*d = Duration(time.Duration(v))
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %w", v, err)
+		}
+		*d = Duration(parsed)
+	case nil:
+		*d = 0
+	default:
+		return fmt.Errorf("invalid duration: %s", string(b))
+	}
+	return nil
 }
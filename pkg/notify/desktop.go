@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// Desktop raises native macOS notifications, preferring terminal-notifier
+// when it is installed and falling back to osascript.
+type Desktop struct {
+	cfg models.DesktopNotifications
+	run func(name string, args ...string) error
+	wg  sync.WaitGroup
+}
+
+// NewDesktop returns nil when alerts are disabled or the platform has no
+// supported notifier.
+func NewDesktop(cfg models.DesktopNotifications) *Desktop {
+	if !cfg.Enabled || runtime.GOOS != "darwin" {
+		return nil
+	}
+	return &Desktop{
+		cfg: cfg,
+		run: func(name string, args ...string) error {
+			return exec.Command(name, args...).Run()
+		},
+	}
+}
+
+// Enabled reports whether alerts can be shown.
+func (d *Desktop) Enabled() bool {
+	return d != nil
+}
+
+// Notify shows n unless its event is filtered out or its service is muted.
+func (d *Desktop) Notify(n Notification) {
+	if d == nil || !d.cfg.Wants(n.Event, n.Service) {
+		return
+	}
+	name, args := desktopCommand(n, lookPath)
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		_ = d.run(name, args...)
+	}()
+}
+
+// Wait blocks until pending alerts are shown or timeout elapses.
+func (d *Desktop) Wait(timeout time.Duration) {
+	if d == nil {
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
+
+var lookPath = func(file string) bool {
+	_, err := exec.LookPath(file)
+	return err == nil
+}
+
+// desktopCommand builds the notifier invocation for n.
+func desktopCommand(n Notification, has func(string) bool) (string, []string) {
+	title := "devpt"
+	subtitle := n.Service
+	if subtitle == "" && n.Port > 0 {
+		subtitle = fmt.Sprintf("port %d", n.Port)
+	}
+	message := n.Event
+	if n.Reason != "" {
+		message += ": " + n.Reason
+	}
+	if has("terminal-notifier") {
+		return "terminal-notifier", []string{
+			"-title", title,
+			"-subtitle", subtitle,
+			"-message", message,
+			"-group", "devpt-" + subtitle,
+		}
+	}
+	script := fmt.Sprintf("display notification %s with title %s subtitle %s",
+		appleScriptString(message), appleScriptString(title), appleScriptString(subtitle))
+	return "osascript", []string{"-e", script}
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,26 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestDesktopCommandQuotesAppleScript(t *testing.T) {
+	t.Parallel()
+
+	n := Notification{Event: models.WebhookOnCrashed, Service: "api", Reason: `exited 1 "oops"`}
+	name, args := desktopCommand(n, func(string) bool { return false })
+	if name != "osascript" || len(args) != 2 {
+		t.Fatalf("unexpected command: %s %v", name, args)
+	}
+	want := `display notification "crashed: exited 1 \"oops\"" with title "devpt" subtitle "api"`
+	if args[1] != want {
+		t.Fatalf("script = %s, want %s", args[1], want)
+	}
+
+	name, _ = desktopCommand(n, func(string) bool { return true })
+	if name != "terminal-notifier" {
+		t.Fatalf("expected terminal-notifier when installed, got %s", name)
+	}
+}
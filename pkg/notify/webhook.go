@@ -0,0 +1,197 @@
+// Package notify delivers service failure notifications to webhooks.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// defaultTimeout bounds a single webhook request when none is configured.
+const defaultTimeout = 5 * time.Second
+
+// maxLogTail is how many log lines default payloads include.
+const maxLogTail = 10
+
+// defaultTemplate renders the chat message for slack and discord hooks.
+const defaultTemplate = `devpt: {{.Service}} {{.Event}}{{if .Port}} (port {{.Port}}){{end}}{{if .Reason}}: {{.Reason}}{{end}}` +
+	"{{if .LogTail}}\n```\n{{join .LogTail \"\\n\"}}\n```{{end}}"
+
+// Notification describes a failure worth telling someone about.
+type Notification struct {
+	Event   string    `json:"event"` // models.WebhookOnCrashed, WebhookOnCrashLooping, WebhookOnHealthDown or WebhookOnOverLimit
+	Service string    `json:"service"`
+	Port    int       `json:"port,omitempty"`
+	Status  string    `json:"status"`
+	Reason  string    `json:"reason,omitempty"`
+	LogTail []string  `json:"log_tail,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// hook is a configured webhook with its parsed template.
+type hook struct {
+	cfg  models.WebhookConfig
+	tmpl *template.Template
+}
+
+// Dispatcher sends notifications to every subscribed webhook in the background.
+type Dispatcher struct {
+	hooks   []hook
+	client  *http.Client
+	onError func(error)
+	wg      sync.WaitGroup
+}
+
+// NewDispatcher prepares the configured webhooks. Hooks with an invalid
+// template are skipped and reported in the returned error.
+func NewDispatcher(cfgs []models.WebhookConfig, onError func(error)) (*Dispatcher, error) {
+	d := &Dispatcher{
+		client:  &http.Client{},
+		onError: onError,
+	}
+	var errs []string
+	for i, cfg := range cfgs {
+		text := cfg.Template
+		if text == "" && cfg.Kind != "" && cfg.Kind != models.WebhookGeneric {
+			text = defaultTemplate
+		}
+		var tmpl *template.Template
+		if text != "" {
+			var err error
+			tmpl, err = template.New(fmt.Sprintf("webhook%d", i)).Funcs(template.FuncMap{"join": strings.Join}).Parse(text)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("webhooks[%d]: invalid template: %v", i, err))
+				continue
+			}
+		}
+		d.hooks = append(d.hooks, hook{cfg: cfg, tmpl: tmpl})
+	}
+	if len(errs) > 0 {
+		return d, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return d, nil
+}
+
+// Enabled reports whether any webhook is configured.
+func (d *Dispatcher) Enabled() bool {
+	return d != nil && len(d.hooks) > 0
+}
+
+// Notify delivers n to every webhook subscribed to n.Event without blocking.
+func (d *Dispatcher) Notify(n Notification) {
+	if !d.Enabled() {
+		return
+	}
+	if n.Time.IsZero() {
+		n.Time = time.Now()
+	}
+	if len(n.LogTail) > maxLogTail {
+		n.LogTail = n.LogTail[len(n.LogTail)-maxLogTail:]
+	}
+	for _, h := range d.hooks {
+		if !h.cfg.Wants(n.Event) {
+			continue
+		}
+		d.wg.Add(1)
+		go func(h hook) {
+			defer d.wg.Done()
+			if err := d.send(h, n); err != nil && d.onError != nil {
+				d.onError(err)
+			}
+		}(h)
+	}
+}
+
+// Wait blocks until pending deliveries finish or timeout elapses.
+func (d *Dispatcher) Wait(timeout time.Duration) {
+	if d == nil {
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
+
+func (d *Dispatcher) send(h hook, n Notification) error {
+	body, err := payload(h, n)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, h.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook %s: %w", h.cfg.URL, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "devpt")
+	for k, v := range h.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	timeout := defaultTimeout
+	if h.cfg.Timeout > 0 {
+		timeout = h.cfg.Timeout.Std()
+	}
+	client := *d.client
+	client.Timeout = timeout
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook %s: %w", h.cfg.URL, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s returned %d", h.cfg.URL, resp.StatusCode)
+	}
+	return nil
+}
+
+// payload builds the request body for a hook. Generic hooks post the
+// notification as JSON unless a template replaces the whole body; chat hooks
+// wrap the rendered message in the field their API expects.
+func payload(h hook, n Notification) ([]byte, error) {
+	if h.tmpl == nil {
+		return json.Marshal(n)
+	}
+	var buf bytes.Buffer
+	if err := h.tmpl.Execute(&buf, n); err != nil {
+		return nil, fmt.Errorf("webhook %s: template: %w", h.cfg.URL, err)
+	}
+	switch h.cfg.Kind {
+	case models.WebhookSlack:
+		return json.Marshal(map[string]string{"text": buf.String()})
+	case models.WebhookDiscord:
+		return json.Marshal(map[string]string{"content": truncate(buf.String(), 2000)})
+	default:
+		return buf.Bytes(), nil
+	}
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	cut := n - len("…")
+	for cut > 0 && !utf8RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "…"
+}
+
+func utf8RuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestDispatcherPayloads(t *testing.T) {
+	t.Parallel()
+
+	bodies := make(chan string, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		bodies <- r.URL.Path + " " + string(data)
+	}))
+	defer srv.Close()
+
+	d, err := NewDispatcher([]models.WebhookConfig{
+		{URL: srv.URL + "/slack", Kind: models.WebhookSlack},
+		{URL: srv.URL + "/generic", Events: []string{models.WebhookOnCrashed}},
+		{URL: srv.URL + "/health-only", Events: []string{models.WebhookOnHealthDown}},
+	}, func(err error) { t.Errorf("delivery failed: %v", err) })
+	if err != nil {
+		t.Fatalf("NewDispatcher: %v", err)
+	}
+
+	d.Notify(Notification{
+		Event:   models.WebhookOnCrashed,
+		Service: "api",
+		Port:    8080,
+		Status:  "crashed",
+		Reason:  "exited 1",
+		LogTail: []string{"panic: boom"},
+	})
+	d.Wait(5 * time.Second)
+	close(bodies)
+
+	got := map[string]string{}
+	for b := range bodies {
+		path, body, _ := strings.Cut(b, " ")
+		got[path] = body
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 deliveries, got %v", got)
+	}
+
+	var slack map[string]string
+	if err := json.Unmarshal([]byte(got["/slack"]), &slack); err != nil {
+		t.Fatalf("slack payload: %v", err)
+	}
+	if !strings.Contains(slack["text"], "api crashed (port 8080): exited 1") || !strings.Contains(slack["text"], "panic: boom") {
+		t.Fatalf("unexpected slack text: %q", slack["text"])
+	}
+
+	var generic Notification
+	if err := json.Unmarshal([]byte(got["/generic"]), &generic); err != nil {
+		t.Fatalf("generic payload: %v", err)
+	}
+	if generic.Service != "api" || generic.Reason != "exited 1" || len(generic.LogTail) != 1 {
+		t.Fatalf("unexpected generic payload: %+v", generic)
+	}
+}
+
+func TestNewDispatcherRejectsBadTemplate(t *testing.T) {
+	t.Parallel()
+
+	d, err := NewDispatcher([]models.WebhookConfig{
+		{URL: "http://example.invalid", Template: "{{.Service"},
+		{URL: "http://example.invalid", Kind: models.WebhookDiscord},
+	}, nil)
+	if err == nil {
+		t.Fatal("expected template error")
+	}
+	if len(d.hooks) != 1 {
+		t.Fatalf("expected the valid hook to be kept, got %d", len(d.hooks))
+	}
+}
@@ -0,0 +1,48 @@
+package process
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bootTimeRe extracts the seconds from `sysctl -n kern.boottime`, which
+// prints e.g. "{ sec = 1767254400, usec = 0 } Thu Jan  1 08:00:00 2026".
+var bootTimeRe = regexp.MustCompile(`sec = (\d+)`)
+
+// BootTime returns when the system last booted. No process started before
+// then can still be running.
+func BootTime() (time.Time, error) {
+	if f, err := os.Open("/proc/stat"); err == nil {
+		defer f.Close()
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			if rest, ok := strings.CutPrefix(sc.Text(), "btime "); ok {
+				sec, err := strconv.ParseInt(strings.TrimSpace(rest), 10, 64)
+				if err != nil {
+					return time.Time{}, err
+				}
+				return time.Unix(sec, 0), nil
+			}
+		}
+		return time.Time{}, errors.New("btime not found in /proc/stat")
+	}
+	out, err := exec.Command("sysctl", "-n", "kern.boottime").Output()
+	if err != nil {
+		return time.Time{}, err
+	}
+	m := bootTimeRe.FindSubmatch(out)
+	if m == nil {
+		return time.Time{}, errors.New("unexpected kern.boottime output")
+	}
+	sec, err := strconv.ParseInt(string(m[1]), 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(sec, 0), nil
+}
@@ -0,0 +1,222 @@
+package process
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// GroupCommand is the hidden subcommand that runs the processes of a
+// compound service as one unit.
+const GroupCommand = "__group"
+
+// groupStopTimeout is how long the other processes of a group get to exit
+// after one of them ended, before they are killed.
+const groupStopTimeout = 5 * time.Second
+
+// groupArgs builds the arguments of the group subcommand for service.
+func groupArgs(service *models.ManagedService, procsFile string) []string {
+	args := []string{GroupCommand, procsFile}
+	if service.Shell {
+		args = append(args, "--shell")
+	}
+	args = append(args, "--")
+	for _, p := range service.Processes {
+		args = append(args, p.Name, p.Command)
+	}
+	return args
+}
+
+// RunGroup implements the group subcommand:
+//
+//	__group <procs-file> [--shell] -- <name> <command> [<name> <command>...]
+//
+// It starts every command in the current process group, writes their output
+// to stdout with each line prefixed by the process name, and records the
+// PIDs in procs-file. When any process exits the others are stopped, like
+// foreman; the group exits with the code of the process that ended first.
+func RunGroup(args []string) int {
+	if len(args) < 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s <procs-file> [--shell] -- <name> <command>...\n", GroupCommand)
+		return 2
+	}
+	procsFile, args := args[0], args[1:]
+	shell := false
+	if len(args) > 0 && args[0] == "--shell" {
+		shell, args = true, args[1:]
+	}
+	if len(args) < 3 || args[0] != "--" || len(args[1:])%2 != 0 {
+		fmt.Fprintf(os.Stderr, "usage: %s <procs-file> [--shell] -- <name> <command>...\n", GroupCommand)
+		return 2
+	}
+	var procs []models.ServiceProcess
+	for i := 1; i < len(args); i += 2 {
+		procs = append(procs, models.ServiceProcess{Name: args[i], Command: args[i+1]})
+	}
+	return runGroup(procs, shell, procsFile, os.Stdout)
+}
+
+type groupMember struct {
+	name string
+	cmd  *exec.Cmd
+	done chan struct{}
+}
+
+func runGroup(procs []models.ServiceProcess, shell bool, procsFile string, out io.Writer) int {
+	// Stop signals reach the whole process group; the members handle them
+	// and the group ends once they are gone.
+	signal.Notify(make(chan os.Signal, 1), syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP, syscall.SIGQUIT)
+
+	width := len("devpt")
+	for _, p := range procs {
+		width = max(width, len(p.Name))
+	}
+	var outMu sync.Mutex
+	logf := func(format string, args ...any) {
+		outMu.Lock()
+		defer outMu.Unlock()
+		fmt.Fprintf(out, "%-*s | %s\n", width, "devpt", fmt.Sprintf(format, args...))
+	}
+
+	var members []*groupMember
+	var copies sync.WaitGroup
+	pids := make(map[string]int, len(procs))
+	exited := make(chan *groupMember, len(procs))
+	for _, p := range procs {
+		argv, err := commandArgv(&models.ManagedService{Command: p.Command, Shell: shell})
+		if err != nil {
+			logf("%s: %v", p.Name, err)
+			stopMembers(members)
+			return 1
+		}
+		cmd := exec.Command(argv[0], argv[1:]...)
+		pr, pw, err := os.Pipe()
+		if err != nil {
+			logf("%s: %v", p.Name, err)
+			stopMembers(members)
+			return 1
+		}
+		cmd.Stdout, cmd.Stderr = pw, pw
+		if err := cmd.Start(); err != nil {
+			pw.Close()
+			pr.Close()
+			logf("%s: failed to start: %v", p.Name, err)
+			stopMembers(members)
+			return 1
+		}
+		pw.Close()
+		member := &groupMember{name: p.Name, cmd: cmd, done: make(chan struct{})}
+		members = append(members, member)
+		pids[p.Name] = cmd.Process.Pid
+		prefix := fmt.Sprintf("%-*s | ", width, p.Name)
+		copies.Add(1)
+		go func() {
+			defer copies.Done()
+			defer pr.Close()
+			sc := bufio.NewScanner(pr)
+			sc.Buffer(make([]byte, 0, 64<<10), 1<<20)
+			for sc.Scan() {
+				outMu.Lock()
+				fmt.Fprintf(out, "%s%s\n", prefix, sc.Text())
+				outMu.Unlock()
+			}
+		}()
+		go func() {
+			_ = cmd.Wait()
+			close(member.done)
+			exited <- member
+		}()
+		logf("started %s (PID %d): %s", p.Name, cmd.Process.Pid, p.Command)
+	}
+	if err := writeGroupPIDs(procsFile, pids); err != nil {
+		logf("failed to record process PIDs: %v", err)
+	}
+
+	first := <-exited
+	status := exitStatusOf(first.cmd.Process.Pid, first.cmd.ProcessState)
+	if len(members) > 1 {
+		logf("%s %s; stopping the other processes", first.name, status.Describe())
+	}
+	stopMembers(members)
+	copies.Wait()
+	return status.Code
+}
+
+// stopMembers sends SIGTERM to the members still running and kills those
+// that outlast groupStopTimeout.
+func stopMembers(members []*groupMember) {
+	for _, m := range members {
+		select {
+		case <-m.done:
+		default:
+			_ = m.cmd.Process.Signal(syscall.SIGTERM)
+		}
+	}
+	deadline := time.After(groupStopTimeout)
+	for _, m := range members {
+		select {
+		case <-m.done:
+		case <-deadline:
+			for _, m := range members {
+				_ = m.cmd.Process.Kill()
+			}
+			for _, m := range members {
+				<-m.done
+			}
+			return
+		}
+	}
+}
+
+func writeGroupPIDs(path string, pids map[string]int) error {
+	data, err := json.MarshalIndent(pids, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// procsFileFor returns the path of the process PIDs recorded for a
+// compound service's run.
+func procsFileFor(logPath string) string {
+	return strings.TrimSuffix(logPath, ".log") + ".procs.json"
+}
+
+// GroupPIDs returns the PIDs of the processes of a compound service's latest
+// run, by process name.
+func (m *Manager) GroupPIDs(serviceName string) (map[string]int, error) {
+	logPath, err := m.LatestLogPath(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	return readGroupPIDs(procsFileFor(logPath))
+}
+
+func readGroupPIDs(path string) (map[string]int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var pids map[string]int
+	if err := json.Unmarshal(data, &pids); err != nil {
+		return nil, fmt.Errorf("failed to parse process PIDs: %w", err)
+	}
+	return pids, nil
+}
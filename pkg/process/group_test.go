@@ -0,0 +1,59 @@
+package process
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestRunGroupPrefixesOutputAndStopsTogether(t *testing.T) {
+	t.Parallel()
+
+	procsFile := filepath.Join(t.TempDir(), "run.procs.json")
+	procs := []models.ServiceProcess{
+		{Name: "web", Command: "sh -c 'echo serving; exit 3'"},
+		{Name: "worker", Command: "sleep 30"},
+	}
+	var out lockedBuffer
+	start := time.Now()
+	code := runGroup(procs, false, procsFile, &out)
+	if code != 3 {
+		t.Fatalf("expected the first exit code 3, got %d\n%s", code, out.String())
+	}
+	if elapsed := time.Since(start); elapsed > groupStopTimeout {
+		t.Fatalf("worker was not stopped promptly (took %s)", elapsed)
+	}
+	if !strings.Contains(out.String(), "web    | serving\n") {
+		t.Fatalf("expected prefixed output, got:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "stopping the other processes") {
+		t.Fatalf("expected the group to report stopping, got:\n%s", out.String())
+	}
+
+	pids, err := readGroupPIDs(procsFile)
+	if err != nil || pids["web"] == 0 || pids["worker"] == 0 {
+		t.Fatalf("expected PIDs for both processes, got %v (%v)", pids, err)
+	}
+}
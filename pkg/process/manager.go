@@ -2,14 +2,18 @@ package process
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -18,7 +22,19 @@ import (
 
 // Manager handles starting and stopping of managed services
 type Manager struct {
-	logsDir string
+	logsDir    string
+	supervisor string // executable that handles SupervisorCommand; empty starts directly
+
+	mu    sync.Mutex
+	ready map[string]*readyScan
+}
+
+// readyScan remembers how far a service's current log has been searched.
+type readyScan struct {
+	path    string
+	offset  int64
+	partial string
+	line    string
 }
 
 var ErrNoLogs = errors.New("no logs available")
@@ -29,11 +45,33 @@ var ErrNoProcessLogs = errors.New("no process logs available")
 func NewManager(logsDir string) *Manager {
 	return &Manager{
 		logsDir: logsDir,
+		ready:   make(map[string]*readyScan),
 	}
 }
 
 // Start starts a managed service
 func (m *Manager) Start(service *models.ManagedService) (int, error) {
+	return m.StartWithEnvContext(context.Background(), service, nil)
+}
+
+// StartContext is Start, giving up before the service is launched once ctx
+// is done. ctx only bounds starting: the service keeps running after it.
+func (m *Manager) StartContext(ctx context.Context, service *models.ManagedService) (int, error) {
+	return m.StartWithEnvContext(ctx, service, nil)
+}
+
+// StartWithEnv starts a managed service with extra "KEY=value" environment
+// entries added to the inherited environment, after the service's own.
+func (m *Manager) StartWithEnv(service *models.ManagedService, env []string) (int, error) {
+	return m.StartWithEnvContext(context.Background(), service, env)
+}
+
+// StartWithEnvContext is StartWithEnv, giving up before the service is
+// launched once ctx is done.
+func (m *Manager) StartWithEnvContext(ctx context.Context, service *models.ManagedService, env []string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
 	// Validate working directory and bind process execution to it.
 	if fi, err := os.Stat(service.CWD); err != nil || !fi.IsDir() {
 		if err != nil {
@@ -43,22 +81,37 @@ func (m *Manager) Start(service *models.ManagedService) (int, error) {
 	}
 
 	// Create log file
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
 	logFile, err := m.createLogFile(service.Name)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create log file: %w", err)
 	}
 	defer logFile.Close()
 
-	// Execute commands directly (no implicit shell) for safer defaults.
-	argv, err := parseCommandArgs(service.Command)
-	if err != nil {
-		return 0, fmt.Errorf("invalid command: %w", err)
+	var argv []string
+	if service.Compound() {
+		if m.supervisor == "" {
+			return 0, fmt.Errorf("compound services need the devpt supervisor")
+		}
+		argv = append([]string{m.supervisor}, groupArgs(service, procsFileFor(logFile.Name()))...)
+	} else if argv, err = commandArgv(service); err != nil {
+		return 0, err
 	}
-	if len(argv) == 0 {
-		return 0, fmt.Errorf("invalid command: empty")
+	env = append(append([]string(nil), service.Env...), env...)
+	if len(env) > 0 {
+		env = append(os.Environ(), env...)
+	}
+	if m.supervisor != "" {
+		return m.startSupervised(argv, service.CWD, env, logFile, service.PTY)
+	}
+	if service.PTY {
+		return 0, fmt.Errorf("pty mode needs the devpt supervisor")
 	}
 	cmd := exec.Command(argv[0], argv[1:]...)
 	cmd.Dir = service.CWD
+	cmd.Env = env
 
 	// Set up process group to manage all child processes
 	cmd.SysProcAttr = &syscall.SysProcAttr{
@@ -77,8 +130,118 @@ func (m *Manager) Start(service *models.ManagedService) (int, error) {
 	return cmd.Process.Pid, nil
 }
 
+// StartForeground starts argv in dir attached to the caller's terminal, for
+// `devpt run`. The process shares the terminal's process group so that
+// Ctrl+C reaches it directly. wait blocks until it exits and reports how.
+func (m *Manager) StartForeground(argv []string, dir string, env []string) (pid int, wait func() *models.ExitStatus, err error) {
+	if len(argv) == 0 {
+		return 0, nil, fmt.Errorf("invalid command: empty")
+	}
+	if len(env) > 0 {
+		env = append(os.Environ(), env...)
+	}
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return 0, nil, fmt.Errorf("failed to start process: %w", err)
+	}
+	wait = func() *models.ExitStatus {
+		_ = cmd.Wait()
+		return exitStatusOf(cmd.Process.Pid, cmd.ProcessState)
+	}
+	return cmd.Process.Pid, wait, nil
+}
+
+// RunLogged runs argv in dir until it exits, for jobs. Its output goes to a
+// new log file under name and, when out is not nil, to out as well. The
+// process stays in the caller's process group so that Ctrl+C reaches it.
+func (m *Manager) RunLogged(name string, argv []string, dir string, out io.Writer) (logPath string, exit *models.ExitStatus, err error) {
+	if len(argv) == 0 {
+		return "", nil, fmt.Errorf("invalid command: empty")
+	}
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid working directory: %w", err)
+		}
+		return "", nil, fmt.Errorf("invalid working directory: not a directory")
+	}
+	logFile, err := m.createLogFile(name)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create log file: %w", err)
+	}
+	defer logFile.Close()
+
+	var w io.Writer = logFile
+	if out != nil {
+		w = io.MultiWriter(logFile, out)
+	}
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = dir
+	cmd.Stdout = w
+	cmd.Stderr = w
+	if err := cmd.Start(); err != nil {
+		return logFile.Name(), nil, fmt.Errorf("failed to start process: %w", err)
+	}
+	_ = cmd.Wait()
+	return logFile.Name(), exitStatusOf(cmd.Process.Pid, cmd.ProcessState), nil
+}
+
+// DefaultStopTimeout is how long a stop waits for a service to exit after
+// the stop signal before killing it.
+const DefaultStopTimeout = 5 * time.Second
+
 // Stop gracefully stops a process with timeout, then force-kills if needed
 func (m *Manager) Stop(pid int, timeout time.Duration) error {
+	return m.StopWith(pid, syscall.SIGTERM, timeout)
+}
+
+// StopService stops pid, the process of service, with the service's stop
+// signal and timeout: SIGTERM and DefaultStopTimeout unless configured.
+func (m *Manager) StopService(service *models.ManagedService, pid int) error {
+	return m.StopServiceContext(context.Background(), service, pid)
+}
+
+// StopServiceContext is StopService, giving up once ctx is done.
+func (m *Manager) StopServiceContext(ctx context.Context, service *models.ManagedService, pid int) error {
+	sig, timeout, err := StopParams(service)
+	if err != nil {
+		return err
+	}
+	return m.StopWithContext(ctx, pid, sig, timeout)
+}
+
+// StopParams returns the signal and timeout that stop service.
+func StopParams(service *models.ManagedService) (syscall.Signal, time.Duration, error) {
+	sig, timeout := syscall.SIGTERM, DefaultStopTimeout
+	if service.StopSignal != "" {
+		var err error
+		if sig, err = ParseSignal(service.StopSignal); err != nil {
+			return 0, 0, fmt.Errorf("invalid stop signal for %q: %w", service.Name, err)
+		}
+	}
+	if service.StopTimeout > 0 {
+		timeout = service.StopTimeout.Std()
+	}
+	return sig, timeout, nil
+}
+
+// StopWith sends sig to the process group led by pid (or to pid alone),
+// waits up to timeout for it to exit, then force-kills it.
+func (m *Manager) StopWith(pid int, sig syscall.Signal, timeout time.Duration) error {
+	return m.StopWithContext(context.Background(), pid, sig, timeout)
+}
+
+// StopWithContext is StopWith, giving up once ctx is done. A stop cut short
+// after the signal was sent returns ctx's error without force-killing: the
+// process may still be shutting down gracefully.
+func (m *Manager) StopWithContext(ctx context.Context, pid int, sig syscall.Signal, timeout time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	if pid <= 0 {
 		return fmt.Errorf("invalid pid: %d", pid)
 	}
@@ -88,18 +251,22 @@ func (m *Manager) Stop(pid int, timeout time.Duration) error {
 
 	// First attempt graceful termination. For non-child processes we cannot use Wait(),
 	// so we send signals and poll for liveness.
-	if err := syscall.Kill(-pid, syscall.SIGTERM); err != nil {
-		if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
-			return fmt.Errorf("failed to send SIGTERM: %w", err)
-		}
+	if err := m.Signal(pid, sig); err != nil {
+		return err
 	}
+	// A paused process only handles the signal once it runs again.
+	_ = m.Signal(pid, syscall.SIGCONT)
 
 	deadline := time.Now().Add(timeout)
 	for time.Now().Before(deadline) {
 		if !m.isAlive(pid) {
 			return nil
 		}
-		time.Sleep(120 * time.Millisecond)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(120 * time.Millisecond):
+		}
 	}
 
 	// Escalate to hard kill.
@@ -113,6 +280,50 @@ func (m *Manager) Stop(pid int, timeout time.Duration) error {
 	return nil
 }
 
+// Kill sends SIGKILL to the process group led by pid (or to pid alone) and
+// waits briefly for it to disappear.
+func (m *Manager) Kill(pid int) error {
+	if pid <= 0 {
+		return fmt.Errorf("invalid pid: %d", pid)
+	}
+	if !m.isAlive(pid) {
+		return nil
+	}
+	if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil {
+		if err := syscall.Kill(pid, syscall.SIGKILL); err != nil {
+			return fmt.Errorf("failed to send SIGKILL: %w", err)
+		}
+	}
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if !m.isAlive(pid) {
+			return nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return ErrNeedSudo
+}
+
+// Signal sends sig to the process group led by pid, or to pid alone when it
+// does not lead a group.
+func (m *Manager) Signal(pid int, sig syscall.Signal) error {
+	if pid <= 0 {
+		return fmt.Errorf("invalid pid: %d", pid)
+	}
+	if err := syscall.Kill(-pid, sig); err != nil {
+		if err := syscall.Kill(pid, sig); err != nil {
+			return fmt.Errorf("failed to send %s: %w", SignalName(sig), err)
+		}
+	}
+	return nil
+}
+
+// IsPaused reports whether pid is stopped by a signal such as SIGSTOP.
+func (m *Manager) IsPaused(pid int) bool {
+	st, err := m.processState(pid)
+	return err == nil && strings.HasPrefix(st, "T")
+}
+
 func (m *Manager) isAlive(pid int) bool {
 	err := syscall.Kill(pid, syscall.Signal(0))
 	if err != nil {
@@ -131,13 +342,30 @@ func (m *Manager) isAlive(pid int) bool {
 func (m *Manager) Restart(service *models.ManagedService) (int, error) {
 	// Stop existing process if running
 	if service.LastPID != nil && *service.LastPID > 0 {
-		m.Stop(*service.LastPID, 5*time.Second)
+		m.StopService(service, *service.LastPID)
 	}
 
 	// Start new process
 	return m.Start(service)
 }
 
+// CrashLoopBackoff returns the delay to wait before the next automatic restart
+// after the given number of consecutive crash restarts: 1s, 2s, 4s, ... capped at 5m.
+func CrashLoopBackoff(restarts int) time.Duration {
+	if restarts <= 0 {
+		return 0
+	}
+	const maxBackoff = 5 * time.Minute
+	d := time.Second
+	for i := 1; i < restarts; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
+
 // IsRunning checks if a process is still running
 func (m *Manager) IsRunning(pid int) bool {
 	if pid <= 0 {
@@ -146,6 +374,18 @@ func (m *Manager) IsRunning(pid int) bool {
 	return m.isAlive(pid)
 }
 
+// lstartLayout is the fixed-width format ps uses for the lstart column.
+const lstartLayout = "Mon Jan _2 15:04:05 2006"
+
+// StartTime returns when the process pid started, to second precision.
+func (m *Manager) StartTime(pid int) (time.Time, error) {
+	out, err := exec.Command("ps", "-p", strconv.Itoa(pid), "-o", "lstart=").Output()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.ParseInLocation(lstartLayout, strings.Join(strings.Fields(string(out)), " "), time.Local)
+}
+
 // createLogFile creates a new log file for a service
 func (m *Manager) createLogFile(serviceName string) (*os.File, error) {
 	// Create service log directory
@@ -154,11 +394,18 @@ func (m *Manager) createLogFile(serviceName string) (*os.File, error) {
 		return nil, err
 	}
 
-	// Create timestamped log file
+	// Create timestamped log file. A restart within the same second gets
+	// its own file, named to sort after the previous one, rather than
+	// truncating the log of the run it replaces.
 	timestamp := time.Now().Format("2006-01-02T15-04-05")
-	logPath := filepath.Join(serviceLogDir, timestamp+".log")
-
-	return os.Create(logPath)
+	name := timestamp
+	for n := 2; ; n++ {
+		f, err := os.OpenFile(filepath.Join(serviceLogDir, name+".log"), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if !errors.Is(err, os.ErrExist) {
+			return f, err
+		}
+		name = fmt.Sprintf("%s_%d", timestamp, n)
+	}
 }
 
 // GetLogs retrieves recent logs for a service
@@ -176,57 +423,359 @@ func (m *Manager) LatestLogPath(serviceName string) (string, error) {
 		}
 		return "", fmt.Errorf("failed to read log directory: %w", err)
 	}
-	if len(entries) == 0 {
+	// Exit-status files share the directory; only consider logs.
+	logs := entries[:0]
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".log") {
+			logs = append(logs, e)
+		}
+	}
+	if len(logs) == 0 {
 		return "", ErrNoLogs
 	}
-	sort.Slice(entries, func(i, j int) bool {
-		return entries[i].Name() < entries[j].Name()
+	sort.Slice(logs, func(i, j int) bool {
+		return logs[i].Name() < logs[j].Name()
 	})
-	latestLog := entries[len(entries)-1]
+	latestLog := logs[len(logs)-1]
 	return filepath.Join(serviceLogDir, latestLog.Name()), nil
 }
 
+// ExpiredLogs returns the files of a service's runs that fall outside the
+// retention policy: all but the newest keep runs, and runs older than
+// maxAge. The latest run is always kept. Each run's exit-status and
+// process files go with its log.
+func (m *Manager) ExpiredLogs(serviceName string, keep int, maxAge time.Duration, now time.Time) ([]string, error) {
+	serviceLogDir := filepath.Join(m.logsDir, serviceName)
+	entries, err := os.ReadDir(serviceLogDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read log directory: %w", err)
+	}
+	var logs []os.DirEntry
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".log") {
+			logs = append(logs, e)
+		}
+	}
+	// Names are timestamps, so newest sorts last.
+	sort.Slice(logs, func(i, j int) bool { return logs[i].Name() > logs[j].Name() })
+
+	var expired []string
+	for i, e := range logs {
+		if i == 0 {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		if i < keep && now.Sub(info.ModTime()) <= maxAge {
+			continue
+		}
+		logPath := filepath.Join(serviceLogDir, e.Name())
+		expired = append(expired, logPath)
+		for _, path := range []string{exitFileFor(logPath), procsFileFor(logPath)} {
+			if _, err := os.Stat(path); err == nil {
+				expired = append(expired, path)
+			}
+		}
+	}
+	return expired, nil
+}
+
+// followPollInterval is how often FollowFile and Follow check a log for new
+// output.
+const followPollInterval = 100 * time.Millisecond
+
+// FollowFile copies logPath to out from offset on and keeps copying what is
+// appended until ctx is done or running reports false; whatever was written
+// by then is copied before it returns. It returns the offset reached, from
+// which a later call can continue.
+func (m *Manager) FollowFile(ctx context.Context, logPath string, offset int64, out io.Writer, running func() bool) (int64, error) {
+	f, err := os.Open(logPath)
+	if err != nil {
+		return offset, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return offset, fmt.Errorf("failed to read log file: %w", err)
+	}
+
+	ticker := time.NewTicker(followPollInterval)
+	defer ticker.Stop()
+	for {
+		n, err := io.Copy(out, f)
+		offset += n
+		if err != nil {
+			return offset, fmt.Errorf("failed to read log file: %w", err)
+		}
+		last := !running()
+		if !last {
+			select {
+			case <-ctx.Done():
+				last = true
+			case <-ticker.C:
+			}
+		}
+		if last {
+			n, err := io.Copy(out, f)
+			return offset + n, err
+		}
+	}
+}
+
+// RestartMarker is the line Follow sends when a restart moves a service's
+// output to a newer log file.
+const RestartMarker = "--- restarted ---"
+
+// followBuffer is how many lines Follow buffers for a slow reader.
+const followBuffer = 1024
+
+// followChunk is how much of a log Follow reads at a time.
+const followChunk = 256 * 1024
+
+// maxLineBytes caps how much of an unterminated line is held back while
+// scanning a log; a longer one is handed on as it is.
+const maxLineBytes = 64 * 1024
+
+// Follow streams the lines a service writes to its log from now on: those
+// appended to its newest log file and, when a restart starts a newer one,
+// RestartMarker and the lines of that file. Only new output is read, from
+// the offset reached, a chunk at a time. A line still being written is sent
+// once it ends or grows past maxLineBytes. The channel is closed after stop
+// is called; stop may be called more than once.
+func (m *Manager) Follow(serviceName string) (<-chan string, func()) {
+	out := make(chan string, followBuffer)
+	done := make(chan struct{})
+	var once sync.Once
+	stop := func() { once.Do(func() { close(done) }) }
+
+	path, _ := m.LatestLogPath(serviceName)
+	var offset int64
+	if info, err := os.Stat(path); path != "" && err == nil {
+		offset = info.Size()
+	}
+
+	go func() {
+		defer close(out)
+		ticker := time.NewTicker(followPollInterval)
+		defer ticker.Stop()
+		send := func(line string) bool {
+			select {
+			case out <- line:
+				return true
+			case <-done:
+				return false
+			}
+		}
+		var partial []byte
+		for {
+			more := false
+			if path != "" {
+				data, next, err := readFrom(path, offset, followChunk)
+				if err == nil {
+					offset = next
+					more = len(data) == followChunk
+					partial = append(partial, data...)
+					for {
+						i := bytes.IndexByte(partial, '\n')
+						if i < 0 {
+							break
+						}
+						if !send(strings.TrimSuffix(string(partial[:i]), "\r")) {
+							return
+						}
+						partial = partial[i+1:]
+					}
+					if len(partial) > maxLineBytes {
+						if !send(string(partial)) {
+							return
+						}
+						partial = nil
+					}
+				}
+			}
+			if latest, err := m.LatestLogPath(serviceName); err == nil && latest != path {
+				if path != "" {
+					if len(partial) > 0 && !send(string(partial)) {
+						return
+					}
+					if !send(RestartMarker) {
+						return
+					}
+				}
+				path, offset, partial = latest, 0, nil
+				continue
+			}
+			if more {
+				// The rest of a backlog is read without waiting.
+				select {
+				case <-done:
+					return
+				default:
+				}
+				continue
+			}
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return out, stop
+}
+
+// readFrom returns up to limit bytes of what path holds past offset, and
+// the offset after them. A file shorter than offset has been truncated and
+// is read from the start.
+func readFrom(path string, offset, limit int64) ([]byte, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, offset, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, offset, err
+	}
+	if info.Size() < offset {
+		offset = 0
+	}
+	if info.Size() == offset {
+		return nil, offset, nil
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, err
+	}
+	data, err := io.ReadAll(io.LimitReader(f, limit))
+	return data, offset + int64(len(data)), err
+}
+
 // Tail returns the last N lines from the most recent log file.
 func (m *Manager) Tail(serviceName string, lines int) ([]string, error) {
+	return m.TailContext(context.Background(), serviceName, lines)
+}
+
+// TailContext is Tail, giving up once ctx is done.
+func (m *Manager) TailContext(ctx context.Context, serviceName string, lines int) ([]string, error) {
+	_, out, err := m.TailLatestContext(ctx, serviceName, lines)
+	return out, err
+}
+
+// TailLatest is Tail that also returns the path of the log file read, so
+// that followers can tell when a restart has started a newer one.
+func (m *Manager) TailLatest(serviceName string, lines int) (string, []string, error) {
+	return m.TailLatestContext(context.Background(), serviceName, lines)
+}
+
+// TailLatestContext is TailLatest, giving up once ctx is done.
+func (m *Manager) TailLatestContext(ctx context.Context, serviceName string, lines int) (string, []string, error) {
 	if lines <= 0 {
-		return []string{}, nil
+		return "", []string{}, nil
 	}
 
 	logPath, err := m.LatestLogPath(serviceName)
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
 
 	file, err := os.Open(logPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open log file: %w", err)
+		return "", nil, fmt.Errorf("failed to open log file: %w", err)
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	buf := make([]byte, 0, 1024*1024)
-	scanner.Buffer(buf, 1024*1024)
-
-	linesBuf := make([]string, 0, lines)
-	for scanner.Scan() {
-		if len(linesBuf) < lines {
-			linesBuf = append(linesBuf, scanner.Text())
-		} else {
-			copy(linesBuf, linesBuf[1:])
-			linesBuf[len(linesBuf)-1] = scanner.Text()
+	linesBuf, err := tailLines(ctx, file, lines)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", nil, err
 		}
+		return "", nil, fmt.Errorf("failed to read log file: %w", err)
 	}
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("failed to read log file: %w", err)
+	return logPath, linesBuf, nil
+}
+
+// ReadyMatch reports whether the service's current log contains any of the
+// ready patterns (case-insensitive) and returns the first matching line.
+// Only bytes appended since the previous call are scanned, so polling is cheap.
+func (m *Manager) ReadyMatch(serviceName string, patterns []string) (string, bool) {
+	if len(patterns) == 0 {
+		return "", false
 	}
-	return linesBuf, nil
+	logPath, err := m.LatestLogPath(serviceName)
+	if err != nil {
+		return "", false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ready == nil {
+		m.ready = make(map[string]*readyScan)
+	}
+	st := m.ready[serviceName]
+	if st == nil || st.path != logPath {
+		// A new run writes a new log file; start over.
+		st = &readyScan{path: logPath}
+		m.ready[serviceName] = st
+	}
+	if st.line != "" {
+		return st.line, true
+	}
+
+	file, err := os.Open(logPath)
+	if err != nil {
+		return "", false
+	}
+	defer file.Close()
+	if _, err := file.Seek(st.offset, io.SeekStart); err != nil {
+		return "", false
+	}
+	chunk, err := io.ReadAll(io.LimitReader(file, 4*1024*1024))
+	if err != nil {
+		return "", false
+	}
+	st.offset += int64(len(chunk))
+
+	text := st.partial + string(chunk)
+	lines := strings.Split(text, "\n")
+	// Keep an unterminated trailing line for the next call.
+	st.partial = lines[len(lines)-1]
+	lines = lines[:len(lines)-1]
+	if len(st.partial) > maxLineBytes {
+		lines = append(lines, st.partial)
+		st.partial = ""
+	}
+
+	lowered := make([]string, len(patterns))
+	for i, p := range patterns {
+		lowered[i] = strings.ToLower(p)
+	}
+	for _, line := range lines {
+		lower := strings.ToLower(line)
+		for _, p := range lowered {
+			if p != "" && strings.Contains(lower, p) {
+				st.line = strings.TrimSpace(line)
+				return st.line, true
+			}
+		}
+	}
+	return "", false
 }
 
 // TailProcess tries to retrieve logs for a non-managed process.
 // Strategy:
-// 1) Tail an open *.log file owned by the process, if any.
-// 2) Fall back to macOS unified logs for that PID.
+//  1. Tail an open *.log file owned by the process, if any.
+//  2. Fall back to the platform's system log for that PID (macOS unified
+//     logs, or journald on Linux when journalctl is available).
 func (m *Manager) TailProcess(pid int, lines int) ([]string, error) {
+	return m.TailProcessContext(context.Background(), pid, lines)
+}
+
+// TailProcessContext is TailProcess, giving up once ctx is done.
+func (m *Manager) TailProcessContext(ctx context.Context, pid int, lines int) ([]string, error) {
 	if pid <= 0 {
 		return nil, fmt.Errorf("invalid pid: %d", pid)
 	}
@@ -234,31 +783,64 @@ func (m *Manager) TailProcess(pid int, lines int) ([]string, error) {
 		return []string{}, nil
 	}
 
-	if path, ok := m.pickProcessLogFile(pid); ok {
-		out, err := m.tailFile(path, lines)
+	if path, ok := m.pickProcessLogFile(ctx, pid); ok {
+		out, err := m.tailFile(ctx, path, lines)
 		if err == nil && len(out) > 0 {
 			return out, nil
 		}
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-	pred := fmt.Sprintf("processID == %d", pid)
-	cmd := exec.Command("log", "show", "--last", "2m", "--style", "compact", "--predicate", pred)
-	output, err := cmd.Output()
-	if err == nil {
+	if output, err := systemLogs(ctx, pid, lines); err == nil {
 		linesOut := lastNLines(strings.Split(string(output), "\n"), lines)
 		if len(linesOut) > 0 {
 			return linesOut, nil
 		}
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	return nil, ErrNoProcessLogs
 }
 
-func (m *Manager) pickProcessLogFile(pid int) (string, bool) {
-	cmd := exec.Command("lsof", "-nP", "-p", strconv.Itoa(pid), "-Fn")
+func (m *Manager) pickProcessLogFile(ctx context.Context, pid int) (string, bool) {
+	candidates := processLogFiles(ctx, pid)
+	if len(candidates) == 0 {
+		return "", false
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		fi, errI := os.Stat(candidates[i])
+		fj, errJ := os.Stat(candidates[j])
+		if errI != nil || errJ != nil {
+			return candidates[i] < candidates[j]
+		}
+		return fi.ModTime().After(fj.ModTime())
+	})
+	return candidates[0], true
+}
+
+// isLogPath reports whether an open file looks like a log worth tailing.
+func isLogPath(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.Contains(lower, ".log") || strings.Contains(lower, "/log")
+}
+
+// isRegularFile reports whether path names an existing regular file.
+func isRegularFile(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.Mode().IsRegular()
+}
+
+// lsofLogFiles lists the log files a process has open according to lsof.
+func lsofLogFiles(ctx context.Context, pid int) []string {
+	cmd := exec.CommandContext(ctx, "lsof", "-nP", "-p", strconv.Itoa(pid), "-Fn")
 	output, err := cmd.Output()
 	if err != nil {
-		return "", false
+		return nil
 	}
 
 	var candidates []string
@@ -267,48 +849,38 @@ func (m *Manager) pickProcessLogFile(pid int) (string, bool) {
 			continue
 		}
 		path := strings.TrimSpace(strings.TrimPrefix(line, "n"))
-		if path == "" {
-			continue
-		}
-		lower := strings.ToLower(path)
-		if !strings.Contains(lower, ".log") && !strings.Contains(lower, "/log") {
-			continue
-		}
-		fi, statErr := os.Stat(path)
-		if statErr != nil || fi.IsDir() {
+		if path == "" || !isLogPath(path) || !isRegularFile(path) {
 			continue
 		}
 		candidates = append(candidates, path)
 	}
-	if len(candidates) == 0 {
-		return "", false
-	}
-
-	sort.Slice(candidates, func(i, j int) bool {
-		fi, errI := os.Stat(candidates[i])
-		fj, errJ := os.Stat(candidates[j])
-		if errI != nil || errJ != nil {
-			return candidates[i] < candidates[j]
-		}
-		return fi.ModTime().After(fj.ModTime())
-	})
-	return candidates[0], true
+	return candidates
 }
 
-func (m *Manager) tailFile(path string, lines int) ([]string, error) {
+func (m *Manager) tailFile(ctx context.Context, path string, lines int) ([]string, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
+	return tailLines(ctx, file, lines)
+}
 
-	scanner := bufio.NewScanner(file)
+// tailLines returns the last n lines of r. Large logs take a while to read,
+// so ctx is checked every few thousand lines.
+func tailLines(ctx context.Context, r io.Reader, n int) ([]string, error) {
+	scanner := bufio.NewScanner(r)
 	buf := make([]byte, 0, 1024*1024)
 	scanner.Buffer(buf, 1024*1024)
 
-	linesBuf := make([]string, 0, lines)
-	for scanner.Scan() {
-		if len(linesBuf) < lines {
+	linesBuf := make([]string, 0, n)
+	for i := 0; scanner.Scan(); i++ {
+		if i%4096 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		if len(linesBuf) < n {
 			linesBuf = append(linesBuf, scanner.Text())
 		} else {
 			copy(linesBuf, linesBuf[1:])
@@ -346,6 +918,61 @@ func (m *Manager) processState(pid int) (string, error) {
 	return strings.TrimSpace(string(out)), nil
 }
 
+// ParseCommand splits a command line into argv using the same quoting rules
+// the manager applies to service commands.
+func ParseCommand(input string) ([]string, error) {
+	return parseCommandArgs(input)
+}
+
+// commandArgv returns the argv that runs service's command. Commands are
+// executed directly (no implicit shell) unless the service opted in to
+// shell execution.
+func commandArgv(service *models.ManagedService) ([]string, error) {
+	return CommandArgv(service.Command, service.Shell)
+}
+
+// CommandArgv returns the argv that runs command: split like ParseCommand,
+// or handed to the user's shell when shell is set.
+func CommandArgv(command string, shell bool) ([]string, error) {
+	if strings.TrimSpace(command) == "" {
+		return nil, fmt.Errorf("invalid command: empty")
+	}
+	if shell {
+		return []string{UserShell(), "-c", command}, nil
+	}
+	argv, err := parseCommandArgs(command)
+	if err != nil {
+		return nil, fmt.Errorf("invalid command: %w", err)
+	}
+	if len(argv) == 0 {
+		return nil, fmt.Errorf("invalid command: empty")
+	}
+	return argv, nil
+}
+
+// UserShell returns the shell that runs commands of services with shell
+// execution enabled: $SHELL when it is an absolute path, else /bin/sh.
+func UserShell() string {
+	if sh := os.Getenv("SHELL"); filepath.IsAbs(sh) {
+		return sh
+	}
+	return "/bin/sh"
+}
+
+// QuoteCommand joins argv into a command line that ParseCommand splits back
+// into the same arguments.
+func QuoteCommand(argv []string) string {
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		if arg != "" && !strings.ContainsAny(arg, " \t\"'\\") {
+			quoted[i] = arg
+			continue
+		}
+		quoted[i] = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+	}
+	return strings.Join(quoted, " ")
+}
+
 func parseCommandArgs(input string) ([]string, error) {
 	var args []string
 	var buf strings.Builder
@@ -0,0 +1,426 @@
+package process
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReadyMatchScansIncrementally(t *testing.T) {
+	t.Parallel()
+
+	logsDir := t.TempDir()
+	svcDir := filepath.Join(logsDir, "web")
+	if err := os.MkdirAll(svcDir, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	first := filepath.Join(svcDir, "2026-01-01T10-00-00.log")
+	if err := os.WriteFile(first, []byte("booting\nwebpack compil"), 0644); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+
+	m := NewManager(logsDir)
+	patterns := []string{"Compiled successfully"}
+	if _, ok := m.ReadyMatch("web", patterns); ok {
+		t.Fatal("expected no match before the pattern is written")
+	}
+
+	f, err := os.OpenFile(first, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("open log: %v", err)
+	}
+	_, _ = f.WriteString("ing...\nwebpack compiled successfully in 812ms\n")
+	f.Close()
+
+	line, ok := m.ReadyMatch("web", patterns)
+	if !ok || line != "webpack compiled successfully in 812ms" {
+		t.Fatalf("expected match across partial writes, got %q (ok=%v)", line, ok)
+	}
+
+	// A restart creates a newer log file and readiness starts over.
+	if err := os.WriteFile(filepath.Join(svcDir, "2026-01-01T10-05-00.log"), []byte("booting\n"), 0644); err != nil {
+		t.Fatalf("write second log: %v", err)
+	}
+	if _, ok := m.ReadyMatch("web", patterns); ok {
+		t.Fatal("expected readiness to reset for a new log file")
+	}
+}
+
+func TestCrashLoopBackoff(t *testing.T) {
+	t.Parallel()
+
+	cases := map[int]time.Duration{
+		0:  0,
+		1:  time.Second,
+		2:  2 * time.Second,
+		4:  8 * time.Second,
+		20: 5 * time.Minute,
+	}
+	for restarts, want := range cases {
+		if got := CrashLoopBackoff(restarts); got != want {
+			t.Fatalf("CrashLoopBackoff(%d) = %s, want %s", restarts, got, want)
+		}
+	}
+}
+
+func TestKillTerminatesProcessGroup(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("sh", "-c", "trap '' TERM; sleep 30")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(done)
+	}()
+
+	if err := NewManager(t.TempDir()).Kill(cmd.Process.Pid); err != nil {
+		t.Fatalf("Kill: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("process still running after Kill")
+	}
+}
+
+func TestStopWithSendsGivenSignal(t *testing.T) {
+	t.Parallel()
+
+	// Exits on SIGINT only; SIGTERM would leave it running until the kill.
+	cmd := exec.Command("sh", "-c", "trap 'exit 3' INT; trap '' TERM; while :; do sleep 0.1; done")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	time.Sleep(100 * time.Millisecond) // let the shell install its traps
+
+	if err := NewManager(t.TempDir()).StopWith(cmd.Process.Pid, syscall.SIGINT, 5*time.Second); err != nil {
+		t.Fatalf("StopWith: %v", err)
+	}
+	select {
+	case <-done:
+		if code := cmd.ProcessState.ExitCode(); code != 3 {
+			t.Fatalf("expected the INT trap to exit 3, got %d", code)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("process still running after StopWith")
+	}
+}
+
+func TestStopWithContextGivesUpWithoutKilling(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("sh", "-c", "trap '' TERM; while :; do sleep 0.1; done")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer func() {
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		_ = cmd.Wait()
+	}()
+	time.Sleep(100 * time.Millisecond) // let the shell install its trap
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	m := NewManager(t.TempDir())
+	start := time.Now()
+	if err := m.StopWithContext(ctx, cmd.Process.Pid, syscall.SIGTERM, 10*time.Second); err != context.DeadlineExceeded {
+		t.Fatalf("StopWithContext error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("StopWithContext waited %s past its deadline", elapsed)
+	}
+	if !m.IsRunning(cmd.Process.Pid) {
+		t.Fatal("process was killed although the stop was abandoned")
+	}
+}
+
+func TestSignalPausesAndResumes(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("sleep", "30")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	pid := cmd.Process.Pid
+	m := NewManager(t.TempDir())
+	defer func() {
+		_ = m.Kill(pid)
+		_ = cmd.Wait()
+	}()
+
+	waitPaused := func(want bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for m.IsPaused(pid) != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected IsPaused = %v", want)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	waitPaused(false)
+	if err := m.Signal(pid, syscall.SIGSTOP); err != nil {
+		t.Fatalf("SIGSTOP: %v", err)
+	}
+	waitPaused(true)
+	if err := m.Signal(pid, syscall.SIGCONT); err != nil {
+		t.Fatalf("SIGCONT: %v", err)
+	}
+	waitPaused(false)
+}
+
+func TestExpiredLogsAppliesRetention(t *testing.T) {
+	t.Parallel()
+
+	logsDir := t.TempDir()
+	svcDir := filepath.Join(logsDir, "web")
+	if err := os.MkdirAll(svcDir, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	names := []string{"2026-01-01T10-00-00", "2026-03-08T10-00-00", "2026-03-09T10-00-00", "2026-03-10T10-00-00"}
+	ages := []time.Duration{68 * 24 * time.Hour, 50 * time.Hour, 26 * time.Hour, 2 * time.Hour}
+	for i, name := range names {
+		path := filepath.Join(svcDir, name+".log")
+		if err := os.WriteFile(path, []byte("log\n"), 0644); err != nil {
+			t.Fatalf("write log: %v", err)
+		}
+		if err := os.Chtimes(path, now.Add(-ages[i]), now.Add(-ages[i])); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(svcDir, names[0]+".exit.json"), []byte("{}"), 0644); err != nil {
+		t.Fatalf("write exit file: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		keep   int
+		maxAge time.Duration
+		want   []string
+	}{
+		{"keep two", 2, 30 * 24 * time.Hour, []string{names[1] + ".log", names[0] + ".log", names[0] + ".exit.json"}},
+		{"max age", 10, 30 * 24 * time.Hour, []string{names[0] + ".log", names[0] + ".exit.json"}},
+		{"latest always kept", 1, time.Minute, []string{names[2] + ".log", names[1] + ".log", names[0] + ".log", names[0] + ".exit.json"}},
+	}
+	m := NewManager(logsDir)
+	for _, tt := range tests {
+		got, err := m.ExpiredLogs("web", tt.keep, tt.maxAge, now)
+		if err != nil {
+			t.Fatalf("%s: ExpiredLogs: %v", tt.name, err)
+		}
+		if len(got) != len(tt.want) {
+			t.Fatalf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+		for i := range got {
+			if filepath.Base(got[i]) != tt.want[i] {
+				t.Fatalf("%s: got %v, want %v", tt.name, got, tt.want)
+			}
+		}
+	}
+
+	if got, err := m.ExpiredLogs("missing", 1, time.Minute, now); err != nil || len(got) != 0 {
+		t.Fatalf("expected nothing for a service without logs, got %v (%v)", got, err)
+	}
+}
+
+func TestFollowFileStreamsAppendedOutput(t *testing.T) {
+	t.Parallel()
+
+	logPath := filepath.Join(t.TempDir(), "run.log")
+	if err := os.WriteFile(logPath, []byte("booting\n"), 0644); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+	var mu sync.Mutex
+	var out strings.Builder
+	w := writerFunc(func(p []byte) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return out.Write(p)
+	})
+
+	var running atomic.Bool
+	running.Store(true)
+	done := make(chan int64)
+	go func() {
+		offset, err := NewManager(t.TempDir()).FollowFile(context.Background(), logPath, 0, w, running.Load)
+		if err != nil {
+			t.Errorf("FollowFile: %v", err)
+		}
+		done <- offset
+	}()
+
+	time.Sleep(3 * followPollInterval / 2)
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("open log: %v", err)
+	}
+	_, _ = f.WriteString("listening\n")
+	f.Close()
+	running.Store(false)
+
+	offset := <-done
+	mu.Lock()
+	got := out.String()
+	mu.Unlock()
+	if got != "booting\nlistening\n" || offset != int64(len(got)) {
+		t.Fatalf("followed %q up to %d", got, offset)
+	}
+}
+
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+func TestCreateLogFileKeepsEarlierRunOfTheSameSecond(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(t.TempDir())
+	var paths []string
+	for range 3 {
+		f, err := m.createLogFile("web")
+		if err != nil {
+			t.Fatalf("createLogFile: %v", err)
+		}
+		paths = append(paths, f.Name())
+		f.Close()
+	}
+	latest, err := m.LatestLogPath("web")
+	if err != nil || latest != paths[2] {
+		t.Fatalf("LatestLogPath = %q, %v; created %q", latest, err, paths)
+	}
+	if paths[0] == paths[1] || paths[1] == paths[2] {
+		t.Fatalf("log files reused: %q", paths)
+	}
+}
+
+func TestTailLatestReportsTheFileRead(t *testing.T) {
+	t.Parallel()
+
+	logsDir := t.TempDir()
+	svcDir := filepath.Join(logsDir, "web")
+	if err := os.MkdirAll(svcDir, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	m := NewManager(logsDir)
+	for _, name := range []string{"2026-01-01T10-00-00.log", "2026-01-01T10-05-00.log"} {
+		path := filepath.Join(svcDir, name)
+		if err := os.WriteFile(path, []byte("run "+name+"\n"), 0644); err != nil {
+			t.Fatalf("write log: %v", err)
+		}
+		got, lines, err := m.TailLatest("web", 10)
+		if err != nil || got != path || len(lines) != 1 || lines[0] != "run "+name {
+			t.Fatalf("TailLatest = %q, %q, %v; want %s", got, lines, err, name)
+		}
+	}
+}
+
+func TestFollowSendsNewLinesAcrossRestarts(t *testing.T) {
+	t.Parallel()
+
+	logsDir := t.TempDir()
+	svcDir := filepath.Join(logsDir, "web")
+	if err := os.MkdirAll(svcDir, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	first := filepath.Join(svcDir, "2026-01-01T10-00-00.log")
+	if err := os.WriteFile(first, []byte("already there\n"), 0644); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+	appendTo := func(path, text string) {
+		t.Helper()
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatalf("open log: %v", err)
+		}
+		_, _ = f.WriteString(text)
+		f.Close()
+	}
+	next := func(lines <-chan string) string {
+		t.Helper()
+		select {
+		case line := <-lines:
+			return line
+		case <-time.After(2 * time.Second):
+			t.Fatal("no line followed")
+			return ""
+		}
+	}
+
+	lines, stop := NewManager(logsDir).Follow("web")
+	appendTo(first, "GET / 200\nhalf a ")
+	if got := next(lines); got != "GET / 200" {
+		t.Fatalf("first line = %q, want only output appended after Follow", got)
+	}
+	appendTo(first, "line\n")
+	if got := next(lines); got != "half a line" {
+		t.Fatalf("line written in two parts = %q", got)
+	}
+
+	appendTo(filepath.Join(svcDir, "2026-01-01T10-05-00.log"), "booting\n")
+	for _, want := range []string{RestartMarker, "booting"} {
+		if got := next(lines); got != want {
+			t.Fatalf("after restart got %q, want %q", got, want)
+		}
+	}
+
+	stop()
+	stop()
+	for range lines {
+	}
+}
+
+func TestFollowCapsUnterminatedLines(t *testing.T) {
+	t.Parallel()
+
+	logsDir := t.TempDir()
+	svcDir := filepath.Join(logsDir, "web")
+	if err := os.MkdirAll(svcDir, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	logPath := filepath.Join(svcDir, "2026-01-01T10-00-00.log")
+	if err := os.WriteFile(logPath, nil, 0644); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+
+	lines, stop := NewManager(logsDir).Follow("web")
+	defer stop()
+	// More than a chunk of output without a newline, then a short line.
+	if err := os.WriteFile(logPath, []byte(strings.Repeat("x", followChunk+maxLineBytes/2)+"\ndone\n"), 0644); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+	total := 0
+	for {
+		select {
+		case line := <-lines:
+			if line == "done" {
+				if total != followChunk+maxLineBytes/2 {
+					t.Fatalf("followed %d bytes of the long line", total)
+				}
+				return
+			}
+			if len(line) > followChunk+maxLineBytes {
+				t.Fatalf("line of %d bytes exceeds the cap", len(line))
+			}
+			total += len(line)
+		case <-time.After(2 * time.Second):
+			t.Fatal("no line followed")
+		}
+	}
+}
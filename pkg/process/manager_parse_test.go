@@ -3,6 +3,8 @@ package process
 import (
 	"reflect"
 	"testing"
+
+	"github.com/devports/devpt/pkg/models"
 )
 
 func TestParseCommandArgs(t *testing.T) {
@@ -25,3 +27,41 @@ func TestParseCommandArgs_UnterminatedQuote(t *testing.T) {
 		t.Fatal("expected unterminated quote error")
 	}
 }
+
+func TestQuoteCommandRoundTrips(t *testing.T) {
+	t.Parallel()
+
+	argv := []string{"python3", "-c", `print("hi there")`, `C:\tmp`, "it's"}
+	got, err := parseCommandArgs(QuoteCommand(argv))
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	if !reflect.DeepEqual(got, argv) {
+		t.Fatalf("round trip: got %#v want %#v", got, argv)
+	}
+}
+
+func TestCommandArgv(t *testing.T) {
+	t.Setenv("SHELL", "/bin/zsh")
+
+	got, err := commandArgv(&models.ManagedService{Command: `npm run dev -- --port "$PORT"`})
+	if err != nil {
+		t.Fatalf("direct: %v", err)
+	}
+	if want := []string{"npm", "run", "dev", "--", "--port", "$PORT"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("direct argv: got %#v want %#v", got, want)
+	}
+
+	got, err = commandArgv(&models.ManagedService{Command: `npm run dev -- --port $PORT`, Shell: true})
+	if err != nil {
+		t.Fatalf("shell: %v", err)
+	}
+	if want := []string{"/bin/zsh", "-c", "npm run dev -- --port $PORT"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("shell argv: got %#v want %#v", got, want)
+	}
+
+	t.Setenv("SHELL", "")
+	if got := UserShell(); got != "/bin/sh" {
+		t.Fatalf("UserShell without $SHELL = %q", got)
+	}
+}
@@ -0,0 +1,18 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// processLogFiles lists the log files a process has open.
+func processLogFiles(ctx context.Context, pid int) []string {
+	return lsofLogFiles(ctx, pid)
+}
+
+// systemLogs returns the last two minutes of unified log entries for pid.
+func systemLogs(ctx context.Context, pid int, lines int) ([]byte, error) {
+	pred := fmt.Sprintf("processID == %d", pid)
+	return exec.CommandContext(ctx, "log", "show", "--last", "2m", "--style", "compact", "--predicate", pred).Output()
+}
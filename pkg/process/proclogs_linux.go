@@ -0,0 +1,55 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// processLogFiles lists the log files a process has open by reading the
+// symlinks in /proc/<pid>/fd. A stdout or stderr redirected to a regular
+// file counts even when its name does not look like a log. When /proc is
+// unreadable (another user's process), lsof is tried instead.
+func processLogFiles(ctx context.Context, pid int) []string {
+	fdDir := filepath.Join("/proc", strconv.Itoa(pid), "fd")
+	entries, err := os.ReadDir(fdDir)
+	if err != nil {
+		return lsofLogFiles(ctx, pid)
+	}
+
+	seen := make(map[string]bool)
+	var candidates []string
+	for _, e := range entries {
+		path, err := os.Readlink(filepath.Join(fdDir, e.Name()))
+		if err != nil || !filepath.IsAbs(path) || seen[path] {
+			continue
+		}
+		// Deleted files keep their fd but are no longer tail-able by name.
+		if strings.HasSuffix(path, " (deleted)") {
+			continue
+		}
+		stdio := e.Name() == "1" || e.Name() == "2"
+		if !stdio && !isLogPath(path) {
+			continue
+		}
+		if !isRegularFile(path) {
+			continue
+		}
+		seen[path] = true
+		candidates = append(candidates, path)
+	}
+	return candidates
+}
+
+// systemLogs returns the journald entries for pid when journalctl is
+// installed; processes not started by systemd usually have none.
+func systemLogs(ctx context.Context, pid int, lines int) ([]byte, error) {
+	if _, err := exec.LookPath("journalctl"); err != nil {
+		return nil, err
+	}
+	return exec.CommandContext(ctx, "journalctl", "--no-pager", "-q", "-o", "short", "-n", strconv.Itoa(lines), fmt.Sprintf("_PID=%d", pid)).Output()
+}
@@ -0,0 +1,81 @@
+package process
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTailProcessReadsRedirectedStdoutFromProc(t *testing.T) {
+	t.Parallel()
+
+	out := filepath.Join(t.TempDir(), "output.txt")
+	f, err := os.Create(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	cmd := exec.Command("sh", "-c", "echo one; echo two; echo three; exec sleep 5")
+	cmd.Stdout = f
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+
+	m := NewManager(t.TempDir())
+	var lines []string
+	for i := 0; i < 50; i++ {
+		lines, err = m.TailProcess(cmd.Process.Pid, 2)
+		if err == nil && len(lines) == 2 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("TailProcess: %v", err)
+	}
+	if want := []string{"two", "three"}; !reflect.DeepEqual(lines, want) {
+		t.Fatalf("lines = %q, want %q", lines, want)
+	}
+}
+
+func TestProcessLogFilesSkipsNonLogFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+	dataPath := filepath.Join(dir, "data.db")
+	for _, p := range []string{logPath, dataPath} {
+		if err := os.WriteFile(p, []byte("x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command("sh", "-c", "exec sleep 5 3<"+logPath+" 4<"+dataPath)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+
+	var got []string
+	for i := 0; i < 50 && len(got) == 0; i++ {
+		got = processLogFiles(context.Background(), cmd.Process.Pid)
+		if len(got) == 0 {
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	if want := []string{logPath}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("processLogFiles = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !linux && !darwin
+
+package process
+
+import (
+	"context"
+	"errors"
+)
+
+// processLogFiles lists the log files a process has open.
+func processLogFiles(ctx context.Context, pid int) []string {
+	return lsofLogFiles(ctx, pid)
+}
+
+// systemLogs is unsupported on this platform.
+func systemLogs(ctx context.Context, pid int, lines int) ([]byte, error) {
+	return nil, errors.New("no system log on this platform")
+}
@@ -0,0 +1,151 @@
+package process
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Terminal size given to services in pty mode. Clients don't negotiate a
+// size; this is wide enough for typical dev-server banners and menus.
+const (
+	ptyRows = 40
+	ptyCols = 120
+)
+
+// ptyClientWriteTimeout bounds how long a stalled `devpt attach` client can
+// hold up the service's output.
+const ptyClientWriteTimeout = time.Second
+
+// ptySession runs a service on a pseudo-terminal and serves it on a Unix
+// socket: every client receives the output and its input is typed into the
+// terminal. Output is also copied to the run's log.
+type ptySession struct {
+	master *os.File
+	tty    *os.File // the service's end; closed in the supervisor once started
+	ln     net.Listener
+	path   string
+
+	mu      sync.Mutex
+	clients map[net.Conn]bool
+	done    chan struct{}
+}
+
+func newPTYSession(socketPath string) (*ptySession, error) {
+	master, tty, err := openPTY()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pty: %w", err)
+	}
+	_ = setPTYSize(master, ptyRows, ptyCols)
+	_ = os.Remove(socketPath)
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		master.Close()
+		tty.Close()
+		return nil, fmt.Errorf("failed to listen on %s: %w", socketPath, err)
+	}
+	// Whoever can connect can type into the service.
+	_ = os.Chmod(socketPath, 0600)
+	return &ptySession{
+		master:  master,
+		tty:     tty,
+		ln:      ln,
+		path:    socketPath,
+		clients: make(map[net.Conn]bool),
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// serve copies the terminal's output to log and to connected clients until
+// the terminal closes, and accepts clients meanwhile.
+func (s *ptySession) serve(log io.Writer) {
+	go s.accept()
+	defer close(s.done)
+	buf := make([]byte, 32<<10)
+	for {
+		n, err := s.master.Read(buf)
+		if n > 0 {
+			_, _ = log.Write(buf[:n])
+			s.broadcast(buf[:n])
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *ptySession) accept() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.clients[conn] = true
+		s.mu.Unlock()
+		go func() {
+			_, _ = io.Copy(s.master, conn)
+			s.drop(conn)
+		}()
+	}
+}
+
+func (s *ptySession) broadcast(p []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.clients {
+		_ = conn.SetWriteDeadline(time.Now().Add(ptyClientWriteTimeout))
+		if _, err := conn.Write(p); err != nil {
+			conn.Close()
+			delete(s.clients, conn)
+		}
+	}
+}
+
+func (s *ptySession) drop(conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	conn.Close()
+	delete(s.clients, conn)
+}
+
+// close waits up to grace for the remaining output, then disconnects
+// clients and removes the socket.
+func (s *ptySession) close(grace time.Duration) {
+	select {
+	case <-s.done:
+	case <-time.After(grace):
+	}
+	s.ln.Close()
+	_ = os.Remove(s.path)
+	s.master.Close()
+	s.mu.Lock()
+	for conn := range s.clients {
+		conn.Close()
+	}
+	s.clients = map[net.Conn]bool{}
+	s.mu.Unlock()
+}
+
+// socketFileFor returns the pty socket path that belongs to a run's log file.
+func socketFileFor(logPath string) string {
+	return strings.TrimSuffix(logPath, ".log") + ".sock"
+}
+
+// AttachSocket returns the socket of the service's latest run when it was
+// started in pty mode and is still running.
+func (m *Manager) AttachSocket(serviceName string) (string, error) {
+	logPath, err := m.LatestLogPath(serviceName)
+	if err != nil {
+		return "", err
+	}
+	path := socketFileFor(logPath)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("the latest run of %q has no terminal to attach to", serviceName)
+	}
+	return path, nil
+}
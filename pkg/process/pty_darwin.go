@@ -0,0 +1,47 @@
+package process
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPTY allocates a pseudo-terminal pair. Neither end becomes the
+// caller's controlling terminal.
+func openPTY() (master, tty *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	fd := master.Fd()
+	for _, req := range []uintptr{unix.TIOCPTYGRANT, unix.TIOCPTYUNLK} {
+		if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, req, 0); errno != 0 {
+			master.Close()
+			return nil, nil, fmt.Errorf("failed to unlock pty: %w", errno)
+		}
+	}
+	var name [128]byte
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, unix.TIOCPTYGNAME, uintptr(unsafe.Pointer(&name[0]))); errno != 0 {
+		master.Close()
+		return nil, nil, fmt.Errorf("failed to get pty name: %w", errno)
+	}
+	path := string(name[:])
+	if i := bytes.IndexByte(name[:], 0); i >= 0 {
+		path = string(name[:i])
+	}
+	tty, err = os.OpenFile(path, os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	return master, tty, nil
+}
+
+// setPTYSize sets the terminal size the service sees.
+func setPTYSize(master *os.File, rows, cols uint16) error {
+	return unix.IoctlSetWinsize(int(master.Fd()), unix.TIOCSWINSZ, &unix.Winsize{Row: rows, Col: cols})
+}
@@ -0,0 +1,15 @@
+//go:build !linux && !darwin
+
+package process
+
+import (
+	"errors"
+	"os"
+)
+
+// openPTY is unsupported on this platform.
+func openPTY() (master, tty *os.File, err error) {
+	return nil, nil, errors.New("pty mode is not supported on this platform")
+}
+
+func setPTYSize(master *os.File, rows, cols uint16) error { return nil }
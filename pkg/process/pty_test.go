@@ -0,0 +1,65 @@
+package process
+
+import (
+	"bufio"
+	"net"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestPTYSessionRelaysInputAndOutput(t *testing.T) {
+	t.Parallel()
+
+	socket := filepath.Join(t.TempDir(), "run.sock")
+	session, err := newPTYSession(socket)
+	if err != nil {
+		t.Skipf("pty unavailable: %v", err)
+	}
+	cmd := exec.Command("sh", "-c", `read line; echo "got $line"`)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = session.tty, session.tty, session.tty
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	session.tty.Close()
+	var mu sync.Mutex
+	var log strings.Builder
+	go session.serve(writerFunc(func(p []byte) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return log.Write(p)
+	}))
+
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("r\n")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if strings.Contains(line, "got r") {
+			break
+		}
+		if err != nil {
+			t.Fatalf("no reply from the service: %v", err)
+		}
+	}
+
+	_ = cmd.Wait()
+	session.close(time.Second)
+	mu.Lock()
+	defer mu.Unlock()
+	if !strings.Contains(log.String(), "got r") {
+		t.Fatalf("log missing output: %q", log.String())
+	}
+}
@@ -0,0 +1,59 @@
+package process
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// signalNames maps conventional names to signals commonly sent to dev servers.
+var signalNames = map[string]syscall.Signal{
+	"SIGHUP":   syscall.SIGHUP,
+	"SIGINT":   syscall.SIGINT,
+	"SIGQUIT":  syscall.SIGQUIT,
+	"SIGABRT":  syscall.SIGABRT,
+	"SIGKILL":  syscall.SIGKILL,
+	"SIGBUS":   syscall.SIGBUS,
+	"SIGSEGV":  syscall.SIGSEGV,
+	"SIGPIPE":  syscall.SIGPIPE,
+	"SIGALRM":  syscall.SIGALRM,
+	"SIGTERM":  syscall.SIGTERM,
+	"SIGUSR1":  syscall.SIGUSR1,
+	"SIGUSR2":  syscall.SIGUSR2,
+	"SIGSTOP":  syscall.SIGSTOP,
+	"SIGCONT":  syscall.SIGCONT,
+	"SIGTSTP":  syscall.SIGTSTP,
+	"SIGWINCH": syscall.SIGWINCH,
+}
+
+// SignalName returns the conventional name for sig, e.g. "SIGKILL".
+func SignalName(sig syscall.Signal) string {
+	for name, s := range signalNames {
+		if s == sig {
+			return name
+		}
+	}
+	return fmt.Sprintf("signal %d", int(sig))
+}
+
+// ParseSignal accepts "SIGTERM", "TERM", "term" or a signal number.
+func ParseSignal(raw string) (syscall.Signal, error) {
+	name := strings.ToUpper(strings.TrimSpace(raw))
+	if name == "" {
+		return 0, fmt.Errorf("empty signal")
+	}
+	if n, err := strconv.Atoi(name); err == nil {
+		if n <= 0 || n > 64 {
+			return 0, fmt.Errorf("invalid signal number: %d", n)
+		}
+		return syscall.Signal(n), nil
+	}
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	if sig, ok := signalNames[name]; ok {
+		return sig, nil
+	}
+	return 0, fmt.Errorf("unknown signal: %s", raw)
+}
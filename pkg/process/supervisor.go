@@ -0,0 +1,226 @@
+package process
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// SupervisorCommand is the hidden subcommand that runs a service under a
+// supervising parent. The CLI exits right after start, so without a parent
+// that Waits on the child nobody ever learns its exit code.
+const SupervisorCommand = "__supervise"
+
+// supervisorReportTimeout bounds how long Start waits for the supervisor to
+// report the child's PID.
+const supervisorReportTimeout = 5 * time.Second
+
+// SetSupervisor makes Start launch services through `<exe> __supervise`, so
+// their exit status is recorded next to the run's log. exe must dispatch that
+// subcommand to RunSupervisor. An empty exe starts services directly.
+func (m *Manager) SetSupervisor(exe string) {
+	m.supervisor = exe
+}
+
+// ptyDrainTimeout bounds how long the supervisor waits for the last output
+// of a pty-mode service; descendants that keep the terminal open would
+// otherwise hold it forever.
+const ptyDrainTimeout = time.Second
+
+// RunSupervisor implements the supervise subcommand:
+//
+//	__supervise [--pty <socket>] <exit-file> -- <argv...>
+//
+// It starts argv in its own process group, reports the child PID on fd 3,
+// waits for it and writes a models.ExitStatus as JSON to exit-file. With
+// --pty the child runs on a pseudo-terminal served on socket for `devpt
+// attach`. The returned value is the supervisor's own exit code.
+func RunSupervisor(args []string) int {
+	report := io.Writer(io.Discard)
+	if f := os.NewFile(3, "report"); f != nil {
+		report = f
+		defer f.Close()
+	}
+	socket := ""
+	if len(args) >= 2 && args[0] == "--pty" {
+		socket, args = args[1], args[2:]
+	}
+	if len(args) < 3 || args[1] != "--" {
+		fmt.Fprintf(report, "err usage: %s [--pty <socket>] <exit-file> -- <command...>\n", SupervisorCommand)
+		return 2
+	}
+	return supervise(args[0], args[2:], socket, report)
+}
+
+func supervise(exitFile string, argv []string, socket string, report io.Writer) int {
+	// Terminal and group signals are aimed at the service, not at us. Catch
+	// rather than ignore them: ignored dispositions would be inherited.
+	signal.Notify(make(chan os.Signal, 1), syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT)
+
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin = nil
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// The child leads its own group so Stop can signal it and its descendants
+	// without hitting the supervisor.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	var session *ptySession
+	if socket != "" {
+		var err error
+		if session, err = newPTYSession(socket); err != nil {
+			fmt.Fprintf(report, "err %s\n", err)
+			return 1
+		}
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = session.tty, session.tty, session.tty
+		// A new session whose controlling terminal is the pty; its process
+		// group is led by the child just the same.
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	}
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(report, "err %s\n", err)
+		if session != nil {
+			session.close(0)
+		}
+		return 1
+	}
+	fmt.Fprintf(report, "pid %d\n", cmd.Process.Pid)
+	if c, ok := report.(io.Closer); ok {
+		_ = c.Close()
+	}
+	if session != nil {
+		session.tty.Close()
+		go session.serve(os.Stdout)
+	}
+
+	_ = cmd.Wait()
+	if session != nil {
+		session.close(ptyDrainTimeout)
+	}
+	status := exitStatusOf(cmd.Process.Pid, cmd.ProcessState)
+	if err := writeExitStatus(exitFile, status); err != nil {
+		fmt.Fprintf(os.Stderr, "devpt: failed to record exit status: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// exitStatusOf converts a finished process state into an ExitStatus.
+func exitStatusOf(pid int, state *os.ProcessState) *models.ExitStatus {
+	status := &models.ExitStatus{PID: pid, ExitedAt: time.Now()}
+	if state == nil {
+		status.Code = -1
+		return status
+	}
+	status.Code = state.ExitCode()
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		// Match shell convention: 128 + signal number.
+		status.Code = 128 + int(ws.Signal())
+		status.Signal = SignalName(ws.Signal())
+	}
+	return status
+}
+
+func writeExitStatus(path string, status *models.ExitStatus) error {
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// startSupervised launches argv through the configured supervisor and
+// returns the PID of the service process itself.
+func (m *Manager) startSupervised(argv []string, dir string, env []string, logFile *os.File, pty bool) (int, error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return 0, fmt.Errorf("failed to create supervisor pipe: %w", err)
+	}
+	defer reader.Close()
+
+	args := []string{SupervisorCommand}
+	if pty {
+		args = append(args, "--pty", socketFileFor(logFile.Name()))
+	}
+	args = append(append(args, exitFileFor(logFile.Name()), "--"), argv...)
+	cmd := exec.Command(m.supervisor, args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.ExtraFiles = []*os.File{writer}
+	// Detach from the terminal so the supervisor outlives the CLI.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		writer.Close()
+		return 0, fmt.Errorf("failed to start process: %w", err)
+	}
+	writer.Close()
+	// The supervisor is reaped by init once we exit; don't leave a zombie
+	// behind in long-running callers such as the TUI.
+	go func() { _ = cmd.Wait() }()
+
+	_ = reader.SetReadDeadline(time.Now().Add(supervisorReportTimeout))
+	line, err := bufio.NewReader(reader).ReadString('\n')
+	if err != nil && line == "" {
+		return 0, fmt.Errorf("failed to start process: supervisor did not report: %w", err)
+	}
+	line = strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(line, "pid "):
+		pid, err := strconv.Atoi(strings.TrimPrefix(line, "pid "))
+		if err != nil || pid <= 0 {
+			return 0, fmt.Errorf("failed to start process: bad supervisor report %q", line)
+		}
+		return pid, nil
+	case strings.HasPrefix(line, "err "):
+		return 0, fmt.Errorf("failed to start process: %s", strings.TrimPrefix(line, "err "))
+	default:
+		return 0, fmt.Errorf("failed to start process: bad supervisor report %q", line)
+	}
+}
+
+// exitFileFor returns the exit-status path that belongs to a run's log file.
+func exitFileFor(logPath string) string {
+	return strings.TrimSuffix(logPath, ".log") + ".exit.json"
+}
+
+// LastExit returns how the service's most recent supervised run ended.
+// It returns nil, nil while that run is still going or when it was not
+// started under a supervisor.
+func (m *Manager) LastExit(serviceName string) (*models.ExitStatus, error) {
+	logPath, err := m.LatestLogPath(serviceName)
+	if err != nil {
+		if errors.Is(err, ErrNoLogs) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	data, err := os.ReadFile(exitFileFor(logPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read exit status: %w", err)
+	}
+	var status models.ExitStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(exitFileFor(logPath)), err)
+	}
+	return &status, nil
+}
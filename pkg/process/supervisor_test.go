@@ -0,0 +1,84 @@
+package process
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSuperviseRecordsExitCodeAndSignal(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		script string
+		code   int
+		signal string
+	}{
+		{name: "exit", script: "exit 3", code: 3},
+		{name: "killed", script: "kill -9 $$", code: 137, signal: "SIGKILL"},
+	}
+	for _, tc := range cases {
+		logsDir := t.TempDir()
+		svcDir := filepath.Join(logsDir, "web")
+		if err := os.MkdirAll(svcDir, 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		logPath := filepath.Join(svcDir, "2026-01-01T10-00-00.log")
+		if err := os.WriteFile(logPath, nil, 0644); err != nil {
+			t.Fatalf("write log: %v", err)
+		}
+
+		var report bytes.Buffer
+		if rc := supervise(exitFileFor(logPath), []string{"sh", "-c", tc.script}, "", &report); rc != 0 {
+			t.Fatalf("%s: supervise returned %d", tc.name, rc)
+		}
+		if !strings.HasPrefix(report.String(), "pid ") {
+			t.Fatalf("%s: expected pid report, got %q", tc.name, report.String())
+		}
+
+		exit, err := NewManager(logsDir).LastExit("web")
+		if err != nil || exit == nil {
+			t.Fatalf("%s: LastExit = %v, %v", tc.name, exit, err)
+		}
+		if exit.Code != tc.code || exit.Signal != tc.signal {
+			t.Fatalf("%s: got code %d signal %q, want %d %q", tc.name, exit.Code, exit.Signal, tc.code, tc.signal)
+		}
+	}
+}
+
+func TestLatestLogPathIgnoresExitFiles(t *testing.T) {
+	t.Parallel()
+
+	logsDir := t.TempDir()
+	svcDir := filepath.Join(logsDir, "web")
+	if err := os.MkdirAll(svcDir, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for _, name := range []string{"2026-01-01T10-00-00.log", "2026-01-01T10-00-00.exit.json"} {
+		if err := os.WriteFile(filepath.Join(svcDir, name), []byte("{}"), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	got, err := NewManager(logsDir).LatestLogPath("web")
+	if err != nil || filepath.Base(got) != "2026-01-01T10-00-00.log" {
+		t.Fatalf("LatestLogPath = %q, %v", got, err)
+	}
+}
+
+func TestParseSignal(t *testing.T) {
+	t.Parallel()
+
+	for _, raw := range []string{"SIGTERM", "term", "15"} {
+		sig, err := ParseSignal(raw)
+		if err != nil || SignalName(sig) != "SIGTERM" {
+			t.Fatalf("ParseSignal(%q) = %v, %v", raw, sig, err)
+		}
+	}
+	if _, err := ParseSignal("SIGNOPE"); err == nil {
+		t.Fatal("expected error for unknown signal")
+	}
+}
@@ -0,0 +1,68 @@
+package process
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Proc is a process seen in the system's process list.
+type Proc struct {
+	PID     int
+	PPID    int
+	Command string
+}
+
+// Descendants returns the processes descended from pid, nearest first.
+// Processes that left pid's process group are included, which is what a
+// stop cannot reach.
+func (m *Manager) Descendants(pid int) ([]Proc, error) {
+	out, err := exec.Command("ps", "-A", "-o", "pid=", "-o", "ppid=", "-o", "command=").Output()
+	if err != nil {
+		return nil, err
+	}
+	return descendants(parseProcList(string(out)), pid), nil
+}
+
+// parseProcList parses "pid ppid command" lines.
+func parseProcList(out string) []Proc {
+	var procs []Proc
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		ppid, err2 := strconv.Atoi(fields[1])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		procs = append(procs, Proc{PID: pid, PPID: ppid, Command: strings.Join(fields[2:], " ")})
+	}
+	return procs
+}
+
+func descendants(procs []Proc, root int) []Proc {
+	children := make(map[int][]Proc)
+	for _, p := range procs {
+		if p.PID != p.PPID {
+			children[p.PPID] = append(children[p.PPID], p)
+		}
+	}
+	var out []Proc
+	queue := []int{root}
+	seen := map[int]bool{root: true}
+	for len(queue) > 0 {
+		pid := queue[0]
+		queue = queue[1:]
+		for _, c := range children[pid] {
+			if seen[c.PID] {
+				continue
+			}
+			seen[c.PID] = true
+			out = append(out, c)
+			queue = append(queue, c.PID)
+		}
+	}
+	return out
+}
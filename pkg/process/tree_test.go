@@ -0,0 +1,31 @@
+package process
+
+import "testing"
+
+func TestDescendantsFollowsTheProcessTree(t *testing.T) {
+	t.Parallel()
+
+	procs := parseProcList(`    1     0 /sbin/init
+  100     1 npm run dev
+  101   100 sh -c node server.js
+  102   101 node server.js
+  103     1 node worker.js
+  200     1 /usr/bin/zsh
+`)
+	got := descendants(procs, 100)
+	want := []int{101, 102}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d descendants, got %+v", len(want), got)
+	}
+	for i, p := range got {
+		if p.PID != want[i] {
+			t.Fatalf("descendant %d: got PID %d, want %d", i, p.PID, want[i])
+		}
+	}
+	if got[1].Command != "node server.js" {
+		t.Fatalf("unexpected command %q", got[1].Command)
+	}
+	if len(descendants(procs, 102)) != 0 {
+		t.Fatal("expected a leaf process to have no descendants")
+	}
+}
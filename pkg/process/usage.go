@@ -0,0 +1,117 @@
+package process
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Usage is the resource use of a process group.
+type Usage struct {
+	RSS     int64         // resident memory in bytes
+	CPUTime time.Duration // CPU time consumed so far
+	Procs   int
+}
+
+// GroupUsage sums the resource use of the processes in each process group,
+// keyed by group ID. A managed service's group ID is its recorded PID.
+func (m *Manager) GroupUsage() (map[int]Usage, error) {
+	out, err := exec.Command("ps", "-A", "-o", "pgid=", "-o", "rss=", "-o", "time=").Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseGroupUsage(string(out)), nil
+}
+
+// parseGroupUsage parses "pgid rss time" lines, rss in KiB.
+func parseGroupUsage(out string) map[int]Usage {
+	usage := make(map[int]Usage)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		pgid, err1 := strconv.Atoi(fields[0])
+		rss, err2 := strconv.ParseInt(fields[1], 10, 64)
+		cpu, err3 := parseCPUTime(fields[2])
+		if err1 != nil || err2 != nil || err3 != nil || pgid <= 0 {
+			continue
+		}
+		u := usage[pgid]
+		u.RSS += rss * 1024
+		u.CPUTime += cpu
+		u.Procs++
+		usage[pgid] = u
+	}
+	return usage
+}
+
+// ProcessStats is the resource use and age of a single process.
+type ProcessStats struct {
+	RSS     int64 // resident memory in bytes
+	CPUTime time.Duration
+	Elapsed time.Duration // time since the process started
+}
+
+// Stats returns the resource use and age of every process, keyed by PID.
+func (m *Manager) Stats() (map[int]ProcessStats, error) {
+	out, err := exec.Command("ps", "-A", "-o", "pid=", "-o", "rss=", "-o", "time=", "-o", "etime=").Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseStats(string(out)), nil
+}
+
+// parseStats parses "pid rss time etime" lines, rss in KiB.
+func parseStats(out string) map[int]ProcessStats {
+	stats := make(map[int]ProcessStats)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		rss, err2 := strconv.ParseInt(fields[1], 10, 64)
+		cpu, err3 := parseCPUTime(fields[2])
+		elapsed, err4 := parseCPUTime(fields[3])
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil || pid <= 0 {
+			continue
+		}
+		stats[pid] = ProcessStats{RSS: rss * 1024, CPUTime: cpu, Elapsed: elapsed}
+	}
+	return stats
+}
+
+// parseCPUTime parses ps's time column: "[[dd-]hh:]mm:ss" on Linux,
+// "mm:ss.cc" on macOS. The etime column uses the same format.
+func parseCPUTime(s string) (time.Duration, error) {
+	var days int
+	if d, rest, ok := strings.Cut(s, "-"); ok {
+		n, err := strconv.Atoi(d)
+		if err != nil {
+			return 0, fmt.Errorf("invalid cpu time %q", s)
+		}
+		days, s = n, rest
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid cpu time %q", s)
+	}
+	secs, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cpu time %q", s)
+	}
+	total := time.Duration(secs * float64(time.Second))
+	unit := time.Minute
+	for i := len(parts) - 2; i >= 0; i-- {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return 0, fmt.Errorf("invalid cpu time %q", s)
+		}
+		total += time.Duration(n) * unit
+		unit *= 60
+	}
+	return total + time.Duration(days)*24*time.Hour, nil
+}
@@ -0,0 +1,47 @@
+package process
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseGroupUsageSumsGroups(t *testing.T) {
+	t.Parallel()
+
+	usage := parseGroupUsage(`  100  2048 00:00:03
+  100  1024 00:01:00
+  200   512 1-02:00:00
+  300   256 12:01.50
+bogus line
+`)
+	if got := usage[100]; got.RSS != 3072*1024 || got.CPUTime != 63*time.Second || got.Procs != 2 {
+		t.Fatalf("unexpected usage for group 100: %+v", got)
+	}
+	if got := usage[200].CPUTime; got != 26*time.Hour {
+		t.Fatalf("expected 26h for a day-long cpu time, got %s", got)
+	}
+	if got := usage[300].CPUTime; got != 12*time.Minute+1500*time.Millisecond {
+		t.Fatalf("expected macOS-style cpu time to parse, got %s", got)
+	}
+	if len(usage) != 3 {
+		t.Fatalf("expected 3 groups, got %d", len(usage))
+	}
+}
+
+func TestParseStats(t *testing.T) {
+	t.Parallel()
+
+	stats := parseStats(`    1   100 00:00:02 3-00:00:00
+ 4242  2048 01:30.25    05:00
+garbage
+`)
+	if got := stats[1]; got.CPUTime != 2*time.Second || got.Elapsed != 72*time.Hour {
+		t.Fatalf("unexpected stats for pid 1: %+v", got)
+	}
+	if got := stats[4242]; got.RSS != 2<<20 || got.CPUTime != 90250*time.Millisecond || got.Elapsed != 5*time.Minute {
+		t.Fatalf("unexpected stats for pid 4242: %+v", got)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 processes, got %d", len(stats))
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -165,11 +166,162 @@ func (r *Registry) UpdateServicePID(name string, pid int) error {
 	now := time.Now()
 	svc.LastStart = &now
 	svc.LastStop = nil
+	svc.VerifiedAt = nil
 	svc.UpdatedAt = now
 
 	return r.save()
 }
 
+// RecordCrashRestart notes that a service is being started after an
+// unexpected exit. Entries older than window are pruned.
+func (r *Registry) RecordCrashRestart(name string, at time.Time, window time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	svc, exists := r.data.Services[name]
+	if !exists {
+		return fmt.Errorf("service %q not found", name)
+	}
+
+	kept := svc.CrashRestarts[:0]
+	for _, t := range svc.CrashRestarts {
+		if at.Sub(t) <= window {
+			kept = append(kept, t)
+		}
+	}
+	svc.CrashRestarts = append(kept, at)
+	svc.RestartCount++
+	svc.UpdatedAt = time.Now()
+	return r.save()
+}
+
+// SetRunPort records the port allocated for the service's current run.
+func (r *Registry) SetRunPort(name string, port int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	svc, exists := r.data.Services[name]
+	if !exists {
+		return fmt.Errorf("service %q not found", name)
+	}
+
+	svc.RunPort = port
+	svc.UpdatedAt = time.Now()
+	return r.save()
+}
+
+// RecordExit stores how the service's most recent run ended.
+func (r *Registry) RecordExit(name string, status *models.ExitStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	svc, exists := r.data.Services[name]
+	if !exists {
+		return fmt.Errorf("service %q not found", name)
+	}
+
+	svc.LastExit = status
+	svc.UpdatedAt = time.Now()
+	return r.save()
+}
+
+// RecordRun appends a run to the service's history, keeping the most recent
+// models.MaxRuns.
+func (r *Registry) RecordRun(name string, run models.RunRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	svc, exists := r.data.Services[name]
+	if !exists {
+		return fmt.Errorf("service %q not found", name)
+	}
+
+	svc.Runs = append(svc.Runs, run)
+	if extra := len(svc.Runs) - models.MaxRuns; extra > 0 {
+		svc.Runs = append([]models.RunRecord{}, svc.Runs[extra:]...)
+	}
+	svc.UpdatedAt = time.Now()
+	return r.save()
+}
+
+// FinishRun records how the run with pid ended. Runs that already have an
+// outcome keep it, so a deliberate stop is not overwritten by the exit that
+// follows it.
+func (r *Registry) FinishRun(name string, pid int, outcome string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	svc, exists := r.data.Services[name]
+	if !exists {
+		return fmt.Errorf("service %q not found", name)
+	}
+
+	for i := len(svc.Runs) - 1; i >= 0; i-- {
+		run := &svc.Runs[i]
+		if run.PID != pid {
+			continue
+		}
+		if run.EndedAt != nil {
+			return nil
+		}
+		run.EndedAt = &at
+		run.Outcome = outcome
+		svc.UpdatedAt = time.Now()
+		return r.save()
+	}
+	return nil
+}
+
+// SetServiceSchedule sets or, with an empty spec, clears the service's
+// schedule.
+func (r *Registry) SetServiceSchedule(name, spec string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	svc, exists := r.data.Services[name]
+	if !exists {
+		return fmt.Errorf("service %q not found", name)
+	}
+	now := time.Now()
+	svc.Schedule = spec
+	// The new schedule counts from now rather than from the last run.
+	svc.LastScheduled = &now
+	svc.UpdatedAt = now
+	return r.save()
+}
+
+// MarkServiceScheduled records that the service's schedule restarted it at
+// the given time.
+func (r *Registry) MarkServiceScheduled(name string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	svc, exists := r.data.Services[name]
+	if !exists {
+		return fmt.Errorf("service %q not found", name)
+	}
+	svc.LastScheduled = &at
+	return r.save()
+}
+
+// MarkPIDVerified records, in memory only, that the service's LastPID was
+// confirmed to still be its process at the given time. It does nothing if
+// the PID changed.
+func (r *Registry) MarkPIDVerified(name string, pid int, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	svc, exists := r.data.Services[name]
+	if !exists {
+		return fmt.Errorf("service %q not found", name)
+	}
+	if svc.LastPID == nil || *svc.LastPID != pid {
+		return nil
+	}
+	svc.VerifiedAt = &at
+	return nil
+}
+
 // ClearServicePID marks a managed service as not running.
 func (r *Registry) ClearServicePID(name string) error {
 	r.mu.Lock()
@@ -182,11 +334,208 @@ func (r *Registry) ClearServicePID(name string) error {
 
 	now := time.Now()
 	svc.LastPID = nil
+	svc.VerifiedAt = nil
+	svc.RunPort = 0
 	svc.LastStop = &now
 	svc.UpdatedAt = now
 	return r.save()
 }
 
+// SetLeftovers records the processes that outlived the service's last stop;
+// nil clears them.
+func (r *Registry) SetLeftovers(name string, leftovers []models.Leftover) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	svc, exists := r.data.Services[name]
+	if !exists {
+		return fmt.Errorf("service %q not found", name)
+	}
+	svc.Leftovers = leftovers
+	svc.UpdatedAt = time.Now()
+	return r.save()
+}
+
+// AddJob registers a new job.
+func (r *Registry) AddJob(job *models.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.data.Jobs[job.Name]; exists {
+		return fmt.Errorf("job %q already exists", job.Name)
+	}
+	if r.data.Jobs == nil {
+		r.data.Jobs = make(map[string]*models.Job)
+	}
+
+	now := time.Now()
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	r.data.Jobs[job.Name] = job
+
+	return r.save()
+}
+
+// GetJob retrieves a job by name
+func (r *Registry) GetJob(name string) *models.Job {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.data.Jobs[name]
+}
+
+// ListJobs returns all registered jobs
+func (r *Registry) ListJobs() []*models.Job {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	jobs := make([]*models.Job, 0, len(r.data.Jobs))
+	for _, job := range r.data.Jobs {
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+// RemoveJob removes a job from the registry
+func (r *Registry) RemoveJob(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.data.Jobs[name]; !exists {
+		return fmt.Errorf("job %q not found", name)
+	}
+
+	delete(r.data.Jobs, name)
+	return r.save()
+}
+
+// SetJobSchedule sets or, with an empty spec, clears the job's schedule.
+func (r *Registry) SetJobSchedule(name, spec string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, exists := r.data.Jobs[name]
+	if !exists {
+		return fmt.Errorf("job %q not found", name)
+	}
+	now := time.Now()
+	job.Schedule = spec
+	// The new schedule counts from now rather than from the last run.
+	job.LastScheduled = &now
+	job.UpdatedAt = now
+	return r.save()
+}
+
+// MarkJobScheduled records that the job's schedule started it at the given
+// time.
+func (r *Registry) MarkJobScheduled(name string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, exists := r.data.Jobs[name]
+	if !exists {
+		return fmt.Errorf("job %q not found", name)
+	}
+	job.LastScheduled = &at
+	return r.save()
+}
+
+// RecordJobRun appends a job run, keeping the most recent models.MaxJobRuns.
+func (r *Registry) RecordJobRun(run models.JobRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.data.JobRuns = append(r.data.JobRuns, run)
+	if extra := len(r.data.JobRuns) - models.MaxJobRuns; extra > 0 {
+		r.data.JobRuns = append([]models.JobRun{}, r.data.JobRuns[extra:]...)
+	}
+	return r.save()
+}
+
+// RecentJobRuns returns up to n job runs, newest first. An empty job name
+// matches runs of every job.
+func (r *Registry) RecentJobRuns(job string, n int) []models.JobRun {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var out []models.JobRun
+	for i := len(r.data.JobRuns) - 1; i >= 0 && len(out) < n; i-- {
+		if job == "" || r.data.JobRuns[i].Job == job {
+			out = append(out, r.data.JobRuns[i])
+		}
+	}
+	return out
+}
+
+// AddProject records a project and its root. A project already recorded
+// keeps its root.
+func (r *Registry) AddProject(name, root string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.data.Projects[name]; exists {
+		return nil
+	}
+	if r.data.Projects == nil {
+		r.data.Projects = make(map[string]*models.Project)
+	}
+	r.data.Projects[name] = &models.Project{Name: name, Root: root}
+	return r.save()
+}
+
+// GetProject retrieves a project by name
+func (r *Registry) GetProject(name string) *models.Project {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.data.Projects[name]
+}
+
+// ListProjects returns all recorded projects
+func (r *Registry) ListProjects() []*models.Project {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	projects := make([]*models.Project, 0, len(r.data.Projects))
+	for _, p := range r.data.Projects {
+		projects = append(projects, p)
+	}
+	return projects
+}
+
+// ProjectForDir returns the project whose root holds dir, the innermost
+// one when roots are nested, or nil.
+func (r *Registry) ProjectForDir(dir string) *models.Project {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	dir = filepath.Clean(dir)
+	var found *models.Project
+	for _, p := range r.data.Projects {
+		root := filepath.Clean(p.Root)
+		if dir != root && !strings.HasPrefix(dir, strings.TrimSuffix(root, string(filepath.Separator))+string(filepath.Separator)) {
+			continue
+		}
+		if found == nil || len(root) > len(filepath.Clean(found.Root)) {
+			found = p
+		}
+	}
+	return found
+}
+
+// RemoveProject forgets a project; its services stay registered.
+func (r *Registry) RemoveProject(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.data.Projects[name]; !exists {
+		return fmt.Errorf("project %q not found", name)
+	}
+
+	delete(r.data.Projects, name)
+	return r.save()
+}
+
 // save (internal) writes the registry without taking locks
 func (r *Registry) save() error {
 	dir := filepath.Dir(r.filePath)
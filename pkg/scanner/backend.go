@@ -0,0 +1,94 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// Scanner backends
+const (
+	BackendNative = "native"
+	BackendExec   = "exec"
+)
+
+// backend lists listening sockets and describes their processes.
+type backend interface {
+	name() string
+	listeners(ctx context.Context, includeUDP bool) ([]*models.ProcessRecord, error)
+	processes(ctx context.Context, pids []int) map[int]psInfo
+	cwds(ctx context.Context, pids []int) map[int]string
+}
+
+// SetBackend selects the scanning backend: "native" (the default where
+// available) or "exec", which shells out to lsof and ps.
+func (ps *ProcessScanner) SetBackend(name string) error {
+	switch name {
+	case "", BackendNative:
+		if native := nativeBackend(); native != nil {
+			ps.backend = native
+			return nil
+		}
+		if name == BackendNative {
+			return fmt.Errorf("native scanner is not available on this platform")
+		}
+		ps.backend = execBackend{}
+	case BackendExec:
+		ps.backend = execBackend{}
+	default:
+		return fmt.Errorf("unknown scanner backend %q (use native or exec)", name)
+	}
+	return nil
+}
+
+// Backend returns the name of the backend in use.
+func (ps *ProcessScanner) Backend() string {
+	return ps.backend.name()
+}
+
+// execBackend runs lsof and ps. It works wherever those tools exist and is
+// the fallback when no native backend is available.
+type execBackend struct{}
+
+func (execBackend) name() string { return BackendExec }
+
+func (execBackend) listeners(ctx context.Context, includeUDP bool) ([]*models.ProcessRecord, error) {
+	output, err := exec.CommandContext(ctx, "lsof", "-nP", "-iTCP", "-sTCP:LISTEN").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run lsof: %w", err)
+	}
+	records, err := parseLsofOutput(string(output))
+	if err != nil {
+		return records, err
+	}
+
+	if includeUDP {
+		// lsof exits 1 when nothing matches; any output is still usable.
+		output, _ := exec.CommandContext(ctx, "lsof", "-nP", "-iUDP").Output()
+		udp, _ := parseLsofOutput(string(output))
+		records = append(records, udp...)
+	}
+	return records, nil
+}
+
+func (execBackend) processes(ctx context.Context, pids []int) map[int]psInfo {
+	cmd := exec.CommandContext(ctx, "ps", "-o", "pid=,ppid=,user=,lstart=,command=", "-p", joinPIDs(pids))
+	// ps exits 1 when some PIDs are gone; the rest of the output is valid.
+	output, err := cmd.Output()
+	if err != nil && len(output) == 0 {
+		return map[int]psInfo{}
+	}
+	return parsePSOutput(string(output))
+}
+
+func (execBackend) cwds(ctx context.Context, pids []int) map[int]string {
+	ctx, cancel := context.WithTimeout(ctx, 1500*time.Millisecond)
+	defer cancel()
+
+	// lsof exits 1 when any PID has no visible cwd; the output is still usable.
+	output, _ := exec.CommandContext(ctx, "lsof", "-a", "-p", joinPIDs(pids), "-d", "cwd", "-Fn").Output()
+	return parseLsofCWDs(string(output))
+}
@@ -0,0 +1,261 @@
+//go:build darwin && cgo
+
+package scanner
+
+/*
+#include <libproc.h>
+#include <sys/proc_info.h>
+#include <sys/sysctl.h>
+#include <netinet/in.h>
+#include <arpa/inet.h>
+#include <stdlib.h>
+#include <string.h>
+
+typedef struct {
+	int  pid;
+	int  port;
+	int  udp;
+	char addr[INET6_ADDRSTRLEN];
+} devpt_listener;
+
+// devpt_listeners fills out with TCP listeners (and unconnected, bound UDP
+// sockets when include_udp is set) of every process we may inspect.
+static int devpt_listeners(devpt_listener *out, int max, int include_udp) {
+	int bytes = proc_listpids(PROC_ALL_PIDS, 0, NULL, 0);
+	if (bytes <= 0) {
+		return -1;
+	}
+	pid_t *pids = malloc(bytes);
+	if (pids == NULL) {
+		return -1;
+	}
+	bytes = proc_listpids(PROC_ALL_PIDS, 0, pids, bytes);
+	int npids = bytes / (int)sizeof(pid_t);
+	int found = 0;
+
+	for (int i = 0; i < npids && found < max; i++) {
+		pid_t pid = pids[i];
+		if (pid <= 0) {
+			continue;
+		}
+		int size = proc_pidinfo(pid, PROC_PIDLISTFDS, 0, NULL, 0);
+		if (size <= 0) {
+			continue;
+		}
+		struct proc_fdinfo *fds = malloc(size);
+		if (fds == NULL) {
+			continue;
+		}
+		size = proc_pidinfo(pid, PROC_PIDLISTFDS, 0, fds, size);
+		int nfds = size / (int)PROC_PIDLISTFD_SIZE;
+
+		for (int j = 0; j < nfds && found < max; j++) {
+			if (fds[j].proc_fdtype != PROX_FDTYPE_SOCKET) {
+				continue;
+			}
+			struct socket_fdinfo si;
+			if (proc_pidfdinfo(pid, fds[j].proc_fd, PROC_PIDFDSOCKETINFO, &si, PROC_PIDFDSOCKETINFO_SIZE) < (int)PROC_PIDFDSOCKETINFO_SIZE) {
+				continue;
+			}
+			struct in_sockinfo *in = NULL;
+			int udp = 0;
+			if (si.psi.soi_kind == SOCKINFO_TCP) {
+				if (si.psi.soi_proto.pri_tcp.tcpsi_state != TSI_S_LISTEN) {
+					continue;
+				}
+				in = &si.psi.soi_proto.pri_tcp.tcpsi_ini;
+			} else if (include_udp && si.psi.soi_kind == SOCKINFO_IN && si.psi.soi_protocol == IPPROTO_UDP) {
+				in = &si.psi.soi_proto.pri_in;
+				if (in->insi_fport != 0) {
+					continue; // connected
+				}
+				udp = 1;
+			} else {
+				continue;
+			}
+
+			int port = ntohs((uint16_t)in->insi_lport);
+			if (port == 0) {
+				continue;
+			}
+			devpt_listener *l = &out[found];
+			l->pid = pid;
+			l->port = port;
+			l->udp = udp;
+			if (in->insi_vflag & INI_IPV4) {
+				inet_ntop(AF_INET, &in->insi_laddr.ina_46.i46a_addr4, l->addr, sizeof(l->addr));
+			} else {
+				inet_ntop(AF_INET6, &in->insi_laddr.ina_6, l->addr, sizeof(l->addr));
+			}
+			found++;
+		}
+		free(fds);
+	}
+	free(pids);
+	return found;
+}
+
+// devpt_bsdinfo reports the parent, owner and start time of pid.
+static int devpt_bsdinfo(int pid, int *ppid, unsigned int *uid, long long *start_sec) {
+	struct proc_bsdinfo info;
+	if (proc_pidinfo(pid, PROC_PIDTBSDINFO, 0, &info, PROC_PIDTBSDINFO_SIZE) < (int)PROC_PIDTBSDINFO_SIZE) {
+		return -1;
+	}
+	*ppid = (int)info.pbi_ppid;
+	*uid = info.pbi_uid;
+	*start_sec = (long long)info.pbi_start_tvsec;
+	return 0;
+}
+
+// devpt_procargs writes pid's argv, joined with spaces, to buf.
+static int devpt_procargs(int pid, char *buf, int size) {
+	int mib[3] = {CTL_KERN, KERN_PROCARGS2, pid};
+	int argmax = 0;
+	size_t len = sizeof(argmax);
+	int argmax_mib[2] = {CTL_KERN, KERN_ARGMAX};
+	if (sysctl(argmax_mib, 2, &argmax, &len, NULL, 0) != 0 || argmax <= 0) {
+		return -1;
+	}
+	char *raw = malloc(argmax);
+	if (raw == NULL) {
+		return -1;
+	}
+	len = (size_t)argmax;
+	if (sysctl(mib, 3, raw, &len, NULL, 0) != 0 || len < sizeof(int)) {
+		free(raw);
+		return -1;
+	}
+
+	int argc;
+	memcpy(&argc, raw, sizeof(argc));
+	char *p = raw + sizeof(argc);
+	char *end = raw + len;
+	while (p < end && *p != '\0') p++; // executable path
+	while (p < end && *p == '\0') p++; // padding
+
+	int n = 0;
+	for (int i = 0; i < argc && p < end; i++) {
+		size_t arglen = strnlen(p, end - p);
+		if (n > 0 && n < size - 1) {
+			buf[n++] = ' ';
+		}
+		for (size_t k = 0; k < arglen && n < size - 1; k++) {
+			buf[n++] = p[k];
+		}
+		p += arglen + 1;
+	}
+	buf[n] = '\0';
+	free(raw);
+	return n;
+}
+
+// devpt_cwd writes pid's current directory to buf.
+static int devpt_cwd(int pid, char *buf, int size) {
+	struct proc_vnodepathinfo info;
+	if (proc_pidinfo(pid, PROC_PIDVNODEPATHINFO, 0, &info, PROC_PIDVNODEPATHINFO_SIZE) < (int)PROC_PIDVNODEPATHINFO_SIZE) {
+		return -1;
+	}
+	strlcpy(buf, info.pvi_cdir.vip_path, size);
+	return (int)strlen(buf);
+}
+*/
+import "C"
+
+import (
+	"context"
+	"fmt"
+	"os/user"
+	"strconv"
+	"time"
+	"unsafe"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// libprocBackend reads sockets and processes through libproc, avoiding an
+// lsof and ps spawn on every refresh.
+type libprocBackend struct{}
+
+func nativeBackend() backend { return libprocBackend{} }
+
+func (libprocBackend) name() string { return BackendNative }
+
+// maxListeners bounds one scan; no dev machine comes close.
+const maxListeners = 8192
+
+func (libprocBackend) listeners(ctx context.Context, includeUDP bool) ([]*models.ProcessRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	buf := make([]C.devpt_listener, maxListeners)
+	udp := C.int(0)
+	if includeUDP {
+		udp = 1
+	}
+	n := int(C.devpt_listeners(&buf[0], C.int(len(buf)), udp))
+	if n < 0 {
+		return nil, fmt.Errorf("failed to list processes via libproc")
+	}
+
+	records := make([]*models.ProcessRecord, 0, n)
+	for _, l := range buf[:n] {
+		host := C.GoString(&l.addr[0])
+		if host == "0.0.0.0" || host == "::" {
+			host = "*" // match lsof
+		}
+		protocol := "tcp"
+		if l.udp != 0 {
+			protocol = "udp"
+		}
+		records = append(records, &models.ProcessRecord{
+			PID:         int(l.pid),
+			Port:        int(l.port),
+			Protocol:    protocol,
+			BindAddress: host,
+		})
+	}
+	return dedupeListeners(records), nil
+}
+
+func (libprocBackend) processes(ctx context.Context, pids []int) map[int]psInfo {
+	infos := make(map[int]psInfo, len(pids))
+	args := make([]byte, 16*1024)
+	for _, pid := range pids {
+		if ctx.Err() != nil {
+			break
+		}
+		var ppid C.int
+		var uid C.uint
+		var startSec C.longlong
+		if C.devpt_bsdinfo(C.int(pid), &ppid, &uid, &startSec) != 0 {
+			continue
+		}
+		info := psInfo{
+			ppid:  int(ppid),
+			user:  strconv.Itoa(int(uid)),
+			start: time.Unix(int64(startSec), 0),
+		}
+		if u, err := user.LookupId(info.user); err == nil {
+			info.user = u.Username
+		}
+		if n := C.devpt_procargs(C.int(pid), (*C.char)(unsafe.Pointer(&args[0])), C.int(len(args))); n > 0 {
+			info.command = string(args[:n])
+		}
+		infos[pid] = info
+	}
+	return infos
+}
+
+func (libprocBackend) cwds(ctx context.Context, pids []int) map[int]string {
+	cwds := make(map[int]string, len(pids))
+	buf := make([]byte, 4096)
+	for _, pid := range pids {
+		if ctx.Err() != nil {
+			break
+		}
+		if n := C.devpt_cwd(C.int(pid), (*C.char)(unsafe.Pointer(&buf[0])), C.int(len(buf))); n > 0 {
+			cwds[pid] = string(buf[:n])
+		}
+	}
+	return cwds
+}
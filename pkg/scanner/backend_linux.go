@@ -0,0 +1,296 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// procBackend reads sockets and processes straight from /proc.
+type procBackend struct {
+	root string // normally "/proc"
+}
+
+func nativeBackend() backend {
+	if _, err := os.Stat("/proc/net/tcp"); err != nil {
+		return nil
+	}
+	return procBackend{root: "/proc"}
+}
+
+func (procBackend) name() string { return BackendNative }
+
+// Socket states in /proc/net/{tcp,udp}
+const (
+	procTCPListen = "0A"
+	procUDPClose  = "07"
+)
+
+func (b procBackend) listeners(ctx context.Context, includeUDP bool) ([]*models.ProcessRecord, error) {
+	type table struct{ file, protocol, state string }
+	tables := []table{
+		{"tcp", "tcp", procTCPListen},
+		{"tcp6", "tcp", procTCPListen},
+	}
+	if includeUDP {
+		tables = append(tables, table{"udp", "udp", procUDPClose}, table{"udp6", "udp", procUDPClose})
+	}
+
+	sockets := make(map[uint64]procSocket)
+	for _, t := range tables {
+		f, err := os.Open(filepath.Join(b.root, "net", t.file))
+		if err != nil {
+			if t.file == "tcp" {
+				return nil, fmt.Errorf("failed to read socket table: %w", err)
+			}
+			continue // IPv6 or UDP may be unavailable
+		}
+		for inode, sock := range parseProcNet(f, t.state) {
+			sock.protocol = t.protocol
+			sockets[inode] = sock
+		}
+		f.Close()
+	}
+	if len(sockets) == 0 {
+		return []*models.ProcessRecord{}, nil
+	}
+
+	owners, err := b.socketOwners(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var records []*models.ProcessRecord
+	for pid, inodes := range owners {
+		for _, inode := range inodes {
+			sock, ok := sockets[inode]
+			if !ok {
+				continue
+			}
+			records = append(records, &models.ProcessRecord{
+				PID:         pid,
+				Port:        sock.port,
+				Protocol:    sock.protocol,
+				BindAddress: sock.host,
+			})
+		}
+	}
+	return dedupeListeners(records), nil
+}
+
+// procSocket is a listening socket from /proc/net.
+type procSocket struct {
+	host     string
+	port     int
+	protocol string
+}
+
+// parseProcNet returns sockets in state from a /proc/net/{tcp,udp}[6]
+// table, keyed by inode.
+func parseProcNet(f *os.File, state string) map[uint64]procSocket {
+	sockets := make(map[uint64]procSocket)
+	sc := bufio.NewScanner(f)
+	sc.Scan() // header
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 10 || fields[3] != state {
+			continue
+		}
+		host, port, err := parseProcAddress(fields[1])
+		if err != nil || port == 0 {
+			continue
+		}
+		if state == procUDPClose {
+			// Connected UDP sockets have a remote port.
+			if _, remote, err := parseProcAddress(fields[2]); err != nil || remote != 0 {
+				continue
+			}
+		}
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil || inode == 0 {
+			continue
+		}
+		sockets[inode] = procSocket{host: host, port: port}
+	}
+	return sockets
+}
+
+// parseProcAddress decodes "0100007F:0BB8" style addresses. The address is
+// stored as host-order 32-bit words, which is little-endian on every
+// platform devpt runs on. Wildcards are reported as "*", like lsof.
+func parseProcAddress(s string) (string, int, error) {
+	addrHex, portHex, ok := strings.Cut(s, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("malformed address %q", s)
+	}
+	port, err := strconv.ParseUint(portHex, 16, 16)
+	if err != nil {
+		return "", 0, err
+	}
+	raw, err := hex.DecodeString(addrHex)
+	if err != nil || (len(raw) != 4 && len(raw) != 16) {
+		return "", 0, fmt.Errorf("malformed address %q", s)
+	}
+	ip := make(net.IP, len(raw))
+	for i := 0; i < len(raw); i += 4 {
+		ip[i], ip[i+1], ip[i+2], ip[i+3] = raw[i+3], raw[i+2], raw[i+1], raw[i]
+	}
+	if ip.IsUnspecified() {
+		return "*", int(port), nil
+	}
+	return ip.String(), int(port), nil
+}
+
+// socketOwners maps each readable PID to the socket inodes it holds open.
+// Walking every process is the slow part of a scan, so it stops early once
+// ctx is done.
+func (b procBackend) socketOwners(ctx context.Context) (map[int][]uint64, error) {
+	owners := make(map[int][]uint64)
+	entries, err := os.ReadDir(b.root)
+	if err != nil {
+		return owners, nil
+	}
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join(b.root, entry.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue // other users' processes
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			if inode, err := strconv.ParseUint(strings.TrimSuffix(link[len("socket:["):], "]"), 10, 64); err == nil {
+				owners[pid] = append(owners[pid], inode)
+			}
+		}
+	}
+	return owners, nil
+}
+
+func (b procBackend) processes(ctx context.Context, pids []int) map[int]psInfo {
+	infos := make(map[int]psInfo, len(pids))
+	boot := b.bootTime()
+	for _, pid := range pids {
+		if ctx.Err() != nil {
+			break
+		}
+		dir := filepath.Join(b.root, strconv.Itoa(pid))
+		stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+		if err != nil {
+			continue
+		}
+		ppid, startTicks, err := parseProcStat(string(stat))
+		if err != nil {
+			continue
+		}
+		info := psInfo{ppid: ppid}
+		if !boot.IsZero() {
+			info.start = boot.Add(time.Duration(startTicks) * time.Second / clockTicks)
+		}
+		if cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+			info.command = strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " "))
+		}
+		if info.command == "" {
+			// Kernel threads and zombies have no cmdline; fall back to comm.
+			if comm, err := os.ReadFile(filepath.Join(dir, "comm")); err == nil {
+				info.command = strings.TrimSpace(string(comm))
+			}
+		}
+		if uid := procUID(filepath.Join(dir, "status")); uid != "" {
+			info.user = uid
+			if u, err := user.LookupId(uid); err == nil {
+				info.user = u.Username
+			}
+		}
+		infos[pid] = info
+	}
+	return infos
+}
+
+// clockTicks is USER_HZ, the unit of start times in /proc/<pid>/stat. It is
+// 100 on all mainstream Linux architectures.
+const clockTicks = 100
+
+// parseProcStat extracts the parent PID and start time (in clock ticks since
+// boot) from /proc/<pid>/stat. The command name may contain spaces and
+// parentheses, so fields are counted from the last ")".
+func parseProcStat(stat string) (ppid int, startTicks uint64, err error) {
+	end := strings.LastIndex(stat, ")")
+	if end < 0 {
+		return 0, 0, fmt.Errorf("malformed stat")
+	}
+	fields := strings.Fields(stat[end+1:])
+	// fields[0] is state (field 3); ppid is field 4, starttime field 22.
+	if len(fields) < 20 {
+		return 0, 0, fmt.Errorf("malformed stat")
+	}
+	if ppid, err = strconv.Atoi(fields[1]); err != nil {
+		return 0, 0, err
+	}
+	if startTicks, err = strconv.ParseUint(fields[19], 10, 64); err != nil {
+		return 0, 0, err
+	}
+	return ppid, startTicks, nil
+}
+
+// procUID returns the real UID from a /proc/<pid>/status file.
+func procUID(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if rest, ok := strings.CutPrefix(line, "Uid:"); ok {
+			if fields := strings.Fields(rest); len(fields) > 0 {
+				return fields[0]
+			}
+		}
+	}
+	return ""
+}
+
+func (b procBackend) bootTime() time.Time {
+	content, err := os.ReadFile(filepath.Join(b.root, "stat"))
+	if err != nil {
+		return time.Time{}
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if rest, ok := strings.CutPrefix(line, "btime "); ok {
+			if secs, err := strconv.ParseInt(strings.TrimSpace(rest), 10, 64); err == nil {
+				return time.Unix(secs, 0)
+			}
+		}
+	}
+	return time.Time{}
+}
+
+func (b procBackend) cwds(ctx context.Context, pids []int) map[int]string {
+	cwds := make(map[int]string, len(pids))
+	for _, pid := range pids {
+		if ctx.Err() != nil {
+			break
+		}
+		if cwd, err := os.Readlink(filepath.Join(b.root, strconv.Itoa(pid), "cwd")); err == nil {
+			cwds[pid] = cwd
+		}
+	}
+	return cwds
+}
@@ -0,0 +1,71 @@
+package scanner
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+)
+
+func TestParseProcAddress(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		in   string
+		host string
+		port int
+	}{
+		{in: "0100007F:0BB8", host: "127.0.0.1", port: 3000},
+		{in: "00000000:1F90", host: "*", port: 8080},
+		{in: "00000000000000000000000001000000:1435", host: "::1", port: 5173},
+		{in: "00000000000000000000000000000000:2328", host: "*", port: 9000},
+		{in: "0000000000000000FFFF00000100007F:0FA0", host: "127.0.0.1", port: 4000},
+	}
+	for _, tc := range cases {
+		host, port, err := parseProcAddress(tc.in)
+		if err != nil || host != tc.host || port != tc.port {
+			t.Fatalf("parseProcAddress(%q) = %q, %d, %v; want %q, %d", tc.in, host, port, err, tc.host, tc.port)
+		}
+	}
+}
+
+func TestParseProcStat(t *testing.T) {
+	t.Parallel()
+
+	stat := "4242 (node (dev) srv) S 4100 4242 4100 0 -1 4194304 1 0 0 0 0 0 0 0 20 0 11 0 987654 1000 100"
+	ppid, start, err := parseProcStat(stat)
+	if err != nil || ppid != 4100 || start != 987654 {
+		t.Fatalf("parseProcStat = %d, %d, %v; want 4100, 987654", ppid, start, err)
+	}
+}
+
+func TestProcBackendFindsOwnListener(t *testing.T) {
+	native := nativeBackend()
+	if native == nil {
+		t.Skip("/proc not available")
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	records, err := native.listeners(context.Background(), false)
+	if err != nil {
+		t.Fatalf("listeners: %v", err)
+	}
+	for _, r := range records {
+		if r.PID == os.Getpid() && r.Port == port {
+			if r.BindAddress != "127.0.0.1" || r.Protocol != "tcp" {
+				t.Fatalf("unexpected record %+v", r)
+			}
+			info := native.processes(context.Background(), []int{r.PID})[r.PID]
+			if info.command == "" || info.ppid != os.Getppid() || info.start.IsZero() {
+				t.Fatalf("unexpected process info %+v", info)
+			}
+			return
+		}
+	}
+	t.Fatalf("listener on port %d not found in %d records", port, len(records))
+}
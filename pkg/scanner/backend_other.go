@@ -0,0 +1,6 @@
+//go:build !linux && !(darwin && cgo)
+
+package scanner
+
+// nativeBackend returns nil: this platform only has the lsof/ps backend.
+func nativeBackend() backend { return nil }
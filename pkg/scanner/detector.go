@@ -3,37 +3,80 @@ package scanner
 import (
 	"fmt"
 	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/devports/devpt/pkg/models"
 )
 
+// maxAncestryDepth bounds the walk from a server up its process tree.
+const maxAncestryDepth = 16
+
+// ancestryCacheTTL is how long a looked-up parent is trusted; PIDs are
+// reused, so entries can't be kept forever.
+const ancestryCacheTTL = 30 * time.Second
+
+// DefaultAgents are the built-in agent signatures. User signatures from the
+// config are checked first.
+var DefaultAgents = []models.AgentSignature{
+	{Name: "opencode", Processes: []string{"opencode"}, EnvPrefixes: []string{"OPENCODE_"}},
+	{Name: "cursor", Processes: []string{"cursor"}, EnvPrefixes: []string{"CURSOR_"}},
+	{Name: "claude", Processes: []string{"claude"}, EnvPrefixes: []string{"CLAUDE_"}},
+	{Name: "gemini", Processes: []string{"gemini"}, EnvPrefixes: []string{"GEMINI_"}},
+	{Name: "copilot", Processes: []string{"copilot"}, EnvPrefixes: []string{"COPILOT_"}},
+	{Name: "unknown", EnvPrefixes: []string{"AI_AGENT_"}},
+}
+
 // AgentDetector identifies servers likely started by AI agents
 type AgentDetector struct {
-	knownAgents map[string]string
+	agents []models.AgentSignature
+
+	// parentOf looks up a process's parent PID, name and arguments;
+	// replaced in tests.
+	parentOf func(pid int) (parentEntry, bool)
+	mu       sync.Mutex
+	parents  map[int]parentEntry
 }
 
-// NewAgentDetector creates a new agent detector
-func NewAgentDetector() *AgentDetector {
+type parentEntry struct {
+	ppid    int
+	name    string
+	args    string
+	fetched time.Time
+}
+
+// NewAgentDetector creates a detector that knows the default agents plus
+// the given signatures.
+func NewAgentDetector(extra []models.AgentSignature) *AgentDetector {
+	agents := make([]models.AgentSignature, 0, len(extra)+len(DefaultAgents))
+	for _, sig := range append(append([]models.AgentSignature{}, extra...), DefaultAgents...) {
+		agents = append(agents, models.AgentSignature{
+			Name:        sig.Name,
+			Processes:   lowerAll(sig.Processes),
+			Commands:    lowerAll(sig.Commands),
+			EnvPrefixes: sig.EnvPrefixes,
+		})
+	}
 	return &AgentDetector{
-		knownAgents: map[string]string{
-			"opencode": "opencode",
-			"cursor":   "cursor",
-			"claude":   "claude",
-			"gemini":   "gemini",
-			"copilot":  "copilot",
-		},
+		agents:   agents,
+		parentOf: psParent,
+		parents:  make(map[int]parentEntry),
 	}
 }
 
 // DetectAgent analyzes a process and returns an AgentTag if detected
 func (ad *AgentDetector) DetectAgent(record *models.ProcessRecord) *models.AgentTag {
-	// Check parent process name
-	if agentName := ad.checkParentProcess(record.PPID); agentName != "" {
+	// Check the names of the process's ancestors
+	if agentName, pid, name := ad.checkAncestors(record.PID, record.PPID); agentName != "" {
 		return &models.AgentTag{
-			Source:     models.SourceAgent,
-			AgentName:  agentName,
-			Confidence: models.ConfidenceHigh,
+			Source:       models.SourceAgent,
+			AgentName:    agentName,
+			Confidence:   models.ConfidenceHigh,
+			AncestorName: name,
+			AncestorPID:  pid,
 		}
 	}
 
@@ -43,10 +86,10 @@ func (ad *AgentDetector) DetectAgent(record *models.ProcessRecord) *models.Agent
 	}
 
 	// Check environment variables set by agents
-	if ad.hasAgentEnvVars(record.PID) {
+	if agentName := ad.agentFromEnv(record.PID); agentName != "" {
 		return &models.AgentTag{
 			Source:     models.SourceAgent,
-			AgentName:  "unknown",
+			AgentName:  agentName,
 			Confidence: models.ConfidenceMedium,
 		}
 	}
@@ -63,24 +106,88 @@ func (ad *AgentDetector) DetectAgent(record *models.ProcessRecord) *models.Agent
 	return nil
 }
 
-// checkParentProcess checks if parent process is a known agent
-func (ad *AgentDetector) checkParentProcess(ppid int) string {
-	cmd := exec.Command("ps", "-p", fmt.Sprintf("%d", ppid), "-o", "comm=")
-	output, err := cmd.Output()
-	if err != nil {
-		return ""
+// checkAncestors walks up from pid (whose parent is ppid, if known) and
+// returns the agent, PID and process name of the nearest ancestor that is a
+// known agent. Agents rarely start servers directly; the chain is usually
+// agent → shell → npm → node.
+func (ad *AgentDetector) checkAncestors(pid, ppid int) (string, int, string) {
+	if ppid <= 0 {
+		p, _ := ad.parent(pid)
+		ppid = p.ppid
 	}
-
-	parentName := strings.TrimSpace(string(output))
-	for key, agentName := range ad.knownAgents {
-		if strings.Contains(parentName, key) {
-			return agentName
+	seen := map[int]bool{pid: true}
+	for depth := 0; depth < maxAncestryDepth && ppid > 1 && !seen[ppid]; depth++ {
+		seen[ppid] = true
+		p, ok := ad.parent(ppid)
+		if !ok {
+			return "", 0, ""
 		}
+		if agentName := ad.matchAgent(p.name, p.args); agentName != "" {
+			return agentName, ppid, p.name
+		}
+		ppid = p.ppid
 	}
+	return "", 0, ""
+}
 
+// AncestorAgent returns the known agent among the ancestors of the process
+// pid (whose parent is ppid), or "".
+func (ad *AgentDetector) AncestorAgent(pid, ppid int) string {
+	agentName, _, _ := ad.checkAncestors(pid, ppid)
+	return agentName
+}
+
+// matchAgent returns the agent a process belongs to, by its name or, for
+// agents that run under an interpreter (e.g. aider under python), by its
+// arguments.
+func (ad *AgentDetector) matchAgent(name, args string) string {
+	name = strings.ToLower(filepath.Base(name))
+	args = strings.ToLower(args)
+	for _, sig := range ad.agents {
+		if containsAny(name, sig.Processes) || containsAny(args, sig.Commands) {
+			return sig.Name
+		}
+	}
 	return ""
 }
 
+// parent returns pid's parent PID, name and arguments, cached for
+// ancestryCacheTTL since servers started together share most ancestors.
+func (ad *AgentDetector) parent(pid int) (parentEntry, bool) {
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+	if e, ok := ad.parents[pid]; ok && time.Since(e.fetched) < ancestryCacheTTL {
+		return e, true
+	}
+	e, ok := ad.parentOf(pid)
+	if !ok {
+		delete(ad.parents, pid)
+		return parentEntry{}, false
+	}
+	e.fetched = time.Now()
+	ad.parents[pid] = e
+	return e, true
+}
+
+// psParent looks up a process's parent PID and command line with ps. The
+// name is the first word of the command line, e.g. "node" or "-zsh".
+func psParent(pid int) (parentEntry, bool) {
+	cmd := exec.Command("ps", "-p", fmt.Sprintf("%d", pid), "-o", "ppid=,args=")
+	output, err := cmd.Output()
+	if err != nil {
+		return parentEntry{}, false
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) < 2 {
+		return parentEntry{}, false
+	}
+	ppid, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return parentEntry{}, false
+	}
+	return parentEntry{ppid: ppid, name: fields[1], args: commandAfterFields(string(output), 1)}, true
+}
+
 // hasTTY checks if process has attached TTY
 func (ad *AgentDetector) hasTTY(pid int) bool {
 	cmd := exec.Command("ps", "-p", fmt.Sprintf("%d", pid), "-o", "tty=")
@@ -93,32 +200,26 @@ func (ad *AgentDetector) hasTTY(pid int) bool {
 	return tty != "" && tty != "?"
 }
 
-// hasAgentEnvVars checks for environment variables commonly set by agents
-func (ad *AgentDetector) hasAgentEnvVars(pid int) bool {
+// agentFromEnv returns the agent whose environment variable prefix appears
+// in the process's environment
+func (ad *AgentDetector) agentFromEnv(pid int) string {
 	// Try to read environment from /proc or ps
 	cmd := exec.Command("ps", "-p", fmt.Sprintf("%d", pid), "-e", "-o", "environ=")
 	output, err := cmd.Output()
 	if err != nil {
-		return false
+		return ""
 	}
 
 	env := string(output)
-	agentEnvVars := []string{
-		"OPENCODE_",
-		"CURSOR_",
-		"CLAUDE_",
-		"GEMINI_",
-		"COPILOT_",
-		"AI_AGENT_",
-	}
-
-	for _, envVar := range agentEnvVars {
-		if strings.Contains(env, envVar) {
-			return true
+	for _, sig := range ad.agents {
+		for _, prefix := range sig.EnvPrefixes {
+			if strings.Contains(env, prefix) {
+				return sig.Name
+			}
 		}
 	}
 
-	return false
+	return ""
 }
 
 // isLikelyAgentProcess checks if process has typical agent characteristics
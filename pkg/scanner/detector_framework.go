@@ -1,280 +1,621 @@
 package scanner
 
 import (
-"os"
-"os/exec"
-"path/filepath"
-"strings"
+	"bufio"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
 )
 
 // FrameworkInfo holds detected framework/language information
 type FrameworkInfo struct {
-Language    string // "Node", "Python", "Go", "Ruby", "PHP", "Java", "Rust", etc.
-Framework   string // "Express", "Django", "Gin", "Rails", "Laravel", etc.
-Version     string // e.g., "18.12.0", "3.9.1"
-PackageJson string // Path to package.json if found
-Confidence  string // "high", "medium", "low"
-}
-
-// DetectFramework analyzes a process to identify its framework and language
+	Language         string // "Node.js", "Python", "Go", "Ruby", "PHP", "Java", "Rust", etc.
+	Framework        string // "Express", "Django", "Gin", "Rails", "Laravel", etc.
+	FrameworkVersion string // e.g. "14.2.3" for Next.js
+	Version          string // runtime version, e.g. "20.11.0", "3.12"
+	PackageJson      string // Path to package.json if found
+	Confidence       string // "high", "medium", "low"
+}
+
+// DetectFramework analyzes a process to identify its framework and language.
+// The language comes from the command line; the framework and versions are
+// read from the nearest package manifest (package.json, go.mod,
+// pyproject.toml, Gemfile, Cargo.toml) and version files (.nvmrc,
+// .tool-versions, ...) in cwd or its parents.
 func DetectFramework(pid int, command string, cwd string) *FrameworkInfo {
-info := &FrameworkInfo{Confidence: "low"}
-
-// Try to detect from command line first
-cmdLower := strings.ToLower(command)
-
-// Node.js detection
-if strings.Contains(cmdLower, "node") || strings.Contains(cmdLower, "npm") || strings.Contains(cmdLower, "yarn") {
-info.Language = "Node.js"
-info.Framework = detectNodeFramework(command, cwd)
-info.Version = extractNodeVersion(pid)
-info.Confidence = "high"
-return info
-}
-
-// Python detection
-if strings.Contains(cmdLower, "python") {
-info.Language = "Python"
-info.Framework = detectPythonFramework(command, cwd)
-info.Version = extractPythonVersion(pid)
-info.Confidence = "high"
-return info
-}
-
-// Go detection
-if strings.Contains(cmdLower, "go run") {
-info.Language = "Go"
-info.Framework = "Go (custom)"
-info.Version = extractGoVersion()
-info.Confidence = "high"
-return info
-}
-
-// Ruby detection
-if strings.Contains(cmdLower, "ruby") || strings.Contains(cmdLower, "rails") {
-info.Language = "Ruby"
-info.Framework = detectRubyFramework(command)
-info.Version = extractRubyVersion(pid)
-info.Confidence = "high"
-return info
-}
-
-// Java detection
-if strings.Contains(cmdLower, "java") {
-info.Language = "Java"
-info.Framework = detectJavaFramework(command)
-info.Version = extractJavaVersion(pid)
-info.Confidence = "medium"
-return info
-}
-
-// PHP detection
-if strings.Contains(cmdLower, "php") {
-info.Language = "PHP"
-info.Framework = "PHP"
-info.Version = extractPHPVersion(pid)
-info.Confidence = "high"
-return info
-}
-
-// Rust detection
-if strings.Contains(cmdLower, "cargo") {
-info.Language = "Rust"
-info.Framework = "Rust (custom)"
-info.Version = extractRustVersion()
-info.Confidence = "high"
-return info
-}
-
-// If we couldn't identify, set to unknown
-info.Language = "Unknown"
-info.Confidence = "low"
-return info
-}
-
-func detectNodeFramework(command string, cwd string) string {
-cmdLower := strings.ToLower(command)
-
-// Check for known frameworks in command
-if strings.Contains(cmdLower, "express") {
-return "Express"
-}
-if strings.Contains(cmdLower, "next") {
-return "Next.js"
-}
-if strings.Contains(cmdLower, "nuxt") {
-return "Nuxt"
-}
-if strings.Contains(cmdLower, "vue") {
-return "Vue"
-}
-if strings.Contains(cmdLower, "react") {
-return "React"
-}
-if strings.Contains(cmdLower, "gatsby") {
-return "Gatsby"
-}
-if strings.Contains(cmdLower, "vite") {
-return "Vite"
-}
-if strings.Contains(cmdLower, "webpack") {
-return "Webpack"
-}
-
-// Check package.json for dependencies
-pkgPath := filepath.Join(cwd, "package.json")
-if data, err := os.ReadFile(pkgPath); err == nil {
-content := string(data)
-if strings.Contains(content, "express") {
-return "Express"
-}
-if strings.Contains(content, "next") {
-return "Next.js"
-}
-if strings.Contains(content, "nuxt") {
-return "Nuxt"
-}
-if strings.Contains(content, "fastify") {
-return "Fastify"
-}
-if strings.Contains(content, "koa") {
-return "Koa"
-}
-if strings.Contains(content, "hapi") {
-return "Hapi"
-}
-}
-
-return "Node.js (generic)"
-}
-
-func detectPythonFramework(command string, cwd string) string {
-cmdLower := strings.ToLower(command)
-
-// Check for known frameworks
-if strings.Contains(cmdLower, "django") || strings.Contains(cmdLower, "manage.py") {
-return "Django"
-}
-if strings.Contains(cmdLower, "flask") {
-return "Flask"
-}
-if strings.Contains(cmdLower, "fastapi") {
-return "FastAPI"
-}
-if strings.Contains(cmdLower, "uvicorn") {
-return "FastAPI (uvicorn)"
-}
-if strings.Contains(cmdLower, "gunicorn") {
-return "Gunicorn"
-}
-if strings.Contains(cmdLower, "pyramid") {
-return "Pyramid"
-}
-if strings.Contains(cmdLower, "starlette") {
-return "Starlette"
-}
-
-// Check for requirements.txt
-if _, err := os.Stat(filepath.Join(cwd, "requirements.txt")); err == nil {
-if data, err := os.ReadFile(filepath.Join(cwd, "requirements.txt")); err == nil {
-content := string(data)
-if strings.Contains(content, "django") {
-return "Django"
-}
-if strings.Contains(content, "flask") {
-return "Flask"
-}
-if strings.Contains(content, "fastapi") {
-return "FastAPI"
-}
-}
-}
-
-return "Python (generic)"
-}
-
-func detectRubyFramework(command string) string {
-cmdLower := strings.ToLower(command)
-
-if strings.Contains(cmdLower, "rails") {
-return "Rails"
-}
-if strings.Contains(cmdLower, "sinatra") {
-return "Sinatra"
-}
-if strings.Contains(cmdLower, "hanami") {
-return "Hanami"
-}
-
-return "Ruby (generic)"
+	info := &FrameworkInfo{Confidence: "low"}
+	cmdLower := strings.ToLower(command)
+	dir := findManifestDir(cwd)
+
+	switch {
+	case strings.Contains(cmdLower, "node") || strings.Contains(cmdLower, "npm") || strings.Contains(cmdLower, "yarn") ||
+		strings.Contains(cmdLower, "pnpm") || commandRuns(command, "bun"):
+		info.Language = "Node.js"
+		info.Confidence = "high"
+		detectNodeFramework(info, command, dir)
+		// Version files pin the runtime more precisely than manifest ranges.
+		if v := runtimeVersion(dir, "nodejs", ".nvmrc", ".node-version"); v != "" {
+			info.Version = v
+		}
+		if info.Version == "" {
+			info.Version = extractNodeVersion(pid)
+		}
+	case strings.Contains(cmdLower, "python") || strings.Contains(cmdLower, "uvicorn") || strings.Contains(cmdLower, "gunicorn") ||
+		strings.Contains(cmdLower, "flask") || strings.Contains(cmdLower, "django"):
+		info.Language = "Python"
+		info.Confidence = "high"
+		detectPythonFramework(info, command, dir)
+		if v := runtimeVersion(dir, "python", ".python-version"); v != "" {
+			info.Version = v
+		}
+		if info.Version == "" {
+			info.Version = extractPythonVersion(pid)
+		}
+	case strings.Contains(cmdLower, "ruby") || strings.Contains(cmdLower, "rails") || strings.Contains(cmdLower, "puma"):
+		info.Language = "Ruby"
+		info.Confidence = "high"
+		detectRubyFramework(info, command, dir)
+		info.Version = runtimeVersion(dir, "ruby", ".ruby-version")
+		if info.Version == "" {
+			info.Version = extractRubyVersion(pid)
+		}
+	case strings.Contains(cmdLower, "java"):
+		info.Language = "Java"
+		info.Confidence = "medium"
+		info.Framework = detectJavaFramework(command)
+		info.Version = runtimeVersion(dir, "java")
+		if info.Version == "" {
+			info.Version = extractJavaVersion(pid)
+		}
+	case strings.Contains(cmdLower, "php"):
+		info.Language = "PHP"
+		info.Framework = "PHP"
+		info.Confidence = "high"
+		info.Version = runtimeVersion(dir, "php")
+		if info.Version == "" {
+			info.Version = extractPHPVersion(pid)
+		}
+	// Checked before Go: "cargo run" contains "go run".
+	case strings.Contains(cmdLower, "cargo") || fileExists(filepath.Join(dir, "Cargo.toml")):
+		info.Language = "Rust"
+		info.Confidence = "high"
+		detectRustFramework(info, dir)
+		info.Version = runtimeVersion(dir, "rust")
+		if info.Version == "" {
+			info.Version = extractRustVersion()
+		}
+	case strings.Contains(cmdLower, "go run") || fileExists(filepath.Join(dir, "go.mod")):
+		info.Language = "Go"
+		info.Confidence = "high"
+		detectGoFramework(info, dir)
+		if info.Version == "" {
+			info.Version = runtimeVersion(dir, "golang")
+		}
+		if info.Version == "" {
+			info.Version = extractGoVersion()
+		}
+	default:
+		// If we couldn't identify, set to unknown
+		info.Language = "Unknown"
+	}
+	return info
+}
+
+// manifestFiles mark the directory whose manifests describe a process.
+var manifestFiles = []string{"package.json", "go.mod", "pyproject.toml", "requirements.txt", "Gemfile", "Cargo.toml", "composer.json", "pom.xml"}
+
+// findManifestDir returns the closest directory at or above cwd that holds a
+// manifest, stopping at a repository root. It returns cwd when none is found.
+func findManifestDir(cwd string) string {
+	if cwd == "" {
+		return ""
+	}
+	dir := cwd
+	for i := 0; i < 8; i++ {
+		for _, name := range manifestFiles {
+			if fileExists(filepath.Join(dir, name)) {
+				return dir
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir || fileExists(filepath.Join(dir, ".git")) {
+			break
+		}
+		dir = parent
+	}
+	return cwd
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// frameworkDep maps a dependency name to the framework it indicates.
+type frameworkDep struct {
+	dep       string
+	framework string
+}
+
+// nodeFrameworks are checked in order, so meta-frameworks win over the
+// libraries they build on.
+var nodeFrameworks = []frameworkDep{
+	{"next", "Next.js"},
+	{"nuxt", "Nuxt"},
+	{"@remix-run/dev", "Remix"},
+	{"@sveltejs/kit", "SvelteKit"},
+	{"astro", "Astro"},
+	{"gatsby", "Gatsby"},
+	{"@angular/core", "Angular"},
+	{"@nestjs/core", "NestJS"},
+	{"express", "Express"},
+	{"fastify", "Fastify"},
+	{"koa", "Koa"},
+	{"@hapi/hapi", "Hapi"},
+	{"hono", "Hono"},
+	{"vite", "Vite"},
+	{"react-scripts", "Create React App"},
+	{"webpack-dev-server", "Webpack"},
+}
+
+// nodeCommandHints identify the framework from the binary being run, which
+// beats guessing from dependencies in monorepos.
+var nodeCommandHints = []frameworkDep{
+	{"next", "Next.js"},
+	{"nuxt", "Nuxt"},
+	{"remix", "Remix"},
+	{"astro", "Astro"},
+	{"gatsby", "Gatsby"},
+	{"vite", "Vite"},
+	{"webpack", "Webpack"},
+}
+
+type packageJSON struct {
+	Dependencies    map[string]string `json:"dependencies"`
+	DevDependencies map[string]string `json:"devDependencies"`
+	Engines         struct {
+		Node string `json:"node"`
+	} `json:"engines"`
+}
+
+func detectNodeFramework(info *FrameworkInfo, command string, dir string) {
+	var pkg packageJSON
+	pkgPath := filepath.Join(dir, "package.json")
+	if data, err := os.ReadFile(pkgPath); err == nil && json.Unmarshal(data, &pkg) == nil {
+		info.PackageJson = pkgPath
+	}
+	deps := make(map[string]string)
+	for name, v := range pkg.DevDependencies {
+		deps[name] = v
+	}
+	for name, v := range pkg.Dependencies {
+		deps[name] = v
+	}
+
+	dep := ""
+	for _, hint := range nodeCommandHints {
+		if commandRuns(command, hint.dep) {
+			info.Framework = hint.framework
+			dep = frameworkDepName(hint.framework)
+			break
+		}
+	}
+	if info.Framework == "" {
+		for _, fw := range nodeFrameworks {
+			if _, ok := deps[fw.dep]; ok {
+				info.Framework, dep = fw.framework, fw.dep
+				break
+			}
+		}
+	}
+	if info.Framework == "" {
+		info.Framework = "Node.js (generic)"
+		return
+	}
+	info.FrameworkVersion = installedNodeVersion(dir, dep)
+	if info.FrameworkVersion == "" {
+		info.FrameworkVersion = versionNumber(deps[dep])
+	}
+	if info.Version == "" && pkg.Engines.Node != "" {
+		info.Version = versionNumber(pkg.Engines.Node)
+	}
+}
+
+// commandRuns reports whether one of command's words runs bin, e.g.
+// "node_modules/.bin/next dev" or "npx next dev" for "next".
+func commandRuns(command, bin string) bool {
+	for _, word := range strings.Fields(strings.ToLower(command)) {
+		if filepath.Base(word) == bin {
+			return true
+		}
+	}
+	return false
+}
+
+func frameworkDepName(framework string) string {
+	for _, fw := range nodeFrameworks {
+		if fw.framework == framework {
+			return fw.dep
+		}
+	}
+	return ""
+}
+
+// installedNodeVersion reads the exact version of dep from node_modules.
+func installedNodeVersion(dir, dep string) string {
+	if dep == "" {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "node_modules", dep, "package.json"))
+	if err != nil {
+		return ""
+	}
+	var pkg struct {
+		Version string `json:"version"`
+	}
+	if json.Unmarshal(data, &pkg) != nil {
+		return ""
+	}
+	return pkg.Version
+}
+
+var pythonFrameworks = []frameworkDep{
+	{"django", "Django"},
+	{"fastapi", "FastAPI"},
+	{"flask", "Flask"},
+	{"starlette", "Starlette"},
+	{"pyramid", "Pyramid"},
+	{"tornado", "Tornado"},
+	{"aiohttp", "aiohttp"},
+}
+
+func detectPythonFramework(info *FrameworkInfo, command string, dir string) {
+	cmdLower := strings.ToLower(command)
+	deps := make(map[string]string)
+	if data, err := os.ReadFile(filepath.Join(dir, "pyproject.toml")); err == nil {
+		for name, v := range tomlDependencies(string(data)) {
+			deps[strings.ToLower(name)] = v
+		}
+		if v := deps["python"]; v != "" {
+			info.Version = versionNumber(v) // poetry pins the interpreter here
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "requirements.txt")); err == nil {
+		for name, v := range requirementsDependencies(string(data)) {
+			deps[name] = v
+		}
+	}
+
+	for _, fw := range pythonFrameworks {
+		_, inDeps := deps[fw.dep]
+		if inDeps || strings.Contains(cmdLower, fw.dep) || (fw.dep == "django" && strings.Contains(cmdLower, "manage.py")) {
+			info.Framework = fw.framework
+			info.FrameworkVersion = versionNumber(deps[fw.dep])
+			return
+		}
+	}
+	switch {
+	case strings.Contains(cmdLower, "uvicorn"):
+		info.Framework = "ASGI (uvicorn)"
+	case strings.Contains(cmdLower, "gunicorn"):
+		info.Framework = "Gunicorn"
+	default:
+		info.Framework = "Python (generic)"
+	}
+}
+
+// requirementsDependencies parses "name==1.2" style lines.
+func requirementsDependencies(content string) map[string]string {
+	deps := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		name, spec := splitRequirement(line)
+		deps[name] = spec
+	}
+	return deps
+}
+
+// splitRequirement splits "Django[argon2]>=4.2" into "django" and ">=4.2".
+func splitRequirement(req string) (string, string) {
+	end := strings.IndexAny(req, "<>=!~[; ")
+	if end < 0 {
+		return strings.ToLower(req), ""
+	}
+	name := strings.ToLower(req[:end])
+	spec := req[end:]
+	if strings.HasPrefix(spec, "[") {
+		if close := strings.Index(spec, "]"); close >= 0 {
+			spec = spec[close+1:]
+		}
+	}
+	if semi := strings.Index(spec, ";"); semi >= 0 {
+		spec = spec[:semi]
+	}
+	return name, strings.TrimSpace(spec)
+}
+
+// tomlDependencies extracts dependency names and version specs from a
+// pyproject.toml or Cargo.toml: PEP 621 `dependencies = [...]` arrays and
+// `[dependencies]`-style tables (Cargo, Poetry). It is not a general TOML
+// parser, just enough for manifests.
+func tomlDependencies(content string) map[string]string {
+	deps := make(map[string]string)
+	section := ""
+	inArray := false
+	sc := bufio.NewScanner(strings.NewReader(content))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if inArray {
+			for _, item := range quotedStrings(line) {
+				name, spec := splitRequirement(item)
+				deps[name] = spec
+			}
+			if strings.Contains(line, "]") {
+				inArray = false
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[] ")
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.Trim(strings.TrimSpace(key), `"`)
+		value = strings.TrimSpace(value)
+
+		switch {
+		case section == "project" && key == "dependencies":
+			for _, item := range quotedStrings(value) {
+				name, spec := splitRequirement(item)
+				deps[name] = spec
+			}
+			inArray = !strings.Contains(value, "]")
+		case section == "dependencies" || section == "tool.poetry.dependencies" || section == "workspace.dependencies":
+			if strings.HasPrefix(value, "{") {
+				// name = { version = "1.0", features = [...] }
+				if _, rest, ok := strings.Cut(value, "version"); ok {
+					if q := quotedStrings(rest); len(q) > 0 {
+						value = q[0]
+					}
+				} else {
+					value = ""
+				}
+			}
+			deps[key] = strings.Trim(value, `"'`)
+		}
+	}
+	return deps
+}
+
+var quotedPattern = regexp.MustCompile(`"([^"]*)"|'([^']*)'`)
+
+func quotedStrings(s string) []string {
+	var out []string
+	for _, m := range quotedPattern.FindAllStringSubmatch(s, -1) {
+		out = append(out, m[1]+m[2])
+	}
+	return out
+}
+
+var goFrameworks = []frameworkDep{
+	{"github.com/gin-gonic/gin", "Gin"},
+	{"github.com/labstack/echo", "Echo"},
+	{"github.com/gofiber/fiber", "Fiber"},
+	{"github.com/go-chi/chi", "Chi"},
+	{"github.com/gorilla/mux", "Gorilla"},
+	{"github.com/beego/beego", "Beego"},
+	{"github.com/gobuffalo/buffalo", "Buffalo"},
+}
+
+func detectGoFramework(info *FrameworkInfo, dir string) {
+	info.Framework = "Go (custom)"
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return
+	}
+	requires := make(map[string]string)
+	inBlock := false
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case fields[0] == "go" && len(fields) > 1:
+			info.Version = fields[1]
+		case fields[0] == "require" && len(fields) > 1 && fields[1] == "(":
+			inBlock = true
+		case fields[0] == ")":
+			inBlock = false
+		case fields[0] == "require" && len(fields) > 2:
+			requires[fields[1]] = fields[2]
+		case inBlock && len(fields) > 1:
+			requires[fields[0]] = fields[1]
+		}
+	}
+	for _, fw := range goFrameworks {
+		for mod, version := range requires {
+			// Major versions live in the module path, e.g. .../echo/v4.
+			if mod == fw.dep || strings.HasPrefix(mod, fw.dep+"/v") {
+				info.Framework = fw.framework
+				info.FrameworkVersion = versionNumber(version)
+				return
+			}
+		}
+	}
+}
+
+var rubyFrameworks = []frameworkDep{
+	{"rails", "Rails"},
+	{"hanami", "Hanami"},
+	{"sinatra", "Sinatra"},
+	{"roda", "Roda"},
+}
+
+var gemPattern = regexp.MustCompile(`^\s*gem\s+["']([^"']+)["'](?:\s*,\s*["']([^"']+)["'])?`)
+var gemLockPattern = regexp.MustCompile(`^    ([a-z0-9_-]+) \(([^)]+)\)$`)
+
+func detectRubyFramework(info *FrameworkInfo, command string, dir string) {
+	cmdLower := strings.ToLower(command)
+	gems := make(map[string]string)
+	if data, err := os.ReadFile(filepath.Join(dir, "Gemfile")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if m := gemPattern.FindStringSubmatch(line); m != nil {
+				gems[m[1]] = m[2]
+			}
+		}
+	}
+	locked := make(map[string]string)
+	if data, err := os.ReadFile(filepath.Join(dir, "Gemfile.lock")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if m := gemLockPattern.FindStringSubmatch(line); m != nil {
+				locked[m[1]] = m[2]
+			}
+		}
+	}
+
+	for _, fw := range rubyFrameworks {
+		_, inGemfile := gems[fw.dep]
+		if inGemfile || strings.Contains(cmdLower, fw.dep) {
+			info.Framework = fw.framework
+			info.FrameworkVersion = locked[fw.dep]
+			if info.FrameworkVersion == "" {
+				info.FrameworkVersion = versionNumber(gems[fw.dep])
+			}
+			return
+		}
+	}
+	info.Framework = "Ruby (generic)"
 }
 
 func detectJavaFramework(command string) string {
-cmdLower := strings.ToLower(command)
-
-if strings.Contains(cmdLower, "spring") {
-return "Spring"
-}
-if strings.Contains(cmdLower, "quarkus") {
-return "Quarkus"
-}
-if strings.Contains(cmdLower, "micronaut") {
-return "Micronaut"
-}
-if strings.Contains(cmdLower, "dropwizard") {
-return "Dropwizard"
-}
-
-return "Java (generic)"
-}
-
-// Version extraction helpers
+	cmdLower := strings.ToLower(command)
+
+	if strings.Contains(cmdLower, "spring") {
+		return "Spring"
+	}
+	if strings.Contains(cmdLower, "quarkus") {
+		return "Quarkus"
+	}
+	if strings.Contains(cmdLower, "micronaut") {
+		return "Micronaut"
+	}
+	if strings.Contains(cmdLower, "dropwizard") {
+		return "Dropwizard"
+	}
+
+	return "Java (generic)"
+}
+
+var rustFrameworks = []frameworkDep{
+	{"actix-web", "Actix Web"},
+	{"axum", "Axum"},
+	{"rocket", "Rocket"},
+	{"warp", "Warp"},
+	{"poem", "Poem"},
+	{"tide", "Tide"},
+}
+
+func detectRustFramework(info *FrameworkInfo, dir string) {
+	info.Framework = "Rust (custom)"
+	data, err := os.ReadFile(filepath.Join(dir, "Cargo.toml"))
+	if err != nil {
+		return
+	}
+	deps := tomlDependencies(string(data))
+	for _, fw := range rustFrameworks {
+		if v, ok := deps[fw.dep]; ok {
+			info.Framework = fw.framework
+			info.FrameworkVersion = versionNumber(v)
+			return
+		}
+	}
+}
+
+// runtimeVersion reads a runtime version from the project's version files:
+// the tool's line in .tool-versions (asdf/mise) or one of the single-value
+// files such as .nvmrc.
+func runtimeVersion(dir string, tool string, files ...string) string {
+	if dir == "" {
+		return ""
+	}
+	for _, name := range files {
+		if data, err := os.ReadFile(filepath.Join(dir, name)); err == nil {
+			if v := versionNumber(strings.TrimSpace(string(data))); v != "" {
+				return v
+			}
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(dir, ".tool-versions"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 1 && (fields[0] == tool || (tool == "nodejs" && fields[0] == "node")) {
+			return versionNumber(fields[1])
+		}
+	}
+	return ""
+}
+
+var versionPattern = regexp.MustCompile(`\d+(?:\.\d+)*`)
+
+// versionNumber extracts the version from specs and tool output such as
+// "^14.1.0", "v20.11.0", "~> 7.1" or "Python 3.12.1".
+func versionNumber(s string) string {
+	return versionPattern.FindString(s)
+}
+
+// Version extraction helpers. These report the runtime on PATH and are only
+// used when the project does not pin a version.
 func extractNodeVersion(pid int) string {
-out, _ := exec.Command("node", "--version").Output()
-return strings.TrimSpace(string(out))
+	out, _ := exec.Command("node", "--version").Output()
+	return versionNumber(string(out))
 }
 
 func extractPythonVersion(pid int) string {
-out, _ := exec.Command("python3", "--version").Output()
-if len(out) == 0 {
-out, _ = exec.Command("python", "--version").Output()
-}
-return strings.TrimSpace(string(out))
+	out, _ := exec.Command("python3", "--version").Output()
+	if len(out) == 0 {
+		out, _ = exec.Command("python", "--version").Output()
+	}
+	return versionNumber(string(out))
 }
 
 func extractGoVersion() string {
-out, _ := exec.Command("go", "version").Output()
-parts := strings.Fields(string(out))
-if len(parts) >= 3 {
-return parts[2]
-}
-return ""
+	out, _ := exec.Command("go", "version").Output()
+	return versionNumber(strings.TrimPrefix(strings.TrimSpace(string(out)), "go version go"))
 }
 
 func extractRubyVersion(pid int) string {
-out, _ := exec.Command("ruby", "--version").Output()
-parts := strings.Fields(string(out))
-if len(parts) > 0 {
-return parts[1]
-}
-return ""
+	out, _ := exec.Command("ruby", "--version").Output()
+	return versionNumber(string(out))
 }
 
 func extractJavaVersion(pid int) string {
-out, _ := exec.Command("java", "-version").CombinedOutput()
-return strings.TrimSpace(string(out))
+	out, _ := exec.Command("java", "-version").CombinedOutput()
+	return versionNumber(string(out))
 }
 
 func extractPHPVersion(pid int) string {
-out, _ := exec.Command("php", "--version").Output()
-parts := strings.Fields(string(out))
-if len(parts) > 0 {
-return parts[1]
-}
-return ""
+	out, _ := exec.Command("php", "--version").Output()
+	return versionNumber(string(out))
 }
 
 func extractRustVersion() string {
-out, _ := exec.Command("rustc", "--version").Output()
-return strings.TrimSpace(string(out))
+	out, _ := exec.Command("rustc", "--version").Output()
+	return versionNumber(string(out))
 }
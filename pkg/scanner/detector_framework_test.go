@@ -0,0 +1,127 @@
+package scanner
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/devports/devpt/internal/testutil"
+)
+
+func TestDetectFrameworkManifests(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name          string
+		command       string
+		files         map[string]string
+		wantLanguage  string
+		wantFramework string
+		wantFwVersion string
+		wantRuntime   string
+	}{
+		{
+			name:    "next from installed package and nvmrc",
+			command: "node /app/node_modules/.bin/next dev",
+			files: map[string]string{
+				"package.json":                   `{"dependencies": {"next": "^14.1.0", "react": "18.2.0"}, "devDependencies": {"express": "4"}}`,
+				"node_modules/next/package.json": `{"name": "next", "version": "14.2.3"}`,
+				".nvmrc":                         "v20.11.0\n",
+			},
+			wantLanguage: "Node.js", wantFramework: "Next.js", wantFwVersion: "14.2.3", wantRuntime: "20.11.0",
+		},
+		{
+			// "nextgen-utils" must not be mistaken for next.
+			name:    "express from package.json ranges and engines",
+			command: "npm run dev",
+			files: map[string]string{
+				"package.json": `{"dependencies": {"express": "~4.18.2", "nextgen-utils": "1.0.0"}, "engines": {"node": ">=18"}}`,
+			},
+			wantLanguage: "Node.js", wantFramework: "Express", wantFwVersion: "4.18.2", wantRuntime: "18",
+		},
+		{
+			name:    "gin from go.mod",
+			command: "/tmp/go-build123/exe/api",
+			files: map[string]string{
+				"go.mod": "module example.com/api\n\ngo 1.22\n\nrequire (\n\tgithub.com/gin-gonic/gin v1.9.1\n\tgolang.org/x/net v0.20.0 // indirect\n)\n",
+			},
+			wantLanguage: "Go", wantFramework: "Gin", wantFwVersion: "1.9.1", wantRuntime: "1.22",
+		},
+		{
+			name:    "echo major version module path",
+			command: "go run .",
+			files: map[string]string{
+				"go.mod": "module example.com/api\n\ngo 1.21\n\nrequire github.com/labstack/echo/v4 v4.11.4\n",
+			},
+			wantLanguage: "Go", wantFramework: "Echo", wantFwVersion: "4.11.4", wantRuntime: "1.21",
+		},
+		{
+			name:    "fastapi from pyproject and tool-versions",
+			command: "python -m uvicorn app.main:app --reload",
+			files: map[string]string{
+				"pyproject.toml": "[project]\nname = \"api\"\ndependencies = [\n  \"fastapi>=0.110\",\n  \"uvicorn[standard]\",\n]\n",
+				".tool-versions": "nodejs 20.11.0\npython 3.12.1\n",
+			},
+			wantLanguage: "Python", wantFramework: "FastAPI", wantFwVersion: "0.110", wantRuntime: "3.12.1",
+		},
+		{
+			name:    "django from poetry",
+			command: "python manage.py runserver",
+			files: map[string]string{
+				"pyproject.toml": "[tool.poetry.dependencies]\npython = \"^3.11\"\nDjango = \"^5.0.1\"\n",
+			},
+			wantLanguage: "Python", wantFramework: "Django", wantFwVersion: "5.0.1", wantRuntime: "3.11",
+		},
+		{
+			name:    "rails from Gemfile.lock",
+			command: "ruby bin/rails server",
+			files: map[string]string{
+				"Gemfile":       "source \"https://rubygems.org\"\ngem \"rails\", \"~> 7.1\"\n",
+				"Gemfile.lock":  "GEM\n  specs:\n    rails (7.1.3)\n      actionpack (= 7.1.3)\n",
+				".ruby-version": "3.3.0\n",
+			},
+			wantLanguage: "Ruby", wantFramework: "Rails", wantFwVersion: "7.1.3", wantRuntime: "3.3.0",
+		},
+		{
+			name:    "axum from Cargo.toml",
+			command: "cargo run",
+			files: map[string]string{
+				"Cargo.toml":     "[package]\nname = \"api\"\n\n[dependencies]\naxum = \"0.7.4\"\ntokio = { version = \"1\", features = [\"full\"] }\n",
+				".tool-versions": "rust 1.76.0\n",
+			},
+			wantLanguage: "Rust", wantFramework: "Axum", wantFwVersion: "0.7.4", wantRuntime: "1.76.0",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			testutil.WriteFiles(t, dir, tc.files)
+
+			info := DetectFramework(1, tc.command, dir)
+			if info.Language != tc.wantLanguage || info.Framework != tc.wantFramework {
+				t.Fatalf("DetectFramework = %s/%s, want %s/%s", info.Language, info.Framework, tc.wantLanguage, tc.wantFramework)
+			}
+			if info.FrameworkVersion != tc.wantFwVersion {
+				t.Fatalf("FrameworkVersion = %q, want %q", info.FrameworkVersion, tc.wantFwVersion)
+			}
+			if info.Version != tc.wantRuntime {
+				t.Fatalf("Version = %q, want %q", info.Version, tc.wantRuntime)
+			}
+		})
+	}
+}
+
+func TestDetectFrameworkFindsManifestInParent(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	testutil.WriteFiles(t, root, map[string]string{
+		"package.json": `{"devDependencies": {"vite": "5.1.0"}}`,
+		".nvmrc":       "20",
+		"src/.keep":    "",
+	})
+	info := DetectFramework(1, "node node_modules/.bin/vite", filepath.Join(root, "src"))
+	if info.Framework != "Vite" || info.FrameworkVersion != "5.1.0" || info.Version != "20" {
+		t.Fatalf("got %s %s on Node %s, want Vite 5.1.0 on Node 20", info.Framework, info.FrameworkVersion, info.Version)
+	}
+}
@@ -0,0 +1,119 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+type fakeProc struct {
+	ppid int
+	name string
+	args string
+}
+
+func fakeDetector(procs map[int]fakeProc, lookups *int, extra ...models.AgentSignature) *AgentDetector {
+	ad := NewAgentDetector(extra)
+	ad.parentOf = func(pid int) (parentEntry, bool) {
+		*lookups++
+		p, ok := procs[pid]
+		return parentEntry{ppid: p.ppid, name: p.name, args: p.args}, ok
+	}
+	return ad
+}
+
+func TestCheckAncestorsWalksUpTheTree(t *testing.T) {
+	t.Parallel()
+
+	// claude → zsh → npm → node (server)
+	procs := map[int]fakeProc{
+		100: {ppid: 1, name: "/usr/local/bin/claude"},
+		200: {ppid: 100, name: "-zsh"},
+		300: {ppid: 200, name: "npm run dev"},
+		400: {ppid: 300, name: "node"},
+		500: {ppid: 300, name: "node"},
+	}
+	lookups := 0
+	ad := fakeDetector(procs, &lookups)
+
+	tag := ad.DetectAgent(&models.ProcessRecord{PID: 400, PPID: 300})
+	if tag == nil || tag.AgentName != "claude" || tag.AncestorPID != 100 || tag.AncestorName != "/usr/local/bin/claude" {
+		t.Fatalf("DetectAgent = %+v, want claude via PID 100", tag)
+	}
+	if tag.Confidence != models.ConfidenceHigh {
+		t.Fatalf("Confidence = %s, want high", tag.Confidence)
+	}
+
+	// A sibling shares the chain, which is served from the cache.
+	before := lookups
+	if name, pid, _ := ad.checkAncestors(500, 300); name != "claude" || pid != 100 {
+		t.Fatalf("checkAncestors(500) = %q, %d", name, pid)
+	}
+	if lookups != before {
+		t.Fatalf("sibling walk made %d lookups, want 0", lookups-before)
+	}
+}
+
+func TestCheckAncestorsStops(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		procs map[int]fakeProc
+	}{
+		{name: "no agent", procs: map[int]fakeProc{10: {ppid: 1, name: "launchd"}, 20: {ppid: 10, name: "bash"}}},
+		{name: "cycle", procs: map[int]fakeProc{10: {ppid: 20, name: "a"}, 20: {ppid: 10, name: "b"}}},
+		{name: "vanished parent", procs: map[int]fakeProc{20: {ppid: 15, name: "bash"}}},
+	}
+	for _, tc := range cases {
+		lookups := 0
+		ad := fakeDetector(tc.procs, &lookups)
+		if name, _, _ := ad.checkAncestors(30, 20); name != "" {
+			t.Fatalf("%s: matched %q", tc.name, name)
+		}
+		if lookups > maxAncestryDepth+1 {
+			t.Fatalf("%s: %d lookups exceed depth limit", tc.name, lookups)
+		}
+	}
+
+	// A chain deeper than the limit is cut off before reaching the agent.
+	deep := map[int]fakeProc{1000: {ppid: 1, name: "cursor"}}
+	for pid := 1001; pid <= 1000+maxAncestryDepth+1; pid++ {
+		deep[pid] = fakeProc{ppid: pid - 1, name: "sh"}
+	}
+	lookups := 0
+	ad := fakeDetector(deep, &lookups)
+	if name, _, _ := ad.checkAncestors(2000, 1000+maxAncestryDepth+1); name != "" {
+		t.Fatalf("deep chain matched %q beyond the depth limit", name)
+	}
+}
+
+func TestConfiguredAgents(t *testing.T) {
+	t.Parallel()
+
+	procs := map[int]fakeProc{
+		100: {ppid: 1, name: "/usr/bin/python3", args: "/usr/bin/python3 /home/me/.local/bin/aider --model x"},
+		200: {ppid: 100, name: "bash", args: "bash -c npm run dev"},
+		300: {ppid: 1, name: "Windsurf Helper", args: "/Applications/Windsurf.app/Contents/MacOS/Windsurf Helper"},
+		400: {ppid: 300, name: "zsh", args: "zsh"},
+	}
+	agents := []models.AgentSignature{
+		{Name: "aider", Commands: []string{"aider"}},
+		{Name: "windsurf", Processes: []string{"Windsurf"}},
+	}
+
+	lookups := 0
+	ad := fakeDetector(procs, &lookups, agents...)
+	if name, pid, _ := ad.checkAncestors(250, 200); name != "aider" || pid != 100 {
+		t.Fatalf("aider walk = %q, %d; want aider, 100", name, pid)
+	}
+	if name, pid, _ := ad.checkAncestors(450, 400); name != "windsurf" || pid != 300 {
+		t.Fatalf("windsurf walk = %q, %d; want windsurf, 300", name, pid)
+	}
+
+	// Without the config, neither is known.
+	ad = fakeDetector(procs, &lookups)
+	if name, _, _ := ad.checkAncestors(250, 200); name != "" {
+		t.Fatalf("unconfigured detector matched %q", name)
+	}
+}
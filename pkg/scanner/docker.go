@@ -0,0 +1,191 @@
+package scanner
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// dockerProxyPatterns match the processes that hold published container
+// ports on the host: Docker Desktop's backend on macOS, docker-proxy on
+// Linux, the rootless/podman port forwarders, and the host agent of Lima
+// VMs such as colima's, which forwards every port listening in the VM.
+var dockerProxyPatterns = []string{
+	"com.docker.backend",
+	"com.docker.vpnkit",
+	"vpnkit-bridge",
+	"docker-proxy",
+	"containerd-proxy",
+	"rootlessport",
+	"gvproxy",
+	limaHostAgent,
+}
+
+// limaHostAgent is in the command line of a Lima VM's host agent, e.g.
+// "limactl hostagent --pidfile ~/.colima/_lima/colima/ha.pid ... colima".
+const limaHostAgent = "limactl hostagent"
+
+// IsDockerProxy reports whether command is a container port proxy.
+func IsDockerProxy(command string) bool {
+	cmd := strings.ToLower(command)
+	for _, pattern := range dockerProxyPatterns {
+		if strings.Contains(cmd, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainerResolver maps published host ports to the containers behind them
+// by asking the docker CLI.
+type ContainerResolver struct {
+	timeout time.Duration
+}
+
+// NewContainerResolver creates a resolver that gives docker timeout to answer.
+func NewContainerResolver() *ContainerResolver {
+	return &ContainerResolver{timeout: 2 * time.Second}
+}
+
+// Annotate sets Container on records held by a docker proxy. Docker is only
+// queried when at least one such record exists. Ports forwarded by a Lima
+// VM are looked up in the VM's docker context, which colima names after
+// the VM, and are marked as served from the VM when no container
+// publishes them. The project a devcontainer was opened from becomes the
+// record's project root.
+func (cr *ContainerResolver) Annotate(records []*models.ProcessRecord) {
+	cr.AnnotateContext(context.Background(), records)
+}
+
+// AnnotateContext is Annotate, giving up on docker once ctx is done.
+func (cr *ContainerResolver) AnnotateContext(ctx context.Context, records []*models.ProcessRecord) {
+	var proxied []*models.ProcessRecord
+	for _, rec := range records {
+		if rec != nil && IsDockerProxy(rec.Command) {
+			proxied = append(proxied, rec)
+		}
+	}
+	if len(proxied) == 0 {
+		return
+	}
+
+	// Published ports by docker context, "" being the current one.
+	published := make(map[string]map[int]*models.ContainerInfo)
+	for _, rec := range proxied {
+		vm := limaInstance(rec.Command)
+		if _, ok := published[vm]; !ok {
+			published[vm] = cr.publishedPorts(ctx, vm)
+		}
+	}
+	if ctx.Err() != nil {
+		// Keep what the previous scan found rather than clear it.
+		return
+	}
+	for _, rec := range proxied {
+		vm := limaInstance(rec.Command)
+		c := published[vm][rec.Port]
+		if vm != "" {
+			if c == nil {
+				c = &models.ContainerInfo{}
+			}
+			c.VM = vm
+		}
+		// Records are reused across scans, so a vanished container is cleared.
+		if prev := rec.Container; prev != nil && prev.Workspace != "" && rec.ProjectRoot == prev.Workspace {
+			rec.ProjectRoot = ""
+		}
+		rec.Container = c
+		if c != nil && c.Workspace != "" {
+			rec.ProjectRoot = c.Workspace
+		}
+	}
+}
+
+// limaInstance returns the VM whose host agent runs command, "" when it is
+// not a Lima host agent. The VM is the agent's last argument.
+func limaInstance(command string) string {
+	if !strings.Contains(command, limaHostAgent) {
+		return ""
+	}
+	fields := strings.Fields(command)
+	if last := fields[len(fields)-1]; !strings.HasPrefix(last, "-") && last != "hostagent" {
+		return last
+	}
+	return ""
+}
+
+// publishedPorts returns host port -> container for the containers running
+// in dockerContext, or in the current context when it is "".
+func (cr *ContainerResolver) publishedPorts(ctx context.Context, dockerContext string) map[int]*models.ContainerInfo {
+	ctx, cancel := context.WithTimeout(ctx, cr.timeout)
+	defer cancel()
+
+	format := `{{.ID}}\t{{.Names}}\t{{.Image}}\t{{.Label "com.docker.compose.project"}}\t{{.Label "com.docker.compose.service"}}\t{{.Ports}}\t{{.Label "devcontainer.local_folder"}}`
+	var args []string
+	if dockerContext != "" {
+		args = append(args, "--context", dockerContext)
+	}
+	args = append(args, "ps", "--format", format)
+	output, err := exec.CommandContext(ctx, "docker", args...).Output()
+	if err != nil {
+		return nil
+	}
+	return parseDockerPS(string(output))
+}
+
+// parseDockerPS parses `docker ps` rows in the format used by publishedPorts.
+func parseDockerPS(output string) map[int]*models.ContainerInfo {
+	out := make(map[int]*models.ContainerInfo)
+	for _, line := range strings.Split(output, "\n") {
+		cols := strings.Split(line, "\t")
+		if len(cols) < 6 {
+			continue
+		}
+		info := &models.ContainerInfo{
+			ID:             cols[0],
+			Name:           cols[1],
+			Image:          cols[2],
+			ComposeProject: cols[3],
+			ComposeService: cols[4],
+		}
+		if len(cols) > 6 {
+			info.Workspace = strings.TrimSpace(cols[6])
+		}
+		for _, port := range publishedHostPorts(cols[5]) {
+			out[port] = info
+		}
+	}
+	return out
+}
+
+// publishedHostPorts extracts host ports from a docker Ports column such as
+// "0.0.0.0:5432->5432/tcp, :::5432->5432/tcp, 127.0.0.1:8000-8001->80-81/tcp".
+func publishedHostPorts(ports string) []int {
+	var out []int
+	for _, mapping := range strings.Split(ports, ",") {
+		mapping = strings.TrimSpace(mapping)
+		arrow := strings.Index(mapping, "->")
+		if arrow < 0 {
+			continue // exposed but not published
+		}
+		host := mapping[:arrow]
+		host = host[strings.LastIndex(host, ":")+1:]
+		first, last := host, host
+		if dash := strings.Index(host, "-"); dash >= 0 {
+			first, last = host[:dash], host[dash+1:]
+		}
+		lo, err1 := strconv.Atoi(first)
+		hi, err2 := strconv.Atoi(last)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		for p := lo; p <= hi; p++ {
+			out = append(out, p)
+		}
+	}
+	return out
+}
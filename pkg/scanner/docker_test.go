@@ -0,0 +1,99 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestParseDockerPS(t *testing.T) {
+	t.Parallel()
+
+	output := "a1b2c3d4e5f6\tshop-db-1\tpostgres:16\tshop\tdb\t0.0.0.0:5432->5432/tcp, :::5432->5432/tcp\n" +
+		"0f9e8d7c6b5a\tcache\tredis:7\t\t\t6379/tcp\n" +
+		"112233445566\tweb\tnginx\t\t\t127.0.0.1:8000-8001->80-81/tcp\n"
+
+	ports := parseDockerPS(output)
+	db := ports[5432]
+	if db == nil || db.Name != "shop-db-1" || db.ComposeService != "db" {
+		t.Fatalf("expected compose db on 5432, got %+v", db)
+	}
+	if got := db.Label(); got != "db (postgres:16)" {
+		t.Fatalf("Label() = %q", got)
+	}
+	if _, ok := ports[6379]; ok {
+		t.Fatal("unpublished port should not be mapped")
+	}
+	if ports[8000] == nil || ports[8001] == nil || ports[8001].Name != "web" {
+		t.Fatalf("port range not expanded: %+v", ports)
+	}
+}
+
+func TestIsDockerProxy(t *testing.T) {
+	t.Parallel()
+
+	for _, cmd := range []string{
+		"/Applications/Docker.app/Contents/MacOS/com.docker.backend",
+		"/usr/bin/docker-proxy -proto tcp -host-ip 0.0.0.0 -host-port 5432",
+	} {
+		if !IsDockerProxy(cmd) {
+			t.Fatalf("IsDockerProxy(%q) = false", cmd)
+		}
+	}
+	if IsDockerProxy("node server.js") {
+		t.Fatal("node should not be a docker proxy")
+	}
+}
+
+func TestParseDockerPSDevcontainer(t *testing.T) {
+	t.Parallel()
+
+	output := "a1b2c3d4e5f6\tvibrant_wozniak\tnode:20\t\t\t0.0.0.0:3000->3000/tcp\t/Users/me/src/shop\n"
+	c := parseDockerPS(output)[3000]
+	if c == nil || c.Workspace != "/Users/me/src/shop" {
+		t.Fatalf("expected the devcontainer of shop on 3000, got %+v", c)
+	}
+	if got := c.Label(); got != "shop devcontainer (node:20)" {
+		t.Fatalf("Label() = %q", got)
+	}
+	if got := c.Runtime(); got != "docker" {
+		t.Fatalf("Runtime() = %q", got)
+	}
+}
+
+func TestLimaInstance(t *testing.T) {
+	t.Parallel()
+
+	for cmd, want := range map[string]string{
+		"/opt/homebrew/bin/limactl hostagent --pidfile /Users/me/.colima/_lima/colima/ha.pid --socket /Users/me/.colima/_lima/colima/ha.sock colima": "colima",
+		"limactl hostagent --pidfile ha.pid --socket ha.sock colima-work":                                                                            "colima-work",
+		"limactl hostagent": "",
+		"/usr/bin/docker-proxy -proto tcp -host-port 5432": "",
+	} {
+		if got := limaInstance(cmd); got != want {
+			t.Errorf("limaInstance(%q) = %q, want %q", cmd, got, want)
+		}
+	}
+	if !IsDockerProxy("limactl hostagent --pidfile ha.pid colima") {
+		t.Error("a Lima host agent should count as a port proxy")
+	}
+}
+
+func TestAnnotateMarksPortsForwardedFromAVM(t *testing.T) {
+	t.Parallel()
+
+	// No docker context is named after this VM, so the port is served by
+	// a process in it.
+	rec := &models.ProcessRecord{PID: 7, Port: 8080, Command: "limactl hostagent --pidfile ha.pid --socket ha.sock devpt-test-vm"}
+	NewContainerResolver().Annotate([]*models.ProcessRecord{rec})
+	c := rec.Container
+	if c == nil || c.VM != "devpt-test-vm" || c.ID != "" {
+		t.Fatalf("Container = %+v, want the VM", c)
+	}
+	if got := c.Label(); got != "process in VM devpt-test-vm" {
+		t.Errorf("Label() = %q", got)
+	}
+	if got := c.Runtime(); got != "lima" {
+		t.Errorf("Runtime() = %q", got)
+	}
+}
@@ -6,92 +6,131 @@ import (
 	"github.com/devports/devpt/pkg/models"
 )
 
-// IsDevProcess checks if a process is likely a development server
-func IsDevProcess(record *models.ProcessRecord, commandInfo string) bool {
-	if record == nil {
-		return false
-	}
+// DefaultIgnorePatterns are command substrings of editor helpers that are
+// never reported, even though they run node or similar runtimes.
+var DefaultIgnorePatterns = []string{
+	"/.cursor/",
+	"cursor.app",
+	"cursor-server",
+	"/.vscode/",
+	"code helper",
+	"com.microsoft.vscode",
+}
 
-	// Extract process name from command
-	cmd := strings.ToLower(commandInfo)
+// DefaultDevPatterns are command substrings of known dev tools and frameworks.
+var DefaultDevPatterns = []string{
+	"node",
+	"npm",
+	"yarn",
+	"pnpm",
+	"python",
+	"python3",
+	"ruby",
+	"rails",
+	"go",
+	"java",
+	"mvn",
+	"gradle",
+	"cargo",
+	"rust",
+	"php",
+	"laravel",
+	"symfony",
+	"dotnet",
+	"flask",
+	"django",
+	"fastapi",
+	"uvicorn",
+	"gunicorn",
+	"express",
+	"next",
+	"nuxt",
+	"vite",
+	"webpack",
+	"parcel",
+	"gulp",
+	"deno",
+	"bun",
+	"rspec",
+	"pytest",
+	"jest",
+	"vitest",
+}
 
-	ignorePatterns := []string{
-		"/.cursor/",
-		"cursor.app",
-		"cursor-server",
-		"/.vscode/",
-		"code helper",
-		"com.microsoft.vscode",
-	}
-	for _, pattern := range ignorePatterns {
-		if strings.Contains(cmd, pattern) {
-			return false
-		}
-	}
+// DevFilter decides which listeners count as development servers. User
+// patterns from the scan settings extend the defaults.
+type DevFilter struct {
+	include []string
+	exclude []string
+	custom  []string
+}
 
-	// Check for known dev tools and frameworks
-	devPatterns := []string{
-		"node",
-		"npm",
-		"yarn",
-		"pnpm",
-		"python",
-		"python3",
-		"ruby",
-		"rails",
-		"go",
-		"java",
-		"mvn",
-		"gradle",
-		"cargo",
-		"rust",
-		"php",
-		"laravel",
-		"symfony",
-		"dotnet",
-		"flask",
-		"django",
-		"fastapi",
-		"uvicorn",
-		"gunicorn",
-		"express",
-		"next",
-		"nuxt",
-		"vite",
-		"webpack",
-		"parcel",
-		"gulp",
-		"deno",
-		"bun",
-		"rspec",
-		"pytest",
-		"jest",
-		"vitest",
+// NewDevFilter creates a filter from the defaults plus the given include and
+// exclude command substrings (case-insensitive).
+func NewDevFilter(include, exclude []string) *DevFilter {
+	custom := lowerAll(include)
+	return &DevFilter{
+		include: append(append([]string{}, DefaultDevPatterns...), custom...),
+		exclude: append(append([]string{}, DefaultIgnorePatterns...), lowerAll(exclude)...),
+		custom:  custom,
 	}
+}
 
-	for _, pattern := range devPatterns {
-		if strings.Contains(cmd, pattern) {
-			return true
+func lowerAll(patterns []string) []string {
+	out := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+			out = append(out, p)
 		}
 	}
+	return out
+}
 
-	return false
+// Excluded reports whether command matches an exclude pattern.
+func (f *DevFilter) Excluded(command string) bool {
+	return containsAny(strings.ToLower(command), f.exclude)
+}
+
+// IsDevProcess reports whether command looks like a development server.
+func (f *DevFilter) IsDevProcess(command string) bool {
+	cmd := strings.ToLower(command)
+	return !containsAny(cmd, f.exclude) && containsAny(cmd, f.include)
+}
+
+// Custom reports whether command matches one of the user's include patterns.
+func (f *DevFilter) Custom(command string) bool {
+	cmd := strings.ToLower(command)
+	return !containsAny(cmd, f.exclude) && containsAny(cmd, f.custom)
 }
 
-// FilterDevProcesses keeps only development-related processes
-func FilterDevProcesses(records []*models.ProcessRecord, commandMap map[int]string) []*models.ProcessRecord {
+// Filter keeps development processes, plus containers, port-forwards and
+// local infra that are not excluded.
+func (f *DevFilter) Filter(records []*models.ProcessRecord) []*models.ProcessRecord {
 	filtered := make([]*models.ProcessRecord, 0)
 
 	for _, record := range records {
 		if record == nil {
 			continue
 		}
-
-		cmd := commandMap[record.PID]
-		if IsDevProcess(record, cmd) {
+		if record.Container != nil || record.PortForward != nil || record.Infra != "" {
+			if !f.Excluded(record.Command) {
+				filtered = append(filtered, record)
+			}
+			continue
+		}
+		if f.IsDevProcess(record.Command) {
 			filtered = append(filtered, record)
 		}
 	}
 
 	return filtered
 }
+
+func containsAny(s string, patterns []string) bool {
+	for _, p := range patterns {
+		if strings.Contains(s, p) {
+			return true
+		}
+	}
+	return false
+}
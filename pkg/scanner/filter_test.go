@@ -0,0 +1,43 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestDevFilterUserPatterns(t *testing.T) {
+	t.Parallel()
+
+	f := NewDevFilter([]string{"Mix", " zig "}, []string{"jest"})
+	cases := []struct {
+		command string
+		dev     bool
+		custom  bool
+	}{
+		{command: "node server.js", dev: true},
+		{command: "/usr/local/bin/mix phx.server", dev: true, custom: true},
+		{command: "zig build run", dev: true, custom: true},
+		{command: "node node_modules/.bin/jest --watch", dev: false},
+		{command: "/Applications/Cursor.app/Contents/MacOS/node", dev: false},
+		{command: "/usr/sbin/sshd", dev: false},
+	}
+	for _, tc := range cases {
+		if got := f.IsDevProcess(tc.command); got != tc.dev {
+			t.Fatalf("IsDevProcess(%q) = %v, want %v", tc.command, got, tc.dev)
+		}
+		if got := f.Custom(tc.command); got != tc.custom {
+			t.Fatalf("Custom(%q) = %v, want %v", tc.command, got, tc.custom)
+		}
+	}
+
+	records := []*models.ProcessRecord{
+		{PID: 1, Command: "mix phx.server"},
+		{PID: 2, Command: "/usr/sbin/sshd"},
+		{PID: 3, Command: "redis-server *:6379", Infra: models.InfraRedis},
+	}
+	kept := f.Filter(records)
+	if len(kept) != 2 || kept[0].PID != 1 || kept[1].PID != 3 {
+		t.Fatalf("Filter kept %+v, want PIDs 1 and 3", kept)
+	}
+}
@@ -0,0 +1,88 @@
+package scanner
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// infraBinaries maps server executables to the infra kind they provide.
+var infraBinaries = map[string]string{
+	"postgres":        models.InfraPostgres,
+	"postmaster":      models.InfraPostgres,
+	"mysqld":          models.InfraMySQL,
+	"mariadbd":        models.InfraMySQL,
+	"redis-server":    models.InfraRedis,
+	"valkey-server":   models.InfraRedis,
+	"mongod":          models.InfraMongo,
+	"mongos":          models.InfraMongo,
+	"elasticsearch":   models.InfraElasticsearch,
+	"minio":           models.InfraMinio,
+	"rabbitmq-server": models.InfraRabbitMQ,
+}
+
+// infraImages maps container image names to the infra kind they provide.
+var infraImages = map[string]string{
+	"postgres":      models.InfraPostgres,
+	"postgis":       models.InfraPostgres,
+	"mysql":         models.InfraMySQL,
+	"mariadb":       models.InfraMySQL,
+	"redis":         models.InfraRedis,
+	"valkey":        models.InfraRedis,
+	"mongo":         models.InfraMongo,
+	"elasticsearch": models.InfraElasticsearch,
+	"minio":         models.InfraMinio,
+	"rabbitmq":      models.InfraRabbitMQ,
+}
+
+// DetectInfra returns the infra kind (e.g. "postgres") of a server command
+// line, or "" when the command is not a recognized database or broker.
+func DetectInfra(command string) string {
+	lower := strings.ToLower(command)
+	args := strings.Fields(lower)
+	if len(args) == 0 {
+		return ""
+	}
+	base := filepath.Base(args[0])
+	if kind, ok := infraBinaries[base]; ok {
+		return kind
+	}
+	switch {
+	case base == "java" && strings.Contains(lower, "org.elasticsearch."):
+		return models.InfraElasticsearch
+	case strings.HasPrefix(base, "beam") && strings.Contains(lower, "rabbit"):
+		// RabbitMQ runs inside the Erlang VM.
+		return models.InfraRabbitMQ
+	}
+	return ""
+}
+
+// detectInfraImage returns the infra kind of a container image reference
+// such as "postgres:16" or "docker.io/bitnami/redis:7.2".
+func detectInfraImage(image string) string {
+	name := strings.ToLower(image)
+	if at := strings.Index(name, "@"); at >= 0 {
+		name = name[:at]
+	}
+	name = name[strings.LastIndex(name, "/")+1:]
+	if colon := strings.Index(name, ":"); colon >= 0 {
+		name = name[:colon]
+	}
+	return infraImages[name]
+}
+
+// AnnotateInfra sets Infra on listeners served by local databases and
+// brokers, including containers running their official images.
+func AnnotateInfra(records []*models.ProcessRecord) {
+	for _, rec := range records {
+		if rec == nil || rec.PortForward != nil {
+			continue
+		}
+		if rec.Container != nil {
+			rec.Infra = detectInfraImage(rec.Container.Image)
+			continue
+		}
+		rec.Infra = DetectInfra(rec.Command)
+	}
+}
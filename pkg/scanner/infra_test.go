@@ -0,0 +1,45 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestDetectInfra(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		command string
+		want    string
+	}{
+		{command: "/opt/homebrew/opt/postgresql@16/bin/postgres -D /opt/homebrew/var/postgresql@16", want: models.InfraPostgres},
+		{command: "/usr/sbin/mysqld --basedir=/usr", want: models.InfraMySQL},
+		{command: "redis-server *:6379", want: models.InfraRedis},
+		{command: "mongod --config /usr/local/etc/mongod.conf", want: models.InfraMongo},
+		{command: "/usr/bin/java -Xms1g org.elasticsearch.bootstrap.Elasticsearch", want: models.InfraElasticsearch},
+		{command: "minio server /data", want: models.InfraMinio},
+		{command: "/usr/lib/erlang/erts-14/bin/beam.smp -- -root /usr/lib/erlang -s rabbit boot", want: models.InfraRabbitMQ},
+		{command: "node server.js", want: ""},
+		{command: "go run ./cmd/mongodump-ui", want: ""},
+	}
+	for _, tc := range cases {
+		if got := DetectInfra(tc.command); got != tc.want {
+			t.Fatalf("DetectInfra(%q) = %q, want %q", tc.command, got, tc.want)
+		}
+	}
+}
+
+func TestAnnotateInfraContainers(t *testing.T) {
+	t.Parallel()
+
+	db := &models.ProcessRecord{Command: "com.docker.backend", Container: &models.ContainerInfo{Name: "db", Image: "docker.io/library/postgres:16"}}
+	web := &models.ProcessRecord{Command: "com.docker.backend", Container: &models.ContainerInfo{Name: "web", Image: "nginx:latest"}}
+	AnnotateInfra([]*models.ProcessRecord{db, web})
+	if db.Infra != models.InfraPostgres {
+		t.Fatalf("postgres container Infra = %q, want %q", db.Infra, models.InfraPostgres)
+	}
+	if web.Infra != "" {
+		t.Fatalf("nginx container Infra = %q, want empty", web.Infra)
+	}
+}
@@ -0,0 +1,167 @@
+package scanner
+
+import (
+	"strings"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// NewServers wraps listening processes as running servers, with the source
+// their annotations imply.
+func NewServers(processes []*models.ProcessRecord) []*models.ServerInfo {
+	var servers []*models.ServerInfo
+	for _, proc := range processes {
+		source := models.SourceManual
+		if proc.AgentTag != nil {
+			source = proc.AgentTag.Source
+		}
+		if proc.Container != nil {
+			source = models.SourceContainer
+		}
+		if proc.PortForward != nil {
+			source = models.SourceForward
+		}
+
+		servers = append(servers, &models.ServerInfo{
+			ProcessRecord: proc,
+			Source:        source,
+			Status:        "running",
+		})
+	}
+	return servers
+}
+
+// MatchManaged sets the ManagedService of each server run by one of
+// services and returns the services no server matched, in order. A
+// service matches by its directory or project root when no other service
+// shares it, then by a declared port it alone claims, then by its last
+// PID when the process agrees on directory or port.
+func MatchManaged(servers []*models.ServerInfo, services []*models.ManagedService, resolver *ProjectResolver) []*models.ManagedService {
+	type managedIdentity struct {
+		cwd  string
+		root string
+	}
+
+	portOwners := make(map[int][]*models.ManagedService)
+	rootOwners := make(map[string]int)
+	cwdOwners := make(map[string]int)
+	identities := make(map[*models.ManagedService]managedIdentity, len(services))
+	for _, svc := range services {
+		svcCWD := normalizePath(svc.CWD)
+		svcRoot := normalizePath(resolver.FindProjectRoot(svc.CWD))
+		identities[svc] = managedIdentity{
+			cwd:  svcCWD,
+			root: svcRoot,
+		}
+		if svcCWD != "" {
+			cwdOwners[svcCWD]++
+		}
+		if svcRoot != "" {
+			rootOwners[svcRoot]++
+		}
+		for _, port := range svc.ActivePorts() {
+			portOwners[port] = append(portOwners[port], svc)
+		}
+	}
+	var unmatched []*models.ManagedService
+	for _, svc := range services {
+		found := false
+		identity := identities[svc]
+		svcCWD := identity.cwd
+		svcRoot := identity.root
+
+		for _, server := range servers {
+			if server.ProcessRecord == nil || server.ManagedService != nil {
+				continue
+			}
+			procCWD := normalizePath(server.ProcessRecord.CWD)
+			procRoot := normalizePath(server.ProcessRecord.ProjectRoot)
+			if canMatchByPath(svcRoot, svcCWD, procRoot, procCWD, rootOwners, cwdOwners) {
+				server.ManagedService = svc
+				found = true
+				break
+			}
+		}
+
+		if ports := svc.ActivePorts(); !found && len(ports) > 0 {
+			for _, port := range ports {
+				if owners := portOwners[port]; len(owners) != 1 {
+					continue
+				}
+				for _, server := range servers {
+					if server.ProcessRecord != nil && server.ProcessRecord.Port == port && server.ManagedService == nil {
+						procCWD := normalizePath(server.ProcessRecord.CWD)
+						procRoot := normalizePath(server.ProcessRecord.ProjectRoot)
+						if svcRoot != "" && procRoot != "" && svcRoot != procRoot {
+							continue
+						}
+						if svcCWD != "" && procCWD != "" && svcCWD != procCWD {
+							continue
+						}
+						server.ManagedService = svc
+						found = true
+						break
+					}
+				}
+				if found {
+					break
+				}
+			}
+		}
+
+		if !found && svc.LastPID != nil && *svc.LastPID > 0 {
+			for _, server := range servers {
+				if server.ProcessRecord == nil || server.ManagedService != nil || server.ProcessRecord.PID != *svc.LastPID {
+					continue
+				}
+				procCWD := normalizePath(server.ProcessRecord.CWD)
+				procRoot := normalizePath(server.ProcessRecord.ProjectRoot)
+				if serviceMatchesProcess(svc, server.ProcessRecord, svcRoot, procRoot, procCWD) {
+					server.ManagedService = svc
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			unmatched = append(unmatched, svc)
+		}
+	}
+	return unmatched
+}
+
+func normalizePath(p string) string {
+	p = strings.TrimSpace(p)
+	p = strings.TrimRight(p, "/")
+	return p
+}
+
+func canMatchByPath(svcRoot, svcCWD, procRoot, procCWD string, rootOwners, cwdOwners map[string]int) bool {
+	if svcRoot != "" && procRoot != "" && svcRoot == procRoot && rootOwners[svcRoot] == 1 {
+		return true
+	}
+	if svcCWD != "" && procCWD != "" && svcCWD == procCWD && cwdOwners[svcCWD] == 1 {
+		return true
+	}
+	return false
+}
+
+func serviceMatchesProcess(svc *models.ManagedService, proc *models.ProcessRecord, svcRoot, procRoot, procCWD string) bool {
+	if svc == nil || proc == nil {
+		return false
+	}
+
+	svcCWD := normalizePath(svc.CWD)
+	if svcCWD != "" && procCWD != "" && svcCWD == procCWD {
+		return true
+	}
+	if svcRoot != "" && procRoot != "" && svcRoot == procRoot {
+		return true
+	}
+	for _, port := range svc.ActivePorts() {
+		if port > 0 && proc.Port == port {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestCanMatchByPathRequiresUniqueOwner(t *testing.T) {
+	t.Parallel()
+
+	if !canMatchByPath(
+		"/workspace/app",
+		"/workspace/app",
+		"/workspace/app",
+		"/workspace/app",
+		map[string]int{"/workspace/app": 1},
+		map[string]int{"/workspace/app": 1},
+	) {
+		t.Fatal("expected unique path ownership to match")
+	}
+
+	if canMatchByPath(
+		"/workspace/app",
+		"/workspace/app",
+		"/workspace/app",
+		"/workspace/app",
+		map[string]int{"/workspace/app": 2},
+		map[string]int{"/workspace/app": 2},
+	) {
+		t.Fatal("expected ambiguous path ownership to be rejected")
+	}
+}
+
+func TestServiceMatchesProcessRequiresStrongerSignalThanPID(t *testing.T) {
+	t.Parallel()
+
+	svc := &models.ManagedService{
+		Name:  "api",
+		CWD:   "/workspace/api",
+		Ports: []int{3000},
+	}
+
+	if !serviceMatchesProcess(
+		svc,
+		&models.ProcessRecord{PID: 1234, Port: 3000},
+		"/workspace/api",
+		"",
+		"",
+	) {
+		t.Fatal("expected declared port to validate the process")
+	}
+
+	if !serviceMatchesProcess(
+		svc,
+		&models.ProcessRecord{PID: 1234, Port: 9999, CWD: "/workspace/api"},
+		"/workspace/api",
+		"/workspace/api",
+		"/workspace/api",
+	) {
+		t.Fatal("expected matching cwd/project root to validate the process")
+	}
+
+	if serviceMatchesProcess(
+		svc,
+		&models.ProcessRecord{PID: 1234, Port: 9999, CWD: "/tmp/other"},
+		"/workspace/api",
+		"/tmp/other",
+		"/tmp/other",
+	) {
+		t.Fatal("expected PID-only match without path/port agreement to be rejected")
+	}
+}
+
+func TestMatchManagedReturnsUnmatchedServices(t *testing.T) {
+	t.Parallel()
+
+	servers := NewServers([]*models.ProcessRecord{
+		{PID: 10, Port: 3000, CWD: "/workspace/web"},
+		{PID: 11, Port: 8080, CWD: "/workspace/api"},
+	})
+	web := &models.ManagedService{Name: "web", CWD: "/workspace/web", Ports: []int{3000}}
+	worker := &models.ManagedService{Name: "worker", CWD: "/workspace/worker"}
+
+	unmatched := MatchManaged(servers, []*models.ManagedService{web, worker}, NewProjectResolver())
+	if len(unmatched) != 1 || unmatched[0] != worker {
+		t.Fatalf("expected only worker to be unmatched, got %v", unmatched)
+	}
+	if servers[0].ManagedService != web || servers[1].ManagedService != nil {
+		t.Fatalf("unexpected matches: %v, %v", servers[0].ManagedService, servers[1].ManagedService)
+	}
+}
@@ -0,0 +1,70 @@
+package scanner
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// ParsePortForward recognizes `kubectl port-forward` command lines and
+// returns the forwarded target, or nil for any other command.
+func ParsePortForward(command string) *models.PortForwardInfo {
+	args := strings.Fields(command)
+	if len(args) < 2 {
+		return nil
+	}
+	if base := filepath.Base(args[0]); base != "kubectl" && base != "oc" {
+		return nil
+	}
+
+	info := &models.PortForwardInfo{}
+	forward := false
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		value := func() string {
+			if eq := strings.Index(arg, "="); eq >= 0 {
+				return arg[eq+1:]
+			}
+			if i+1 < len(args) {
+				i++
+				return args[i]
+			}
+			return ""
+		}
+		switch {
+		case arg == "port-forward":
+			forward = true
+		case arg == "-n" || arg == "--namespace" || strings.HasPrefix(arg, "--namespace="):
+			info.Namespace = value()
+		case strings.HasPrefix(arg, "-n") && len(arg) > 2:
+			info.Namespace = arg[2:]
+		case arg == "--context" || strings.HasPrefix(arg, "--context="):
+			info.Context = value()
+		case arg == "--address" || arg == "--kubeconfig" || arg == "--pod-running-timeout":
+			value()
+		case strings.HasPrefix(arg, "-"):
+			// Other flags carry their value inline or are booleans.
+		case forward && info.Target == "":
+			info.Target = arg
+		case forward:
+			info.Ports = append(info.Ports, arg)
+		}
+	}
+	if !forward || info.Target == "" {
+		return nil
+	}
+	if !strings.Contains(info.Target, "/") {
+		info.Target = "pod/" + info.Target
+	}
+	return info
+}
+
+// AnnotatePortForwards sets PortForward on kubectl port-forward listeners.
+func AnnotatePortForwards(records []*models.ProcessRecord) {
+	for _, rec := range records {
+		if rec != nil {
+			rec.PortForward = ParsePortForward(rec.Command)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package scanner
+
+import "testing"
+
+func TestParsePortForward(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		command string
+		label   string
+	}{
+		{command: "kubectl port-forward svc/api 8080:80", label: "svc/api"},
+		{command: "kubectl -n staging port-forward svc/api 8080:80", label: "staging/svc/api"},
+		{command: "/usr/local/bin/kubectl port-forward --namespace=db pod/postgres-0 5432", label: "db/pod/postgres-0"},
+		{command: "kubectl --context kind-dev port-forward web-7d9f 3000:3000 --address 0.0.0.0", label: "kind-dev:pod/web-7d9f"},
+	}
+	for _, tc := range cases {
+		info := ParsePortForward(tc.command)
+		if info == nil {
+			t.Fatalf("ParsePortForward(%q) = nil", tc.command)
+		}
+		if got := info.Label(); got != tc.label {
+			t.Fatalf("ParsePortForward(%q).Label() = %q, want %q", tc.command, got, tc.label)
+		}
+	}
+
+	for _, cmd := range []string{"kubectl get pods", "node server.js", "kubectl port-forward"} {
+		if info := ParsePortForward(cmd); info != nil {
+			t.Fatalf("ParsePortForward(%q) = %+v, want nil", cmd, info)
+		}
+	}
+}
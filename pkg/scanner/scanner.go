@@ -4,184 +4,435 @@ import (
 	"bufio"
 	"context"
 	"fmt"
-	"os/exec"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
-"github.com/devports/devpt/pkg/models"
+	"github.com/devports/devpt/pkg/models"
 )
 
-// ProcessScanner discovers listening ports using macOS tools
+// ProcessScanner discovers listening ports, natively where the platform
+// allows it and with lsof and ps otherwise
 type ProcessScanner struct {
-cwdCache map[int]string
-mu       sync.RWMutex
+	cwdCache   map[int]string
+	frameworks map[string]*FrameworkInfo // by "pid:command"
+	mu         sync.RWMutex
+	includeUDP bool
+	backend    backend
+
+	// scanMu serializes scans, which may run on several goroutines.
+	// previous holds the last scan's records by recordKey; listeners still
+	// present are reused as-is instead of being enriched again.
+	scanMu   sync.Mutex
+	previous map[string]*models.ProcessRecord
+	stats    ScanStats
+}
+
+// ScanStats describes the most recent scan.
+type ScanStats struct {
+	Duration  time.Duration
+	Listeners int
+	New       int // listeners not present in the previous scan
 }
 
 // NewProcessScanner creates a new scanner instance
 func NewProcessScanner() *ProcessScanner {
-return &ProcessScanner{
-cwdCache: make(map[int]string),
+	ps := &ProcessScanner{
+		cwdCache:   make(map[int]string),
+		frameworks: make(map[string]*FrameworkInfo),
+		previous:   make(map[string]*models.ProcessRecord),
+		backend:    execBackend{},
+	}
+	if native := nativeBackend(); native != nil {
+		ps.backend = native
+	}
+	return ps
 }
+
+// LastStats returns statistics about the most recent scan.
+func (ps *ProcessScanner) LastStats() ScanStats {
+	ps.scanMu.Lock()
+	defer ps.scanMu.Unlock()
+	return ps.stats
 }
 
-// ScanListeningPorts discovers all TCP listening ports
-func (ps *ProcessScanner) ScanListeningPorts() ([]*models.ProcessRecord, error) {
-cmd := exec.Command("lsof", "-nP", "-iTCP", "-sTCP:LISTEN")
-output, err := cmd.Output()
-if err != nil {
-return nil, fmt.Errorf("failed to run lsof: %w", err)
+func recordKey(r *models.ProcessRecord) string {
+	return fmt.Sprintf("%d:%d/%s", r.PID, r.Port, r.Protocol)
 }
 
-records, err := ps.parseLsofOutput(string(output))
-if err != nil {
-return records, err
+// SetIncludeUDP makes ScanListeningPorts also report bound UDP sockets.
+func (ps *ProcessScanner) SetIncludeUDP(on bool) {
+	ps.includeUDP = on
 }
 
-// Enrich records with command information
-ps.enrichWithCommands(records)
-return records, nil
+// ScanListeningPorts discovers all TCP listening ports, plus bound UDP
+// sockets when enabled with SetIncludeUDP. Listeners seen in the previous
+// scan are returned as the same records, so callers can keep their
+// enrichment; only new listeners are looked up.
+func (ps *ProcessScanner) ScanListeningPorts() ([]*models.ProcessRecord, error) {
+	return ps.ScanListeningPortsContext(context.Background())
 }
 
-// parseLsofOutput parses lsof output into ProcessRecords
-func (ps *ProcessScanner) parseLsofOutput(output string) ([]*models.ProcessRecord, error) {
-scanner := bufio.NewScanner(strings.NewReader(output))
-records := make([]*models.ProcessRecord, 0)
-seen := make(map[string]bool)
+// ScanListeningPortsContext is ScanListeningPorts, abandoning the scan and
+// the lsof and ps commands it runs once ctx is done. A canceled scan returns
+// ctx's error and leaves the previous scan to be reused next time.
+func (ps *ProcessScanner) ScanListeningPortsContext(ctx context.Context) ([]*models.ProcessRecord, error) {
+	ps.scanMu.Lock()
+	defer ps.scanMu.Unlock()
+	start := time.Now()
+	b := ps.backend
+	records, err := b.listeners(ctx, ps.includeUDP)
+	if err != nil && ctx.Err() == nil && b.name() != BackendExec {
+		// The native API can be denied (e.g. sandboxing); lsof may still work.
+		b = execBackend{}
+		records, err = b.listeners(ctx, ps.includeUDP)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
 
-// Skip header
-if !scanner.Scan() {
-return records, nil
+	// Enrich new records with command information
+	previous := ps.previous
+	fresh := ps.reusePrevious(records)
+	ps.enrich(ctx, b, fresh)
+	if err := ctx.Err(); err != nil {
+		// Half-enriched records must not be reused.
+		ps.previous = previous
+		return nil, err
+	}
+	ps.stats = ScanStats{Duration: time.Since(start), Listeners: len(records), New: len(fresh)}
+	return records, nil
 }
 
-for scanner.Scan() {
-line := scanner.Text()
-record, err := ps.parseLsofLine(line)
-if err != nil {
-continue
+// reusePrevious replaces records that were present in the previous scan
+// with the earlier record and returns the ones that are new.
+func (ps *ProcessScanner) reusePrevious(records []*models.ProcessRecord) []*models.ProcessRecord {
+	var fresh []*models.ProcessRecord
+	current := make(map[string]*models.ProcessRecord, len(records))
+	for i, record := range records {
+		key := recordKey(record)
+		if prev, ok := ps.previous[key]; ok {
+			if prev.BindAddress != record.BindAddress {
+				prev.BindAddress = record.BindAddress
+			}
+			records[i] = prev
+		} else {
+			fresh = append(fresh, record)
+		}
+		current[key] = records[i]
+	}
+	ps.previous = current
+	return fresh
 }
 
-if record != nil {
-key := fmt.Sprintf("%d:%d", record.PID, record.Port)
-if !seen[key] {
-seen[key] = true
-records = append(records, record)
-}
-}
+// parseLsofOutput parses lsof output into ProcessRecords
+func parseLsofOutput(output string) ([]*models.ProcessRecord, error) {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	records := make([]*models.ProcessRecord, 0)
+
+	// Skip header
+	if !scanner.Scan() {
+		return records, nil
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		record, err := parseLsofLine(line)
+		if err != nil {
+			continue
+		}
+		if record != nil {
+			records = append(records, record)
+		}
+	}
+
+	return dedupeListeners(records), nil
 }
 
-return records, nil
+// dedupeListeners merges sockets of one process on the same port and
+// protocol, e.g. IPv4 and IPv6 listeners of a single server.
+func dedupeListeners(records []*models.ProcessRecord) []*models.ProcessRecord {
+	out := make([]*models.ProcessRecord, 0, len(records))
+	seen := make(map[string]*models.ProcessRecord)
+	for _, record := range records {
+		key := recordKey(record)
+		if prev, ok := seen[key]; ok {
+			// A server bound to both loopback and a wildcard is exposed.
+			if record.Exposed() && !prev.Exposed() {
+				prev.BindAddress = record.BindAddress
+			}
+			continue
+		}
+		seen[key] = record
+		out = append(out, record)
+	}
+	return out
 }
 
 // parseLsofLine parses a single lsof output line
-func (ps *ProcessScanner) parseLsofLine(line string) (*models.ProcessRecord, error) {
-fields := strings.Fields(line)
-if len(fields) < 9 {
-return nil, fmt.Errorf("insufficient fields")
-}
+func parseLsofLine(line string) (*models.ProcessRecord, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 9 {
+		return nil, fmt.Errorf("insufficient fields")
+	}
 
-pidStr := fields[1]
-nameField := fields[8]
+	pidStr := fields[1]
 
-pid, err := strconv.Atoi(pidStr)
-if err != nil {
-return nil, fmt.Errorf("invalid pid")
-}
+	pid, err := strconv.Atoi(pidStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pid")
+	}
 
-port, err := extractPort(nameField)
-if err != nil {
-return nil, fmt.Errorf("no port")
-}
+	// NAME follows the NODE column ("TCP" or "UDP"); locating it this way
+	// tolerates rows where SIZE/OFF is blank.
+	protocol := ""
+	nameField := ""
+	for i := 4; i < len(fields)-1; i++ {
+		if fields[i] == "TCP" || fields[i] == "UDP" {
+			protocol = strings.ToLower(fields[i])
+			nameField = fields[i+1]
+			break
+		}
+	}
+	if nameField == "" {
+		return nil, fmt.Errorf("no name")
+	}
+	if strings.Contains(nameField, "->") {
+		// Connected socket, not a listener.
+		return nil, fmt.Errorf("connected socket")
+	}
 
-return &models.ProcessRecord{
-PID:      pid,
-Port:     port,
-Command:  "", // Will be enriched later
-CWD:      "", // Skip for now - was causing hangs
-Protocol: "tcp",
-}, nil
-}
+	host, port, err := splitAddress(nameField)
+	if err != nil {
+		return nil, err
+	}
 
-// extractPort extracts port from NAME field
-func extractPort(name string) (int, error) {
-parts := strings.Split(name, ":")
-if len(parts) < 2 {
-return 0, fmt.Errorf("no port")
+	return &models.ProcessRecord{
+		PID:         pid,
+		User:        fields[2],
+		Port:        port,
+		Command:     "", // Will be enriched later
+		CWD:         "", // Skip for now - was causing hangs
+		Protocol:    protocol,
+		BindAddress: host,
+	}, nil
 }
 
-portStr := parts[len(parts)-1]
-port, err := strconv.Atoi(portStr)
-if err != nil {
-return 0, fmt.Errorf("invalid port")
-}
+// splitAddress splits a NAME field such as "127.0.0.1:3000", "*:3000",
+// "[::1]:3000" or "[fe80::1%lo0]:3000" into host and port.
+func splitAddress(name string) (string, int, error) {
+	var host, portStr string
+	if strings.HasPrefix(name, "[") {
+		end := strings.Index(name, "]")
+		if end < 0 || !strings.HasPrefix(name[end+1:], ":") {
+			return "", 0, fmt.Errorf("no port")
+		}
+		host, portStr = name[1:end], name[end+2:]
+	} else {
+		idx := strings.LastIndex(name, ":")
+		if idx < 0 {
+			return "", 0, fmt.Errorf("no port")
+		}
+		host, portStr = name[:idx], name[idx+1:]
+	}
 
-return port, nil
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 || port > 65535 {
+		return "", 0, fmt.Errorf("invalid port")
+	}
+	if i := strings.Index(host, "%"); i >= 0 {
+		host = host[:i] // drop zone, e.g. "fe80::1%lo0"
+	}
+	return host, port, nil
 }
 
-// enrichWithCommands fetches command information for each PID
-func (ps *ProcessScanner) enrichWithCommands(records []*models.ProcessRecord) {
+// enrich fills in command, parent, user, start time and working directory
+// for all records with one batched lookup each.
+func (ps *ProcessScanner) enrich(ctx context.Context, b backend, records []*models.ProcessRecord) {
+	pids := uniquePIDs(records)
+	if len(pids) == 0 {
+		return
+	}
+
+	infos := b.processes(ctx, pids)
+	cwds := ps.resolveCWDs(ctx, b, pids)
+
 	for _, record := range records {
 		if record == nil {
 			continue
 		}
+		if info, ok := infos[record.PID]; ok {
+			record.Command = info.command
+			record.PPID = info.ppid
+			if info.user != "" {
+				record.User = info.user
+			}
+			if !info.start.IsZero() {
+				start := info.start
+				record.StartTime = &start
+			}
+		}
+		if record.CWD == "" {
+			record.CWD = cwds[record.PID]
+		}
+	}
+}
+
+// psInfo describes a process, as reported by one row of
+// `ps -o pid=,ppid=,user=,lstart=,command=` or the native backend.
+type psInfo struct {
+	ppid    int
+	user    string
+	start   time.Time
+	command string
+}
 
-		cmd := exec.Command("ps", "-p", fmt.Sprintf("%d", record.PID), "-o", "command=")
-		output, err := cmd.Output()
-		if err == nil {
-			record.Command = strings.TrimSpace(string(output))
+// lstartLayout is the fixed-width format ps uses for the lstart column.
+const lstartLayout = "Mon Jan _2 15:04:05 2006"
+
+// parsePSOutput parses rows such as
+// "4242 4100 alice Fri Oct 16 19:27:56 2026 node server.js" keyed by PID.
+func parsePSOutput(output string) map[int]psInfo {
+	infos := make(map[int]psInfo)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 8 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		info := psInfo{user: fields[2]}
+		info.ppid, _ = strconv.Atoi(fields[1])
+		if start, err := time.ParseInLocation(lstartLayout, strings.Join(fields[3:8], " "), time.Local); err == nil {
+			info.start = start
 		}
+		// Cut rather than join fields to keep the command's own spacing.
+		info.command = commandAfterFields(line, 8)
+		infos[pid] = info
+	}
+	return infos
+}
 
-		if record.CWD == "" {
-			if cwd, ok := ps.getCWD(record.PID); ok {
-				record.CWD = cwd
-			}
+// commandAfterFields returns line with its first n whitespace-separated
+// fields removed.
+func commandAfterFields(line string, n int) string {
+	rest := strings.TrimLeft(line, " \t")
+	for i := 0; i < n; i++ {
+		end := strings.IndexAny(rest, " \t")
+		if end < 0 {
+			return ""
 		}
+		rest = strings.TrimLeft(rest[end:], " \t")
 	}
+	return strings.TrimSpace(rest)
 }
 
-func (ps *ProcessScanner) getCWD(pid int) (string, bool) {
+// resolveCWDs returns the working directory of each PID, looking up all
+// uncached PIDs in one batch.
+func (ps *ProcessScanner) resolveCWDs(ctx context.Context, b backend, pids []int) map[int]string {
+	cwds := make(map[int]string, len(pids))
+	var missing []int
 	ps.mu.RLock()
-	if cached, ok := ps.cwdCache[pid]; ok {
-		ps.mu.RUnlock()
-		if cached == "" {
-			return "", false
+	for _, pid := range pids {
+		if cached, ok := ps.cwdCache[pid]; ok {
+			cwds[pid] = cached
+		} else {
+			missing = append(missing, pid)
 		}
-		return cached, true
 	}
 	ps.mu.RUnlock()
+	if len(missing) == 0 {
+		return cwds
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 400*time.Millisecond)
-	defer cancel()
+	found := b.cwds(ctx, missing)
+	if ctx.Err() != nil {
+		// Lookups cut short say nothing about the PIDs; don't cache them.
+		return cwds
+	}
 
-	cmd := exec.CommandContext(ctx, "lsof", "-a", "-p", fmt.Sprintf("%d", pid), "-d", "cwd", "-Fn")
-	output, err := cmd.Output()
-	if err != nil || ctx.Err() != nil {
-		ps.mu.Lock()
-		ps.cwdCache[pid] = ""
-		ps.mu.Unlock()
-		return "", false
+	ps.mu.Lock()
+	for _, pid := range missing {
+		// Failed lookups are cached as "" so they are not retried every tick.
+		ps.cwdCache[pid] = found[pid]
+		cwds[pid] = found[pid]
 	}
+	ps.mu.Unlock()
+	return cwds
+}
 
-	cwd := ""
-	for _, line := range strings.Split(string(output), "\n") {
-		if strings.HasPrefix(line, "n") {
-			cwd = strings.TrimPrefix(line, "n")
-			break
+// parseLsofCWDs parses `lsof -Fn` field output ("p<pid>" followed by
+// "n<path>") into a PID to path map.
+func parseLsofCWDs(output string) map[int]string {
+	cwds := make(map[int]string)
+	pid := 0
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case strings.HasPrefix(line, "p"):
+			pid, _ = strconv.Atoi(line[1:])
+		case strings.HasPrefix(line, "n") && pid > 0:
+			if _, ok := cwds[pid]; !ok {
+				cwds[pid] = line[1:]
+			}
 		}
 	}
+	return cwds
+}
 
-	ps.mu.Lock()
-	ps.cwdCache[pid] = cwd
-	ps.mu.Unlock()
+func uniquePIDs(records []*models.ProcessRecord) []int {
+	seen := make(map[int]bool)
+	var pids []int
+	for _, record := range records {
+		if record != nil && record.PID > 0 && !seen[record.PID] {
+			seen[record.PID] = true
+			pids = append(pids, record.PID)
+		}
+	}
+	return pids
+}
 
-	if cwd == "" {
-		return "", false
+func joinPIDs(pids []int) string {
+	parts := make([]string, len(pids))
+	for i, pid := range pids {
+		parts[i] = strconv.Itoa(pid)
 	}
-	return cwd, true
+	return strings.Join(parts, ",")
 }
 
-// DetectFrameworkInfo detects the framework and language of a process
+// DetectFrameworkInfo detects the framework and language of a process. Results
+// are cached per PID and command, since detection reads project files and
+// runs version commands.
 func (ps *ProcessScanner) DetectFrameworkInfo(pid int, command string, cwd string) *FrameworkInfo {
-	return DetectFramework(pid, command, cwd)
+	key := fmt.Sprintf("%d:%s", pid, command)
+	ps.mu.RLock()
+	info, ok := ps.frameworks[key]
+	ps.mu.RUnlock()
+	if ok {
+		return info
+	}
+
+	info = DetectFramework(pid, command, cwd)
+	ps.mu.Lock()
+	ps.frameworks[key] = info
+	ps.mu.Unlock()
+	return info
+}
+
+// AnnotateFramework sets the detected language, framework and their
+// versions on rec.
+func (ps *ProcessScanner) AnnotateFramework(rec *models.ProcessRecord) {
+	info := ps.DetectFrameworkInfo(rec.PID, rec.Command, rec.CWD)
+	if info == nil || info.Language == "Unknown" {
+		return
+	}
+	rec.Language = info.Language
+	rec.RuntimeVersion = info.Version
+	rec.Framework = info.Framework
+	rec.FrameworkVersion = info.FrameworkVersion
 }
@@ -0,0 +1,207 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+func TestParseLsofOutputCapturesBindAddress(t *testing.T) {
+	t.Parallel()
+
+	output := `COMMAND   PID USER   FD   TYPE DEVICE SIZE/OFF NODE NAME
+node     4242 me     20u  IPv4 0x1111      0t0  TCP 127.0.0.1:3000 (LISTEN)
+node     4242 me     21u  IPv6 0x2222      0t0  TCP [::1]:3000 (LISTEN)
+vite     4343 me     22u  IPv4 0x3333      0t0  TCP 127.0.0.1:5173 (LISTEN)
+vite     4343 me     23u  IPv6 0x4444      0t0  TCP *:5173 (LISTEN)
+`
+	records, err := parseLsofOutput(output)
+	if err != nil {
+		t.Fatalf("parseLsofOutput: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if got := records[0].User; got != "me" {
+		t.Fatalf("user = %q, want %q", got, "me")
+	}
+	if got := records[0].BindAddress; got != "127.0.0.1" || records[0].Exposed() {
+		t.Fatalf("loopback listener: bind %q exposed=%v", got, records[0].Exposed())
+	}
+	if got := records[1].BindAddress; got != "*" || !records[1].Exposed() {
+		t.Fatalf("wildcard listener should win: bind %q exposed=%v", got, records[1].Exposed())
+	}
+}
+
+func TestSplitAddress(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		host string
+		port int
+	}{
+		{name: "127.0.0.1:3000", host: "127.0.0.1", port: 3000},
+		{name: "*:8080", host: "*", port: 8080},
+		{name: "[::1]:5173", host: "::1", port: 5173},
+		{name: "[::]:9000", host: "::", port: 9000},
+		{name: "[fe80::1%lo0]:4000", host: "fe80::1", port: 4000},
+	}
+	for _, tc := range cases {
+		host, port, err := splitAddress(tc.name)
+		if err != nil || host != tc.host || port != tc.port {
+			t.Fatalf("splitAddress(%q) = %q, %d, %v; want %q, %d", tc.name, host, port, err, tc.host, tc.port)
+		}
+	}
+	for _, bad := range []string{"*:*", "[::1]", "localhost"} {
+		if _, _, err := splitAddress(bad); err == nil {
+			t.Fatalf("splitAddress(%q) should fail", bad)
+		}
+	}
+}
+
+func TestParseLsofOutputUDP(t *testing.T) {
+	t.Parallel()
+
+	output := `COMMAND   PID USER   FD   TYPE DEVICE SIZE/OFF NODE NAME
+node     4242 me     20u  IPv6 0x1111      0t0  UDP [::]:24678
+node     4242 me     21u  IPv4 0x2222      0t0  UDP 127.0.0.1:51000->127.0.0.1:53
+mdns      111 me     22u  IPv4 0x3333      0t0  UDP *:*
+`
+	records, err := parseLsofOutput(output)
+	if err != nil {
+		t.Fatalf("parseLsofOutput: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected only the bound socket, got %d records", len(records))
+	}
+	if r := records[0]; r.Protocol != "udp" || r.Port != 24678 || r.BindAddress != "::" {
+		t.Fatalf("unexpected record %+v", r)
+	}
+}
+
+func TestParsePSOutput(t *testing.T) {
+	t.Parallel()
+
+	output := ` 4242  4100 alice    Fri Oct 16 09:07:56 2026 node  server.js --port 3000
+  515     1 _postgres Mon Oct  5 08:00:01 2026 /opt/homebrew/bin/postgres -D /var/db
+`
+	infos := parsePSOutput(output)
+	node, ok := infos[4242]
+	if !ok {
+		t.Fatalf("missing pid 4242 in %+v", infos)
+	}
+	if node.ppid != 4100 || node.user != "alice" || node.command != "node  server.js --port 3000" {
+		t.Fatalf("unexpected node row %+v", node)
+	}
+	if node.start.Hour() != 9 || node.start.Day() != 16 {
+		t.Fatalf("unexpected start time %v", node.start)
+	}
+	if pg := infos[515]; pg.user != "_postgres" || pg.start.Day() != 5 || !strings.HasPrefix(pg.command, "/opt/homebrew/bin/postgres") {
+		t.Fatalf("unexpected postgres row %+v", pg)
+	}
+}
+
+func TestParseLsofCWDs(t *testing.T) {
+	t.Parallel()
+
+	cwds := parseLsofCWDs("p4242\nfcwd\nn/Users/me/app\np515\nfcwd\nn/var/db\n")
+	if cwds[4242] != "/Users/me/app" || cwds[515] != "/var/db" || len(cwds) != 2 {
+		t.Fatalf("unexpected cwds %+v", cwds)
+	}
+}
+
+func TestReusePreviousKeepsEnrichedRecords(t *testing.T) {
+	t.Parallel()
+
+	ps := NewProcessScanner()
+	first := []*models.ProcessRecord{{PID: 10, Port: 3000, Protocol: "tcp"}}
+	if fresh := ps.reusePrevious(first); len(fresh) != 1 {
+		t.Fatalf("first scan: %d fresh records, want 1", len(fresh))
+	}
+	first[0].Command = "node server.js"
+
+	second := []*models.ProcessRecord{
+		{PID: 10, Port: 3000, Protocol: "tcp", BindAddress: "*"},
+		{PID: 11, Port: 5173, Protocol: "tcp"},
+	}
+	fresh := ps.reusePrevious(second)
+	if len(fresh) != 1 || fresh[0].PID != 11 {
+		t.Fatalf("second scan fresh = %+v, want only pid 11", fresh)
+	}
+	if second[0] != first[0] || second[0].Command != "node server.js" || second[0].BindAddress != "*" {
+		t.Fatalf("known listener was not reused: %+v", second[0])
+	}
+}
+
+func TestScanListeningPortsContextCanceled(t *testing.T) {
+	t.Parallel()
+
+	ps := NewProcessScanner()
+	prev := &models.ProcessRecord{PID: 10, Port: 3000, Protocol: "tcp", Command: "node server.js"}
+	ps.previous[recordKey(prev)] = prev
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := ps.ScanListeningPortsContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if ps.previous[recordKey(prev)] != prev {
+		t.Fatal("canceled scan dropped the previous scan's records")
+	}
+}
+
+// benchmarkRecords returns listeners owned by a few live PIDs, several ports each.
+func benchmarkRecords() []*models.ProcessRecord {
+	var records []*models.ProcessRecord
+	for _, pid := range []int{os.Getpid(), os.Getppid(), 1} {
+		for port := 0; port < 4; port++ {
+			records = append(records, &models.ProcessRecord{PID: pid, Port: 3000 + port})
+		}
+	}
+	return records
+}
+
+// BenchmarkEnrichPerPID measures the previous approach of one ps and one
+// lsof call per listener, as a baseline for BenchmarkEnrichBatched.
+func BenchmarkEnrichPerPID(b *testing.B) {
+	if _, err := exec.LookPath("lsof"); err != nil {
+		b.Skip("lsof not available")
+	}
+	records := benchmarkRecords()
+	for i := 0; i < b.N; i++ {
+		for _, r := range records {
+			out, _ := exec.Command("ps", "-p", fmt.Sprintf("%d", r.PID), "-o", "command=").Output()
+			r.Command = strings.TrimSpace(string(out))
+			_, _ = exec.Command("lsof", "-a", "-p", fmt.Sprintf("%d", r.PID), "-d", "cwd", "-Fn").Output()
+		}
+	}
+}
+
+func BenchmarkEnrichBatched(b *testing.B) {
+	if _, err := exec.LookPath("lsof"); err != nil {
+		b.Skip("lsof not available")
+	}
+	records := benchmarkRecords()
+	for i := 0; i < b.N; i++ {
+		// A fresh scanner per iteration so the CWD cache does not hide the lsof call.
+		NewProcessScanner().enrich(context.Background(), execBackend{}, records)
+	}
+}
+
+func BenchmarkEnrichNative(b *testing.B) {
+	native := nativeBackend()
+	if native == nil {
+		b.Skip("no native backend on this platform")
+	}
+	records := benchmarkRecords()
+	for i := 0; i < b.N; i++ {
+		NewProcessScanner().enrich(context.Background(), native, records)
+	}
+}
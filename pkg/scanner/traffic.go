@@ -0,0 +1,210 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Throughput is the network traffic of a listening port, in bytes per
+// second: In received from its clients, Out sent to them.
+type Throughput struct {
+	In  float64
+	Out float64
+}
+
+// Total is the traffic both ways.
+func (t Throughput) Total() float64 {
+	return t.In + t.Out
+}
+
+// ErrTrafficUnsupported is returned on platforms without a way to count
+// traffic.
+var ErrTrafficUnsupported = errors.New("traffic counters are not available on this platform")
+
+// byteCounts are the bytes a connection or process has received and sent.
+type byteCounts struct {
+	in, out int64
+}
+
+// TrafficSampler turns the byte counters of the connections to listening
+// ports into throughput between two samples. On Linux it reads the
+// counters of every TCP connection from ss; on macOS, which has no such
+// per-connection counters, those of every process from nettop, crediting a
+// process's traffic to its listening port.
+type TrafficSampler struct {
+	goos string
+	run  func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+	prev   map[string]byteCounts
+	prevAt time.Time
+}
+
+// NewTrafficSampler returns a sampler for this platform.
+func NewTrafficSampler() *TrafficSampler {
+	return &TrafficSampler{
+		goos: runtime.GOOS,
+		run: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			return exec.CommandContext(ctx, name, args...).Output()
+		},
+	}
+}
+
+// Sample reads the counters and returns the throughput of each port in
+// listeners (port to PID) since the previous sample; the first sample
+// returns none. Traffic of connections that closed between samples after
+// the previous one is not counted.
+func (s *TrafficSampler) Sample(ctx context.Context, listeners map[int]int) (map[int]Throughput, error) {
+	var counts map[string]byteCounts
+	switch s.goos {
+	case "linux":
+		out, err := s.run(ctx, "ss", "-tinH")
+		if err != nil {
+			return nil, err
+		}
+		counts = parseSSCounters(out, listeners)
+	case "darwin":
+		out, err := s.run(ctx, "nettop", "-P", "-x", "-L", "1", "-J", "bytes_in,bytes_out")
+		if err != nil {
+			return nil, err
+		}
+		counts = parseNettopCounters(out, listeners)
+	default:
+		return nil, ErrTrafficUnsupported
+	}
+	now := time.Now()
+	prev, prevAt := s.prev, s.prevAt
+	s.prev, s.prevAt = counts, now
+	if prev == nil {
+		return nil, nil
+	}
+	secs := now.Sub(prevAt).Seconds()
+	if secs <= 0 {
+		return nil, nil
+	}
+	byPort := make(map[int]byteCounts)
+	for key, c := range counts {
+		port, ok := counterPort(key)
+		if !ok {
+			continue
+		}
+		p := prev[key] // a new connection counts from zero
+		if c.in < p.in || c.out < p.out {
+			p = byteCounts{} // a new process or connection reusing the key
+		}
+		sum := byPort[port]
+		sum.in += c.in - p.in
+		sum.out += c.out - p.out
+		byPort[port] = sum
+	}
+	rates := make(map[int]Throughput, len(listeners))
+	for port := range listeners {
+		c := byPort[port]
+		rates[port] = Throughput{In: float64(c.in) / secs, Out: float64(c.out) / secs}
+	}
+	return rates, nil
+}
+
+// counterPort returns the listening port a counter key starts with.
+func counterPort(key string) (int, bool) {
+	port, _, _ := strings.Cut(key, " ")
+	n, err := strconv.Atoi(port)
+	return n, err == nil
+}
+
+// parseSSCounters reads the output of ss -tinH: a line per connection with
+// its state, queues and addresses, followed by an indented line of
+// tcp_info that holds bytes_received and bytes_sent. It keeps connections
+// whose local port is one of listeners, keyed by "<port> <peer>".
+func parseSSCounters(out []byte, listeners map[int]int) map[string]byteCounts {
+	counts := make(map[string]byteCounts)
+	key := ""
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			continue
+		}
+		if line[0] != ' ' && line[0] != '\t' {
+			key = ""
+			fields := strings.Fields(line)
+			if len(fields) < 5 || fields[0] == "LISTEN" {
+				continue
+			}
+			_, port, err := net.SplitHostPort(fields[3])
+			if err != nil {
+				continue
+			}
+			if n, err := strconv.Atoi(port); err == nil {
+				if _, ok := listeners[n]; ok {
+					key = port + " " + fields[4]
+				}
+			}
+			continue
+		}
+		if key == "" {
+			continue
+		}
+		var c byteCounts
+		for _, f := range strings.Fields(line) {
+			name, value, ok := strings.Cut(f, ":")
+			if !ok {
+				continue
+			}
+			switch name {
+			case "bytes_received":
+				c.in, _ = strconv.ParseInt(value, 10, 64)
+			case "bytes_sent":
+				c.out, _ = strconv.ParseInt(value, 10, 64)
+			}
+		}
+		counts[key] = c
+		key = ""
+	}
+	return counts
+}
+
+// parseNettopCounters reads the CSV nettop -P -x prints: a row per process,
+// named <name>.<pid>, with its bytes in and out. It keeps the processes
+// that listen on one of listeners, keyed by "<port> <pid>".
+func parseNettopCounters(out []byte, listeners map[int]int) map[string]byteCounts {
+	ports := make(map[int]int, len(listeners))
+	for port, pid := range listeners {
+		ports[pid] = port
+	}
+	counts := make(map[string]byteCounts)
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		fields := strings.Split(sc.Text(), ",")
+		for i, f := range fields {
+			// The first field is the time of day, e.g. 11:19:31.086362.
+			dot := strings.LastIndex(f, ".")
+			if dot <= 0 || strings.Contains(f, ":") || i+2 >= len(fields) {
+				continue
+			}
+			pid, err := strconv.Atoi(f[dot+1:])
+			if err != nil {
+				continue
+			}
+			port, ok := ports[pid]
+			if !ok {
+				break
+			}
+			in, errIn := strconv.ParseInt(fields[i+1], 10, 64)
+			out, errOut := strconv.ParseInt(fields[i+2], 10, 64)
+			if errIn == nil && errOut == nil {
+				counts[strconv.Itoa(port)+" "+strconv.Itoa(pid)] = byteCounts{in: in, out: out}
+			}
+			break
+		}
+	}
+	return counts
+}
@@ -0,0 +1,81 @@
+package scanner
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+const ssSample = `LISTEN 0      511    127.0.0.1:3000 0.0.0.0:*
+	 cubic cwnd:10
+ESTAB  0      0      127.0.0.1:3000 127.0.0.1:51000
+	 cubic wscale:7,7 rto:204 bytes_sent:5000 bytes_acked:5001 bytes_received:1000 segs_out:10
+ESTAB  0      0      [::1]:3000 [::1]:51002
+	 cubic bytes_sent:200 bytes_received:100
+ESTAB  0      0      127.0.0.1:51000 127.0.0.1:3000
+	 cubic bytes_sent:1000 bytes_received:5000
+ESTAB  0      0      127.0.0.1:5432 127.0.0.1:52000
+	 cubic bytes_sent:9 bytes_received:9
+`
+
+func TestParseSSCounters(t *testing.T) {
+	t.Parallel()
+	counts := parseSSCounters([]byte(ssSample), map[int]int{3000: 42})
+	if len(counts) != 2 {
+		t.Fatalf("counts = %+v, want the two connections to port 3000", counts)
+	}
+	if c := counts["3000 127.0.0.1:51000"]; c.in != 1000 || c.out != 5000 {
+		t.Errorf("IPv4 connection = %+v", c)
+	}
+	if c := counts["3000 [::1]:51002"]; c.in != 100 || c.out != 200 {
+		t.Errorf("IPv6 connection = %+v", c)
+	}
+}
+
+func TestParseNettopCounters(t *testing.T) {
+	t.Parallel()
+	out := "time,,bytes_in,bytes_out,\n" +
+		"11:19:31.086362,launchd.1,0,0,\n" +
+		"11:19:31.086362,node.4242,2048,1024,\n" +
+		"11:19:31.086362,Google Chrome H.999,5,5,\n"
+	counts := parseNettopCounters([]byte(out), map[int]int{3000: 4242})
+	if len(counts) != 1 || counts["3000 4242"] != (byteCounts{in: 2048, out: 1024}) {
+		t.Errorf("counts = %+v", counts)
+	}
+}
+
+func TestTrafficSamplerRates(t *testing.T) {
+	t.Parallel()
+	outputs := []string{
+		"ESTAB 0 0 127.0.0.1:3000 127.0.0.1:51000\n\t bytes_sent:1000 bytes_received:100\n",
+		"ESTAB 0 0 127.0.0.1:3000 127.0.0.1:51000\n\t bytes_sent:3000 bytes_received:300\n" +
+			"ESTAB 0 0 127.0.0.1:3000 127.0.0.1:51001\n\t bytes_sent:1000 bytes_received:0\n",
+	}
+	s := &TrafficSampler{goos: "linux", run: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		out := outputs[0]
+		outputs = outputs[1:]
+		return []byte(out), nil
+	}}
+	listeners := map[int]int{3000: 42, 8080: 43}
+	if rates, err := s.Sample(context.Background(), listeners); err != nil || rates != nil {
+		t.Fatalf("first sample = %v, %v; want only a baseline", rates, err)
+	}
+	s.prevAt = time.Now().Add(-2 * time.Second)
+	rates, err := s.Sample(context.Background(), listeners)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2000 more bytes out on the old connection and 1000 on the new one,
+	// over about 2 seconds.
+	if got := rates[3000]; math.Abs(got.Out-1500) > 10 || math.Abs(got.In-100) > 1 {
+		t.Errorf("port 3000 = %+v, want about 100 in and 1500 out per second", got)
+	}
+	if got, ok := rates[8080]; !ok || got.Total() != 0 {
+		t.Errorf("idle port = %+v, %v", got, ok)
+	}
+
+	if _, err := (&TrafficSampler{goos: "plan9"}).Sample(context.Background(), listeners); err != ErrTrafficUnsupported {
+		t.Errorf("unsupported platform = %v", err)
+	}
+}
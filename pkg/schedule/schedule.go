@@ -0,0 +1,198 @@
+// Package schedule parses the schedules of periodic jobs and services: a
+// fixed interval ("every 15m") or a five-field cron expression.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule computes when a periodic job or service runs next.
+type Schedule struct {
+	spec  string
+	every time.Duration
+	cron  *cronSpec
+}
+
+// minEvery is the shortest interval accepted; schedules are checked on the
+// TUI's refresh ticks, so anything finer would not be honored.
+const minEvery = 10 * time.Second
+
+// cronHorizon bounds the search for the next matching time, for
+// expressions such as "0 0 30 2 *" that never match.
+const cronHorizon = 5 * 366 * 24 * time.Hour
+
+// Parse parses spec, which is one of
+//
+//	every 15m          (also "every: 15m" and "@every 15m")
+//	*/5 9-17 * * 1-5   (minute hour day-of-month month day-of-week)
+//	@hourly, @daily, @weekly, @monthly
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	lower := strings.ToLower(spec)
+	for _, prefix := range []string{"@every", "every:", "every"} {
+		if rest, ok := strings.CutPrefix(lower, prefix); ok {
+			d, err := time.ParseDuration(strings.TrimSpace(rest))
+			if err != nil {
+				return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+			}
+			if d < minEvery {
+				return nil, fmt.Errorf("invalid schedule %q: interval must be at least %s", spec, minEvery)
+			}
+			return &Schedule{spec: spec, every: d}, nil
+		}
+	}
+	switch lower {
+	case "@hourly":
+		lower = "0 * * * *"
+	case "@daily", "@midnight":
+		lower = "0 0 * * *"
+	case "@weekly":
+		lower = "0 0 * * 0"
+	case "@monthly":
+		lower = "0 0 1 * *"
+	}
+	cron, err := parseCron(lower)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+	}
+	return &Schedule{spec: spec, cron: cron}, nil
+}
+
+// String returns the schedule as it was written.
+func (s *Schedule) String() string {
+	return s.spec
+}
+
+// Next returns the first time strictly after after at which the schedule
+// fires. It returns the zero time for cron expressions that never match.
+func (s *Schedule) Next(after time.Time) time.Time {
+	if s.every > 0 {
+		return after.Add(s.every)
+	}
+	return s.cron.next(after)
+}
+
+// cronSpec holds the allowed values of each field as bit sets.
+type cronSpec struct {
+	minute, hour, dom, month, dow uint64
+	// Per cron convention, when both day fields are restricted a day
+	// matches if either does.
+	domAny, dowAny bool
+}
+
+func parseCron(expr string) (*cronSpec, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 cron fields or \"every <duration>\", got %d fields", len(fields))
+	}
+	var c cronSpec
+	var err error
+	if c.minute, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if c.hour, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if c.dom, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if c.month, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if c.dow, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+	// 7 is Sunday too.
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domAny = fields[2] == "*"
+	c.dowAny = fields[4] == "*"
+	return &c, nil
+}
+
+// parseField parses a comma-separated list of "*", "N", "N-M", each
+// optionally followed by "/step".
+func parseField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepStr)
+			}
+			step = n
+		}
+		lo, hi := min, max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = parseValue(a, min, max); err != nil {
+				return 0, err
+			}
+			if hi, err = parseValue(b, min, max); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q", rng)
+			}
+		default:
+			v, err := parseValue(rng, min, max)
+			if err != nil {
+				return 0, err
+			}
+			lo, hi = v, v
+			if hasStep {
+				hi = max
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(s string, min, max int) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil || v < min || v > max {
+		return 0, fmt.Errorf("value %q out of range %d-%d", s, min, max)
+	}
+	return v, nil
+}
+
+func (c *cronSpec) next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.Add(cronHorizon)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (c *cronSpec) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,51 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	t.Parallel()
+
+	// A Wednesday.
+	base := time.Date(2026, 3, 11, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"every 15m", base.Add(15 * time.Minute)},
+		{"every: 2h", base.Add(2 * time.Hour)},
+		{"@every 30s", base.Add(30 * time.Second)},
+		{"*/5 * * * *", time.Date(2026, 3, 11, 10, 10, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2026, 3, 11, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 3, 12, 0, 0, 0, 0, time.UTC)},
+		{"30 9 * * 1-5", time.Date(2026, 3, 12, 9, 30, 0, 0, time.UTC)},
+		{"0 12 * * 0", time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)},
+		{"0 0 1 4 *", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"15,45 10 * * *", time.Date(2026, 3, 11, 10, 15, 0, 0, time.UTC)},
+		// Both day fields restricted: either may match.
+		{"0 0 20 * 5", time.Date(2026, 3, 13, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.spec, err)
+		}
+		if got := s.Next(base); !got.Equal(tt.want) {
+			t.Fatalf("%q: Next = %s, want %s", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestParseRejectsInvalidSpecs(t *testing.T) {
+	t.Parallel()
+
+	for _, spec := range []string{"", "every", "every 1s", "every soon", "* * * *", "60 * * * *", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := Parse(spec); err == nil {
+			t.Fatalf("expected %q to be rejected", spec)
+		}
+	}
+}
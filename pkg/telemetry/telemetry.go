@@ -0,0 +1,366 @@
+// Package telemetry exports spans of devpt operations to an OpenTelemetry
+// collector over OTLP/HTTP, encoded as JSON so no SDK is needed.
+package telemetry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// Environment variables read by New, as defined by the OpenTelemetry
+// specification.
+const (
+	EnvEndpoint       = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	EnvTracesEndpoint = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+	EnvHeaders        = "OTEL_EXPORTER_OTLP_HEADERS"
+	EnvServiceName    = "OTEL_SERVICE_NAME"
+	EnvSDKDisabled    = "OTEL_SDK_DISABLED"
+	// EnvTraceParent holds a W3C traceparent the spans of devpt join, so a
+	// script that traces its own steps can wrap devpt in them. devpt sets it
+	// for the services it starts.
+	EnvTraceParent = "TRACEPARENT"
+)
+
+// Export tuning: spans are sent in batches every flushInterval, and
+// dropped beyond maxQueued while the collector is unreachable.
+const (
+	flushInterval  = 5 * time.Second
+	maxQueued      = 2048
+	requestTimeout = 5 * time.Second
+)
+
+// Attr is a span attribute; its value is a string, int64, float64 or bool.
+type Attr struct {
+	Key   string
+	Value any
+}
+
+// String returns a string attribute.
+func String(key, value string) Attr { return Attr{Key: key, Value: value} }
+
+// Int returns an integer attribute.
+func Int(key string, value int) Attr { return Attr{Key: key, Value: int64(value)} }
+
+// Bool returns a boolean attribute.
+func Bool(key string, value bool) Attr { return Attr{Key: key, Value: value} }
+
+// spanContext identifies a span within its trace.
+type spanContext struct {
+	traceID [16]byte
+	spanID  [8]byte
+}
+
+func (sc spanContext) valid() bool {
+	return sc.traceID != [16]byte{}
+}
+
+// Span is an operation being timed. A nil span, from a nil Tracer, records
+// nothing.
+type Span struct {
+	t      *Tracer
+	sc     spanContext
+	parent [8]byte
+	name   string
+	start  time.Time
+
+	mu    sync.Mutex
+	end   time.Time
+	attrs []Attr
+	err   error
+}
+
+// Tracer starts spans and exports the finished ones in the background. A
+// nil Tracer, returned while export is not configured, is valid and does
+// nothing.
+type Tracer struct {
+	endpoint string
+	headers  map[string]string
+	service  string
+	client   *http.Client
+
+	// remote is the span named by TRACEPARENT; root, once set, is the parent
+	// of spans started without one in their context.
+	remote spanContext
+
+	mu       sync.Mutex
+	root     *Span
+	queue    []*Span
+	failed   bool
+	onError  func(error)
+	stop     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// New returns a tracer exporting to the collector configured by cfg or
+// the environment (read through getenv), or nil when none is. Export
+// failures are reported to onError, once until an export succeeds again.
+func New(cfg models.TelemetrySettings, getenv func(string) string, onError func(error)) (*Tracer, error) {
+	if strings.EqualFold(strings.TrimSpace(getenv(EnvSDKDisabled)), "true") {
+		return nil, nil
+	}
+	endpoint := strings.TrimSpace(getenv(EnvTracesEndpoint))
+	if endpoint == "" {
+		base := strings.TrimSpace(getenv(EnvEndpoint))
+		if base == "" {
+			base = cfg.Endpoint
+		}
+		if base == "" {
+			return nil, nil
+		}
+		endpoint = strings.TrimRight(base, "/") + "/v1/traces"
+	}
+	if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q", endpoint)
+	}
+	headers := make(map[string]string, len(cfg.Headers))
+	for k, v := range cfg.Headers {
+		headers[k] = v
+	}
+	for k, v := range parseHeaders(getenv(EnvHeaders)) {
+		headers[k] = v
+	}
+	service := cfg.ServiceName
+	if s := strings.TrimSpace(getenv(EnvServiceName)); s != "" {
+		service = s
+	}
+	if service == "" {
+		service = "devpt"
+	}
+	t := &Tracer{
+		endpoint: endpoint,
+		headers:  headers,
+		service:  service,
+		client:   &http.Client{Timeout: requestTimeout},
+		onError:  onError,
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	t.remote, _ = parseTraceParent(getenv(EnvTraceParent))
+	go t.loop()
+	return t, nil
+}
+
+// parseHeaders reads the comma-separated key=value list of
+// OTEL_EXPORTER_OTLP_HEADERS, whose values may be URL-encoded.
+func parseHeaders(raw string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			continue
+		}
+		if dec, err := url.QueryUnescape(strings.TrimSpace(v)); err == nil {
+			v = dec
+		}
+		headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return headers
+}
+
+// parseTraceParent reads a W3C traceparent, "00-<trace id>-<span id>-<flags>".
+func parseTraceParent(raw string) (spanContext, bool) {
+	var sc spanContext
+	parts := strings.Split(strings.TrimSpace(raw), "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return sc, false
+	}
+	trace, err1 := hex.DecodeString(parts[1])
+	span, err2 := hex.DecodeString(parts[2])
+	if err1 != nil || err2 != nil || len(trace) != 16 || len(span) != 8 {
+		return sc, false
+	}
+	copy(sc.traceID[:], trace)
+	copy(sc.spanID[:], span)
+	if !sc.valid() || sc.spanID == [8]byte{} {
+		return spanContext{}, false
+	}
+	return sc, true
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns ctx carrying span, the parent of spans started
+// from it.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	if span == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the span ctx carries, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// Start begins a span named name, a child of the span in ctx, of the root
+// span, or of TRACEPARENT, whichever is found first. The returned context
+// carries the new span.
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	span := t.newSpan(t.parentOf(ctx), name, attrs)
+	return ContextWithSpan(ctx, span), span
+}
+
+// Root begins a span that becomes the parent of spans started without one,
+// such as the span of a whole command.
+func (t *Tracer) Root(name string, attrs ...Attr) *Span {
+	if t == nil {
+		return nil
+	}
+	span := t.newSpan(t.remote, name, attrs)
+	t.mu.Lock()
+	t.root = span
+	t.mu.Unlock()
+	return span
+}
+
+// Record exports a span for an operation that already finished, started
+// at start, as Start and End would have.
+func (t *Tracer) Record(ctx context.Context, name string, start time.Time, err error, attrs ...Attr) {
+	if t == nil {
+		return
+	}
+	span := t.newSpan(t.parentOf(ctx), name, attrs)
+	span.start = start
+	span.End(err)
+}
+
+func (t *Tracer) parentOf(ctx context.Context) spanContext {
+	if ctx != nil {
+		if span := SpanFromContext(ctx); span != nil {
+			return span.sc
+		}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.root != nil {
+		return t.root.sc
+	}
+	return t.remote
+}
+
+func (t *Tracer) newSpan(parent spanContext, name string, attrs []Attr) *Span {
+	span := &Span{t: t, name: name, start: time.Now(), attrs: attrs}
+	if parent.valid() {
+		span.sc.traceID = parent.traceID
+		span.parent = parent.spanID
+	} else {
+		_, _ = rand.Read(span.sc.traceID[:])
+	}
+	_, _ = rand.Read(span.sc.spanID[:])
+	return span
+}
+
+// SetAttributes adds attributes to the span.
+func (s *Span) SetAttributes(attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// End finishes the span, marking it failed when err is not nil, and queues
+// it for export. Ending a span twice has no effect.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if !s.end.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	s.end, s.err = time.Now(), err
+	s.mu.Unlock()
+	s.t.enqueue(s)
+}
+
+// TraceParent returns the W3C traceparent of the span, for passing it to
+// other programs; empty for a nil span.
+func (s *Span) TraceParent() string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-01", hex.EncodeToString(s.sc.traceID[:]), hex.EncodeToString(s.sc.spanID[:]))
+}
+
+func (t *Tracer) enqueue(s *Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.queue) >= maxQueued {
+		return
+	}
+	t.queue = append(t.queue, s)
+}
+
+// loop exports the queued spans every flushInterval until Close.
+func (t *Tracer) loop() {
+	defer close(t.stopped)
+	tick := time.NewTicker(flushInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-tick.C:
+			ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+			t.Flush(ctx)
+			cancel()
+		}
+	}
+}
+
+// Flush exports the queued spans now. Spans that fail to export are
+// dropped, and the failure is reported unless the previous export failed
+// too.
+func (t *Tracer) Flush(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	spans := t.queue
+	t.queue = nil
+	t.mu.Unlock()
+	if len(spans) == 0 {
+		return
+	}
+	err := t.export(ctx, spans)
+	t.mu.Lock()
+	report := err != nil && !t.failed && t.onError != nil
+	t.failed = err != nil
+	t.mu.Unlock()
+	if report {
+		t.onError(fmt.Errorf("telemetry export to %s: %w", t.endpoint, err))
+	}
+}
+
+// Close stops the background export and exports the spans still queued,
+// waiting at most timeout.
+func (t *Tracer) Close(timeout time.Duration) {
+	if t == nil {
+		return
+	}
+	t.stopOnce.Do(func() {
+		close(t.stop)
+		<-t.stopped
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		t.Flush(ctx)
+	})
+}
@@ -0,0 +1,177 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/devports/devpt/pkg/models"
+)
+
+// collector is a fake OTLP/HTTP endpoint recording what it receives.
+type collector struct {
+	mu       sync.Mutex
+	requests []otlpRequest
+	headers  []http.Header
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req otlpRequest
+	if r.URL.Path != "/v1/traces" || json.NewDecoder(r.Body).Decode(&req) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	c.requests = append(c.requests, req)
+	c.headers = append(c.headers, r.Header)
+	c.mu.Unlock()
+}
+
+func (c *collector) spans() []otlpSpan {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var spans []otlpSpan
+	for _, req := range c.requests {
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}
+	return spans
+}
+
+func env(vars map[string]string) func(string) string {
+	return func(k string) string { return vars[k] }
+}
+
+func TestNewIsOffWithoutEndpoint(t *testing.T) {
+	t.Parallel()
+	tr, err := New(models.TelemetrySettings{}, env(nil), nil)
+	if tr != nil || err != nil {
+		t.Fatalf("New = %v, %v; want no tracer", tr, err)
+	}
+	// A nil tracer and its spans do nothing.
+	_, span := tr.Start(context.Background(), "scan")
+	span.SetAttributes(String("k", "v"))
+	span.End(nil)
+	if span.TraceParent() != "" {
+		t.Error("nil span has a traceparent")
+	}
+	tr.Close(time.Second)
+
+	tr, _ = New(models.TelemetrySettings{Endpoint: "http://localhost:4318"}, env(map[string]string{EnvSDKDisabled: "true"}), nil)
+	if tr != nil {
+		t.Error("OTEL_SDK_DISABLED did not turn export off")
+	}
+	if _, err := New(models.TelemetrySettings{}, env(map[string]string{EnvEndpoint: "localhost:4318"}), nil); err == nil {
+		t.Error("endpoint without a scheme accepted")
+	}
+}
+
+func TestExportSpans(t *testing.T) {
+	t.Parallel()
+	col := &collector{}
+	srv := httptest.NewServer(col)
+	defer srv.Close()
+
+	remote := "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+	tr, err := New(models.TelemetrySettings{Endpoint: "http://unused", Headers: map[string]string{"X-Team": "web"}}, env(map[string]string{
+		EnvEndpoint:    srv.URL + "/",
+		EnvHeaders:     "Authorization=Bearer%20abc",
+		EnvServiceName: "dev-loop",
+		EnvTraceParent: remote,
+	}), func(err error) { t.Error(err) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := tr.Root("devpt start", String("devpt.args", "api"))
+	ctx, start := tr.Start(context.Background(), "devpt.start", String("devpt.service", "api"))
+	_, pre := tr.Start(ctx, "devpt.prerequisites")
+	pre.End(errors.New("job failed"))
+	tr.Record(ctx, "devpt.health_check", time.Now().Add(-time.Second), nil, Int("devpt.port", 8080))
+	start.End(nil)
+	root.End(nil)
+	tr.Close(time.Second)
+
+	spans := col.spans()
+	if len(spans) != 4 {
+		t.Fatalf("exported %d spans, want 4", len(spans))
+	}
+	byName := make(map[string]otlpSpan)
+	for _, s := range spans {
+		if s.TraceID != "0af7651916cd43dd8448eb211c80319c" {
+			t.Errorf("%s is not in the TRACEPARENT trace: %s", s.Name, s.TraceID)
+		}
+		byName[s.Name] = s
+	}
+	if byName["devpt start"].ParentSpanID != "b7ad6b7169203331" {
+		t.Errorf("root parent = %q", byName["devpt start"].ParentSpanID)
+	}
+	if byName["devpt.start"].ParentSpanID != byName["devpt start"].SpanID {
+		t.Error("operation is not a child of the command span")
+	}
+	for _, child := range []string{"devpt.prerequisites", "devpt.health_check"} {
+		if byName[child].ParentSpanID != byName["devpt.start"].SpanID {
+			t.Errorf("%s is not a child of the span in its context", child)
+		}
+	}
+	if st := byName["devpt.prerequisites"].Status; st.Code != statusError || st.Message != "job failed" {
+		t.Errorf("failed span status = %+v", st)
+	}
+	if kv := byName["devpt.health_check"].Attributes; len(kv) != 1 || kv[0].Value["intValue"] != "8080" {
+		t.Errorf("int attribute = %+v", kv)
+	}
+
+	req := col.requests[0]
+	if attrs := req.ResourceSpans[0].Resource.Attributes; attrs[0].Value["stringValue"] != "dev-loop" {
+		t.Errorf("service.name = %+v", attrs)
+	}
+	if h := col.headers[0]; h.Get("Authorization") != "Bearer abc" || h.Get("X-Team") != "web" || h.Get("Content-Type") != "application/json" {
+		t.Errorf("headers = %v", h)
+	}
+}
+
+func TestExportFailureReportedOnce(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	var reported []error
+	tr, err := New(models.TelemetrySettings{Endpoint: srv.URL}, env(nil), func(err error) { reported = append(reported, err) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tr.Close(time.Second)
+	for i := 0; i < 2; i++ {
+		_, span := tr.Start(context.Background(), "devpt.scan")
+		span.End(nil)
+		tr.Flush(context.Background())
+	}
+	if len(reported) != 1 {
+		t.Errorf("reported %d failures, want 1: %v", len(reported), reported)
+	}
+}
+
+func TestTraceParent(t *testing.T) {
+	t.Parallel()
+	sc, ok := parseTraceParent("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	if !ok {
+		t.Fatal("valid traceparent rejected")
+	}
+	span := &Span{sc: sc}
+	if got := span.TraceParent(); got != "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01" {
+		t.Errorf("TraceParent = %q", got)
+	}
+	for _, bad := range []string{"", "00-abc-def-01", "00-00000000000000000000000000000000-b7ad6b7169203331-01", "ff-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"} {
+		if _, ok := parseTraceParent(bad); ok {
+			t.Errorf("accepted %q", bad)
+		}
+	}
+}
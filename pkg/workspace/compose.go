@@ -0,0 +1,77 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// composeFiles are the names docker compose looks for, in its order, so a
+// plain docker compose up reads the same file.
+var composeFiles = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+// discoverCompose proposes a service for each service of the compose file
+// at root, run in the foreground with docker compose up. Compose stops its
+// containers on SIGINT, so that is their stop signal.
+func discoverCompose(root string) []Service {
+	for _, file := range composeFiles {
+		data, err := os.ReadFile(filepath.Join(root, file))
+		if err != nil {
+			continue
+		}
+		doc, _ := parseYAML(data).(map[string]any)
+		services, _ := doc["services"].(map[string]any)
+		names := make([]string, 0, len(services))
+		for name := range services {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var found []Service
+		for _, name := range names {
+			def, _ := services[name].(map[string]any)
+			found = append(found, Service{
+				Name:       serviceName(name),
+				Dir:        root,
+				Command:    "docker compose up " + name,
+				Port:       publishedPort(def["ports"]),
+				StopSignal: "SIGINT",
+				Source:     file,
+			})
+		}
+		return found
+	}
+	return nil
+}
+
+// publishedPort returns the first host port a compose service publishes,
+// from the short syntax ("8080:80", "127.0.0.1:8080:80/tcp",
+// "9090-9091:80-81") or the long one (published: 8080); 0 when none is
+// fixed.
+func publishedPort(ports any) int {
+	items, _ := ports.([]any)
+	for _, item := range items {
+		switch p := item.(type) {
+		case string:
+			spec, _, _ := strings.Cut(p, "/")
+			parts := strings.Split(spec, ":")
+			if len(parts) < 2 {
+				// Only a container port: the host port is picked by docker.
+				continue
+			}
+			host, _, _ := strings.Cut(parts[len(parts)-2], "-")
+			if port, err := strconv.Atoi(host); err == nil && port > 0 {
+				return port
+			}
+		case map[string]any:
+			published, _ := p["published"].(string)
+			host, _, _ := strings.Cut(published, "-")
+			if port, err := strconv.Atoi(host); err == nil && port > 0 {
+				return port
+			}
+		}
+	}
+	return 0
+}
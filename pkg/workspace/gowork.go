@@ -0,0 +1,113 @@
+package workspace
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// discoverGoWork proposes a service for each main package of the modules
+// go.work uses: the module directory itself, or each directory under its
+// cmd/. Go servers have no conventional port, so they get an automatic one
+// through $PORT.
+func discoverGoWork(root string) []Service {
+	path := filepath.Join(root, "go.work")
+	mods, err := goWorkUses(path)
+	if err != nil {
+		return nil
+	}
+	var found []Service
+	for _, mod := range mods {
+		dir := filepath.Join(root, filepath.FromSlash(mod))
+		if isMainPackage(dir) {
+			found = append(found, Service{
+				Name:     serviceName(filepath.Base(dir)),
+				Dir:      dir,
+				Command:  "go run .",
+				AutoPort: true,
+				Source:   "go.work",
+			})
+			continue
+		}
+		cmds, _ := os.ReadDir(filepath.Join(dir, "cmd"))
+		for _, c := range cmds {
+			if c.IsDir() && isMainPackage(filepath.Join(dir, "cmd", c.Name())) {
+				found = append(found, Service{
+					Name:     serviceName(c.Name()),
+					Dir:      dir,
+					Command:  "go run ./cmd/" + c.Name(),
+					AutoPort: true,
+					Source:   "go.work",
+				})
+			}
+		}
+	}
+	return found
+}
+
+// goWorkUses returns the module directories of the use directives of a
+// go.work file, in either the single-line or the block form.
+func goWorkUses(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var mods []string
+	inBlock := false
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case inBlock && line == ")":
+			inBlock = false
+		case inBlock && line != "":
+			mods = append(mods, strings.Trim(line, `"`))
+		case line == "use (":
+			inBlock = true
+		case strings.HasPrefix(line, "use "):
+			mods = append(mods, strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "use ")), `"`))
+		}
+	}
+	return mods, sc.Err()
+}
+
+// isMainPackage reports whether dir holds a non-test Go file of package
+// main.
+func isMainPackage(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		if goPackage(filepath.Join(dir, name)) == "main" {
+			return true
+		}
+	}
+	return false
+}
+
+// goPackage returns the package clause of a Go file.
+func goPackage(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if fields := strings.Fields(sc.Text()); len(fields) > 1 && fields[0] == "package" {
+			return fields[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,263 @@
+package workspace
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// packageJSON is the part of a package.json discovery reads.
+type packageJSON struct {
+	Name           string            `json:"name"`
+	Scripts        map[string]string `json:"scripts"`
+	Workspaces     json.RawMessage   `json:"workspaces"`
+	PackageManager string            `json:"packageManager"`
+}
+
+// devScripts are the scripts that run a development server, most preferred
+// first. Persistent turbo.json tasks come before them.
+var devScripts = []string{"dev", "start", "serve"}
+
+// buildTools run scripts that build or type-check rather than serve, such
+// as the "dev" watcher of a library package, or that only run the scripts
+// of other packages.
+var buildTools = map[string]bool{
+	"tsc": true, "tsup": true, "rollup": true, "babel": true, "unbuild": true,
+	"turbo": true, "nx": true, "lerna": true,
+}
+
+// defaultPorts are the ports dev servers listen on unless told otherwise,
+// by the executable a script runs.
+var defaultPorts = map[string]int{
+	"next": 3000, "nuxt": 3000, "nuxi": 3000, "react-scripts": 3000, "remix": 3000,
+	"vite": 5173, "astro": 4321, "ng": 4200, "gatsby": 8000, "storybook": 6006,
+}
+
+// scriptPortFlag finds a port given on a script's command line.
+var scriptPortFlag = regexp.MustCompile(`(?:--port[= ]|-p[= ]?|\bPORT=)(\d{2,5})\b`)
+
+// discoverNode proposes a service for each workspace package of the
+// package.json at root with a dev server script, or for the root package
+// when it declares no workspaces.
+func discoverNode(root string) []Service {
+	pkg, ok := readPackageJSON(filepath.Join(root, "package.json"))
+	if !ok {
+		return nil
+	}
+	pm := packageManager(root, pkg)
+	scripts := append(persistentTasks(root), devScripts...)
+	dirs := workspaceDirs(root, workspacePatterns(root, pkg))
+	if len(dirs) == 0 {
+		dirs = []string{root}
+	}
+
+	var found []Service
+	for _, dir := range dirs {
+		path := filepath.Join(dir, "package.json")
+		p, ok := readPackageJSON(path)
+		if !ok {
+			continue
+		}
+		script := pickScript(p.Scripts, scripts)
+		if script == "" {
+			continue
+		}
+		name := p.Name
+		if name == "" {
+			name = filepath.Base(dir)
+		}
+		svc := Service{
+			Name:    serviceName(name),
+			Dir:     dir,
+			Command: pm + " run " + script,
+			Port:    scriptPort(p.Scripts[script]),
+			Source:  relSource(root, path),
+		}
+		svc.AutoPort = svc.Port == 0
+		found = append(found, svc)
+	}
+	return found
+}
+
+func readPackageJSON(path string) (*packageJSON, bool) {
+	var pkg packageJSON
+	if !readJSON(path, &pkg) {
+		return nil, false
+	}
+	return &pkg, true
+}
+
+// readJSON decodes the JSON file at path into v, allowing the whole-line
+// // comments turbo.json and nx.json files may have.
+func readJSON(path string, v any) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "//") {
+			lines[i] = ""
+		}
+	}
+	return json.Unmarshal([]byte(strings.Join(lines, "\n")), v) == nil
+}
+
+// packageManager names the package manager of the workspace, from the
+// packageManager field or the lockfile, npm by default.
+func packageManager(root string, pkg *packageJSON) string {
+	if name, _, _ := strings.Cut(pkg.PackageManager, "@"); name != "" {
+		return name
+	}
+	for _, lock := range []struct{ file, pm string }{
+		{"pnpm-lock.yaml", "pnpm"},
+		{"yarn.lock", "yarn"},
+		{"bun.lockb", "bun"},
+		{"bun.lock", "bun"},
+	} {
+		if _, err := os.Stat(filepath.Join(root, lock.file)); err == nil {
+			return lock.pm
+		}
+	}
+	return "npm"
+}
+
+// workspacePatterns returns the workspace globs of package.json, a list or
+// {"packages": [...]}, and of pnpm-workspace.yaml.
+func workspacePatterns(root string, pkg *packageJSON) []string {
+	var patterns []string
+	if len(pkg.Workspaces) > 0 {
+		var list []string
+		var obj struct {
+			Packages []string `json:"packages"`
+		}
+		if json.Unmarshal(pkg.Workspaces, &list) == nil {
+			patterns = append(patterns, list...)
+		} else if json.Unmarshal(pkg.Workspaces, &obj) == nil {
+			patterns = append(patterns, obj.Packages...)
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "pnpm-workspace.yaml")); err == nil {
+		if doc, ok := parseYAML(data).(map[string]any); ok {
+			patterns = append(patterns, stringList(doc["packages"])...)
+		}
+	}
+	return patterns
+}
+
+// workspaceDirs expands workspace globs into the package directories they
+// match, in order. Patterns starting with ! exclude directories; a
+// trailing /** matches one level, as it does for most layouts.
+func workspaceDirs(root string, patterns []string) []string {
+	excluded := make(map[string]bool)
+	var include []string
+	for _, p := range patterns {
+		p = strings.TrimSuffix(strings.TrimSuffix(strings.TrimSpace(p), "/"), "/**")
+		if p == "" {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(p, "!"); ok {
+			matches, _ := filepath.Glob(filepath.Join(root, filepath.FromSlash(rest)))
+			for _, m := range matches {
+				excluded[m] = true
+			}
+			continue
+		}
+		include = append(include, p)
+	}
+	var dirs []string
+	seen := make(map[string]bool)
+	for _, p := range include {
+		matches, _ := filepath.Glob(filepath.Join(root, filepath.FromSlash(p)))
+		for _, m := range matches {
+			if seen[m] || excluded[m] {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(m, "package.json")); err != nil {
+				continue
+			}
+			seen[m] = true
+			dirs = append(dirs, m)
+		}
+	}
+	return dirs
+}
+
+// persistentTasks returns the tasks turbo.json marks persistent, the
+// long-running ones such as "dev", in name order.
+func persistentTasks(root string) []string {
+	type task struct {
+		Persistent bool `json:"persistent"`
+	}
+	var turbo struct {
+		Tasks    map[string]task `json:"tasks"`
+		Pipeline map[string]task `json:"pipeline"` // before turbo 2
+	}
+	if !readJSON(filepath.Join(root, "turbo.json"), &turbo) {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var names []string
+	for _, tasks := range []map[string]task{turbo.Tasks, turbo.Pipeline} {
+		for name, t := range tasks {
+			// "web#dev" configures the dev task of the web package.
+			if i := strings.LastIndex(name, "#"); i >= 0 {
+				name = name[i+1:]
+			}
+			if t.Persistent && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// pickScript returns the first of preferred that scripts defines and that
+// does not run a build tool.
+func pickScript(scripts map[string]string, preferred []string) string {
+	for _, name := range preferred {
+		cmd, ok := scripts[name]
+		if !ok {
+			continue
+		}
+		if fields := strings.Fields(cmd); len(fields) > 0 && buildTools[fields[0]] {
+			continue
+		}
+		return name
+	}
+	return ""
+}
+
+// scriptPort returns the port a script's dev server listens on: one given
+// on its command line, or the default of the tool it runs; 0 when unknown.
+func scriptPort(script string) int {
+	if m := scriptPortFlag.FindStringSubmatch(script); m != nil {
+		if port, err := strconv.Atoi(m[1]); err == nil && port > 0 && port <= 65535 {
+			return port
+		}
+	}
+	for _, field := range strings.Fields(script) {
+		if port, ok := defaultPorts[field]; ok {
+			return port
+		}
+	}
+	return 0
+}
+
+// stringList returns the strings of a parsed YAML sequence.
+func stringList(v any) []string {
+	items, _ := v.([]any)
+	var out []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
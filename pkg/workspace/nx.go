@@ -0,0 +1,103 @@
+package workspace
+
+import (
+	"io/fs"
+	"path/filepath"
+)
+
+// nxServeTargets are the project targets that run a server, most preferred
+// first.
+var nxServeTargets = []string{"serve", "dev", "start"}
+
+// skippedDirs are not searched for project files.
+var skippedDirs = map[string]bool{
+	"node_modules": true, ".git": true, "dist": true, "build": true, "vendor": true,
+	".next": true, ".nx": true, "tmp": true, "coverage": true,
+}
+
+// maxProjectDepth bounds how deep below the root project.json files are
+// looked for.
+const maxProjectDepth = 4
+
+// nxProject is the part of a project.json discovery reads.
+type nxProject struct {
+	Name    string `json:"name"`
+	Targets map[string]struct {
+		Options struct {
+			Port int `json:"port"`
+		} `json:"options"`
+	} `json:"targets"`
+}
+
+// discoverNx proposes a service for each project.json of an Nx workspace
+// with a serve target, run from the project directory, where nx still
+// finds the workspace.
+func discoverNx(root string) []Service {
+	var nx struct{}
+	if !readJSON(filepath.Join(root, "nx.json"), &nx) {
+		return nil
+	}
+	runner := "npx"
+	if pkg, ok := readPackageJSON(filepath.Join(root, "package.json")); ok {
+		if pm := packageManager(root, pkg); pm != "npm" {
+			runner = pm
+		}
+	}
+
+	var found []Service
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			rel, _ := filepath.Rel(root, path)
+			if path != root && (skippedDirs[d.Name()] || depth(rel) > maxProjectDepth) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() != "project.json" {
+			return nil
+		}
+		var p nxProject
+		if !readJSON(path, &p) {
+			return nil
+		}
+		dir := filepath.Dir(path)
+		name := p.Name
+		if name == "" {
+			name = filepath.Base(dir)
+		}
+		for _, target := range nxServeTargets {
+			t, ok := p.Targets[target]
+			if !ok {
+				continue
+			}
+			found = append(found, Service{
+				Name:     serviceName(name),
+				Dir:      dir,
+				Command:  runner + " nx run " + name + ":" + target,
+				Port:     t.Options.Port,
+				AutoPort: t.Options.Port == 0,
+				Source:   relSource(root, path),
+			})
+			break
+		}
+		return nil
+	})
+	return found
+}
+
+// depth is the number of directories in a relative path.
+func depth(rel string) int {
+	if rel == "." {
+		return 0
+	}
+	n := 1
+	for _, c := range filepath.ToSlash(rel) {
+		if c == '/' {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,95 @@
+// Package workspace finds the services of a repository from the files that
+// describe it: package.json workspaces, turbo.json, nx.json, go.work and
+// compose files.
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Service is a long-running process proposed for a project of a workspace.
+type Service struct {
+	Name    string
+	Dir     string // absolute working directory
+	Command string
+	// Port is the port the service is known to listen on, 0 when unknown.
+	// AutoPort asks for a port allocated on every start instead.
+	Port     int
+	AutoPort bool
+	// StopSignal, when set, stops the service more gracefully than SIGTERM.
+	StopSignal string
+	// Source is the file the service was found through, relative to the
+	// root, e.g. "apps/web/package.json".
+	Source string
+}
+
+// Root returns the repository dir belongs to: the nearest directory at or
+// above it with a .git entry, or dir itself outside a repository.
+func Root(dir string) string {
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
+
+// Discover proposes services for the repository at root, an absolute path.
+// Names are unique; a directory already proposed by an earlier source is
+// not proposed again by Nx. Unreadable or malformed files are skipped.
+func Discover(root string) []Service {
+	var found []Service
+	seenDirs := make(map[string]bool)
+	for _, s := range discoverNode(root) {
+		found = append(found, s)
+		seenDirs[s.Dir] = true
+	}
+	for _, s := range discoverNx(root) {
+		if !seenDirs[s.Dir] {
+			found = append(found, s)
+		}
+	}
+	found = append(found, discoverGoWork(root)...)
+	found = append(found, discoverCompose(root)...)
+
+	names := make(map[string]int)
+	for i := range found {
+		name := found[i].Name
+		names[name]++
+		if n := names[name]; n > 1 {
+			found[i].Name = name + "-" + strconv.Itoa(n)
+		}
+	}
+	return found
+}
+
+var unsafeNameChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// serviceName turns a package, project or directory name into a service
+// name: lower case, without an npm scope, with other characters as dashes.
+func serviceName(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.Trim(unsafeNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-.")
+	if name == "" {
+		return "app"
+	}
+	return name
+}
+
+// relSource returns path relative to root, for Service.Source.
+func relSource(root, path string) string {
+	if rel, err := filepath.Rel(root, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
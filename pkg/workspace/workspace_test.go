@@ -0,0 +1,109 @@
+package workspace
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/devports/devpt/internal/testutil"
+)
+
+func TestDiscoverMonorepo(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	testutil.WriteFiles(t, root, map[string]string{
+		".git/HEAD":      "ref: refs/heads/main\n",
+		"pnpm-lock.yaml": "",
+		"package.json":   `{"name": "acme", "private": true, "scripts": {"dev": "turbo run dev"}}`,
+		"pnpm-workspace.yaml": `packages:
+  - "apps/*"
+  - "packages/*"
+  - "!apps/legacy"
+`,
+		"turbo.json":                   `{"tasks": {"build": {}, "dev": {"persistent": true, "cache": false}}}`,
+		"apps/web/package.json":        `{"name": "@acme/web", "scripts": {"dev": "next dev", "build": "next build"}}`,
+		"apps/docs/package.json":       `{"name": "@acme/docs", "scripts": {"dev": "vite --port 3001"}}`,
+		"apps/api/package.json":        `{"name": "@acme/api", "scripts": {"start": "node server.js"}}`,
+		"apps/legacy/package.json":     `{"name": "legacy", "scripts": {"dev": "node old.js"}}`,
+		"packages/ui/package.json":     `{"name": "@acme/ui", "scripts": {"dev": "tsup --watch"}}`,
+		"go.work":                      "go 1.22\n\nuse (\n\t./services/auth // auth\n\t./services/tools\n)\n",
+		"services/auth/main.go":        "package main\n",
+		"services/tools/lib.go":        "package tools\n",
+		"services/tools/cmd/seed/m.go": "package main\n",
+		"docker-compose.yml": `services:
+  db:
+    image: postgres:16
+    ports:
+      - "5432:5432"
+  web:
+    image: nginx
+`,
+	})
+	got := Discover(Root(filepath.Join(root, "apps", "web")))
+
+	want := []Service{
+		{Name: "api", Dir: filepath.Join(root, "apps/api"), Command: "pnpm run start", AutoPort: true, Source: "apps/api/package.json"},
+		{Name: "docs", Dir: filepath.Join(root, "apps/docs"), Command: "pnpm run dev", Port: 3001, Source: "apps/docs/package.json"},
+		{Name: "web", Dir: filepath.Join(root, "apps/web"), Command: "pnpm run dev", Port: 3000, Source: "apps/web/package.json"},
+		{Name: "auth", Dir: filepath.Join(root, "services/auth"), Command: "go run .", AutoPort: true, Source: "go.work"},
+		{Name: "seed", Dir: filepath.Join(root, "services/tools"), Command: "go run ./cmd/seed", AutoPort: true, Source: "go.work"},
+		{Name: "db", Dir: root, Command: "docker compose up db", Port: 5432, StopSignal: "SIGINT", Source: "docker-compose.yml"},
+		{Name: "web-2", Dir: root, Command: "docker compose up web", StopSignal: "SIGINT", Source: "docker-compose.yml"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Discover =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestDiscoverNxAndSinglePackage(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	testutil.WriteFiles(t, root, map[string]string{
+		"package.json":                `{"name": "shop", "scripts": {"start": "react-scripts start"}}`,
+		"yarn.lock":                   "",
+		"nx.json":                     "// nx\n{\"npmScope\": \"shop\"}",
+		"apps/admin/project.json":     `{"name": "admin", "targets": {"build": {}, "serve": {"options": {"port": 4300}}}}`,
+		"libs/ui/project.json":        `{"name": "ui", "targets": {"build": {}}}`,
+		"node_modules/x/project.json": `{"name": "x", "targets": {"serve": {}}}`,
+	})
+	got := Discover(root)
+	want := []Service{
+		{Name: "shop", Dir: root, Command: "yarn run start", Port: 3000, Source: "package.json"},
+		{Name: "admin", Dir: filepath.Join(root, "apps/admin"), Command: "yarn nx run admin:serve", Port: 4300, Source: "apps/admin/project.json"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Discover =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestPublishedPort(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		ports any
+		want  int
+	}{
+		{[]any{"8080:80"}, 8080},
+		{[]any{"127.0.0.1:8081:80/tcp"}, 8081},
+		{[]any{"9090-9091:80-81"}, 9090},
+		{[]any{"3000", "4000:4000"}, 4000},
+		{[]any{map[string]any{"target": "80", "published": "8082"}}, 8082},
+		{nil, 0},
+	} {
+		if got := publishedPort(tc.ports); got != tc.want {
+			t.Errorf("publishedPort(%v) = %d, want %d", tc.ports, got, tc.want)
+		}
+	}
+}
+
+func TestServiceName(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]string{"@acme/Web App": "web-app", "api": "api", "@@": "app"} {
+		if got := serviceName(in); got != want {
+			t.Errorf("serviceName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package workspace
+
+import (
+	"strings"
+)
+
+// parseYAML reads the block-style subset of YAML that compose files and
+// pnpm-workspace.yaml use: nested mappings and sequences of scalars or
+// mappings, plus flow sequences of scalars. Mappings become map[string]any,
+// sequences []any and scalars strings. Block scalars (| and >), anchors and
+// flow mappings are kept as their raw text.
+func parseYAML(data []byte) any {
+	var lines []yamlLine
+	for _, raw := range strings.Split(string(data), "\n") {
+		text := stripYAMLComment(strings.TrimRight(raw, " \t\r"))
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || trimmed == "---" || strings.HasPrefix(trimmed, "%") {
+			continue
+		}
+		lines = append(lines, yamlLine{indent: len(text) - len(trimmed), text: trimmed})
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	p := &yamlParser{lines: lines}
+	return p.node(lines[0].indent)
+}
+
+// yamlLine is a line with content, without its indentation and comment.
+type yamlLine struct {
+	indent int
+	text   string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// node reads the mapping or sequence whose entries start at indent.
+func (p *yamlParser) node(indent int) any {
+	if p.pos >= len(p.lines) {
+		return nil
+	}
+	if isSeqItem(p.lines[p.pos].text) {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+func (p *yamlParser) sequence(indent int) []any {
+	var items []any
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent != indent || !isSeqItem(line.text) {
+			break
+		}
+		rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		p.pos++
+		switch {
+		case rest == "":
+			items = append(items, p.child(indent))
+		case isMappingEntry(rest):
+			// "- key: value" starts a mapping whose keys line up after "- ".
+			inner := indent + len(line.text) - len(rest)
+			p.pos--
+			p.lines[p.pos] = yamlLine{indent: inner, text: rest}
+			items = append(items, p.mapping(inner))
+		default:
+			items = append(items, scalar(rest))
+		}
+	}
+	return items
+}
+
+func (p *yamlParser) mapping(indent int) map[string]any {
+	m := make(map[string]any)
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent > indent {
+			// A line that fits nowhere is skipped so a malformed entry does
+			// not end the document.
+			p.pos++
+			continue
+		}
+		if line.indent < indent || !isMappingEntry(line.text) {
+			break
+		}
+		key, value := splitMappingEntry(line.text)
+		p.pos++
+		switch {
+		case value == "":
+			m[key] = p.child(indent)
+		case value == "|" || value == ">" || strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">"):
+			p.skipDeeper(indent)
+			m[key] = value
+		default:
+			m[key] = scalar(value)
+		}
+	}
+	return m
+}
+
+// child reads the value of a key or sequence item given on the following
+// lines: deeper ones, or a sequence at the same indent as the key.
+func (p *yamlParser) child(indent int) any {
+	if p.pos >= len(p.lines) {
+		return nil
+	}
+	next := p.lines[p.pos]
+	if next.indent > indent || (next.indent == indent && isSeqItem(next.text)) {
+		return p.node(next.indent)
+	}
+	return nil
+}
+
+func (p *yamlParser) skipDeeper(indent int) {
+	for p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+		p.pos++
+	}
+}
+
+func isSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func isMappingEntry(text string) bool {
+	key, _, ok := cutMappingKey(text)
+	return ok && key != ""
+}
+
+func splitMappingEntry(text string) (key, value string) {
+	key, value, _ = cutMappingKey(text)
+	return unquote(key), strings.TrimSpace(value)
+}
+
+// cutMappingKey splits "key: value" at the first ": " (or a trailing ":")
+// outside quotes.
+func cutMappingKey(text string) (key, value string, ok bool) {
+	if strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{") {
+		return "", "", false
+	}
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ':' && (i == len(text)-1 || text[i+1] == ' '):
+			return strings.TrimSpace(text[:i]), text[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+// scalar returns a plain or quoted scalar, or the items of a flow
+// sequence such as ["3000:3000", "9229"].
+func scalar(value string) any {
+	if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+		var items []any
+		for _, item := range strings.Split(value[1:len(value)-1], ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, unquote(item))
+			}
+		}
+		return items
+	}
+	return unquote(value)
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// stripYAMLComment drops a " #" comment outside quotes.
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" \t:-[,", line[i-1]) >= 0):
+			// Only a quote that opens a scalar counts, not one inside it.
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return strings.TrimRight(line[:i], " \t")
+		}
+	}
+	return line
+}
@@ -0,0 +1,62 @@
+package workspace
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	t.Parallel()
+
+	doc := parseYAML([]byte(`# compose file
+services:
+  web:
+    build: .
+    command: |
+      npm run dev
+      --port 3000
+    ports:
+      - "3000:3000" # app
+      - 9229
+  db:
+    image: postgres:16
+    ports: ["127.0.0.1:5432:5432"]
+  proxy:
+    ports:
+    - target: 80
+      published: "8080"
+      protocol: tcp
+volumes:
+  data:
+`))
+	want := map[string]any{
+		"services": map[string]any{
+			"web": map[string]any{
+				"build":   ".",
+				"command": "|",
+				"ports":   []any{"3000:3000", "9229"},
+			},
+			"db": map[string]any{
+				"image": "postgres:16",
+				"ports": []any{"127.0.0.1:5432:5432"},
+			},
+			"proxy": map[string]any{
+				"ports": []any{map[string]any{"target": "80", "published": "8080", "protocol": "tcp"}},
+			},
+		},
+		"volumes": map[string]any{"data": nil},
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Fatalf("parseYAML =\n%#v\nwant\n%#v", doc, want)
+	}
+}
+
+func TestParseYAMLKeepsHashesInsideScalars(t *testing.T) {
+	t.Parallel()
+
+	doc := parseYAML([]byte("packages:\n  - 'apps/*'\n  - \"it's # here\"\n  - color#1 # comment\n"))
+	want := map[string]any{"packages": []any{"apps/*", "it's # here", "color#1"}}
+	if !reflect.DeepEqual(doc, want) {
+		t.Fatalf("parseYAML = %#v, want %#v", doc, want)
+	}
+}